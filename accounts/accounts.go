@@ -40,6 +40,8 @@ const (
 	MimetypeTypedData         = "data/typed"
 	MimetypeClique            = "application/x-clique-header"
 	MimetypeCongress          = "application/x-congress-header"
+	MimetypePreconfirm        = "application/x-heco-preconfirm"
+	MimetypeAttestation       = "application/x-heco-attestation"
 	MimetypeTextPlain         = "text/plain"
 )
 
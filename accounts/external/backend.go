@@ -17,9 +17,12 @@
 package external
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts"
@@ -64,11 +67,18 @@ type ExternalSigner struct {
 	client   *rpc.Client
 	endpoint string
 	status   string
+	timeout  time.Duration // Timeout of the requests to the signer, 0 for none
 	cacheMu  sync.RWMutex
 	cache    []accounts.Account
 }
 
 func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
+	return NewExternalSignerWithTimeout(endpoint, 0)
+}
+
+// NewExternalSignerWithTimeout creates an external signer whose requests time
+// out after the given duration, 0 means no timeout.
+func NewExternalSignerWithTimeout(endpoint string, timeout time.Duration) (*ExternalSigner, error) {
 	client, err := rpc.Dial(endpoint)
 	if err != nil {
 		return nil, err
@@ -76,6 +86,7 @@ func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
 	extsigner := &ExternalSigner{
 		client:   client,
 		endpoint: endpoint,
+		timeout:  timeout,
 	}
 	// Check if reachable
 	version, err := extsigner.pingVersion()
@@ -160,14 +171,14 @@ func (api *ExternalSigner) signHash(account accounts.Account, hash []byte) ([]by
 func (api *ExternalSigner) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
 	var res hexutil.Bytes
 	var signAddress = common.NewMixedcaseAddress(account.Address)
-	if err := api.client.Call(&res, "account_signData",
+	if err := api.call(&res, "account_signData",
 		mimeType,
 		&signAddress, // Need to use the pointer here, because of how MarshalJSON is defined
 		hexutil.Encode(data)); err != nil {
 		return nil, err
 	}
 	// If V is on 27/28-form, convert to 0/1 for Clique/Congress
-	if (mimeType == accounts.MimetypeClique || mimeType == accounts.MimetypeCongress) && (res[64] == 27 || res[64] == 28) {
+	if (mimeType == accounts.MimetypeClique || mimeType == accounts.MimetypeCongress || mimeType == accounts.MimetypePreconfirm || mimeType == accounts.MimetypeAttestation) && (res[64] == 27 || res[64] == 28) {
 		res[64] -= 27 // Transform V from 27/28 to 0/1 for Clique/Congress use
 	}
 	return res, nil
@@ -176,7 +187,7 @@ func (api *ExternalSigner) SignData(account accounts.Account, mimeType string, d
 func (api *ExternalSigner) SignText(account accounts.Account, text []byte) ([]byte, error) {
 	var signature hexutil.Bytes
 	var signAddress = common.NewMixedcaseAddress(account.Address)
-	if err := api.client.Call(&signature, "account_signData",
+	if err := api.call(&signature, "account_signData",
 		accounts.MimetypeTextPlain,
 		&signAddress, // Need to use the pointer here, because of how MarshalJSON is defined
 		hexutil.Encode(text)); err != nil {
@@ -239,7 +250,7 @@ func (api *ExternalSigner) SignTx(account accounts.Account, tx *types.Transactio
 		args.AccessList = &accessList
 	}
 	var res signTransactionResult
-	if err := api.client.Call(&res, "account_signTransaction", args); err != nil {
+	if err := api.call(&res, "account_signTransaction", args); err != nil {
 		return nil, err
 	}
 	return res.Tx, nil
@@ -258,15 +269,51 @@ func (api *ExternalSigner) SignDataWithPassphrase(account accounts.Account, pass
 
 func (api *ExternalSigner) listAccounts() ([]common.Address, error) {
 	var res []common.Address
-	if err := api.client.Call(&res, "account_list"); err != nil {
+	if err := api.call(&res, "account_list"); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ImportKey imports the web3 keystore encrypted key into the signer, re-encrypting
+// it with the new passphrase. The signer needs to serve the clef_import method.
+func (api *ExternalSigner) ImportKey(keyJSON []byte, passphrase, newPassphrase string) (accounts.Account, error) {
+	var res struct {
+		Address common.Address `json:"address"` // The URL is local to the signer, skip it
+	}
+	if err := api.call(&res, "clef_import", json.RawMessage(keyJSON), passphrase, newPassphrase); err != nil {
+		return accounts.Account{}, err
+	}
+	api.cacheMu.Lock()
+	api.cache = nil
+	api.cacheMu.Unlock()
+	return accounts.Account{Address: res.Address, URL: api.URL()}, nil
+}
+
+// ExportKey retrieves the web3 keystore encrypted key of the account from the
+// signer. The signer needs to serve the clef_export method.
+func (api *ExternalSigner) ExportKey(account accounts.Account) ([]byte, error) {
+	var res json.RawMessage
+	if err := api.call(&res, "clef_export", account.Address); err != nil {
 		return nil, err
 	}
 	return res, nil
 }
 
+// call performs a request to the signer, honouring the configured timeout.
+func (api *ExternalSigner) call(result interface{}, method string, args ...interface{}) error {
+	ctx := context.Background()
+	if api.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, api.timeout)
+		defer cancel()
+	}
+	return api.client.CallContext(ctx, result, method, args...)
+}
+
 func (api *ExternalSigner) pingVersion() (string, error) {
 	var v string
-	if err := api.client.Call(&v, "account_version"); err != nil {
+	if err := api.call(&v, "account_version"); err != nil {
 		return "", err
 	}
 	return v, nil
@@ -0,0 +1,226 @@
+package external
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// ErrSignerUnavailable is returned if none of the remote signers could serve a
+// signing request within the configured retries.
+var ErrSignerUnavailable = errors.New("remote signer unavailable")
+
+var (
+	failoverRequestMeter = metrics.NewRegisteredMeter("accounts/external/failover/requests", nil)
+	failoverFailureMeter = metrics.NewRegisteredMeter("accounts/external/failover/failures", nil)
+	failoverSwitchMeter  = metrics.NewRegisteredMeter("accounts/external/failover/switches", nil)
+)
+
+// FailoverConfig is the configuration of a failover signer.
+type FailoverConfig struct {
+	Endpoints  []string      // Clef compatible signer endpoints (HTTP, WS or IPC), in order of preference
+	Timeout    time.Duration // Timeout of a single signing request
+	Retries    int           // Number of extra rounds over all the endpoints before giving up
+	RetryDelay time.Duration // Delay between the rounds
+}
+
+// DefaultFailoverConfig contains the default failover settings, tight enough for
+// a signing round to fit into a 3s block.
+var DefaultFailoverConfig = FailoverConfig{
+	Timeout:    time.Second,
+	Retries:    1,
+	RetryDelay: 100 * time.Millisecond,
+}
+
+// sanitize checks the provided user configurations and changes anything that's
+// unreasonable or unworkable.
+func (config *FailoverConfig) sanitize() FailoverConfig {
+	conf := *config
+	if conf.Timeout <= 0 {
+		log.Warn("Sanitizing invalid signer timeout", "provided", conf.Timeout, "updated", DefaultFailoverConfig.Timeout)
+		conf.Timeout = DefaultFailoverConfig.Timeout
+	}
+	if conf.Retries < 0 {
+		log.Warn("Sanitizing invalid signer retries", "provided", conf.Retries, "updated", 0)
+		conf.Retries = 0
+	}
+	if conf.RetryDelay < 0 {
+		conf.RetryDelay = 0
+	}
+	return conf
+}
+
+// FailoverSigner signs through a list of remote signers holding the same key,
+// e.g. clef instances backed by an HSM or a cloud KMS. Requests go to the last
+// healthy signer, and fail over to the others on errors or timeouts. If none of
+// them responds, ErrSignerUnavailable is returned so that the caller (e.g. the
+// block sealer) halts instead of signing with anything else.
+type FailoverSigner struct {
+	config  FailoverConfig
+	signers []*ExternalSigner // Connected signers, nil if not connected yet
+	active  int               // Index of the last healthy signer
+	lock    sync.Mutex
+}
+
+// NewFailoverSigner creates a failover signer. Unreachable endpoints are retried
+// on demand, but at least one of them needs to be reachable on startup.
+func NewFailoverSigner(config FailoverConfig) (*FailoverSigner, error) {
+	if len(config.Endpoints) == 0 {
+		return nil, errors.New("no remote signer endpoints")
+	}
+	s := &FailoverSigner{
+		config:  config.sanitize(),
+		signers: make([]*ExternalSigner, len(config.Endpoints)),
+		active:  -1,
+	}
+	for i := range s.signers {
+		if err := s.connect(i); err != nil {
+			log.Warn("Remote signer unreachable", "endpoint", config.Endpoints[i], "err", err)
+			continue
+		}
+		if s.active < 0 {
+			s.active = i
+		}
+	}
+	if s.active < 0 {
+		return nil, ErrSignerUnavailable
+	}
+	return s, nil
+}
+
+// connect dials the i-th endpoint if it's not connected yet.
+func (s *FailoverSigner) connect(i int) error {
+	if s.signers[i] != nil {
+		return nil
+	}
+	signer, err := NewExternalSignerWithTimeout(s.config.Endpoints[i], s.config.Timeout)
+	if err != nil {
+		return err
+	}
+	s.signers[i] = signer
+	return nil
+}
+
+// do runs the request against the signers, starting with the last healthy one,
+// until one of them succeeds or the retries are exhausted. The lock is released
+// between the rounds so that the other callers don't stall on the retry delay.
+func (s *FailoverSigner) do(request func(signer *ExternalSigner) error) error {
+	failoverRequestMeter.Mark(1)
+
+	var err error
+	for round := 0; round <= s.config.Retries; round++ {
+		if round > 0 && s.config.RetryDelay > 0 {
+			time.Sleep(s.config.RetryDelay)
+		}
+		if err = s.try(request); err == nil {
+			return nil
+		}
+	}
+	failoverFailureMeter.Mark(1)
+	return fmt.Errorf("%w: %v", ErrSignerUnavailable, err)
+}
+
+// try runs a single round of the request over all the signers, returning the
+// error of the last one if none of them succeeded.
+func (s *FailoverSigner) try(request func(signer *ExternalSigner) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var err error
+	for j := 0; j < len(s.signers); j++ {
+		i := (s.active + j) % len(s.signers)
+		if err = s.connect(i); err == nil {
+			if err = request(s.signers[i]); err == nil {
+				if i != s.active {
+					log.Warn("Switched remote signer", "endpoint", s.config.Endpoints[i])
+					failoverSwitchMeter.Mark(1)
+					s.active = i
+				}
+				return nil
+			}
+		}
+		log.Warn("Remote signer request failed", "endpoint", s.config.Endpoints[i], "err", err)
+	}
+	return err
+}
+
+// SignData signs keccak256(data) with the first responsive signer, it's usable
+// as the validator signing function of the consensus engines.
+func (s *FailoverSigner) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	var sig []byte
+	err := s.do(func(signer *ExternalSigner) (err error) {
+		sig, err = signer.SignData(account, mimeType, data)
+		return err
+	})
+	return sig, err
+}
+
+// SignTx signs the transaction with the first responsive signer.
+func (s *FailoverSigner) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	var signed *types.Transaction
+	err := s.do(func(signer *ExternalSigner) (err error) {
+		signed, err = signer.SignTx(account, tx, chainID)
+		return err
+	})
+	return signed, err
+}
+
+// Contains reports whether the active signer holds the account, or the error if
+// none of the signers could be queried.
+func (s *FailoverSigner) Contains(account accounts.Account) (bool, error) {
+	var found bool
+	err := s.do(func(signer *ExternalSigner) error {
+		addrs, err := signer.listAccounts()
+		if err != nil {
+			return err
+		}
+		found = false
+		for _, addr := range addrs {
+			if addr == account.Address {
+				found = true
+				break
+			}
+		}
+		return nil
+	})
+	return found, err
+}
+
+// Import imports the web3 keystore encrypted key into every signer, so that any
+// of them can take over, re-encrypting it with the new passphrase. It fails if
+// any of the signers could not import it.
+func (s *FailoverSigner) Import(keyJSON []byte, passphrase, newPassphrase string) (accounts.Account, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var account accounts.Account
+	for i := range s.signers {
+		if err := s.connect(i); err != nil {
+			return accounts.Account{}, fmt.Errorf("signer %s: %w", s.config.Endpoints[i], err)
+		}
+		imported, err := s.signers[i].ImportKey(keyJSON, passphrase, newPassphrase)
+		if err != nil {
+			return accounts.Account{}, fmt.Errorf("signer %s: %w", s.config.Endpoints[i], err)
+		}
+		account = imported
+	}
+	return account, nil
+}
+
+// Export retrieves the web3 keystore encrypted key of the account from the first
+// responsive signer.
+func (s *FailoverSigner) Export(account accounts.Account) ([]byte, error) {
+	var keyJSON []byte
+	err := s.do(func(signer *ExternalSigner) (err error) {
+		keyJSON, err = signer.ExportKey(account)
+		return err
+	})
+	return keyJSON, err
+}
@@ -0,0 +1,145 @@
+package external
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testSigner is a minimal clef compatible signer returning a fixed signature.
+type testSigner struct {
+	addr common.Address
+}
+
+func (s *testSigner) Version() string { return "6.0.0" }
+
+func (s *testSigner) List() []common.Address { return []common.Address{s.addr} }
+
+func (s *testSigner) SignData(mimeType string, addr common.MixedcaseAddress, data hexutil.Bytes) (hexutil.Bytes, error) {
+	return make([]byte, 65), nil
+}
+
+// testKeyStore is a minimal clef compatible key import and export API, storing
+// the imported keys as is.
+type testKeyStore struct {
+	keys map[common.Address]json.RawMessage
+}
+
+func (ks *testKeyStore) Import(keyJSON json.RawMessage, passphrase, newPassphrase string) (accounts.Account, error) {
+	var key struct {
+		Address string `json:"address"`
+	}
+	if err := json.Unmarshal(keyJSON, &key); err != nil {
+		return accounts.Account{}, err
+	}
+	addr := common.HexToAddress(key.Address)
+	ks.keys[addr] = keyJSON
+	return accounts.Account{Address: addr}, nil
+}
+
+func (ks *testKeyStore) Export(addr common.Address) (json.RawMessage, error) {
+	if key, ok := ks.keys[addr]; ok {
+		return key, nil
+	}
+	return nil, errors.New("unknown account")
+}
+
+func newTestSignerServer(t *testing.T, addr common.Address) (*httptest.Server, *testKeyStore) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", &testSigner{addr: addr}); err != nil {
+		t.Fatalf("failed to register signer: %v", err)
+	}
+	ks := &testKeyStore{keys: make(map[common.Address]json.RawMessage)}
+	if err := server.RegisterName("clef", ks); err != nil {
+		t.Fatalf("failed to register key store: %v", err)
+	}
+	return httptest.NewServer(server), ks
+}
+
+func TestFailoverSigner(t *testing.T) {
+	addr := common.HexToAddress("0x5b38da6a701c568545dcfcb03fcb875f56beddc4")
+	healthy, _ := newTestSignerServer(t, addr)
+
+	// Nothing is listening on the first endpoint
+	dead := httptest.NewServer(nil)
+	dead.Close()
+
+	signer, err := NewFailoverSigner(FailoverConfig{
+		Endpoints: []string{dead.URL, healthy.URL},
+		Timeout:   time.Second,
+	})
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	account := accounts.Account{Address: addr}
+	if found, err := signer.Contains(account); err != nil || !found {
+		t.Fatalf("account missing from the signer: %v", err)
+	}
+	if _, err := signer.SignData(account, accounts.MimetypeCongress, []byte{0x01}); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	// Take the healthy signer down, signing must fail rather than hang
+	healthy.Close()
+	if _, err := signer.SignData(account, accounts.MimetypeCongress, []byte{0x01}); !errors.Is(err, ErrSignerUnavailable) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrSignerUnavailable)
+	}
+	if _, err := signer.Contains(account); !errors.Is(err, ErrSignerUnavailable) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrSignerUnavailable)
+	}
+	// No reachable signer on startup
+	if _, err := NewFailoverSigner(FailoverConfig{Endpoints: []string{dead.URL}}); err != ErrSignerUnavailable {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrSignerUnavailable)
+	}
+}
+
+// Tests that the keys are imported into every signer and exported from the
+// responsive ones.
+func TestFailoverSignerImportExport(t *testing.T) {
+	addr := common.HexToAddress("0x5b38da6a701c568545dcfcb03fcb875f56beddc4")
+	first, firstKeys := newTestSignerServer(t, addr)
+	defer first.Close()
+	second, secondKeys := newTestSignerServer(t, addr)
+	defer second.Close()
+
+	signer, err := NewFailoverSigner(FailoverConfig{
+		Endpoints: []string{first.URL, second.URL},
+		Timeout:   time.Second,
+	})
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	keyJSON := []byte(`{"address":"5b38da6a701c568545dcfcb03fcb875f56beddc4","crypto":{},"version":3}`)
+	account, err := signer.Import(keyJSON, "old", "new")
+	if err != nil {
+		t.Fatalf("failed to import key: %v", err)
+	}
+	if account.Address != addr {
+		t.Fatalf("imported address mismatch: have %x, want %x", account.Address, addr)
+	}
+	for i, keys := range []*testKeyStore{firstKeys, secondKeys} {
+		if !bytes.Equal(keys.keys[addr], keyJSON) {
+			t.Fatalf("signer %d: imported key mismatch: have %s, want %s", i, keys.keys[addr], keyJSON)
+		}
+	}
+	// Take the active signer down, the key is exported from the other one
+	first.Close()
+	exported, err := signer.Export(account)
+	if err != nil {
+		t.Fatalf("failed to export key: %v", err)
+	}
+	if !bytes.Equal(exported, keyJSON) {
+		t.Fatalf("exported key mismatch: have %s, want %s", exported, keyJSON)
+	}
+	// An import not reaching every signer fails
+	if _, err := signer.Import(keyJSON, "old", "new"); err == nil {
+		t.Fatalf("import succeeded with an unreachable signer")
+	}
+}
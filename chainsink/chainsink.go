@@ -0,0 +1,461 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package chainsink implements a daemon publishing the canonical chain events to
+// a message broker (NATS JetStream or Kafka) for external consumers.
+//
+// The events are published at least once: the position of the last published
+// block is kept in a cursor file, updated once the broker acknowledged all the
+// events of the block, and the publishing resumes from it after a restart. The
+// events carry a sequence number, the same for the events published again, so
+// the consumers can drop the duplicates.
+package chainsink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	// maxBlocksPerSync is the max number of blocks published in a row, before
+	// checking the quit channel again while catching up with the chain.
+	maxBlocksPerSync = 256
+
+	// minRetryDelay and maxRetryDelay bound the delay before publishing again
+	// after a failure.
+	minRetryDelay = time.Second
+	maxRetryDelay = 30 * time.Second
+
+	// publishTimeout is the time the broker has to acknowledge the events of a block.
+	publishTimeout = 10 * time.Second
+)
+
+// Event types published by the sink, also the last element of the subjects.
+const (
+	EventHead       = "head"       // New canonical block
+	EventReorg      = "reorg"      // Canonical blocks dropped, rolled back to the ancestor
+	EventFinalized  = "finalized"  // Canonical block reaching the finalized depth
+	EventGovernance = "governance" // System governance proposal executed by a block
+)
+
+var (
+	publishedMeter = metrics.NewRegisteredMeter("chainsink/published", nil)
+	failureMeter   = metrics.NewRegisteredMeter("chainsink/failures", nil)
+	lagGauge       = metrics.NewRegisteredGauge("chainsink/lag", nil)
+)
+
+// Config is the configuration of the chain event sink.
+type Config struct {
+	URL            string // Broker url, nats://host:port or kafka://broker1:port,broker2:port
+	Prefix         string // Prefix of the subjects or topics, the event type being appended
+	FinalizedDepth uint64 // Number of blocks on top of a block to consider it final
+	CursorFile     string // File keeping the position of the last published block
+}
+
+// Event is a chain event published to the broker.
+type Event struct {
+	Seq        uint64      `json:"seq"` // Sequence number of the event, increasing by one
+	Type       string      `json:"type"`
+	Number     uint64      `json:"number"`
+	Hash       common.Hash `json:"hash"`
+	ParentHash common.Hash `json:"parentHash"`
+	Time       uint64      `json:"timestamp"`
+
+	Reorg      *Reorg      `json:"reorg,omitempty"`
+	Governance *Governance `json:"governance,omitempty"`
+}
+
+// Reorg lists the canonical blocks dropped by a reorg, the event being about the
+// common ancestor the chain is rolled back to.
+type Reorg struct {
+	Dropped []common.Hash `json:"dropped"` // Dropped blocks, highest first
+}
+
+// Governance is a system governance proposal executed by a block.
+type Governance struct {
+	TxHash common.Hash    `json:"txHash"`
+	Id     *hexutil.Big   `json:"id"`
+	Action *hexutil.Big   `json:"action"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Value  *hexutil.Big   `json:"value"`
+	Data   hexutil.Bytes  `json:"data"`
+	Status hexutil.Uint64 `json:"status"` // Receipt status of the execution
+}
+
+// cursor is the position of the last published block, persisted across restarts.
+type cursor struct {
+	Seq       uint64      `json:"seq"`       // Sequence number of the next event
+	Number    uint64      `json:"number"`    // Last published block
+	Hash      common.Hash `json:"hash"`      // Hash of the last published block
+	Finalized uint64      `json:"finalized"` // Last block published as finalized
+}
+
+// backend encompasses the chain access needed by the sink.
+type backend interface {
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+	CurrentHeader() *types.Header
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+	HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error)
+	BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error)
+	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
+}
+
+// Service is the daemon publishing the canonical chain events to the broker.
+type Service struct {
+	backend   backend
+	publisher publisher
+	config    Config
+
+	cursor  cursor
+	headSub event.Subscription
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New creates the chain event sink and registers it into the node's lifecycle.
+func New(stack *node.Node, backend backend, config Config) error {
+	pub, err := newPublisher(config.URL)
+	if err != nil {
+		return err
+	}
+	if config.CursorFile == "" {
+		config.CursorFile = stack.ResolvePath("chainsink.json")
+	}
+	stack.RegisterLifecycle(newService(backend, pub, config))
+	return nil
+}
+
+// newService creates the sink publishing to the given publisher.
+func newService(backend backend, pub publisher, config Config) *Service {
+	return &Service{
+		backend:   backend,
+		publisher: pub,
+		config:    config,
+		quit:      make(chan struct{}),
+	}
+}
+
+// Start implements node.Lifecycle, starting to publish from the cursor.
+func (s *Service) Start() error {
+	if err := s.loadCursor(); err != nil {
+		return err
+	}
+	heads := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	s.headSub = s.backend.SubscribeChainHeadEvent(heads)
+
+	s.wg.Add(1)
+	go s.loop(heads)
+
+	log.Info("Chain event sink started", "url", s.config.URL, "prefix", s.config.Prefix, "number", s.cursor.Number, "seq", s.cursor.Seq)
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the publishing.
+func (s *Service) Stop() error {
+	s.headSub.Unsubscribe()
+	close(s.quit)
+	s.wg.Wait()
+
+	err := s.publisher.Close()
+	log.Info("Chain event sink stopped")
+	return err
+}
+
+// loop publishes the events of the new canonical blocks, retrying on failures.
+func (s *Service) loop(heads chan core.ChainHeadEvent) {
+	defer s.wg.Done()
+
+	var (
+		retry = time.NewTimer(0)
+		delay = minRetryDelay
+	)
+	defer retry.Stop()
+
+	for {
+		select {
+		case <-heads:
+		case <-retry.C:
+		case <-s.headSub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+		done, err := s.sync()
+		switch {
+		case err != nil:
+			failureMeter.Mark(1)
+			log.Warn("Failed to publish chain events", "number", s.cursor.Number+1, "retry", delay, "err", err)
+			retry.Reset(delay)
+			if delay *= 2; delay > maxRetryDelay {
+				delay = maxRetryDelay
+			}
+		case !done:
+			delay = minRetryDelay
+			retry.Reset(0) // Still catching up
+		default:
+			delay = minRetryDelay
+		}
+	}
+}
+
+// sync publishes the events from the cursor up to the current head, at most
+// maxBlocksPerSync blocks, reporting whether it caught up with the head.
+func (s *Service) sync() (bool, error) {
+	ctx := context.Background()
+	head := s.backend.CurrentHeader()
+
+	// Roll back to the last published block still canonical, if any was dropped
+	canon, err := s.backend.HeaderByNumber(ctx, rpc.BlockNumber(s.cursor.Number))
+	if err != nil {
+		return false, err
+	}
+	if canon == nil || canon.Hash() != s.cursor.Hash {
+		if err := s.rollback(ctx); err != nil {
+			return false, err
+		}
+	}
+	// Publish the new canonical blocks in order
+	for n := 0; n < maxBlocksPerSync; n++ {
+		number := s.cursor.Number + 1
+		if number > head.Number.Uint64() {
+			lagGauge.Update(0)
+			return true, nil
+		}
+		select {
+		case <-s.quit:
+			return true, nil
+		default:
+		}
+		block, err := s.backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return false, err
+		}
+		if block == nil || block.ParentHash() != s.cursor.Hash {
+			return true, nil // Reorged meanwhile, the next head event handles it
+		}
+		if err := s.publishBlock(ctx, block); err != nil {
+			return false, err
+		}
+		lagGauge.Update(int64(head.Number.Uint64() - number))
+	}
+	return false, nil
+}
+
+// rollback publishes the reorg dropping the published blocks not canonical
+// anymore, moving the cursor back to their common ancestor.
+func (s *Service) rollback(ctx context.Context) error {
+	var (
+		dropped []common.Hash
+		hash    = s.cursor.Hash
+	)
+	for {
+		header, err := s.backend.HeaderByHash(ctx, hash)
+		if err != nil {
+			return err
+		}
+		if header == nil {
+			return fmt.Errorf("published block %x not found", hash)
+		}
+		canon, err := s.backend.HeaderByNumber(ctx, rpc.BlockNumber(header.Number.Uint64()))
+		if err != nil {
+			return err
+		}
+		if canon != nil && canon.Hash() == header.Hash() {
+			// Common ancestor found, publish the reorg
+			if header.Number.Uint64() <= s.cursor.Finalized {
+				log.Error("Reorg dropped a finalized block", "finalized", s.cursor.Finalized, "ancestor", header.Number)
+			}
+			next := s.cursor
+			next.Number, next.Hash = header.Number.Uint64(), header.Hash()
+			if next.Finalized > next.Number {
+				next.Finalized = next.Number
+			}
+			ev := s.newEvent(&next, EventReorg, header)
+			ev.Reorg = &Reorg{Dropped: dropped}
+			if err := s.publish(ctx, []*Event{ev}); err != nil {
+				return err
+			}
+			log.Info("Published chain reorg", "ancestor", header.Number, "dropped", len(dropped))
+			return s.saveCursor(next)
+		}
+		dropped = append(dropped, header.Hash())
+		hash = header.ParentHash
+	}
+}
+
+// publishBlock publishes the events of a new canonical block, moving the cursor
+// to it once acknowledged.
+func (s *Service) publishBlock(ctx context.Context, block *types.Block) error {
+	var (
+		next   = s.cursor
+		header = block.Header()
+		events = []*Event{s.newEvent(&next, EventHead, header)}
+	)
+	next.Number, next.Hash = block.NumberU64(), block.Hash()
+
+	// Collect the executed system governance proposals
+	var receipts types.Receipts
+	for i, tx := range block.Transactions() {
+		prop, err := congress.DecodeProposal(tx)
+		if err != nil {
+			continue
+		}
+		if receipts == nil {
+			if receipts, err = s.backend.GetReceipts(ctx, block.Hash()); err != nil {
+				return err
+			}
+		}
+		gov := &Governance{
+			TxHash: tx.Hash(),
+			Id:     (*hexutil.Big)(prop.Id),
+			Action: (*hexutil.Big)(prop.Action),
+			From:   prop.From,
+			To:     prop.To,
+			Value:  (*hexutil.Big)(prop.Value),
+			Data:   prop.Data,
+		}
+		if i < len(receipts) {
+			gov.Status = hexutil.Uint64(receipts[i].Status)
+		}
+		ev := s.newEvent(&next, EventGovernance, header)
+		ev.Governance = gov
+		events = append(events, ev)
+	}
+	// Collect the blocks reaching the finalized depth
+	if depth := s.config.FinalizedDepth; next.Number >= depth {
+		for number := next.Finalized + 1; number <= next.Number-depth; number++ {
+			final, err := s.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+			if err != nil {
+				return err
+			}
+			if final == nil {
+				return fmt.Errorf("canonical block #%d not found", number)
+			}
+			events = append(events, s.newEvent(&next, EventFinalized, final))
+			next.Finalized = number
+		}
+	}
+	if err := s.publish(ctx, events); err != nil {
+		return err
+	}
+	return s.saveCursor(next)
+}
+
+// newEvent creates an event about the block, taking the next sequence number.
+func (s *Service) newEvent(next *cursor, typ string, header *types.Header) *Event {
+	ev := &Event{
+		Seq:        next.Seq,
+		Type:       typ,
+		Number:     header.Number.Uint64(),
+		Hash:       header.Hash(),
+		ParentHash: header.ParentHash,
+		Time:       header.Time,
+	}
+	next.Seq++
+	return ev
+}
+
+// publish publishes the events, waiting for the broker to acknowledge them.
+func (s *Service) publish(ctx context.Context, events []*Event) error {
+	msgs := make([]message, 0, len(events))
+	for _, ev := range events {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, message{
+			subject: s.config.Prefix + "." + ev.Type,
+			key:     fmt.Sprintf("%d", ev.Seq),
+			data:    data,
+		})
+	}
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	if err := s.publisher.Publish(ctx, msgs); err != nil {
+		return err
+	}
+	publishedMeter.Mark(int64(len(msgs)))
+	return nil
+}
+
+// loadCursor loads the cursor from its file, or starts from the current head if
+// there is none.
+func (s *Service) loadCursor() error {
+	blob, err := ioutil.ReadFile(s.config.CursorFile)
+	if err == nil {
+		if err := json.Unmarshal(blob, &s.cursor); err != nil {
+			return fmt.Errorf("invalid chain sink cursor %s: %v", s.config.CursorFile, err)
+		}
+		return nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	// Start from the current head, without publishing the history
+	head := s.backend.CurrentHeader()
+	if head.Number.Uint64() == 0 {
+		s.cursor = cursor{Hash: head.Hash()}
+		return nil
+	}
+	s.cursor = cursor{Number: head.Number.Uint64() - 1, Hash: head.ParentHash}
+	if s.cursor.Number > s.config.FinalizedDepth {
+		s.cursor.Finalized = s.cursor.Number - s.config.FinalizedDepth
+	}
+	return nil
+}
+
+// saveCursor moves the cursor, persisting it atomically.
+func (s *Service) saveCursor(next cursor) error {
+	blob, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+	tmp := s.config.CursorFile + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(tmp), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(tmp, blob, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.config.CursorFile); err != nil {
+		return err
+	}
+	s.cursor = next
+	return nil
+}
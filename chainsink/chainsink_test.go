@@ -0,0 +1,198 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package chainsink
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// testBackend is a chain of blocks with switchable canonical blocks.
+type testBackend struct {
+	feed   event.Feed
+	canon  []*types.Block
+	blocks map[common.Hash]*types.Block
+}
+
+func newTestBackend(n int) *testBackend {
+	genesis := types.NewBlockWithHeader(&types.Header{Number: new(big.Int)})
+	b := &testBackend{blocks: map[common.Hash]*types.Block{genesis.Hash(): genesis}}
+	b.setCanon(b.extend(genesis, n, 0))
+	return b
+}
+
+// extend creates n blocks on top of the parent, the salt making them unique.
+func (b *testBackend) extend(parent *types.Block, n int, salt byte) []*types.Block {
+	chain := []*types.Block{parent}
+	for i := 0; i < n; i++ {
+		block := types.NewBlockWithHeader(&types.Header{
+			Number:     new(big.Int).Add(parent.Number(), common.Big1),
+			ParentHash: parent.Hash(),
+			Time:       parent.Time() + 3,
+			Extra:      []byte{salt},
+		})
+		b.blocks[block.Hash()] = block
+		chain = append(chain, block)
+		parent = block
+	}
+	return chain
+}
+
+// setCanon makes the chain, starting with an existing block, canonical.
+func (b *testBackend) setCanon(chain []*types.Block) {
+	first := chain[0].NumberU64()
+	if b.canon == nil {
+		b.canon = chain
+		return
+	}
+	b.canon = append(b.canon[:first], chain...)
+}
+
+func (b *testBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return b.feed.Subscribe(ch)
+}
+
+func (b *testBackend) CurrentHeader() *types.Header {
+	return b.canon[len(b.canon)-1].Header()
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	if block := b.blocks[hash]; block != nil {
+		return block.Header(), nil
+	}
+	return nil, nil
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	block, _ := b.BlockByNumber(ctx, number)
+	if block == nil {
+		return nil, nil
+	}
+	return block.Header(), nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number < 0 || int(number) >= len(b.canon) {
+		return nil, nil
+	}
+	return b.canon[number], nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return nil, nil
+}
+
+// testPublisher records the published events.
+type testPublisher struct {
+	events []*Event
+}
+
+func (p *testPublisher) Publish(ctx context.Context, msgs []message) error {
+	for _, msg := range msgs {
+		ev := new(Event)
+		if err := json.Unmarshal(msg.data, ev); err != nil {
+			return err
+		}
+		if want := "test." + ev.Type; msg.subject != want {
+			panic("subject mismatch: have " + msg.subject + ", want " + want)
+		}
+		p.events = append(p.events, ev)
+	}
+	return nil
+}
+
+func (p *testPublisher) Close() error { return nil }
+
+// summary is the type and number of an event.
+type summary struct {
+	typ    string
+	number uint64
+}
+
+func (p *testPublisher) take() []summary {
+	var res []summary
+	for _, ev := range p.events {
+		res = append(res, summary{ev.Type, ev.Number})
+	}
+	p.events = nil
+	return res
+}
+
+func TestChainSink(t *testing.T) {
+	var (
+		backend = newTestBackend(5)
+		pub     = new(testPublisher)
+		config  = Config{Prefix: "test", FinalizedDepth: 2, CursorFile: filepath.Join(t.TempDir(), "cursor.json")}
+		sink    = newService(backend, pub, config)
+	)
+	if err := sink.loadCursor(); err != nil {
+		t.Fatalf("failed to load cursor: %v", err)
+	}
+	// A fresh sink publishes from the head on
+	if _, err := sink.sync(); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	if have, want := pub.take(), []summary{{EventHead, 5}, {EventFinalized, 3}}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("events mismatch: have %v, want %v", have, want)
+	}
+	// Reorg the chain from block 3, dropping 4 and 5
+	dropped := []common.Hash{backend.canon[5].Hash(), backend.canon[4].Hash()}
+	backend.setCanon(backend.extend(backend.canon[3], 3, 1))
+	if _, err := sink.sync(); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	reorg := pub.events[0]
+	if reorg.Reorg == nil || !reflect.DeepEqual(reorg.Reorg.Dropped, dropped) {
+		t.Errorf("dropped blocks mismatch: have %v, want %v", reorg.Reorg, dropped)
+	}
+	if reorg.Seq != 2 {
+		t.Errorf("sequence mismatch: have %d, want 2", reorg.Seq)
+	}
+	want := []summary{{EventReorg, 3}, {EventHead, 4}, {EventHead, 5}, {EventHead, 6}, {EventFinalized, 4}}
+	if have := pub.take(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("events mismatch: have %v, want %v", have, want)
+	}
+	// A restarted sink resumes from the persisted cursor
+	backend.setCanon(backend.extend(backend.canon[6], 1, 1))
+
+	restarted := newService(backend, pub, config)
+	if err := restarted.loadCursor(); err != nil {
+		t.Fatalf("failed to load cursor: %v", err)
+	}
+	if restarted.cursor != sink.cursor {
+		t.Fatalf("cursor mismatch: have %+v, want %+v", restarted.cursor, sink.cursor)
+	}
+	if _, err := restarted.sync(); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	if pub.events[0].Seq != 7 {
+		t.Errorf("sequence mismatch: have %d, want 7", pub.events[0].Seq)
+	}
+	if have, want := pub.take(), []summary{{EventHead, 7}, {EventFinalized, 5}}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("events mismatch: have %v, want %v", have, want)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package chainsink
+
+import "context"
+
+// message is an event encoded for the broker.
+type message struct {
+	subject string // NATS subject or Kafka topic
+	key     string // Unique id of the event, for the deduplication
+	data    []byte
+}
+
+// publisher publishes the messages to a broker, returning once they are all
+// acknowledged.
+type publisher interface {
+	Publish(ctx context.Context, msgs []message) error
+	Close() error
+}
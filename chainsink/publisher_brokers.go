@@ -0,0 +1,119 @@
+//go:build chainsink
+// +build chainsink
+
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package chainsink
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/segmentio/kafka-go"
+)
+
+// newPublisher creates the publisher of the broker url.
+func newPublisher(url string) (publisher, error) {
+	switch {
+	case strings.HasPrefix(url, "nats://"), strings.HasPrefix(url, "tls://"):
+		return newNATSPublisher(url)
+	case strings.HasPrefix(url, "kafka://"):
+		return newKafkaPublisher(strings.Split(strings.TrimPrefix(url, "kafka://"), ","))
+	default:
+		return nil, fmt.Errorf("unsupported chain sink url %q, want nats:// or kafka://", url)
+	}
+}
+
+// natsPublisher publishes to NATS JetStream, which acknowledges the messages once
+// stored by the stream bound to the subjects.
+type natsPublisher struct {
+	conn *nats.Conn
+	js   nats.JetStreamContext
+}
+
+func newNATSPublisher(url string) (*natsPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name("heco-chainsink"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &natsPublisher{conn: conn, js: js}, nil
+}
+
+// Publish implements publisher, the messages ids letting the stream drop the
+// ones published again within its duplicates window.
+func (p *natsPublisher) Publish(ctx context.Context, msgs []message) error {
+	for _, msg := range msgs {
+		if _, err := p.js.Publish(msg.subject, msg.data, nats.MsgId(msg.key), nats.Context(ctx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements publisher.
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
+
+// kafkaPublisher publishes to Kafka, waiting for all the in-sync replicas to
+// acknowledge the messages. The messages of a topic share the partition key, so
+// they're consumed in order.
+type kafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+func newKafkaPublisher(brokers []string) (*kafkaPublisher, error) {
+	if len(brokers) == 0 || brokers[0] == "" {
+		return nil, fmt.Errorf("no kafka broker set")
+	}
+	return &kafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			BatchTimeout:           10 * time.Millisecond, // Events are written per block, don't linger
+			AllowAutoTopicCreation: true,
+		},
+	}, nil
+}
+
+// Publish implements publisher.
+func (p *kafkaPublisher) Publish(ctx context.Context, msgs []message) error {
+	batch := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		batch[i] = kafka.Message{
+			Topic:   msg.subject,
+			Key:     []byte(msg.subject),
+			Value:   msg.data,
+			Headers: []kafka.Header{{Key: "id", Value: []byte(msg.key)}},
+		}
+	}
+	return p.writer.WriteMessages(ctx, batch...)
+}
+
+// Close implements publisher.
+func (p *kafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -19,10 +19,12 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"gopkg.in/urfave/cli.v1"
@@ -185,6 +187,50 @@ Note:
 As you can directly copy your encrypted accounts to another ethereum instance,
 this import mechanism is not needed when you transfer an account between
 nodes.
+`,
+			},
+			{
+				Name:   "signer-import",
+				Usage:  "Import an encrypted key file into the remote validator signers",
+				Action: utils.MigrateFlags(accountSignerImport),
+				Flags: []cli.Flag{
+					utils.ValidatorSignerFlag,
+					utils.ValidatorSignerTimeoutFlag,
+					utils.PasswordFileFlag,
+				},
+				ArgsUsage: "<keyFile>",
+				Description: `
+    geth account signer-import --congress.signer <endpoints> <keyfile>
+
+Imports a web3 keystore encrypted key from <keyfile> into every remote signer
+given by --congress.signer, so that any of them can seal for the validator.
+Prints the address.
+
+You are prompted for the password of the key file and for the password the
+signers store the key with. For non-interactive use they can be given as the
+first and second lines of the --password file.
+
+The signers need to serve the clef_import method, e.g. the HSM or KMS backed
+signers exposing it on their endpoint.
+`,
+			},
+			{
+				Name:   "signer-export",
+				Usage:  "Export an encrypted key file from the remote validator signers",
+				Action: utils.MigrateFlags(accountSignerExport),
+				Flags: []cli.Flag{
+					utils.ValidatorSignerFlag,
+					utils.ValidatorSignerTimeoutFlag,
+				},
+				ArgsUsage: "<address> <keyFile>",
+				Description: `
+    geth account signer-export --congress.signer <endpoints> <address> <keyfile>
+
+Exports the web3 keystore encrypted key of <address> from the first responsive
+remote signer given by --congress.signer into <keyfile>, which must not exist.
+The key stays encrypted with the password of the signer.
+
+The signers need to serve the clef_export method.
 `,
 			},
 		},
@@ -260,7 +306,7 @@ func ambiguousAddrRecovery(ks *keystore.KeyStore, err *keystore.AmbiguousAddrErr
 
 // accountCreate creates a new account into the keystore defined by the CLI flags.
 func accountCreate(ctx *cli.Context) error {
-	cfg := gethConfig{Node: defaultNodeConfig()}
+	cfg := gethConfig{Node: defaultNodeConfig(), Log: defaultLogConfig()}
 	// Load config file.
 	if file := ctx.GlobalString(configFileFlag.Name); file != "" {
 		if err := loadConfig(file, &cfg); err != nil {
@@ -357,3 +403,54 @@ func accountImport(ctx *cli.Context) error {
 	fmt.Printf("Address: {%x}\n", acct.Address)
 	return nil
 }
+
+// accountSignerImport imports an encrypted key file into the remote validator
+// signers.
+func accountSignerImport(ctx *cli.Context) error {
+	keyfile := ctx.Args().First()
+	if len(keyfile) == 0 {
+		utils.Fatalf("keyfile must be given as argument")
+	}
+	keyJSON, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		utils.Fatalf("Could not read key file: %v", err)
+	}
+	signer := utils.MakeValidatorSigner(ctx)
+
+	passwords := utils.MakePasswordList(ctx)
+	passphrase := utils.GetPassPhraseWithList("Please give the password of the key file.", false, 0, passwords)
+	newPassphrase := utils.GetPassPhraseWithList("Please give the password to store the key with in the signers. Do not forget this password.", true, 1, passwords)
+
+	acct, err := signer.Import(keyJSON, passphrase, newPassphrase)
+	if err != nil {
+		utils.Fatalf("Could not import the key: %v", err)
+	}
+	fmt.Printf("Address: {%x}\n", acct.Address)
+	return nil
+}
+
+// accountSignerExport exports the encrypted key of an account from the remote
+// validator signers.
+func accountSignerExport(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires two arguments: <address> <keyFile>")
+	}
+	address, keyfile := ctx.Args().Get(0), ctx.Args().Get(1)
+	if !common.IsHexAddress(address) {
+		utils.Fatalf("Invalid address: %s", address)
+	}
+	if _, err := os.Stat(keyfile); err == nil {
+		utils.Fatalf("Key file %s already exists", keyfile)
+	}
+	signer := utils.MakeValidatorSigner(ctx)
+
+	keyJSON, err := signer.Export(accounts.Account{Address: common.HexToAddress(address)})
+	if err != nil {
+		utils.Fatalf("Could not export the key: %v", err)
+	}
+	if err := ioutil.WriteFile(keyfile, keyJSON, 0600); err != nil {
+		utils.Fatalf("Could not write the key file: %v", err)
+	}
+	fmt.Printf("Key file: %s\n", keyfile)
+	return nil
+}
@@ -0,0 +1,415 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	SnapshotURLFlag = cli.StringFlag{
+		Name:  "url",
+		Usage: "URL of the signed manifest of the chain data snapshot",
+	}
+	SnapshotPubkeysFlag = cli.StringFlag{
+		Name:  "pubkeys",
+		Usage: "Comma separated minisign public keys trusted to sign the snapshot manifest",
+	}
+	downloadSnapshotCommand = cli.Command{
+		Action:    utils.MigrateFlags(downloadSnapshot),
+		Name:      "download-snapshot",
+		Usage:     "Bootstrap the chain database from a published chain data snapshot",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.HecoMainnetFlag,
+			utils.HecoTestnetFlag,
+			SnapshotURLFlag,
+			SnapshotPubkeysFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The download-snapshot command fetches a published chain data snapshot and unpacks
+it as the chain database of the data directory, the node then syncing from the
+head block of the snapshot once started.
+
+The --url points to the JSON manifest of the snapshot, signed with minisign into
+<url>.minisig by one of the --pubkeys. The manifest gives the archive to download,
+relative to the manifest URL, its size and SHA-256 checksum, the genesis hash of
+the network and the chain data backup manifest of the snapshot head. The archive
+is a gzipped tarball of a chain data backup directory, holding the chaindata
+directory at its root.
+
+An interrupted download is resumed when running the command again. The chain
+database must not exist yet, use removedb to drop an existing one.`,
+	}
+)
+
+// snapshotManifest describes a published chain data snapshot.
+type snapshotManifest struct {
+	Genesis common.Hash         `json:"genesis"`
+	Archive string              `json:"archive"` // Archive URL, relative to the manifest one
+	Size    int64               `json:"size"`    // Archive size in bytes
+	SHA256  string              `json:"sha256"`  // Hex encoded archive checksum
+	Head    *eth.BackupManifest `json:"head"`
+}
+
+func downloadSnapshot(ctx *cli.Context) error {
+	var (
+		manifestURL = ctx.String(SnapshotURLFlag.Name)
+		pubkeys     = utils.SplitAndTrim(ctx.String(SnapshotPubkeysFlag.Name))
+		preset      = utils.NetworkPreset(ctx)
+	)
+	if manifestURL == "" {
+		utils.Fatalf("The snapshot manifest must be given with --%s", SnapshotURLFlag.Name)
+	}
+	if len(pubkeys) == 0 {
+		utils.Fatalf("The keys trusted to sign the snapshot must be given with --%s", SnapshotPubkeysFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.AncientFlag.Name) {
+		utils.Fatalf("Snapshots can't be unpacked with a custom --%s", utils.AncientFlag.Name)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindata := stack.ResolvePath("chaindata")
+	if files, _ := ioutil.ReadDir(chaindata); len(files) > 0 {
+		utils.Fatalf("Chain database %s already exists, remove it first with removedb", chaindata)
+	}
+	// Fetch and authenticate the manifest of the snapshot
+	data, err := fetch(manifestURL)
+	if err != nil {
+		utils.Fatalf("Failed to retrieve snapshot manifest: %v", err)
+	}
+	sig, err := fetch(manifestURL + ".minisig")
+	if err != nil {
+		utils.Fatalf("Failed to retrieve snapshot manifest signature: %v", err)
+	}
+	if err := verifySignature(pubkeys, data, sig); err != nil {
+		utils.Fatalf("Invalid snapshot manifest: %v", err)
+	}
+	manifest := new(snapshotManifest)
+	if err := json.Unmarshal(data, manifest); err != nil {
+		utils.Fatalf("Invalid snapshot manifest: %v", err)
+	}
+	if manifest.Head == nil {
+		utils.Fatalf("Invalid snapshot manifest: missing head")
+	}
+	if preset != nil && manifest.Genesis != preset.GenesisHash {
+		utils.Fatalf("Snapshot of another network: genesis %x, %s genesis %x", manifest.Genesis, preset.Name, preset.GenesisHash)
+	}
+	archiveURL, err := resolveSnapshotURL(manifestURL, manifest.Archive)
+	if err != nil {
+		utils.Fatalf("Invalid snapshot archive URL: %v", err)
+	}
+	log.Info("Downloading chain data snapshot", "number", manifest.Head.Number, "hash", manifest.Head.Hash, "size", common.StorageSize(manifest.Size))
+
+	// Download the archive next to the chain database and unpack it
+	archive := fmt.Sprintf("%s-%d.tar.gz", chaindata, manifest.Head.Number)
+	if err := downloadSnapshotArchive(archiveURL, archive, manifest.Size, manifest.SHA256); err != nil {
+		utils.Fatalf("Failed to download snapshot archive: %v", err)
+	}
+	start := time.Now()
+	if err := unpackSnapshotArchive(archive, chaindata); err != nil {
+		utils.Fatalf("Failed to unpack snapshot archive: %v", err)
+	}
+	log.Info("Unpacked chain data snapshot", "path", chaindata, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Make sure the database is the one announced before dropping the archive
+	db, err := stack.OpenDatabaseWithFreezer("chaindata", 0, 0, "", "", true)
+	if err != nil {
+		utils.Fatalf("Failed to open unpacked chain database: %v", err)
+	}
+	genesis, head := rawdb.ReadCanonicalHash(db, 0), rawdb.ReadHeadBlockHash(db)
+	db.Close()
+
+	if genesis != manifest.Genesis || head != manifest.Head.Hash {
+		utils.Fatalf("Unpacked chain database mismatch: genesis %x, head %x, want genesis %x, head %x", genesis, head, manifest.Genesis, manifest.Head.Hash)
+	}
+	if err := os.Remove(archive); err != nil {
+		log.Warn("Failed to remove snapshot archive", "path", archive, "err", err)
+	}
+	log.Info("Bootstrapped chain database from snapshot", "number", manifest.Head.Number, "hash", manifest.Head.Hash)
+	return nil
+}
+
+// resolveSnapshotURL resolves the archive URL relative to the manifest one.
+func resolveSnapshotURL(manifest, archive string) (string, error) {
+	base, err := url.Parse(manifest)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(archive)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// downloadSnapshotArchive downloads the archive into the file, resuming from the
+// partially downloaded file left by a previous run if the server supports it, and
+// checks its size and checksum.
+func downloadSnapshotArchive(src, dst string, size int64, checksum string) error {
+	want, err := hex.DecodeString(strings.TrimPrefix(checksum, "0x"))
+	if err != nil || len(want) != sha256.Size {
+		return fmt.Errorf("invalid checksum %q", checksum)
+	}
+	// The archive is only renamed in place once verified
+	if common.FileExist(dst) {
+		return nil
+	}
+	part := dst + ".part"
+	out, err := os.OpenFile(part, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// Hash the partial download, fetching the remainder only
+	hasher := sha256.New()
+	offset, err := io.Copy(hasher, out)
+	if err != nil {
+		return err
+	}
+	if offset > size {
+		return fmt.Errorf("partial download %s larger than the archive", part)
+	}
+	if offset < size {
+		body, resumed, err := openSnapshotArchive(src, offset)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+
+		if !resumed {
+			if err := out.Truncate(0); err != nil {
+				return err
+			}
+			if _, err := out.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			hasher.Reset()
+			offset = 0
+		}
+		if offset > 0 {
+			log.Info("Resuming snapshot download", "done", common.StorageSize(offset))
+		}
+		if err := copySnapshotArchive(io.MultiWriter(out, hasher), body, offset, size); err != nil {
+			return err
+		}
+		if err := out.Sync(); err != nil {
+			return err
+		}
+	}
+	if have := hasher.Sum(nil); string(have) != string(want) {
+		out.Close()
+		os.Remove(part)
+		return fmt.Errorf("checksum mismatch: have %x, want %x", have, want)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(part, dst)
+}
+
+// openSnapshotArchive opens the archive from the offset, reporting whether the
+// source could skip the first bytes or serves the whole archive instead.
+func openSnapshotArchive(src string, offset int64) (io.ReadCloser, bool, error) {
+	if file := strings.TrimPrefix(src, "file://"); file != src {
+		in, err := os.Open(file)
+		if err != nil {
+			return nil, false, err
+		}
+		if _, err := in.Seek(offset, io.SeekStart); err != nil {
+			in.Close()
+			return nil, false, err
+		}
+		return in, true, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, src, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	switch res.StatusCode {
+	case http.StatusOK:
+		return res.Body, offset == 0, nil
+	case http.StatusPartialContent:
+		return res.Body, true, nil
+	default:
+		res.Body.Close()
+		return nil, false, fmt.Errorf("unexpected status %s", res.Status)
+	}
+}
+
+// copySnapshotArchive copies the remainder of the archive, logging the progress.
+func copySnapshotArchive(dst io.Writer, src io.Reader, offset, size int64) error {
+	var (
+		buf    = make([]byte, 1024*1024)
+		start  = time.Now()
+		logged = time.Now()
+		done   = offset
+	)
+	for done < size {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if done+int64(n) > size {
+				return errors.New("archive larger than announced")
+			}
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return err
+			}
+			done += int64(n)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Downloading snapshot archive", "done", common.StorageSize(done), "size", common.StorageSize(size),
+				"percent", fmt.Sprintf("%.2f%%", float64(done)*100/float64(size)), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if done != size {
+		return fmt.Errorf("archive truncated: have %d bytes, want %d", done, size)
+	}
+	return nil
+}
+
+// unpackSnapshotArchive extracts the chaindata directory of the archive into the
+// destination, through a temporary directory so an interrupted unpacking doesn't
+// leave a partial database behind.
+func unpackSnapshotArchive(archive, dst string) error {
+	in, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tmp := dst + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := untarChaindata(tar.NewReader(gz), tmp); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// untarChaindata writes the entries of the chaindata directory of the archive
+// into the destination, skipping the other entries.
+func untarChaindata(tr *tar.Reader, dst string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	var files int
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		// Cleaned names escaping the directory don't match, and are skipped
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if name != "chaindata" && !strings.HasPrefix(name, "chaindata/") {
+			continue
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, "chaindata"), "/")
+		if rel == "" {
+			continue
+		}
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := writeArchiveFile(target, tr); err != nil {
+				return err
+			}
+			files++
+		default:
+			return fmt.Errorf("unsupported archive entry %q", header.Name)
+		}
+	}
+	if files == 0 {
+		return errors.New("no chaindata in archive")
+	}
+	return nil
+}
+
+// writeArchiveFile writes the content of the current archive entry into the file.
+func writeArchiveFile(target string, src io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
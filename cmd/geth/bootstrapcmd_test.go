@@ -0,0 +1,111 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// makeSnapshotArchive creates a gzipped tarball of the given files.
+func makeSnapshotArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDownloadSnapshotArchive(t *testing.T) {
+	archive := makeSnapshotArchive(t, map[string]string{
+		"manifest.json":           "{}",
+		"chaindata/CURRENT":       "MANIFEST-000001",
+		"chaindata/ancient/FLOCK": "",
+		"../chaindata/escaped":    "x",
+	})
+	sum := sha256.Sum256(archive)
+	checksum := hex.EncodeToString(sum[:])
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "snapshot.tar.gz", time.Time{}, bytes.NewReader(archive))
+	}))
+	defer srv.Close()
+
+	var (
+		dir       = t.TempDir()
+		dst       = filepath.Join(dir, "chaindata-1.tar.gz")
+		chaindata = filepath.Join(dir, "chaindata")
+	)
+	// Leave a partial download behind, which is resumed
+	if err := ioutil.WriteFile(dst+".part", archive[:len(archive)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := downloadSnapshotArchive(srv.URL, dst, int64(len(archive)), checksum); err != nil {
+		t.Fatalf("failed to download archive: %v", err)
+	}
+	if have, _ := ioutil.ReadFile(dst); !bytes.Equal(have, archive) {
+		t.Fatalf("downloaded archive mismatch")
+	}
+	if err := unpackSnapshotArchive(dst, chaindata); err != nil {
+		t.Fatalf("failed to unpack archive: %v", err)
+	}
+	if have, _ := ioutil.ReadFile(filepath.Join(chaindata, "CURRENT")); string(have) != "MANIFEST-000001" {
+		t.Errorf("unpacked file mismatch: have %q", have)
+	}
+	files, _ := ioutil.ReadDir(dir)
+	for _, file := range files {
+		if name := file.Name(); name != "chaindata" && name != "chaindata-1.tar.gz" {
+			t.Errorf("unexpected file %s", name)
+		}
+	}
+	// A corrupted partial download is detected and dropped
+	corrupted := filepath.Join(dir, "corrupted.tar.gz")
+	if err := ioutil.WriteFile(corrupted+".part", bytes.Repeat([]byte{0xff}, 16), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := downloadSnapshotArchive(srv.URL, corrupted, int64(len(archive)), checksum)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("corrupted download: have error %v, want checksum mismatch", err)
+	}
+	if _, err := ioutil.ReadFile(corrupted + ".part"); err == nil {
+		t.Errorf("corrupted download left behind")
+	}
+}
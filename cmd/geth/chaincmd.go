@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"runtime"
 	"strconv"
@@ -29,15 +30,18 @@ import (
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/congress"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/trie"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -46,9 +50,11 @@ var (
 		Action:    utils.MigrateFlags(initGenesis),
 		Name:      "init",
 		Usage:     "Bootstrap and initialize a new genesis block",
-		ArgsUsage: "<genesisPath>",
+		ArgsUsage: "[<genesisPath>]",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.HecoMainnetFlag,
+			utils.HecoTestnetFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -56,7 +62,9 @@ The init command initializes a new genesis block and definition for the network.
 This is a destructive action and changes the network in which you will be
 participating.
 
-It expects the genesis file as argument.`,
+It expects the genesis file as argument, or the --heco-mainnet/--heco-testnet
+preset for the public networks. A genesis file reusing the chain id of a public
+network with another genesis block is rejected.`,
 	}
 	dumpGenesisCommand = cli.Command{
 		Action:    utils.MigrateFlags(dumpGenesis),
@@ -65,7 +73,9 @@ It expects the genesis file as argument.`,
 		ArgsUsage: "",
 		Flags: []cli.Flag{
 			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
 			utils.TestnetFlag,
+			utils.HecoTestnetFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -176,6 +186,36 @@ It's deprecated, please use "geth db export" instead.
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
 This command dumps out the state for a given block (or latest, if none provided).
+`,
+	}
+	verifyReportFlag = cli.StringFlag{
+		Name:  "report",
+		Usage: "File to write the JSON report of the failed blocks to",
+	}
+	verifyChainCommand = cli.Command{
+		Action:    utils.MigrateFlags(verifyChain),
+		Name:      "verify-chain",
+		Usage:     "Re-verify the congress consensus rules of the local chain",
+		ArgsUsage: "[<blockNumFirst> [<blockNumLast>]]",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			verifyReportFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The verify-chain command re-verifies the blocks of the local chain in the given
+range (the whole chain by default) offline: the congress seals, the difficulty
+turn-ness, the checkpoint validator lists and the system transactions. The
+validator snapshots are recomputed from the headers, starting at the genesis or
+at a checkpoint before the range, and compared with the ones stored.
+
+If the state of the block before the range is available, the blocks are also
+re-executed, cross-checking the checkpoint validators against the contracts and
+the system transaction counts against the passed proposals. It's useful after
+restoring a datadir backup of unknown provenance.
 `,
 	}
 )
@@ -183,20 +223,32 @@ This command dumps out the state for a given block (or latest, if none provided)
 // initGenesis will initialise the given JSON format genesis file and writes it as
 // the zero'd block (i.e. genesis) or will fail hard if it can't succeed.
 func initGenesis(ctx *cli.Context) error {
-	// Make sure we have a valid genesis JSON
+	// Make sure we have a valid genesis JSON, or a network preset
 	genesisPath := ctx.Args().First()
-	if len(genesisPath) == 0 {
-		utils.Fatalf("Must supply path to genesis JSON file")
-	}
-	file, err := os.Open(genesisPath)
-	if err != nil {
-		utils.Fatalf("Failed to read genesis file: %v", err)
-	}
-	defer file.Close()
+	preset := utils.NetworkPreset(ctx)
+
+	var genesis *core.Genesis
+	switch {
+	case len(genesisPath) > 0 && preset != nil:
+		utils.Fatalf("Either a genesis JSON file or a network preset can be given, not both")
+	case preset != nil:
+		genesis = preset.Genesis()
+	case len(genesisPath) == 0:
+		utils.Fatalf("Must supply path to genesis JSON file, or --%s/--%s", utils.HecoMainnetFlag.Name, utils.HecoTestnetFlag.Name)
+	default:
+		file, err := os.Open(genesisPath)
+		if err != nil {
+			utils.Fatalf("Failed to read genesis file: %v", err)
+		}
+		defer file.Close()
 
-	genesis := new(core.Genesis)
-	if err := json.NewDecoder(file).Decode(genesis); err != nil {
-		utils.Fatalf("invalid genesis file: %v", err)
+		genesis = new(core.Genesis)
+		if err := json.NewDecoder(file).Decode(genesis); err != nil {
+			utils.Fatalf("invalid genesis file: %v", err)
+		}
+		if preset := core.ImpersonatedPreset(genesis); preset != nil {
+			utils.Fatalf("The genesis file reuses the chain id of %s with another genesis block, starting a fork without its balances. Use --%s to join %s", preset.Name, preset.Name, preset.Name)
+		}
 	}
 	// Open and initialise both full and light databases
 	stack, _ := makeConfigNode(ctx)
@@ -211,6 +263,9 @@ func initGenesis(ctx *cli.Context) error {
 		if err != nil {
 			utils.Fatalf("Failed to write genesis block: %v", err)
 		}
+		// Re-running init with the same genesis is allowed, track the config it was
+		// last initialised with for the startup check.
+		rawdb.WriteGenesisConfigHash(chaindb, core.ConfigFingerprint(genesis.Config))
 		chaindb.Close()
 		log.Info("Successfully wrote genesis state", "database", name, "hash", hash)
 	}
@@ -471,3 +526,125 @@ func hashish(x string) bool {
 	_, err := strconv.Atoi(x)
 	return err != nil
 }
+
+// verifyChain re-verifies the consensus rules of the blocks in the local chain.
+func verifyChain(ctx *cli.Context) error {
+	if len(ctx.Args()) > 2 {
+		utils.Fatalf("This command accepts at most two arguments.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack)
+	defer chain.Stop()
+
+	engine, ok := chain.Engine().(*congress.Congress)
+	if !ok {
+		utils.Fatalf("The chain isn't run by the congress consensus engine")
+	}
+	first, last := uint64(1), chain.CurrentBlock().NumberU64()
+	if len(ctx.Args()) > 0 {
+		n, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+		if err != nil {
+			utils.Fatalf("Invalid first block number: %v", err)
+		}
+		first = n
+	}
+	if len(ctx.Args()) > 1 {
+		n, err := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+		if err != nil {
+			utils.Fatalf("Invalid last block number: %v", err)
+		}
+		last = n
+	}
+	if first == 0 {
+		first = 1 // The genesis block has nothing to verify
+	}
+	if head := chain.CurrentBlock().NumberU64(); last > head {
+		utils.Fatalf("Last block number %d larger than head block %d", last, head)
+	}
+	if first > last {
+		utils.Fatalf("First block number %d larger than last block %d", first, last)
+	}
+	verifier, err := engine.NewChainVerifier(chain, first-1)
+	if err != nil {
+		utils.Fatalf("Failed to recompute the snapshot of block #%d: %v", first-1, err)
+	}
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		database = state.NewDatabaseWithConfig(db, &trie.Config{Cache: 16})
+		statedb  *state.StateDB // State of the parent block, carried over while re-executing
+		root     common.Hash    // Root of the carried over state referenced in the trie database
+		failed   []*congress.BlockVerification
+		executed uint64
+	)
+	for number := first; number <= last; number++ {
+		block := chain.GetBlockByNumber(number)
+		if block == nil {
+			utils.Fatalf("Block #%d not found", number)
+		}
+		// Open the parent state from the database if it's not carried over
+		if statedb == nil {
+			if parent := chain.GetHeader(block.ParentHash(), number-1); parent != nil {
+				statedb, _ = state.New(parent.Root, database, nil)
+			}
+		}
+		v := verifier.Verify(block, statedb)
+
+		// Re-execute the block to verify the system transaction count and the state
+		if statedb == nil {
+			v.Skip("execution")
+		} else {
+			receipts, _, usedGas, err := chain.Processor().Process(block, statedb, vm.Config{})
+			if err == nil {
+				err = chain.Validator().ValidateState(block, statedb, receipts, usedGas)
+			}
+			if err != nil {
+				v.Fail("execution", err)
+				statedb = nil
+			} else if statedb, err = commitVerifiedState(database, statedb, block, &root); err != nil {
+				log.Warn("Failed to carry over verified state", "number", number, "err", err)
+			} else {
+				executed++
+			}
+		}
+		if !v.Valid() {
+			log.Error("Block verification failed", "number", v.Number, "hash", v.Hash, "signer", v.Signer, "errors", v.Errors)
+			failed = append(failed, v)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Verifying chain", "number", number, "last", last, "failed", len(failed), "executed", executed, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if path := ctx.String(verifyReportFlag.Name); path != "" {
+		out, err := json.MarshalIndent(failed, "", "  ")
+		if err != nil {
+			utils.Fatalf("Failed to encode report: %v", err)
+		}
+		if err := ioutil.WriteFile(path, out, 0644); err != nil {
+			utils.Fatalf("Failed to write report: %v", err)
+		}
+	}
+	fmt.Printf("Verified blocks %d-%d in %v: %d failed, %d re-executed\n", first, last, common.PrettyDuration(time.Since(start)), len(failed), executed)
+	if len(failed) > 0 {
+		return fmt.Errorf("%d blocks failed verification", len(failed))
+	}
+	return nil
+}
+
+// commitVerifiedState commits the state of a verified block into the trie database
+// and reopens it for the next block, releasing the state of the previous one.
+func commitVerifiedState(database state.Database, statedb *state.StateDB, block *types.Block, root *common.Hash) (*state.StateDB, error) {
+	next, err := statedb.Commit(true)
+	if err != nil {
+		return nil, err
+	}
+	database.TrieDB().Reference(next, common.Hash{})
+	if *root != (common.Hash{}) {
+		database.TrieDB().Dereference(*root)
+	}
+	*root = next
+	return state.New(next, database, nil)
+}
@@ -92,6 +92,7 @@ type gethConfig struct {
 	Node     node.Config
 	Ethstats ethstatsConfig
 	Metrics  metrics.Config
+	Log      log.RotateConfig
 }
 
 func loadConfig(file string, cfg *gethConfig) error {
@@ -106,7 +107,41 @@ func loadConfig(file string, cfg *gethConfig) error {
 	if _, ok := err.(*toml.LineError); ok {
 		err = errors.New(file + ", " + err.Error())
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	return validateConfig(file, cfg)
+}
+
+// validateConfig checks the options of the subsystems only configurable through
+// the configuration file.
+func validateConfig(file string, cfg *gethConfig) error {
+	if err := cfg.Eth.Validate(); err != nil {
+		return fmt.Errorf("%s, %v", file, err)
+	}
+	if err := cfg.Log.Validate(); err != nil {
+		return fmt.Errorf("%s, Log: %v", file, err)
+	}
+	return nil
+}
+
+// defaultGethConfig returns the configuration defaults, overridden by the
+// configuration file and the flags.
+func defaultGethConfig() gethConfig {
+	return gethConfig{
+		Eth:     ethconfig.Defaults,
+		Node:    defaultNodeConfig(),
+		Metrics: metrics.DefaultConfig,
+		Log:     defaultLogConfig(),
+	}
+}
+
+// defaultLogConfig returns the log rotation defaults, the log folder being taken
+// from --log.path unless set.
+func defaultLogConfig() log.RotateConfig {
+	cfg := *log.NewRotateConfig()
+	cfg.LogDir = ""
+	return cfg
 }
 
 func defaultNodeConfig() node.Config {
@@ -122,17 +157,16 @@ func defaultNodeConfig() node.Config {
 // makeConfigNode loads geth configuration and creates a blank node instance.
 func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 	// Load defaults.
-	cfg := gethConfig{
-		Eth:     ethconfig.Defaults,
-		Node:    defaultNodeConfig(),
-		Metrics: metrics.DefaultConfig,
-	}
+	cfg := defaultGethConfig()
 
 	// Load config file.
 	if file := ctx.GlobalString(configFileFlag.Name); file != "" {
 		if err := loadConfig(file, &cfg); err != nil {
 			utils.Fatalf("%v", err)
 		}
+		if err := debug.SetLogRotation(cfg.Log); err != nil {
+			utils.Fatalf("%v", err)
+		}
 	}
 
 	// Apply flags.
@@ -161,9 +195,29 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 	if ctx.GlobalIsSet(utils.OverrideArrowGlacierFlag.Name) {
 		cfg.Eth.OverrideArrowGlacier = new(big.Int).SetUint64(ctx.GlobalUint64(utils.OverrideArrowGlacierFlag.Name))
 	}
+	if ctx.GlobalIsSet(utils.OverrideRedCoastFlag.Name) {
+		cfg.Eth.OverrideRedCoast = new(big.Int).SetUint64(ctx.GlobalUint64(utils.OverrideRedCoastFlag.Name))
+	}
+	if ctx.GlobalIsSet(utils.OverrideSophonFlag.Name) {
+		cfg.Eth.OverrideSophon = new(big.Int).SetUint64(ctx.GlobalUint64(utils.OverrideSophonFlag.Name))
+	}
+	if ctx.GlobalIsSet(utils.OverrideProxyCheckFlag.Name) {
+		cfg.Eth.OverrideProxyCheck = new(big.Int).SetUint64(ctx.GlobalUint64(utils.OverrideProxyCheckFlag.Name))
+	}
+	if ctx.GlobalIsSet(utils.OverrideTaurusFlag.Name) {
+		cfg.Eth.OverrideTaurus = new(big.Int).SetUint64(ctx.GlobalUint64(utils.OverrideTaurusFlag.Name))
+	}
+	if ctx.GlobalIsSet(utils.OverrideGenesisCheckFlag.Name) {
+		cfg.Eth.OverrideGenesisCheck = ctx.GlobalBool(utils.OverrideGenesisCheckFlag.Name)
+	}
 	backend, eth := utils.RegisterEthService(stack, &cfg.Eth)
 	debug.ID = enode.PubkeyToIDV4(&cfg.Node.NodeKey().PublicKey).TerminalString()
 
+	// Reload the runtime reloadable options of the configuration file on SIGHUP
+	if file := ctx.GlobalString(configFileFlag.Name); file != "" {
+		watchConfigReload(file, eth)
+	}
+
 	// Configure catalyst.
 	if ctx.GlobalBool(utils.CatalystFlag.Name) {
 		if eth == nil {
@@ -178,6 +232,17 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 	if ctx.GlobalIsSet(utils.GraphQLEnabledFlag.Name) {
 		utils.RegisterGraphQLService(stack, backend, cfg.Node)
 	}
+	// Configure the log export endpoint if requested
+	if ctx.GlobalIsSet(utils.LogExportEnabledFlag.Name) {
+		utils.RegisterLogExportService(ctx, stack, backend, cfg.Node)
+	}
+	// Publish the chain events to the broker if requested
+	if ctx.GlobalIsSet(utils.ChainSinkURLFlag.Name) {
+		if eth == nil {
+			utils.Fatalf("Chain event sink does not work in light client mode.")
+		}
+		utils.RegisterChainSinkService(ctx, stack, backend)
+	}
 	// Add the Ethereum Stats daemon if requested.
 	if cfg.Ethstats.URL != "" {
 		utils.RegisterEthStatsService(stack, backend, cfg.Ethstats.URL)
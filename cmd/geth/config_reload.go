@@ -0,0 +1,58 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/internal/debug"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// watchConfigReload reloads the configuration file on SIGHUP, applying the runtime
+// reloadable options: the log rotation, the tx jam index and the gas price
+// prediction settings. The other options require a restart to take effect.
+func watchConfigReload(file string, backend *eth.Ethereum) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	go func() {
+		for range sighup {
+			reloadConfig(file, backend)
+		}
+	}()
+}
+
+// reloadConfig loads the configuration file and applies its reloadable options,
+// leaving the running configuration as is if it's invalid.
+func reloadConfig(file string, backend *eth.Ethereum) {
+	cfg := defaultGethConfig()
+	if err := loadConfig(file, &cfg); err != nil {
+		log.Error("Failed to reload the configuration file", "err", err)
+		return
+	}
+	if err := debug.SetLogRotation(cfg.Log); err != nil {
+		log.Error("Failed to reload the log rotation", "err", err)
+	}
+	if backend != nil {
+		backend.ReloadConfig(&cfg.Eth)
+	}
+	log.Info("Reloaded the configuration file", "file", file)
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigValidation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geth-config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		config string
+		err    string
+	}{
+		{"[Eth.TxPool.JamConfig]\nJamSecs = 20\nMaxValidPendingSecs = 600\n", ""},
+		{"[Eth.TxPool.JamConfig]\nJamSecs = 20\nMaxValidPendingSecs = 10\n", "Eth.TxPool.JamConfig"},
+		{"[Eth]\nCongressEpochCheck = \"loud\"\n", "Eth.CongressEpochCheck"},
+		{"[Eth.CongressPunishWebhook]\nPreHook = true\n", "Eth.CongressPunishWebhook"},
+		{"[Log]\nMaxSize = 500\nMaxBackups = 3\n", ""},
+		{"[Log]\nMaxSize = 0\n", "Log"},
+	}
+	for i, tt := range tests {
+		file := filepath.Join(dir, "config.toml")
+		if err := ioutil.WriteFile(file, []byte(tt.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg := defaultGethConfig()
+		err := loadConfig(file, &cfg)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("test %d: valid config rejected: %v", i, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("test %d: error mismatch: have %v, want %q", i, err, tt.err)
+		}
+	}
+}
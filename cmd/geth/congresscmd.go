@@ -0,0 +1,288 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// bootstrapSyncedAge is the head block age below which the local chain is
+// considered synced by the validator bootstrap.
+const bootstrapSyncedAge = time.Minute
+
+var (
+	bootstrapOutFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "Path of the validator configuration file written (default = <datadir>/validator.toml)",
+	}
+
+	congressCommand = cli.Command{
+		Name:      "congress",
+		Usage:     "Manage the congress validator node",
+		ArgsUsage: "",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "bootstrap-validator",
+				Usage:     "Import the sealing key and check the node is ready to validate",
+				ArgsUsage: "[<keyfile>]",
+				Action:    utils.MigrateFlags(bootstrapValidator),
+				Flags: []cli.Flag{
+					configFileFlag,
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.AncientFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+					utils.MainnetFlag,
+					utils.HecoMainnetFlag,
+					utils.TestnetFlag,
+					utils.HecoTestnetFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+					utils.MinerEtherbaseFlag,
+					utils.ValidatorSignerFlag,
+					bootstrapOutFlag,
+				},
+				Description: `
+    geth congress bootstrap-validator [options] [<keyfile>]
+
+Sets a node up as a congress validator in one go, before it's started:
+
+ - imports the sealing key from <keyfile> into the keystore, either an encrypted
+   keystore file or an unencrypted private key in hexadecimal format,
+ - sets the etherbase to the validator account,
+ - configures the signing, either by the remote signer given with --congress.signer
+   (clef, HSM/KMS backed signers) or by unlocking the imported account,
+ - checks the validator is staked in the validators contract and whether it's in
+   the active validator set as of the local head block,
+
+then writes the node configuration and prints a readiness checklist along with
+the command line to start the validator with.
+
+The keyfile may be omitted if the key is held by the remote signer, in which
+case the validator address must be given with --miner.etherbase.
+`,
+			},
+			{
+				Name:      "export-vectors",
+				Usage:     "Export the consensus test vectors",
+				ArgsUsage: "<file>",
+				Action:    utils.MigrateFlags(exportVectors),
+				Description: `
+    geth congress export-vectors <file>
+
+Builds a congress chain out of deterministic validator keys, one of them never
+sealing, and writes the outcome of the consensus rules on its blocks in JSON: the
+signers, the state roots, the validator snapshots, the epoch transitions and the
+punishments, along with blocks breaking the rules and their expected rejections.
+
+The vectors let alternative implementations and auditors check the congress rules
+without running this node, see verify-vectors.
+`,
+			},
+			{
+				Name:      "verify-vectors",
+				Usage:     "Verify the node against the consensus test vectors",
+				ArgsUsage: "<file>",
+				Action:    utils.MigrateFlags(verifyVectors),
+				Description: `
+    geth congress verify-vectors <file>
+
+Imports the blocks of the consensus test vectors into a fresh in-memory chain,
+checking the outcome of each against the expected one, and that the invalid blocks
+are rejected for the expected reason.
+`,
+			},
+		},
+	}
+)
+
+// bootstrapValidator imports the sealing key, configures the validator and
+// prints the readiness checklist.
+func bootstrapValidator(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		utils.Fatalf("This command accepts at most one argument.")
+	}
+	stack, cfg := makeConfigNode(ctx)
+	defer stack.Close()
+
+	var (
+		validator common.Address
+		remote    = len(cfg.Eth.ValidatorSigner.Endpoints) > 0
+		imported  string
+	)
+	if keyfile := ctx.Args().First(); keyfile != "" {
+		ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+		account, err := importSealingKey(ctx, ks, keyfile)
+		switch {
+		case errors.Is(err, keystore.ErrAccountAlreadyExists):
+			imported = "sealing key already in the keystore"
+		case err != nil:
+			utils.Fatalf("Could not import the sealing key: %v", err)
+		default:
+			imported = "sealing key imported into the keystore"
+		}
+		validator = account.Address
+	} else {
+		if !remote {
+			utils.Fatalf("A keyfile must be given unless the key is held by a remote signer (--%s)", utils.ValidatorSignerFlag.Name)
+		}
+		if !ctx.GlobalIsSet(utils.MinerEtherbaseFlag.Name) {
+			utils.Fatalf("The validator address must be given with --%s for a remote signer", utils.MinerEtherbaseFlag.Name)
+		}
+		validator = cfg.Eth.Miner.Etherbase
+		imported = "sealing key held by the remote signer"
+	}
+	cfg.Eth.Miner.Etherbase = validator
+
+	// Write the validator configuration out before the checks, they only tell
+	// whether the node may seal right away
+	out := ctx.String(bootstrapOutFlag.Name)
+	if out == "" {
+		out = filepath.Join(stack.DataDir(), "validator.toml")
+	}
+	cfg.Eth.Genesis = nil
+	config, err := tomlSettings.Marshal(&cfg)
+	if err != nil {
+		utils.Fatalf("Failed to encode the configuration: %v", err)
+	}
+	if err := ioutil.WriteFile(out, config, 0644); err != nil {
+		utils.Fatalf("Failed to write the configuration: %v", err)
+	}
+
+	chain, _ := utils.MakeChain(ctx, stack)
+	defer chain.Stop()
+
+	engine, ok := chain.Engine().(*congress.Congress)
+	if !ok {
+		utils.Fatalf("The chain isn't run by the congress consensus engine")
+	}
+	fmt.Printf("Validator %s readiness:\n", validator.Hex())
+	checkItem(true, imported)
+	checkItem(true, "etherbase set to the validator")
+	if remote {
+		checkItem(true, "signing by the remote signer %s", strings.Join(cfg.Eth.ValidatorSigner.Endpoints, ", "))
+	} else {
+		checkItem(true, "signing by the unlocked keystore account")
+	}
+	head := chain.CurrentHeader()
+	age := time.Since(time.Unix(int64(head.Time), 0)).Round(time.Second)
+	checkItem(age < bootstrapSyncedAge, "chain synced (head #%d, %v old)", head.Number, age)
+
+	statedb, err := chain.StateAt(head.Root)
+	if err != nil {
+		checkItem(false, "staked in the validators contract (state of #%d unavailable: %v)", head.Number, err)
+	} else if readiness, err := engine.CheckValidator(chain, head, statedb, validator); err != nil {
+		checkItem(false, "staked in the validators contract (check failed: %v)", err)
+	} else {
+		checkItem(readiness.Staked, "staked in the validators contract (#%d)", readiness.Number)
+		checkItem(readiness.Active, "in the active validator set (%d validators)", readiness.Validators)
+	}
+
+	fmt.Printf("\nConfiguration written to %s, start the validator with:\n\n", out)
+	if remote {
+		fmt.Printf("    geth --config %s --mine\n\n", out)
+	} else {
+		fmt.Printf("    geth --config %s --mine --unlock %s --password <passwordfile>\n\n", out, validator.Hex())
+	}
+	return nil
+}
+
+// importSealingKey imports the sealing key from an encrypted keystore file, kept
+// locked with its passphrase, or from an unencrypted hex private key, locked with
+// a new passphrase. The account is returned along with ErrAccountAlreadyExists if
+// the key was in the keystore already.
+func importSealingKey(ctx *cli.Context, ks *keystore.KeyStore, keyfile string) (accounts.Account, error) {
+	keyjson, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	if json.Valid(keyjson) {
+		passphrase := utils.GetPassPhraseWithList("Unlocking the encrypted key file.", false, 0, utils.MakePasswordList(ctx))
+		return ks.Import(keyjson, passphrase, passphrase)
+	}
+	key, err := crypto.LoadECDSA(keyfile)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	passphrase := utils.GetPassPhraseWithList("Your sealing key is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+	return ks.ImportECDSA(key, passphrase)
+}
+
+// checkItem prints a readiness checklist item.
+func checkItem(ok bool, format string, args ...interface{}) {
+	mark := " "
+	if ok {
+		mark = "x"
+	}
+	fmt.Printf("  [%s] %s\n", mark, fmt.Sprintf(format, args...))
+}
+
+// exportVectors generates the consensus test vectors and writes them out.
+func exportVectors(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	suite, err := congress.GenerateVectors(congress.DefaultVectorConfig)
+	if err != nil {
+		utils.Fatalf("Failed to generate the vectors: %v", err)
+	}
+	blob, err := json.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode the vectors: %v", err)
+	}
+	if err := ioutil.WriteFile(ctx.Args().First(), blob, 0644); err != nil {
+		utils.Fatalf("Failed to write the vectors: %v", err)
+	}
+	fmt.Printf("Exported %d blocks and %d invalid blocks to %s\n", len(suite.Blocks), len(suite.Invalid), ctx.Args().First())
+	return nil
+}
+
+// verifyVectors checks the node against the consensus test vectors.
+func verifyVectors(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	blob, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read the vectors: %v", err)
+	}
+	suite := new(congress.VectorSuite)
+	if err := json.Unmarshal(blob, suite); err != nil {
+		utils.Fatalf("Failed to decode the vectors: %v", err)
+	}
+	if err := congress.VerifyVectors(suite); err != nil {
+		utils.Fatalf("Vectors verification failed: %v", err)
+	}
+	fmt.Printf("Verified %d blocks and %d invalid blocks\n", len(suite.Blocks), len(suite.Invalid))
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the validator bootstrap imports the sealing key, writes the validator
+// configuration and prints the readiness checklist of the genesis validator.
+func TestBootstrapValidator(t *testing.T) {
+	datadir := tmpdir(t)
+	defer os.RemoveAll(datadir)
+
+	// Initialize a congress chain sealed by the validator of the key
+	validator := common.HexToAddress("0xfcad0b19bb29d4674531d6f115237e16afce377c")
+	extra := make([]byte, 32+common.AddressLength+65)
+	copy(extra[32:], validator.Bytes())
+
+	genesis, err := json.Marshal(&core.Genesis{
+		Config:     params.AllCongressProtocolChanges,
+		ExtraData:  extra,
+		GasLimit:   params.GenesisGasLimit,
+		Difficulty: big.NewInt(1),
+		Alloc:      core.DefaultGenesisBlock().Alloc,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode genesis: %v", err)
+	}
+	files := map[string]string{
+		"genesis.json": string(genesis),
+		"key.prv":      "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		"password.txt": "foobar",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(datadir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	runGeth(t, "--datadir", datadir, "init", filepath.Join(datadir, "genesis.json")).WaitExit()
+
+	// The genesis validator is active, but not staked until the system contracts
+	// are initialized, and the genesis is far from synced
+	bootstrap := func(imported string) {
+		geth := runGeth(t, "congress", "bootstrap-validator", "--datadir", datadir, "--lightkdf",
+			"--password", filepath.Join(datadir, "password.txt"), filepath.Join(datadir, "key.prv"))
+		geth.ExpectRegexp(`(?s).*Validator 0xFCAd0B19bB29D4674531d6f115237E16AfCE377c readiness:
+  \[x\] ` + imported + `
+  \[x\] etherbase set to the validator
+  \[x\] signing by the unlocked keystore account
+  \[ \] chain synced \(head #0, .* old\)
+  \[ \] staked in the validators contract \(#0\)
+  \[x\] in the active validator set \(1 validators\)
+
+Configuration written to .*validator.toml, start the validator with:
+
+    geth --config .*validator.toml --mine --unlock 0xFCAd0B19bB29D4674531d6f115237E16AfCE377c --password <passwordfile>
+`)
+		geth.ExpectExit()
+	}
+	bootstrap("sealing key imported into the keystore")
+	bootstrap("sealing key already in the keystore")
+
+	config, err := ioutil.ReadFile(filepath.Join(datadir, "validator.toml"))
+	if err != nil {
+		t.Fatalf("failed to read validator configuration: %v", err)
+	}
+	if !strings.Contains(string(config), `Etherbase = "0xfcad0b19bb29d4674531d6f115237e16afce377c"`) {
+		t.Errorf("validator configuration missing the etherbase:\n%s", config)
+	}
+}
+
+// Tests that the validator bootstrap requires the validator address if the key is
+// held by a remote signer.
+func TestBootstrapValidatorRemote(t *testing.T) {
+	geth := runGeth(t, "congress", "bootstrap-validator", "--congress.signer", "http://localhost:8550")
+	geth.ExpectRegexp(`(?s).*Fatal: The validator address must be given with --miner.etherbase for a remote signer`)
+	geth.ExpectExit()
+
+	geth = runGeth(t, "congress", "bootstrap-validator")
+	geth.ExpectRegexp(`(?s).*Fatal: A keyfile must be given unless the key is held by a remote signer \(--congress.signer\)`)
+	geth.ExpectExit()
+}
@@ -120,8 +120,8 @@ func remoteConsole(ctx *cli.Context) error {
 			path = ctx.GlobalString(utils.DataDirFlag.Name)
 		}
 		if path != "" {
-			if ctx.GlobalBool(utils.TestnetFlag.Name) {
-				path = filepath.Join(path, "testnet")
+			if preset := utils.NetworkPreset(ctx); preset != nil && preset.DataDir != "" {
+				path = filepath.Join(path, preset.DataDir)
 			}
 		}
 		endpoint = fmt.Sprintf("%s/geth.ipc", path)
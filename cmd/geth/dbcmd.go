@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -69,6 +70,8 @@ Remove blockchain and state databases`,
 			dbDumpFreezerIndex,
 			dbImportCmd,
 			dbExportCmd,
+			dbMigrateCmd,
+			dbCompressCmd,
 		},
 	}
 	dbInspectCmd = cli.Command{
@@ -80,7 +83,9 @@ Remove blockchain and state databases`,
 			utils.AncientFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
 			utils.TestnetFlag,
+			utils.HecoTestnetFlag,
 		},
 		Usage:       "Inspect the storage size for each type of data in the database",
 		Description: `This commands iterates the entire database. If the optional 'prefix' and 'start' arguments are provided, then the iteration is limited to the given subset of data.`,
@@ -93,7 +98,9 @@ Remove blockchain and state databases`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
 			utils.TestnetFlag,
+			utils.HecoTestnetFlag,
 		},
 	}
 	dbCompactCmd = cli.Command{
@@ -104,13 +111,57 @@ Remove blockchain and state databases`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
 			utils.TestnetFlag,
+			utils.HecoTestnetFlag,
 			utils.CacheFlag,
 			utils.CacheDatabaseFlag,
 		},
 		Description: `This command performs a database compaction. 
 WARNING: This operation may take a very long time to finish, and may cause database
 corruption if it is aborted during execution'!`,
+	}
+	dbMigrateCmd = cli.Command{
+		Action: utils.MigrateFlags(dbMigrate),
+		Name:   "migrate",
+		Usage:  "Migrate the leveldb chain database to pebble",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
+			utils.TestnetFlag,
+			utils.HecoTestnetFlag,
+			utils.CacheFlag,
+			utils.CacheDatabaseFlag,
+		},
+		Description: `This command copies the key-value store of the chain database from leveldb
+to pebble, the node must be stopped. The ancient store is copied over as is, unless
+stored elsewhere with --datadir.ancient, and the leveldb database is kept whole as a
+backup next to the migrated one, to be removed once the node runs fine on pebble.
+The disk space needed is up to the size of the chain database.`,
+	}
+	dbCompressCmd = cli.Command{
+		Action:    utils.MigrateFlags(dbCompress),
+		Name:      "compress",
+		Usage:     "Re-encode the stored block bodies and receipts with a compression codec",
+		ArgsUsage: "<none|snappy|zstd>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
+			utils.TestnetFlag,
+			utils.HecoTestnetFlag,
+			utils.CacheFlag,
+			utils.CacheDatabaseFlag,
+		},
+		Description: `This command re-encodes the block bodies and receipts in the key-value store
+with the given codec, the node must be stopped. It can be interrupted and resumed
+later on. The ancient store keeps its own compression. Run the node with the same
+--db.compression for the new blocks to be written with the codec too.`,
 	}
 	dbGetCmd = cli.Command{
 		Action:    utils.MigrateFlags(dbGet),
@@ -121,7 +172,9 @@ corruption if it is aborted during execution'!`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
 			utils.TestnetFlag,
+			utils.HecoTestnetFlag,
 		},
 		Description: "This command looks up the specified database key from the database.",
 	}
@@ -134,7 +187,9 @@ corruption if it is aborted during execution'!`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
 			utils.TestnetFlag,
+			utils.HecoTestnetFlag,
 		},
 		Description: `This command deletes the specified database key from the database. 
 WARNING: This is a low-level operation which may cause database corruption!`,
@@ -148,7 +203,9 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
 			utils.TestnetFlag,
+			utils.HecoTestnetFlag,
 		},
 		Description: `This command sets a given database key to the given value. 
 WARNING: This is a low-level operation which may cause database corruption!`,
@@ -162,7 +219,9 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
 			utils.TestnetFlag,
+			utils.HecoTestnetFlag,
 		},
 		Description: "This command looks up the specified database key from the database.",
 	}
@@ -175,7 +234,9 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
 			utils.TestnetFlag,
+			utils.HecoTestnetFlag,
 		},
 		Description: "This command displays information about the freezer index.",
 	}
@@ -188,6 +249,7 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
 		},
 		Description: "The import command imports the specific chain data from an RLP encoded stream.",
 	}
@@ -200,6 +262,7 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 			utils.DataDirFlag,
 			utils.SyncModeFlag,
 			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
 		},
 		Description: "Exports the specified chain data to an RLP encoded stream, optionally gzip-compressed.",
 	}
@@ -340,6 +403,146 @@ func dbCompact(ctx *cli.Context) error {
 	return nil
 }
 
+// dbMigrate copies the leveldb chain database into a pebble one, swapping them
+// once done.
+func dbMigrate(ctx *cli.Context) error {
+	if !rawdb.PebbleSupported {
+		return errors.New("pebble is only supported on 64 bit platforms")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	var (
+		src    = stack.ResolvePath("chaindata")
+		dst    = src + ".pebble"
+		backup = src + ".leveldb"
+	)
+	if engine := rawdb.PreexistingDatabase(src); engine != rawdb.DBLeveldb {
+		return fmt.Errorf("no leveldb chain database to migrate in %s", src)
+	}
+	for _, path := range []string{dst, backup} {
+		if common.FileExist(path) {
+			return fmt.Errorf("%s already exists, remove it first", path)
+		}
+	}
+	var (
+		cache   = ctx.GlobalInt(utils.CacheFlag.Name) * ctx.GlobalInt(utils.CacheDatabaseFlag.Name) / 100
+		handles = utils.MakeDatabaseHandles()
+	)
+	srcdb, err := rawdb.OpenKeyValueStore(rawdb.DBLeveldb, src, cache/2, handles/2, "", true)
+	if err != nil {
+		return err
+	}
+	dstdb, err := rawdb.OpenKeyValueStore(rawdb.DBPebble, dst, cache/2, handles/2, "", false)
+	if err != nil {
+		srcdb.Close()
+		return err
+	}
+	start := time.Now()
+	it := srcdb.NewIterator(nil, nil)
+	keys, size, err := rawdb.CopyKeyValueStore(dstdb, it, nil)
+	it.Release()
+	if err != nil {
+		dstdb.Close()
+		srcdb.Close()
+		return fmt.Errorf("migration failed, remove %s before retrying: %v", dst, err)
+	}
+	srcdb.Close()
+	if err := dstdb.Close(); err != nil {
+		return err
+	}
+	// Copy the ancient store into the migrated database unless stored elsewhere,
+	// the backup keeping its own to be usable as is
+	if !ctx.GlobalIsSet(utils.AncientFlag.Name) {
+		if ancient := filepath.Join(src, "ancient"); common.FileExist(ancient) {
+			log.Info("Copying ancient store", "path", ancient)
+			if err := copyAncientStore(filepath.Join(dst, "ancient"), ancient); err != nil {
+				return fmt.Errorf("ancient store copy failed, remove %s before retrying: %v", dst, err)
+			}
+		}
+	}
+	if err := os.Rename(src, backup); err != nil {
+		return err
+	}
+	if err := os.Rename(dst, src); err != nil {
+		return err
+	}
+	log.Info("Migrated chain database to pebble", "keys", keys, "size", size,
+		"elapsed", common.PrettyDuration(time.Since(start)), "backup", backup)
+	return nil
+}
+
+// copyAncientStore copies the files of the ancient store in src into dst, leaving
+// out the lock of the freezer.
+func copyAncientStore(dst, src string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case rel == "FLOCK" || !info.Mode().IsRegular():
+			return nil
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Sync(); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// dbCompress re-encodes the block bodies and receipts of the chain database with
+// the given codec.
+func dbCompress(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	codec, err := rawdb.ParseChainDataCodec(ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	defer stack.Close()
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during compression, stopping at next value")
+		}
+		close(stop)
+	}()
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	return rawdb.MigrateChainDataCodec(db, codec, stop)
+}
+
 // dbGet shows the value of a given database key
 func dbGet(ctx *cli.Context) error {
 	if ctx.NArg() != 1 {
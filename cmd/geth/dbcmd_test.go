@@ -0,0 +1,74 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests that the ancient store is copied whole into the migrated database, the
+// source one being left untouched as part of the backup.
+func TestCopyAncientStore(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	var (
+		src   = filepath.Join(dir, "chaindata", "ancient")
+		dst   = filepath.Join(dir, "chaindata.pebble", "ancient")
+		files = map[string][]byte{
+			"headers.0000.cdat": bytes.Repeat([]byte{0x01}, 1024),
+			"headers.cidx":      {0x02, 0x03},
+			"bodies.0000.cdat":  {},
+		}
+	)
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatalf("failed to create ancient store: %v", err)
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(src, name), data, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "FLOCK"), nil, 0644); err != nil {
+		t.Fatalf("failed to write lock: %v", err)
+	}
+	if err := copyAncientStore(dst, src); err != nil {
+		t.Fatalf("failed to copy ancient store: %v", err)
+	}
+	for name, data := range files {
+		for _, path := range []string{src, dst} {
+			have, err := ioutil.ReadFile(filepath.Join(path, name))
+			if err != nil {
+				t.Fatalf("failed to read %s in %s: %v", name, path, err)
+			}
+			if !bytes.Equal(have, data) {
+				t.Errorf("%s in %s mismatch: have %d bytes, want %d", name, path, len(have), len(data))
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "FLOCK")); !os.IsNotExist(err) {
+		t.Errorf("freezer lock copied: %v", err)
+	}
+	// A leftover copy is never overwritten
+	if err := copyAncientStore(dst, src); err == nil {
+		t.Errorf("copy over existing ancient store succeeded")
+	}
+}
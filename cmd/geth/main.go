@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/console/prompt"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -65,6 +66,12 @@ var (
 		utils.BootnodesFlag,
 		utils.DataDirFlag,
 		utils.AncientFlag,
+		utils.DBEngineFlag,
+		utils.DBCompressionFlag,
+		utils.DBSlowQueryFlag,
+		utils.BackupIntervalFlag,
+		utils.BackupDirFlag,
+		utils.BackupKeepFlag,
 		utils.MinFreeDiskSpaceFlag,
 		utils.KeyStoreDirFlag,
 		utils.ExternalSignerFlag,
@@ -72,6 +79,11 @@ var (
 		utils.USBFlag,
 		utils.SmartCardDaemonPathFlag,
 		utils.OverrideArrowGlacierFlag,
+		utils.OverrideRedCoastFlag,
+		utils.OverrideSophonFlag,
+		utils.OverrideProxyCheckFlag,
+		utils.OverrideTaurusFlag,
+		utils.OverrideGenesisCheckFlag,
 		utils.EthashCacheDirFlag,
 		utils.EthashCachesInMemoryFlag,
 		utils.EthashCachesOnDiskFlag,
@@ -91,11 +103,30 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolPrivateFlag,
+		utils.TxPoolPrivatePeersFlag,
+		utils.TxPoolResubmitFlag,
+		utils.TxPoolResubmitBlocksFlag,
+		utils.TxPoolResubmitPriceBumpFlag,
+		utils.TxPoolResubmitMaxBumpsFlag,
+		utils.TxPoolResubmitMaxPriceFlag,
+		utils.CongressEpochCheckFlag,
+		utils.CongressSysCodeCheckFlag,
+		utils.CongressPreannounceFlag,
+		utils.CongressMaxParentAgeFlag,
+		utils.CongressVanityFlag,
+		utils.CongressVanityTagFlag,
+		utils.CongressBlacklistFileFlag,
+		utils.CongressBlacklistWebhookFlag,
+		utils.ValidatorSignerFlag,
+		utils.ValidatorSignerTimeoutFlag,
+		utils.ValidatorSignerRetriesFlag,
 		utils.SyncModeFlag,
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag,
+		utils.ProofWitnessDepthFlag,
 		utils.LightServeFlag,
 		utils.LightIngressFlag,
 		utils.LightEgressFlag,
@@ -107,6 +138,7 @@ var (
 		utils.UltraLightOnlyAnnounceFlag,
 		utils.LightNoSyncServeFlag,
 		utils.WhitelistFlag,
+		utils.SyncTargetFlag,
 		utils.BloomFilterSizeFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
@@ -117,9 +149,24 @@ var (
 		utils.CacheSnapshotFlag,
 		utils.CacheNoPrefetchFlag,
 		utils.CachePreimagesFlag,
+		utils.CacheBlockMemFlag,
+		utils.CacheBlockMemCapFlag,
+		utils.GoPoolBloomFlag,
+		utils.GoPoolPreloadFlag,
+		utils.GoPoolVerifyFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
+		utils.TxAnnouncesFlag,
+		utils.TxUnderpricedSetFlag,
+		utils.TxFloodLimitFlag,
+		utils.KnownTxsFlag,
+		utils.StrictForkIDFlag,
+		utils.ValidatorPeersFlag,
+		utils.EgressBlockCapFlag,
+		utils.EgressTxCapFlag,
+		utils.LatencyPeerRatioFlag,
+		utils.LatencyThresholdFlag,
 		utils.MiningEnabledFlag,
 		utils.MinerThreadsFlag,
 		utils.MinerNotifyFlag,
@@ -129,6 +176,8 @@ var (
 		utils.MinerEtherbaseFlag,
 		utils.MinerExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
+		utils.MinerRecommitAdaptiveFlag,
+		utils.MinerAccessListPrefetchFlag,
 		utils.MinerNoVerifyFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
@@ -138,16 +187,24 @@ var (
 		utils.NodeKeyHexFlag,
 		utils.DNSDiscoveryFlag,
 		utils.MainnetFlag,
+		utils.HecoMainnetFlag,
 		utils.DeveloperFlag,
 		utils.DeveloperPeriodFlag,
 		utils.TestnetFlag,
+		utils.HecoTestnetFlag,
 		utils.VMEnableDebugFlag,
+		utils.VMProfileFlag,
+		utils.BlockSTMResearchFlag,
 		utils.NetworkIdFlag,
 		utils.EthStatsURLFlag,
+		utils.ChainSinkURLFlag,
+		utils.ChainSinkPrefixFlag,
+		utils.ChainSinkDepthFlag,
 		utils.FakePoWFlag,
 		utils.NoCompactionFlag,
 		utils.GpoBlocksFlag,
 		utils.GpoPercentileFlag,
+		utils.GpoMinGasPriceFlag,
 		utils.GpoMaxGasPriceFlag,
 		utils.GpoIgnoreGasPriceFlag,
 		utils.MinerNotifyFullFlag,
@@ -164,6 +221,9 @@ var (
 		utils.GraphQLEnabledFlag,
 		utils.GraphQLCORSDomainFlag,
 		utils.GraphQLVirtualHostsFlag,
+		utils.LogExportEnabledFlag,
+		utils.LogExportRateFlag,
+		utils.LogExportStreamsFlag,
 		utils.HTTPApiFlag,
 		utils.HTTPPathPrefixFlag,
 		utils.WSEnabledFlag,
@@ -176,7 +236,17 @@ var (
 		utils.IPCPathFlag,
 		utils.InsecureUnlockAllowedFlag,
 		utils.RPCGlobalGasCapFlag,
+		utils.RPCStateCacheFlag,
 		utils.RPCGlobalEVMTimeoutFlag,
+		utils.RPCEstimateGasCapFlag,
+		utils.RPCEstimateEVMTimeoutFlag,
+		utils.RPCTraceGasCapFlag,
+		utils.RPCTraceEVMTimeoutFlag,
+		utils.RPCHeavyCallLimitFlag,
+		utils.RPCResponseCacheFlag,
+		utils.RPCResponseCacheTTLFlag,
+		utils.GovernorFlag,
+		utils.GovernorThresholdFlag,
 		utils.RPCGlobalTxFeeCapFlag,
 		utils.AllowUnprotectedTxs,
 	}
@@ -213,10 +283,15 @@ func init() {
 		exportPreimagesCommand,
 		removedbCommand,
 		dumpCommand,
+		verifyChainCommand,
 		dumpGenesisCommand,
+		// See bootstrapcmd.go:
+		downloadSnapshotCommand,
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
+		// See congresscmd.go:
+		congressCommand,
 		// See consolecmd.go:
 		consoleCommand,
 		attachCommand,
@@ -265,9 +340,10 @@ func main() {
 // This function should be called before launching devp2p stack.
 func prepare(ctx *cli.Context) {
 	// If we're running a known preset, log it for convenience.
+	preset := utils.NetworkPreset(ctx)
 	switch {
-	case ctx.GlobalIsSet(utils.TestnetFlag.Name):
-		log.Info("Starting Geth on testnet...")
+	case preset != nil && preset != core.HecoMainnet:
+		log.Info(fmt.Sprintf("Starting Geth on %s...", preset.Name))
 	case ctx.GlobalIsSet(utils.DeveloperFlag.Name):
 		log.Info("Starting Geth in ephemeral dev mode...")
 	case !ctx.GlobalIsSet(utils.NetworkIdFlag.Name):
@@ -276,7 +352,7 @@ func prepare(ctx *cli.Context) {
 	// If we're a full node on mainnet without --cache specified, bump default cache allowance
 	if ctx.GlobalString(utils.SyncModeFlag.Name) != "light" && !ctx.GlobalIsSet(utils.CacheFlag.Name) && !ctx.GlobalIsSet(utils.NetworkIdFlag.Name) {
 		// Make sure we're not on any supported preconfigured testnet either
-		if !ctx.GlobalIsSet(utils.TestnetFlag.Name) && !ctx.GlobalIsSet(utils.DeveloperFlag.Name) {
+		if (preset == nil || preset == core.HecoMainnet) && !ctx.GlobalIsSet(utils.DeveloperFlag.Name) {
 			// Nope, we're really on mainnet. Bump that cache up!
 			log.Info("Bumping default cache on mainnet", "provided", ctx.GlobalInt(utils.CacheFlag.Name), "updated", 4096)
 			ctx.GlobalSet(utils.CacheFlag.Name, strconv.Itoa(4096))
@@ -288,6 +364,9 @@ func prepare(ctx *cli.Context) {
 		ctx.GlobalSet(utils.CacheFlag.Name, strconv.Itoa(128))
 	}
 
+	// Size the goroutine pools of the block processing
+	utils.SetupGoPools(ctx)
+
 	// Start metrics export if enabled
 	utils.SetupMetrics(ctx)
 
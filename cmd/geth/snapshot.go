@@ -62,6 +62,7 @@ var (
 					utils.DataDirFlag,
 					utils.AncientFlag,
 					utils.TestnetFlag,
+					utils.HecoTestnetFlag,
 					utils.CacheTrieJournalFlag,
 					utils.BloomFilterSizeFlag,
 				},
@@ -90,6 +91,7 @@ the trie clean cache with default directory will be deleted.
 					utils.DataDirFlag,
 					utils.AncientFlag,
 					utils.TestnetFlag,
+					utils.HecoTestnetFlag,
 				},
 				Description: `
 geth snapshot verify-state <state-root>
@@ -108,6 +110,7 @@ In other words, this command does the snapshot to trie conversion.
 					utils.DataDirFlag,
 					utils.AncientFlag,
 					utils.TestnetFlag,
+					utils.HecoTestnetFlag,
 				},
 				Description: `
 geth snapshot traverse-state <state-root>
@@ -128,6 +131,7 @@ It's also usable without snapshot enabled.
 					utils.DataDirFlag,
 					utils.AncientFlag,
 					utils.TestnetFlag,
+					utils.HecoTestnetFlag,
 				},
 				Description: `
 geth snapshot traverse-rawstate <state-root>
@@ -149,6 +153,7 @@ It's also usable without snapshot enabled.
 					utils.DataDirFlag,
 					utils.AncientFlag,
 					utils.TestnetFlag,
+					utils.HecoTestnetFlag,
 					utils.ExcludeCodeFlag,
 					utils.ExcludeStorageFlag,
 					utils.StartKeyFlag,
@@ -36,21 +36,34 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			configFileFlag,
 			utils.DataDirFlag,
 			utils.AncientFlag,
+			utils.DBEngineFlag,
+			utils.DBCompressionFlag,
+			utils.DBSlowQueryFlag,
+			utils.BackupIntervalFlag,
+			utils.BackupDirFlag,
+			utils.BackupKeepFlag,
 			utils.MinFreeDiskSpaceFlag,
 			utils.KeyStoreDirFlag,
 			utils.USBFlag,
 			utils.SmartCardDaemonPathFlag,
 			utils.NetworkIdFlag,
 			utils.MainnetFlag,
+			utils.HecoMainnetFlag,
 			utils.TestnetFlag,
+			utils.HecoTestnetFlag,
 			utils.SyncModeFlag,
 			utils.ExitWhenSyncedFlag,
 			utils.GCModeFlag,
 			utils.TxLookupLimitFlag,
+			utils.ProofWitnessDepthFlag,
 			utils.EthStatsURLFlag,
+			utils.ChainSinkURLFlag,
+			utils.ChainSinkPrefixFlag,
+			utils.ChainSinkDepthFlag,
 			utils.IdentityFlag,
 			utils.LightKDFFlag,
 			utils.WhitelistFlag,
+			utils.SyncTargetFlag,
 		},
 	},
 	{
@@ -102,6 +115,29 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolPrivateFlag,
+			utils.TxPoolPrivatePeersFlag,
+			utils.TxPoolResubmitFlag,
+			utils.TxPoolResubmitBlocksFlag,
+			utils.TxPoolResubmitPriceBumpFlag,
+			utils.TxPoolResubmitMaxBumpsFlag,
+			utils.TxPoolResubmitMaxPriceFlag,
+		},
+	},
+	{
+		Name: "CONGRESS",
+		Flags: []cli.Flag{
+			utils.CongressEpochCheckFlag,
+			utils.CongressSysCodeCheckFlag,
+			utils.CongressPreannounceFlag,
+			utils.CongressMaxParentAgeFlag,
+			utils.CongressVanityFlag,
+			utils.CongressVanityTagFlag,
+			utils.CongressBlacklistFileFlag,
+			utils.CongressBlacklistWebhookFlag,
+			utils.ValidatorSignerFlag,
+			utils.ValidatorSignerTimeoutFlag,
+			utils.ValidatorSignerRetriesFlag,
 		},
 	},
 	{
@@ -116,6 +152,11 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.CacheSnapshotFlag,
 			utils.CacheNoPrefetchFlag,
 			utils.CachePreimagesFlag,
+			utils.CacheBlockMemFlag,
+			utils.CacheBlockMemCapFlag,
+			utils.GoPoolBloomFlag,
+			utils.GoPoolPreloadFlag,
+			utils.GoPoolVerifyFlag,
 		},
 	},
 	{
@@ -148,8 +189,21 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.GraphQLEnabledFlag,
 			utils.GraphQLCORSDomainFlag,
 			utils.GraphQLVirtualHostsFlag,
+			utils.LogExportEnabledFlag,
+			utils.LogExportRateFlag,
+			utils.LogExportStreamsFlag,
 			utils.RPCGlobalGasCapFlag,
+			utils.RPCStateCacheFlag,
 			utils.RPCGlobalEVMTimeoutFlag,
+			utils.RPCEstimateGasCapFlag,
+			utils.RPCEstimateEVMTimeoutFlag,
+			utils.RPCTraceGasCapFlag,
+			utils.RPCTraceEVMTimeoutFlag,
+			utils.RPCHeavyCallLimitFlag,
+			utils.RPCResponseCacheFlag,
+			utils.RPCResponseCacheTTLFlag,
+			utils.GovernorFlag,
+			utils.GovernorThresholdFlag,
 			utils.RPCGlobalTxFeeCapFlag,
 			utils.AllowUnprotectedTxs,
 			utils.JSpathFlag,
@@ -165,6 +219,16 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.ListenPortFlag,
 			utils.MaxPeersFlag,
 			utils.MaxPendingPeersFlag,
+			utils.TxAnnouncesFlag,
+			utils.TxUnderpricedSetFlag,
+			utils.TxFloodLimitFlag,
+			utils.KnownTxsFlag,
+			utils.StrictForkIDFlag,
+			utils.ValidatorPeersFlag,
+			utils.EgressBlockCapFlag,
+			utils.EgressTxCapFlag,
+			utils.LatencyPeerRatioFlag,
+			utils.LatencyThresholdFlag,
 			utils.NATFlag,
 			utils.NoDiscoverFlag,
 			utils.DiscoveryV5Flag,
@@ -185,6 +249,8 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.MinerEtherbaseFlag,
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
+			utils.MinerRecommitAdaptiveFlag,
+			utils.MinerAccessListPrefetchFlag,
 			utils.MinerNoVerifyFlag,
 		},
 	},
@@ -193,6 +259,7 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 		Flags: []cli.Flag{
 			utils.GpoBlocksFlag,
 			utils.GpoPercentileFlag,
+			utils.GpoMinGasPriceFlag,
 			utils.GpoMaxGasPriceFlag,
 			utils.GpoIgnoreGasPriceFlag,
 		},
@@ -201,6 +268,7 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 		Name: "VIRTUAL MACHINE",
 		Flags: []cli.Flag{
 			utils.VMEnableDebugFlag,
+			utils.VMProfileFlag,
 		},
 	},
 	{
@@ -253,6 +321,10 @@ func init() {
 			// Only add uncategorized flags if they are not deprecated
 			var uncategorized []cli.Flag
 			for _, flag := range data.(*cli.App).Flags {
+				// Hidden flags are experimental ones, not listed
+				if f, ok := flag.(cli.BoolFlag); ok && f.Hidden {
+					continue
+				}
 				if _, ok := categorized[flag.String()]; !ok {
 					if _, ok := deprecated[flag.String()]; !ok {
 						uncategorized = append(uncategorized, flag)
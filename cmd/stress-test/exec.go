@@ -10,6 +10,8 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
 	"gopkg.in/urfave/cli.v1"
@@ -21,6 +23,10 @@ var commandStressTestNormal = cli.Command{
 	Flags: []cli.Flag{
 		nodeURLFlag,
 		privKeyFlag,
+		fromFlag,
+		utils.KeyStoreDirFlag,
+		utils.PasswordFileFlag,
+		utils.LightKDFFlag,
 		accountNumberFlag,
 		totalTxsFlag,
 		threadsFlag,
@@ -34,6 +40,10 @@ var commandStressTestToken = cli.Command{
 	Flags: []cli.Flag{
 		nodeURLFlag,
 		privKeyFlag,
+		fromFlag,
+		utils.KeyStoreDirFlag,
+		utils.PasswordFileFlag,
+		utils.LightKDFFlag,
 		accountNumberFlag,
 		totalTxsFlag,
 		threadsFlag,
@@ -66,7 +76,8 @@ func stressTest(ctx *cli.Context, token common.Address, decimal int) error {
 
 	var (
 		client        = clients[0]
-		mainAccount   = newAccount(ctx.GlobalString(privKeyFlag.Name))
+		store         = newAccountStore(ctx)
+		mainAccount   = newMainAccount(ctx, store)
 		accountAmount = ctx.Int(accountNumberFlag.Name)
 		total         = ctx.Int(totalTxsFlag.Name)
 		threads       = ctx.Int(threadsFlag.Name)
@@ -76,15 +87,50 @@ func stressTest(ctx *cli.Context, token common.Address, decimal int) error {
 		return errors.New("total tx amount should bigger than account amount")
 	}
 
+	accounts, err := prepareAccounts(store, mainAccount, accountAmount, token, decimal, client)
+	if err != nil {
+		return err
+	}
+
+	// generate signed transactions
+	amount := big.NewInt(params.Ether)
+	amount.Div(amount, big.NewInt(1e+3))
+	if (token != common.Address{}) {
+		amount.Div(amount, divisor(defaultDecimal-decimal))
+	}
+	txs := generateSignedTransactions(total, accounts, amount, token, client)
+	log.Info("generate txs over", "total", len(txs))
+
+	currentBlock, _ := client.BlockByNumber(context.Background(), nil)
+	log.Info("current block", "number", currentBlock.Number())
+
+	// send txs
+	start := time.Now()
+	stressSendTransactions(txs, threads, clients, client)
+	log.Info("send transaction over", "cost(milliseconds)", time.Now().Sub(start).Milliseconds())
+
+	return nil
+}
+
+// prepareAccounts loads the stored test accounts, generating and funding new
+// ones from the main account if there are not enough of them.
+func prepareAccounts(store accountStore, mainAccount *bind.TransactOpts, accountAmount int, token common.Address, decimal int, client *ethclient.Client) ([]*bind.TransactOpts, error) {
 	first := false
 	var accounts []*bind.TransactOpts
 	var toGen int
-	keys, err := loadAccounts(getStorePath())
+	keys, err := store.load()
 	if err != nil {
 		log.Warn("load accounts failed", "err", err)
 		first = true
 		toGen = accountAmount
 	}
+	// a shared keystore may hold the main account as well, never spend from it
+	for i, key := range keys {
+		if crypto.PubkeyToAddress(key.PublicKey) == mainAccount.From {
+			keys = append(keys[:i], keys[i+1:]...)
+			break
+		}
+	}
 	log.Info("load original accounts", "amount", len(keys))
 
 	if !first && accountAmount > len(keys) {
@@ -100,14 +146,8 @@ func stressTest(ctx *cli.Context, token common.Address, decimal int) error {
 		log.Info("generate accounts over", "generated", len(genAccounts))
 
 		accounts = append(accounts, genAccounts...)
-		if first {
-			if err := writeAccounts(getStorePath(), genKeys); err != nil {
-				return err
-			}
-		} else {
-			if err := appendAccounts(getStorePath(), genKeys); err != nil {
-				return err
-			}
+		if err := store.add(genKeys); err != nil {
+			return nil, err
 		}
 
 		// send this accounts hb and hsct.
@@ -124,24 +164,5 @@ func stressTest(ctx *cli.Context, token common.Address, decimal int) error {
 		sendEtherToRandomAccount(mainAccount, accounts, amount, token, client)
 	}
 
-	accounts = accounts[:accountAmount]
-
-	// generate signed transactions
-	amount := big.NewInt(params.Ether)
-	amount.Div(amount, big.NewInt(1e+3))
-	if (token != common.Address{}) {
-		amount.Div(amount, divisor(defaultDecimal-decimal))
-	}
-	txs := generateSignedTransactions(total, accounts, amount, token, client)
-	log.Info("generate txs over", "total", len(txs))
-
-	currentBlock, _ := client.BlockByNumber(context.Background(), nil)
-	log.Info("current block", "number", currentBlock.Number())
-
-	// send txs
-	start := time.Now()
-	stressSendTransactions(txs, threads, clients, client)
-	log.Info("send transaction over", "cost(milliseconds)", time.Now().Sub(start).Milliseconds())
-
-	return nil
+	return accounts[:accountAmount], nil
 }
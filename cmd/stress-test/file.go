@@ -12,7 +12,7 @@ import (
 )
 
 func writeAccounts(path string, accounts []*ecdsa.PrivateKey) error {
-	file, err := os.Create(path)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
 	if err != nil {
 		return err
 	}
@@ -31,7 +31,7 @@ func writeAccounts(path string, accounts []*ecdsa.PrivateKey) error {
 }
 
 func appendAccounts(path string, accounts []*ecdsa.PrivateKey) error {
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
 		return err
 	}
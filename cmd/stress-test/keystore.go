@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var errNoPassphrase = errors.New("keystore requires a password file")
+
+var fromFlag = cli.StringFlag{
+	Name:  "from",
+	Usage: "The address of the main account in the keystore, used instead of --privkey",
+}
+
+// accountStore persists the test accounts between runs.
+type accountStore interface {
+	// load returns the stored accounts, failing if there are none yet.
+	load() ([]*ecdsa.PrivateKey, error)
+
+	// add stores the newly generated accounts.
+	add(keys []*ecdsa.PrivateKey) error
+}
+
+// plainStore keeps the test accounts as plaintext hex keys, one per line.
+type plainStore struct {
+	path string
+}
+
+func (s *plainStore) load() ([]*ecdsa.PrivateKey, error) {
+	return loadAccounts(s.path)
+}
+
+func (s *plainStore) add(keys []*ecdsa.PrivateKey) error {
+	return appendAccounts(s.path, keys)
+}
+
+// keyStore keeps the test accounts as web3 v3 keystore files encrypted with a
+// single passphrase. The directory may be the one of a node, the files encrypted
+// with other passphrases being skipped.
+type keyStore struct {
+	ks         *keystore.KeyStore
+	passphrase string
+}
+
+func newKeyStore(dir, passphrase string, lightKDF bool) *keyStore {
+	scryptN, scryptP := keystore.StandardScryptN, keystore.StandardScryptP
+	if lightKDF {
+		scryptN, scryptP = keystore.LightScryptN, keystore.LightScryptP
+	}
+	return &keyStore{ks: keystore.NewKeyStore(dir, scryptN, scryptP), passphrase: passphrase}
+}
+
+func (s *keyStore) load() ([]*ecdsa.PrivateKey, error) {
+	keys := make([]*ecdsa.PrivateKey, 0)
+	for _, account := range s.ks.Accounts() {
+		key, err := s.decrypt(account)
+		if err != nil {
+			log.Debug("skip keystore account", "address", account.Address, "err", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (s *keyStore) add(keys []*ecdsa.PrivateKey) error {
+	for _, key := range keys {
+		if _, err := s.ks.ImportECDSA(key, s.passphrase); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// find returns the key of the given address in the keystore.
+func (s *keyStore) find(address common.Address) (*ecdsa.PrivateKey, error) {
+	account, err := s.ks.Find(accounts.Account{Address: address})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decrypt(account)
+}
+
+func (s *keyStore) decrypt(account accounts.Account) (*ecdsa.PrivateKey, error) {
+	keyJSON, err := ioutil.ReadFile(account.URL.Path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(keyJSON, s.passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.PrivateKey, nil
+}
+
+// readPassphrase returns the first line of the password file.
+func readPassphrase(path string) (string, error) {
+	if path == "" {
+		return "", errNoPassphrase
+	}
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(string(text), "\n")
+
+	return strings.TrimRight(lines[0], "\r"), nil
+}
+
+// newAccountStore returns the encrypted keystore if a keystore directory is set,
+// falling back to the plaintext key file.
+func newAccountStore(ctx *cli.Context) accountStore {
+	dir := ctx.GlobalString(utils.KeyStoreDirFlag.Name)
+	if dir == "" {
+		log.Warn("test accounts stored in plaintext, use --keystore to encrypt them", "path", getStorePath())
+		return &plainStore{path: getStorePath()}
+	}
+	passphrase, err := readPassphrase(ctx.GlobalString(utils.PasswordFileFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to read keystore passphrase: %v", err)
+	}
+
+	return newKeyStore(dir, passphrase, ctx.GlobalBool(utils.LightKDFFlag.Name))
+}
+
+// newMainAccount returns the main account, unlocked from the keystore if an
+// address is given, created from the plaintext key otherwise.
+func newMainAccount(ctx *cli.Context, store accountStore) *bind.TransactOpts {
+	from := ctx.GlobalString(fromFlag.Name)
+	if from == "" {
+		return newAccount(ctx.GlobalString(privKeyFlag.Name))
+	}
+	ks, ok := store.(*keyStore)
+	if !ok {
+		utils.Fatalf("Main account %s requires --keystore", from)
+	}
+	if !common.IsHexAddress(from) {
+		utils.Fatalf("Invalid main account address: %s", from)
+	}
+	key, err := ks.find(common.HexToAddress(from))
+	if err != nil {
+		utils.Fatalf("Failed to unlock main account %s: %v", from, err)
+	}
+
+	return bind.NewKeyedTransactor(key)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyStoreAccounts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stress-keystore")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	account, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	// an account of the node encrypted with another passphrase is skipped
+	require.Nil(t, newKeyStore(dir, "node", true).add([]*ecdsa.PrivateKey{other}))
+
+	store := newKeyStore(dir, "test", true)
+	require.Nil(t, store.add([]*ecdsa.PrivateKey{account}))
+
+	actual, err := newKeyStore(dir, "test", true).load()
+	require.Nil(t, err)
+	require.Equal(t, 1, len(actual))
+	require.True(t, account.D.Cmp(actual[0].D) == 0)
+
+	found, err := store.find(crypto.PubkeyToAddress(account.PublicKey))
+	require.Nil(t, err)
+	require.True(t, account.D.Cmp(found.D) == 0)
+
+	_, err = store.find(crypto.PubkeyToAddress(other.PublicKey))
+	require.NotNil(t, err)
+}
+
+func TestReadPassphrase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stress-password")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "password")
+	require.Nil(t, ioutil.WriteFile(path, []byte("secret\r\nignored\n"), 0600))
+
+	passphrase, err := readPassphrase(path)
+	require.Nil(t, err)
+	require.Equal(t, "secret", passphrase)
+
+	_, err = readPassphrase("")
+	require.Equal(t, errNoPassphrase, err)
+}
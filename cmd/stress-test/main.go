@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"os"
 
+	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/fdlimit"
 	"github.com/ethereum/go-ethereum/internal/flags"
@@ -42,10 +43,16 @@ func init() {
 	app.Commands = []cli.Command{
 		commandStressTestNormal,
 		commandStressTestToken,
+		commandStressTestNonce,
+		commandStressTestRPC,
 	}
 	app.Flags = []cli.Flag{
 		nodeURLFlag,
 		privKeyFlag,
+		fromFlag,
+		utils.KeyStoreDirFlag,
+		utils.PasswordFileFlag,
+		utils.LightKDFFlag,
 	}
 	cli.CommandHelpTemplate = flags.OriginCommandHelpTemplate
 }
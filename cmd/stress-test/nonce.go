@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// nonce test scenarios
+const (
+	scenarioBase             = "base"
+	scenarioReplaceSamePrice = "replace-same-price"
+	scenarioReplaceLowBump   = "replace-low-bump"
+	scenarioReplaceBump      = "replace-bump"
+	scenarioFutureNonce      = "future-nonce"
+	scenarioFillGap          = "fill-gap"
+	scenarioConflict         = "conflict"
+	scenarioStaleNonce       = "stale-nonce"
+)
+
+var (
+	priceBumpFlag = cli.Uint64Flag{
+		Name:  "pricebump",
+		Value: 10,
+		Usage: "The price bump percentage the tested txpool requires to replace a transaction",
+	}
+	conflictsFlag = cli.IntFlag{
+		Name:  "conflicts",
+		Value: 4,
+		Usage: "The number of conflicting same-nonce transactions sent concurrently per account",
+	}
+)
+
+var commandStressTestNonce = cli.Command{
+	Name:  "testNonce",
+	Usage: "Send replacement, future-nonce and conflicting transactions to exercise the txpool",
+	Flags: []cli.Flag{
+		nodeURLFlag,
+		privKeyFlag,
+		fromFlag,
+		utils.KeyStoreDirFlag,
+		utils.PasswordFileFlag,
+		utils.LightKDFFlag,
+		accountNumberFlag,
+		threadsFlag,
+		priceBumpFlag,
+		conflictsFlag,
+	},
+	Action: utils.MigrateFlags(stressTestNonce),
+}
+
+// scenarioStats is the acceptance statistic of a single scenario.
+type scenarioStats struct {
+	sent       int
+	accepted   int
+	rejected   int
+	unexpected int
+	reasons    map[string]int
+}
+
+// nonceStats collects the per scenario statistics of the nonce test.
+type nonceStats struct {
+	scenarios map[string]*scenarioStats
+	lock      sync.Mutex
+}
+
+func newNonceStats() *nonceStats {
+	return &nonceStats{scenarios: make(map[string]*scenarioStats)}
+}
+
+func (s *nonceStats) scenario(name string) *scenarioStats {
+	stats, ok := s.scenarios[name]
+	if !ok {
+		stats = &scenarioStats{reasons: make(map[string]int)}
+		s.scenarios[name] = stats
+	}
+	return stats
+}
+
+// record accounts the outcome of a single transaction submission.
+func (s *nonceStats) record(name string, err error, expectAccept bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := s.scenario(name)
+	stats.sent++
+	if err == nil {
+		stats.accepted++
+	} else {
+		stats.rejected++
+		stats.reasons[err.Error()]++
+	}
+	if (err == nil) != expectAccept {
+		stats.unexpected++
+	}
+}
+
+// recordConflict accounts the outcome of a batch of conflicting transactions,
+// out of which exactly one is expected to be accepted.
+func (s *nonceStats) recordConflict(name string, errs []error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := s.scenario(name)
+	accepted := 0
+	for _, err := range errs {
+		stats.sent++
+		if err == nil {
+			stats.accepted++
+			accepted++
+		} else {
+			stats.rejected++
+			stats.reasons[err.Error()]++
+		}
+	}
+	if accepted > 1 {
+		stats.unexpected += accepted - 1
+	} else if accepted == 0 {
+		stats.unexpected++
+	}
+}
+
+// report logs the statistics of every scenario and returns the total number
+// of unexpected outcomes.
+func (s *nonceStats) report() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	names := make([]string, 0, len(s.scenarios))
+	for name := range s.scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	unexpected := 0
+	for _, name := range names {
+		stats := s.scenarios[name]
+		log.Info("nonce scenario result", "scenario", name, "sent", stats.sent, "accepted", stats.accepted,
+			"rejected", stats.rejected, "unexpected", stats.unexpected)
+		for reason, count := range stats.reasons {
+			log.Info("nonce scenario rejection", "scenario", name, "reason", reason, "count", count)
+		}
+		unexpected += stats.unexpected
+	}
+	return unexpected
+}
+
+// bumpPrice returns the price increased by the given percentage.
+func bumpPrice(price *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(price, new(big.Int).SetUint64(100+percent))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// newPricedTransferTransaction creates a normal transfer transaction with the given gas price.
+func newPricedTransferTransaction(nonce uint64, to common.Address, amount, gasPrice *big.Int) *types.Transaction {
+	return types.NewTransaction(nonce, to, amount, hbTransferLimit, gasPrice, []byte{})
+}
+
+func stressTestNonce(ctx *cli.Context) error {
+	clients := newClients(getRPCList(ctx))
+	if len(clients) == 0 {
+		return errors.New("no rpc url set")
+	}
+
+	var (
+		client        = clients[0]
+		store         = newAccountStore(ctx)
+		mainAccount   = newMainAccount(ctx, store)
+		accountAmount = ctx.Int(accountNumberFlag.Name)
+		threads       = ctx.Int(threadsFlag.Name)
+		bump          = ctx.Uint64(priceBumpFlag.Name)
+		conflicts     = ctx.Int(conflictsFlag.Name)
+	)
+	if conflicts < 2 {
+		return errors.New("conflicts should be at least 2")
+	}
+	if threads > accountAmount {
+		threads = accountAmount
+	}
+
+	accounts, err := prepareAccounts(store, mainAccount, accountAmount, common.Address{}, 0, client)
+	if err != nil {
+		return err
+	}
+
+	stats := newNonceStats()
+	workFn := func(start, end int, data ...interface{}) []interface{} {
+		for i := start; i < end; i++ {
+			c := clients[i%len(clients)]
+			if err := runNonceScenarios(accounts[i], bump, conflicts, stats, c); err != nil {
+				log.Error("nonce scenarios failed", "account", accounts[i].From, "err", err)
+			}
+		}
+
+		return []interface{}{}
+	}
+
+	start := time.Now()
+	concurrentWork(threads, len(accounts), workFn, nil)
+	log.Info("nonce test over", "cost(milliseconds)", time.Now().Sub(start).Milliseconds())
+
+	if unexpected := stats.report(); unexpected > 0 {
+		return fmt.Errorf("%d unexpected txpool outcomes", unexpected)
+	}
+
+	return nil
+}
+
+// runNonceScenarios sends the replacement, future-nonce and conflicting
+// transactions of all scenarios from a single account, in order.
+func runNonceScenarios(account *bind.TransactOpts, bump uint64, conflicts int, stats *nonceStats, client *ethclient.Client) error {
+	nonce, err := client.PendingNonceAt(context.Background(), account.From)
+	if err != nil {
+		return err
+	}
+
+	price := new(big.Int).Mul(big.NewInt(10), big.NewInt(params.GWei))
+	amount := new(big.Int).Div(big.NewInt(params.Ether), big.NewInt(1e+3))
+
+	// every transaction pays a different amount so none of them is a duplicate
+	send := func(nonce uint64, to common.Address, gasPrice *big.Int) error {
+		amount = new(big.Int).Add(amount, common.Big1)
+		signedTx, err := account.Signer(account.From, newPricedTransferTransaction(nonce, to, amount, gasPrice))
+		if err != nil {
+			return err
+		}
+		return client.SendTransaction(context.Background(), signedTx)
+	}
+
+	stats.record(scenarioBase, send(nonce, receiver, price), true)
+	stats.record(scenarioReplaceSamePrice, send(nonce, receiver, price), false)
+	if bump > 1 {
+		stats.record(scenarioReplaceLowBump, send(nonce, receiver, bumpPrice(price, bump-1)), false)
+	}
+	stats.record(scenarioReplaceBump, send(nonce, receiver, bumpPrice(price, bump)), true)
+	stats.record(scenarioFutureNonce, send(nonce+2, receiver, price), true)
+	stats.record(scenarioFillGap, send(nonce+1, receiver, price), true)
+
+	// conflicting transactions with the same nonce and price racing each other
+	var (
+		errs = make([]error, conflicts)
+		txs  = make([]*types.Transaction, conflicts)
+		wg   sync.WaitGroup
+	)
+	for i := range txs {
+		amount = new(big.Int).Add(amount, common.Big1)
+		if txs[i], err = account.Signer(account.From, newPricedTransferTransaction(nonce+3, receiver, amount, price)); err != nil {
+			return err
+		}
+	}
+	for i := range txs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = client.SendTransaction(context.Background(), txs[i])
+		}(i)
+	}
+	wg.Wait()
+	stats.recordConflict(scenarioConflict, errs)
+
+	if nonce > 0 {
+		stats.record(scenarioStaleNonce, send(nonce-1, receiver, price), false)
+	}
+
+	return nil
+}
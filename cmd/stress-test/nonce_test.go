@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBumpPrice(t *testing.T) {
+	assert.Equal(t, big.NewInt(110), bumpPrice(big.NewInt(100), 10))
+	assert.Equal(t, big.NewInt(10), bumpPrice(big.NewInt(10), 0))
+	assert.Equal(t, big.NewInt(10), bumpPrice(big.NewInt(10), 9))
+}
+
+func TestNonceStats(t *testing.T) {
+	stats := newNonceStats()
+	underpriced := errors.New("replacement transaction underpriced")
+
+	stats.record(scenarioBase, nil, true)
+	stats.record(scenarioReplaceSamePrice, underpriced, false)
+	stats.record(scenarioReplaceSamePrice, nil, false)
+	stats.recordConflict(scenarioConflict, []error{nil, underpriced, underpriced})
+	stats.recordConflict(scenarioConflict, []error{nil, nil, underpriced})
+	stats.recordConflict(scenarioConflict, []error{underpriced, underpriced})
+
+	base := stats.scenarios[scenarioBase]
+	assert.Equal(t, 1, base.accepted)
+	assert.Equal(t, 0, base.unexpected)
+
+	replace := stats.scenarios[scenarioReplaceSamePrice]
+	assert.Equal(t, 2, replace.sent)
+	assert.Equal(t, 1, replace.rejected)
+	assert.Equal(t, 1, replace.unexpected)
+	assert.Equal(t, 1, replace.reasons[underpriced.Error()])
+
+	conflict := stats.scenarios[scenarioConflict]
+	assert.Equal(t, 8, conflict.sent)
+	assert.Equal(t, 3, conflict.accepted)
+	assert.Equal(t, 2, conflict.unexpected)
+
+	assert.Equal(t, 3, stats.report())
+}
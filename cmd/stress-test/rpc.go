@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	mrand "math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// balanceOfSig is the selector of the erc20 balanceOf method.
+const balanceOfSig = "70a08231"
+
+// rpcKinds are the kinds of the read requests the rpc test can generate.
+var rpcKinds = []string{"call", "logs", "balance", "trace"}
+
+var (
+	totalRequestsFlag = cli.IntFlag{
+		Name:  "totalRequests",
+		Value: 10000,
+		Usage: "The total number of rpc requests sent for test",
+	}
+	rpcMixFlag = cli.StringFlag{
+		Name:  "mix",
+		Value: "call=40,logs=20,balance=30,trace=10",
+		Usage: "The weights of the request kinds sent for test (call, logs, balance, trace)",
+	}
+	logsRangeFlag = cli.IntFlag{
+		Name:  "logsRange",
+		Value: 100,
+		Usage: "The number of blocks below the head the logs are queried over",
+	}
+	requestTimeoutFlag = cli.DurationFlag{
+		Name:  "timeout",
+		Value: 10 * time.Second,
+		Usage: "The timeout of a single rpc request",
+	}
+)
+
+var commandStressTestRPC = cli.Command{
+	Name:  "testRPC",
+	Usage: "Send a mix of read rpc requests for stress test, reporting their latency percentiles",
+	Flags: []cli.Flag{
+		nodeURLFlag,
+		totalRequestsFlag,
+		threadsFlag,
+		rpcMixFlag,
+		tokenFlag,
+		logsRangeFlag,
+		requestTimeoutFlag,
+	},
+	Action: utils.MigrateFlags(stressTestRPC),
+}
+
+// rpcMix is the weighted mix of the request kinds.
+type rpcMix struct {
+	kinds   []string
+	weights []int // Cumulative weights of the kinds
+}
+
+// parseRPCMix parses a comma separated list of kind=weight pairs.
+func parseRPCMix(s string) (*rpcMix, error) {
+	mix := new(rpcMix)
+	total := 0
+	for _, item := range strings.Split(s, separator) {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid mix item %q, want kind=weight", item)
+		}
+		kind := strings.TrimSpace(parts[0])
+		known := false
+		for _, k := range rpcKinds {
+			known = known || k == kind
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown request kind %q, want one of %v", kind, rpcKinds)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight of %s: %q", kind, parts[1])
+		}
+		if weight == 0 {
+			continue
+		}
+		total += weight
+		mix.kinds = append(mix.kinds, kind)
+		mix.weights = append(mix.weights, total)
+	}
+	if total == 0 {
+		return nil, errors.New("empty request mix")
+	}
+	return mix, nil
+}
+
+// pick returns a random kind of the mix, according to the weights.
+func (m *rpcMix) pick(r *mrand.Rand) string {
+	n := r.Intn(m.weights[len(m.weights)-1])
+	i := sort.SearchInts(m.weights, n+1)
+	return m.kinds[i]
+}
+
+// latencyStats collects the latencies and the errors of the requests of a kind.
+type latencyStats struct {
+	latencies []time.Duration
+	errors    int
+}
+
+// percentile returns the p-th percentile of the sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+// rpcTester sends the read requests to the endpoints.
+type rpcTester struct {
+	token   common.Address
+	from    uint64 // First block of the logs queries
+	to      uint64 // Last block of the logs queries
+	timeout time.Duration
+}
+
+// send sends a request of the kind to the client, returning its latency.
+func (t *rpcTester) send(client *rpc.Client, kind string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+
+	var (
+		ec    = ethclient.NewClient(client)
+		start = time.Now()
+		err   error
+	)
+	switch kind {
+	case "call":
+		_, err = ec.CallContract(ctx, t.balanceOfCall(), nil)
+	case "logs":
+		_, err = ec.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(t.from),
+			ToBlock:   new(big.Int).SetUint64(t.to),
+			Addresses: []common.Address{t.token},
+		})
+	case "balance":
+		_, err = ec.BalanceAt(ctx, randomAddress(), nil)
+	case "trace":
+		msg := t.balanceOfCall()
+		args := map[string]interface{}{"to": msg.To, "data": hexutil.Bytes(msg.Data)}
+		var res interface{}
+		err = client.CallContext(ctx, &res, "debug_traceCall", args, "latest", map[string]interface{}{"tracer": "callTracer"})
+	default:
+		err = fmt.Errorf("unknown request kind %q", kind)
+	}
+	return time.Since(start), err
+}
+
+// balanceOfCall returns a call of the token balance of a random address.
+func (t *rpcTester) balanceOfCall() ethereum.CallMsg {
+	return ethereum.CallMsg{
+		To:   &t.token,
+		Data: append(common.Hex2Bytes(balanceOfSig), common.LeftPadBytes(randomAddress().Bytes(), 32)...),
+	}
+}
+
+// randomAddress returns a random address, queried to miss the caches.
+func randomAddress() common.Address {
+	var addr common.Address
+	rand.Read(addr[:])
+	return addr
+}
+
+func stressTestRPC(ctx *cli.Context) error {
+	mix, err := parseRPCMix(ctx.String(rpcMixFlag.Name))
+	if err != nil {
+		return err
+	}
+	var clients []*rpc.Client
+	for _, url := range getRPCList(ctx) {
+		client, err := rpc.Dial(url)
+		if err != nil {
+			log.Warn("Failed to connect to rpc endpoint", "url", url, "err", err)
+			continue
+		}
+		clients = append(clients, client)
+	}
+	if len(clients) == 0 {
+		return errors.New("no rpc url set")
+	}
+	head, err := ethclient.NewClient(clients[0]).BlockNumber(context.Background())
+	if err != nil {
+		return err
+	}
+	var (
+		total   = ctx.Int(totalRequestsFlag.Name)
+		threads = ctx.Int(threadsFlag.Name)
+		span    = uint64(ctx.Int(logsRangeFlag.Name))
+		tester  = &rpcTester{
+			token:   common.HexToAddress(ctx.String(tokenFlag.Name)),
+			to:      head,
+			timeout: ctx.Duration(requestTimeoutFlag.Name),
+		}
+	)
+	if head > span {
+		tester.from = head - span
+	}
+	if threads <= 0 {
+		threads = 1
+	}
+	log.Info("Start sending rpc requests", "total", total, "threads", threads, "endpoints", len(clients), "head", head)
+
+	var (
+		stats = make(map[string]*latencyStats)
+		lock  sync.Mutex
+		wg    sync.WaitGroup
+		jobs  = make(chan int, threads)
+		start = time.Now()
+	)
+	for _, kind := range mix.kinds {
+		stats[kind] = new(latencyStats)
+	}
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			r := mrand.New(mrand.NewSource(seed))
+			for job := range jobs {
+				kind := mix.pick(r)
+				latency, err := tester.send(clients[job%len(clients)], kind)
+
+				lock.Lock()
+				if err != nil {
+					stats[kind].errors++
+					log.Debug("Rpc request failed", "kind", kind, "err", err)
+				} else {
+					stats[kind].latencies = append(stats[kind].latencies, latency)
+				}
+				lock.Unlock()
+			}
+		}(time.Now().UnixNano() + int64(i))
+	}
+	for i := 0; i < total; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	log.Info("Send rpc requests over", "total", total, "elapsed", common.PrettyDuration(elapsed),
+		"qps", fmt.Sprintf("%.1f", float64(total)/elapsed.Seconds()))
+	for _, kind := range mix.kinds {
+		s := stats[kind]
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+		log.Info("Rpc latency", "kind", kind, "ok", len(s.latencies), "errors", s.errors,
+			"p50", common.PrettyDuration(percentile(s.latencies, 50)),
+			"p90", common.PrettyDuration(percentile(s.latencies, 90)),
+			"p99", common.PrettyDuration(percentile(s.latencies, 99)),
+			"max", common.PrettyDuration(percentile(s.latencies, 100)))
+	}
+	return nil
+}
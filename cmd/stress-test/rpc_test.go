@@ -0,0 +1,40 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRPCMix(t *testing.T) {
+	mix, err := parseRPCMix("call=3, logs=0,balance=1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"call", "balance"}, mix.kinds)
+	assert.Equal(t, []int{3, 4}, mix.weights)
+
+	counts := make(map[string]int)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 4000; i++ {
+		counts[mix.pick(r)]++
+	}
+	assert.InDelta(t, 3000, counts["call"], 200)
+	assert.InDelta(t, 1000, counts["balance"], 200)
+
+	for _, bad := range []string{"", "call", "call=x", "call=-1", "send=1", "call=0"} {
+		_, err := parseRPCMix(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 50))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 99))
+	assert.Equal(t, 100*time.Millisecond, percentile(latencies, 100))
+	assert.Equal(t, time.Duration(0), percentile(nil, 50))
+}
@@ -34,11 +34,15 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/external"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/chainsink"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/fdlimit"
+	"github.com/ethereum/go-ethereum/common/gopool"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/congress"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -47,6 +51,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -117,6 +122,31 @@ var (
 		Name:  "datadir.ancient",
 		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
 	}
+	DBCompressionFlag = cli.StringFlag{
+		Name:  "db.compression",
+		Usage: "Compression codec of the new block bodies and receipts in the database (none, snappy, zstd), see geth db compress for the stored ones",
+	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: "Backing database implementation to use ('leveldb' or 'pebble', default = the one of the existing database, leveldb for a new one)",
+	}
+	DBSlowQueryFlag = cli.DurationFlag{
+		Name:  "db.slowquery",
+		Usage: "Duration past which the database queries are logged as slow with their call site (0 = disabled)",
+	}
+	BackupIntervalFlag = cli.DurationFlag{
+		Name:  "backup.interval",
+		Usage: "Interval of the point-in-time backups of the chain database (0 = disabled)",
+	}
+	BackupDirFlag = DirectoryFlag{
+		Name:  "backup.dir",
+		Usage: "Directory to write the chain database backups into (default = inside the datadir)",
+	}
+	BackupKeepFlag = cli.IntFlag{
+		Name:  "backup.keep",
+		Usage: "Number of most recent chain database backups to keep",
+		Value: ethconfig.Defaults.BackupKeep,
+	}
 	MinFreeDiskSpaceFlag = DirectoryFlag{
 		Name:  "datadir.minfreedisk",
 		Usage: "Minimum free disk space in MB, once reached triggers auto shut down (default = --cache.gc converted to MB, 0 = disabled)",
@@ -147,6 +177,14 @@ var (
 		Name:  "testnet",
 		Usage: "Testnet network: pre-configured proof-of-authority shortlived test network.",
 	}
+	HecoMainnetFlag = cli.BoolFlag{
+		Name:  "heco-mainnet",
+		Usage: "Heco mainnet: preset genesis, chain config, network id and bootnodes",
+	}
+	HecoTestnetFlag = cli.BoolFlag{
+		Name:  "heco-testnet",
+		Usage: "Heco testnet: preset genesis, chain config, network id and bootnodes",
+	}
 	SepoliaFlag = cli.BoolFlag{
 		Name:  "sepolia",
 		Usage: "Sepolia network: pre-configured proof-of-work test network",
@@ -223,6 +261,11 @@ var (
 		Usage: "Number of recent blocks to maintain transactions index for (default = about one year, 0 = entire chain)",
 		Value: ethconfig.Defaults.TxLookupLimit,
 	}
+	ProofWitnessDepthFlag = cli.Uint64Flag{
+		Name:  "gcmode.witnessdepth",
+		Usage: "Number of recent blocks to retain state proof witnesses for on pruned nodes (0 = disabled, must exceed 128)",
+		Value: ethconfig.Defaults.ProofWitnessDepth,
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -231,6 +274,10 @@ var (
 		Name:  "whitelist",
 		Usage: "Comma separated block number-to-hash mappings to enforce (<number>=<hash>)",
 	}
+	SyncTargetFlag = cli.StringFlag{
+		Name:  "synctarget",
+		Usage: "Trusted block hash the synced chain must contain, refusing the peers serving a chain without it",
+	}
 	BloomFilterSizeFlag = cli.Uint64Flag{
 		Name:  "bloomfilter.size",
 		Usage: "Megabytes of memory allocated to bloom-filter for pruning",
@@ -240,6 +287,26 @@ var (
 		Name:  "override.arrowglacier",
 		Usage: "Manually specify Arrow Glacier fork-block, overriding the bundled setting",
 	}
+	OverrideRedCoastFlag = cli.Uint64Flag{
+		Name:  "override.redcoast",
+		Usage: "Manually specify RedCoast fork-block, overriding the bundled setting (not allowed on mainnet)",
+	}
+	OverrideSophonFlag = cli.Uint64Flag{
+		Name:  "override.sophon",
+		Usage: "Manually specify Sophon fork-block, overriding the bundled setting (not allowed on mainnet)",
+	}
+	OverrideProxyCheckFlag = cli.Uint64Flag{
+		Name:  "override.proxycheck",
+		Usage: "Manually specify ProxyCheck fork-block, overriding the bundled setting (not allowed on mainnet)",
+	}
+	OverrideTaurusFlag = cli.Uint64Flag{
+		Name:  "override.taurus",
+		Usage: "Manually specify Taurus fork-block, overriding the bundled setting (not allowed on mainnet)",
+	}
+	OverrideGenesisCheckFlag = cli.BoolFlag{
+		Name:  "override.genesis-check",
+		Usage: "Skip the check of the datadir genesis against the configured network, running with the datadir's genesis",
+	}
 	// Light server and client settings
 	LightServeFlag = cli.IntFlag{
 		Name:  "light.serve",
@@ -375,6 +442,87 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: ethconfig.Defaults.TxPool.Lifetime,
 	}
+	TxPoolPrivateFlag = cli.BoolFlag{
+		Name:  "txpool.private",
+		Usage: "Send the transactions submitted over RPC only to the trusted and private peers instead of gossiping them",
+	}
+	TxPoolPrivatePeersFlag = cli.StringFlag{
+		Name:  "txpool.privatepeers",
+		Usage: "Comma separated enode URLs of the peers private transactions are sent to, on top of the trusted peers",
+	}
+	TxPoolResubmitFlag = cli.BoolFlag{
+		Name:  "txpool.resubmit",
+		Usage: "Enables tracking and automatic resubmission of local transactions not mined in time",
+	}
+	TxPoolResubmitBlocksFlag = cli.Uint64Flag{
+		Name:  "txpool.resubmit.blocks",
+		Usage: "Number of blocks a local transaction can stay unmined before being resubmitted",
+		Value: ethconfig.Defaults.TxPool.ResubmitConfig.Blocks,
+	}
+	TxPoolResubmitPriceBumpFlag = cli.Uint64Flag{
+		Name:  "txpool.resubmit.pricebump",
+		Usage: "Fee bump percentage of a resubmission (0 = rebroadcast only, bumping needs an unlocked account)",
+		Value: ethconfig.Defaults.TxPool.ResubmitConfig.PriceBump,
+	}
+	TxPoolResubmitMaxBumpsFlag = cli.IntFlag{
+		Name:  "txpool.resubmit.maxbumps",
+		Usage: "Maximum number of fee bumps for a single local transaction",
+		Value: ethconfig.Defaults.TxPool.ResubmitConfig.MaxBumps,
+	}
+	TxPoolResubmitMaxPriceFlag = cli.Uint64Flag{
+		Name:  "txpool.resubmit.maxprice",
+		Usage: "Maximum gas price (fee cap) in gwei a resubmission can bump to",
+		Value: ethconfig.Defaults.TxPool.ResubmitConfig.MaxGasPrice,
+	}
+	// Congress settings
+	CongressEpochCheckFlag = cli.StringFlag{
+		Name:  "congress.verifyepoch",
+		Usage: "Cross-checks the checkpoint validators against the contract state on import if the parent state is available (off, log, halt)",
+		Value: "off",
+	}
+	CongressSysCodeCheckFlag = cli.StringFlag{
+		Name:  "congress.verifysyscode",
+		Usage: "Verifies the system contract code against the bundled versions at startup and upgrades (off, warn, halt = refuse to seal)",
+		Value: "warn",
+	}
+	CongressPreannounceFlag = cli.BoolFlag{
+		Name:  "congress.preannounce",
+		Usage: "Sends the blocks sealed out of turn to the trusted peers while they wait out their wiggle delay",
+	}
+	CongressMaxParentAgeFlag = cli.DurationFlag{
+		Name:  "congress.maxparentage",
+		Usage: "Refuses to seal on top of a parent block older than this against the wall clock, so an isolated validator doesn't mint a private fork, unless the connected peers report no heavier head (0 = unlimited)",
+	}
+	CongressVanityFlag = cli.BoolFlag{
+		Name:  "congress.vanity",
+		Usage: "Tags the vanity prefix of the sealed blocks with the client version",
+	}
+	CongressVanityTagFlag = cli.StringFlag{
+		Name:  "congress.vanitytag",
+		Usage: "Short operator tag appended to the client version in the vanity prefix (implies --congress.vanity)",
+	}
+	CongressBlacklistFileFlag = cli.StringFlag{
+		Name:  "congress.blacklist.expected",
+		Usage: "JSON file of the expected blacklist (address to from, to or both), alarming when the on-chain blacklist diverges from it",
+	}
+	CongressBlacklistWebhookFlag = cli.StringFlag{
+		Name:  "congress.blacklist.webhook",
+		Usage: "Endpoint the expected blacklist divergence alarms are posted to as JSON",
+	}
+	ValidatorSignerFlag = cli.StringFlag{
+		Name:  "congress.signer",
+		Usage: "Comma separated clef compatible remote signer endpoints holding the validator key, tried in order (HSM/KMS backed signers)",
+	}
+	ValidatorSignerTimeoutFlag = cli.DurationFlag{
+		Name:  "congress.signer.timeout",
+		Usage: "Timeout of a single request to the remote validator signer",
+		Value: ethconfig.Defaults.ValidatorSigner.Timeout,
+	}
+	ValidatorSignerRetriesFlag = cli.IntFlag{
+		Name:  "congress.signer.retries",
+		Usage: "Number of extra rounds over the remote validator signers before sealing is halted",
+		Value: ethconfig.Defaults.ValidatorSigner.Retries,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -419,6 +567,27 @@ var (
 		Name:  "cache.preimages",
 		Usage: "Enable recording the SHA3/keccak preimages of trie keys",
 	}
+	CacheBlockMemFlag = cli.IntFlag{
+		Name:  "cache.blockmem",
+		Usage: "Memory allowance (MB) of the logs of a block being imported, past which their data is spilled to disk (0 = disabled)",
+		Value: ethconfig.Defaults.BlockMemoryLimit,
+	}
+	CacheBlockMemCapFlag = cli.IntFlag{
+		Name:  "cache.blockmem.cap",
+		Usage: "Memory limit (MB) of the receipts and logs of a block being imported, past which the import is aborted (0 = unlimited)",
+	}
+	GoPoolBloomFlag = cli.IntFlag{
+		Name:  "gopool.bloom",
+		Usage: "Number of goroutines creating the receipt blooms during block processing (default = number of CPUs)",
+	}
+	GoPoolPreloadFlag = cli.IntFlag{
+		Name:  "gopool.preload",
+		Usage: "Number of goroutines preloading the block accounts from the snapshot (default = number of CPUs)",
+	}
+	GoPoolVerifyFlag = cli.IntFlag{
+		Name:  "gopool.verify",
+		Usage: "Number of goroutines recovering the transaction senders (default = number of CPUs)",
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -461,6 +630,14 @@ var (
 		Usage: "Time interval to recreate the block being mined",
 		Value: ethconfig.Defaults.Miner.Recommit,
 	}
+	MinerRecommitAdaptiveFlag = cli.BoolFlag{
+		Name:  "miner.recommit.adaptive",
+		Usage: "Adapt the recommit interval to the block fullness and the new transactions",
+	}
+	MinerAccessListPrefetchFlag = cli.BoolFlag{
+		Name:  "miner.prefetchaccesslists",
+		Usage: "Generate the access lists of the pending transactions in the background to prefetch their state while sealing",
+	}
 	MinerNoVerifyFlag = cli.BoolFlag{
 		Name:  "miner.noverify",
 		Usage: "Disable remote sealing verification",
@@ -485,6 +662,15 @@ var (
 		Name:  "vmdebug",
 		Usage: "Record information useful for VM and contract debugging",
 	}
+	VMProfileFlag = cli.DurationFlag{
+		Name:  "vm.profile",
+		Usage: "Sampling window of the per opcode and contract EVM profiling, exposed via debug_evmProfile (0 = disabled)",
+	}
+	BlockSTMResearchFlag = cli.BoolFlag{
+		Name:   "experimental.blockstm",
+		Usage:  "Cross-validate the imported blocks with the experimental Block-STM executor, dumping the divergences (research only)",
+		Hidden: true,
+	}
 	InsecureUnlockAllowedFlag = cli.BoolFlag{
 		Name:  "allow-insecure-unlock",
 		Usage: "Allow insecure account unlocking when account-related RPCs are exposed by http",
@@ -494,11 +680,54 @@ var (
 		Usage: "Sets a cap on gas that can be used in eth_call/estimateGas (0=infinite)",
 		Value: ethconfig.Defaults.RPCGasCap,
 	}
+	RPCStateCacheFlag = cli.Uint64Flag{
+		Name:  "rpc.statecache",
+		Usage: "Number of recent blocks to serve the RPC state reads for from the multi-version state cache (0 = disabled)",
+		Value: ethconfig.Defaults.RPCStateCacheDepth,
+	}
 	RPCGlobalEVMTimeoutFlag = cli.DurationFlag{
 		Name:  "rpc.evmtimeout",
 		Usage: "Sets a timeout used for eth_call (0=infinite)",
 		Value: ethconfig.Defaults.RPCEVMTimeout,
 	}
+	RPCEstimateGasCapFlag = cli.Uint64Flag{
+		Name:  "rpc.estimategascap",
+		Usage: "Sets a cap on gas that can be used in eth_estimateGas (0 = same as rpc.gascap)",
+	}
+	RPCEstimateEVMTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.estimatetimeout",
+		Usage: "Sets a timeout used for a whole eth_estimateGas (0=infinite)",
+	}
+	RPCTraceGasCapFlag = cli.Uint64Flag{
+		Name:  "rpc.tracegascap",
+		Usage: "Sets a cap on gas that can be used in debug_traceCall (0 = same as rpc.gascap)",
+	}
+	RPCTraceEVMTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.tracetimeout",
+		Usage: "Sets the default and maximum timeout of tracing a single transaction (0 = tracer default, no cap)",
+	}
+	RPCHeavyCallLimitFlag = cli.IntFlag{
+		Name:  "rpc.heavycalls",
+		Usage: "Maximum number of concurrent heavy calls, i.e. traces and gas estimations (0 = unlimited)",
+	}
+	RPCResponseCacheFlag = cli.IntFlag{
+		Name:  "rpc.respcache",
+		Usage: "Number of immutable RPC responses cached, i.e. blocks by hash, receipts and code of final blocks (0 = disabled)",
+	}
+	RPCResponseCacheTTLFlag = cli.DurationFlag{
+		Name:  "rpc.respcache.ttl",
+		Usage: "Time a cached RPC response is kept for (0 = until evicted)",
+		Value: ethconfig.Defaults.RPCResponseCacheTTL,
+	}
+	GovernorFlag = cli.BoolFlag{
+		Name:  "governor",
+		Usage: "Run the heavy calls and log queries one at a time while the block import lags",
+	}
+	GovernorThresholdFlag = cli.DurationFlag{
+		Name:  "governor.threshold",
+		Usage: "Block import latency above which the governor throttles the heavy calls",
+		Value: ethconfig.Defaults.GovernorThreshold,
+	}
 	RPCGlobalTxFeeCapFlag = cli.Float64Flag{
 		Name:  "rpc.txfeecap",
 		Usage: "Sets a cap on transaction fee (in ether) that can be sent via the RPC APIs (0 = no cap)",
@@ -509,6 +738,20 @@ var (
 		Name:  "ethstats",
 		Usage: "Reporting URL of a ethstats service (nodename:secret@host:port)",
 	}
+	ChainSinkURLFlag = cli.StringFlag{
+		Name:  "chainsink",
+		Usage: "Broker URL the canonical chain events are published to (nats://host:port or kafka://broker1:port,broker2:port), requires the chainsink build tag",
+	}
+	ChainSinkPrefixFlag = cli.StringFlag{
+		Name:  "chainsink.prefix",
+		Usage: "Prefix of the subjects or topics the chain events are published to",
+		Value: "heco.chain",
+	}
+	ChainSinkDepthFlag = cli.Uint64Flag{
+		Name:  "chainsink.depth",
+		Usage: "Number of blocks on top of a block to publish it as finalized",
+		Value: 15,
+	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fakepow",
 		Usage: "Disables proof-of-work verification",
@@ -574,6 +817,20 @@ var (
 		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
 		Value: strings.Join(node.DefaultConfig.GraphQLVirtualHosts, ","),
 	}
+	LogExportEnabledFlag = cli.BoolFlag{
+		Name:  "logexport",
+		Usage: "Enable the NDJSON log export endpoint (/logs/export) on the HTTP-RPC server",
+	}
+	LogExportRateFlag = cli.IntFlag{
+		Name:  "logexport.rate",
+		Usage: "Maximum number of blocks scanned per second by each log export",
+		Value: filters.DefaultExportConfig.BlockRate,
+	}
+	LogExportStreamsFlag = cli.IntFlag{
+		Name:  "logexport.streams",
+		Usage: "Maximum number of concurrent log exports",
+		Value: filters.DefaultExportConfig.MaxStreams,
+	}
 	WSEnabledFlag = cli.BoolFlag{
 		Name:  "ws",
 		Usage: "Enable the WS-RPC server",
@@ -627,6 +884,50 @@ var (
 		Usage: "Maximum number of pending connection attempts (defaults used if set to 0)",
 		Value: node.DefaultConfig.P2P.MaxPendingPeers,
 	}
+	TxAnnouncesFlag = cli.IntFlag{
+		Name:  "p2p.txannounces",
+		Usage: "Maximum number of unique transaction announcements a peer can have in flight",
+		Value: ethconfig.Defaults.TxFetcher.MaxAnnounces,
+	}
+	TxUnderpricedSetFlag = cli.IntFlag{
+		Name:  "p2p.txunderpriced",
+		Usage: "Size of the set tracking recently rejected underpriced transactions",
+		Value: ethconfig.Defaults.TxFetcher.UnderpricedSetSize,
+	}
+	TxFloodLimitFlag = cli.IntFlag{
+		Name:  "p2p.txfloodlimit",
+		Usage: "Repeated announcements of underpriced transactions per minute after which a peer's announcements are dropped (0 = disabled)",
+		Value: ethconfig.Defaults.TxFetcher.FloodLimit,
+	}
+	KnownTxsFlag = cli.IntFlag{
+		Name:  "p2p.knowntxs",
+		Usage: "Size of the per-peer known transactions cache (0 = default)",
+	}
+	StrictForkIDFlag = cli.BoolFlag{
+		Name:  "p2p.strictforkid",
+		Usage: "Drop peers announcing a different next fork than the local node (e.g. not upgraded ones)",
+	}
+	ValidatorPeersFlag = cli.StringFlag{
+		Name:  "p2p.validatorpeers",
+		Usage: "Comma separated enode URLs of the validator peers, which blocks are propagated to first and which are exempt from the egress caps",
+	}
+	EgressBlockCapFlag = cli.Uint64Flag{
+		Name:  "p2p.egress.blocks",
+		Usage: "Per-peer bandwidth cap in KB/s of the block propagations and sync replies sent to non-validator peers, propagations delay the sync replies (0 = uncapped)",
+	}
+	EgressTxCapFlag = cli.Uint64Flag{
+		Name:  "p2p.egress.txs",
+		Usage: "Per-peer bandwidth cap in KB/s of the transaction traffic sent to non-validator peers (0 = uncapped)",
+	}
+	LatencyPeerRatioFlag = cli.IntFlag{
+		Name:  "p2p.latency.ratio",
+		Usage: "Percentage of the peer slots preferably held by low latency peers, dropping the slowest peer once in a while below it (0 = disabled)",
+	}
+	LatencyThresholdFlag = cli.DurationFlag{
+		Name:  "p2p.latency.threshold",
+		Usage: "Ping round trip time under which a peer is low latency",
+		Value: ethconfig.Defaults.LatencyThreshold,
+	}
 	ListenPortFlag = cli.IntFlag{
 		Name:  "port",
 		Usage: "Network listening port",
@@ -685,6 +986,10 @@ var (
 		Usage: "Suggested gas price is the given percentile of a set of recent transaction gas prices",
 		Value: ethconfig.Defaults.GPO.Percentile,
 	}
+	GpoMinGasPriceFlag = cli.Int64Flag{
+		Name:  "gpo.minprice",
+		Usage: "Minimum transaction priority fee (or gasprice before London fork) to be recommended by gpo (0 = no minimum)",
+	}
 	GpoMaxGasPriceFlag = cli.Int64Flag{
 		Name:  "gpo.maxprice",
 		Usage: "Maximum transaction priority fee (or gasprice before London fork) to be recommended by gpo",
@@ -788,8 +1093,8 @@ var (
 // then a subdirectory of the specified datadir will be used.
 func MakeDataDir(ctx *cli.Context) string {
 	if path := ctx.GlobalString(DataDirFlag.Name); path != "" {
-		if ctx.GlobalBool(TestnetFlag.Name) {
-			return filepath.Join(path, "testnet")
+		if preset := NetworkPreset(ctx); preset != nil && preset.DataDir != "" {
+			return filepath.Join(path, preset.DataDir)
 		}
 		if ctx.GlobalBool(SepoliaFlag.Name) {
 			return filepath.Join(path, "sepolia")
@@ -800,6 +1105,18 @@ func MakeDataDir(ctx *cli.Context) string {
 	return ""
 }
 
+// NetworkPreset returns the preset of the public network selected by the command
+// line flags, nil if none.
+func NetworkPreset(ctx *cli.Context) *core.NetworkPreset {
+	switch {
+	case ctx.GlobalBool(HecoMainnetFlag.Name) || ctx.GlobalBool(MainnetFlag.Name):
+		return core.HecoMainnet
+	case ctx.GlobalBool(HecoTestnetFlag.Name) || ctx.GlobalBool(TestnetFlag.Name):
+		return core.HecoTestnet
+	}
+	return nil
+}
+
 // setNodeKey creates a node key from set command line flags, either loading it
 // from a file or as a specified hex value. If neither flags were provided, this
 // method returns nil and an emphemeral key is to be generated.
@@ -837,11 +1154,12 @@ func setNodeUserIdent(ctx *cli.Context, cfg *node.Config) {
 // flags, reverting to pre-configured ones if none have been specified.
 func setBootstrapNodes(ctx *cli.Context, cfg *p2p.Config) {
 	urls := params.MainnetBootnodes
+	preset := NetworkPreset(ctx)
 	switch {
 	case ctx.GlobalIsSet(BootnodesFlag.Name):
 		urls = SplitAndTrim(ctx.GlobalString(BootnodesFlag.Name))
-	case ctx.GlobalBool(TestnetFlag.Name):
-		urls = params.TestnetBootnodes
+	case preset != nil:
+		urls = preset.Bootnodes
 	case cfg.BootstrapNodes != nil:
 		return // already set, don't apply defaults.
 	}
@@ -1194,6 +1512,7 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setNodeUserIdent(ctx, cfg)
 	setDataDir(ctx, cfg)
 	setSmartCard(ctx, cfg)
+	setDBEngine(ctx, cfg)
 
 	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
 		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
@@ -1219,6 +1538,23 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+func setDBEngine(ctx *cli.Context, cfg *node.Config) {
+	if !ctx.GlobalIsSet(DBEngineFlag.Name) {
+		return
+	}
+	switch engine := ctx.GlobalString(DBEngineFlag.Name); engine {
+	case rawdb.DBLeveldb:
+		cfg.DBEngine = engine
+	case rawdb.DBPebble:
+		if !rawdb.PebbleSupported {
+			Fatalf("--%s: pebble is only supported on 64 bit platforms", DBEngineFlag.Name)
+		}
+		cfg.DBEngine = engine
+	default:
+		Fatalf("--%s must be either '%s' or '%s'", DBEngineFlag.Name, rawdb.DBLeveldb, rawdb.DBPebble)
+	}
+}
+
 func setSmartCard(ctx *cli.Context, cfg *node.Config) {
 	// Skip enabling smartcards if no path is set
 	path := ctx.GlobalString(SmartCardDaemonPathFlag.Name)
@@ -1240,13 +1576,14 @@ func setSmartCard(ctx *cli.Context, cfg *node.Config) {
 }
 
 func setDataDir(ctx *cli.Context, cfg *node.Config) {
+	preset := NetworkPreset(ctx)
 	switch {
 	case ctx.GlobalIsSet(DataDirFlag.Name):
 		cfg.DataDir = ctx.GlobalString(DataDirFlag.Name)
 	case ctx.GlobalBool(DeveloperFlag.Name):
 		cfg.DataDir = "" // unless explicitly requested, use memory databases
-	case ctx.GlobalBool(TestnetFlag.Name) && cfg.DataDir == node.DefaultDataDir():
-		cfg.DataDir = filepath.Join(node.DefaultDataDir(), "testnet")
+	case preset != nil && preset.DataDir != "" && cfg.DataDir == node.DefaultDataDir():
+		cfg.DataDir = filepath.Join(node.DefaultDataDir(), preset.DataDir)
 	}
 }
 
@@ -1262,6 +1599,9 @@ func setGPO(ctx *cli.Context, cfg *gasprice.Config, light bool) {
 	if ctx.GlobalIsSet(GpoPercentileFlag.Name) {
 		cfg.Percentile = ctx.GlobalInt(GpoPercentileFlag.Name)
 	}
+	if ctx.GlobalIsSet(GpoMinGasPriceFlag.Name) {
+		cfg.MinPrice = big.NewInt(ctx.GlobalInt64(GpoMinGasPriceFlag.Name))
+	}
 	if ctx.GlobalIsSet(GpoMaxGasPriceFlag.Name) {
 		cfg.MaxPrice = big.NewInt(ctx.GlobalInt64(GpoMaxGasPriceFlag.Name))
 	}
@@ -1311,6 +1651,79 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolResubmitFlag.Name) {
+		cfg.ResubmitConfig.Enabled = ctx.GlobalBool(TxPoolResubmitFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolResubmitBlocksFlag.Name) {
+		cfg.ResubmitConfig.Blocks = ctx.GlobalUint64(TxPoolResubmitBlocksFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolResubmitPriceBumpFlag.Name) {
+		cfg.ResubmitConfig.PriceBump = ctx.GlobalUint64(TxPoolResubmitPriceBumpFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolResubmitMaxBumpsFlag.Name) {
+		cfg.ResubmitConfig.MaxBumps = ctx.GlobalInt(TxPoolResubmitMaxBumpsFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolResubmitMaxPriceFlag.Name) {
+		cfg.ResubmitConfig.MaxGasPrice = ctx.GlobalUint64(TxPoolResubmitMaxPriceFlag.Name)
+	}
+}
+
+func setTxFetcher(ctx *cli.Context, cfg *ethconfig.Config) {
+	if ctx.GlobalIsSet(TxAnnouncesFlag.Name) {
+		cfg.TxFetcher.MaxAnnounces = ctx.GlobalInt(TxAnnouncesFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxUnderpricedSetFlag.Name) {
+		cfg.TxFetcher.UnderpricedSetSize = ctx.GlobalInt(TxUnderpricedSetFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxFloodLimitFlag.Name) {
+		cfg.TxFetcher.FloodLimit = ctx.GlobalInt(TxFloodLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(KnownTxsFlag.Name) {
+		cfg.KnownTxsCacheSize = ctx.GlobalInt(KnownTxsFlag.Name)
+	}
+}
+
+func setValidatorSigner(ctx *cli.Context, cfg *external.FailoverConfig) {
+	if ctx.GlobalIsSet(ValidatorSignerFlag.Name) {
+		cfg.Endpoints = SplitAndTrim(ctx.GlobalString(ValidatorSignerFlag.Name))
+	}
+	if ctx.GlobalIsSet(ValidatorSignerTimeoutFlag.Name) {
+		cfg.Timeout = ctx.GlobalDuration(ValidatorSignerTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(ValidatorSignerRetriesFlag.Name) {
+		cfg.Retries = ctx.GlobalInt(ValidatorSignerRetriesFlag.Name)
+	}
+}
+
+func setStrictForkID(ctx *cli.Context, cfg *ethconfig.Config) {
+	if ctx.GlobalIsSet(StrictForkIDFlag.Name) {
+		cfg.StrictForkID = ctx.GlobalBool(StrictForkIDFlag.Name)
+	}
+}
+
+func setEgress(ctx *cli.Context, cfg *ethconfig.Config) {
+	if ctx.GlobalIsSet(ValidatorPeersFlag.Name) {
+		cfg.ValidatorPeers = SplitAndTrim(ctx.GlobalString(ValidatorPeersFlag.Name))
+	}
+	if ctx.GlobalIsSet(EgressBlockCapFlag.Name) {
+		cfg.EgressBlockCap = ctx.GlobalUint64(EgressBlockCapFlag.Name)
+	}
+	if ctx.GlobalIsSet(EgressTxCapFlag.Name) {
+		cfg.EgressTxCap = ctx.GlobalUint64(EgressTxCapFlag.Name)
+	}
+}
+
+func setLatencyPreference(ctx *cli.Context, cfg *ethconfig.Config) {
+	if ctx.GlobalIsSet(LatencyPeerRatioFlag.Name) {
+		ratio := ctx.GlobalInt(LatencyPeerRatioFlag.Name)
+		if ratio < 0 || ratio > 100 {
+			Fatalf("--%s must be within [0, 100]", LatencyPeerRatioFlag.Name)
+		}
+		cfg.LatencyPeerRatio = ratio
+	}
+	if ctx.GlobalIsSet(LatencyThresholdFlag.Name) {
+		cfg.LatencyThreshold = ctx.GlobalDuration(LatencyThresholdFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1357,6 +1770,12 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.GlobalIsSet(MinerRecommitIntervalFlag.Name) {
 		cfg.Recommit = ctx.GlobalDuration(MinerRecommitIntervalFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerRecommitAdaptiveFlag.Name) {
+		cfg.RecommitAdaptive = ctx.GlobalBool(MinerRecommitAdaptiveFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerAccessListPrefetchFlag.Name) {
+		cfg.AccessListPrefetch = ctx.GlobalBool(MinerAccessListPrefetchFlag.Name)
+	}
 	if ctx.GlobalIsSet(MinerNoVerifyFlag.Name) {
 		cfg.Noverify = ctx.GlobalBool(MinerNoVerifyFlag.Name)
 	}
@@ -1388,6 +1807,16 @@ func setWhitelist(ctx *cli.Context, cfg *ethconfig.Config) {
 	}
 }
 
+func setSyncTarget(ctx *cli.Context, cfg *ethconfig.Config) {
+	if !ctx.GlobalIsSet(SyncTargetFlag.Name) {
+		return
+	}
+	target := ctx.GlobalString(SyncTargetFlag.Name)
+	if err := cfg.SyncTarget.UnmarshalText([]byte(target)); err != nil {
+		Fatalf("Invalid sync target hash %s: %v", target, err)
+	}
+}
+
 // CheckExclusive verifies that only a single instance of the provided flags was
 // set by the user. Each flag might optionally be followed by a string type to
 // specialize it further.
@@ -1432,7 +1861,7 @@ func CheckExclusive(ctx *cli.Context, args ...interface{}) {
 // SetEthConfig applies eth-related command line flags to the config.
 func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	// Avoid conflicting network flags
-	CheckExclusive(ctx, MainnetFlag, DeveloperFlag, TestnetFlag)
+	CheckExclusive(ctx, MainnetFlag, HecoMainnetFlag, DeveloperFlag, TestnetFlag, HecoTestnetFlag)
 	CheckExclusive(ctx, LightServeFlag, SyncModeFlag, "light")
 	CheckExclusive(ctx, DeveloperFlag, ExternalSignerFlag) // Can't use both ephemeral unlocked and external signer
 	if ctx.GlobalString(GCModeFlag.Name) == "archive" && ctx.GlobalUint64(TxLookupLimitFlag.Name) != 0 {
@@ -1449,9 +1878,51 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	setEtherbase(ctx, ks, cfg)
 	setGPO(ctx, &cfg.GPO, ctx.GlobalString(SyncModeFlag.Name) == "light")
 	setTxPool(ctx, &cfg.TxPool)
+	if ctx.GlobalIsSet(TxPoolPrivateFlag.Name) {
+		cfg.TxPrivacy = ctx.GlobalBool(TxPoolPrivateFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolPrivatePeersFlag.Name) {
+		cfg.PrivateTxPeers = SplitAndTrim(ctx.GlobalString(TxPoolPrivatePeersFlag.Name))
+	}
+	setTxFetcher(ctx, cfg)
+	setStrictForkID(ctx, cfg)
+	setEgress(ctx, cfg)
+	setLatencyPreference(ctx, cfg)
 	setEthash(ctx, cfg)
+	if ctx.GlobalIsSet(CongressEpochCheckFlag.Name) {
+		cfg.CongressEpochCheck = ctx.GlobalString(CongressEpochCheckFlag.Name)
+	}
+	if ctx.GlobalIsSet(CongressSysCodeCheckFlag.Name) {
+		cfg.CongressSysCodeCheck = ctx.GlobalString(CongressSysCodeCheckFlag.Name)
+	}
+	if ctx.GlobalIsSet(CongressPreannounceFlag.Name) {
+		cfg.CongressPreannounce = ctx.GlobalBool(CongressPreannounceFlag.Name)
+	}
+	if ctx.GlobalIsSet(CongressMaxParentAgeFlag.Name) {
+		cfg.CongressMaxParentAge = ctx.GlobalDuration(CongressMaxParentAgeFlag.Name)
+	}
+	if ctx.GlobalIsSet(CongressVanityFlag.Name) {
+		cfg.CongressVanity = ctx.GlobalBool(CongressVanityFlag.Name)
+	}
+	if ctx.GlobalIsSet(CongressVanityTagFlag.Name) {
+		cfg.CongressVanity = true
+		cfg.CongressVanityTag = ctx.GlobalString(CongressVanityTagFlag.Name)
+	}
+	if ctx.GlobalIsSet(CongressBlacklistFileFlag.Name) || ctx.GlobalIsSet(CongressBlacklistWebhookFlag.Name) {
+		if cfg.CongressBlacklistWatch == nil {
+			cfg.CongressBlacklistWatch = new(congress.BlacklistWatchConfig)
+		}
+		if ctx.GlobalIsSet(CongressBlacklistFileFlag.Name) {
+			cfg.CongressBlacklistWatch.File = ctx.GlobalString(CongressBlacklistFileFlag.Name)
+		}
+		if ctx.GlobalIsSet(CongressBlacklistWebhookFlag.Name) {
+			cfg.CongressBlacklistWatch.Webhook = ctx.GlobalString(CongressBlacklistWebhookFlag.Name)
+		}
+	}
+	setValidatorSigner(ctx, &cfg.ValidatorSigner)
 	setMiner(ctx, &cfg.Miner)
 	setWhitelist(ctx, cfg)
+	setSyncTarget(ctx, cfg)
 	setLes(ctx, cfg)
 
 	// Cap the cache allowance and tune the garbage collector
@@ -1487,6 +1958,25 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(AncientFlag.Name) {
 		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
 	}
+	if ctx.GlobalIsSet(DBCompressionFlag.Name) {
+		codec := ctx.GlobalString(DBCompressionFlag.Name)
+		if _, err := rawdb.ParseChainDataCodec(codec); err != nil {
+			Fatalf("--%s: %v", DBCompressionFlag.Name, err)
+		}
+		cfg.DatabaseCompression = codec
+	}
+	if ctx.GlobalIsSet(DBSlowQueryFlag.Name) {
+		cfg.DatabaseSlowQuery = ctx.GlobalDuration(DBSlowQueryFlag.Name)
+	}
+	if ctx.GlobalIsSet(BackupIntervalFlag.Name) {
+		cfg.BackupInterval = ctx.GlobalDuration(BackupIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(BackupDirFlag.Name) {
+		cfg.BackupDir = ctx.GlobalString(BackupDirFlag.Name)
+	}
+	if ctx.GlobalIsSet(BackupKeepFlag.Name) {
+		cfg.BackupKeep = ctx.GlobalInt(BackupKeepFlag.Name)
+	}
 
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
@@ -1497,6 +1987,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(CacheNoPrefetchFlag.Name) {
 		cfg.NoPrefetch = ctx.GlobalBool(CacheNoPrefetchFlag.Name)
 	}
+	if ctx.GlobalIsSet(CacheBlockMemFlag.Name) {
+		cfg.BlockMemoryLimit = ctx.GlobalInt(CacheBlockMemFlag.Name)
+	}
+	if ctx.GlobalIsSet(CacheBlockMemCapFlag.Name) {
+		cfg.BlockMemoryCap = ctx.GlobalInt(CacheBlockMemCapFlag.Name)
+	}
 	// Read the value from the flag no matter if it's set or not.
 	cfg.Preimages = ctx.GlobalBool(CachePreimagesFlag.Name)
 	if cfg.NoPruning && !cfg.Preimages {
@@ -1506,6 +2002,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.GlobalUint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.GlobalIsSet(ProofWitnessDepthFlag.Name) {
+		cfg.ProofWitnessDepth = ctx.GlobalUint64(ProofWitnessDepthFlag.Name)
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
 	}
@@ -1537,10 +2036,19 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
 	}
+	if ctx.GlobalIsSet(VMProfileFlag.Name) {
+		cfg.EVMProfileWindow = ctx.GlobalDuration(VMProfileFlag.Name)
+	}
+	if ctx.GlobalIsSet(BlockSTMResearchFlag.Name) {
+		cfg.BlockSTMResearch = ctx.GlobalBool(BlockSTMResearchFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.GlobalUint64(RPCGlobalGasCapFlag.Name)
 	}
+	if ctx.GlobalIsSet(RPCStateCacheFlag.Name) {
+		cfg.RPCStateCacheDepth = ctx.GlobalUint64(RPCStateCacheFlag.Name)
+	}
 	if cfg.RPCGasCap != 0 {
 		log.Info("Set global gas cap", "cap", cfg.RPCGasCap)
 	} else {
@@ -1549,6 +2057,33 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(RPCGlobalEVMTimeoutFlag.Name) {
 		cfg.RPCEVMTimeout = ctx.GlobalDuration(RPCGlobalEVMTimeoutFlag.Name)
 	}
+	if ctx.GlobalIsSet(RPCEstimateGasCapFlag.Name) {
+		cfg.RPCEstimateGasCap = ctx.GlobalUint64(RPCEstimateGasCapFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCEstimateEVMTimeoutFlag.Name) {
+		cfg.RPCEstimateEVMTimeout = ctx.GlobalDuration(RPCEstimateEVMTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCTraceGasCapFlag.Name) {
+		cfg.RPCTraceGasCap = ctx.GlobalUint64(RPCTraceGasCapFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCTraceEVMTimeoutFlag.Name) {
+		cfg.RPCTraceEVMTimeout = ctx.GlobalDuration(RPCTraceEVMTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCHeavyCallLimitFlag.Name) {
+		cfg.RPCHeavyCallLimit = ctx.GlobalInt(RPCHeavyCallLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCResponseCacheFlag.Name) {
+		cfg.RPCResponseCache = ctx.GlobalInt(RPCResponseCacheFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCResponseCacheTTLFlag.Name) {
+		cfg.RPCResponseCacheTTL = ctx.GlobalDuration(RPCResponseCacheTTLFlag.Name)
+	}
+	if ctx.GlobalIsSet(GovernorFlag.Name) {
+		cfg.Governor = ctx.GlobalBool(GovernorFlag.Name)
+	}
+	if ctx.GlobalIsSet(GovernorThresholdFlag.Name) {
+		cfg.GovernorThreshold = ctx.GlobalDuration(GovernorThresholdFlag.Name)
+	}
 	if ctx.GlobalIsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.GlobalFloat64(RPCGlobalTxFeeCapFlag.Name)
 	}
@@ -1563,18 +2098,13 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		}
 	}
 	// Override any default configs for hard coded networks.
-	switch {
-	case ctx.GlobalBool(MainnetFlag.Name):
-		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
-			cfg.NetworkId = 128
-		}
-		cfg.Genesis = core.DefaultGenesisBlock()
-		SetDNSDiscoveryDefaults(cfg, params.MainnetGenesisHash)
-	case ctx.GlobalBool(TestnetFlag.Name):
+	switch preset := NetworkPreset(ctx); {
+	case preset != nil:
 		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
-			cfg.NetworkId = 256
+			cfg.NetworkId = preset.NetworkID
 		}
-		cfg.Genesis = core.DefaultTestnetGenesisBlock()
+		cfg.Genesis = preset.Genesis()
+		SetDNSDiscoveryDefaults(cfg, preset.GenesisHash)
 	case ctx.GlobalBool(DeveloperFlag.Name):
 		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
 			cfg.NetworkId = 1337
@@ -1679,6 +2209,19 @@ func RegisterEthStatsService(stack *node.Node, backend ethapi.Backend, url strin
 	}
 }
 
+// RegisterChainSinkService configures the chain event sink publishing to the
+// broker and adds it to the given node.
+func RegisterChainSinkService(ctx *cli.Context, stack *node.Node, backend ethapi.Backend) {
+	config := chainsink.Config{
+		URL:            ctx.GlobalString(ChainSinkURLFlag.Name),
+		Prefix:         ctx.GlobalString(ChainSinkPrefixFlag.Name),
+		FinalizedDepth: ctx.GlobalUint64(ChainSinkDepthFlag.Name),
+	}
+	if err := chainsink.New(stack, backend, config); err != nil {
+		Fatalf("Failed to register the chain event sink: %v", err)
+	}
+}
+
 // RegisterGraphQLService is a utility function to construct a new service and register it against a node.
 func RegisterGraphQLService(stack *node.Node, backend ethapi.Backend, cfg node.Config) {
 	if err := graphql.New(stack, backend, cfg.GraphQLCors, cfg.GraphQLVirtualHosts); err != nil {
@@ -1686,6 +2229,34 @@ func RegisterGraphQLService(stack *node.Node, backend ethapi.Backend, cfg node.C
 	}
 }
 
+// RegisterLogExportService mounts the log export endpoint on the HTTP-RPC server
+// of the node.
+func RegisterLogExportService(ctx *cli.Context, stack *node.Node, backend ethapi.Backend, cfg node.Config) {
+	filterBackend, ok := backend.(filters.Backend)
+	if !ok {
+		Fatalf("Log export is not supported by the %T backend", backend)
+	}
+	config := filters.ExportConfig{
+		BlockRate:  ctx.GlobalInt(LogExportRateFlag.Name),
+		MaxStreams: ctx.GlobalInt(LogExportStreamsFlag.Name),
+	}
+	handler := node.NewHTTPHandlerStack(filters.NewExportHandler(filterBackend, config), cfg.HTTPCors, cfg.HTTPVirtualHosts)
+	stack.RegisterHandler("Log export", "/logs/export", handler)
+}
+
+// SetupGoPools sizes the goroutine pools of the subsystems as configured.
+func SetupGoPools(ctx *cli.Context) {
+	if ctx.GlobalIsSet(GoPoolBloomFlag.Name) {
+		gopool.Bloom.Resize(ctx.GlobalInt(GoPoolBloomFlag.Name))
+	}
+	if ctx.GlobalIsSet(GoPoolPreloadFlag.Name) {
+		gopool.Preload.Resize(ctx.GlobalInt(GoPoolPreloadFlag.Name))
+	}
+	if ctx.GlobalIsSet(GoPoolVerifyFlag.Name) {
+		gopool.Verification.Resize(ctx.GlobalInt(GoPoolVerifyFlag.Name))
+	}
+}
+
 func SetupMetrics(ctx *cli.Context) {
 	if metrics.Enabled {
 		log.Info("Enabling metrics collection")
@@ -1762,6 +2333,21 @@ func SplitTagsFlag(tagsFlag string) map[string]string {
 	return tagsMap
 }
 
+// MakeValidatorSigner connects to the remote validator signers configured by the
+// flags and will hard crash if none is configured or reachable.
+func MakeValidatorSigner(ctx *cli.Context) *external.FailoverSigner {
+	cfg := ethconfig.Defaults.ValidatorSigner
+	setValidatorSigner(ctx, &cfg)
+	if len(cfg.Endpoints) == 0 {
+		Fatalf("No remote signer specified, use --%s", ValidatorSignerFlag.Name)
+	}
+	signer, err := external.NewFailoverSigner(cfg)
+	if err != nil {
+		Fatalf("Could not connect to the remote signers: %v", err)
+	}
+	return signer
+}
+
 // MakeChainDatabase open an LevelDB using the flags passed to the client and will hard crash if it fails.
 func MakeChainDatabase(ctx *cli.Context, stack *node.Node, readonly bool) ethdb.Database {
 	var (
@@ -1786,11 +2372,9 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node, readonly bool) ethdb.
 
 func MakeGenesis(ctx *cli.Context) *core.Genesis {
 	var genesis *core.Genesis
-	switch {
-	case ctx.GlobalBool(MainnetFlag.Name):
-		genesis = core.DefaultGenesisBlock()
-	case ctx.GlobalBool(TestnetFlag.Name):
-		genesis = core.DefaultTestnetGenesisBlock()
+	switch preset := NetworkPreset(ctx); {
+	case preset != nil:
+		genesis = preset.Genesis()
 	case ctx.GlobalBool(DeveloperFlag.Name):
 		Fatalf("Developer chains are ephemeral")
 	}
@@ -1805,9 +2389,14 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	if err != nil {
 		Fatalf("%v", err)
 	}
-	var engine consensus.Engine
+	var (
+		engine   consensus.Engine
+		chainRef = new(congress.ChainRef)
+	)
 	if config.Clique != nil {
 		engine = clique.New(config.Clique, chainDb)
+	} else if config.Congress != nil {
+		engine = congress.New(config, chainDb, chainRef)
 	} else {
 		engine = ethash.NewFaker()
 		if !ctx.GlobalBool(FakePoWFlag.Name) {
@@ -1834,6 +2423,9 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		TrieTimeLimit:       ethconfig.Defaults.TrieTimeout,
 		SnapshotLimit:       ethconfig.Defaults.SnapshotCache,
 		Preimages:           ctx.GlobalBool(CachePreimagesFlag.Name),
+		BlockMemoryLimit:    ctx.GlobalInt(CacheBlockMemFlag.Name),
+		BlockMemoryCap:      ctx.GlobalInt(CacheBlockMemCapFlag.Name),
+		BlockSpillDir:       stack.ResolvePath("logspill"),
 	}
 	if cache.TrieDirtyDisabled && !cache.Preimages {
 		cache.Preimages = true
@@ -1856,6 +2448,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	if err != nil {
 		Fatalf("Can't create BlockChain: %v", err)
 	}
+	chainRef.BlockChain = chain
 	return chain, chainDb
 }
 
@@ -4,13 +4,109 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/panjf2000/ants/v2"
 )
 
+const (
+	// expiry is the time an idle worker is kept for, block interval is 3
+	expiry = 5 * time.Second
+
+	// maxWaitingPerWorker bounds the tasks blocked in Submit waiting for a free
+	// worker, per worker of the pool. Past it the pool is overloaded and Submit
+	// fails fast, the callers running the task inline instead of piling it up.
+	maxWaitingPerWorker = 64
+)
+
 var (
-	defaultPool, _ = ants.NewPool(runtime.NumCPU(), ants.WithExpiryDuration(5*time.Second)) // block interval is 3
+	// Bloom is the pool creating the receipt blooms during block processing.
+	Bloom = newPool("bloom", runtime.NumCPU())
+
+	// Preload is the pool preloading the accounts of the block transactions from
+	// the snapshot.
+	Preload = newPool("preload", runtime.NumCPU())
+
+	// Verification is the pool recovering the transaction senders.
+	Verification = newPool("verify", runtime.NumCPU())
+
+	// defaultPool runs all the other tasks, e.g. the parallel hashing.
+	defaultPool = newPool("default", runtime.NumCPU())
 )
 
+// Pool is a bounded pool of goroutines running the tasks of a subsystem, sized
+// once at startup. The tasks submitted while all the workers are busy wait for a
+// free one, up to a limit past which Submit fails.
+type Pool struct {
+	name string
+	pool *ants.Pool
+
+	queued   metrics.Gauge // Tasks submitted but not started yet
+	wait     metrics.Timer // Time from the submission of a task to its start
+	rejected metrics.Meter // Tasks rejected because the pool is overloaded
+}
+
+// newPool creates a pool of the given number of workers.
+func newPool(name string, size int) *Pool {
+	pool, err := ants.NewPool(size, ants.WithExpiryDuration(expiry), ants.WithMaxBlockingTasks(size*maxWaitingPerWorker))
+	if err != nil {
+		panic(err)
+	}
+	return &Pool{
+		name:     name,
+		pool:     pool,
+		queued:   metrics.NewRegisteredGauge("gopool/"+name+"/queued", nil),
+		wait:     metrics.NewRegisteredTimer("gopool/"+name+"/wait", nil),
+		rejected: metrics.NewRegisteredMeter("gopool/"+name+"/rejected", nil),
+	}
+}
+
+// Submit runs the task on a worker of the pool, blocking while all the workers
+// are busy. It fails without running the task if too many tasks are waiting, Run
+// runs it inline then.
+func (p *Pool) Submit(task func()) error {
+	p.queued.Inc(1)
+	submitted := time.Now()
+	err := p.pool.Submit(func() {
+		p.queued.Dec(1)
+		p.wait.UpdateSince(submitted)
+		task()
+	})
+	if err != nil {
+		p.queued.Dec(1)
+		p.rejected.Mark(1)
+	}
+	return err
+}
+
+// Run runs the task on a worker of the pool like Submit, or inline if the pool
+// is overloaded.
+func (p *Pool) Run(task func()) {
+	if err := p.Submit(task); err != nil {
+		task()
+	}
+}
+
+// Resize sets the number of workers of the pool, ignored if not positive.
+func (p *Pool) Resize(size int) {
+	if size <= 0 || size == p.pool.Cap() {
+		return
+	}
+	log.Info("Resized goroutine pool", "name", p.name, "size", size)
+	p.pool.Tune(size)
+}
+
+// Size returns the number of workers of the pool.
+func (p *Pool) Size() int {
+	return p.pool.Cap()
+}
+
+// Submit runs the task on the default pool, see Pool.Submit.
 func Submit(task func()) error {
 	return defaultPool.Submit(task)
 }
+
+// Run runs the task on the default pool, see Pool.Run.
+func Run(task func()) {
+	defaultPool.Run(task)
+}
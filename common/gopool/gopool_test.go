@@ -0,0 +1,107 @@
+package gopool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Tests that the pool can be resized, the invalid sizes being ignored.
+func TestPoolResize(t *testing.T) {
+	p := newPool("test-resize", 2)
+	if p.Size() != 2 {
+		t.Fatalf("size mismatch: have %d, want 2", p.Size())
+	}
+	p.Resize(4)
+	if p.Size() != 4 {
+		t.Fatalf("size mismatch after resize: have %d, want 4", p.Size())
+	}
+	p.Resize(0)
+	p.Resize(-1)
+	if p.Size() != 4 {
+		t.Fatalf("size changed by invalid resize: have %d, want 4", p.Size())
+	}
+	// The added workers run tasks concurrently
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		if err := p.Submit(func() {
+			defer wg.Done()
+			started <- struct{}{}
+			<-release
+		}); err != nil {
+			t.Fatalf("task %d rejected: %v", i, err)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second):
+			t.Fatalf("only %d tasks running concurrently, want 4", i)
+		}
+	}
+	close(release)
+	wg.Wait()
+}
+
+// Tests that the tasks submitted past the waiting limit of a busy pool are
+// rejected without being run, and that Run runs them inline instead.
+func TestPoolOverload(t *testing.T) {
+	p := newPool("test-overload", 1)
+
+	// Occupy the only worker, then fill up the waiting tasks
+	var (
+		release = make(chan struct{})
+		ran     int32
+		wg      sync.WaitGroup
+	)
+	if err := p.Submit(func() { <-release }); err != nil {
+		t.Fatalf("blocking task rejected: %v", err)
+	}
+	var (
+		extra    = 8
+		rejected = make(chan struct{}, extra)
+	)
+	for i := 0; i < maxWaitingPerWorker+extra; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.Submit(func() { atomic.AddInt32(&ran, 1) }); err != nil {
+				rejected <- struct{}{}
+			}
+		}()
+	}
+	// At most the waiting limit blocks, the others are rejected
+	for i := 0; i < extra; i++ {
+		select {
+		case <-rejected:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d tasks rejected, want %d", i, extra)
+		}
+	}
+	// Run executes the rejected tasks inline, on the calling goroutine
+	var inline bool
+	p.Run(func() { inline = true })
+	if !inline {
+		t.Fatalf("overloaded task not run inline")
+	}
+	// Once the worker is released, only the waiting tasks run
+	close(release)
+	wg.Wait()
+	for deadline := time.Now().Add(5 * time.Second); atomic.LoadInt32(&ran) < maxWaitingPerWorker && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if n := atomic.LoadInt32(&ran); n != maxWaitingPerWorker {
+		t.Fatalf("run tasks mismatch: have %d, want %d", n, maxWaitingPerWorker)
+	}
+	// An idle pool runs the tasks on its workers
+	done := make(chan struct{})
+	p.Run(func() { close(done) })
+	<-done
+}
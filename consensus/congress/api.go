@@ -18,8 +18,10 @@ package congress
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -90,16 +92,152 @@ func (api *API) GetValidatorsAtHash(hash common.Hash) ([]common.Address, error)
 	return snap.validators(), nil
 }
 
+// GetSystemGasUsed retrieves the gas used by the consensus system calls of the
+// specified block, which is not included in the gasUsed of the header. Only the
+// recent blocks finalized by the node are known, kept across clean restarts.
+func (api *API) GetSystemGasUsed(number *rpc.BlockNumber) (hexutil.Uint64, error) {
+	var header *types.Header
+	if number == nil || *number == rpc.LatestBlockNumber {
+		header = api.chain.CurrentHeader()
+	} else {
+		header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return 0, errUnknownBlock
+	}
+	used, ok := api.congress.systemGas.get(SealHash(header))
+	if !ok {
+		return 0, errUnknownSystemGas
+	}
+	return hexutil.Uint64(used), nil
+}
+
+// GetPendingBlacklist retrieves the blacklist changes made but not enforced yet at
+// the next block, due to the activation delay of the blacklist updates.
+func (api *API) GetPendingBlacklist() ([]*PendingBlacklistEntry, error) {
+	head := api.chain.CurrentHeader()
+	statedb, err := api.congress.chain.StateAt(head.Root)
+	if err != nil {
+		return nil, err
+	}
+	next := &types.Header{
+		ParentHash: head.Hash(),
+		Number:     new(big.Int).Add(head.Number, common.Big1),
+		Coinbase:   head.Coinbase,
+		Difficulty: new(big.Int),
+		GasLimit:   head.GasLimit,
+		Time:       head.Time,
+	}
+	enforced, pending, err := api.congress.resolveBlacklist(next, statedb)
+	if err != nil {
+		return nil, err
+	}
+	return pendingBlacklistEntries(enforced, pending), nil
+}
+
+// DecodeExtra decodes the extra-data of the specified header and checks it against
+// the extra-data rules, to debug the headers rejected on import.
+func (api *API) DecodeExtra(blockNrOrHash rpc.BlockNumberOrHash) (*DecodedExtra, error) {
+	var header *types.Header
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header = api.chain.GetHeaderByHash(hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+			header = api.chain.CurrentHeader()
+		} else {
+			header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+		}
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	return api.congress.decodeExtra(api.chain, header), nil
+}
+
+// GetVanityTags decodes the client versions and operator tags from the vanity
+// prefixes of the last count blocks (64 if unspecified), reporting the client
+// version distribution among the validators sealing them.
+func (api *API) GetVanityTags(count *uint64) (*VanityReport, error) {
+	blocks := uint64(defaultVanityBlocks)
+	if count != nil && *count > 0 {
+		blocks = *count
+	}
+	if blocks > maxVanityBlocks {
+		return nil, fmt.Errorf("too many blocks requested: %d, max %d", blocks, maxVanityBlocks)
+	}
+	to := api.chain.CurrentHeader().Number.Uint64()
+	from := uint64(1)
+	if to >= blocks {
+		from = to - blocks + 1
+	}
+	if to < from {
+		return nil, errUnknownBlock
+	}
+	return api.congress.vanityReport(api.chain, from, to)
+}
+
+// SimulateProposal executes the system governance proposal on top of the specified
+// block the way Finalize would, without changing the chain, so that validators
+// can evaluate the proposal before voting.
+func (api *API) SimulateProposal(args ProposalArgs, blockNrOrHash rpc.BlockNumberOrHash) (*ProposalSimulation, error) {
+	var header *types.Header
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header = api.chain.GetHeaderByHash(hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+			header = api.chain.CurrentHeader()
+		} else {
+			header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+		}
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	statedb, err := api.congress.chain.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	return api.congress.simulateProposal(api.chain, header, statedb, args.toProposal()), nil
+}
+
+// CheckLogAgainstRules checks whether the event log would be denied if emitted in
+// the block following the specified one, against the event check rules and the
+// blacklist in force there, so that token teams can make sure their events pass
+// before deploying.
+func (api *API) CheckLogAgainstRules(args EventLogArgs, blockNrOrHash rpc.BlockNumberOrHash) (*LogRuleCheck, error) {
+	var header *types.Header
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header = api.chain.GetHeaderByHash(hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+			header = api.chain.CurrentHeader()
+		} else {
+			header = api.chain.GetHeaderByNumber(uint64(number.Int64()))
+		}
+	}
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	statedb, err := api.congress.chain.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	return api.congress.checkLogAgainstRules(header, statedb, &args)
+}
+
 type status struct {
 	InturnPercent float64                `json:"inturnPercent"`
 	SigningStatus map[common.Address]int `json:"sealerActivity"`
 	NumBlocks     uint64                 `json:"numBlocks"`
+	Validator     common.Address         `json:"validator"` // Local validator address, zero if not authorized
+	Active        bool                   `json:"active"`    // Whether the local validator is in the current set
 }
 
 // Status returns the status of the last N blocks,
 // - the number of active validators,
 // - the number of validators,
 // - the percentage of in-turn blocks
+// along with the local validator and whether it's currently a validator.
 func (api *API) Status() (*status, error) {
 	var (
 		numBlocks = uint64(64)
@@ -139,9 +277,16 @@ func (api *API) Status() (*status, error) {
 		}
 		signStatus[sealer]++
 	}
+	api.congress.lock.RLock()
+	validator := api.congress.validator
+	api.congress.lock.RUnlock()
+
+	_, active := snap.Validators[validator]
 	return &status{
 		InturnPercent: float64(100*optimals) / float64(numBlocks),
 		SigningStatus: signStatus,
 		NumBlocks:     numBlocks,
+		Validator:     validator,
+		Active:        active && validator != (common.Address{}),
 	}, nil
 }
@@ -0,0 +1,53 @@
+package congress
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+)
+
+// attestationPrefix domain separates the head attestations from any other data
+// signed by the validator keys.
+var attestationPrefix = []byte("\x19HECO Attestation:\n")
+
+// AttestationMessage returns the message a validator signs to announce the block
+// of the given number and hash as its canonical one.
+func AttestationMessage(chainID *big.Int, number uint64, hash common.Hash) []byte {
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], number)
+
+	msg := make([]byte, 0, len(attestationPrefix)+32+8+common.HashLength)
+	msg = append(msg, attestationPrefix...)
+	msg = append(msg, common.BigToHash(chainID).Bytes()...)
+	msg = append(msg, enc[:]...)
+	return append(msg, hash.Bytes()...)
+}
+
+// SignAttestation signs a head attestation message with the local validator key,
+// returning the validator address along with the signature.
+func (c *Congress) SignAttestation(msg []byte) (common.Address, []byte, error) {
+	return c.signMessage(accounts.MimetypeAttestation, msg)
+}
+
+// RecoverAttestation returns the validator that signed a head attestation.
+func RecoverAttestation(msg []byte, sig []byte) (common.Address, error) {
+	return RecoverPreconfirmation(msg, sig)
+}
+
+// IsCurrentValidator reports whether the address is in the validator set of the
+// current head of the chain.
+func (c *Congress) IsCurrentValidator(chain consensus.ChainHeaderReader, validator common.Address) bool {
+	head := chain.CurrentHeader()
+	if head == nil {
+		return false
+	}
+	snap, err := c.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return false
+	}
+	_, ok := snap.Validators[validator]
+	return ok
+}
@@ -0,0 +1,236 @@
+package congress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// The blacklist updates past the blacklist delay fork are queued in the storage of
+	// the system governance contract until enforced, so that the blacklist in force is
+	// resolved from the parent state alone. The queue slot holds the number of updates,
+	// each update holding its block, its entry count and its entries from its own slot.
+	blacklistQueueSlot = crypto.Keccak256Hash([]byte("congress.blacklist.delayed"))
+
+	// errBlacklistUnresolved is returned if the blacklist in force at a block can't be
+	// resolved from its parent state, the block failing.
+	errBlacklistUnresolved = errors.New("blacklist unresolved")
+)
+
+// String implements the fmt.Stringer interface.
+func (d blacklistDirection) String() string {
+	switch d {
+	case DirectionFrom:
+		return "from"
+	case DirectionTo:
+		return "to"
+	case DirectionBoth:
+		return "both"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint(d))
+	}
+}
+
+// blacklistUpdate is an update of the blacklist made but not enforced yet.
+type blacklistUpdate struct {
+	number     uint64                                // Block the update was made in
+	activation uint64                                // First block the update is enforced at
+	blacks     map[common.Address]blacklistDirection // Blacklist of the contract after the update
+}
+
+// blacklistActivation returns the first block an update of the blacklist made in
+// the given block is enforced at.
+func (c *Congress) blacklistActivation(updated uint64) uint64 {
+	return updated + c.config.BlacklistDelayAt(new(big.Int).SetUint64(updated)) + 1
+}
+
+// blacklistDelayed returns whether the blacklist updates are queued before being
+// enforced at the given block.
+func (c *Congress) blacklistDelayed(number *big.Int) bool {
+	return c.blacklistActive(number) && c.config.BlacklistDelayAt(number) > 0
+}
+
+// blacklistUpdateSlot returns the first storage slot of the i-th queued update.
+func blacklistUpdateSlot(i uint64) common.Hash {
+	return crypto.Keccak256Hash(blacklistQueueSlot[:], common.BigToHash(new(big.Int).SetUint64(i)).Bytes())
+}
+
+// offsetSlot returns the storage slot at the given offset from another one.
+func offsetSlot(slot common.Hash, offset uint64) common.Hash {
+	return common.BigToHash(new(big.Int).Add(slot.Big(), new(big.Int).SetUint64(offset)))
+}
+
+// readBlacklistQueue reads the queued blacklist updates from the given state, the
+// oldest first.
+func (c *Congress) readBlacklistQueue(state consensus.StateReader) []*blacklistUpdate {
+	count := state.GetState(systemcontract.SysGovContractAddr, blacklistQueueSlot).Big().Uint64()
+
+	queue := make([]*blacklistUpdate, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var (
+			slot    = blacklistUpdateSlot(i)
+			number  = state.GetState(systemcontract.SysGovContractAddr, slot).Big().Uint64()
+			entries = state.GetState(systemcontract.SysGovContractAddr, offsetSlot(slot, 1)).Big().Uint64()
+			blacks  = make(map[common.Address]blacklistDirection, entries)
+		)
+		for j := uint64(0); j < entries; j++ {
+			entry := state.GetState(systemcontract.SysGovContractAddr, offsetSlot(slot, j+2))
+			blacks[common.BytesToAddress(entry[common.HashLength-common.AddressLength:])] = blacklistDirection(entry[common.HashLength-common.AddressLength-1])
+		}
+		queue = append(queue, &blacklistUpdate{number: number, activation: c.blacklistActivation(number), blacks: blacks})
+	}
+	return queue
+}
+
+// writeBlacklistQueue replaces the queued blacklist updates of the given state,
+// clearing the slots of the previous ones.
+func writeBlacklistQueue(state *state.StateDB, prev, queue []*blacklistUpdate) {
+	for i, update := range prev {
+		slot := blacklistUpdateSlot(uint64(i))
+		for j := uint64(0); j < uint64(len(update.blacks))+2; j++ {
+			state.SetState(systemcontract.SysGovContractAddr, offsetSlot(slot, j), common.Hash{})
+		}
+	}
+	state.SetState(systemcontract.SysGovContractAddr, blacklistQueueSlot, common.BigToHash(new(big.Int).SetUint64(uint64(len(queue)))))
+	for i, update := range queue {
+		slot := blacklistUpdateSlot(uint64(i))
+		state.SetState(systemcontract.SysGovContractAddr, slot, common.BigToHash(new(big.Int).SetUint64(update.number)))
+		state.SetState(systemcontract.SysGovContractAddr, offsetSlot(slot, 1), common.BigToHash(big.NewInt(int64(len(update.blacks)))))
+
+		addrs := make([]common.Address, 0, len(update.blacks))
+		for addr := range update.blacks {
+			addrs = append(addrs, addr)
+		}
+		sort.Slice(addrs, func(i, j int) bool {
+			return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+		})
+		for j, addr := range addrs {
+			var entry common.Hash
+			entry[common.HashLength-common.AddressLength-1] = byte(update.blacks[addr])
+			copy(entry[common.HashLength-common.AddressLength:], addr[:])
+			state.SetState(systemcontract.SysGovContractAddr, offsetSlot(slot, uint64(j)+2), entry)
+		}
+	}
+}
+
+// blacklistQueue returns the queued blacklist updates at the header, the oldest
+// first, the first one being enforced, and whether the queue of the parent state
+// is outdated. An update of the parent block is queued, and the updates enforced
+// drop the older ones. An empty queue starts with the blacklist of the parent
+// state, enforced right away.
+func (c *Congress) blacklistQueue(header *types.Header, parentState *state.StateDB) ([]*blacklistUpdate, bool, error) {
+	var (
+		queue   = c.readBlacklistQueue(parentState)
+		updated = lastBlacklistUpdatedNumber(parentState)
+		changed bool
+	)
+	if len(queue) == 0 || updated > queue[len(queue)-1].number {
+		blacks, err := c.readBlacklist(header, parentState)
+		if err != nil {
+			return nil, false, err
+		}
+		queue = append(queue, &blacklistUpdate{number: updated, activation: c.blacklistActivation(updated), blacks: blacks})
+		changed = true
+	}
+	enforced := 0
+	for i, update := range queue {
+		if update.activation <= header.Number.Uint64() {
+			enforced = i
+		}
+	}
+	if enforced > 0 {
+		queue, changed = queue[enforced:], true
+	}
+	return queue, changed, nil
+}
+
+// updateBlacklistQueue brings the queued blacklist updates of the parent state up
+// to date for the header, ahead of its transactions.
+func (c *Congress) updateBlacklistQueue(header *types.Header, parentState *state.StateDB) error {
+	prev := c.readBlacklistQueue(parentState)
+	queue, changed, err := c.blacklistQueue(header, parentState)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errBlacklistUnresolved, err)
+	}
+	if changed {
+		writeBlacklistQueue(parentState, prev, queue)
+	}
+	return nil
+}
+
+// resolveBlacklist returns the blacklist enforced at the header, along with the
+// updates made but not enforced yet, the newest first, from the parent state.
+func (c *Congress) resolveBlacklist(header *types.Header, parentState *state.StateDB) (map[common.Address]blacklistDirection, []*blacklistUpdate, error) {
+	if !c.blacklistDelayed(header.Number) {
+		blacks, err := c.readBlacklist(header, parentState)
+		return blacks, nil, err
+	}
+	queue, _, err := c.blacklistQueue(header, parentState)
+	if err != nil {
+		return nil, nil, err
+	}
+	pending := make([]*blacklistUpdate, 0, len(queue)-1)
+	for i := len(queue) - 1; i > 0; i-- {
+		pending = append(pending, queue[i])
+	}
+	return queue[0].blacks, pending, nil
+}
+
+// PendingBlacklistEntry is a change of the blacklist made but not enforced yet.
+type PendingBlacklistEntry struct {
+	Address    common.Address `json:"address"`
+	Direction  string         `json:"direction"`  // Direction once enforced, empty if removed
+	Previous   string         `json:"previous"`   // Direction before the change, empty if not blacklisted
+	Number     hexutil.Uint64 `json:"number"`     // Block the change was made in
+	Activation hexutil.Uint64 `json:"activation"` // First block the change is enforced at
+}
+
+// pendingBlacklistEntries flattens the pending updates, the newest first, into the
+// changes they make on top of the enforced blacklist, ordered by block and address.
+func pendingBlacklistEntries(enforced map[common.Address]blacklistDirection, pending []*blacklistUpdate) []*PendingBlacklistEntry {
+	entries := make([]*PendingBlacklistEntry, 0)
+
+	prev := enforced
+	for i := len(pending) - 1; i >= 0; i-- {
+		update := pending[i]
+		add := func(addr common.Address, direction, previous string) {
+			entries = append(entries, &PendingBlacklistEntry{
+				Address:    addr,
+				Direction:  direction,
+				Previous:   previous,
+				Number:     hexutil.Uint64(update.number),
+				Activation: hexutil.Uint64(update.activation),
+			})
+		}
+		start := len(entries)
+		for addr, d := range update.blacks {
+			if old, ok := prev[addr]; !ok {
+				add(addr, d.String(), "")
+			} else if old != d {
+				add(addr, d.String(), old.String())
+			}
+		}
+		for addr, old := range prev {
+			if _, ok := update.blacks[addr]; !ok {
+				add(addr, "", old.String())
+			}
+		}
+		changed := entries[start:]
+		sort.Slice(changed, func(i, j int) bool {
+			return bytes.Compare(changed[i].Address[:], changed[j].Address[:]) < 0
+		})
+		prev = update.blacks
+	}
+	return entries
+}
@@ -0,0 +1,116 @@
+package congress
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestBlacklistActivation(t *testing.T) {
+	c := &Congress{config: &params.CongressConfig{BlacklistDelay: 20, BlacklistDelayBlock: big.NewInt(100)}}
+
+	tests := []struct {
+		updated uint64
+		want    uint64
+	}{
+		{0, 1},
+		{99, 100},  // Updates before the fork are enforced on the next block
+		{100, 121}, // Updates after the fork wait for the delay
+		{150, 171},
+	}
+	for i, tt := range tests {
+		if have := c.blacklistActivation(tt.updated); have != tt.want {
+			t.Errorf("test %d: activation mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+}
+
+func TestPendingBlacklistEntries(t *testing.T) {
+	var (
+		a = common.HexToAddress("0x01")
+		b = common.HexToAddress("0x02")
+		c = common.HexToAddress("0x03")
+	)
+	enforced := map[common.Address]blacklistDirection{a: DirectionFrom, b: DirectionTo}
+	pending := []*blacklistUpdate{
+		// Newest first: unblacklist b, then blacklist c
+		{number: 12, activation: 33, blacks: map[common.Address]blacklistDirection{a: DirectionBoth, c: DirectionFrom}},
+		{number: 10, activation: 31, blacks: map[common.Address]blacklistDirection{a: DirectionBoth, b: DirectionTo, c: DirectionFrom}},
+	}
+	want := []PendingBlacklistEntry{
+		{Address: a, Direction: "both", Previous: "from", Number: 10, Activation: 31},
+		{Address: c, Direction: "from", Previous: "", Number: 10, Activation: 31},
+		{Address: b, Direction: "", Previous: "to", Number: 12, Activation: 33},
+	}
+	have := pendingBlacklistEntries(enforced, pending)
+	if len(have) != len(want) {
+		t.Fatalf("entry count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for i := range want {
+		if *have[i] != want[i] {
+			t.Errorf("entry %d mismatch: have %+v, want %+v", i, *have[i], want[i])
+		}
+	}
+	if entries := pendingBlacklistEntries(enforced, nil); entries == nil || len(entries) != 0 {
+		t.Errorf("entries without pending updates mismatch: have %v", entries)
+	}
+}
+
+func TestBlacklistQueue(t *testing.T) {
+	c := &Congress{config: &params.CongressConfig{BlacklistDelay: 20, BlacklistDelayBlock: big.NewInt(100)}}
+
+	var (
+		a = common.HexToAddress("0x01")
+		b = common.HexToAddress("0x02")
+	)
+	queue := []*blacklistUpdate{
+		{number: 90, activation: 91, blacks: map[common.Address]blacklistDirection{a: DirectionFrom}},
+		{number: 110, activation: 131, blacks: map[common.Address]blacklistDirection{a: DirectionFrom, b: DirectionTo}},
+		{number: 120, activation: 141, blacks: map[common.Address]blacklistDirection{b: DirectionBoth}},
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetState(systemcontract.AddressListContractAddr, systemcontract.BlackLastUpdatedNumberPosition, common.BigToHash(big.NewInt(120)))
+	writeBlacklistQueue(statedb, nil, queue)
+
+	if have := c.readBlacklistQueue(statedb); !reflect.DeepEqual(have, queue) {
+		t.Fatalf("queue mismatch: have %v, want %v", have, queue)
+	}
+	// The enforced updates drop the older ones
+	tests := []struct {
+		number  int64
+		queue   []*blacklistUpdate
+		changed bool
+	}{
+		{130, queue, false},
+		{131, queue[1:], true},
+		{140, queue[1:], true},
+		{141, queue[2:], true},
+		{200, queue[2:], true},
+	}
+	for i, tt := range tests {
+		have, changed, err := c.blacklistQueue(&types.Header{Number: big.NewInt(tt.number)}, statedb)
+		if err != nil {
+			t.Fatalf("test %d: failed to resolve the queue: %v", i, err)
+		}
+		if !reflect.DeepEqual(have, tt.queue) || changed != tt.changed {
+			t.Errorf("test %d: queue mismatch: have %v (changed %v), want %v (changed %v)", i, have, changed, tt.queue, tt.changed)
+		}
+	}
+	// Replacing the queue must clear the slots of the dropped updates
+	writeBlacklistQueue(statedb, queue, queue[2:])
+	if have := c.readBlacklistQueue(statedb); !reflect.DeepEqual(have, queue[2:]) {
+		t.Fatalf("replaced queue mismatch: have %v, want %v", have, queue[2:])
+	}
+	for _, slot := range []common.Hash{offsetSlot(blacklistUpdateSlot(1), 3), offsetSlot(blacklistUpdateSlot(2), 2)} {
+		if value := statedb.GetState(systemcontract.SysGovContractAddr, slot); value != (common.Hash{}) {
+			t.Errorf("slot %x not cleared: %x", slot, value)
+		}
+	}
+}
@@ -12,8 +12,9 @@ type EventCheckRule struct {
 }
 
 type blacklistValidator struct {
-	blacks map[common.Address]blacklistDirection
-	rules  map[common.Hash]*EventCheckRule
+	blacks     map[common.Address]blacklistDirection
+	rules      map[common.Hash]*EventCheckRule
+	proxyCheck bool // whether the ProxyCheck fork is activated
 }
 
 func (b *blacklistValidator) IsAddressDenied(address common.Address, cType common.AddressCheckType) (hit bool) {
@@ -57,3 +58,43 @@ func (b *blacklistValidator) IsLogDenied(evLog *types.Log) bool {
 	}
 	return false
 }
+
+// deniedTopic returns the index of the first topic of the log holding a denied
+// address along with the check denying it, the same way IsLogDenied checks the
+// log but in a deterministic order.
+func (b *blacklistValidator) deniedTopic(evLog *types.Log) (int, common.AddressCheckType, bool) {
+	if nil == evLog || len(evLog.Topics) <= 1 {
+		return 0, common.CheckNone, false
+	}
+	rule, exist := b.rules[evLog.Topics[0]]
+	if !exist {
+		return 0, common.CheckNone, false
+	}
+	for idx := range evLog.Topics {
+		checkType, ok := rule.Checks[idx]
+		if !ok {
+			continue
+		}
+		if b.IsAddressDenied(common.BytesToAddress(evLog.Topics[idx].Bytes()), checkType) {
+			return idx, checkType, true
+		}
+	}
+	return 0, common.CheckNone, false
+}
+
+// IsCallTargetDenied denies running the code of any blacklisted contract on behalf
+// of another account, otherwise a proxy could delegate to it freely.
+func (b *blacklistValidator) IsCallTargetDenied(caller, target common.Address) bool {
+	if !b.proxyCheck || caller == target {
+		return false
+	}
+	return b.IsAddressDenied(target, common.CheckBothInAny)
+}
+
+// IsDeployerDenied denies the CREATE2 deployments of blacklisted senders.
+func (b *blacklistValidator) IsDeployerDenied(deployer common.Address) bool {
+	if !b.proxyCheck {
+		return false
+	}
+	return b.IsAddressDenied(deployer, common.CheckFrom)
+}
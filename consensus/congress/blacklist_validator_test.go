@@ -0,0 +1,81 @@
+package congress
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestBlacklistProxyChecks(t *testing.T) {
+	var (
+		proxy = common.HexToAddress("0x01")
+		from  = common.HexToAddress("0x02")
+		to    = common.HexToAddress("0x03")
+	)
+	blacks := map[common.Address]blacklistDirection{
+		from: DirectionFrom,
+		to:   DirectionTo,
+	}
+	before := &blacklistValidator{blacks: blacks}
+	if before.IsCallTargetDenied(proxy, from) || before.IsDeployerDenied(from) {
+		t.Fatalf("proxy checks enforced before the fork")
+	}
+
+	after := &blacklistValidator{blacks: blacks, proxyCheck: true}
+	if !after.IsCallTargetDenied(proxy, from) || !after.IsCallTargetDenied(proxy, to) {
+		t.Fatalf("blacklisted call target not denied")
+	}
+	if after.IsCallTargetDenied(proxy, common.HexToAddress("0x04")) {
+		t.Fatalf("clean call target denied")
+	}
+	if after.IsCallTargetDenied(from, from) {
+		t.Fatalf("self delegatecall denied")
+	}
+	if !after.IsDeployerDenied(from) {
+		t.Fatalf("blacklisted deployer not denied")
+	}
+	if after.IsDeployerDenied(to) {
+		t.Fatalf("to-only blacklisted deployer denied")
+	}
+}
+
+func TestBlacklistDeniedTopic(t *testing.T) {
+	var (
+		sig   = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+		from  = common.HexToAddress("0x02")
+		to    = common.HexToAddress("0x03")
+		clean = common.HexToAddress("0x04")
+	)
+	b := &blacklistValidator{
+		blacks: map[common.Address]blacklistDirection{
+			from: DirectionFrom,
+			to:   DirectionTo,
+		},
+		rules: map[common.Hash]*EventCheckRule{
+			sig: {EventSig: sig, Checks: map[int]common.AddressCheckType{1: common.CheckFrom, 2: common.CheckTo}},
+		},
+	}
+	tests := []struct {
+		topics []common.Hash
+		idx    int
+		denied bool
+	}{
+		{[]common.Hash{sig, clean.Hash(), clean.Hash()}, 0, false},
+		{[]common.Hash{sig, from.Hash(), clean.Hash()}, 1, true},
+		{[]common.Hash{sig, clean.Hash(), to.Hash()}, 2, true},
+		{[]common.Hash{sig, to.Hash(), from.Hash()}, 0, false},          // blacklisted in the other direction
+		{[]common.Hash{sig, from.Hash()}, 1, true},                      // topic of the second check missing
+		{[]common.Hash{clean.Hash(), from.Hash(), to.Hash()}, 0, false}, // no rule for the event
+	}
+	for i, tt := range tests {
+		evLog := &types.Log{Topics: tt.topics}
+		idx, _, denied := b.deniedTopic(evLog)
+		if denied != tt.denied || idx != tt.idx {
+			t.Errorf("test %d: denied topic mismatch: have (%d, %v), want (%d, %v)", i, idx, denied, tt.idx, tt.denied)
+		}
+		if denied != b.IsLogDenied(evLog) {
+			t.Errorf("test %d: denial differs from IsLogDenied", i)
+		}
+	}
+}
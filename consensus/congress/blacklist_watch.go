@@ -0,0 +1,373 @@
+package congress
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	blacklistWatchHeads = 16 // Number of chain head events buffered for the watch
+	blacklistWatchQueue = 16 // Number of alarms waiting to be posted
+)
+
+var (
+	blacklistDivergedGauge       = metrics.NewRegisteredGauge("congress/blacklist/watch/diverged", nil)
+	blacklistChangeMeter         = metrics.NewRegisteredMeter("congress/blacklist/watch/change", nil)
+	blacklistAlarmMeter          = metrics.NewRegisteredMeter("congress/blacklist/watch/alarm", nil)
+	blacklistWebhookFailedMeter  = metrics.NewRegisteredMeter("congress/blacklist/watch/webhook/failed", nil)
+	blacklistWebhookDroppedMeter = metrics.NewRegisteredMeter("congress/blacklist/watch/webhook/dropped", nil)
+)
+
+// Kinds of the blacklist alarms.
+const (
+	BlacklistDivergence = "divergence" // The on-chain blacklist diverges from the expected one
+	BlacklistChange     = "change"     // The on-chain blacklist was updated, diverging from the expected one
+	BlacklistResolved   = "resolved"   // The on-chain blacklist matches the expected one again
+)
+
+// BlacklistWatchConfig is the configuration of the expected blacklist watch.
+type BlacklistWatchConfig struct {
+	File    string        // JSON file mapping the expected blacklisted addresses to their direction (from, to, both)
+	Webhook string        `toml:",omitempty"` // Endpoint the alarms are posted to as JSON
+	Timeout time.Duration `toml:",omitempty"` // Timeout of a single post, 5s if zero
+}
+
+// BlacklistDiff is an address blacklisted differently on chain than expected.
+type BlacklistDiff struct {
+	Address  common.Address `json:"address"`
+	Expected string         `json:"expected"` // Expected direction, empty if not expected to be blacklisted
+	Actual   string         `json:"actual"`   // On-chain direction, empty if not blacklisted
+}
+
+// BlacklistAlarm reports the on-chain blacklist diverging from the expected one,
+// or matching it again.
+type BlacklistAlarm struct {
+	Kind    string           `json:"kind"`
+	Number  uint64           `json:"number"`
+	Hash    common.Hash      `json:"hash"`
+	Updated uint64           `json:"updated"` // Block of the last on-chain update
+	Diffs   []*BlacklistDiff `json:"diffs"`
+}
+
+// blacklistWatchChain is the chain followed by the blacklist watch.
+type blacklistWatchChain interface {
+	CurrentHeader() *types.Header
+	StateAt(root common.Hash) (*state.StateDB, error)
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// BlacklistWatch compares the blacklist of the address list contract against the
+// one expected by the operator on the chain heads, raising an alarm when they
+// diverge: an early warning of a compromised governance key. The contract state
+// is compared, so the updates are alarmed as soon as made, ahead of their
+// enforcement.
+type BlacklistWatch struct {
+	config BlacklistWatchConfig
+	engine *Congress
+	client *http.Client
+
+	expected map[common.Address]blacklistDirection
+	modTime  time.Time // Modification time of the loaded expected blacklist file
+
+	checked  bool   // Whether the on-chain blacklist was checked yet
+	updated  uint64 // Block of the last on-chain update, as of the last check
+	diverged string // Key of the divergence alarmed last, empty if none
+
+	alarms chan *BlacklistAlarm
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewBlacklistWatch creates a watch of the on-chain blacklist, loading the expected
+// one from the configured file.
+func NewBlacklistWatch(config BlacklistWatchConfig, engine *Congress) (*BlacklistWatch, error) {
+	if config.File == "" {
+		return nil, errors.New("expected blacklist file missing")
+	}
+	if config.Timeout == 0 {
+		config.Timeout = punishWebhookTimeout
+	}
+	expected, modTime, err := loadBlacklistFile(config.File)
+	if err != nil {
+		return nil, err
+	}
+	return &BlacklistWatch{
+		config:   config,
+		engine:   engine,
+		client:   &http.Client{Timeout: config.Timeout},
+		expected: expected,
+		modTime:  modTime,
+		alarms:   make(chan *BlacklistAlarm, blacklistWatchQueue),
+		quit:     make(chan struct{}),
+	}, nil
+}
+
+// loadBlacklistFile loads an expected blacklist, returning it along with the
+// modification time of the file.
+func loadBlacklistFile(path string) (map[common.Address]blacklistDirection, time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var entries map[common.Address]string
+	if err := json.Unmarshal(blob, &entries); err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid expected blacklist %s: %v", path, err)
+	}
+	blacks := make(map[common.Address]blacklistDirection, len(entries))
+	for addr, direction := range entries {
+		d, err := parseBlacklistDirection(direction)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("invalid expected blacklist %s: %x: %v", path, addr, err)
+		}
+		blacks[addr] = d
+	}
+	return blacks, info.ModTime(), nil
+}
+
+// parseBlacklistDirection parses the textual form of a blacklist direction.
+func parseBlacklistDirection(s string) (blacklistDirection, error) {
+	switch s {
+	case "from":
+		return DirectionFrom, nil
+	case "to":
+		return DirectionTo, nil
+	case "both":
+		return DirectionBoth, nil
+	default:
+		return 0, fmt.Errorf("invalid blacklist direction %q (from, to, both)", s)
+	}
+}
+
+// diffBlacklist returns the addresses blacklisted differently than expected,
+// ordered by address.
+func diffBlacklist(expected, actual map[common.Address]blacklistDirection) []*BlacklistDiff {
+	diffs := make([]*BlacklistDiff, 0)
+	for addr, d := range expected {
+		if a, ok := actual[addr]; !ok {
+			diffs = append(diffs, &BlacklistDiff{Address: addr, Expected: d.String()})
+		} else if a != d {
+			diffs = append(diffs, &BlacklistDiff{Address: addr, Expected: d.String(), Actual: a.String()})
+		}
+	}
+	for addr, a := range actual {
+		if _, ok := expected[addr]; !ok {
+			diffs = append(diffs, &BlacklistDiff{Address: addr, Actual: a.String()})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return bytes.Compare(diffs[i].Address[:], diffs[j].Address[:]) < 0
+	})
+	return diffs
+}
+
+// Start starts following the chain, checking the current head right away.
+func (w *BlacklistWatch) Start(chain blacklistWatchChain) {
+	heads := make(chan core.ChainHeadEvent, blacklistWatchHeads)
+	sub := chain.SubscribeChainHeadEvent(heads)
+
+	w.wg.Add(1)
+	go w.loop(chain, heads, sub)
+	if w.config.Webhook != "" {
+		w.wg.Add(1)
+		go w.postLoop()
+	}
+	log.Info("Started the expected blacklist watch", "file", w.config.File, "entries", len(w.expected), "webhook", w.config.Webhook)
+}
+
+// Stop stops following the chain, dropping the alarms not posted yet.
+func (w *BlacklistWatch) Stop() {
+	close(w.quit)
+	w.wg.Wait()
+}
+
+// loop checks the blacklist on the chain heads until stopped, skipping the heads
+// superseded while checking.
+func (w *BlacklistWatch) loop(chain blacklistWatchChain, heads chan core.ChainHeadEvent, sub event.Subscription) {
+	defer w.wg.Done()
+	defer sub.Unsubscribe()
+
+	w.checkHead(chain, chain.CurrentHeader())
+	for {
+		select {
+		case ev := <-heads:
+			head := ev.Block.Header()
+			for drained := false; !drained; {
+				select {
+				case ev = <-heads:
+					head = ev.Block.Header()
+				default:
+					drained = true
+				}
+			}
+			w.checkHead(chain, head)
+		case <-sub.Err():
+			return
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// checkHead checks the blacklist of the contract at the head, raising an alarm
+// if needed.
+func (w *BlacklistWatch) checkHead(chain blacklistWatchChain, head *types.Header) {
+	sophon := w.engine.chainConfig.SophonBlock
+	if head == nil || sophon == nil || head.Number.Cmp(sophon) < 0 {
+		return
+	}
+	statedb, err := chain.StateAt(head.Root)
+	if err != nil {
+		log.Debug("Blacklist watch state unavailable", "number", head.Number, "hash", head.Hash(), "err", err)
+		return
+	}
+	alarm, err := w.check(head, statedb)
+	if err != nil {
+		log.Warn("Failed to check the blacklist against the expected one", "number", head.Number, "hash", head.Hash(), "err", err)
+		return
+	}
+	if alarm != nil {
+		w.raise(alarm)
+	}
+}
+
+// check reads the blacklist of the contract at the state of the head if updated,
+// or if the expected blacklist file changed, and compares it against the expected
+// one.
+func (w *BlacklistWatch) check(head *types.Header, statedb *state.StateDB) (*BlacklistAlarm, error) {
+	reloaded := w.reload()
+	updated := lastBlacklistUpdatedNumber(statedb)
+	if w.checked && !reloaded && updated == w.updated {
+		return nil, nil
+	}
+	next := &types.Header{
+		ParentHash: head.Hash(),
+		Number:     new(big.Int).Add(head.Number, common.Big1),
+		Coinbase:   head.Coinbase,
+		Difficulty: new(big.Int),
+		GasLimit:   head.GasLimit,
+		Time:       head.Time,
+	}
+	actual, err := w.engine.readBlacklist(next, statedb)
+	if err != nil {
+		return nil, err
+	}
+	return w.evaluate(head, updated, actual), nil
+}
+
+// reload reloads the expected blacklist if its file was modified, keeping the
+// previous one if it can't be loaded. It reports whether it was reloaded.
+func (w *BlacklistWatch) reload() bool {
+	info, err := os.Stat(w.config.File)
+	if err != nil || info.ModTime().Equal(w.modTime) {
+		return false
+	}
+	expected, modTime, err := loadBlacklistFile(w.config.File)
+	if err != nil {
+		log.Warn("Failed to reload the expected blacklist, keeping the previous one", "err", err)
+		w.modTime = info.ModTime()
+		return false
+	}
+	log.Info("Reloaded the expected blacklist", "file", w.config.File, "entries", len(expected))
+	w.expected, w.modTime = expected, modTime
+	return true
+}
+
+// evaluate compares the on-chain blacklist against the expected one, returning
+// the alarm to raise, if any. A divergence is alarmed once, and again on every
+// on-chain update not resolving it.
+func (w *BlacklistWatch) evaluate(head *types.Header, updated uint64, actual map[common.Address]blacklistDirection) *BlacklistAlarm {
+	changed := w.checked && updated != w.updated
+	w.checked, w.updated = true, updated
+	if changed {
+		blacklistChangeMeter.Mark(1)
+		log.Info("On-chain blacklist updated", "number", head.Number, "updated", updated, "entries", len(actual))
+	}
+	diffs := diffBlacklist(w.expected, actual)
+	blacklistDivergedGauge.Update(int64(len(diffs)))
+
+	alarm := &BlacklistAlarm{Number: head.Number.Uint64(), Hash: head.Hash(), Updated: updated, Diffs: diffs}
+	if len(diffs) == 0 {
+		if w.diverged == "" {
+			return nil
+		}
+		w.diverged = ""
+		alarm.Kind = BlacklistResolved
+		return alarm
+	}
+	keys := make([]string, len(diffs))
+	for i, diff := range diffs {
+		keys[i] = fmt.Sprintf("%x:%s:%s", diff.Address, diff.Expected, diff.Actual)
+	}
+	key := strings.Join(keys, ",")
+	switch {
+	case changed:
+		alarm.Kind = BlacklistChange
+	case key != w.diverged:
+		alarm.Kind = BlacklistDivergence
+	default:
+		return nil
+	}
+	w.diverged = key
+	return alarm
+}
+
+// raise logs the alarm and schedules it to be posted to the webhook.
+func (w *BlacklistWatch) raise(alarm *BlacklistAlarm) {
+	blacklistAlarmMeter.Mark(1)
+	if alarm.Kind == BlacklistResolved {
+		log.Info("On-chain blacklist matches the expected one again", "number", alarm.Number, "hash", alarm.Hash, "updated", alarm.Updated)
+	} else {
+		for _, diff := range alarm.Diffs {
+			log.Error("On-chain blacklist diverges from the expected one", "kind", alarm.Kind, "number", alarm.Number, "updated", alarm.Updated,
+				"address", diff.Address, "expected", diff.Expected, "actual", diff.Actual)
+		}
+	}
+	if w.config.Webhook == "" {
+		return
+	}
+	select {
+	case w.alarms <- alarm:
+	default:
+		blacklistWebhookDroppedMeter.Mark(1)
+		log.Warn("Blacklist webhook queue full, alarm dropped", "kind", alarm.Kind, "number", alarm.Number)
+	}
+}
+
+// postLoop posts the queued alarms until stopped.
+func (w *BlacklistWatch) postLoop() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case alarm := <-w.alarms:
+			if err := postWebhook(w.client, w.config.Webhook, alarm); err != nil {
+				blacklistWebhookFailedMeter.Mark(1)
+				log.Warn("Failed to post blacklist alarm", "url", w.config.Webhook, "kind", alarm.Kind, "number", alarm.Number, "err", err)
+			}
+		case <-w.quit:
+			return
+		}
+	}
+}
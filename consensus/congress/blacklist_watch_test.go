@@ -0,0 +1,69 @@
+package congress
+
+import (
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestBlacklistWatch(t *testing.T) {
+	var (
+		addr1 = common.HexToAddress("0x01")
+		addr2 = common.HexToAddress("0x02")
+		addr3 = common.HexToAddress("0x03")
+	)
+	file := filepath.Join(t.TempDir(), "blacklist.json")
+	if err := ioutil.WriteFile(file, []byte(`{"0x0000000000000000000000000000000000000001": "from", "0x0000000000000000000000000000000000000002": "both"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewBlacklistWatch(BlacklistWatchConfig{File: file}, nil)
+	if err != nil {
+		t.Fatalf("failed to create watch: %v", err)
+	}
+	head := func(n int64) *types.Header {
+		return &types.Header{Number: big.NewInt(n)}
+	}
+	check := func(n int64, updated uint64, actual map[common.Address]blacklistDirection, kind string, diffs int) {
+		t.Helper()
+		alarm := w.evaluate(head(n), updated, actual)
+		switch {
+		case kind == "" && alarm != nil:
+			t.Errorf("block %d: unexpected %s alarm: %+v", n, alarm.Kind, alarm.Diffs)
+		case kind != "" && alarm == nil:
+			t.Errorf("block %d: missing %s alarm", n, kind)
+		case alarm != nil && (alarm.Kind != kind || len(alarm.Diffs) != diffs):
+			t.Errorf("block %d: alarm mismatch: have %s with %d diffs, want %s with %d", n, alarm.Kind, len(alarm.Diffs), kind, diffs)
+		}
+	}
+	// Matching blacklists raise no alarm, nor do the expected updates
+	expected := map[common.Address]blacklistDirection{addr1: DirectionFrom, addr2: DirectionBoth}
+	check(1, 0, expected, "", 0)
+	check(2, 0, expected, "", 0)
+
+	// Divergences are alarmed once, and again on every update
+	diverged := map[common.Address]blacklistDirection{addr1: DirectionTo, addr3: DirectionFrom}
+	check(3, 3, diverged, BlacklistChange, 3)
+	check(4, 3, diverged, "", 0)
+	check(5, 5, diverged, BlacklistChange, 3)
+
+	// Resolutions are reported once
+	check(6, 6, expected, BlacklistResolved, 0)
+	check(7, 6, expected, "", 0)
+
+	// An updated expected blacklist is compared right away
+	w.expected = map[common.Address]blacklistDirection{addr1: DirectionFrom}
+	check(8, 6, expected, BlacklistDivergence, 1)
+	check(9, 6, expected, "", 0)
+
+	// Invalid expected blacklists are rejected
+	if err := ioutil.WriteFile(file, []byte(`{"0x0000000000000000000000000000000000000001": "sideways"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := loadBlacklistFile(file); err == nil {
+		t.Errorf("invalid direction accepted")
+	}
+}
@@ -39,6 +39,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
 	"github.com/ethereum/go-ethereum/consensus/congress/vmcaller"
 	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -56,9 +57,9 @@ const (
 	checkpointInterval = 1024 // Number of blocks after which to save the vote snapshot to the database
 	inmemorySnapshots  = 128  // Number of recent vote snapshots to keep in memory
 	inmemorySignatures = 4096 // Number of recent block signatures to keep in memory
+	inmemorySystemGas  = 4096 // Number of recent block system gas used to keep in memory
 
-	wiggleTime    = 500 * time.Millisecond // Random delay (per validator) to allow concurrent validators
-	maxValidators = 21                     // Max validators allowed to seal.
+	wiggleTime = 500 * time.Millisecond // Random delay (per validator) to allow concurrent validators
 
 	inmemoryBlacklist = 21 // Number of recent blacklist snapshots to keep in memory
 )
@@ -93,6 +94,10 @@ var (
 	// that is not part of the local blockchain.
 	errUnknownBlock = errors.New("unknown block")
 
+	// errUnknownSystemGas is returned when the system gas used is requested for a block
+	// that was not finalized by the local node, e.g. imported by a snap sync.
+	errUnknownSystemGas = errors.New("unknown system gas used")
+
 	// errMissingVanity is returned if a block's extra-data section is shorter than
 	// 32 bytes, which is required to store the validator vanity.
 	errMissingVanity = errors.New("extra-data 32 byte vanity prefix missing")
@@ -148,22 +153,83 @@ var (
 	// that already signed a header recently, thus is temporarily not allowed to.
 	errRecentlySigned = errors.New("recently signed")
 
-	// errInvalidValidatorLen is returned if validators length is zero or bigger than maxValidators.
+	// errInvalidValidatorsLength is returned if validators length is zero or bigger than the max validators.
 	errInvalidValidatorsLength = errors.New("Invalid validators length")
 
 	// errInvalidCoinbase is returned if the coinbase isn't the validator of the block.
 	errInvalidCoinbase = errors.New("Invalid coin base")
 
 	errInvalidSysGovCount = errors.New("invalid system governance tx count")
+
+	// errNotProposalTx is returned if a transaction to decode a governance
+	// proposal from is not a system governance transaction.
+	errNotProposalTx = errors.New("not a system governance transaction")
+
+	// errSysCodeMismatch is returned if the code of the system contracts in state
+	// differs from the versions bundled with the binary.
+	errSysCodeMismatch = errors.New("system contract code mismatches the bundled version")
+
+	// errMissingSignTxFn is returned if a block executing passed system governance
+	// proposals is assembled by a node without the transaction signing function,
+	// e.g. an RPC-only node running the worker, which can't execute governance.
+	errMissingSignTxFn = errors.New("missing signTxFn, can't execute the system governance proposals")
+
+	// errStaleParent is returned if the block to seal is on top of a parent older
+	// than the configured maximum age, e.g. if the validator is isolated.
+	errStaleParent = errors.New("refusing to seal on top of a stale parent")
 )
 
 var (
 	getblacklistTimer = metrics.NewRegisteredTimer("congress/blacklist/get", nil)
 	getRulesTimer     = metrics.NewRegisteredTimer("congress/eventcheckrules/get", nil)
+
+	staleParentMeter = metrics.NewRegisteredMeter("congress/seal/staleparent", nil)
 )
 
-// StateFn gets state by the state root hash.
-type StateFn func(hash common.Hash) (*state.StateDB, error)
+// DelayedSealFn is invoked with a block sealed out of turn while it waits out its
+// wiggle delay, along with the time it's released at. It returns the function
+// withdrawing the block, invoked if the sealing is terminated before its release.
+type DelayedSealFn func(block *types.Block, release time.Time) (cancel func())
+
+// PeerHeadFn reports the number of connected peers, and whether any of them
+// announced a chain head heavier than the given parent.
+type PeerHeadFn func(parent *types.Header) (peers int, ahead bool)
+
+// ChainBackend is the chain the engine runs on, read for the ancestor headers and
+// the states beyond the ones given to the engine calls.
+type ChainBackend interface {
+	consensus.ChainHeaderReader
+
+	// StateAt returns the state of the given root.
+	StateAt(root common.Hash) (*state.StateDB, error)
+}
+
+// ChainRef is the chain backend of an engine created ahead of the blockchain it
+// runs on, as the blockchain needs the engine. The blockchain is set right after
+// its creation, before the engine is run.
+type ChainRef struct {
+	*core.BlockChain
+}
+
+// Option configures the engine at construction.
+type Option func(*Congress)
+
+// WithClock sets the source of the current time the headers are checked against
+// and the blocks are timestamped and sealed with, for testing.
+func WithClock(clock func() time.Time) Option {
+	return func(c *Congress) {
+		c.clock = clock
+	}
+}
+
+// WithTrustedSnapshot trusts the validators at the given block, the headers on
+// top of it being verified against them without looking further back, e.g. to
+// verify a chain of headers without the ones preceding it.
+func WithTrustedSnapshot(number uint64, hash common.Hash, validators []common.Address) Option {
+	return func(c *Congress) {
+		c.recents.Add(hash, newSnapshot(c.config, c.signatures, number, hash, validators))
+	}
+}
 
 // ValidatorFn hashes and signs the data to be signed by a backing account.
 type ValidatorFn func(validator accounts.Account, mimeType string, message []byte) ([]byte, error)
@@ -218,19 +284,34 @@ type Congress struct {
 	signTxFn  SignTxFn
 	lock      sync.RWMutex // Protects the validator fields
 
-	stateFn StateFn // Function to get state by state root
+	delayedSealFn DelayedSealFn // Pre-announcement of the blocks sealed out of turn, protected by lock
+
+	epochCheck EpochCheckMode // Mode of cross-checking the checkpoint validators on header import
+
+	sysCode *sysCodeWatchdog // Verification of the system contract code against the bundled versions
+
+	maxParentAge time.Duration // Maximum age of the parent of the blocks to seal, 0 for unlimited
+	peerHeadFn   PeerHeadFn    // Heads announced by the peers, nil if unknown, protected by lock
+
+	systemGas *systemGasTracker // Gas used by the system calls of the blocks being finalized
+
+	punishHooks []PunishHook // Hooks invoked around the validator punishments
+	hookLock    sync.RWMutex // Protects the punish hooks
 
 	abi map[string]abi.ABI // Interactive with system contracts
 
-	chain consensus.ChainHeaderReader // chain is only for reading parent headers when getting blacklist and rules
+	chain ChainBackend // Chain the engine runs on, read for the states
+
+	clock func() time.Time // Source of the current time, time.Now if nil
 
 	// The fields below are for testing only
 	fakeDiff bool // Skip difficulty verifications
+	fakeSeal bool // Trust the coinbase as the signer, skip the seal signatures and delays
 }
 
 // New creates a Congress proof-of-stake-authority consensus engine with the initial
-// validators set to the ones provided by the user.
-func New(chainConfig *params.ChainConfig, db ethdb.Database) *Congress {
+// validators set to the ones provided by the user, running on the given chain.
+func New(chainConfig *params.ChainConfig, db ethdb.Database, chain ChainBackend, opts ...Option) *Congress {
 	// Set any missing consensus parameters to their defaults
 	conf := *chainConfig.Congress
 	if conf.Epoch == 0 {
@@ -244,34 +325,147 @@ func New(chainConfig *params.ChainConfig, db ethdb.Database) *Congress {
 
 	abi := systemcontract.GetInteractiveABI()
 
-	return &Congress{
+	c := &Congress{
 		chainConfig:     chainConfig,
 		config:          &conf,
 		db:              db,
+		chain:           chain,
 		recents:         recents,
 		signatures:      signatures,
 		blacklists:      blacklists,
 		eventCheckRules: rules,
 		proposals:       make(map[common.Address]bool),
+		systemGas:       newSystemGasTracker(),
+		sysCode:         &sysCodeWatchdog{mode: SysCodeCheckWarn},
 		abi:             abi,
 		signer:          types.LatestSignerForChainID(chainConfig.ChainID),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.loadSystemGas()
+	return c
+}
+
+// NewFaker creates a Congress engine for testing, accepting any difficulty and
+// trusting the coinbase of the headers as their signer, so unsigned chains can
+// be imported. The faker seals the blocks without signing nor waiting for their
+// slot. The options, e.g. WithClock, apply on top.
+func NewFaker(chainConfig *params.ChainConfig, db ethdb.Database, chain ChainBackend, opts ...Option) *Congress {
+	c := New(chainConfig, db, chain, opts...)
+	c.fakeDiff = true
+	c.fakeSeal = true
+	return c
 }
 
-func (c *Congress) SetChain(chain consensus.ChainHeaderReader) {
-	c.chain = chain
+// now returns the current time of the engine's clock.
+func (c *Congress) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock()
 }
 
-// SetStateFn sets the function to get state.
-func (c *Congress) SetStateFn(fn StateFn) {
-	c.stateFn = fn
+// recoverSigner returns the signer of the header, its coinbase if the seal is faked.
+func (c *Congress) recoverSigner(header *types.Header) (common.Address, error) {
+	if c.fakeSeal {
+		return header.Coinbase, nil
+	}
+	return ecrecover(header, c.signatures)
 }
 
-// Author implements consensus.Engine, returning the Ethereum address recovered
-// from the signature in the header's extra-data section.
+// SetDelayedSealFn sets the function pre-announcing the blocks sealed out of turn
+// while they wait out their wiggle delay.
+func (c *Congress) SetDelayedSealFn(fn DelayedSealFn) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.delayedSealFn = fn
+}
+
+// SetMaxParentAge sets the maximum age, against the wall clock, of the parent of
+// the blocks to seal. A validator cut off from the network hence stops sealing
+// instead of minting a long private fork, forcing a deep reorg when it's back.
+// Zero disables the check.
+func (c *Congress) SetMaxParentAge(age time.Duration) {
+	c.maxParentAge = age
+}
+
+// SetPeerHeadFn sets the function reporting the heads announced by the peers. A
+// stale parent is then extended if peers are connected and none of them is ahead,
+// so that the validators can restart a chain stalled network wide.
+func (c *Congress) SetPeerHeadFn(fn PeerHeadFn) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.peerHeadFn = fn
+}
+
+// checkParentAge refuses to extend a stale chain, the validator being likely
+// isolated, unless its peers report that the whole network stalled.
+func (c *Congress) checkParentAge(parent *types.Header) error {
+	if c.maxParentAge == 0 {
+		return nil
+	}
+	age := c.now().Sub(time.Unix(int64(parent.Time), 0))
+	if age <= c.maxParentAge {
+		return nil
+	}
+	c.lock.RLock()
+	peerHeadFn := c.peerHeadFn
+	c.lock.RUnlock()
+
+	if peerHeadFn != nil {
+		if peers, ahead := peerHeadFn(parent); peers > 0 && !ahead {
+			log.Warn("Sealing on top of a stale parent, no peer is ahead", "number", parent.Number, "hash", parent.Hash(),
+				"age", common.PrettyDuration(age), "limit", common.PrettyDuration(c.maxParentAge), "peers", peers)
+			return nil
+		}
+	}
+	staleParentMeter.Mark(1)
+	log.Warn("Refusing to seal on top of a stale parent", "number", parent.Number, "hash", parent.Hash(),
+		"age", common.PrettyDuration(age), "limit", common.PrettyDuration(c.maxParentAge))
+	return errStaleParent
+}
+
+// SetEpochCheckMode sets the mode of cross-checking the checkpoint validators on header import.
+func (c *Congress) SetEpochCheckMode(mode EpochCheckMode) {
+	c.epochCheck = mode
+}
+
+// SetSysCodeCheckMode sets the mode of verifying the system contract code against
+// the versions bundled with the binary.
+func (c *Congress) SetSysCodeCheckMode(mode SysCodeCheckMode) {
+	c.sysCode.lock.Lock()
+	defer c.sysCode.lock.Unlock()
+
+	c.sysCode.mode = mode
+}
+
+// Author implements consensus.Engine, returning the coinbase of the header, which
+// the seal verification checks to be the validator that signed it.
 func (c *Congress) Author(header *types.Header) (common.Address, error) {
 	return header.Coinbase, nil
-	// return ecrecover(header, c.signatures)
+}
+
+// Signer returns the validator that sealed the header, recovered from the signature
+// in the header's extra-data section.
+func (c *Congress) Signer(header *types.Header) (common.Address, error) {
+	return c.recoverSigner(header)
+}
+
+// InTurn reports whether the header was sealed by the in-turn validator.
+func InTurn(header *types.Header) bool {
+	return header.Difficulty != nil && header.Difficulty.Cmp(diffInTurn) == 0
+}
+
+// CheckpointValidators returns the validators stored in the extra-data of an epoch
+// checkpoint header, or false if the header is not a checkpoint.
+func (c *Congress) CheckpointValidators(header *types.Header) ([]common.Address, bool) {
+	if header.Number.Uint64()%c.config.Epoch != 0 {
+		return nil, false
+	}
+	return parseExtraValidators(header), true
 }
 
 // VerifyHeader checks whether a header conforms to the consensus rules.
@@ -311,27 +505,12 @@ func (c *Congress) verifyHeader(chain consensus.ChainHeaderReader, header *types
 	number := header.Number.Uint64()
 
 	// Don't waste time checking blocks from the future
-	if header.Time > uint64(time.Now().Unix()) {
+	if header.Time > uint64(c.now().Unix()) {
 		return consensus.ErrFutureBlock
 	}
 	// Check that the extra-data contains the vanity, validators and signature.
-	if len(header.Extra) < extraVanity {
-		return errMissingVanity
-	}
-	if len(header.Extra) < extraVanity+extraSeal {
-		return errMissingSignature
-	}
-	// check extra data
-	isEpoch := number%c.config.Epoch == 0
-
-	// Ensure that the extra-data contains a validator list on checkpoint, but none otherwise
-	validatorsBytes := len(header.Extra) - extraVanity - extraSeal
-	if !isEpoch && validatorsBytes != 0 {
-		return errExtraValidators
-	}
-	// Ensure that the validator bytes length is valid
-	if isEpoch && validatorsBytes%common.AddressLength != 0 {
-		return errExtraValidators
+	if err := c.verifyExtra(header); err != nil {
+		return err
 	}
 
 	// Ensure that the mix digest is zero as we don't have fork protection currently
@@ -380,7 +559,7 @@ func (c *Congress) verifyCascadingFields(chain consensus.ChainHeaderReader, head
 		return consensus.ErrUnknownAncestor
 	}
 
-	if parent.Time+c.config.Period > header.Time {
+	if c.minBlockTime(parent, header) > header.Time {
 		return ErrInvalidTimestamp
 	}
 
@@ -401,6 +580,13 @@ func (c *Congress) verifyCascadingFields(chain consensus.ChainHeaderReader, head
 		return err
 	}
 
+	// Cross-check the checkpoint validators against the contract state if required
+	if number%c.config.Epoch == 0 {
+		if err := c.verifyEpochValidators(chain, header, parent); err != nil {
+			return err
+		}
+	}
+
 	// All basic checks passed, verify the seal and return
 	return c.verifySeal(chain, header, parents)
 }
@@ -470,6 +656,13 @@ func (c *Congress) snapshot(chain consensus.ChainHeaderReader, number uint64, ha
 	for i := 0; i < len(headers)/2; i++ {
 		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
 	}
+	// Fake engines trust the coinbases, prime the signature cache the snapshot
+	// recovers the signers from
+	if c.fakeSeal {
+		for _, header := range headers {
+			c.signatures.Add(header.Hash(), header.Coinbase)
+		}
+	}
 	snap, err := snap.apply(headers, chain, parents)
 	if err != nil {
 		return nil, err
@@ -518,7 +711,7 @@ func (c *Congress) verifySeal(chain consensus.ChainHeaderReader, header *types.H
 	}
 
 	// Resolve the authorization key and check against validators
-	signer, err := ecrecover(header, c.signatures)
+	signer, err := c.recoverSigner(header)
 	if err != nil {
 		return err
 	}
@@ -595,16 +788,40 @@ func (c *Congress) Prepare(chain consensus.ChainHeaderReader, header *types.Head
 	if parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
+	// Refuse to seal on top of unexpected system contract code or a stale parent
+	if err := c.verifySealingSysCode(parent); err != nil {
+		return err
+	}
+	if err := c.checkParentAge(parent); err != nil {
+		return err
+	}
 	header.Time = parent.Time + c.config.Period
-	if header.Time < uint64(time.Now().Unix()) {
-		header.Time = uint64(time.Now().Unix())
+	if now := uint64(c.now().Unix()); header.Time < now {
+		header.Time = now
+	}
+	if min := c.minBlockTime(parent, header); header.Time < min {
+		header.Time = min
 	}
 	return nil
 }
 
+// minBlockTime returns the earliest timestamp allowed for the header on top of its
+// parent. The out-of-turn validators of the first block after a validator set change
+// wait an extra delay, as they'd otherwise race each other with their recents reset.
+func (c *Congress) minBlockTime(parent, header *types.Header) uint64 {
+	min := parent.Time + c.config.Period
+	if header.Number.Uint64()%c.config.Epoch == 1 && header.Difficulty != nil && header.Difficulty.Cmp(diffNoTurn) == 0 {
+		min += c.config.EpochSealDelayAt(header.Number)
+	}
+	return min
+}
+
 // Finalize implements consensus.Engine, ensuring no uncles are set, nor block
 // rewards given.
 func (c *Congress) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs *[]*types.Transaction, uncles []*types.Header, receipts *[]*types.Receipt, systemTxs []*types.Transaction) error {
+	c.beginSystemGas(header)
+	defer c.endSystemGas(header)
+
 	// Initialize all system contracts at block 1.
 	if header.Number.Cmp(common.Big1) == 0 {
 		if err := c.initializeSystemContracts(chain, header, state); err != nil {
@@ -650,6 +867,8 @@ func (c *Congress) Finalize(chain consensus.ChainHeaderReader, header *types.Hea
 
 		extraSuffix := len(header.Extra) - extraSeal
 		if !bytes.Equal(header.Extra[extraVanity:extraSuffix], validatorsBytes) {
+			log.Error("Mismatching epoch validators", "number", header.Number, "hash", header.Hash(),
+				"extra", parseExtraValidators(header), "contract", newValidators)
 			return errInvalidExtraValidators
 		}
 	}
@@ -707,31 +926,34 @@ func (c *Congress) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header
 			log.Warn("FinalizeAndAssemble failed", "err", err)
 		}
 	}()
+	c.beginSystemGas(header)
+	defer c.endSystemGas(header)
+
 	// Initialize all system contracts at block 1.
 	if header.Number.Cmp(common.Big1) == 0 {
 		if err := c.initializeSystemContracts(chain, header, state); err != nil {
-			panic(err)
+			return nil, nil, err
 		}
 	}
 
 	// punish validator if necessary
 	if header.Difficulty.Cmp(diffInTurn) != 0 {
 		if err := c.tryPunishValidator(chain, header, state); err != nil {
-			panic(err)
+			return nil, nil, err
 		}
 	}
 
 	// deposit block reward if any tx exists.
 	if len(txs) > 0 {
 		if err := c.trySendBlockReward(chain, header, state); err != nil {
-			panic(err)
+			return nil, nil, err
 		}
 	}
 
 	// do epoch thing at the end, because it will update active validators
 	if header.Number.Uint64()%c.config.Epoch == 0 {
 		if _, err := c.doSomethingAtEpoch(chain, header, state); err != nil {
-			panic(err)
+			return nil, nil, err
 		}
 	}
 
@@ -740,12 +962,16 @@ func (c *Congress) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header
 	// Note:
 	// Even if the miner is not `running`, it's still working,
 	// the 'miner.worker' will try to FinalizeAndAssemble a block,
-	// in this case, the signTxFn is not set. A `non-miner node` can't execute system governance proposal.
-	if c.signTxFn != nil && chain.Config().IsRedCoast(header.Number) {
+	// in this case, the signTxFn is not set. A `non-miner node` can't execute system governance proposal,
+	// so it refuses to assemble the blocks executing some instead of leaving them out.
+	if chain.Config().IsRedCoast(header.Number) {
 		proposalCount, err := c.getPassedProposalCount(chain, header, state)
 		if err != nil {
 			return nil, nil, err
 		}
+		if proposalCount > 0 && c.signTxFn == nil {
+			return nil, nil, errMissingSignTxFn
+		}
 
 		// Due to the logics of the finish operation of contract `governance`, when finishing a proposal which
 		// is not the last passed proposal, it will change the sequence. So in here we must first executes all
@@ -802,9 +1028,9 @@ func (c *Congress) trySendBlockReward(chain consensus.ChainHeaderReader, header
 	}
 
 	nonce := state.GetNonce(header.Coinbase)
-	msg := vmcaller.NewLegacyMessage(header.Coinbase, systemcontract.GetValidatorAddr(header.Number, c.chainConfig), nonce, fee, math.MaxUint64, new(big.Int), data, true)
+	msg := vmcaller.NewLegacyMessage(header.Coinbase, systemcontract.GetValidatorAddr(header.Number, c.chainConfig), nonce, fee, c.systemCallGas(header), new(big.Int), data, true)
 
-	if _, err := vmcaller.ExecuteMsg(msg, state, header, newChainContext(chain, c), c.chainConfig); err != nil {
+	if _, err := c.executeSystemMsg(chain, header, msg, state); err != nil {
 		return err
 	}
 
@@ -817,18 +1043,8 @@ func (c *Congress) tryPunishValidator(chain consensus.ChainHeaderReader, header
 	if err != nil {
 		return err
 	}
-	validators := snap.validators()
-	outTurnValidator := validators[number%uint64(len(validators))]
-	// check sigend recently or not
-	signedRecently := false
-	for _, recent := range snap.Recents {
-		if recent == outTurnValidator {
-			signedRecently = true
-			break
-		}
-	}
-	if !signedRecently {
-		if err := c.punishValidator(outTurnValidator, chain, header, state); err != nil {
+	if outTurnValidator, ok := snap.punishTarget(number); ok {
+		if err := c.punishOrSkip(outTurnValidator, chain, header, state); err != nil {
 			return err
 		}
 	}
@@ -862,7 +1078,7 @@ func (c *Congress) initializeSystemContracts(chain consensus.ChainHeaderReader,
 	}
 
 	genesisValidators := snap.validators()
-	if len(genesisValidators) == 0 || len(genesisValidators) > maxValidators {
+	if len(genesisValidators) == 0 || len(genesisValidators) > c.config.MaxValidatorsAt(header.Number) {
 		return errInvalidValidatorsLength
 	}
 
@@ -887,9 +1103,9 @@ func (c *Congress) initializeSystemContracts(chain consensus.ChainHeaderReader,
 		}
 
 		nonce := state.GetNonce(header.Coinbase)
-		msg := vmcaller.NewLegacyMessage(header.Coinbase, &contract.addr, nonce, new(big.Int), math.MaxUint64, new(big.Int), data, true)
+		msg := vmcaller.NewLegacyMessage(header.Coinbase, &contract.addr, nonce, new(big.Int), c.systemCallGas(header), new(big.Int), data, true)
 
-		if _, err := vmcaller.ExecuteMsg(msg, state, header, newChainContext(chain, c), c.chainConfig); err != nil {
+		if _, err := c.executeSystemMsg(chain, header, msg, state); err != nil {
 			return err
 		}
 	}
@@ -903,11 +1119,15 @@ func (c *Congress) getTopValidators(chain consensus.ChainHeaderReader, header *t
 	if parent == nil {
 		return []common.Address{}, consensus.ErrUnknownAncestor
 	}
-	statedb, err := c.stateFn(parent.Root)
+	statedb, err := c.chain.StateAt(parent.Root)
 	if err != nil {
 		return []common.Address{}, err
 	}
+	return c.getTopValidatorsAt(chain, header, parent, statedb)
+}
 
+// getTopValidatorsAt calls getTopValidators of the validators contract on the given parent state.
+func (c *Congress) getTopValidatorsAt(chain consensus.ChainHeaderReader, header *types.Header, parent *types.Header, statedb *state.StateDB) ([]common.Address, error) {
 	method := "getTopValidators"
 	data, err := c.abi[systemcontract.ValidatorsContractName].Pack(method)
 	if err != nil {
@@ -915,10 +1135,11 @@ func (c *Congress) getTopValidators(chain consensus.ChainHeaderReader, header *t
 		return []common.Address{}, err
 	}
 
-	msg := vmcaller.NewLegacyMessage(header.Coinbase, systemcontract.GetValidatorAddr(parent.Number, c.chainConfig), 0, new(big.Int), math.MaxUint64, new(big.Int), data, false)
+	msg := vmcaller.NewLegacyMessage(header.Coinbase, systemcontract.GetValidatorAddr(parent.Number, c.chainConfig), 0, new(big.Int), c.systemCallGas(header), new(big.Int), data, false)
 
 	// use parent
-	result, err := vmcaller.ExecuteMsg(msg, statedb, parent, newChainContext(chain, c), c.chainConfig)
+	result, used, err := vmcaller.ExecuteMsgWithGas(msg, statedb, parent, newChainContext(chain, c), c.chainConfig)
+	c.systemGas.add(header, used)
 	if err != nil {
 		return []common.Address{}, err
 	}
@@ -940,6 +1161,16 @@ func (c *Congress) getTopValidators(chain consensus.ChainHeaderReader, header *t
 }
 
 func (c *Congress) updateValidators(vals []common.Address, chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) error {
+	// The validator set updates are only capped once the max validators fork is
+	// reached, the chains not configuring it keep their original rules. The cap is
+	// the one of the epoch sealed by the updated validators.
+	if fork := c.config.MaxValidatorsBlock; fork != nil && header.Number.Cmp(fork) >= 0 {
+		next := new(big.Int).Add(header.Number, common.Big1)
+		if max := c.config.MaxValidatorsAt(next); len(vals) > max {
+			log.Error("Too many validators to update", "number", header.Number, "validators", len(vals), "max", max)
+			return errInvalidValidatorsLength
+		}
+	}
 	// method
 	method := "updateActiveValidatorSet"
 	data, err := c.abi[systemcontract.ValidatorsContractName].Pack(method, vals, new(big.Int).SetUint64(c.config.Epoch))
@@ -950,8 +1181,8 @@ func (c *Congress) updateValidators(vals []common.Address, chain consensus.Chain
 
 	// call contract
 	nonce := state.GetNonce(header.Coinbase)
-	msg := vmcaller.NewLegacyMessage(header.Coinbase, systemcontract.GetValidatorAddr(header.Number, c.chainConfig), nonce, new(big.Int), math.MaxUint64, new(big.Int), data, true)
-	if _, err := vmcaller.ExecuteMsg(msg, state, header, newChainContext(chain, c), c.chainConfig); err != nil {
+	msg := vmcaller.NewLegacyMessage(header.Coinbase, systemcontract.GetValidatorAddr(header.Number, c.chainConfig), nonce, new(big.Int), c.systemCallGas(header), new(big.Int), data, true)
+	if _, err := c.executeSystemMsg(chain, header, msg, state); err != nil {
 		log.Error("Can't update validators to contract", "err", err)
 		return err
 	}
@@ -970,8 +1201,8 @@ func (c *Congress) punishValidator(val common.Address, chain consensus.ChainHead
 
 	// call contract
 	nonce := state.GetNonce(header.Coinbase)
-	msg := vmcaller.NewLegacyMessage(header.Coinbase, systemcontract.GetPunishAddr(header.Number, c.chainConfig), nonce, new(big.Int), math.MaxUint64, new(big.Int), data, true)
-	if _, err := vmcaller.ExecuteMsg(msg, state, header, newChainContext(chain, c), c.chainConfig); err != nil {
+	msg := vmcaller.NewLegacyMessage(header.Coinbase, systemcontract.GetPunishAddr(header.Number, c.chainConfig), nonce, new(big.Int), c.systemCallGas(header), new(big.Int), data, true)
+	if _, err := c.executeSystemMsg(chain, header, msg, state); err != nil {
 		log.Error("Can't punish validator", "err", err)
 		return err
 	}
@@ -990,8 +1221,8 @@ func (c *Congress) decreaseMissedBlocksCounter(chain consensus.ChainHeaderReader
 
 	// call contract
 	nonce := state.GetNonce(header.Coinbase)
-	msg := vmcaller.NewLegacyMessage(header.Coinbase, systemcontract.GetPunishAddr(header.Number, c.chainConfig), nonce, new(big.Int), math.MaxUint64, new(big.Int), data, true)
-	if _, err := vmcaller.ExecuteMsg(msg, state, header, newChainContext(chain, c), c.chainConfig); err != nil {
+	msg := vmcaller.NewLegacyMessage(header.Coinbase, systemcontract.GetPunishAddr(header.Number, c.chainConfig), nonce, new(big.Int), c.systemCallGas(header), new(big.Int), data, true)
+	if _, err := c.executeSystemMsg(chain, header, msg, state); err != nil {
 		log.Error("Can't decrease missed blocks counter for validator", "err", err)
 		return err
 	}
@@ -1027,7 +1258,7 @@ func (c *Congress) Seal(chain consensus.ChainHeaderReader, block *types.Block, r
 	}
 	// Don't hold the val fields for the entire sealing procedure
 	c.lock.RLock()
-	val, signFn := c.validator, c.signFn
+	val, signFn, delayedSealFn := c.validator, c.signFn, c.delayedSealFn
 	c.lock.RUnlock()
 
 	// Bail out if we're unauthorized to sign a block
@@ -1049,9 +1280,19 @@ func (c *Congress) Seal(chain consensus.ChainHeaderReader, block *types.Block, r
 		}
 	}
 
+	// Fake engines release the block as is, unsigned and without waiting
+	if c.fakeSeal {
+		select {
+		case results <- block:
+		default:
+			log.Warn("Sealing result is not read by miner", "sealhash", SealHash(header))
+		}
+		return nil
+	}
 	// Sweet, the protocol permits us to sign the block, wait for our time
-	delay := time.Unix(int64(header.Time), 0).Sub(time.Now()) // nolint: gosimple
-	if header.Difficulty.Cmp(diffNoTurn) == 0 {
+	delay := time.Unix(int64(header.Time), 0).Sub(c.now())
+	outOfTurn := header.Difficulty.Cmp(diffNoTurn) == 0
+	if outOfTurn {
 		// It's not our turn explicitly to sign, delay it a bit
 		wiggle := time.Duration(len(snap.Validators)/2+1) * wiggleTime
 		delay += time.Duration(rand.Int63n(int64(wiggle)))
@@ -1064,17 +1305,28 @@ func (c *Congress) Seal(chain consensus.ChainHeaderReader, block *types.Block, r
 		return err
 	}
 	copy(header.Extra[len(header.Extra)-extraSeal:], sighash)
+	sealed := block.WithSeal(header)
+
+	// Let the trusted peers fetch the block ahead while it waits out the wiggle
+	var cancel func()
+	if outOfTurn && delay > 0 && delayedSealFn != nil {
+		cancel = delayedSealFn(sealed, c.now().Add(delay))
+	}
 	// Wait until sealing is terminated or delay timeout.
 	log.Trace("Waiting for slot to sign and propagate", "delay", common.PrettyDuration(delay))
 	go func() {
 		select {
 		case <-stop:
+			// The block is replaced, withdraw it so that it's never released
+			if cancel != nil {
+				cancel()
+			}
 			return
 		case <-time.After(delay):
 		}
 
 		select {
-		case results <- block.WithSeal(header):
+		case results <- sealed:
 		default:
 			log.Warn("Sealing result is not read by miner", "sealhash", SealHash(header))
 		}
@@ -1109,6 +1361,17 @@ func (c *Congress) SealHash(header *types.Header) common.Hash {
 
 // Close implements consensus.Engine. It's a noop for congress as there are no background threads.
 func (c *Congress) Close() error {
+	c.hookLock.Lock()
+	defer c.hookLock.Unlock()
+
+	for _, hook := range c.punishHooks {
+		if closer, ok := hook.(interface{ Close() }); ok {
+			closer.Close()
+		}
+	}
+	c.punishHooks = nil
+
+	c.journalSystemGas()
 	return nil
 }
 
@@ -1169,10 +1432,13 @@ func encodeSigHeader(w io.Writer, header *types.Header) {
 
 func (c *Congress) PreHandle(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) error {
 	if c.chainConfig.RedCoastBlock != nil && c.chainConfig.RedCoastBlock.Cmp(header.Number) == 0 {
-		return systemcontract.ApplySystemContractUpgrade(systemcontract.SysContractV1, state, header, newChainContext(chain, c), c.chainConfig)
+		return c.applySystemContractUpgrade(chain, systemcontract.SysContractV1, header, state)
 	}
 	if c.chainConfig.SophonBlock != nil && c.chainConfig.SophonBlock.Cmp(header.Number) == 0 {
-		return systemcontract.ApplySystemContractUpgrade(systemcontract.SysContractV2, state, header, newChainContext(chain, c), c.chainConfig)
+		return c.applySystemContractUpgrade(chain, systemcontract.SysContractV2, header, state)
+	}
+	if c.blacklistDelayed(header.Number) {
+		return c.updateBlacklistQueue(header, state)
 	}
 	return nil
 }
@@ -1253,7 +1519,7 @@ func (c *Congress) getBlacklist(header *types.Header, parentState *state.StateDB
 	if c.chainConfig.SophonBlock != nil && header.Number.Cmp(c.chainConfig.SophonBlock) > 0 {
 		num := header.Number.Uint64()
 		lastUpdated := lastBlacklistUpdatedNumber(parentState)
-		if num >= 2 && num > lastUpdated+1 {
+		if num >= 2 && num > c.blacklistActivation(lastUpdated) {
 			parent := c.chain.GetHeader(header.ParentHash, num-1)
 			if parent != nil {
 				if v, ok := c.blacklists.Get(parent.ParentHash); ok {
@@ -1268,9 +1534,19 @@ func (c *Congress) getBlacklist(header *types.Header, parentState *state.StateDB
 	}
 
 	// can't get blacklist from cache, try to call the contract
+	m, _, err := c.resolveBlacklist(header, parentState)
+	if err != nil {
+		return nil, err
+	}
+	c.blacklists.Add(header.ParentHash, m)
+	return m, nil
+}
+
+// readBlacklist reads the blacklist of the address list contract at the given state.
+func (c *Congress) readBlacklist(header *types.Header, statedb *state.StateDB) (map[common.Address]blacklistDirection, error) {
 	alABI := c.abi[systemcontract.AddressListContractName]
 	get := func(method string) ([]common.Address, error) {
-		ret, err := c.commonCallContract(header, parentState, alABI, systemcontract.AddressListContractAddr, method, 1)
+		ret, err := c.commonCallContract(header, statedb, alABI, systemcontract.AddressListContractAddr, method, 1)
 		if err != nil {
 			log.Error(fmt.Sprintf("%s failed", method), "err", err)
 			return nil, err
@@ -1302,28 +1578,36 @@ func (c *Congress) getBlacklist(header *types.Header, parentState *state.StateDB
 			m[to] = DirectionTo
 		}
 	}
-	c.blacklists.Add(header.ParentHash, m)
 	return m, nil
 }
 
-func (c *Congress) CreateEvmExtraValidator(header *types.Header, parentState *state.StateDB) types.EvmExtraValidator {
-	if c.chainConfig.SophonBlock != nil && c.chainConfig.SophonBlock.Cmp(header.Number) < 0 {
+// blacklistActive returns whether the blacklist and the event check rules apply to
+// the transactions of the given block, from the block after the Sophon fork.
+func (c *Congress) blacklistActive(number *big.Int) bool {
+	return c.chainConfig.SophonBlock != nil && c.chainConfig.SophonBlock.Cmp(number) < 0
+}
+
+// CreateEvmExtraValidator returns the blacklist validator of the header, failing if
+// the blacklist or the event check rules in force can't be resolved.
+func (c *Congress) CreateEvmExtraValidator(header *types.Header, parentState *state.StateDB) (types.EvmExtraValidator, error) {
+	if c.blacklistActive(header.Number) {
 		blacks, err := c.getBlacklist(header, parentState)
 		if err != nil {
 			log.Error("getBlacklist failed", "err", err)
-			return nil
+			return nil, fmt.Errorf("%w: %v", errBlacklistUnresolved, err)
 		}
 		rules, err := c.getEventCheckRules(header, parentState)
 		if err != nil {
 			log.Error("getEventCheckRules failed", "err", err)
-			return nil
+			return nil, err
 		}
 		return &blacklistValidator{
-			blacks: blacks,
-			rules:  rules,
-		}
+			blacks:     blacks,
+			rules:      rules,
+			proxyCheck: c.chainConfig.IsProxyCheck(header.Number),
+		}, nil
 	}
-	return nil
+	return nil, nil
 }
 
 func (c *Congress) getEventCheckRules(header *types.Header, parentState *state.StateDB) (map[common.Hash]*EventCheckRule, error) {
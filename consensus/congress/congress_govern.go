@@ -15,7 +15,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
-	"math"
 	"math/big"
 )
 
@@ -29,6 +28,19 @@ type Proposal struct {
 	Data   []byte
 }
 
+// DecodeProposal decodes the governance proposal carried by a system governance
+// transaction.
+func DecodeProposal(tx *types.Transaction) (*Proposal, error) {
+	if to := tx.To(); to == nil || *to != systemcontract.SysGovToAddr {
+		return nil, errNotProposalTx
+	}
+	prop := &Proposal{}
+	if err := rlp.DecodeBytes(tx.Data(), prop); err != nil {
+		return nil, err
+	}
+	return prop, nil
+}
+
 func (c *Congress) getPassedProposalCount(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) (uint32, error) {
 
 	method := "getPassedProposalCount"
@@ -38,10 +50,10 @@ func (c *Congress) getPassedProposalCount(chain consensus.ChainHeaderReader, hea
 		return 0, err
 	}
 
-	msg := vmcaller.NewLegacyMessage(header.Coinbase, &systemcontract.SysGovContractAddr, 0, new(big.Int), math.MaxUint64, new(big.Int), data, false)
+	msg := vmcaller.NewLegacyMessage(header.Coinbase, &systemcontract.SysGovContractAddr, 0, new(big.Int), c.systemCallGas(header), new(big.Int), data, false)
 
 	// use parent
-	result, err := vmcaller.ExecuteMsg(msg, state, header, newChainContext(chain, c), c.chainConfig)
+	result, err := c.executeSystemMsg(chain, header, msg, state)
 	if err != nil {
 		return 0, err
 	}
@@ -71,10 +83,10 @@ func (c *Congress) getPassedProposalByIndex(chain consensus.ChainHeaderReader, h
 		return nil, err
 	}
 
-	msg := vmcaller.NewLegacyMessage(header.Coinbase, &systemcontract.SysGovContractAddr, 0, new(big.Int), math.MaxUint64, new(big.Int), data, false)
+	msg := vmcaller.NewLegacyMessage(header.Coinbase, &systemcontract.SysGovContractAddr, 0, new(big.Int), c.systemCallGas(header), new(big.Int), data, false)
 
 	// use parent
-	result, err := vmcaller.ExecuteMsg(msg, state, header, newChainContext(chain, c), c.chainConfig)
+	result, err := c.executeSystemMsg(chain, header, msg, state)
 	if err != nil {
 		return nil, err
 	}
@@ -98,11 +110,11 @@ func (c *Congress) finishProposalById(chain consensus.ChainHeaderReader, header
 		return err
 	}
 
-	msg := vmcaller.NewLegacyMessage(header.Coinbase, &systemcontract.SysGovContractAddr, 0, new(big.Int), math.MaxUint64, new(big.Int), data, false)
+	msg := vmcaller.NewLegacyMessage(header.Coinbase, &systemcontract.SysGovContractAddr, 0, new(big.Int), c.systemCallGas(header), new(big.Int), data, false)
 
 	// execute message without a transaction
 	state.Prepare(common.Hash{}, 0)
-	_, err = vmcaller.ExecuteMsg(msg, state, header, newChainContext(chain, c), c.chainConfig)
+	_, err = c.executeSystemMsg(chain, header, msg, state)
 	if err != nil {
 		return err
 	}
@@ -115,7 +127,7 @@ func (c *Congress) executeProposal(chain consensus.ChainHeaderReader, header *ty
 	// the 'miner.worker' will try to FinalizeAndAssemble a block,
 	// in this case, the signTxFn is not set. A `non-miner node` can't execute system governance proposal.
 	if c.signTxFn == nil {
-		return nil, nil, errors.New("signTxFn not set")
+		return nil, nil, errMissingSignTxFn
 	}
 
 	propRLP, err := rlp.EncodeToBytes(prop)
@@ -124,23 +136,28 @@ func (c *Congress) executeProposal(chain consensus.ChainHeaderReader, header *ty
 	}
 	//make system governance transaction
 	nonce := state.GetNonce(c.validator)
-	amout := prop.Value
-	if c.chainConfig.IsSophon(header.Number) {
-		// fix bug
-		amout = new(big.Int)
-	}
-	tx := types.NewTransaction(nonce, systemcontract.SysGovToAddr, amout, header.GasLimit, new(big.Int), propRLP)
+	tx := types.NewTransaction(nonce, systemcontract.SysGovToAddr, c.proposalValue(header.Number, prop), header.GasLimit, new(big.Int), propRLP)
 	tx, err = c.signTxFn(accounts.Account{Address: c.validator}, tx, chain.Config().ChainID)
 	if err != nil {
 		return nil, nil, err
 	}
 	//add nonce for validator
 	state.SetNonce(c.validator, nonce+1)
-	receipt := c.executeProposalMsg(chain, header, state, prop, totalTxIndex, tx.Hash(), common.Hash{})
+	receipt := c.executeProposalMsg(chain, header, state, prop, totalTxIndex, tx, common.Hash{})
 
 	return tx, receipt, nil
 }
 
+// proposalValue returns the value of the system governance transaction executing
+// the proposal in the given block, zero since the Sophon fork, as the proposal's
+// value is transferred by the proposal execution itself.
+func (c *Congress) proposalValue(number *big.Int, prop *Proposal) *big.Int {
+	if c.chainConfig.IsSophon(number) {
+		return new(big.Int)
+	}
+	return prop.Value
+}
+
 func (c *Congress) replayProposal(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, prop *Proposal, totalTxIndex int, tx *types.Transaction) (*types.Receipt, error) {
 	sender, err := types.Sender(c.signer, tx)
 	if err != nil {
@@ -160,25 +177,38 @@ func (c *Congress) replayProposal(chain consensus.ChainHeaderReader, header *typ
 	nonce := state.GetNonce(sender)
 	//add nonce for validator
 	state.SetNonce(sender, nonce+1)
-	receipt := c.executeProposalMsg(chain, header, state, prop, totalTxIndex, tx.Hash(), header.Hash())
+	receipt := c.executeProposalMsg(chain, header, state, prop, totalTxIndex, tx, header.Hash())
 
 	return receipt, nil
 }
 
-func (c *Congress) executeProposalMsg(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, prop *Proposal, totalTxIndex int, txHash, bHash common.Hash) *types.Receipt {
-	var receipt *types.Receipt
+func (c *Congress) executeProposalMsg(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, prop *Proposal, totalTxIndex int, tx *types.Transaction, bHash common.Hash) *types.Receipt {
+	var (
+		receipt *types.Receipt
+		txHash  = tx.Hash()
+	)
 	action := prop.Action.Uint64()
 	switch action {
 	case 0:
 		// evm action.
-		receipt = c.executeEvmCallProposal(chain, header, state, prop, totalTxIndex, txHash, bHash)
+		receipt = c.executeEvmCallProposal(chain, header, state, prop, totalTxIndex, tx, bHash)
 	case 1:
 		// delete code action
 		ok := state.Erase(prop.To)
-		receipt = types.NewReceipt([]byte{}, ok != true, header.GasUsed)
+		receipt = newSysGovReceipt(tx, ok != true, header.GasUsed)
 		log.Info("executeProposalMsg", "action", "erase", "id", prop.Id.String(), "to", prop.To, "txHash", txHash.String(), "success", ok)
+	case punishBreakerAction:
+		// arm or disarm the punish breaker, unsupported before its fork
+		err := c.applyPunishBreakerProposal(header.Number, state, prop)
+		receipt = newSysGovReceipt(tx, err != nil, header.GasUsed)
+		log.Info("executeProposalMsg", "action", "punishBreaker", "id", prop.Id.String(), "data", hexutil.Encode(prop.Data), "txHash", txHash.String(), "err", err)
+	case feeBurnAction:
+		// tune the fee burn ratio, unsupported before its fork
+		err := c.applyFeeBurnProposal(header.Number, state, prop)
+		receipt = newSysGovReceipt(tx, err != nil, header.GasUsed)
+		log.Info("executeProposalMsg", "action", "feeBurn", "id", prop.Id.String(), "data", hexutil.Encode(prop.Data), "txHash", txHash.String(), "err", err)
 	default:
-		receipt = types.NewReceipt([]byte{}, true, header.GasUsed)
+		receipt = newSysGovReceipt(tx, true, header.GasUsed)
 		log.Warn("executeProposalMsg failed, unsupported action", "action", action, "id", prop.Id.String(), "from", prop.From, "to", prop.To, "value", prop.Value.String(), "data", hexutil.Encode(prop.Data), "txHash", txHash.String())
 	}
 
@@ -190,17 +220,36 @@ func (c *Congress) executeProposalMsg(chain consensus.ChainHeaderReader, header
 	return receipt
 }
 
+// newSysGovReceipt creates the receipt of a system governance transaction in the
+// form its raw encoding decodes to: typed as the transaction, with a status but
+// no post state and an empty log list, so the receipt round-trips through the
+// raw receipt APIs and the JSON ones.
+func newSysGovReceipt(tx *types.Transaction, failed bool, cumulativeGasUsed uint64) *types.Receipt {
+	receipt := &types.Receipt{
+		Type:              tx.Type(),
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: cumulativeGasUsed,
+		Logs:              []*types.Log{},
+	}
+	if failed {
+		receipt.Status = types.ReceiptStatusFailed
+	}
+	return receipt
+}
+
 // the returned value should not nil.
-func (c *Congress) executeEvmCallProposal(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, prop *Proposal, totalTxIndex int, txHash, bHash common.Hash) *types.Receipt {
+func (c *Congress) executeEvmCallProposal(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, prop *Proposal, totalTxIndex int, tx *types.Transaction, bHash common.Hash) *types.Receipt {
+	txHash := tx.Hash()
+
 	// actually run the governance message
 	msg := vmcaller.NewLegacyMessage(prop.From, &prop.To, 0, prop.Value, header.GasLimit, new(big.Int), prop.Data, false)
 	state.Prepare(txHash, totalTxIndex)
-	_, err := vmcaller.ExecuteMsg(msg, state, header, newChainContext(chain, c), c.chainConfig)
+	_, err := c.executeSystemMsg(chain, header, msg, state)
 
 	// governance message will not actually consumes gas
-	receipt := types.NewReceipt([]byte{}, err != nil, header.GasUsed)
+	receipt := newSysGovReceipt(tx, err != nil, header.GasUsed)
 	// Set the receipt logs and create a bloom for filtering
-	receipt.Logs = state.GetLogs(txHash, bHash)
+	receipt.Logs = append(receipt.Logs, state.GetLogs(txHash, bHash)...)
 	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
 
 	log.Info("executeProposalMsg", "action", "evmCall", "id", prop.Id.String(), "from", prop.From, "to", prop.To, "value", prop.Value.String(), "data", hexutil.Encode(prop.Data), "txHash", txHash.String(), "err", err)
@@ -238,6 +287,10 @@ func (c *Congress) ApplySysTx(evm *vm.EVM, state *state.StateDB, txIndex int, se
 	case 1:
 		// delete code action
 		_ = state.Erase(prop.To)
+	case punishBreakerAction:
+		vmerr = c.applyPunishBreakerProposal(evm.Context.BlockNumber, state, prop)
+	case feeBurnAction:
+		vmerr = c.applyFeeBurnProposal(evm.Context.BlockNumber, state, prop)
 	default:
 		vmerr = errors.New("unsupported action")
 	}
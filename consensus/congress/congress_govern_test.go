@@ -0,0 +1,36 @@
+package congress
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestSysGovReceiptRoundTrip(t *testing.T) {
+	to := common.HexToAddress("0x000000000000000000000000000000000000f000")
+	txs := []*types.Transaction{
+		types.NewTransaction(0, to, new(big.Int), 0, new(big.Int), nil),
+		types.NewTx(&types.AccessListTx{ChainID: big.NewInt(1), To: &to, Value: new(big.Int), GasPrice: new(big.Int)}),
+	}
+	for _, tx := range txs {
+		for _, failed := range []bool{false, true} {
+			receipt := newSysGovReceipt(tx, failed, 21000)
+			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+			blob, err := receipt.MarshalBinary()
+			if err != nil {
+				t.Fatalf("type %d, failed %v: failed to encode receipt: %v", tx.Type(), failed, err)
+			}
+			decoded := new(types.Receipt)
+			if err := decoded.UnmarshalBinary(blob); err != nil {
+				t.Fatalf("type %d, failed %v: failed to decode receipt: %v", tx.Type(), failed, err)
+			}
+			if !reflect.DeepEqual(decoded, receipt) {
+				t.Errorf("type %d, failed %v: receipt mismatch: have %+v, want %+v", tx.Type(), failed, decoded, receipt)
+			}
+		}
+	}
+}
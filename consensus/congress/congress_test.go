@@ -1,8 +1,24 @@
 package congress
 
 import (
-	"github.com/ethereum/go-ethereum/common"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 func TestCalcSlotOfDevMappingKey(t *testing.T) {
@@ -12,3 +28,953 @@ func TestCalcSlotOfDevMappingKey(t *testing.T) {
 	// want: 0xb314f101a00aa0d8cc6704cc6dd1e9dd7551ec98c9df52079c192c560ba66c4a
 
 }
+
+func TestParseExtraValidators(t *testing.T) {
+	vals := []common.Address{
+		common.HexToAddress("0x5b38da6a701c568545dcfcb03fcb875f56beddc4"),
+		common.HexToAddress("0xab8483f64d9c6d1ecf9b849ae677dd3315835cb2"),
+	}
+	extra := make([]byte, extraVanity)
+	for _, v := range vals {
+		extra = append(extra, v.Bytes()...)
+	}
+	extra = append(extra, make([]byte, extraSeal)...)
+
+	got := parseExtraValidators(&types.Header{Extra: extra})
+	if len(got) != len(vals) {
+		t.Fatalf("validators length mismatch: have %d, want %d", len(got), len(vals))
+	}
+	for i := range vals {
+		if got[i] != vals[i] {
+			t.Errorf("validator %d mismatch: have %s, want %s", i, got[i], vals[i])
+		}
+	}
+	if got := parseExtraValidators(&types.Header{Extra: make([]byte, extraVanity)}); got != nil {
+		t.Errorf("expected nil for short extra, have %v", got)
+	}
+}
+
+func TestPreconfirmation(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	c := &Congress{}
+	msg := PreconfirmMessage(big.NewInt(128), 100, common.Hash{0x01}, common.Hash{0x02})
+	if _, _, err := c.SignPreconfirmation(msg); err != errNotAuthorized {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNotAuthorized)
+	}
+	c.Authorize(addr, func(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+		return crypto.Sign(crypto.Keccak256(data), key)
+	}, nil)
+
+	validator, sig, err := c.SignPreconfirmation(msg)
+	if err != nil {
+		t.Fatalf("failed to sign preconfirmation: %v", err)
+	}
+	if validator != addr {
+		t.Fatalf("validator mismatch: have %s, want %s", validator, addr)
+	}
+	if signer, err := RecoverPreconfirmation(msg, sig); err != nil || signer != addr {
+		t.Fatalf("signer mismatch: have %s, want %s, err %v", signer, addr, err)
+	}
+	if !c.InTurn(&types.Header{Coinbase: addr, Difficulty: diffInTurn}) {
+		t.Fatalf("in-turn header not detected")
+	}
+	if c.InTurn(&types.Header{Coinbase: addr, Difficulty: diffNoTurn}) {
+		t.Fatalf("out-of-turn header detected as in-turn")
+	}
+}
+
+// emptyChain is a header reader of a chain the node knows nothing about.
+type emptyChain struct {
+	config *params.ChainConfig
+}
+
+func (c *emptyChain) Config() *params.ChainConfig                    { return c.config }
+func (c *emptyChain) CurrentHeader() *types.Header                   { return nil }
+func (c *emptyChain) GetHeader(common.Hash, uint64) *types.Header    { return nil }
+func (c *emptyChain) GetHeaderByNumber(uint64) *types.Header         { return nil }
+func (c *emptyChain) GetHeaderByHash(hash common.Hash) *types.Header { return nil }
+
+// Tests that a node without a signing function, e.g. an RPC-only node running the
+// worker, can assemble the blocks on a real chain as long as no system governance
+// proposal is to be executed, and gets an error instead of a panic otherwise.
+func TestFinalizeAndAssembleWithoutSigner(t *testing.T) {
+	keys := []*ecdsa.PrivateKey{vectorKey("validator-0"), vectorKey("validator-1")}
+	validators := []common.Address{crypto.PubkeyToAddress(keys[0].PublicKey), crypto.PubkeyToAddress(keys[1].PublicKey)}
+
+	genesis := vectorGenesis(validators, 200)
+	genesis.Config.RedCoastBlock = big.NewInt(2)
+	vc, err := newVectorChain(genesis, true)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer vc.chain.Stop()
+
+	parent, err := vc.forge(vc.chain.Genesis(), validators[0], keys[0])
+	if err != nil {
+		t.Fatalf("failed to forge block 1: %v", err)
+	}
+	if _, err := vc.chain.InsertChain(types.Blocks{parent}); err != nil {
+		t.Fatalf("failed to import block 1: %v", err)
+	}
+	// Stub the system governance contract to report the given count of passed
+	// proposals, and assemble the next block out of turn, punishing included.
+	assemble := func(proposals byte) (*types.Block, error) {
+		vc.engine.Authorize(validators[0], nil, nil)
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number(), common.Big1),
+			GasLimit:   parent.GasLimit(),
+		}
+		if err := vc.engine.Prepare(vc.chain, header); err != nil {
+			t.Fatalf("failed to prepare header: %v", err)
+		}
+		statedb, err := vc.chain.StateAt(parent.Root())
+		if err != nil {
+			t.Fatalf("failed to load parent state: %v", err)
+		}
+		statedb.SetCode(systemcontract.SysGovContractAddr, []byte{
+			byte(vm.PUSH1), proposals, byte(vm.PUSH1), 0, byte(vm.MSTORE),
+			byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+		})
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("finalize panicked: %v", r)
+			}
+		}()
+		block, _, err := vc.engine.FinalizeAndAssemble(vc.chain, header, statedb, nil, nil, nil)
+		return block, err
+	}
+	if block, err := assemble(0); err != nil {
+		t.Fatalf("failed to assemble block without proposals: %v", err)
+	} else if block.NumberU64() != 2 {
+		t.Fatalf("block number mismatch: have %d, want 2", block.NumberU64())
+	}
+	block, err := assemble(1)
+	if err != errMissingSignTxFn {
+		t.Fatalf("error mismatch: have %v, want %v", err, errMissingSignTxFn)
+	}
+	if block != nil {
+		t.Fatalf("unexpected block assembled: %d", block.NumberU64())
+	}
+}
+
+// parentChain is a header reader serving only the parent of the verified block.
+type parentChain struct {
+	emptyChain
+	parent *types.Header
+}
+
+func (c *parentChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if number == c.parent.Number.Uint64() {
+		return c.parent
+	}
+	return nil
+}
+
+// Tests that the offline re-verification of stored blocks flags the broken seals
+// and difficulties.
+func TestVerifyBlock(t *testing.T) {
+	snap, _, keys := newTestChain(t, 3, 0)
+
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: snap.config}
+	engine := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+	engine.recents.Add(common.Hash{}, snap)
+	chain := &parentChain{emptyChain{config}, &types.Header{Number: big.NewInt(0)}}
+
+	var inturn, outturn *ecdsa.PrivateKey
+	for _, key := range keys {
+		if snap.inturn(1, crypto.PubkeyToAddress(key.PublicKey)) {
+			inturn = key
+		} else {
+			outturn = key
+		}
+	}
+	stranger, _ := crypto.GenerateKey()
+
+	tests := []struct {
+		key        *ecdsa.PrivateKey
+		difficulty *big.Int
+		errors     int
+	}{
+		{inturn, diffInTurn, 0},
+		{outturn, diffNoTurn, 0},
+		{inturn, diffNoTurn, 1},
+		{outturn, diffInTurn, 1},
+		{stranger, diffNoTurn, 1},
+	}
+	for i, tt := range tests {
+		header := &types.Header{
+			Number:     big.NewInt(1),
+			Coinbase:   crypto.PubkeyToAddress(tt.key.PublicKey),
+			Difficulty: new(big.Int).Set(tt.difficulty),
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+		sealTestHeader(t, header, tt.key)
+
+		v := engine.verifyBlock(chain, types.NewBlockWithHeader(header), snap, nil)
+		if v.Signer != header.Coinbase {
+			t.Errorf("test %d: signer mismatch: have %x, want %x", i, v.Signer, header.Coinbase)
+		}
+		if len(v.Errors) != tt.errors {
+			t.Errorf("test %d: errors mismatch: have %v, want %d", i, v.Errors, tt.errors)
+		}
+	}
+}
+
+// Tests that the chain verifier recomputes the snapshots from the headers, flagging
+// the stored ones mismatching them.
+func TestChainVerifierSnapshots(t *testing.T) {
+	keys := []*ecdsa.PrivateKey{vectorKey("validator-0"), vectorKey("validator-1"), vectorKey("validator-2")}
+	validators := make([]common.Address, len(keys))
+	for i, key := range keys {
+		validators[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+	vc, err := newVectorChain(vectorGenesis(validators, 4), true)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer vc.chain.Stop()
+
+	blocks := []*types.Block{vc.chain.Genesis()}
+	for i := 1; i <= 12; i++ {
+		block, err := vc.forge(blocks[i-1], validators[i%len(validators)], keys[i%len(keys)])
+		if err != nil {
+			t.Fatalf("failed to forge block %d: %v", i, err)
+		}
+		if _, err := vc.chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatalf("failed to import block %d: %v", i, err)
+		}
+		blocks = append(blocks, block)
+	}
+	// Store a snapshot with a forged validator set for a block in the range
+	tampered, err := vc.snapshot(blocks[10])
+	if err != nil {
+		t.Fatalf("failed to retrieve snapshot: %v", err)
+	}
+	tampered = tampered.copy()
+	tampered.Validators[common.Address{0x01}] = struct{}{}
+	if err := tampered.store(vc.engine.db); err != nil {
+		t.Fatalf("failed to store snapshot: %v", err)
+	}
+	vc.engine.recents.Purge()
+
+	verifier, err := vc.engine.NewChainVerifier(vc.chain, 8)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+	if verifier.snap.Number != 8 || verifier.snap.Hash != blocks[8].Hash() {
+		t.Fatalf("base snapshot mismatch: have #%d %x, want #8 %x", verifier.snap.Number, verifier.snap.Hash, blocks[8].Hash())
+	}
+	for _, block := range blocks[9:] {
+		v := verifier.Verify(block, nil)
+		if block.NumberU64() == 10 {
+			if len(v.Errors) != 1 || !strings.Contains(v.Errors[0], errMismatchingSnapshot.Error()) {
+				t.Errorf("block %d: errors mismatch: have %v, want %v", block.NumberU64(), v.Errors, errMismatchingSnapshot)
+			}
+		} else if !v.Valid() {
+			t.Errorf("block %d: unexpected errors: %v", block.NumberU64(), v.Errors)
+		}
+	}
+}
+
+// Tests that the extra-data is decoded along with the first rule it violates.
+func TestDecodeExtra(t *testing.T) {
+	snap, _, keys := newTestChain(t, 3, 0)
+
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: snap.config}
+	engine := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+	engine.recents.Add(common.Hash{}, snap)
+	chain := &parentChain{emptyChain{config}, &types.Header{Number: big.NewInt(0)}}
+
+	stranger, _ := crypto.GenerateKey()
+	vanity := common.Hash{0x01}
+
+	tests := []struct {
+		key       *ecdsa.PrivateKey
+		extra     []byte
+		recovered bool
+		err       error
+	}{
+		{keys[0], make([]byte, extraVanity+extraSeal), true, nil},
+		{stranger, make([]byte, extraVanity+extraSeal), true, errUnauthorizedValidator},
+		{keys[0], make([]byte, extraVanity+common.AddressLength+extraSeal), false, errExtraValidators},
+		{nil, make([]byte, extraVanity), false, errMissingSignature},
+	}
+	for i, tt := range tests {
+		header := &types.Header{
+			Number:     big.NewInt(1),
+			Difficulty: new(big.Int).Set(diffNoTurn),
+			Extra:      tt.extra,
+		}
+		copy(header.Extra, vanity[:])
+		if tt.key != nil {
+			sealTestHeader(t, header, tt.key)
+		}
+		decoded := engine.decodeExtra(chain, header)
+		if tt.err == nil && (!decoded.Valid || decoded.Error != "") {
+			t.Errorf("test %d: unexpected violation: %s", i, decoded.Error)
+		}
+		if tt.err != nil && (decoded.Valid || decoded.Error != tt.err.Error()) {
+			t.Errorf("test %d: violation mismatch: have %q, want %q", i, decoded.Error, tt.err)
+		}
+		if common.BytesToHash(decoded.Vanity) != vanity {
+			t.Errorf("test %d: vanity mismatch: have %x, want %x", i, decoded.Vanity, vanity)
+		}
+		if !tt.recovered && decoded.Signer != nil {
+			t.Errorf("test %d: unexpected signer %x", i, *decoded.Signer)
+		}
+		if tt.recovered && (decoded.Signer == nil || *decoded.Signer != crypto.PubkeyToAddress(tt.key.PublicKey)) {
+			t.Errorf("test %d: signer mismatch: have %v, want %x", i, decoded.Signer, crypto.PubkeyToAddress(tt.key.PublicKey))
+		}
+	}
+}
+
+// Tests that the author is the coinbase of the header, the seal verification
+// rejecting the headers whose coinbase isn't their signer.
+func TestAuthorCoinbase(t *testing.T) {
+	snap, _, keys := newTestChain(t, 3, 0)
+
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: snap.config}
+	chain := &parentChain{emptyChain{config}, &types.Header{Number: big.NewInt(0)}}
+	engine := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+	engine.recents.Add(common.Hash{}, snap)
+
+	signer := crypto.PubkeyToAddress(keys[0].PublicKey)
+	spoofed := common.HexToAddress("0xdeadbeef")
+
+	header := &types.Header{
+		Number:     big.NewInt(1),
+		Coinbase:   spoofed,
+		Difficulty: calcDifficulty(snap, signer),
+		Extra:      make([]byte, extraVanity+extraSeal),
+	}
+	sealTestHeader(t, header, keys[0])
+	if author, err := engine.Author(header); err != nil || author != spoofed {
+		t.Fatalf("spoofed author mismatch: have %x, %v, want %x", author, err, spoofed)
+	}
+	if err := engine.VerifySeal(chain, header); err != errInvalidCoinbase {
+		t.Fatalf("spoofed coinbase error mismatch: have %v, want %v", err, errInvalidCoinbase)
+	}
+	header.Coinbase = signer
+	sealTestHeader(t, header, keys[0])
+	if author, err := engine.Author(header); err != nil || author != signer {
+		t.Fatalf("author mismatch: have %x, %v, want %x", author, err, signer)
+	}
+	if err := engine.VerifySeal(chain, header); err != nil {
+		t.Fatalf("failed to verify seal: %v", err)
+	}
+}
+
+func TestMinBlockTime(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: &params.CongressConfig{
+		Period:              3,
+		Epoch:               200,
+		EpochSealDelay:      2,
+		EpochSealDelayBlock: big.NewInt(400),
+	}}
+	engine := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+
+	tests := []struct {
+		number uint64
+		diff   *big.Int
+		want   uint64
+	}{
+		{201, diffNoTurn, 103}, // before the fork
+		{401, diffInTurn, 103}, // in-turn validator after a set change
+		{401, diffNoTurn, 105}, // out-of-turn validator after a set change
+		{402, diffNoTurn, 103}, // out-of-turn validator later in the epoch
+		{400, diffNoTurn, 103}, // checkpoint block
+	}
+	for i, tt := range tests {
+		parent := &types.Header{Number: new(big.Int).SetUint64(tt.number - 1), Time: 100}
+		header := &types.Header{Number: new(big.Int).SetUint64(tt.number), Difficulty: tt.diff}
+		if have := engine.minBlockTime(parent, header); have != tt.want {
+			t.Errorf("test %d: min block time mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that preparing a block is refused on top of a parent older than the
+// configured maximum age, unless connected peers report no heavier head.
+func TestStaleParentPrepare(t *testing.T) {
+	snap, _, _ := newTestChain(t, 3, 0)
+
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: snap.config}
+	engine := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+	engine.SetSysCodeCheckMode(SysCodeCheckOff)
+	engine.SetMaxParentAge(time.Minute)
+
+	tests := []struct {
+		age   time.Duration
+		peers int  // Number of connected peers, -1 if unknown
+		ahead bool // Whether a peer reports a heavier head
+		stale bool
+	}{
+		{0, -1, false, false},
+		{30 * time.Second, -1, false, false},
+		{2 * time.Minute, -1, false, true},
+		// An isolated validator or one behind its peers refuses to seal
+		{2 * time.Minute, 0, false, true},
+		{2 * time.Minute, 3, true, true},
+		// A chain stalled network wide is restarted
+		{2 * time.Minute, 3, false, false},
+		{30 * time.Second, 3, true, false},
+	}
+	for i, tt := range tests {
+		parent := &types.Header{Number: big.NewInt(9), Time: uint64(time.Now().Add(-tt.age).Unix())}
+		header := &types.Header{Number: big.NewInt(10), ParentHash: parent.Hash()}
+		chain := &parentChain{emptyChain: emptyChain{config: config}, parent: parent}
+		engine.recents.Add(parent.Hash(), snap)
+
+		if tt.peers < 0 {
+			engine.SetPeerHeadFn(nil)
+		} else {
+			peers, ahead := tt.peers, tt.ahead
+			engine.SetPeerHeadFn(func(*types.Header) (int, bool) { return peers, ahead })
+		}
+		err := engine.Prepare(chain, header)
+		if stale := err == errStaleParent; stale != tt.stale {
+			t.Errorf("test %d: stale parent mismatch: have %v, want %v (err %v)", i, stale, tt.stale, err)
+		}
+	}
+}
+
+// Tests that a block sealed out of turn is sent ahead of its release, and withdrawn
+// if the sealing is terminated before it.
+func TestDelayedSealCancel(t *testing.T) {
+	snap, _, keys := newTestChain(t, 3, 0)
+
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: snap.config}
+	engine := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+	engine.recents.Add(common.Hash{}, snap)
+	engine.Authorize(crypto.PubkeyToAddress(keys[0].PublicKey), func(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+		return crypto.Sign(crypto.Keccak256(data), keys[0])
+	}, nil)
+
+	var (
+		announced = make(chan *types.Block, 1)
+		cancelled = make(chan common.Hash, 1)
+	)
+	engine.SetDelayedSealFn(func(block *types.Block, release time.Time) func() {
+		announced <- block
+		return func() { cancelled <- block.Hash() }
+	})
+	header := &types.Header{
+		Number:     big.NewInt(1),
+		Time:       uint64(time.Now().Add(time.Minute).Unix()),
+		Difficulty: new(big.Int).Set(diffNoTurn),
+		Extra:      make([]byte, extraVanity+extraSeal),
+	}
+	var (
+		results = make(chan *types.Block, 1)
+		stop    = make(chan struct{})
+	)
+	if err := engine.Seal(&emptyChain{config}, types.NewBlockWithHeader(header), results, stop); err != nil {
+		t.Fatalf("failed to seal: %v", err)
+	}
+	var block *types.Block
+	select {
+	case block = <-announced:
+	default:
+		t.Fatalf("block sealed out of turn not sent ahead")
+	}
+	close(stop)
+	select {
+	case hash := <-cancelled:
+		if hash != block.Hash() {
+			t.Errorf("withdrawn block mismatch: have %x, want %x", hash, block.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("replaced block not withdrawn")
+	}
+	select {
+	case <-results:
+		t.Errorf("replaced block released")
+	default:
+	}
+}
+
+// Tests that the faker trusts the coinbase of unsigned headers, skipping the
+// difficulty checks, and runs on the given clock.
+func TestFaker(t *testing.T) {
+	snap, _, keys := newTestChain(t, 3, 0)
+
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: snap.config}
+	chain := &parentChain{emptyChain{config}, &types.Header{Number: big.NewInt(0)}}
+	now := time.Unix(1000, 0)
+
+	engine := NewFaker(config, rawdb.NewMemoryDatabase(), new(testChainBackend), WithClock(func() time.Time { return now }))
+	engine.recents.Add(common.Hash{}, snap)
+
+	stranger, _ := crypto.GenerateKey()
+	tests := []struct {
+		coinbase common.Address
+		err      error
+	}{
+		{crypto.PubkeyToAddress(keys[0].PublicKey), nil},
+		{crypto.PubkeyToAddress(keys[1].PublicKey), nil},
+		{crypto.PubkeyToAddress(stranger.PublicKey), errUnauthorizedValidator},
+	}
+	for i, tt := range tests {
+		header := &types.Header{
+			Number:     big.NewInt(1),
+			Time:       uint64(now.Unix()),
+			Coinbase:   tt.coinbase,
+			Difficulty: new(big.Int).Set(diffInTurn),
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+		if err := engine.VerifySeal(chain, header); err != tt.err {
+			t.Errorf("test %d: seal error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		if signer, _ := engine.Signer(header); signer != tt.coinbase {
+			t.Errorf("test %d: signer mismatch: have %x, want %x", i, signer, tt.coinbase)
+		}
+	}
+	// Headers are from the future according to the fake clock
+	header := &types.Header{Number: big.NewInt(1), Time: uint64(now.Unix()) + 1}
+	if err := engine.verifyHeader(chain, header, nil); err != consensus.ErrFutureBlock {
+		t.Errorf("future block error mismatch: have %v, want %v", err, consensus.ErrFutureBlock)
+	}
+	// The regular engine refuses the unsigned headers
+	header = &types.Header{
+		Number:     big.NewInt(1),
+		Coinbase:   crypto.PubkeyToAddress(keys[0].PublicKey),
+		Difficulty: new(big.Int).Set(diffInTurn),
+		Extra:      make([]byte, extraVanity+extraSeal),
+	}
+	strict := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+	strict.recents.Add(common.Hash{}, snap)
+	if err := strict.VerifySeal(chain, header); err == nil {
+		t.Errorf("unsigned header accepted by the regular engine")
+	}
+}
+
+// Tests that the vanity tags round trip through the padded vanity prefix, and
+// that the ones not fitting into it are refused.
+func TestVanityTag(t *testing.T) {
+	tests := []struct {
+		version, tag string
+		fail         bool
+	}{
+		{"1.3.0", "", false},
+		{"1.3.0", "pool-a", false},
+		{"1.3.0", "a-tag-of-exactly-21-b", false},
+		{"1.3.0", "a-tag-of-exactly-22-by", true},
+		{"1.3.0", "bad/tag", true},
+		{"1.3.0", "bad\ntag", true},
+	}
+	for i, tt := range tests {
+		vanity, err := VanityTag(tt.version, tt.tag)
+		if (err != nil) != tt.fail {
+			t.Fatalf("test %d: error mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+		if err != nil {
+			continue
+		}
+		extra := append(append(vanity, make([]byte, extraVanity-len(vanity))...), make([]byte, extraSeal)...)
+		version, tag, ok := decodeVanityTag(extra)
+		if !ok || version != tt.version || tag != tt.tag {
+			t.Errorf("test %d: decoded tag mismatch: have %q %q %v, want %q %q", i, version, tag, ok, tt.version, tt.tag)
+		}
+	}
+	if _, _, ok := decodeVanityTag(make([]byte, extraVanity+extraSeal)); ok {
+		t.Errorf("decoded a vanity tag from an empty vanity")
+	}
+}
+
+func TestHeaderConsensusInfo(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: &params.CongressConfig{Period: 3, Epoch: 200}}
+	engine := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	validators := []common.Address{{0x01}, {0x02}}
+
+	extra := make([]byte, extraVanity)
+	for _, validator := range validators {
+		extra = append(extra, validator.Bytes()...)
+	}
+	header := &types.Header{
+		Number:     big.NewInt(200),
+		Difficulty: new(big.Int).Set(diffInTurn),
+		Extra:      append(extra, make([]byte, extraSeal)...),
+	}
+	sealTestHeader(t, header, key)
+
+	if validator, err := engine.Signer(header); err != nil || validator != signer {
+		t.Fatalf("signer mismatch: have %x, %v, want %x", validator, err, signer)
+	}
+	if !InTurn(header) {
+		t.Fatalf("in-turn header reported out of turn")
+	}
+	if set, ok := engine.CheckpointValidators(header); !ok || len(set) != 2 || set[0] != validators[0] || set[1] != validators[1] {
+		t.Fatalf("checkpoint validators mismatch: have %v, %v, want %v", set, ok, validators)
+	}
+	header.Number = big.NewInt(201)
+	header.Difficulty = new(big.Int).Set(diffNoTurn)
+	if InTurn(header) {
+		t.Fatalf("out-of-turn header reported in turn")
+	}
+	if _, ok := engine.CheckpointValidators(header); ok {
+		t.Fatalf("validators returned for a non-checkpoint header")
+	}
+}
+
+func TestAttestation(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	c := &Congress{}
+	msg := AttestationMessage(big.NewInt(128), 100, common.Hash{0x01})
+	if _, _, err := c.SignAttestation(msg); err != errNotAuthorized {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNotAuthorized)
+	}
+	var mimes []string
+	c.Authorize(addr, func(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+		mimes = append(mimes, mimeType)
+		return crypto.Sign(crypto.Keccak256(data), key)
+	}, nil)
+
+	validator, sig, err := c.SignAttestation(msg)
+	if err != nil {
+		t.Fatalf("failed to sign attestation: %v", err)
+	}
+	if validator != addr {
+		t.Fatalf("validator mismatch: have %s, want %s", validator, addr)
+	}
+	if len(mimes) != 1 || mimes[0] != accounts.MimetypeAttestation {
+		t.Fatalf("mimetype mismatch: have %v, want %s", mimes, accounts.MimetypeAttestation)
+	}
+	if signer, err := RecoverAttestation(msg, sig); err != nil || signer != addr {
+		t.Fatalf("signer mismatch: have %s, want %s, err %v", signer, addr, err)
+	}
+	// An attestation of another block must not verify for the signer
+	other := AttestationMessage(big.NewInt(128), 100, common.Hash{0x02})
+	if signer, _ := RecoverAttestation(other, sig); signer == addr {
+		t.Fatalf("signature verified for another block")
+	}
+}
+
+// Tests that the punish breaker armed by the system governance turns the failed
+// punishments into skips counted in state, and only once its fork is reached.
+func TestPunishBreaker(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(1), RedCoastBlock: big.NewInt(2), SophonBlock: big.NewInt(3), Congress: &params.CongressConfig{Period: 3, Epoch: 200, PunishBreakerBlock: big.NewInt(10)}}
+	engine := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+
+	var (
+		chain     = &emptyChain{config}
+		validator = common.HexToAddress("0x01")
+		revert    = common.FromHex("0x60006000fd") // revert(0, 0)
+		arm       = &Proposal{Id: new(big.Int), Action: big.NewInt(punishBreakerAction), Value: new(big.Int), Data: []byte{0x01}}
+		disarm    = &Proposal{Id: new(big.Int), Action: big.NewInt(punishBreakerAction), Value: new(big.Int), Data: []byte{0x00}}
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(systemcontract.SysGovContractAddr, []byte{0x00})
+	statedb.SetCode(systemcontract.PunishV1ContractAddr, revert)
+
+	header := &types.Header{Number: big.NewInt(9), GasLimit: 8000000, Difficulty: new(big.Int)}
+	if err := engine.applyPunishBreakerProposal(header.Number, statedb, arm); err != errUnsupportedAction {
+		t.Fatalf("error mismatch before the fork: have %v, want %v", err, errUnsupportedAction)
+	}
+	header.Number = big.NewInt(10)
+	if err := engine.punishOrSkip(validator, chain, header, statedb); err == nil {
+		t.Fatalf("failed punishment skipped by an unarmed breaker")
+	}
+	invalid := &Proposal{Id: new(big.Int), Action: big.NewInt(punishBreakerAction), Value: new(big.Int), Data: []byte{0x02}}
+	if err := engine.applyPunishBreakerProposal(header.Number, statedb, invalid); err != errInvalidPunishBreaker {
+		t.Fatalf("error mismatch for invalid data: have %v, want %v", err, errInvalidPunishBreaker)
+	}
+	if err := engine.applyPunishBreakerProposal(header.Number, statedb, arm); err != nil {
+		t.Fatalf("failed to arm the breaker: %v", err)
+	}
+	// The failures are skipped and counted in state
+	for i := uint64(1); i <= 2; i++ {
+		if err := engine.punishOrSkip(validator, chain, header, statedb); err != nil {
+			t.Fatalf("failed punishment %d not skipped: %v", i, err)
+		}
+		if have := punishSkipped(statedb); have != i {
+			t.Fatalf("skipped count mismatch: have %d, want %d", have, i)
+		}
+	}
+	// A successful punishment resets the count
+	statedb.SetCode(systemcontract.PunishV1ContractAddr, []byte{0x00})
+	if err := engine.punishOrSkip(validator, chain, header, statedb); err != nil {
+		t.Fatalf("failed to punish: %v", err)
+	}
+	if have := punishSkipped(statedb); have != 0 {
+		t.Fatalf("skipped count not reset: have %d", have)
+	}
+	// A disarmed breaker lets the failures through again
+	statedb.SetCode(systemcontract.PunishV1ContractAddr, revert)
+	if err := engine.applyPunishBreakerProposal(header.Number, statedb, disarm); err != nil {
+		t.Fatalf("failed to disarm the breaker: %v", err)
+	}
+	if err := engine.punishOrSkip(validator, chain, header, statedb); err == nil {
+		t.Fatalf("failed punishment skipped by a disarmed breaker")
+	}
+}
+
+// Tests that the fee burn ratio tuned by the system governance overrides the chain
+// config one, and only once its fork is reached.
+func TestFeeBurnProposal(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: &params.CongressConfig{Period: 3, Epoch: 200, FeeBurnRatio: 300, FeeBurnBlock: big.NewInt(10)}}
+	engine := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(systemcontract.SysGovContractAddr, []byte{0x00})
+
+	propose := func(data []byte) *Proposal {
+		return &Proposal{Id: new(big.Int), Action: big.NewInt(feeBurnAction), Value: new(big.Int), Data: data}
+	}
+	if err := engine.applyFeeBurnProposal(big.NewInt(9), statedb, propose([]byte{0x64})); err != errUnsupportedAction {
+		t.Fatalf("error mismatch before the fork: have %v, want %v", err, errUnsupportedAction)
+	}
+	if ratio := engine.FeeBurnRatio(statedb, big.NewInt(9)); ratio != 0 {
+		t.Fatalf("ratio mismatch before the fork: have %d, want 0", ratio)
+	}
+	if ratio := engine.FeeBurnRatio(statedb, big.NewInt(10)); ratio != 300 {
+		t.Fatalf("ratio mismatch at the fork: have %d, want 300", ratio)
+	}
+	for _, data := range [][]byte{nil, {0x03, 0xe9}, make([]byte, 33)} {
+		if err := engine.applyFeeBurnProposal(big.NewInt(10), statedb, propose(data)); err != errInvalidFeeBurnRatio {
+			t.Fatalf("error mismatch for data %x: have %v, want %v", data, err, errInvalidFeeBurnRatio)
+		}
+	}
+	// The governance ratio applies, including the zero one
+	tests := []struct {
+		data  []byte
+		ratio uint64
+	}{
+		{[]byte{0x03, 0xe8}, 1000},
+		{[]byte{0x00}, 0},
+	}
+	for _, tt := range tests {
+		if err := engine.applyFeeBurnProposal(big.NewInt(10), statedb, propose(tt.data)); err != nil {
+			t.Fatalf("failed to set ratio %d: %v", tt.ratio, err)
+		}
+		if have := engine.FeeBurnRatio(statedb, big.NewInt(11)); have != tt.ratio {
+			t.Fatalf("governance ratio mismatch: have %d, want %d", have, tt.ratio)
+		}
+	}
+}
+
+// Tests that the validator set updates are only capped once the max validators
+// fork is reached, from the first epoch block at or after it.
+func TestUpdateValidatorsCap(t *testing.T) {
+	vals := make([]common.Address, params.DefaultCongressMaxValidators+4)
+	for i := range vals {
+		vals[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+	}
+	tests := []struct {
+		config *params.CongressConfig
+		number int64
+		err    error
+	}{
+		{&params.CongressConfig{Period: 3, Epoch: 100}, 100, nil},
+		{&params.CongressConfig{Period: 3, Epoch: 100, MaxValidators: 24, MaxValidatorsBlock: big.NewInt(200)}, 100, nil},
+		{&params.CongressConfig{Period: 3, Epoch: 100, MaxValidators: 24, MaxValidatorsBlock: big.NewInt(100)}, 100, errInvalidValidatorsLength},
+		{&params.CongressConfig{Period: 3, Epoch: 100, MaxValidators: 25, MaxValidatorsBlock: big.NewInt(100)}, 100, nil},
+		{&params.CongressConfig{Period: 3, Epoch: 100, MaxValidators: 24, MaxValidatorsBlock: big.NewInt(150)}, 200, errInvalidValidatorsLength},
+	}
+	for i, tt := range tests {
+		config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: tt.config}
+		engine := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		header := &types.Header{Number: big.NewInt(tt.number), GasLimit: 8000000, Difficulty: new(big.Int)}
+		if err := engine.updateValidators(vals, &emptyChain{config}, header, statedb); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestDecodeProposal(t *testing.T) {
+	prop := &Proposal{
+		Id:     big.NewInt(7),
+		Action: big.NewInt(0),
+		From:   common.HexToAddress("0x5b38da6a701c568545dcfcb03fcb875f56beddc4"),
+		To:     common.HexToAddress("0xab8483f64d9c6d1ecf9b849ae677dd3315835cb2"),
+		Value:  big.NewInt(100),
+		Data:   []byte{0x01, 0x02},
+	}
+	data, _ := rlp.EncodeToBytes(prop)
+
+	tx := types.NewTransaction(0, systemcontract.SysGovToAddr, new(big.Int), 100000, new(big.Int), data)
+	decoded, err := DecodeProposal(tx)
+	if err != nil {
+		t.Fatalf("failed to decode proposal: %v", err)
+	}
+	if decoded.Id.Cmp(prop.Id) != 0 || decoded.Action.Cmp(prop.Action) != 0 || decoded.From != prop.From ||
+		decoded.To != prop.To || decoded.Value.Cmp(prop.Value) != 0 || string(decoded.Data) != string(prop.Data) {
+		t.Errorf("proposal mismatch: have %+v, want %+v", decoded, prop)
+	}
+	tx = types.NewTransaction(0, systemcontract.SysGovContractAddr, new(big.Int), 100000, new(big.Int), data)
+	if _, err := DecodeProposal(tx); err != errNotProposalTx {
+		t.Errorf("error mismatch: have %v, want %v", err, errNotProposalTx)
+	}
+	tx = types.NewTransaction(0, systemcontract.SysGovToAddr, new(big.Int), 100000, new(big.Int), []byte{0x01})
+	if _, err := DecodeProposal(tx); err == nil {
+		t.Errorf("decoded proposal from junk payload")
+	}
+}
+
+// testChainBackend is a chain serving a single state if any, and no headers.
+type testChainBackend struct {
+	consensus.ChainHeaderReader
+	state *state.StateDB
+}
+
+func (b *testChainBackend) StateAt(common.Hash) (*state.StateDB, error) {
+	if b.state == nil {
+		return nil, errors.New("state not available")
+	}
+	return b.state, nil
+}
+
+// Tests that the engine reads the states from the chain it's created with.
+func TestChainBackend(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: &params.CongressConfig{Period: 3, Epoch: 200}}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	engine := New(config, rawdb.NewMemoryDatabase(), &testChainBackend{state: statedb})
+	if have, err := engine.chain.StateAt(common.Hash{}); err != nil || have != statedb {
+		t.Fatalf("state mismatch: have %p %v, want %p", have, err, statedb)
+	}
+}
+
+func TestSysCodeWatchdog(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(1), RedCoastBlock: big.NewInt(10), Congress: &params.CongressConfig{Period: 3, Epoch: 200}}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	engine := New(config, rawdb.NewMemoryDatabase(), &testChainBackend{state: statedb})
+	engine.SetSysCodeCheckMode(SysCodeCheckHalt)
+
+	// No system contract is bundled before the upgrade
+	if err := engine.verifySealingSysCode(&types.Header{Number: big.NewInt(5)}); err != nil {
+		t.Fatalf("sealing refused before the upgrade: %v", err)
+	}
+	// Crossing the upgrade without the upgraded code in state is caught
+	parent := &types.Header{Number: big.NewInt(10)}
+	if err := engine.verifySealingSysCode(parent); err != errSysCodeMismatch {
+		t.Fatalf("error mismatch after the upgrade: have %v, want %v", err, errSysCodeMismatch)
+	}
+	engine.SetSysCodeCheckMode(SysCodeCheckWarn)
+	if err := engine.verifySealingSysCode(parent); err != nil {
+		t.Fatalf("sealing refused in warn mode: %v", err)
+	}
+	if err := engine.CheckSystemContracts(parent); err != errSysCodeMismatch {
+		t.Fatalf("error mismatch on explicit check: have %v, want %v", err, errSysCodeMismatch)
+	}
+}
+
+// Tests that the system contract code is checked right after the upgrade applied
+// on block import at the fork height, the result being kept for sealing.
+func TestSysCodeCheckOnUpgrade(t *testing.T) {
+	key := vectorKey("validator-0")
+	validator := crypto.PubkeyToAddress(key.PublicKey)
+
+	genesis := vectorGenesis([]common.Address{validator}, 200)
+	genesis.Config.RedCoastBlock = big.NewInt(3)
+	vc, err := newVectorChain(genesis, true)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer vc.chain.Stop()
+
+	parent := vc.chain.Genesis()
+	for i := 1; i < 3; i++ {
+		if parent, err = vc.forge(parent, validator, key); err != nil {
+			t.Fatalf("failed to forge block %d: %v", i, err)
+		}
+		if _, err := vc.chain.InsertChain(types.Blocks{parent}); err != nil {
+			t.Fatalf("failed to import block %d: %v", i, err)
+		}
+	}
+	vc.engine.SetSysCodeCheckMode(SysCodeCheckHalt)
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     big.NewInt(3),
+		GasLimit:   parent.GasLimit(),
+		Time:       parent.Time() + genesis.Config.Congress.Period,
+		Difficulty: new(big.Int).Set(diffInTurn),
+		Coinbase:   validator,
+	}
+	statedb, err := vc.chain.StateAt(parent.Root())
+	if err != nil {
+		t.Fatalf("failed to load parent state: %v", err)
+	}
+	if err := vc.engine.PreHandle(vc.chain, header, statedb); err != nil {
+		t.Fatalf("failed to upgrade system contracts: %v", err)
+	}
+	w := vc.engine.sysCode
+	if !w.checked || w.version != systemcontract.SysContractV1 || w.mismatch {
+		t.Fatalf("upgrade check mismatch: checked %v, version %d, mismatch %v", w.checked, w.version, w.mismatch)
+	}
+	// The upgraded code is taken as verified for sealing, without state to re-check
+	vc.engine.chain = new(testChainBackend)
+	if err := vc.engine.verifySealingSysCode(header); err != nil {
+		t.Fatalf("sealing refused after the upgrade: %v", err)
+	}
+}
+
+// Tests that simulating a proposal reports its outcome and state changes, without
+// touching the state it's based on.
+func TestSimulateProposal(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: &params.CongressConfig{Period: 3, Epoch: 200}}
+	engine := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+
+	var (
+		sender = common.HexToAddress("0x5b38da6a701c568545dcfcb03fcb875f56beddc4")
+		target = common.HexToAddress("0xab8483f64d9c6d1ecf9b849ae677dd3315835cb2")
+		// sstore(0, 42), log0(0, 0)
+		code = common.FromHex("0x602a60005560006000a000")
+	)
+	db := state.NewDatabase(rawdb.NewMemoryDatabase())
+	statedb, _ := state.New(common.Hash{}, db, nil)
+	statedb.SetBalance(sender, big.NewInt(1000))
+	statedb.SetCode(target, code)
+	root, _ := statedb.Commit(false)
+
+	parent := &types.Header{Number: big.NewInt(1), GasLimit: 8000000, Difficulty: new(big.Int), Root: root}
+	base, _ := state.New(root, db, nil)
+	prop := &Proposal{Id: new(big.Int), Action: big.NewInt(0), From: sender, To: target, Value: big.NewInt(100)}
+	result := engine.simulateProposal(&emptyChain{config}, parent, base, prop)
+	if !result.Success {
+		t.Fatalf("proposal failed: %s", result.Error)
+	}
+	if result.GasUsed == 0 {
+		t.Errorf("no gas used")
+	}
+	if len(result.Logs) != 1 || result.Logs[0].Address != target {
+		t.Errorf("logs mismatch: %v", result.Logs)
+	}
+	diff := result.StateDiff[target]
+	if diff == nil || diff.Before == nil || diff.After == nil {
+		t.Fatalf("missing target diff: %+v", diff)
+	}
+	slot := common.Hash{}
+	if have := diff.After.Storage[slot]; have != common.BigToHash(big.NewInt(42)) {
+		t.Errorf("slot after mismatch: have %x", have)
+	}
+	if have := diff.Before.Storage[slot]; have != (common.Hash{}) {
+		t.Errorf("slot before mismatch: have %x", have)
+	}
+	if have := diff.After.Balance.ToInt(); have.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("balance after mismatch: have %v, want 100", have)
+	}
+	if diff := result.StateDiff[sender]; diff == nil || diff.After.Balance.ToInt().Cmp(big.NewInt(900)) != 0 {
+		t.Errorf("sender diff mismatch: %+v", diff)
+	}
+	// A failing proposal is reported as such
+	prop.Value = big.NewInt(2000)
+	base, _ = state.New(root, db, nil)
+	if result := engine.simulateProposal(&emptyChain{config}, parent, base, prop); result.Success {
+		t.Errorf("proposal transferring more than the balance succeeded")
+	}
+	prop.Action = big.NewInt(2)
+	base, _ = state.New(root, db, nil)
+	if result := engine.simulateProposal(&emptyChain{config}, parent, base, prop); result.Error != errUnsupportedAction.Error() {
+		t.Errorf("error mismatch: have %q, want %q", result.Error, errUnsupportedAction)
+	}
+}
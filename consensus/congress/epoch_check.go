@@ -0,0 +1,89 @@
+package congress
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// EpochCheckMode is the mode of cross-checking the validators in a checkpoint's
+// extra-data against the ones re-derived from the contract state on header import.
+type EpochCheckMode string
+
+const (
+	EpochCheckOff  EpochCheckMode = "off"  // no cross-check (default)
+	EpochCheckLog  EpochCheckMode = "log"  // log the mismatches only
+	EpochCheckHalt EpochCheckMode = "halt" // reject the header on mismatch
+)
+
+var (
+	epochCheckMeter    = metrics.NewRegisteredMeter("congress/epochcheck/checked", nil)
+	epochMismatchMeter = metrics.NewRegisteredMeter("congress/epochcheck/mismatch", nil)
+	epochSkippedMeter  = metrics.NewRegisteredMeter("congress/epochcheck/skipped", nil)
+)
+
+// ParseEpochCheckMode parses the mode string, empty means off.
+func ParseEpochCheckMode(s string) (EpochCheckMode, error) {
+	switch mode := EpochCheckMode(s); mode {
+	case "", EpochCheckOff:
+		return EpochCheckOff, nil
+	case EpochCheckLog, EpochCheckHalt:
+		return mode, nil
+	default:
+		return EpochCheckOff, fmt.Errorf("invalid epoch check mode %q, want one of off, log, halt", s)
+	}
+}
+
+// parseExtraValidators returns the validators stored in a checkpoint header's extra-data.
+func parseExtraValidators(header *types.Header) []common.Address {
+	if len(header.Extra) < extraVanity+extraSeal {
+		return nil
+	}
+	validators := make([]common.Address, (len(header.Extra)-extraVanity-extraSeal)/common.AddressLength)
+	for i := 0; i < len(validators); i++ {
+		copy(validators[i][:], header.Extra[extraVanity+i*common.AddressLength:])
+	}
+	return validators
+}
+
+// verifyEpochValidators re-derives the top validators from the parent state of a checkpoint
+// header, and compares them with the ones in the extra-data. It's only done if the parent
+// state is available, e.g. on an archive node, or when importing blocks one by one.
+func (c *Congress) verifyEpochValidators(chain consensus.ChainHeaderReader, header *types.Header, parent *types.Header) error {
+	if c.epochCheck == "" || c.epochCheck == EpochCheckOff {
+		return nil
+	}
+	statedb, err := c.chain.StateAt(parent.Root)
+	if err != nil {
+		epochSkippedMeter.Mark(1)
+		log.Debug("Skip epoch validators check, parent state missing", "number", header.Number, "err", err)
+		return nil
+	}
+	expected, err := c.getTopValidatorsAt(chain, header, parent, statedb)
+	if err != nil {
+		epochSkippedMeter.Mark(1)
+		log.Warn("Skip epoch validators check, failed to get top validators", "number", header.Number, "err", err)
+		return nil
+	}
+	epochCheckMeter.Mark(1)
+
+	extra := parseExtraValidators(header)
+	mismatch := len(extra) != len(expected)
+	for i := 0; !mismatch && i < len(extra); i++ {
+		mismatch = extra[i] != expected[i]
+	}
+	if !mismatch {
+		return nil
+	}
+	epochMismatchMeter.Mark(1)
+	log.Error("Checkpoint validators mismatch the contract state", "number", header.Number, "hash", header.Hash(),
+		"extra", extra, "contract", expected, "mode", c.epochCheck)
+	if c.epochCheck == EpochCheckHalt {
+		return errMismatchingCheckpointValidators
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package congress
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DecodedExtra is the content of a header's extra-data section along with the
+// result of checking it against the extra-data rules.
+type DecodedExtra struct {
+	Number     hexutil.Uint64   `json:"number"`
+	Hash       common.Hash      `json:"hash"`
+	Checkpoint bool             `json:"checkpoint"`
+	Vanity     hexutil.Bytes    `json:"vanity"`
+	Validators []common.Address `json:"validators"` // Validators embedded on checkpoints, nil otherwise
+	Signature  hexutil.Bytes    `json:"signature"`
+	Signer     *common.Address  `json:"signer"` // Recovered signer, nil if the signature is invalid
+	Valid      bool             `json:"valid"`
+	Error      string           `json:"error,omitempty"` // First rule violated, if any
+}
+
+// verifyExtra checks that the extra-data contains the vanity, the validators on
+// checkpoints only, and the signature.
+func (c *Congress) verifyExtra(header *types.Header) error {
+	if len(header.Extra) < extraVanity {
+		return errMissingVanity
+	}
+	if len(header.Extra) < extraVanity+extraSeal {
+		return errMissingSignature
+	}
+	isEpoch := header.Number.Uint64()%c.config.Epoch == 0
+
+	// Ensure that the extra-data contains a validator list on checkpoint, but none otherwise
+	validatorsBytes := len(header.Extra) - extraVanity - extraSeal
+	if !isEpoch && validatorsBytes != 0 {
+		return errExtraValidators
+	}
+	// Ensure that the validator bytes length is valid
+	if isEpoch && validatorsBytes%common.AddressLength != 0 {
+		return errExtraValidators
+	}
+	return nil
+}
+
+// decodeExtra decodes the extra-data of the header and checks it against all the
+// extra-data rules: the layout, the signer being an authorized validator and, on
+// checkpoints, the validators matching the ones of the contract state if the
+// parent state is available.
+func (c *Congress) decodeExtra(chain consensus.ChainHeaderReader, header *types.Header) *DecodedExtra {
+	decoded := &DecodedExtra{
+		Number:     hexutil.Uint64(header.Number.Uint64()),
+		Hash:       header.Hash(),
+		Checkpoint: header.Number.Uint64()%c.config.Epoch == 0,
+	}
+	extra := header.Extra
+	if len(extra) >= extraVanity {
+		decoded.Vanity = common.CopyBytes(extra[:extraVanity])
+	} else {
+		decoded.Vanity = common.CopyBytes(extra)
+	}
+	if len(extra) >= extraVanity+extraSeal {
+		decoded.Signature = common.CopyBytes(extra[len(extra)-extraSeal:])
+		if decoded.Checkpoint {
+			decoded.Validators = parseExtraValidators(header)
+		}
+	}
+	if err := c.checkExtra(chain, header, decoded); err != nil {
+		decoded.Error = err.Error()
+	} else {
+		decoded.Valid = true
+	}
+	return decoded
+}
+
+// checkExtra runs the extra-data rules on the header, filling the recovered signer.
+func (c *Congress) checkExtra(chain consensus.ChainHeaderReader, header *types.Header, decoded *DecodedExtra) error {
+	if err := c.verifyExtra(header); err != nil {
+		return err
+	}
+	signer, err := c.recoverSigner(header)
+	if err != nil {
+		return err
+	}
+	decoded.Signer = &signer
+
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		return err
+	}
+	if _, ok := snap.Validators[signer]; !ok {
+		return errUnauthorizedValidator
+	}
+	if !decoded.Checkpoint {
+		return nil
+	}
+	statedb, err := c.chain.StateAt(parent.Root)
+	if err != nil {
+		// The contract state is pruned or there's none on light clients, the
+		// checkpoint validators can't be checked
+		return nil
+	}
+	expected, err := c.getTopValidatorsAt(chain, types.CopyHeader(header), parent, statedb)
+	if err != nil {
+		return err
+	}
+	if len(expected) != len(decoded.Validators) {
+		return errInvalidCheckpointValidators
+	}
+	for i := range expected {
+		if expected[i] != decoded.Validators[i] {
+			return errInvalidCheckpointValidators
+		}
+	}
+	return nil
+}
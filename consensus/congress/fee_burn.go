@@ -0,0 +1,57 @@
+package congress
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// feeBurnAction is the system governance proposal action setting the per mille of
+// the transaction fees burnt, its data being the big endian ratio.
+const feeBurnAction = 3
+
+var (
+	// The fee burn ratio set by the system governance is kept in the storage of the
+	// system governance contract, offset by one so that the zero value means unset.
+	feeBurnRatioSlot = crypto.Keccak256Hash([]byte("congress.feeBurn.ratio"))
+
+	// errInvalidFeeBurnRatio is returned by a fee burn proposal with data other than
+	// a ratio up to the denominator.
+	errInvalidFeeBurnRatio = errors.New("invalid fee burn ratio proposal data")
+)
+
+// FeeBurnRatio returns the per mille of the transaction fees burnt at the given
+// height: the one set by the system governance if any, the chain config one
+// otherwise.
+func (c *Congress) FeeBurnRatio(state consensus.StateReader, height *big.Int) uint64 {
+	if !c.config.IsFeeBurn(height) {
+		return 0
+	}
+	if value := state.GetState(systemcontract.SysGovContractAddr, feeBurnRatioSlot); value != (common.Hash{}) {
+		return value.Big().Uint64() - 1
+	}
+	return c.config.FeeBurnRatioAt(height)
+}
+
+// applyFeeBurnProposal executes a system governance proposal of the fee burn action
+// at the given block, the ratio applying from the next block.
+func (c *Congress) applyFeeBurnProposal(number *big.Int, state *state.StateDB, prop *Proposal) error {
+	if !c.config.IsFeeBurn(number) {
+		return errUnsupportedAction
+	}
+	if len(prop.Data) == 0 || len(prop.Data) > common.HashLength {
+		return errInvalidFeeBurnRatio
+	}
+	ratio := new(big.Int).SetBytes(prop.Data)
+	if ratio.Cmp(big.NewInt(params.CongressFeeBurnDenominator)) > 0 {
+		return errInvalidFeeBurnRatio
+	}
+	state.SetState(systemcontract.SysGovContractAddr, feeBurnRatioSlot, common.BigToHash(ratio.Add(ratio, common.Big1)))
+	return nil
+}
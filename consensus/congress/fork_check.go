@@ -0,0 +1,123 @@
+package congress
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// forkBehavior is a row of the fork behavior matrix: a fork gated behavior of the
+// engine and the first block expected to have it.
+type forkBehavior struct {
+	name   string
+	fork   uint64
+	active func(number *big.Int) bool
+}
+
+// sophonBehaviors returns the behaviors switched by the Sophon fork at the given
+// block.
+func (c *Congress) sophonBehaviors(sophon uint64) []forkBehavior {
+	probe := &Proposal{Value: common.Big1}
+
+	return []forkBehavior{
+		{
+			name: "system contract upgrade",
+			fork: sophon,
+			active: func(number *big.Int) bool {
+				return systemcontract.ActiveVersion(c.chainConfig, number) >= systemcontract.SysContractV2
+			},
+		},
+		{
+			name:   "zero value proposal transactions",
+			fork:   sophon,
+			active: func(number *big.Int) bool { return c.proposalValue(number, probe).Sign() == 0 },
+		},
+		{
+			name:   "blacklist and event rule checks",
+			fork:   sophon + 1,
+			active: c.blacklistActive,
+		},
+	}
+}
+
+// checkForkMatrix verifies that the fork gated behaviors, under the configuration
+// in use, are off right before and on from their fork block in the stored chain
+// configuration the chain was synced with, for the forks the head reached.
+func (c *Congress) checkForkMatrix(stored *params.ChainConfig, head *big.Int) error {
+	if stored == nil || stored.SophonBlock == nil {
+		return nil
+	}
+	for _, b := range c.sophonBehaviors(stored.SophonBlock.Uint64()) {
+		pre, post := new(big.Int).SetUint64(b.fork-1), new(big.Int).SetUint64(b.fork)
+		if post.Cmp(head) > 0 {
+			continue
+		}
+		if b.active(pre) || !b.active(post) {
+			return fmt.Errorf("fork self-test failed: %s not switching at block %d (stored Sophon at %v, configured at %v, pre %v, post %v)",
+				b.name, b.fork, stored.SophonBlock, c.chainConfig.SophonBlock, b.active(pre), b.active(post))
+		}
+	}
+	return nil
+}
+
+// checkForkVersion fails if the system contracts in the state of the head were
+// upgraded by a fork the configured schedule doesn't have passed yet, i.e. the
+// chain went through a fork this release or its configuration lacks.
+func (c *Congress) checkForkVersion(head *types.Header, state systemcontract.CodeHashReader) error {
+	have, want := systemcontract.StateVersion(state), systemcontract.ActiveVersion(c.chainConfig, head.Number)
+	if have > want {
+		return fmt.Errorf("chain head %v runs the v%d system contracts, upgraded by a fork missing from the configuration of this release (%s, Sophon at %v), upgrade the release or fix the fork overrides",
+			head.Number, have, params.VersionWithMeta, c.chainConfig.SophonBlock)
+	}
+	return nil
+}
+
+// CheckForks is the startup self-test of the fork gated behaviors against the
+// fork schedule of the chain configuration stored before the startup and the
+// state of the given head. It fails with a clear message if the chain is past a
+// fork the release doesn't support, instead of the next blocks being rejected as
+// bad ones.
+func (c *Congress) CheckForks(head *types.Header, stored *params.ChainConfig) error {
+	if err := c.checkForkMatrix(stored, head.Number); err != nil {
+		return err
+	}
+	statedb, err := c.chain.StateAt(head.Root)
+	if err != nil {
+		log.Debug("Skip fork state self-test, state missing", "number", head.Number, "err", err)
+		return nil
+	}
+	return c.checkForkState(head, statedb)
+}
+
+// checkForkState runs the fork gated lookups of the block following the head on
+// the head's state.
+func (c *Congress) checkForkState(head *types.Header, statedb *state.StateDB) error {
+	if err := c.checkForkVersion(head, statedb); err != nil {
+		return err
+	}
+	next := &types.Header{
+		ParentHash: head.Hash(),
+		Number:     new(big.Int).Add(head.Number, common.Big1),
+		Coinbase:   head.Coinbase,
+		Difficulty: new(big.Int),
+		GasLimit:   head.GasLimit,
+		Time:       head.Time,
+	}
+	if c.blacklistActive(next.Number) {
+		if _, err := c.getBlacklist(next, statedb); err != nil {
+			return fmt.Errorf("fork self-test failed: blacklist lookup of block %v: %v", next.Number, err)
+		}
+		if _, err := c.getEventCheckRules(next, statedb); err != nil {
+			return fmt.Errorf("fork self-test failed: event rule lookup of block %v: %v", next.Number, err)
+		}
+	}
+	log.Info("Fork self-test passed", "head", head.Number, "sophon", c.chainConfig.SophonBlock,
+		"contracts", systemcontract.StateVersion(statedb), "blacklist", c.blacklistActive(next.Number))
+	return nil
+}
@@ -0,0 +1,80 @@
+package congress
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type forkCodeHashes map[common.Address]common.Hash
+
+func (c forkCodeHashes) GetCodeHash(addr common.Address) common.Hash {
+	return c[addr]
+}
+
+func TestForkSelfTest(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(1), RedCoastBlock: big.NewInt(10), SophonBlock: big.NewInt(20), Congress: &params.CongressConfig{Period: 3, Epoch: 200}}
+	engine := New(config, rawdb.NewMemoryDatabase(), new(testChainBackend))
+
+	for _, b := range engine.sophonBehaviors(20) {
+		if b.active(big.NewInt(19)) || !b.active(big.NewInt(21)) {
+			t.Errorf("%s: pre/post Sophon behavior mismatch", b.name)
+		}
+	}
+	// The behaviors must switch at the forks of the stored config the head reached
+	moved := *config
+	moved.SophonBlock = big.NewInt(15)
+
+	matrix := []struct {
+		stored *params.ChainConfig
+		head   int64
+		fail   bool
+	}{
+		{nil, 25, false},
+		{&params.ChainConfig{ChainID: big.NewInt(1)}, 25, false},
+		{config, 25, false},
+		{&moved, 10, false}, // Rescheduling a fork ahead of the head is fine
+		{&moved, 15, true},
+		{&moved, 25, true},
+	}
+	for i, tt := range matrix {
+		err := engine.checkForkMatrix(tt.stored, big.NewInt(tt.head))
+		if (err != nil) != tt.fail {
+			t.Errorf("matrix %d: failure mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+	}
+	// The system contracts of the state must not be ahead of the schedule
+	var (
+		v1 = forkCodeHashes(systemcontract.ExpectedCodeHashes(config, big.NewInt(10)))
+		v2 = forkCodeHashes(systemcontract.ExpectedCodeHashes(config, big.NewInt(20)))
+	)
+	tests := []struct {
+		number int64
+		state  forkCodeHashes
+		fail   bool
+	}{
+		{5, forkCodeHashes{}, false},
+		{5, v1, true},
+		{15, v1, false},
+		{15, v2, true},
+		{25, v1, false}, // Behind the schedule is left to the system contract check
+		{25, v2, false},
+	}
+	for i, tt := range tests {
+		err := engine.checkForkVersion(&types.Header{Number: big.NewInt(tt.number)}, tt.state)
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: failure mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+	}
+	// A release dropping a fork the chain passed must not start
+	dropped := *config
+	dropped.SophonBlock = nil
+	if err := New(&dropped, rawdb.NewMemoryDatabase(), new(testChainBackend)).checkForkMatrix(config, big.NewInt(25)); err == nil {
+		t.Errorf("fork matrix without the passed Sophon accepted")
+	}
+}
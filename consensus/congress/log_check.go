@@ -0,0 +1,99 @@
+package congress
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// EventLogArgs is the hypothetical event log to check against the event check
+// rules and the blacklist.
+type EventLogArgs struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+// LogRuleCheck is the outcome of an event log checked against the event check
+// rules and the blacklist in force at a block.
+type LogRuleCheck struct {
+	Number hexutil.Uint64 `json:"number"` // Block the log is checked as emitted in
+	Denied bool           `json:"denied"`
+	Rule   *common.Hash   `json:"rule"` // Event signature of the rule applying to the log, nil if none
+
+	// The topic holding the denied address and why it's denied, set if the log
+	// is denied only.
+	Topic     *hexutil.Uint64 `json:"topic,omitempty"`
+	Address   *common.Address `json:"address,omitempty"`
+	CheckType string          `json:"checkType,omitempty"` // Check of the rule for the topic: from, to or any
+	Direction string          `json:"direction,omitempty"` // Direction the address is blacklisted in
+}
+
+// checkTypeName returns the name of an address check of the event check rules.
+func checkTypeName(ct common.AddressCheckType) string {
+	switch ct {
+	case common.CheckNone:
+		return "none"
+	case common.CheckFrom:
+		return "from"
+	case common.CheckTo:
+		return "to"
+	case common.CheckBothInAny:
+		return "any"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(ct))
+	}
+}
+
+// checkLogAgainstRules checks the event log as if it was emitted in the block
+// following the header, against the event check rules and the blacklist in force
+// there, read from the header's state.
+func (c *Congress) checkLogAgainstRules(header *types.Header, statedb *state.StateDB, args *EventLogArgs) (*LogRuleCheck, error) {
+	next := &types.Header{
+		ParentHash: header.Hash(),
+		Number:     new(big.Int).Add(header.Number, common.Big1),
+		Coinbase:   header.Coinbase,
+		Difficulty: new(big.Int),
+		GasLimit:   header.GasLimit,
+		Time:       header.Time,
+	}
+	check := &LogRuleCheck{Number: hexutil.Uint64(next.Number.Uint64())}
+
+	// The logs are checked from the block after the Sophon fork only
+	if !c.chainConfig.IsSophon(header.Number) {
+		return check, nil
+	}
+	blacks, err := c.getBlacklist(next, statedb)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := c.getEventCheckRules(next, statedb)
+	if err != nil {
+		return nil, err
+	}
+	evLog := &types.Log{Address: args.Address, Topics: args.Topics, Data: args.Data}
+	if len(evLog.Topics) > 0 {
+		if rule, ok := rules[evLog.Topics[0]]; ok {
+			check.Rule = &rule.EventSig
+		}
+	}
+	validator := &blacklistValidator{blacks: blacks, rules: rules}
+	idx, checkType, denied := validator.deniedTopic(evLog)
+	if !denied {
+		return check, nil
+	}
+	var (
+		topic = hexutil.Uint64(idx)
+		addr  = common.BytesToAddress(evLog.Topics[idx].Bytes())
+	)
+	check.Denied = true
+	check.Topic = &topic
+	check.Address = &addr
+	check.CheckType = checkTypeName(checkType)
+	check.Direction = blacks[addr].String()
+	return check, nil
+}
@@ -0,0 +1,75 @@
+package congress
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// preconfirmPrefix domain separates the preconfirmation messages from any other
+// data signed by the validator keys.
+var preconfirmPrefix = []byte("\x19HECO Preconfirmation:\n")
+
+// errNotAuthorized is returned if a preconfirmation is requested from a node
+// without validator credentials.
+var errNotAuthorized = errors.New("no validator credentials")
+
+// PreconfirmMessage returns the message a validator signs to commit to including
+// the transaction in the block of the given number on top of the given parent.
+func PreconfirmMessage(chainID *big.Int, number uint64, parent common.Hash, tx common.Hash) []byte {
+	var enc [8]byte
+	binary.BigEndian.PutUint64(enc[:], number)
+
+	msg := make([]byte, 0, len(preconfirmPrefix)+32+8+2*common.HashLength)
+	msg = append(msg, preconfirmPrefix...)
+	msg = append(msg, common.BigToHash(chainID).Bytes()...)
+	msg = append(msg, enc[:]...)
+	msg = append(msg, parent.Bytes()...)
+	return append(msg, tx.Bytes()...)
+}
+
+// SignPreconfirmation signs a preconfirmation message with the local validator
+// key, returning the validator address along with the signature.
+func (c *Congress) SignPreconfirmation(msg []byte) (common.Address, []byte, error) {
+	return c.signMessage(accounts.MimetypePreconfirm, msg)
+}
+
+// signMessage signs a domain separated message of the given mimetype with the
+// local validator key.
+func (c *Congress) signMessage(mimeType string, msg []byte) (common.Address, []byte, error) {
+	c.lock.RLock()
+	val, signFn := c.validator, c.signFn
+	c.lock.RUnlock()
+
+	if signFn == nil || val == (common.Address{}) {
+		return common.Address{}, nil, errNotAuthorized
+	}
+	sig, err := signFn(accounts.Account{Address: val}, mimeType, msg)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return val, sig, nil
+}
+
+// InTurn reports whether the header is prepared by the local validator in its turn.
+func (c *Congress) InTurn(header *types.Header) bool {
+	c.lock.RLock()
+	val := c.validator
+	c.lock.RUnlock()
+
+	return val != (common.Address{}) && header.Coinbase == val && header.Difficulty != nil && header.Difficulty.Cmp(diffInTurn) == 0
+}
+
+// RecoverPreconfirmation returns the validator that signed a preconfirmation.
+func RecoverPreconfirmation(msg []byte, sig []byte) (common.Address, error) {
+	pubkey, err := crypto.SigToPub(crypto.Keccak256(msg), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
@@ -0,0 +1,156 @@
+package congress
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress/vmcaller"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// errUnsupportedAction is returned by a proposal with an action other than an
+	// evm call, a code erase or, since its fork, a punish breaker one.
+	errUnsupportedAction = errors.New("unsupported action")
+)
+
+// ProposalArgs is the system governance proposal to simulate.
+type ProposalArgs struct {
+	Action *hexutil.Big   `json:"action"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Value  *hexutil.Big   `json:"value"`
+	Data   hexutil.Bytes  `json:"data"`
+}
+
+// toProposal converts the arguments to a proposal, the missing numbers being zero.
+func (args *ProposalArgs) toProposal() *Proposal {
+	prop := &Proposal{
+		Id:     new(big.Int),
+		Action: new(big.Int),
+		From:   args.From,
+		To:     args.To,
+		Value:  new(big.Int),
+		Data:   args.Data,
+	}
+	if args.Action != nil {
+		prop.Action.Set(args.Action.ToInt())
+	}
+	if args.Value != nil {
+		prop.Value.Set(args.Value.ToInt())
+	}
+	return prop
+}
+
+// AccountState is the state of an account touched by a simulated proposal.
+type AccountState struct {
+	Balance  *hexutil.Big                `json:"balance"`
+	Nonce    hexutil.Uint64              `json:"nonce"`
+	CodeHash common.Hash                 `json:"codeHash"`
+	Storage  map[common.Hash]common.Hash `json:"storage,omitempty"` // Modified slots only
+}
+
+// AccountDiff is the change of an account made by a simulated proposal, the
+// states being nil if the account doesn't exist.
+type AccountDiff struct {
+	Before *AccountState `json:"before"`
+	After  *AccountState `json:"after"`
+}
+
+// ProposalSimulation is the outcome of a system governance proposal executed the
+// way Finalize would at the next block.
+type ProposalSimulation struct {
+	Success    bool                            `json:"success"`
+	Error      string                          `json:"error,omitempty"`
+	GasUsed    hexutil.Uint64                  `json:"gasUsed"`
+	ReturnData hexutil.Bytes                   `json:"returnData"`
+	Logs       []*types.Log                    `json:"logs"`
+	StateDiff  map[common.Address]*AccountDiff `json:"stateDiff"`
+}
+
+// simulateProposal executes the proposal on top of the given block and state, the
+// state being modified.
+func (c *Congress) simulateProposal(chain consensus.ChainHeaderReader, parent *types.Header, statedb *state.StateDB, prop *Proposal) *ProposalSimulation {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		Coinbase:   parent.Coinbase,
+		Difficulty: new(big.Int),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + c.config.Period,
+	}
+	if c.chainConfig.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(c.chainConfig, parent)
+	}
+	pre := statedb.Copy()
+
+	var (
+		ret  []byte
+		used uint64
+		err  error
+	)
+	statedb.Prepare(common.Hash{}, 0)
+	switch prop.Action.Uint64() {
+	case 0:
+		msg := vmcaller.NewLegacyMessage(prop.From, &prop.To, 0, prop.Value, header.GasLimit, new(big.Int), prop.Data, false)
+		ret, used, err = vmcaller.ExecuteMsgWithGas(msg, statedb, header, newChainContext(chain, c), c.chainConfig)
+	case 1:
+		if !statedb.Erase(prop.To) {
+			err = errors.New("account not found")
+		}
+		statedb.Finalise(true)
+	case punishBreakerAction:
+		err = c.applyPunishBreakerProposal(header.Number, statedb, prop)
+		statedb.Finalise(true)
+	case feeBurnAction:
+		err = c.applyFeeBurnProposal(header.Number, statedb, prop)
+		statedb.Finalise(true)
+	default:
+		err = errUnsupportedAction
+	}
+	result := &ProposalSimulation{
+		Success:    err == nil,
+		GasUsed:    hexutil.Uint64(used),
+		ReturnData: ret,
+		Logs:       statedb.GetLogs(common.Hash{}, common.Hash{}),
+		StateDiff:  make(map[common.Address]*AccountDiff),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if result.Logs == nil {
+		result.Logs = []*types.Log{}
+	}
+	for addr, slots := range statedb.PendingChanges() {
+		result.StateDiff[addr] = &AccountDiff{
+			Before: accountState(pre, addr, slots),
+			After:  accountState(statedb, addr, slots),
+		}
+	}
+	return result
+}
+
+// accountState returns the state of the account with the given slots, nil if the
+// account doesn't exist.
+func accountState(statedb *state.StateDB, addr common.Address, slots []common.Hash) *AccountState {
+	if !statedb.Exist(addr) {
+		return nil
+	}
+	account := &AccountState{
+		Balance:  (*hexutil.Big)(statedb.GetBalance(addr)),
+		Nonce:    hexutil.Uint64(statedb.GetNonce(addr)),
+		CodeHash: statedb.GetCodeHash(addr),
+	}
+	if len(slots) > 0 {
+		account.Storage = make(map[common.Hash]common.Hash, len(slots))
+		for _, slot := range slots {
+			account.Storage[slot] = statedb.GetState(addr, slot)
+		}
+	}
+	return account
+}
@@ -0,0 +1,90 @@
+package congress
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// punishBreakerAction is the system governance proposal action arming the punish
+// circuit breaker if its data is 0x01, and disarming it if its data is 0x00.
+const punishBreakerAction = 2
+
+var (
+	// The punish breaker state is kept in the storage of the system governance
+	// contract, at hashed slots which can't collide with the contract's own ones,
+	// so every node takes the same skip decisions from the state of the block.
+	punishBreakerArmedSlot   = crypto.Keccak256Hash([]byte("congress.punishBreaker.armed"))
+	punishBreakerSkippedSlot = crypto.Keccak256Hash([]byte("congress.punishBreaker.skipped"))
+
+	// errInvalidPunishBreaker is returned by a punish breaker proposal with data
+	// other than 0x00 or 0x01.
+	errInvalidPunishBreaker = errors.New("invalid punish breaker proposal data")
+)
+
+var (
+	punishFailedMeter  = metrics.NewRegisteredMeter("congress/punish/failed", nil)
+	punishSkippedMeter = metrics.NewRegisteredMeter("congress/punish/skipped", nil)
+)
+
+// punishBreakerArmed reports whether the system governance armed the punish
+// breaker in the given state.
+func punishBreakerArmed(state *state.StateDB) bool {
+	return state.GetState(systemcontract.SysGovContractAddr, punishBreakerArmedSlot) != (common.Hash{})
+}
+
+// punishSkipped returns the number of consecutive punish failures skipped by the
+// breaker in the given state.
+func punishSkipped(state *state.StateDB) uint64 {
+	return state.GetState(systemcontract.SysGovContractAddr, punishBreakerSkippedSlot).Big().Uint64()
+}
+
+func setPunishSkipped(state *state.StateDB, skipped uint64) {
+	state.SetState(systemcontract.SysGovContractAddr, punishBreakerSkippedSlot, common.BigToHash(new(big.Int).SetUint64(skipped)))
+}
+
+// applyPunishBreakerProposal executes a system governance proposal of the punish
+// breaker action at the given block.
+func (c *Congress) applyPunishBreakerProposal(number *big.Int, state *state.StateDB, prop *Proposal) error {
+	if !c.config.IsPunishBreaker(number) {
+		return errUnsupportedAction
+	}
+	if len(prop.Data) != 1 || prop.Data[0] > 1 {
+		return errInvalidPunishBreaker
+	}
+	state.SetState(systemcontract.SysGovContractAddr, punishBreakerArmedSlot, common.BytesToHash(prop.Data))
+	return nil
+}
+
+// punishOrSkip punishes the validator, turning the failure into a logged skip if
+// the system governance armed the punish breaker. The skips are counted in state
+// until the next successful punishment.
+func (c *Congress) punishOrSkip(validator common.Address, chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) error {
+	breaker := c.config.IsPunishBreaker(header.Number) && punishBreakerArmed(state)
+
+	err := c.punishWithHooks(validator, chain, header, state)
+	if err == nil {
+		if breaker && punishSkipped(state) != 0 {
+			setPunishSkipped(state, 0)
+		}
+		return nil
+	}
+	punishFailedMeter.Mark(1)
+	if !breaker {
+		return err
+	}
+	skipped := punishSkipped(state) + 1
+	setPunishSkipped(state, skipped)
+
+	punishSkippedMeter.Mark(1)
+	log.Error("Punish contract call failed, skipped by the circuit breaker", "number", header.Number, "validator", validator, "skipped", skipped, "err", err)
+	return nil
+}
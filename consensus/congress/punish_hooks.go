@@ -0,0 +1,239 @@
+package congress
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/consensus/congress/vmcaller"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	punishWebhookQueue   = 64              // Number of punish events waiting to be posted
+	punishWebhookTimeout = 5 * time.Second // Default timeout of a webhook post
+	punishWebhookDedup   = 1024            // Number of recently posted events to drop duplicates of
+)
+
+var (
+	punishWebhookSentMeter    = metrics.NewRegisteredMeter("congress/punish/webhook/sent", nil)
+	punishWebhookFailedMeter  = metrics.NewRegisteredMeter("congress/punish/webhook/failed", nil)
+	punishWebhookDroppedMeter = metrics.NewRegisteredMeter("congress/punish/webhook/dropped", nil)
+)
+
+// PunishEvent is the context of a validator punishment passed to the punish hooks.
+type PunishEvent struct {
+	Validator  common.Address `json:"validator"`
+	Number     uint64         `json:"number"`
+	ParentHash common.Hash    `json:"parentHash"`
+	Missed     uint64         `json:"missed"` // Missed blocks counter of the validator, as of the hook invocation
+}
+
+// PunishHook is invoked before and after a validator is punished. The hooks run
+// synchronously within the block processing, so they must return promptly. Note
+// they are invoked whenever a block is processed, including both the sealing and
+// the import of the same block by a validator, the chain sync and reorgs.
+type PunishHook interface {
+	// PrePunish is invoked before the punish contract call.
+	PrePunish(ev PunishEvent)
+
+	// PostPunish is invoked after the punish contract call with its error.
+	PostPunish(ev PunishEvent, err error)
+}
+
+// AddPunishHook registers a hook invoked around the validator punishments.
+func (c *Congress) AddPunishHook(hook PunishHook) {
+	c.hookLock.Lock()
+	defer c.hookLock.Unlock()
+
+	c.punishHooks = append(c.punishHooks, hook)
+}
+
+// punishWithHooks punishes the validator, invoking the registered punish hooks
+// around the contract call.
+func (c *Congress) punishWithHooks(val common.Address, chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) error {
+	c.hookLock.RLock()
+	hooks := c.punishHooks
+	c.hookLock.RUnlock()
+
+	if len(hooks) == 0 {
+		return c.punishValidator(val, chain, header, state)
+	}
+	ev := PunishEvent{
+		Validator:  val,
+		Number:     header.Number.Uint64(),
+		ParentHash: header.ParentHash,
+		Missed:     c.missedBlocks(val, chain, header, state),
+	}
+	for _, hook := range hooks {
+		hook.PrePunish(ev)
+	}
+	err := c.punishValidator(val, chain, header, state)
+	if err == nil {
+		ev.Missed = c.missedBlocks(val, chain, header, state)
+	}
+	for _, hook := range hooks {
+		hook.PostPunish(ev, err)
+	}
+	return err
+}
+
+// missedBlocks reads the missed blocks counter of the validator from the punish
+// contract, on a copy of the state so the block processing is left intact.
+func (c *Congress) missedBlocks(val common.Address, chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) uint64 {
+	method := "getPunishRecord"
+	data, err := c.abi[systemcontract.PunishContractName].Pack(method, val)
+	if err != nil {
+		log.Error("Can't pack data for getPunishRecord", "error", err)
+		return 0
+	}
+	msg := vmcaller.NewLegacyMessage(header.Coinbase, systemcontract.GetPunishAddr(header.Number, c.chainConfig), 0, new(big.Int), c.systemCallGas(header), new(big.Int), data, false)
+	result, err := vmcaller.ExecuteMsg(msg, state.Copy(), header, newChainContext(chain, c), c.chainConfig)
+	if err != nil {
+		return 0
+	}
+	ret, err := c.abi[systemcontract.PunishContractName].Unpack(method, result)
+	if err != nil || len(ret) != 1 {
+		return 0
+	}
+	missed, ok := ret[0].(*big.Int)
+	if !ok || !missed.IsUint64() {
+		return 0
+	}
+	return missed.Uint64()
+}
+
+// PunishWebhookConfig is the configuration of the built-in punish webhook notifier.
+type PunishWebhookConfig struct {
+	URL     string        // Endpoint the punish events are posted to as JSON
+	Timeout time.Duration `toml:",omitempty"` // Timeout of a single post, 5s if zero
+	PreHook bool          `toml:",omitempty"` // Post the events before the punishments as well
+}
+
+// punishWebhookMessage is the JSON body posted to the webhook.
+type punishWebhookMessage struct {
+	Stage string `json:"stage"` // "pre" or "post"
+	PunishEvent
+	Error string `json:"error,omitempty"`
+}
+
+// PunishWebhook is a punish hook posting the events to a webhook endpoint in the
+// background, dropping the events if the endpoint can't keep up.
+type PunishWebhook struct {
+	config PunishWebhookConfig
+	client *http.Client
+
+	recents *lru.Cache // Recently queued events, to drop the duplicates of reprocessed blocks
+	queue   chan punishWebhookMessage
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewPunishWebhook creates a webhook notifier and starts posting the events.
+func NewPunishWebhook(config PunishWebhookConfig) (*PunishWebhook, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("punish webhook URL missing")
+	}
+	if config.Timeout == 0 {
+		config.Timeout = punishWebhookTimeout
+	}
+	recents, _ := lru.New(punishWebhookDedup)
+	w := &PunishWebhook{
+		config:  config,
+		client:  &http.Client{Timeout: config.Timeout},
+		recents: recents,
+		queue:   make(chan punishWebhookMessage, punishWebhookQueue),
+		quit:    make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.loop()
+	return w, nil
+}
+
+// PrePunish implements PunishHook, posting the event if configured so.
+func (w *PunishWebhook) PrePunish(ev PunishEvent) {
+	if w.config.PreHook {
+		w.enqueue(punishWebhookMessage{Stage: "pre", PunishEvent: ev})
+	}
+}
+
+// PostPunish implements PunishHook, posting the event with the punish result.
+func (w *PunishWebhook) PostPunish(ev PunishEvent, err error) {
+	msg := punishWebhookMessage{Stage: "post", PunishEvent: ev}
+	if err != nil {
+		msg.Error = err.Error()
+	}
+	w.enqueue(msg)
+}
+
+// enqueue schedules the message to be posted, unless it was already.
+func (w *PunishWebhook) enqueue(msg punishWebhookMessage) {
+	key := fmt.Sprintf("%s-%d-%x-%x", msg.Stage, msg.Number, msg.ParentHash, msg.Validator)
+	if ok, _ := w.recents.ContainsOrAdd(key, struct{}{}); ok {
+		return
+	}
+	select {
+	case w.queue <- msg:
+	default:
+		punishWebhookDroppedMeter.Mark(1)
+		log.Warn("Punish webhook queue full, event dropped", "number", msg.Number, "validator", msg.Validator)
+	}
+}
+
+// loop posts the queued messages until stopped.
+func (w *PunishWebhook) loop() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case msg := <-w.queue:
+			if err := w.post(msg); err != nil {
+				punishWebhookFailedMeter.Mark(1)
+				log.Warn("Failed to post punish event", "url", w.config.URL, "number", msg.Number, "validator", msg.Validator, "err", err)
+				continue
+			}
+			punishWebhookSentMeter.Mark(1)
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// post sends a single message to the webhook endpoint.
+func (w *PunishWebhook) post(msg punishWebhookMessage) error {
+	return postWebhook(w.client, w.config.URL, msg)
+}
+
+// postWebhook posts a message as JSON to a webhook endpoint.
+func postWebhook(client *http.Client, url string, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	res, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+// Close stops posting the events, dropping the ones still queued.
+func (w *PunishWebhook) Close() {
+	close(w.quit)
+	w.wg.Wait()
+}
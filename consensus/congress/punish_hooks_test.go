@@ -0,0 +1,85 @@
+package congress
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPunishWebhook(t *testing.T) {
+	received := make(chan punishWebhookMessage, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg punishWebhookMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("failed to decode punish event: %v", err)
+		}
+		received <- msg
+	}))
+	defer server.Close()
+
+	if _, err := NewPunishWebhook(PunishWebhookConfig{}); err == nil {
+		t.Fatalf("webhook without URL accepted")
+	}
+	webhook, err := NewPunishWebhook(PunishWebhookConfig{URL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	defer webhook.Close()
+
+	ev := PunishEvent{Validator: common.HexToAddress("0x01"), Number: 100, ParentHash: common.HexToHash("0x02"), Missed: 24}
+	webhook.PrePunish(ev)
+	webhook.PostPunish(ev, errors.New("reverted"))
+	// The same block reprocessed, e.g. imported after sealing, is posted once
+	webhook.PostPunish(ev, nil)
+
+	select {
+	case msg := <-received:
+		if msg.Stage != "post" || msg.PunishEvent != ev || msg.Error != "reverted" {
+			t.Fatalf("punish event mismatch: have %+v", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("punish event not posted")
+	}
+	select {
+	case msg := <-received:
+		t.Fatalf("unexpected punish event posted: %+v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPunishWebhookPreHook(t *testing.T) {
+	received := make(chan punishWebhookMessage, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg punishWebhookMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		received <- msg
+	}))
+	defer server.Close()
+
+	webhook, err := NewPunishWebhook(PunishWebhookConfig{URL: server.URL, PreHook: true})
+	if err != nil {
+		t.Fatalf("failed to create webhook: %v", err)
+	}
+	defer webhook.Close()
+
+	ev := PunishEvent{Validator: common.HexToAddress("0x01"), Number: 100, Missed: 23}
+	webhook.PrePunish(ev)
+	ev.Missed = 24
+	webhook.PostPunish(ev, nil)
+
+	for _, want := range []string{"pre", "post"} {
+		select {
+		case msg := <-received:
+			if msg.Stage != want || msg.Error != "" {
+				t.Fatalf("punish event mismatch: have %+v, want stage %s", msg, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s punish event not posted", want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package congress
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ValidatorReadiness is the standing of a validator in the validator set as of a
+// block, checked before the validator node starts sealing.
+type ValidatorReadiness struct {
+	Number     uint64 `json:"number"`
+	Staked     bool   `json:"staked"`     // Among the top validators of the validators contract
+	Active     bool   `json:"active"`     // In the validator set sealing the next blocks
+	Validators int    `json:"validators"` // Size of the active validator set
+}
+
+// CheckValidator reports the standing of the validator as of the given header,
+// querying the validators contract on the header's state, which is left untouched.
+func (c *Congress) CheckValidator(chain consensus.ChainHeaderReader, header *types.Header, statedb *state.StateDB, validator common.Address) (*ValidatorReadiness, error) {
+	ret, err := c.commonCallContract(header, statedb.Copy(), c.abi[systemcontract.ValidatorsContractName],
+		*systemcontract.GetValidatorAddr(header.Number, c.chainConfig), "getTopValidators", 1)
+	if err != nil {
+		return nil, err
+	}
+	top, ok := ret[0].([]common.Address)
+	if !ok {
+		return nil, errors.New("invalid validators format")
+	}
+	snap, err := c.snapshot(chain, header.Number.Uint64(), header.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	r := &ValidatorReadiness{Number: header.Number.Uint64(), Validators: len(snap.Validators)}
+	for _, val := range top {
+		if val == validator {
+			r.Staked = true
+			break
+		}
+	}
+	_, r.Active = snap.Validators[validator]
+	return r, nil
+}
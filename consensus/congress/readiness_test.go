@@ -0,0 +1,58 @@
+package congress
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that the genesis validator is reported active from the genesis, and staked
+// once the system contracts got initialized by the first block.
+func TestCheckValidator(t *testing.T) {
+	key := vectorKey("validator-0")
+	validator := crypto.PubkeyToAddress(key.PublicKey)
+
+	vc, err := newVectorChain(vectorGenesis([]common.Address{validator}, 30000), true)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer vc.chain.Stop()
+
+	block, err := vc.forge(vc.chain.Genesis(), validator, key)
+	if err != nil {
+		t.Fatalf("failed to forge block: %v", err)
+	}
+	if _, err := vc.chain.InsertChain([]*types.Block{block}); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	tests := []struct {
+		number    uint64
+		validator common.Address
+		want      ValidatorReadiness
+	}{
+		{0, validator, ValidatorReadiness{Number: 0, Active: true, Validators: 1}},
+		{1, validator, ValidatorReadiness{Number: 1, Staked: true, Active: true, Validators: 1}},
+		{1, common.Address{0x01}, ValidatorReadiness{Number: 1, Validators: 1}},
+	}
+	for i, tt := range tests {
+		header := vc.chain.GetHeaderByNumber(tt.number)
+		statedb, err := vc.chain.StateAt(header.Root)
+		if err != nil {
+			t.Fatalf("test %d: failed to get state: %v", i, err)
+		}
+		root := statedb.IntermediateRoot(false)
+
+		readiness, err := vc.engine.CheckValidator(vc.chain, header, statedb, tt.validator)
+		if err != nil {
+			t.Fatalf("test %d: failed to check validator: %v", i, err)
+		}
+		if *readiness != tt.want {
+			t.Errorf("test %d: readiness mismatch: have %+v, want %+v", i, *readiness, tt.want)
+		}
+		if statedb.IntermediateRoot(false) != root {
+			t.Errorf("test %d: state modified by the check", i)
+		}
+	}
+}
@@ -19,7 +19,9 @@ package congress
 import (
 	"bytes"
 	"encoding/json"
+	"runtime"
 	"sort"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
@@ -40,6 +42,10 @@ type Snapshot struct {
 	Recents    map[uint64]common.Address   `json:"recents"`    // Set of recent validators for spam protections
 }
 
+// minParallelRecover is the minimum number of headers to recover the signers of
+// concurrently, below that the goroutine overhead isn't worth it.
+const minParallelRecover = 16
+
 // validatorsAscending implements the sort interface to allow sorting a list of addresses
 type validatorsAscending []common.Address
 
@@ -126,30 +132,45 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 	if headers[0].Number.Uint64() != s.Number+1 {
 		return nil, errInvalidVotingChain
 	}
+	// Resolve the authorization keys of all the headers up front
+	signers, err := recoverSigners(headers, s.sigcache)
+	if err != nil {
+		return nil, err
+	}
 	// Iterate through the headers and create a new snapshot
 	snap := s.copy()
 
-	for _, header := range headers {
+	// Index the recent validators by address, so the spam check doesn't need to
+	// walk the whole recents list for every header
+	recents := make(map[common.Address]int, len(snap.Recents))
+	for _, validator := range snap.Recents {
+		recents[validator]++
+	}
+	forget := func(block uint64) {
+		if validator, ok := snap.Recents[block]; ok {
+			delete(snap.Recents, block)
+			if recents[validator]--; recents[validator] == 0 {
+				delete(recents, validator)
+			}
+		}
+	}
+	for i, header := range headers {
 		// Remove any votes on checkpoint blocks
 		number := header.Number.Uint64()
 		// Delete the oldest validator from the recent list to allow it signing again
 		if limit := uint64(len(snap.Validators)/2 + 1); number >= limit {
-			delete(snap.Recents, number-limit)
-		}
-		// Resolve the authorization key and check against validators
-		validator, err := ecrecover(header, s.sigcache)
-		if err != nil {
-			return nil, err
+			forget(number - limit)
 		}
+		// Check the authorization key against validators
+		validator := signers[i]
 		if _, ok := snap.Validators[validator]; !ok {
 			return nil, errUnauthorizedValidator
 		}
-		for _, recent := range snap.Recents {
-			if recent == validator {
-				return nil, errRecentlySigned
-			}
+		if recents[validator] > 0 {
+			return nil, errRecentlySigned
 		}
 		snap.Recents[number] = validator
+		recents[validator]++
 
 		// update validators at the first block at epoch
 		if number > 0 && number%s.config.Epoch == 0 {
@@ -170,7 +191,7 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 			// decreases.
 			limit := uint64(len(newValidators)/2 + 1)
 			for i := 0; i < len(snap.Validators)/2-len(newValidators)/2; i++ {
-				delete(snap.Recents, number-limit-uint64(i))
+				forget(number - limit - uint64(i))
 			}
 
 			snap.Validators = newValidators
@@ -183,6 +204,49 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 	return snap, nil
 }
 
+// recoverSigners recovers the signers of a batch of headers, spreading the work
+// across multiple goroutines for larger batches. The signers are returned in the
+// order of the headers, and the first error encountered (in header order) is
+// returned if any signature fails to recover.
+func recoverSigners(headers []*types.Header, sigcache *lru.ARCCache) ([]common.Address, error) {
+	var (
+		signers = make([]common.Address, len(headers))
+		errs    = make([]error, len(headers))
+		workers = runtime.GOMAXPROCS(0)
+	)
+	if len(headers) < minParallelRecover || workers < 2 {
+		for i, header := range headers {
+			signer, err := ecrecover(header, sigcache)
+			if err != nil {
+				return nil, err
+			}
+			signers[i] = signer
+		}
+		return signers, nil
+	}
+	if workers > len(headers)/minParallelRecover {
+		workers = len(headers) / minParallelRecover
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(headers); i += workers {
+				signers[i], errs[i] = ecrecover(headers[i], sigcache)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return signers, nil
+}
+
 // validators retrieves the list of authorized validators in ascending order.
 func (s *Snapshot) validators() []common.Address {
 	sigs := make([]common.Address, 0, len(s.Validators))
@@ -193,6 +257,20 @@ func (s *Snapshot) validators() []common.Address {
 	return sigs
 }
 
+// punishTarget returns the in-turn validator of the block at the given height,
+// punished if the block is sealed out of turn, false if it's spared as it signed
+// recently.
+func (s *Snapshot) punishTarget(number uint64) (common.Address, bool) {
+	validators := s.validators()
+	outTurnValidator := validators[number%uint64(len(validators))]
+	for _, recent := range s.Recents {
+		if recent == outTurnValidator {
+			return outTurnValidator, false
+		}
+	}
+	return outTurnValidator, true
+}
+
 // inturn returns if a validator at a given block height is in-turn or not.
 func (s *Snapshot) inturn(number uint64, validator common.Address) bool {
 	validators, offset := s.validators(), 0
@@ -0,0 +1,127 @@
+package congress
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// newTestChain creates a snapshot of the given number of validators at genesis,
+// and a chain of headers sealed by the validators in turn.
+func newTestChain(t testing.TB, validators int, blocks int) (*Snapshot, []*types.Header, []*ecdsa.PrivateKey) {
+	var (
+		keys  = make([]*ecdsa.PrivateKey, validators)
+		addrs = make([]common.Address, validators)
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	sigcache, _ := lru.NewARC(inmemorySignatures)
+	snap := newSnapshot(&params.CongressConfig{Period: 3, Epoch: 200}, sigcache, 0, common.Hash{}, addrs)
+
+	headers := make([]*types.Header, blocks)
+	for i := range headers {
+		number := uint64(i + 1)
+		header := &types.Header{
+			Number:     new(big.Int).SetUint64(number),
+			Difficulty: diffNoTurn,
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+		if number%snap.config.Epoch == 0 {
+			vals := make([]byte, 0, validators*common.AddressLength)
+			for _, addr := range snap.validators() {
+				vals = append(vals, addr.Bytes()...)
+			}
+			header.Extra = append(append(header.Extra[:extraVanity], vals...), make([]byte, extraSeal)...)
+		}
+		if i > 0 {
+			header.ParentHash = headers[i-1].Hash()
+		}
+		sealTestHeader(t, header, keys[i%validators])
+		headers[i] = header
+	}
+	return snap, headers, keys
+}
+
+// sealTestHeader signs the header with the given key.
+func sealTestHeader(t testing.TB, header *types.Header, key *ecdsa.PrivateKey) {
+	sig, err := crypto.Sign(SealHash(header).Bytes(), key)
+	if err != nil {
+		t.Fatalf("failed to sign header: %v", err)
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+}
+
+// Tests that the signers recovered concurrently match the sequential ones.
+func TestRecoverSigners(t *testing.T) {
+	_, headers, _ := newTestChain(t, 21, 100)
+
+	parallel, err := recoverSigners(headers, mustNewARC(t))
+	if err != nil {
+		t.Fatalf("failed to recover signers: %v", err)
+	}
+	for i, header := range headers {
+		signer, err := ecrecover(header, mustNewARC(t))
+		if err != nil {
+			t.Fatalf("failed to recover signer %d: %v", i, err)
+		}
+		if parallel[i] != signer {
+			t.Errorf("signer %d mismatch: have %x, want %x", i, parallel[i], signer)
+		}
+	}
+	// A broken signature anywhere in the batch must fail the whole batch
+	headers[42].Extra = headers[42].Extra[:extraSeal-1]
+	if _, err := recoverSigners(headers, mustNewARC(t)); err != errMissingSignature {
+		t.Fatalf("error mismatch: have %v, want %v", err, errMissingSignature)
+	}
+}
+
+// Tests that the recents bookkeeping rejects validators signing too often.
+func TestSnapshotApplyRecents(t *testing.T) {
+	snap, headers, keys := newTestChain(t, 21, 450)
+
+	res, err := snap.apply(headers, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to apply headers: %v", err)
+	}
+	if res.Number != 450 || res.Hash != headers[449].Hash() {
+		t.Fatalf("snapshot head mismatch: have #%d [%x]", res.Number, res.Hash)
+	}
+	if len(res.Recents) != 21/2+1 {
+		t.Fatalf("recents length mismatch: have %d, want %d", len(res.Recents), 21/2+1)
+	}
+	// Have the validator of block #2 seal block #3 too
+	sealTestHeader(t, headers[2], keys[1])
+	if _, err := snap.apply(headers, nil, nil); err != errRecentlySigned {
+		t.Fatalf("error mismatch: have %v, want %v", err, errRecentlySigned)
+	}
+}
+
+func mustNewARC(t testing.TB) *lru.ARCCache {
+	cache, err := lru.NewARC(inmemorySignatures)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	return cache
+}
+
+func BenchmarkSnapshotApply(b *testing.B) {
+	snap, headers, _ := newTestChain(b, 21, 1024)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// Reset the signature cache to measure the recovery too
+		snap.sigcache = mustNewARC(b)
+		if _, err := snap.apply(headers, nil, nil); err != nil {
+			b.Fatalf("failed to apply headers: %v", err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package congress
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// SysCodeCheckMode is the mode of verifying the code of the system contracts in
+// state against the versions bundled with the binary.
+type SysCodeCheckMode string
+
+const (
+	SysCodeCheckOff  SysCodeCheckMode = "off"  // no verification
+	SysCodeCheckWarn SysCodeCheckMode = "warn" // log the mismatches only (default)
+	SysCodeCheckHalt SysCodeCheckMode = "halt" // refuse to seal on mismatch
+)
+
+var (
+	sysCodeCheckMeter    = metrics.NewRegisteredMeter("congress/syscode/checked", nil)
+	sysCodeMismatchMeter = metrics.NewRegisteredMeter("congress/syscode/mismatch", nil)
+)
+
+// ParseSysCodeCheckMode parses the mode string, empty means warn.
+func ParseSysCodeCheckMode(s string) (SysCodeCheckMode, error) {
+	switch mode := SysCodeCheckMode(s); mode {
+	case "", SysCodeCheckWarn:
+		return SysCodeCheckWarn, nil
+	case SysCodeCheckOff, SysCodeCheckHalt:
+		return mode, nil
+	default:
+		return SysCodeCheckWarn, fmt.Errorf("invalid system contract check mode %q, want one of off, warn, halt", s)
+	}
+}
+
+// sysCodeWatchdog tracks the result of the last system contract code check,
+// which is redone whenever the chain crosses a system contract upgrade.
+type sysCodeWatchdog struct {
+	mode     SysCodeCheckMode
+	checked  bool                              // Whether any state was checked yet
+	version  systemcontract.SysContractVersion // Version of the system contracts checked last
+	mismatch bool                              // Whether the last check found mismatching code
+	lock     sync.Mutex
+}
+
+// CheckSystemContracts verifies the code of the system contracts in the state of
+// the given header against the versions bundled with the binary, e.g. at startup.
+func (c *Congress) CheckSystemContracts(header *types.Header) error {
+	statedb, err := c.chain.StateAt(header.Root)
+	if err != nil {
+		log.Debug("Skip system contract check, state missing", "number", header.Number, "err", err)
+		return nil
+	}
+	return c.checkSystemContracts(header, statedb)
+}
+
+// checkSystemContracts verifies the system contract code in the given state of
+// the header, recording the result for sealing.
+func (c *Congress) checkSystemContracts(header *types.Header, state systemcontract.CodeHashReader) error {
+	w := c.sysCode
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.mode == SysCodeCheckOff {
+		return nil
+	}
+	sysCodeCheckMeter.Mark(1)
+
+	mismatches := systemcontract.CheckCodeVersions(c.chainConfig, header.Number, state)
+	w.checked, w.version = true, systemcontract.ActiveVersion(c.chainConfig, header.Number)
+	w.mismatch = len(mismatches) > 0
+	if !w.mismatch {
+		log.Debug("System contract code verified", "number", header.Number, "version", w.version)
+		return nil
+	}
+	sysCodeMismatchMeter.Mark(1)
+	for _, m := range mismatches {
+		log.Error("System contract code mismatches the bundled version", "number", header.Number, "hash", header.Hash(),
+			"contract", m.Addr, "have", m.Have, "want", m.Want, "mode", w.mode)
+	}
+	return errSysCodeMismatch
+}
+
+// applySystemContractUpgrade upgrades the system contracts at the fork height of
+// the version, then checks the upgraded code, on block import as on sealing. A
+// mismatch is only recorded, refusing to seal on top of it in halt mode.
+func (c *Congress) applySystemContractUpgrade(chain consensus.ChainHeaderReader, version systemcontract.SysContractVersion, header *types.Header, state *state.StateDB) error {
+	if err := systemcontract.ApplySystemContractUpgrade(version, state, header, newChainContext(chain, c), c.chainConfig); err != nil {
+		return err
+	}
+	c.checkSystemContracts(header, state)
+	return nil
+}
+
+// verifySealingSysCode re-checks the system contract code in the parent state if
+// the chain crossed an upgrade since the last check, or the last check failed,
+// and refuses to seal on top of mismatching code in halt mode.
+func (c *Congress) verifySealingSysCode(parent *types.Header) error {
+	w := c.sysCode
+	w.lock.Lock()
+	mode := w.mode
+	stale := !w.checked || w.mismatch || w.version != systemcontract.ActiveVersion(c.chainConfig, parent.Number)
+	w.lock.Unlock()
+
+	if mode == SysCodeCheckOff {
+		return nil
+	}
+	if stale {
+		c.CheckSystemContracts(parent)
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.mismatch && w.mode == SysCodeCheckHalt {
+		return errSysCodeMismatch
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package congress
+
+import (
+	"math"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress/vmcaller"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+var systemGasGauge = metrics.NewRegisteredGauge("congress/systemgas", nil)
+
+// systemGasTracker accumulates the gas used by the consensus system calls of the
+// blocks being finalized. Only the blocks registered by begin are tracked, so the
+// system calls made outside of finalization (e.g. by the APIs) are not counted.
+type systemGasTracker struct {
+	used   map[*types.Header]uint64
+	recent *lru.Cache // System gas used of the recently finalized blocks, by seal hash
+	lock   sync.Mutex
+}
+
+func newSystemGasTracker() *systemGasTracker {
+	recent, _ := lru.New(inmemorySystemGas)
+	return &systemGasTracker{used: make(map[*types.Header]uint64), recent: recent}
+}
+
+func (t *systemGasTracker) begin(header *types.Header) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.used[header] = 0
+}
+
+func (t *systemGasTracker) add(header *types.Header, gas uint64) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if used, ok := t.used[header]; ok {
+		if used+gas < used {
+			t.used[header] = math.MaxUint64
+		} else {
+			t.used[header] = used + gas
+		}
+	}
+}
+
+func (t *systemGasTracker) end(header *types.Header) uint64 {
+	if t == nil {
+		return 0
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	used := t.used[header]
+	delete(t.used, header)
+	return used
+}
+
+// remember keeps the system gas used of a finalized block.
+func (t *systemGasTracker) remember(sealHash common.Hash, used uint64) {
+	if t == nil {
+		return
+	}
+	t.recent.Add(sealHash, used)
+}
+
+// get retrieves the system gas used of a recently finalized block.
+func (t *systemGasTracker) get(sealHash common.Hash) (uint64, bool) {
+	if t == nil {
+		return 0, false
+	}
+	if used, ok := t.recent.Get(sealHash); ok {
+		return used.(uint64), true
+	}
+	return 0, false
+}
+
+// systemGasEntry is the system gas used of a block in the journal.
+type systemGasEntry struct {
+	SealHash common.Hash
+	Used     uint64
+}
+
+// journal serializes the system gas used of the recent blocks, oldest first.
+func (t *systemGasTracker) journal() ([]byte, error) {
+	var entries []systemGasEntry
+	for _, key := range t.recent.Keys() {
+		if used, ok := t.recent.Peek(key); ok {
+			entries = append(entries, systemGasEntry{SealHash: key.(common.Hash), Used: used.(uint64)})
+		}
+	}
+	return rlp.EncodeToBytes(entries)
+}
+
+// loadJournal restores the system gas used of the recent blocks from a journal.
+func (t *systemGasTracker) loadJournal(journal []byte) error {
+	var entries []systemGasEntry
+	if err := rlp.DecodeBytes(journal, &entries); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		t.recent.Add(entry.SealHash, entry.Used)
+	}
+	return nil
+}
+
+// loadSystemGas restores the system gas used of the recent blocks saved at the
+// last shutdown, so that they're still known after a restart.
+func (c *Congress) loadSystemGas() {
+	if c.db == nil {
+		return
+	}
+	journal := rawdb.ReadSystemGasJournal(c.db)
+	if len(journal) == 0 {
+		return
+	}
+	if err := c.systemGas.loadJournal(journal); err != nil {
+		log.Warn("Failed to load system gas journal", "err", err)
+	}
+}
+
+// journalSystemGas saves the system gas used of the recent blocks for the next
+// start. It's only done at shutdown, the entries lost on a crash being unknown.
+func (c *Congress) journalSystemGas() {
+	if c.db == nil {
+		return
+	}
+	journal, err := c.systemGas.journal()
+	if err != nil {
+		log.Warn("Failed to journal system gas", "err", err)
+		return
+	}
+	rawdb.WriteSystemGasJournal(c.db, journal)
+}
+
+// beginSystemGas starts accounting the system calls of the block being finalized.
+func (c *Congress) beginSystemGas(header *types.Header) {
+	c.systemGas.begin(header)
+}
+
+// endSystemGas stops accounting the system calls of the finalized block, and
+// keeps the system gas used in memory under the seal hash, which is final once
+// the block is finalized, both for imported and locally sealed blocks. The recent
+// entries are only persisted at shutdown, the blocks assembled by the miner and
+// never sealed are just evicted with the other old entries.
+func (c *Congress) endSystemGas(header *types.Header) {
+	used := c.systemGas.end(header)
+	systemGasGauge.Update(int64(used))
+	c.systemGas.remember(SealHash(header), used)
+}
+
+// executeSystemMsg executes a consensus system call, and accounts its gas into the
+// system gas used of the block.
+func (c *Congress) executeSystemMsg(chain consensus.ChainHeaderReader, header *types.Header, msg core.Message, state *state.StateDB) ([]byte, error) {
+	ret, used, err := vmcaller.ExecuteMsgWithGas(msg, state, header, newChainContext(chain, c), c.chainConfig)
+	c.systemGas.add(header, used)
+	return ret, err
+}
+
+// systemCallGas returns the gas limit of the system calls at the given block.
+func (c *Congress) systemCallGas(header *types.Header) uint64 {
+	return c.config.SystemCallGasAt(header.Number)
+}
@@ -0,0 +1,88 @@
+package congress
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestSystemGasTracker(t *testing.T) {
+	tracker := newSystemGasTracker()
+	header, other := new(types.Header), new(types.Header)
+
+	// System calls of blocks not being finalized are not accounted
+	tracker.add(header, 100)
+	if used := tracker.end(header); used != 0 {
+		t.Fatalf("untracked block accounted: have %d, want 0", used)
+	}
+	tracker.begin(header)
+	tracker.add(header, 100)
+	tracker.add(header, 50)
+	tracker.add(other, 10)
+	if used := tracker.end(header); used != 150 {
+		t.Fatalf("system gas mismatch: have %d, want 150", used)
+	}
+	if len(tracker.used) != 0 {
+		t.Fatalf("finished blocks not released: %d", len(tracker.used))
+	}
+	// Uncapped failing calls must not overflow
+	tracker.begin(header)
+	tracker.add(header, 1)
+	tracker.add(header, math.MaxUint64)
+	if used := tracker.end(header); used != math.MaxUint64 {
+		t.Fatalf("system gas overflowed: have %d", used)
+	}
+}
+
+func TestSystemGasRecent(t *testing.T) {
+	tracker := newSystemGasTracker()
+	hash := common.HexToHash("0x01")
+
+	if _, ok := tracker.get(hash); ok {
+		t.Fatalf("unknown block system gas found")
+	}
+	tracker.remember(hash, 12345)
+	if used, ok := tracker.get(hash); !ok || used != 12345 {
+		t.Fatalf("system gas mismatch: have %d/%v, want 12345", used, ok)
+	}
+	// Only the recent blocks are kept
+	for i := 0; i < inmemorySystemGas; i++ {
+		tracker.remember(common.BigToHash(big.NewInt(int64(i+2))), 1)
+	}
+	if _, ok := tracker.get(hash); ok {
+		t.Fatalf("old block system gas not evicted")
+	}
+}
+
+// Tests that the system gas used of the recent blocks is kept across restarts,
+// in the order of recency.
+func TestSystemGasJournal(t *testing.T) {
+	config := &params.ChainConfig{ChainID: big.NewInt(1), Congress: &params.CongressConfig{Period: 3, Epoch: 200}}
+	db := rawdb.NewMemoryDatabase()
+
+	engine := New(config, db, new(testChainBackend))
+	for i := 0; i < inmemorySystemGas; i++ {
+		engine.systemGas.remember(common.BigToHash(big.NewInt(int64(i))), uint64(i))
+	}
+	engine.Close()
+
+	engine = New(config, db, new(testChainBackend))
+	if used, ok := engine.systemGas.get(common.BigToHash(big.NewInt(inmemorySystemGas - 1))); !ok || used != inmemorySystemGas-1 {
+		t.Fatalf("newest block system gas mismatch: have %d/%v, want %d", used, ok, inmemorySystemGas-1)
+	}
+	// The oldest entry is evicted first after the restart too
+	engine.systemGas.remember(common.BigToHash(big.NewInt(inmemorySystemGas)), 1)
+	if _, ok := engine.systemGas.get(common.BigToHash(big.NewInt(0))); ok {
+		t.Fatalf("oldest block system gas not evicted")
+	}
+	for i := 1; i < inmemorySystemGas; i++ {
+		if used, ok := engine.systemGas.get(common.BigToHash(big.NewInt(int64(i)))); !ok || used != uint64(i) {
+			t.Fatalf("block %d: system gas mismatch: have %d/%v, want %d", i, used, ok, i)
+		}
+	}
+}
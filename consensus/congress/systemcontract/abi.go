@@ -148,7 +148,26 @@ const PunishInteractiveABI = `
 		"outputs": [],
 		"stateMutability": "nonpayable",
 		"type": "function"
-	  }
+	  },
+	{
+		"inputs": [
+		  {
+			"internalType": "address",
+			"name": "val",
+			"type": "address"
+		  }
+		],
+		"name": "getPunishRecord",
+		"outputs": [
+		  {
+			"internalType": "uint256",
+			"name": "",
+			"type": "uint256"
+		  }
+		],
+		"stateMutability": "view",
+		"type": "function"
+	}
 ]
 `
 
@@ -166,6 +185,30 @@ const ProposalInteractiveABI = `
 		"outputs": [],
 		"stateMutability": "nonpayable",
 		"type": "function"
+	},
+	{
+		"inputs": [
+		  {
+			"internalType": "bytes32",
+			"name": "id",
+			"type": "bytes32"
+		  },
+		  {
+			"internalType": "bool",
+			"name": "auth",
+			"type": "bool"
+		  }
+		],
+		"name": "voteProposal",
+		"outputs": [
+		  {
+			"internalType": "bool",
+			"name": "",
+			"type": "bool"
+		  }
+		],
+		"stateMutability": "nonpayable",
+		"type": "function"
 	}
 ]
 `
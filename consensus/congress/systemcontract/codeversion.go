@@ -0,0 +1,100 @@
+package systemcontract
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Code hashes of the system contracts bundled with the binary.
+var (
+	govCodeHash           = codeHash(govCode)
+	addressListCodeHash   = codeHash(addressListCode)
+	validatorV1CodeHash   = codeHash(validatorV1Code)
+	punishV1CodeHash      = codeHash(punishV1Code)
+	addressListV2CodeHash = codeHash(addressListV2Code)
+	validatorsV2CodeHash  = codeHash(validatorsV2Code)
+)
+
+// CodeHashReader is the state access needed to verify the system contract code.
+type CodeHashReader interface {
+	GetCodeHash(addr common.Address) common.Hash
+}
+
+// CodeMismatch is a system contract whose code in state differs from the
+// version bundled with the binary.
+type CodeMismatch struct {
+	Addr common.Address
+	Have common.Hash
+	Want common.Hash
+}
+
+func codeHash(code string) common.Hash {
+	return crypto.Keccak256Hash(common.FromHex(code))
+}
+
+// ActiveVersion returns the version of the system contracts upgraded by the
+// hard forks active at the given height, or 0 if none is active yet.
+func ActiveVersion(config *params.ChainConfig, height *big.Int) SysContractVersion {
+	switch {
+	case config.IsSophon(height):
+		return SysContractV2
+	case config.IsRedCoast(height):
+		return SysContractV1
+	default:
+		return 0
+	}
+}
+
+// StateVersion returns the version of the system contracts deployed in the given
+// state, or 0 if none of the bundled upgrades was applied.
+func StateVersion(state CodeHashReader) SysContractVersion {
+	switch {
+	case state.GetCodeHash(AddressListContractAddr) == addressListV2CodeHash:
+		return SysContractV2
+	case state.GetCodeHash(SysGovContractAddr) == govCodeHash:
+		return SysContractV1
+	default:
+		return 0
+	}
+}
+
+// ExpectedCodeHashes returns the code hashes the system contracts must have in
+// the state of the given height. Only the contracts deployed by hard forks are
+// covered, the genesis ones are chain specific and not bundled.
+func ExpectedCodeHashes(config *params.ChainConfig, height *big.Int) map[common.Address]common.Hash {
+	hashes := make(map[common.Address]common.Hash)
+
+	version := ActiveVersion(config, height)
+	if version >= SysContractV1 {
+		hashes[SysGovContractAddr] = govCodeHash
+		hashes[AddressListContractAddr] = addressListCodeHash
+		hashes[ValidatorsV1ContractAddr] = validatorV1CodeHash
+		hashes[PunishV1ContractAddr] = punishV1CodeHash
+	}
+	if version >= SysContractV2 {
+		hashes[AddressListContractAddr] = addressListV2CodeHash
+		hashes[ValidatorsV1ContractAddr] = validatorsV2CodeHash
+	}
+	return hashes
+}
+
+// CheckCodeVersions compares the code of the system contracts in the given state
+// of the given height against the versions bundled with the binary, returning
+// the mismatching ones ordered by address.
+func CheckCodeVersions(config *params.ChainConfig, height *big.Int, state CodeHashReader) []CodeMismatch {
+	var mismatches []CodeMismatch
+	for addr, want := range ExpectedCodeHashes(config, height) {
+		if have := state.GetCodeHash(addr); have != want {
+			mismatches = append(mismatches, CodeMismatch{Addr: addr, Have: have, Want: want})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		return bytes.Compare(mismatches[i].Addr[:], mismatches[j].Addr[:]) < 0
+	})
+	return mismatches
+}
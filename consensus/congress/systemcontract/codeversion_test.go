@@ -0,0 +1,44 @@
+package systemcontract
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+type codeHashes map[common.Address]common.Hash
+
+func (c codeHashes) GetCodeHash(addr common.Address) common.Hash {
+	return c[addr]
+}
+
+func TestCheckCodeVersions(t *testing.T) {
+	config := &params.ChainConfig{RedCoastBlock: big.NewInt(10), SophonBlock: big.NewInt(20)}
+
+	require.Equal(t, SysContractVersion(0), ActiveVersion(config, big.NewInt(9)))
+	require.Equal(t, SysContractV1, ActiveVersion(config, big.NewInt(10)))
+	require.Equal(t, SysContractV2, ActiveVersion(config, big.NewInt(20)))
+
+	// Nothing bundled before the first upgrade
+	require.Empty(t, CheckCodeVersions(config, big.NewInt(9), codeHashes{}))
+
+	// State upgraded to V1 matches at V1 heights
+	v1 := codeHashes(ExpectedCodeHashes(config, big.NewInt(10)))
+	require.Len(t, v1, 4)
+	require.Empty(t, CheckCodeVersions(config, big.NewInt(19), v1))
+
+	// The version is recognized from the state
+	require.Equal(t, SysContractVersion(0), StateVersion(codeHashes{}))
+	require.Equal(t, SysContractV1, StateVersion(v1))
+	require.Equal(t, SysContractV2, StateVersion(codeHashes(ExpectedCodeHashes(config, big.NewInt(20)))))
+
+	// But a missed V2 upgrade is detected
+	mismatches := CheckCodeVersions(config, big.NewInt(20), v1)
+	require.Equal(t, []CodeMismatch{
+		{Addr: AddressListContractAddr, Have: addressListCodeHash, Want: addressListV2CodeHash},
+		{Addr: ValidatorsV1ContractAddr, Have: validatorV1CodeHash, Want: validatorsV2CodeHash},
+	}, mismatches)
+}
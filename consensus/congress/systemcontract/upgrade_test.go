@@ -0,0 +1,169 @@
+package systemcontract
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/require"
+)
+
+// To add a fragment, dump the system contracts and any account the upgrade
+// touches at the parent of the fork block from an archive node into
+// testdata/golden/<chain>_<fork>.json, leaving storageRoots empty, then run
+//
+//	go test -run TestGoldenUpgrades -record-golden
+//
+// and check the recorded roots against the ones of the historical block.
+// Fragments which already hold their roots are never rewritten.
+var recordGoldenFlag = flag.Bool("record-golden", false, "Record the post-upgrade roots of new fragments in testdata/golden/")
+
+// goldenUpgrade is a state fragment recorded right before a system contract
+// upgrade height, together with the storage roots the upgrade produced.
+type goldenUpgrade struct {
+	Chain    string             `json:"chain"`
+	Version  SysContractVersion `json:"version"`
+	Number   uint64             `json:"number"`
+	Time     uint64             `json:"time"`
+	Coinbase common.Address     `json:"coinbase"`
+	Pre      core.GenesisAlloc  `json:"pre"`
+
+	// StorageRoots are the post-upgrade storage roots of the system contracts,
+	// Root is the root of the whole fragment after the upgrade.
+	StorageRoots map[common.Address]common.Hash `json:"storageRoots"`
+	Root         common.Hash                    `json:"root"`
+}
+
+var goldenChainConfigs = map[string]*params.ChainConfig{
+	"mainnet": params.MainnetChainConfig,
+	"testnet": params.TestnetChainConfig,
+}
+
+// goldenChainContext serves the upgrade calls, which don't look at any ancestor.
+type goldenChainContext struct{}
+
+func (goldenChainContext) Engine() consensus.Engine                    { return ethash.NewFaker() }
+func (goldenChainContext) GetHeader(common.Hash, uint64) *types.Header { return nil }
+
+func (g *goldenUpgrade) config(t *testing.T) *params.ChainConfig {
+	config, ok := goldenChainConfigs[g.Chain]
+	require.True(t, ok, "unknown chain %q", g.Chain)
+	return config
+}
+
+func (g *goldenUpgrade) header() *types.Header {
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(g.Number),
+		Time:       g.Time,
+		Coinbase:   g.Coinbase,
+		Difficulty: big.NewInt(2),
+		GasLimit:   params.GenesisGasLimit,
+	}
+}
+
+func (g *goldenUpgrade) preState(t *testing.T) *state.StateDB {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.NoError(t, err)
+	for addr, account := range g.Pre {
+		if account.Balance != nil {
+			statedb.SetBalance(addr, account.Balance)
+		}
+		statedb.SetNonce(addr, account.Nonce)
+		statedb.SetCode(addr, account.Code)
+		for key, value := range account.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	return statedb
+}
+
+// apply runs the upgrade on the pre-state and returns the post-upgrade state.
+func (g *goldenUpgrade) apply(t *testing.T) *state.StateDB {
+	config := g.config(t)
+	statedb := g.preState(t)
+	require.NoError(t, ApplySystemContractUpgrade(g.Version, statedb, g.header(), goldenChainContext{}, config))
+	return statedb
+}
+
+func loadGoldenUpgrades(t *testing.T) map[string]*goldenUpgrade {
+	files, err := filepath.Glob(filepath.Join("testdata", "golden", "*.json"))
+	require.NoError(t, err)
+
+	upgrades := make(map[string]*goldenUpgrade)
+	for _, file := range files {
+		blob, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		upgrade := new(goldenUpgrade)
+		require.NoError(t, json.Unmarshal(blob, upgrade), file)
+		upgrades[file] = upgrade
+	}
+	return upgrades
+}
+
+// record fills in the post-upgrade roots of a new fragment and writes it back.
+func (g *goldenUpgrade) record(t *testing.T, file string) {
+	statedb := g.apply(t)
+	g.Root = statedb.IntermediateRoot(true)
+	g.StorageRoots = make(map[common.Address]common.Hash)
+	for addr := range ExpectedCodeHashes(g.config(t), g.header().Number) {
+		g.StorageRoots[addr] = statedb.StorageTrie(addr).Hash()
+	}
+	blob, err := json.MarshalIndent(g, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(file, append(blob, '\n'), 0644))
+}
+
+// TestGoldenUpgrades replays the system contract upgrades on the recorded state
+// fragments and checks they still produce the historical post-upgrade state.
+func TestGoldenUpgrades(t *testing.T) {
+	upgrades := loadGoldenUpgrades(t)
+	if len(upgrades) == 0 {
+		t.Skip("no golden upgrade fragments recorded")
+	}
+	for file, golden := range upgrades {
+		file, golden := file, golden
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			// The fragment must sit exactly on the fork it records
+			config := golden.config(t)
+			var fork *big.Int
+			switch golden.Version {
+			case SysContractV1:
+				fork = config.RedCoastBlock
+			case SysContractV2:
+				fork = config.SophonBlock
+			}
+			require.NotNil(t, fork, "unsupported version %d", golden.Version)
+			require.Equal(t, fork.Uint64(), golden.Number)
+
+			if len(golden.StorageRoots) == 0 {
+				if !*recordGoldenFlag {
+					t.Fatal("fragment has no recorded roots, run with -record-golden")
+				}
+				golden.record(t, file)
+			}
+
+			statedb := golden.apply(t)
+			root := statedb.IntermediateRoot(true)
+
+			// Check the contracts one by one first for a readable failure
+			for addr, want := range golden.StorageRoots {
+				trie := statedb.StorageTrie(addr)
+				require.NotNil(t, trie, "missing contract %x", addr)
+				require.Equal(t, want, trie.Hash(), "storage root mismatch for %x", addr)
+			}
+			require.Empty(t, CheckCodeVersions(config, golden.header().Number, statedb))
+			require.Equal(t, golden.Root, root)
+		})
+	}
+}
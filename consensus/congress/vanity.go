@@ -0,0 +1,143 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package congress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+)
+
+const (
+	// vanityTagPrefix marks the vanity prefixes carrying a client version tag.
+	vanityTagPrefix = "heco/"
+
+	// vanityTagSeparator separates the client version from the operator tag.
+	vanityTagSeparator = "/"
+
+	// defaultVanityBlocks is the number of recent blocks the vanity tags are
+	// collected over if unspecified, maxVanityBlocks the maximum one.
+	defaultVanityBlocks = 64
+	maxVanityBlocks     = 4096
+)
+
+// errInvalidVanityTag is returned if the operator tag has characters not allowed
+// in a vanity tag.
+var errInvalidVanityTag = errors.New("vanity tag must be printable ascii without '/'")
+
+// VanityTag returns the vanity prefix tagging the blocks with the client version
+// and the operator tag, e.g. "heco/1.3.0/pool-a". It fails if the tag doesn't fit
+// into the 32 bytes of the vanity prefix.
+func VanityTag(version, tag string) ([]byte, error) {
+	for _, c := range tag {
+		if c < 0x20 || c > 0x7e || string(c) == vanityTagSeparator {
+			return nil, errInvalidVanityTag
+		}
+	}
+	vanity := vanityTagPrefix + version
+	if tag != "" {
+		vanity += vanityTagSeparator + tag
+	}
+	if len(vanity) > extraVanity {
+		return nil, fmt.Errorf("vanity tag %q too long: have %d bytes, max %d", vanity, len(vanity), extraVanity)
+	}
+	return []byte(vanity), nil
+}
+
+// decodeVanityTag decodes the client version and the operator tag from the vanity
+// prefix of the extra-data, reporting whether it carries a vanity tag.
+func decodeVanityTag(extra []byte) (version string, tag string, ok bool) {
+	if len(extra) > extraVanity {
+		extra = extra[:extraVanity]
+	}
+	vanity := string(bytes.TrimRight(extra, "\x00"))
+	if !strings.HasPrefix(vanity, vanityTagPrefix) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(vanity, vanityTagPrefix), vanityTagSeparator, 2)
+	if parts[0] == "" {
+		return "", "", false
+	}
+	if len(parts) == 2 {
+		tag = parts[1]
+	}
+	return parts[0], tag, true
+}
+
+// ValidatorVanity is the vanity of the last block sealed by a validator.
+type ValidatorVanity struct {
+	Version   string        `json:"version,omitempty"` // Client version, empty if untagged
+	Tag       string        `json:"tag,omitempty"`     // Operator tag
+	Vanity    hexutil.Bytes `json:"vanity"`            // Raw vanity prefix
+	Blocks    int           `json:"blocks"`            // Blocks sealed over the range
+	LastBlock uint64        `json:"lastBlock"`
+}
+
+// VanityReport is the client version distribution among the validators sealing a
+// range of blocks, read from their vanity tags.
+type VanityReport struct {
+	From       uint64                              `json:"from"`
+	To         uint64                              `json:"to"`
+	Validators map[common.Address]*ValidatorVanity `json:"validators"`
+	Versions   map[string]int                      `json:"versions"` // Number of validators per client version, "untagged" if none
+}
+
+// vanityReport collects the vanity tags of the blocks in the range [from, to].
+func (c *Congress) vanityReport(chain consensus.ChainHeaderReader, from, to uint64) (*VanityReport, error) {
+	report := &VanityReport{
+		From:       from,
+		To:         to,
+		Validators: make(map[common.Address]*ValidatorVanity),
+		Versions:   make(map[string]int),
+	}
+	for n := from; n <= to; n++ {
+		header := chain.GetHeaderByNumber(n)
+		if header == nil {
+			return nil, fmt.Errorf("missing block %d", n)
+		}
+		sealer, err := c.Author(header)
+		if err != nil {
+			return nil, err
+		}
+		entry := report.Validators[sealer]
+		if entry == nil {
+			entry = new(ValidatorVanity)
+			report.Validators[sealer] = entry
+		}
+		vanity := header.Extra
+		if len(vanity) > extraVanity {
+			vanity = vanity[:extraVanity]
+		}
+		entry.Version, entry.Tag, _ = decodeVanityTag(vanity)
+		entry.Vanity = common.CopyBytes(vanity)
+		entry.Blocks++
+		entry.LastBlock = n
+	}
+	for _, entry := range report.Validators {
+		version := entry.Version
+		if version == "" {
+			version = "untagged"
+		}
+		report.Versions[version]++
+	}
+	return report, nil
+}
@@ -0,0 +1,578 @@
+package congress
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	vectorChainID     = 1337       // Chain id of the vector chains
+	vectorGenesisTime = 1600000000 // Timestamp of the vector genesis blocks
+	vectorPeriod      = 3          // Block period of the vector chains
+	vectorGasLimit    = 10000000   // Gas limit of the vector blocks
+)
+
+// VectorConfig is the shape of the chain the consensus test vectors are taken from.
+type VectorConfig struct {
+	Validators int    // Number of genesis validators
+	Absent     int    // Number of validators never sealing, the last ones by address
+	Epoch      uint64 // Epoch length of the chain
+	Blocks     int    // Number of blocks of the chain
+}
+
+// DefaultVectorConfig spans a few epochs with an absent validator, long enough for
+// it to be punished repeatedly.
+var DefaultVectorConfig = VectorConfig{
+	Validators: 3,
+	Absent:     1,
+	Epoch:      100,
+	Blocks:     210,
+}
+
+// VectorSuite is a set of consensus test vectors, a congress chain along with the
+// expected outcome of the consensus rules on each of its blocks, for alternative
+// implementations and auditors to check the rules against without running this
+// codebase. The blocks are imported in order on top of the genesis, the invalid
+// blocks are rejected on top of their parent.
+type VectorSuite struct {
+	Genesis     *core.Genesis    `json:"genesis"`
+	GenesisHash common.Hash      `json:"genesisHash"`
+	Blocks      []*VectorBlock   `json:"blocks"`
+	Invalid     []*InvalidVector `json:"invalid"`
+}
+
+// VectorBlock is a valid block of the vector chain and its expected outcome.
+type VectorBlock struct {
+	Number     uint64           `json:"number"`
+	Hash       common.Hash      `json:"hash"`
+	RLP        hexutil.Bytes    `json:"rlp"`
+	Signer     common.Address   `json:"signer"`
+	InTurn     bool             `json:"inTurn"`
+	StateRoot  common.Hash      `json:"stateRoot"`
+	Punished   *common.Address  `json:"punished,omitempty"`   // Validator punished for missing its turn
+	Checkpoint []common.Address `json:"checkpoint,omitempty"` // Validators set by an epoch block
+	Snapshot   *VectorSnapshot  `json:"snapshot"`             // Snapshot after the block
+}
+
+// VectorSnapshot is the expected snapshot of the validators after a block.
+type VectorSnapshot struct {
+	Validators []common.Address          `json:"validators"` // Ascending
+	Recents    map[uint64]common.Address `json:"recents"`
+}
+
+// InvalidVector is a block breaking one of the consensus rules.
+type InvalidVector struct {
+	Name   string        `json:"name"`
+	Parent uint64        `json:"parent"` // Number of the valid block it's built on
+	RLP    hexutil.Bytes `json:"rlp"`
+	Error  string        `json:"error"` // Expected rejection
+}
+
+// vectorKey derives the deterministic key of a vector chain account.
+func vectorKey(name string) *ecdsa.PrivateKey {
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte("congress-vectors-" + name)))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// vectorGenesis creates the genesis of a vector chain, with the system contracts
+// of the mainnet genesis.
+func vectorGenesis(validators []common.Address, epoch uint64) *core.Genesis {
+	config := &params.ChainConfig{
+		ChainID:             big.NewInt(vectorChainID),
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(0),
+		PetersburgBlock:     big.NewInt(0),
+		IstanbulBlock:       big.NewInt(0),
+		Congress:            &params.CongressConfig{Period: vectorPeriod, Epoch: epoch},
+	}
+	extra := make([]byte, extraVanity, extraVanity+len(validators)*common.AddressLength+extraSeal)
+	for _, validator := range validators {
+		extra = append(extra, validator.Bytes()...)
+	}
+	extra = append(extra, make([]byte, extraSeal)...)
+
+	return &core.Genesis{
+		Config:     config,
+		Timestamp:  vectorGenesisTime,
+		ExtraData:  extra,
+		GasLimit:   vectorGasLimit,
+		Difficulty: big.NewInt(1),
+		Alloc:      core.DefaultGenesisBlock().Alloc,
+	}
+}
+
+// sealVectorHeader signs the header with the key.
+func sealVectorHeader(header *types.Header, key *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(SealHash(header).Bytes(), key)
+	if err != nil {
+		return err
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+	return nil
+}
+
+// maySeal reports whether the validator may seal the block on top of the snapshot
+// as far as the recent signers are concerned.
+func (s *Snapshot) maySeal(number uint64, validator common.Address) bool {
+	limit := uint64(len(s.Validators)/2 + 1)
+	for seen, recent := range s.Recents {
+		if recent == validator && seen > number-limit {
+			return false
+		}
+	}
+	return true
+}
+
+// vectorSnapshot converts the snapshot into its vector form.
+func vectorSnapshot(snap *Snapshot) *VectorSnapshot {
+	recents := make(map[uint64]common.Address, len(snap.Recents))
+	for number, validator := range snap.Recents {
+		recents[number] = validator
+	}
+	return &VectorSnapshot{Validators: snap.validators(), Recents: recents}
+}
+
+// vectorChain is a congress chain the vectors are generated on or verified against.
+type vectorChain struct {
+	engine *Congress
+	chain  *core.BlockChain
+	now    uint64 // Current unix time of the engine's clock, atomically accessed
+}
+
+// newVectorChain creates a chain on top of the vector genesis. The engine runs
+// on the real clock unless faked, then the generator drives it.
+func newVectorChain(genesis *core.Genesis, fakeClock bool) (*vectorChain, error) {
+	db := rawdb.NewMemoryDatabase()
+	if _, err := genesis.Commit(db); err != nil {
+		return nil, err
+	}
+	vc := &vectorChain{now: genesis.Timestamp}
+
+	var opts []Option
+	if fakeClock {
+		opts = append(opts, WithClock(func() time.Time {
+			return time.Unix(int64(atomic.LoadUint64(&vc.now)), 0)
+		}))
+	}
+	ref := new(ChainRef)
+	vc.engine = New(genesis.Config, db, ref, opts...)
+	vc.engine.SetSysCodeCheckMode(SysCodeCheckOff)
+
+	chain, err := core.NewBlockChain(db, nil, genesis.Config, vc.engine, vm.Config{}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	ref.BlockChain = chain
+	vc.chain = chain
+	return vc, nil
+}
+
+// snapshot returns the snapshot after the block.
+func (vc *vectorChain) snapshot(block *types.Block) (*Snapshot, error) {
+	return vc.engine.snapshot(vc.chain, block.NumberU64(), block.Hash(), nil)
+}
+
+// forge creates and seals the block of the validator on top of the parent.
+func (vc *vectorChain) forge(parent *types.Block, validator common.Address, key *ecdsa.PrivateKey) (*types.Block, error) {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+	}
+	atomic.StoreUint64(&vc.now, parent.Time())
+	vc.engine.Authorize(validator, nil, nil)
+	if err := vc.engine.Prepare(vc.chain, header); err != nil {
+		return nil, err
+	}
+	statedb, err := vc.chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	block, _, err := vc.engine.FinalizeAndAssemble(vc.chain, header, statedb, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	header = block.Header()
+	if err := sealVectorHeader(header, key); err != nil {
+		return nil, err
+	}
+	atomic.StoreUint64(&vc.now, header.Time)
+	return block.WithSeal(header), nil
+}
+
+// GenerateVectors builds a congress chain of the given shape out of deterministic
+// keys, and exports the outcome of the consensus rules on its blocks: the signers,
+// the state roots, the snapshots, the epoch transitions and the punishments, along
+// with the blocks breaking the rules on top of it.
+func GenerateVectors(config VectorConfig) (*VectorSuite, error) {
+	if config.Validators == 0 || config.Absent >= config.Validators || config.Epoch == 0 {
+		return nil, errors.New("invalid vector chain shape")
+	}
+	var (
+		keys       = make(map[common.Address]*ecdsa.PrivateKey)
+		validators []common.Address
+		absent     = make(map[common.Address]bool)
+	)
+	for i := 0; i < config.Validators; i++ {
+		key := vectorKey(fmt.Sprintf("validator-%d", i))
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		keys[addr] = key
+		validators = append(validators, addr)
+	}
+	sort.Sort(validatorsAscending(validators))
+	for _, validator := range validators[len(validators)-config.Absent:] {
+		absent[validator] = true
+	}
+	genesis := vectorGenesis(validators, config.Epoch)
+
+	vc, err := newVectorChain(genesis, true)
+	if err != nil {
+		return nil, err
+	}
+	defer vc.chain.Stop()
+
+	suite := &VectorSuite{
+		Genesis:     genesis,
+		GenesisHash: vc.chain.Genesis().Hash(),
+	}
+	for i := 0; i < config.Blocks; i++ {
+		parent := vc.chain.CurrentBlock()
+		number := parent.NumberU64() + 1
+
+		snap, err := vc.snapshot(parent)
+		if err != nil {
+			return nil, err
+		}
+		// Seal in turn if possible, otherwise with the first validator allowed to
+		validators := snap.validators()
+		candidates := append([]common.Address{validators[number%uint64(len(validators))]}, validators...)
+
+		var signer common.Address
+		for _, candidate := range candidates {
+			if !absent[candidate] && snap.maySeal(number, candidate) {
+				signer = candidate
+				break
+			}
+		}
+		if signer == (common.Address{}) {
+			return nil, fmt.Errorf("no validator may seal block %d", number)
+		}
+		block, err := vc.forge(parent, signer, keys[signer])
+		if err != nil {
+			return nil, fmt.Errorf("failed to forge block %d: %v", number, err)
+		}
+		// Derive the invalid siblings of the block before importing it, the last
+		// block covers the seal rules and the epoch blocks their validators
+		if number%config.Epoch == 0 {
+			invalid, err := vc.forgeInvalidCheckpoint(parent, block, keys[signer])
+			if err != nil {
+				return nil, err
+			}
+			suite.Invalid = append(suite.Invalid, invalid)
+		} else if i == config.Blocks-1 {
+			invalid, err := vc.forgeInvalidSeals(snap, parent, block, keys)
+			if err != nil {
+				return nil, err
+			}
+			suite.Invalid = append(suite.Invalid, invalid...)
+		}
+		if _, err := vc.chain.InsertChain(types.Blocks{block}); err != nil {
+			return nil, fmt.Errorf("failed to import block %d: %v", number, err)
+		}
+		after, err := vc.snapshot(block)
+		if err != nil {
+			return nil, err
+		}
+		blob, err := rlp.EncodeToBytes(block)
+		if err != nil {
+			return nil, err
+		}
+		vb := &VectorBlock{
+			Number:    number,
+			Hash:      block.Hash(),
+			RLP:       blob,
+			Signer:    signer,
+			InTurn:    block.Difficulty().Cmp(diffInTurn) == 0,
+			StateRoot: block.Root(),
+			Snapshot:  vectorSnapshot(after),
+		}
+		if !vb.InTurn {
+			if punished, ok := snap.punishTarget(number); ok {
+				vb.Punished = &punished
+			}
+		}
+		if number%config.Epoch == 0 {
+			vb.Checkpoint = parseExtraValidators(block.Header())
+		}
+		suite.Blocks = append(suite.Blocks, vb)
+	}
+	return suite, nil
+}
+
+// invalidVector re-seals a mutated copy of the valid block's header with the key
+// the mutation returns.
+func invalidVector(name string, parent, block *types.Block, want error, mutate func(header *types.Header) *ecdsa.PrivateKey) (*InvalidVector, error) {
+	header := block.Header()
+	header.Extra = common.CopyBytes(header.Extra)
+	if err := sealVectorHeader(header, mutate(header)); err != nil {
+		return nil, err
+	}
+	blob, err := rlp.EncodeToBytes(block.WithSeal(header))
+	if err != nil {
+		return nil, err
+	}
+	return &InvalidVector{Name: name, Parent: parent.NumberU64(), RLP: blob, Error: want.Error()}, nil
+}
+
+// forgeInvalidSeals derives the blocks breaking the seal rules from the valid
+// block sealed on top of the snapshot.
+func (vc *vectorChain) forgeInvalidSeals(snap *Snapshot, parent, block *types.Block, keys map[common.Address]*ecdsa.PrivateKey) ([]*InvalidVector, error) {
+	var (
+		signer   = block.Coinbase()
+		outsider = vectorKey("outsider")
+		other    common.Address
+	)
+	for _, validator := range snap.validators() {
+		if validator != signer {
+			other = validator
+			break
+		}
+	}
+	cases := []struct {
+		name   string
+		err    error
+		mutate func(header *types.Header) *ecdsa.PrivateKey
+		skip   bool
+	}{
+		{
+			name: "wrong-difficulty",
+			err:  errWrongDifficulty,
+			mutate: func(header *types.Header) *ecdsa.PrivateKey {
+				if header.Difficulty.Cmp(diffInTurn) == 0 {
+					header.Difficulty = new(big.Int).Set(diffNoTurn)
+				} else {
+					header.Difficulty = new(big.Int).Set(diffInTurn)
+				}
+				return keys[signer]
+			},
+		},
+		{
+			name: "unauthorized-validator",
+			err:  errUnauthorizedValidator,
+			mutate: func(header *types.Header) *ecdsa.PrivateKey {
+				header.Coinbase = crypto.PubkeyToAddress(outsider.PublicKey)
+				return outsider
+			},
+		},
+		{
+			name: "coinbase-mismatch",
+			err:  errInvalidCoinbase,
+			mutate: func(header *types.Header) *ecdsa.PrivateKey {
+				header.Coinbase = other
+				return keys[signer]
+			},
+			skip: other == (common.Address{}),
+		},
+		{
+			name: "recently-signed",
+			err:  errRecentlySigned,
+			mutate: func(header *types.Header) *ecdsa.PrivateKey {
+				header.Coinbase = parent.Coinbase()
+				header.Difficulty = calcDifficulty(snap, parent.Coinbase())
+				return keys[parent.Coinbase()]
+			},
+			skip: parent.NumberU64() == 0 || len(snap.Validators) < 2,
+		},
+		{
+			name: "extra-validators",
+			err:  errExtraValidators,
+			mutate: func(header *types.Header) *ecdsa.PrivateKey {
+				extra := append(common.CopyBytes(header.Extra[:extraVanity]), other.Bytes()...)
+				header.Extra = append(extra, make([]byte, extraSeal)...)
+				return keys[signer]
+			},
+		},
+	}
+	var vectors []*InvalidVector
+	for _, c := range cases {
+		if c.skip {
+			continue
+		}
+		vector, err := invalidVector(c.name, parent, block, c.err, c.mutate)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+// forgeInvalidCheckpoint derives the epoch block setting validators other than
+// the ones of the contract from the valid one.
+func (vc *vectorChain) forgeInvalidCheckpoint(parent, block *types.Block, key *ecdsa.PrivateKey) (*InvalidVector, error) {
+	return invalidVector("checkpoint-validators-mismatch", parent, block, errInvalidExtraValidators, func(header *types.Header) *ecdsa.PrivateKey {
+		validators := parseExtraValidators(header)
+		if len(validators) > 1 {
+			validators = validators[:len(validators)-1]
+		} else {
+			outsider := vectorKey("outsider")
+			validators = append(validators, crypto.PubkeyToAddress(outsider.PublicKey))
+		}
+		extra := common.CopyBytes(header.Extra[:extraVanity])
+		for _, validator := range validators {
+			extra = append(extra, validator.Bytes()...)
+		}
+		header.Extra = append(extra, make([]byte, extraSeal)...)
+		return key
+	})
+}
+
+// VerifyVectors checks the consensus rules of this implementation against the
+// vectors, importing the blocks into a fresh chain in order.
+func VerifyVectors(suite *VectorSuite) error {
+	if suite.Genesis == nil || suite.Genesis.Config == nil || suite.Genesis.Config.Congress == nil {
+		return errors.New("missing congress genesis")
+	}
+	vc, err := newVectorChain(suite.Genesis, false)
+	if err != nil {
+		return err
+	}
+	defer vc.chain.Stop()
+
+	if hash := vc.chain.Genesis().Hash(); hash != suite.GenesisHash {
+		return fmt.Errorf("genesis mismatch: have %x, want %x", hash, suite.GenesisHash)
+	}
+	invalid := make(map[uint64][]*InvalidVector)
+	for _, vector := range suite.Invalid {
+		invalid[vector.Parent] = append(invalid[vector.Parent], vector)
+	}
+	if err := vc.verifyInvalid(invalid[0]); err != nil {
+		return err
+	}
+	for _, vb := range suite.Blocks {
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(vb.RLP, block); err != nil {
+			return fmt.Errorf("block %d: invalid rlp: %v", vb.Number, err)
+		}
+		if block.NumberU64() != vb.Number || block.Hash() != vb.Hash {
+			return fmt.Errorf("block %d: hash mismatch: have %d %x, want %d %x", vb.Number, block.NumberU64(), block.Hash(), vb.Number, vb.Hash)
+		}
+		parent := vc.chain.CurrentBlock()
+		snap, err := vc.snapshot(parent)
+		if err != nil {
+			return err
+		}
+		if _, err := vc.chain.InsertChain(types.Blocks{block}); err != nil {
+			return fmt.Errorf("block %d: rejected: %v", vb.Number, err)
+		}
+		if err := vc.verifyBlock(vb, snap, block); err != nil {
+			return fmt.Errorf("block %d: %v", vb.Number, err)
+		}
+		if err := vc.verifyInvalid(invalid[vb.Number]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyBlock checks the outcome of the imported block against its vector.
+func (vc *vectorChain) verifyBlock(vb *VectorBlock, parent *Snapshot, block *types.Block) error {
+	signer, err := ecrecover(block.Header(), vc.engine.signatures)
+	if err != nil {
+		return err
+	}
+	if signer != vb.Signer {
+		return fmt.Errorf("signer mismatch: have %x, want %x", signer, vb.Signer)
+	}
+	if inturn := parent.inturn(vb.Number, signer); inturn != vb.InTurn {
+		return fmt.Errorf("in-turn mismatch: have %v, want %v", inturn, vb.InTurn)
+	}
+	if root := vc.chain.CurrentBlock().Root(); root != vb.StateRoot {
+		return fmt.Errorf("state root mismatch: have %x, want %x", root, vb.StateRoot)
+	}
+	var punished *common.Address
+	if !vb.InTurn {
+		if target, ok := parent.punishTarget(vb.Number); ok {
+			punished = &target
+		}
+	}
+	if (punished == nil) != (vb.Punished == nil) || (punished != nil && *punished != *vb.Punished) {
+		return fmt.Errorf("punished mismatch: have %v, want %v", punished, vb.Punished)
+	}
+	if vb.Number%vc.engine.config.Epoch == 0 {
+		if have := parseExtraValidators(block.Header()); !equalAddresses(have, vb.Checkpoint) {
+			return fmt.Errorf("checkpoint validators mismatch: have %v, want %v", have, vb.Checkpoint)
+		}
+	}
+	snap, err := vc.snapshot(block)
+	if err != nil {
+		return err
+	}
+	have := vectorSnapshot(snap)
+	if !equalAddresses(have.Validators, vb.Snapshot.Validators) {
+		return fmt.Errorf("snapshot validators mismatch: have %v, want %v", have.Validators, vb.Snapshot.Validators)
+	}
+	if len(have.Recents) != len(vb.Snapshot.Recents) {
+		return fmt.Errorf("snapshot recents mismatch: have %v, want %v", have.Recents, vb.Snapshot.Recents)
+	}
+	for number, validator := range have.Recents {
+		if want, ok := vb.Snapshot.Recents[number]; !ok || want != validator {
+			return fmt.Errorf("snapshot recents mismatch: have %v, want %v", have.Recents, vb.Snapshot.Recents)
+		}
+	}
+	return nil
+}
+
+// verifyInvalid checks the invalid blocks are rejected on top of the head.
+func (vc *vectorChain) verifyInvalid(vectors []*InvalidVector) error {
+	for _, vector := range vectors {
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(vector.RLP, block); err != nil {
+			return fmt.Errorf("invalid block %s: invalid rlp: %v", vector.Name, err)
+		}
+		_, err := vc.chain.InsertChain(types.Blocks{block})
+		if err == nil {
+			return fmt.Errorf("invalid block %s: accepted", vector.Name)
+		}
+		if !strings.Contains(err.Error(), vector.Error) {
+			return fmt.Errorf("invalid block %s: error mismatch: have %q, want %q", vector.Name, err, vector.Error)
+		}
+	}
+	return nil
+}
+
+// equalAddresses reports whether the address lists are the same.
+func equalAddresses(a, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package congress
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestVectors(t *testing.T) {
+	suite, err := GenerateVectors(VectorConfig{Validators: 3, Absent: 1, Epoch: 6, Blocks: 14})
+	if err != nil {
+		t.Fatalf("failed to generate vectors: %v", err)
+	}
+	var punished, checkpoints int
+	for _, block := range suite.Blocks {
+		if block.Punished != nil {
+			punished++
+		}
+		if len(block.Checkpoint) > 0 {
+			checkpoints++
+		}
+	}
+	if punished == 0 || checkpoints != 2 {
+		t.Errorf("vectors coverage mismatch: %d punished, %d checkpoints", punished, checkpoints)
+	}
+	if len(suite.Invalid) != 2+5 {
+		t.Errorf("invalid vectors mismatch: have %d, want %d", len(suite.Invalid), 2+5)
+	}
+	// The vectors verify once exported
+	blob, err := json.Marshal(suite)
+	if err != nil {
+		t.Fatalf("failed to encode vectors: %v", err)
+	}
+	decoded := new(VectorSuite)
+	if err := json.Unmarshal(blob, decoded); err != nil {
+		t.Fatalf("failed to decode vectors: %v", err)
+	}
+	if err := VerifyVectors(decoded); err != nil {
+		t.Fatalf("failed to verify vectors: %v", err)
+	}
+	// Tampered expectations are caught
+	decoded.Blocks[3].Snapshot.Validators = decoded.Blocks[3].Snapshot.Validators[1:]
+	if err := VerifyVectors(decoded); err == nil {
+		t.Fatalf("tampered snapshot verified")
+	}
+}
@@ -0,0 +1,211 @@
+// Package verifier implements the stateless verification of congress header
+// chains, for the monitoring services checking the headers served by untrusted
+// nodes without running one.
+package verifier
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// retainedHeaders is the number of headers kept below the head to verify the
+// side chains on, at most the number of snapshots cached by the engine.
+const retainedHeaders = 128
+
+var (
+	// errNotCongress is returned if the chain config isn't a congress one.
+	errNotCongress = errors.New("not a congress chain config")
+
+	// errNoValidators is returned if the verifier is created without validators.
+	errNoValidators = errors.New("no starting validators")
+
+	// ErrUnknownParent is returned if the parent of a header isn't the anchor or
+	// a retained verified header.
+	ErrUnknownParent = errors.New("unknown parent")
+
+	// errNoState is returned if the engine reads a state, as the verifier has none.
+	errNoState = errors.New("no state on a header chain")
+)
+
+// Result is the outcome of verifying a header.
+type Result struct {
+	Number     uint64           `json:"number"`
+	Hash       common.Hash      `json:"hash"`
+	Signer     common.Address   `json:"signer"`
+	InTurn     bool             `json:"inTurn"`
+	Validators []common.Address `json:"validators,omitempty"` // Validators set by a checkpoint header
+}
+
+// Verifier verifies a stream of headers on top of a trusted anchor header and
+// its validators: the extra-data layout, the seals, the turn-ness, the signing
+// spam protection and the validators set by the checkpoints. The checkpoint
+// validators can't be cross-checked against the contract state, they're taken
+// as signed by the validators of the preceding epoch. The validators having
+// signed right before the anchor are unknown, so the spam protection of the
+// first headers only covers the ones on top of the anchor.
+//
+// The headers are kept in memory for a limited depth below the head only, the
+// headers of deeper side chains failing with ErrUnknownParent.
+type Verifier struct {
+	engine *congress.Congress
+	chain  *headerChain
+	lock   sync.Mutex
+}
+
+// New creates a verifier trusting the anchor header and the validators to sign
+// the headers on top of it, e.g. the genesis or a checkpoint and the validators
+// in its extra-data.
+func New(config *params.ChainConfig, anchor *types.Header, validators []common.Address) (*Verifier, error) {
+	if config.Congress == nil {
+		return nil, errNotCongress
+	}
+	if len(validators) == 0 {
+		return nil, errNoValidators
+	}
+	hash := anchor.Hash()
+	chain := &headerChain{
+		config:  config,
+		headers: map[common.Hash]*types.Header{hash: anchor},
+		numbers: map[uint64]common.Hash{anchor.Number.Uint64(): hash},
+		head:    anchor,
+	}
+	engine := congress.New(config, rawdb.NewMemoryDatabase(), chain, congress.WithTrustedSnapshot(anchor.Number.Uint64(), hash, validators))
+	return &Verifier{engine: engine, chain: chain}, nil
+}
+
+// Head returns the highest verified header, the anchor if none.
+func (v *Verifier) Head() *types.Header {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	return v.chain.head
+}
+
+// Verify checks the header on top of the anchor or of a retained verified header,
+// the header becoming the head if it's the highest one.
+func (v *Verifier) Verify(header *types.Header) (*Result, error) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if header.Number == nil || header.Number.Sign() == 0 {
+		return nil, ErrUnknownParent
+	}
+	number := header.Number.Uint64()
+	if v.chain.GetHeader(header.ParentHash, number-1) == nil {
+		return nil, ErrUnknownParent
+	}
+	if err := v.engine.VerifyHeader(v.chain, header, true); err != nil {
+		return nil, err
+	}
+	signer, err := v.engine.Signer(header)
+	if err != nil {
+		return nil, err
+	}
+	v.chain.insert(header)
+
+	result := &Result{
+		Number: number,
+		Hash:   header.Hash(),
+		Signer: signer,
+		InTurn: congress.InTurn(header),
+	}
+	if validators, ok := v.engine.CheckpointValidators(header); ok {
+		result.Validators = validators
+	}
+	return result, nil
+}
+
+// VerifyHeaders checks a batch of consecutive headers, stopping at the first
+// invalid one.
+func (v *Verifier) VerifyHeaders(headers []*types.Header) ([]*Result, error) {
+	results := make([]*Result, 0, len(headers))
+	for _, header := range headers {
+		result, err := v.Verify(header)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// headerChain is the in-memory chain of the verified headers the engine reads
+// the ancestors from.
+type headerChain struct {
+	config  *params.ChainConfig
+	headers map[common.Hash]*types.Header // Anchor and verified headers
+	numbers map[uint64]common.Hash        // Canonical hashes, along the head's chain
+	head    *types.Header
+}
+
+// insert adds a verified header, making it the head if it's the highest one. The
+// canonical hashes are updated along the chain of the new head, and the headers
+// too deep below it are dropped.
+func (hc *headerChain) insert(header *types.Header) {
+	hc.headers[header.Hash()] = header
+	if header.Number.Uint64() <= hc.head.Number.Uint64() {
+		return
+	}
+	for ancestor := header; ancestor != nil; ancestor = hc.headers[ancestor.ParentHash] {
+		hash, number := ancestor.Hash(), ancestor.Number.Uint64()
+		if hc.numbers[number] == hash {
+			break
+		}
+		hc.numbers[number] = hash
+	}
+	hc.head = header
+
+	if head := header.Number.Uint64(); head > retainedHeaders {
+		limit := head - retainedHeaders
+		for hash, header := range hc.headers {
+			if number := header.Number.Uint64(); number < limit {
+				delete(hc.headers, hash)
+				delete(hc.numbers, number)
+			}
+		}
+	}
+}
+
+// Config implements consensus.ChainHeaderReader.
+func (hc *headerChain) Config() *params.ChainConfig {
+	return hc.config
+}
+
+// CurrentHeader implements consensus.ChainHeaderReader.
+func (hc *headerChain) CurrentHeader() *types.Header {
+	return hc.head
+}
+
+// GetHeader implements consensus.ChainHeaderReader.
+func (hc *headerChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := hc.headers[hash]; header != nil && header.Number.Uint64() == number {
+		return header
+	}
+	return nil
+}
+
+// GetHeaderByNumber implements consensus.ChainHeaderReader.
+func (hc *headerChain) GetHeaderByNumber(number uint64) *types.Header {
+	hash, ok := hc.numbers[number]
+	if !ok {
+		return nil
+	}
+	return hc.headers[hash]
+}
+
+// GetHeaderByHash implements consensus.ChainHeaderReader.
+func (hc *headerChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	return hc.headers[hash]
+}
+
+// StateAt implements congress.ChainBackend, the verified headers having no state.
+func (hc *headerChain) StateAt(root common.Hash) (*state.StateDB, error) {
+	return nil, errNoState
+}
@@ -0,0 +1,75 @@
+package verifier
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func decodeHeader(t *testing.T, blob []byte) *types.Header {
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(blob, block); err != nil {
+		t.Fatalf("failed to decode block: %v", err)
+	}
+	return block.Header()
+}
+
+func TestVerifier(t *testing.T) {
+	suite, err := congress.GenerateVectors(congress.VectorConfig{Validators: 3, Absent: 1, Epoch: 6, Blocks: 14})
+	if err != nil {
+		t.Fatalf("failed to generate vectors: %v", err)
+	}
+	genesis := suite.Genesis.ToBlock(nil).Header()
+	extra := genesis.Extra[32 : len(genesis.Extra)-crypto.SignatureLength]
+	validators := make([]common.Address, len(extra)/common.AddressLength)
+	for i := range validators {
+		copy(validators[i][:], extra[i*common.AddressLength:])
+	}
+	v, err := New(suite.Genesis.Config, genesis, validators)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+	// Headers on top of unknown parents are rejected
+	orphan := decodeHeader(t, suite.Blocks[1].RLP)
+	if _, err := v.Verify(orphan); err != ErrUnknownParent {
+		t.Fatalf("orphan header: have error %v, want %v", err, ErrUnknownParent)
+	}
+	// Invalid headers are rejected at their parent, unless only detectable with
+	// the contract state
+	invalid := make(map[uint64][]*congress.InvalidVector)
+	for _, vector := range suite.Invalid {
+		invalid[vector.Parent] = append(invalid[vector.Parent], vector)
+	}
+	checkInvalid := func(parent uint64) {
+		for _, vector := range invalid[parent] {
+			if vector.Name == "checkpoint-validators-mismatch" {
+				continue
+			}
+			if _, err := v.Verify(decodeHeader(t, vector.RLP)); err == nil || err.Error() != vector.Error {
+				t.Errorf("%s on %d: have error %v, want %s", vector.Name, parent, err, vector.Error)
+			}
+		}
+	}
+	checkInvalid(0)
+	for _, vb := range suite.Blocks {
+		result, err := v.Verify(decodeHeader(t, vb.RLP))
+		if err != nil {
+			t.Fatalf("block %d: failed to verify: %v", vb.Number, err)
+		}
+		if result.Hash != vb.Hash || result.Signer != vb.Signer || result.InTurn != vb.InTurn {
+			t.Errorf("block %d: result mismatch: have %x %x %v, want %x %x %v", vb.Number,
+				result.Hash, result.Signer, result.InTurn, vb.Hash, vb.Signer, vb.InTurn)
+		}
+		if len(result.Validators) != len(vb.Checkpoint) {
+			t.Errorf("block %d: checkpoint validators mismatch: have %x, want %x", vb.Number, result.Validators, vb.Checkpoint)
+		}
+		checkInvalid(vb.Number)
+	}
+	if head := v.Head(); head.Hash() != suite.Blocks[len(suite.Blocks)-1].Hash {
+		t.Errorf("head mismatch: have %x", head.Hash())
+	}
+}
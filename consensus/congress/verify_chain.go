@@ -0,0 +1,250 @@
+package congress
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// errMisplacedSysTx is returned if a system transaction is followed by a
+	// normal one in a block.
+	errMisplacedSysTx = errors.New("system transaction followed by normal transaction")
+
+	// errEarlySysTx is returned if a block before the RedCoast fork contains a
+	// system transaction.
+	errEarlySysTx = errors.New("system transaction before RedCoast fork")
+
+	// errMismatchingSnapshot is returned if the snapshot stored for a block differs
+	// from the one recomputed from the headers.
+	errMismatchingSnapshot = errors.New("stored snapshot mismatches the headers")
+)
+
+// verifyBatch is the maximum number of headers applied at once while recomputing
+// a snapshot, bounding the memory used when starting from far away.
+const verifyBatch = 2048
+
+// BlockVerification is the report of re-verifying the consensus fields of a stored
+// block, listing the failed checks and the ones skipped for the missing state.
+type BlockVerification struct {
+	Number  uint64         `json:"number"`
+	Hash    common.Hash    `json:"hash"`
+	Signer  common.Address `json:"signer"`
+	SysTxs  int            `json:"sysTxs"`
+	Errors  []string       `json:"errors,omitempty"`
+	Skipped []string       `json:"skipped,omitempty"`
+}
+
+// Valid reports whether all the checks done passed.
+func (v *BlockVerification) Valid() bool {
+	return len(v.Errors) == 0
+}
+
+// Fail records a failed check.
+func (v *BlockVerification) Fail(check string, err error) {
+	v.Errors = append(v.Errors, fmt.Sprintf("%s: %v", check, err))
+}
+
+// Skip records a check skipped.
+func (v *BlockVerification) Skip(check string) {
+	v.Skipped = append(v.Skipped, check)
+}
+
+// ChainVerifier re-verifies the stored blocks in order, recomputing the validator
+// snapshots from the headers instead of trusting the ones stored in the database,
+// which are compared with the recomputed ones.
+type ChainVerifier struct {
+	engine *Congress
+	chain  consensus.ChainHeaderReader
+	snap   *Snapshot // Snapshot recomputed up to the last verified block, nil if broken
+}
+
+// NewChainVerifier creates a verifier of the blocks following the given one, its
+// snapshot recomputed from the headers.
+func (c *Congress) NewChainVerifier(chain consensus.ChainHeaderReader, number uint64) (*ChainVerifier, error) {
+	snap, err := c.recomputeSnapshot(chain, number)
+	if err != nil {
+		return nil, err
+	}
+	return &ChainVerifier{engine: c, chain: chain, snap: snap}, nil
+}
+
+// recomputeSnapshot rebuilds the snapshot of the canonical block from the headers
+// alone. It starts from the genesis, or from the validators of the checkpoint one
+// epoch before the last one, so that the recent signers are all known again by
+// the given block as long as the validators don't outnumber twice the epoch.
+func (c *Congress) recomputeSnapshot(chain consensus.ChainHeaderReader, number uint64) (*Snapshot, error) {
+	var base uint64
+	if number >= 2*c.config.Epoch {
+		base = (number/c.config.Epoch - 1) * c.config.Epoch
+	}
+	checkpoint := chain.GetHeaderByNumber(base)
+	if checkpoint == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	snap := newSnapshot(c.config, c.signatures, base, checkpoint.Hash(), parseExtraValidators(checkpoint))
+	for snap.Number < number {
+		headers := make([]*types.Header, 0, verifyBatch)
+		for n := snap.Number + 1; n <= number && len(headers) < verifyBatch; n++ {
+			header := chain.GetHeaderByNumber(n)
+			if header == nil {
+				return nil, consensus.ErrUnknownAncestor
+			}
+			if c.fakeSeal {
+				c.signatures.Add(header.Hash(), header.Coinbase)
+			}
+			headers = append(headers, header)
+		}
+		next, err := snap.apply(headers, chain, nil)
+		if err != nil {
+			return nil, err
+		}
+		snap = next
+	}
+	return snap, nil
+}
+
+// Verify re-verifies the consensus fields of the next block already in the chain:
+// the seal, the difficulty turn-ness, the checkpoint validator list, the placement
+// of the system transactions and the stored snapshot if any. The checkpoint
+// validators are cross-checked against the contract state only if the parent
+// state is given, it's left untouched.
+func (v *ChainVerifier) Verify(block *types.Block, parentState *state.StateDB) *BlockVerification {
+	number := block.NumberU64()
+
+	// Rebuild the snapshot if the previous block broke it or isn't the parent
+	if v.snap == nil || v.snap.Hash != block.ParentHash() {
+		v.snap, _ = v.engine.recomputeSnapshot(v.chain, number-1)
+	}
+	res := v.engine.verifyBlock(v.chain, block, v.snap, parentState)
+	if v.snap == nil {
+		return res
+	}
+	if v.snap, _ = v.snap.apply([]*types.Header{block.Header()}, v.chain, nil); v.snap == nil {
+		return res // The seal check failed already
+	}
+	if stored, err := loadSnapshot(v.engine.config, v.engine.signatures, v.engine.db, block.Hash()); err == nil && !equalSnapshots(stored, v.snap) {
+		res.Fail("snapshot", errMismatchingSnapshot)
+	}
+	return res
+}
+
+// verifyBlock re-verifies the consensus fields of a block already in the chain on
+// top of the snapshot of its parent, the seal checks being skipped without one.
+func (c *Congress) verifyBlock(chain consensus.ChainHeaderReader, block *types.Block, snap *Snapshot, parentState *state.StateDB) *BlockVerification {
+	header := block.Header()
+	number := header.Number.Uint64()
+	v := &BlockVerification{Number: number, Hash: block.Hash()}
+	if number == 0 {
+		return v
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		v.Fail("seal", consensus.ErrUnknownAncestor)
+		return v
+	}
+	// Verify the seal and the turn-ness against the validators of the parent
+	signer, err := c.recoverSigner(header)
+	if err != nil {
+		v.Fail("seal", err)
+		return v
+	}
+	v.Signer = signer
+	if signer != header.Coinbase {
+		v.Fail("seal", errInvalidCoinbase)
+	}
+	if snap == nil {
+		v.Skip("seal")
+		v.Skip("difficulty")
+	} else {
+		if _, ok := snap.Validators[signer]; !ok {
+			v.Fail("seal", errUnauthorizedValidator)
+		}
+		for seen, recent := range snap.Recents {
+			if recent == signer {
+				if limit := uint64(len(snap.Validators)/2 + 1); seen > number-limit {
+					v.Fail("seal", errRecentlySigned)
+				}
+			}
+		}
+		inturn := snap.inturn(number, signer)
+		if (inturn && header.Difficulty.Cmp(diffInTurn) != 0) || (!inturn && header.Difficulty.Cmp(diffNoTurn) != 0) {
+			v.Fail("difficulty", fmt.Errorf("%w: have %v, inturn %v", errWrongDifficulty, header.Difficulty, inturn))
+		}
+	}
+	// Verify the validator list of the checkpoints
+	validatorsBytes := len(header.Extra) - extraVanity - extraSeal
+	if number%c.config.Epoch != 0 {
+		if validatorsBytes != 0 {
+			v.Fail("validators", errExtraValidators)
+		}
+	} else if validatorsBytes <= 0 || validatorsBytes%common.AddressLength != 0 {
+		v.Fail("validators", errInvalidCheckpointValidators)
+	} else if parentState == nil {
+		v.Skip("validators")
+	} else {
+		expected, err := c.getTopValidatorsAt(chain, header, parent, parentState.Copy())
+		if err != nil {
+			v.Fail("validators", err)
+		} else if extra := parseExtraValidators(header); !equalValidators(extra, expected) {
+			v.Fail("validators", fmt.Errorf("%w: extra %v, contract %v", errMismatchingCheckpointValidators, extra, expected))
+		}
+	}
+	// Verify the system transactions are placed at the end of the block
+	for i, tx := range block.Transactions() {
+		sender, err := types.Sender(c.signer, tx)
+		if err != nil {
+			v.Fail("systxs", fmt.Errorf("tx %d: %v", i, err))
+			break
+		}
+		isSys, _ := c.IsSysTransaction(sender, tx, header)
+		if isSys {
+			v.SysTxs++
+			continue
+		}
+		if v.SysTxs > 0 {
+			v.Fail("systxs", fmt.Errorf("%w: tx %d", errMisplacedSysTx, i))
+			break
+		}
+	}
+	if v.SysTxs > 0 && !c.chainConfig.IsRedCoast(header.Number) {
+		v.Fail("systxs", errEarlySysTx)
+	}
+	return v
+}
+
+// equalSnapshots reports whether the two snapshots have the same validators and
+// recent signers.
+func equalSnapshots(a, b *Snapshot) bool {
+	if a.Number != b.Number || a.Hash != b.Hash || len(a.Validators) != len(b.Validators) || len(a.Recents) != len(b.Recents) {
+		return false
+	}
+	for validator := range a.Validators {
+		if _, ok := b.Validators[validator]; !ok {
+			return false
+		}
+	}
+	for number, signer := range a.Recents {
+		if b.Recents[number] != signer {
+			return false
+		}
+	}
+	return true
+}
+
+// equalValidators reports whether the two validator lists are the same.
+func equalValidators(a, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
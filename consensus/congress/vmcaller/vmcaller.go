@@ -13,17 +13,23 @@ import (
 
 // ExecuteMsg executes transaction sent to system contracts.
 func ExecuteMsg(msg core.Message, state *state.StateDB, header *types.Header, chainContext core.ChainContext, chainConfig *params.ChainConfig) (ret []byte, err error) {
+	ret, _, err = ExecuteMsgWithGas(msg, state, header, chainContext, chainConfig)
+	return ret, err
+}
+
+// ExecuteMsgWithGas executes transaction sent to system contracts, and returns the gas used by the execution as well.
+func ExecuteMsgWithGas(msg core.Message, state *state.StateDB, header *types.Header, chainContext core.ChainContext, chainConfig *params.ChainConfig) (ret []byte, usedGas uint64, err error) {
 	blockContext := core.NewEVMBlockContext(header, chainContext, nil)
 	vmenv := vm.NewEVM(blockContext, core.NewEVMTxContext(msg), state, chainConfig, vm.Config{})
 
-	ret, _, err = vmenv.Call(vm.AccountRef(msg.From()), *msg.To(), msg.Data(), msg.Gas(), msg.Value())
+	ret, leftOverGas, err := vmenv.Call(vm.AccountRef(msg.From()), *msg.To(), msg.Data(), msg.Gas(), msg.Value())
 	// Finalise the statedb so any changes can take effect,
 	// and especially if the `from` account is empty, it can be finally deleted.
 	state.Finalise(true)
 	if err != nil {
 		log.Error("ExecuteMsg failed", "err", err, "ret", string(ret))
 	}
-	return ret, err
+	return ret, msg.Gas() - leftOverGas, err
 }
 
 // NewLegacyMessage builds a message for consensus and system governance actions, it will not consumes any fee.
@@ -30,6 +30,10 @@ import (
 
 var (
 	FeeRecoder = common.HexToAddress("0xffffffffffffffffffffffffffffffffffffffff")
+
+	// BurnAddress receives the burnt part of the transaction fees once the congress
+	// fee burn fork is reached.
+	BurnAddress = common.HexToAddress("0x000000000000000000000000000000000000dEaD")
 )
 
 // ChainHeaderReader defines a small collection of methods needed to access the local
@@ -147,11 +151,15 @@ type PoSA interface {
 	// CanCreate determines where a given address can create a new contract.
 	CanCreate(state StateReader, addr common.Address, height *big.Int) bool
 
+	// FeeBurnRatio returns the per mille of the transaction fees burnt at the given height.
+	FeeBurnRatio(state StateReader, height *big.Int) uint64
+
 	// ValidateTx do a consensus-related validation on the given transaction at the given header and state.
 	ValidateTx(sender common.Address, tx *types.Transaction, header *types.Header, parentState *state.StateDB) error
 
-	// CreateEvmExtraValidator returns a EvmExtraValidator if necessary.
-	CreateEvmExtraValidator(header *types.Header, parentState *state.StateDB) types.EvmExtraValidator
+	// CreateEvmExtraValidator returns a EvmExtraValidator if necessary, or an error
+	// if the rules it enforces can't be resolved.
+	CreateEvmExtraValidator(header *types.Header, parentState *state.StateDB) (types.EvmExtraValidator, error)
 
 	//Methods for debug trace
 
@@ -21,20 +21,24 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/console/prompt"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/internal/jsre"
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 const (
@@ -340,3 +344,62 @@ func TestIndenting(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the congress validator operator commands are bound in the console
+// and reach the engine and operator APIs of a congress node.
+func TestCongressCommands(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2b96dbc2b50291")
+	validator := crypto.PubkeyToAddress(key.PublicKey)
+
+	tester := newTester(t, func(config *ethconfig.Config) {
+		extra := make([]byte, 32+common.AddressLength+crypto.SignatureLength)
+		copy(extra[32:], validator.Bytes())
+
+		config.Genesis = &core.Genesis{
+			Config:    params.AllCongressProtocolChanges,
+			GasLimit:  11_500_000,
+			ExtraData: extra,
+			Alloc:     core.GenesisAlloc{validator: {Balance: big.NewInt(params.Ether)}},
+		}
+		config.Miner.Etherbase = validator
+	})
+	defer tester.Close(t)
+
+	ks := keystore.NewKeyStore(tester.stack.KeyStoreDir(), keystore.LightScryptN, keystore.LightScryptP)
+	tester.stack.AccountManager().AddBackend(ks)
+
+	account, err := ks.ImportECDSA(key, "")
+	if err != nil {
+		t.Fatalf("failed to import validator key: %v", err)
+	}
+	if err := ks.Unlock(account, ""); err != nil {
+		t.Fatalf("failed to unlock validator account: %v", err)
+	}
+	evaluate := func(cmd string, want string) {
+		t.Helper()
+
+		tester.output.Reset()
+		tester.console.Evaluate(cmd)
+		if output := tester.output.String(); !strings.Contains(output, want) {
+			t.Errorf("%s output mismatch: have %s, want also %s", cmd, output, want)
+		}
+	}
+	evaluate("congress.status().active", "false")
+	evaluate("congress.sealingEnabled(true)", "true")
+	evaluate("congress.status().validator", strings.ToLower(validator.Hex()))
+	evaluate("congress.status().active", "true")
+	evaluate("congress.sealingEnabled(false)", "false")
+	evaluate("congress.setVanity('0x01020304')", "true")
+	evaluate("congress.setVanity('0x"+strings.Repeat("00", 33)+"')", "vanity longer than 32 bytes")
+
+	id := common.Hash{0x01}
+	evaluate(fmt.Sprintf("congress.proposalVote('%s', true)", id.Hex()), "0x")
+	pending, _ := tester.ethereum.TxPool().Content()
+	if txs := pending[validator]; len(txs) != 1 {
+		t.Fatalf("pending vote mismatch: have %d transactions, want 1", len(txs))
+	} else if data := txs[0].Data(); len(data) != 4+2*32 || common.BytesToHash(data[4:36]) != id || data[67] != 1 {
+		t.Errorf("vote data mismatch: have %x", data)
+	} else if output := tester.output.String(); !strings.Contains(output, txs[0].Hash().Hex()) {
+		t.Errorf("vote hash mismatch: have %s, want %s", output, txs[0].Hash().Hex())
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// AttestationSet keeps the latest head attestation of each validator for the
+// recent block numbers, dropping the ones falling out of the window behind the
+// highest attested number.
+type AttestationSet struct {
+	window  uint64
+	highest uint64
+	atts    map[uint64]map[common.Address]*types.HeadAttestation
+	lock    sync.RWMutex
+}
+
+// NewAttestationSet creates an attestation set keeping the given number of
+// recent block numbers.
+func NewAttestationSet(window uint64) *AttestationSet {
+	return &AttestationSet{
+		window: window,
+		atts:   make(map[uint64]map[common.Address]*types.HeadAttestation),
+	}
+}
+
+// Add inserts an attestation, replacing the one of the same validator at the same
+// number if it attests a different block, e.g. after a reorg. It returns whether
+// the set changed, i.e. the attestation is new and worth propagating.
+func (s *AttestationSet) Add(att *types.HeadAttestation) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if att.Number+s.window <= s.highest {
+		return false
+	}
+	atts := s.atts[att.Number]
+	if atts == nil {
+		atts = make(map[common.Address]*types.HeadAttestation)
+		s.atts[att.Number] = atts
+	}
+	if prev := atts[att.Validator]; prev != nil && prev.Hash == att.Hash {
+		return false
+	}
+	atts[att.Validator] = att
+
+	if att.Number > s.highest {
+		s.highest = att.Number
+		for number := range s.atts {
+			if number+s.window <= s.highest {
+				delete(s.atts, number)
+			}
+		}
+	}
+	return true
+}
+
+// Get returns the attestations of the given number, sorted by validator.
+func (s *AttestationSet) Get(number uint64) []*types.HeadAttestation {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	atts := make([]*types.HeadAttestation, 0, len(s.atts[number]))
+	for _, att := range s.atts[number] {
+		atts = append(atts, att)
+	}
+	sort.Slice(atts, func(i, j int) bool {
+		return bytes.Compare(atts[i].Validator[:], atts[j].Validator[:]) < 0
+	})
+	return atts
+}
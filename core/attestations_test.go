@@ -0,0 +1,66 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that the attestation set keeps the latest attestation of each validator
+// and drops the heights falling out of the window.
+func TestAttestationSet(t *testing.T) {
+	var (
+		set  = NewAttestationSet(4)
+		val1 = common.Address{0x01}
+		val2 = common.Address{0x02}
+	)
+	if !set.Add(&types.HeadAttestation{Number: 10, Hash: common.Hash{0xa}, Validator: val2}) {
+		t.Fatalf("new attestation rejected")
+	}
+	if !set.Add(&types.HeadAttestation{Number: 10, Hash: common.Hash{0xa}, Validator: val1}) {
+		t.Fatalf("new attestation rejected")
+	}
+	if set.Add(&types.HeadAttestation{Number: 10, Hash: common.Hash{0xa}, Validator: val1}) {
+		t.Fatalf("duplicate attestation accepted")
+	}
+	if !set.Add(&types.HeadAttestation{Number: 10, Hash: common.Hash{0xb}, Validator: val1}) {
+		t.Fatalf("reorged attestation rejected")
+	}
+	atts := set.Get(10)
+	if len(atts) != 2 {
+		t.Fatalf("attestation count mismatch: have %d, want 2", len(atts))
+	}
+	if atts[0].Validator != val1 || atts[0].Hash != (common.Hash{0xb}) || atts[1].Validator != val2 {
+		t.Fatalf("attestations mismatch: have %x/%x, %x", atts[0].Validator, atts[0].Hash, atts[1].Validator)
+	}
+	// Move the window past the first height
+	if !set.Add(&types.HeadAttestation{Number: 14, Hash: common.Hash{0xc}, Validator: val1}) {
+		t.Fatalf("new attestation rejected")
+	}
+	if atts := set.Get(10); len(atts) != 0 {
+		t.Fatalf("stale attestations kept: %d", len(atts))
+	}
+	if set.Add(&types.HeadAttestation{Number: 10, Hash: common.Hash{0xa}, Validator: val2}) {
+		t.Fatalf("stale attestation accepted")
+	}
+	if !set.Add(&types.HeadAttestation{Number: 11, Hash: common.Hash{0xa}, Validator: val2}) {
+		t.Fatalf("attestation within the window rejected")
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// BadBlockKind is the category of the failure which made a block bad.
+type BadBlockKind string
+
+const (
+	BadBlockHeader      BadBlockKind = "header"      // Header or body verification failed
+	BadBlockBanned      BadBlockKind = "banned"      // Block hash is on the banned list
+	BadBlockProcessing  BadBlockKind = "processing"  // Transaction execution failed
+	BadBlockGasUsed     BadBlockKind = "gasUsed"     // Gas used mismatch after execution
+	BadBlockBloom       BadBlockKind = "bloom"       // Logs bloom mismatch after execution
+	BadBlockReceiptRoot BadBlockKind = "receiptRoot" // Receipt root mismatch after execution
+	BadBlockStateRoot   BadBlockKind = "stateRoot"   // State root mismatch after execution
+)
+
+var badBlockKinds = []BadBlockKind{
+	BadBlockHeader, BadBlockBanned, BadBlockProcessing,
+	BadBlockGasUsed, BadBlockBloom, BadBlockReceiptRoot, BadBlockStateRoot,
+}
+
+// badBlockCounters count the bad blocks per category, to spot the systemic
+// issues across the network.
+var badBlockCounters = func() map[BadBlockKind]metrics.Counter {
+	counters := make(map[BadBlockKind]metrics.Counter, len(badBlockKinds))
+	for _, kind := range badBlockKinds {
+		counters[kind] = metrics.NewRegisteredCounter("chain/badblock/"+string(kind), nil)
+	}
+	return counters
+}()
+
+// MismatchError is returned by the state validation when a field derived from
+// the local execution doesn't match the one of the block.
+type MismatchError struct {
+	Kind   BadBlockKind
+	Remote string // Value in the block
+	Local  string // Value derived locally
+}
+
+func (e *MismatchError) Error() string {
+	switch e.Kind {
+	case BadBlockGasUsed:
+		return fmt.Sprintf("invalid gas used (remote: %s local: %s)", e.Remote, e.Local)
+	case BadBlockBloom:
+		return fmt.Sprintf("invalid bloom (remote: %s  local: %s)", e.Remote, e.Local)
+	case BadBlockReceiptRoot:
+		return fmt.Sprintf("invalid receipt root hash (remote: %s local: %s)", e.Remote, e.Local)
+	case BadBlockStateRoot:
+		return fmt.Sprintf("invalid merkle root (remote: %s local: %s)", e.Remote, e.Local)
+	}
+	return fmt.Sprintf("invalid %s (remote: %s local: %s)", e.Kind, e.Remote, e.Local)
+}
+
+// BadBlockReceipt is the summary of a locally derived receipt of a bad block.
+type BadBlockReceipt struct {
+	TxHash            common.Hash `json:"txHash"`
+	Status            uint64      `json:"status"`
+	GasUsed           uint64      `json:"gasUsed"`
+	CumulativeGasUsed uint64      `json:"cumulativeGasUsed"`
+	Logs              int         `json:"logs"`
+}
+
+// BadBlockReport is the machine readable report of the last bad block.
+type BadBlockReport struct {
+	Number   uint64       `json:"number"`
+	Hash     common.Hash  `json:"hash"`
+	Reported time.Time    `json:"reported"`
+	Kind     BadBlockKind `json:"kind"`
+	Error    string       `json:"error"`
+
+	// Remote and Local are the mismatching values of the block and of the local
+	// execution, set for the mismatch categories only.
+	Remote string `json:"remote,omitempty"`
+	Local  string `json:"local,omitempty"`
+
+	// FirstDivergentReceipt is the index of the first local receipt differing
+	// from the ones stored for the block, nil if the block's receipts are not
+	// known locally or all the derived ones match.
+	FirstDivergentReceipt *int               `json:"firstDivergentReceipt"`
+	Receipts              []*BadBlockReceipt `json:"receipts"`
+}
+
+// classifyBadBlock returns the category of a block import failure, the import
+// stage it was hit in unless the error tells a finer one.
+func classifyBadBlock(stage BadBlockKind, err error) BadBlockKind {
+	var mismatch *MismatchError
+	switch {
+	case errors.As(err, &mismatch):
+		return mismatch.Kind
+	case errors.Is(err, ErrBannedHash):
+		return BadBlockBanned
+	}
+	return stage
+}
+
+// newBadBlockReport assembles the report of a bad block, comparing the locally
+// derived receipts with the stored ones if any.
+func (bc *BlockChain) newBadBlockReport(block *types.Block, receipts types.Receipts, stage BadBlockKind, err error) *BadBlockReport {
+	report := &BadBlockReport{
+		Number:   block.NumberU64(),
+		Hash:     block.Hash(),
+		Reported: time.Now(),
+		Kind:     classifyBadBlock(stage, err),
+		Error:    err.Error(),
+		Receipts: make([]*BadBlockReceipt, 0, len(receipts)),
+	}
+	var mismatch *MismatchError
+	if errors.As(err, &mismatch) {
+		report.Remote, report.Local = mismatch.Remote, mismatch.Local
+	}
+	for _, receipt := range receipts {
+		report.Receipts = append(report.Receipts, &BadBlockReceipt{
+			TxHash:            receipt.TxHash,
+			Status:            receipt.Status,
+			GasUsed:           receipt.GasUsed,
+			CumulativeGasUsed: receipt.CumulativeGasUsed,
+			Logs:              len(receipt.Logs),
+		})
+	}
+	if len(receipts) > 0 {
+		if stored := rawdb.ReadRawReceipts(bc.db, block.Hash(), block.NumberU64()); stored != nil {
+			report.FirstDivergentReceipt = firstDivergentReceipt(stored, receipts)
+		}
+	}
+	return report
+}
+
+// firstDivergentReceipt returns the index of the first receipt whose consensus
+// fields differ between the two lists, nil if they match.
+func firstDivergentReceipt(remote, local types.Receipts) *int {
+	for i := 0; i < len(remote) || i < len(local); i++ {
+		if i >= len(remote) || i >= len(local) ||
+			remote[i].Status != local[i].Status ||
+			remote[i].CumulativeGasUsed != local[i].CumulativeGasUsed ||
+			remote[i].Bloom != local[i].Bloom ||
+			len(remote[i].Logs) != len(local[i].Logs) {
+			return &i
+		}
+	}
+	return nil
+}
+
+// LastBadBlockReport returns the report of the last bad block hit since the node
+// started, nil if there was none.
+func (bc *BlockChain) LastBadBlockReport() *BadBlockReport {
+	report, _ := bc.lastBadBlock.Load().(*BadBlockReport)
+	return report
+}
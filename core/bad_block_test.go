@@ -0,0 +1,103 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestClassifyBadBlock(t *testing.T) {
+	tests := []struct {
+		stage BadBlockKind
+		err   error
+		want  BadBlockKind
+	}{
+		{BadBlockHeader, errors.New("unknown ancestor"), BadBlockHeader},
+		{BadBlockHeader, ErrBannedHash, BadBlockBanned},
+		{BadBlockProcessing, errors.New("could not apply tx"), BadBlockProcessing},
+		{BadBlockProcessing, &MismatchError{Kind: BadBlockStateRoot}, BadBlockStateRoot},
+		{BadBlockProcessing, fmt.Errorf("wrapped: %w", &MismatchError{Kind: BadBlockGasUsed}), BadBlockGasUsed},
+	}
+	for i, tt := range tests {
+		if kind := classifyBadBlock(tt.stage, tt.err); kind != tt.want {
+			t.Errorf("test %d: kind mismatch: have %s, want %s", i, kind, tt.want)
+		}
+	}
+}
+
+func TestFirstDivergentReceipt(t *testing.T) {
+	receipts := func(gas ...uint64) types.Receipts {
+		var list types.Receipts
+		for _, g := range gas {
+			list = append(list, &types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: g})
+		}
+		return list
+	}
+	if i := firstDivergentReceipt(receipts(1, 2, 3), receipts(1, 2, 3)); i != nil {
+		t.Errorf("matching receipts diverge at %d", *i)
+	}
+	if i := firstDivergentReceipt(receipts(1, 2, 3), receipts(1, 5, 6)); i == nil || *i != 1 {
+		t.Errorf("divergent receipt mismatch: have %v, want 1", i)
+	}
+	if i := firstDivergentReceipt(receipts(1, 2, 3), receipts(1, 2)); i == nil || *i != 2 {
+		t.Errorf("missing receipt mismatch: have %v, want 2", i)
+	}
+}
+
+// Tests that a state root mismatch is reported with its category and values.
+func TestBadBlockReport(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 1, nil)
+	header := blocks[0].Header()
+	header.Root[0]++
+	bad := blocks[0].WithSeal(header)
+
+	chain, err := NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if chain.LastBadBlockReport() != nil {
+		t.Fatal("bad block report before any bad block")
+	}
+	if _, err := chain.InsertChain(types.Blocks{bad}); err == nil {
+		t.Fatal("bad block imported")
+	}
+	report := chain.LastBadBlockReport()
+	if report == nil {
+		t.Fatal("no bad block report")
+	}
+	if report.Kind != BadBlockStateRoot || report.Hash != bad.Hash() {
+		t.Errorf("report mismatch: have %s %x, want %s %x", report.Kind, report.Hash, BadBlockStateRoot, bad.Hash())
+	}
+	if report.Remote != fmt.Sprintf("%x", header.Root) || report.Local != fmt.Sprintf("%x", blocks[0].Root()) {
+		t.Errorf("report values mismatch: have %s/%s", report.Remote, report.Local)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// errBuildTimestamp is returned if the timestamp of the block to build doesn't
+// follow the one of its parent.
+var errBuildTimestamp = errors.New("build timestamp not after the parent")
+
+// PoolSnapshot is a frozen view of the pending transactions of the pool, grouped
+// by account and sorted by nonce, the local ones being included first.
+type PoolSnapshot struct {
+	Locals  map[common.Address]types.Transactions
+	Remotes map[common.Address]types.Transactions
+}
+
+// copy returns a deep copy of the snapshot, the transaction sets being reowned
+// while ordered.
+func (s *PoolSnapshot) copy() *PoolSnapshot {
+	cpy := &PoolSnapshot{
+		Locals:  make(map[common.Address]types.Transactions, len(s.Locals)),
+		Remotes: make(map[common.Address]types.Transactions, len(s.Remotes)),
+	}
+	for addr, txs := range s.Locals {
+		cpy.Locals[addr] = append(types.Transactions(nil), txs...)
+	}
+	for addr, txs := range s.Remotes {
+		cpy.Remotes[addr] = append(types.Transactions(nil), txs...)
+	}
+	return cpy
+}
+
+// BuildConfig are the parameters of the block to build, pinning everything the
+// miner would otherwise take from the wall clock or the local settings.
+type BuildConfig struct {
+	Coinbase common.Address // Block beneficiary, if not overridden by the engine
+	Time     uint64         // Block timestamp, overriding the one of the engine
+	GasCeil  uint64         // Target gas ceiling of the block
+	Extra    []byte         // Block extra data, before the engine's additions
+	Seed     common.Hash    // Seed breaking the ties of the transactions paying the same tip
+}
+
+// BuildBlock deterministically assembles the block the miner would build on top
+// of the parent out of the pool snapshot: the same parent, snapshot and config
+// produce the same unsealed block across runs and nodes. The transactions are
+// ordered like the miner does, by tip and nonce, but with the price ties broken by
+// the seed instead of the time the transactions were first seen.
+//
+// It is meant to debug the payload differences between versions, the sealing
+// being left to the caller.
+func BuildBlock(chain *BlockChain, parent *types.Block, snapshot *PoolSnapshot, config *BuildConfig) (*types.Block, []*types.Receipt, error) {
+	if config.Time <= parent.Time() {
+		return nil, nil, fmt.Errorf("%w: parent %d, build %d", errBuildTimestamp, parent.Time(), config.Time)
+	}
+	var (
+		engine      = chain.Engine()
+		chainConfig = chain.Config()
+		num         = parent.Number()
+	)
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     num.Add(num, common.Big1),
+		GasLimit:   CalcGasLimit(parent.GasLimit(), config.GasCeil),
+		Extra:      common.CopyBytes(config.Extra),
+		Time:       config.Time,
+		Coinbase:   config.Coinbase,
+	}
+	if chainConfig.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(chainConfig, parent.Header())
+	}
+	if err := engine.Prepare(chain, header); err != nil {
+		return nil, nil, err
+	}
+	// The engine may derive the timestamp from the wall clock, pin it back
+	header.Time = config.Time
+
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, nil, err
+	}
+	var extraValidator types.EvmExtraValidator
+	posa, isPoSA := engine.(consensus.PoSA)
+	if isPoSA {
+		if err := posa.PreHandle(chain, header, statedb); err != nil {
+			return nil, nil, err
+		}
+		if extraValidator, err = posa.CreateEvmExtraValidator(header, statedb); err != nil {
+			return nil, nil, err
+		}
+	}
+	// Apply the local transactions first, then the remote ones, like the miner
+	var (
+		signer   = types.MakeSigner(chainConfig, header.Number)
+		gasPool  = new(GasPool).AddGas(header.GasLimit)
+		vmConfig = *chain.GetVMConfig()
+		feeless  = NewFeelessCounter(chainConfig, header)
+		txs      []*types.Transaction
+		receipts []*types.Receipt
+	)
+	vmConfig.Profiler = nil
+
+	snapshot = snapshot.copy()
+	for _, set := range []map[common.Address]types.Transactions{snapshot.Locals, snapshot.Remotes} {
+		if len(set) == 0 {
+			continue
+		}
+		ordered := types.NewTransactionsByPriceAndSeed(signer, set, header.BaseFee, config.Seed)
+		for gasPool.Gas() >= params.TxGas {
+			tx := ordered.Peek()
+			if tx == nil {
+				break
+			}
+			from, _ := types.Sender(signer, tx)
+			if tx.Protected() && !chainConfig.IsEIP155(header.Number) {
+				ordered.Pop()
+				continue
+			}
+			if isPoSA {
+				if err := posa.ValidateTx(from, tx, header, statedb); err != nil {
+					ordered.Pop()
+					continue
+				}
+			}
+			if err := feeless.Check(from, tx); err != nil {
+				ordered.Pop()
+				continue
+			}
+			statedb.Prepare(tx.Hash(), len(txs))
+
+			snap := statedb.Snapshot()
+			receipt, err := ApplyTransaction(chainConfig, chain, &header.Coinbase, gasPool, statedb, header, tx, &header.GasUsed, vmConfig, extraValidator)
+			switch {
+			case err == nil:
+				txs = append(txs, tx)
+				receipts = append(receipts, receipt)
+				feeless.Add(tx)
+				ordered.Shift()
+
+			case errors.Is(err, ErrNonceTooLow):
+				statedb.RevertToSnapshot(snap)
+				ordered.Shift()
+
+			case errors.Is(err, ErrGasLimitReached), errors.Is(err, ErrNonceTooHigh), errors.Is(err, ErrTxTypeNotSupported):
+				statedb.RevertToSnapshot(snap)
+				ordered.Pop()
+
+			default:
+				statedb.RevertToSnapshot(snap)
+				log.Debug("Transaction failed in block build, account skipped", "hash", tx.Hash(), "err", err)
+				ordered.Shift()
+			}
+		}
+	}
+	return engine.FinalizeAndAssemble(chain, header, statedb, txs, nil, receipts)
+}
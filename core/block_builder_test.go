@@ -0,0 +1,149 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newBuilderTestChain creates a chain funding the given keys.
+func newBuilderTestChain(t *testing.T, keys []*ecdsa.PrivateKey) *BlockChain {
+	alloc := make(GenesisAlloc)
+	for _, key := range keys {
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = GenesisAccount{Balance: big.NewInt(params.Ether)}
+	}
+	db := rawdb.NewMemoryDatabase()
+	gspec := &Genesis{Config: params.TestChainConfig, Alloc: alloc, GasLimit: 10000000}
+	gspec.MustCommit(db)
+
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	return chain
+}
+
+func gweiTransaction(nonce uint64, gwei int64, key *ecdsa.PrivateKey) *types.Transaction {
+	return pricedTransaction(nonce, params.TxGas, big.NewInt(gwei*params.GWei), key)
+}
+
+// Tests that the block is built with the transactions in the miner's order: the
+// local ones first, then by tip while honouring the nonces.
+func TestBuildBlockGolden(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	chain := newBuilderTestChain(t, keys)
+	defer chain.Stop()
+
+	addr := func(i int) common.Address { return crypto.PubkeyToAddress(keys[i].PublicKey) }
+	snapshot := &PoolSnapshot{
+		Locals: map[common.Address]types.Transactions{
+			addr(2): {gweiTransaction(0, 2, keys[2]), gweiTransaction(1, 2, keys[2])},
+		},
+		Remotes: map[common.Address]types.Transactions{
+			addr(0): {gweiTransaction(0, 5, keys[0]), gweiTransaction(1, 3, keys[0])},
+			addr(1): {gweiTransaction(0, 4, keys[1]), gweiTransaction(1, 4, keys[1])},
+		},
+	}
+	parent := chain.CurrentBlock()
+	config := &BuildConfig{Time: parent.Time() + 3, GasCeil: 10000000}
+
+	block, receipts, err := BuildBlock(chain, parent, snapshot, config)
+	if err != nil {
+		t.Fatalf("failed to build block: %v", err)
+	}
+	golden := []struct {
+		account int
+		nonce   uint64
+	}{{2, 0}, {2, 1}, {0, 0}, {1, 0}, {1, 1}, {0, 1}}
+
+	signer := types.MakeSigner(params.TestChainConfig, block.Number())
+	if len(block.Transactions()) != len(golden) || len(receipts) != len(golden) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(block.Transactions()), len(golden))
+	}
+	for i, tx := range block.Transactions() {
+		from, _ := types.Sender(signer, tx)
+		if from != addr(golden[i].account) || tx.Nonce() != golden[i].nonce {
+			t.Errorf("transaction %d mismatch: have %x/%d, want %x/%d", i, from, tx.Nonce(), addr(golden[i].account), golden[i].nonce)
+		}
+	}
+	if block.Time() != config.Time {
+		t.Errorf("timestamp mismatch: have %d, want %d", block.Time(), config.Time)
+	}
+	// The snapshot is left intact for further builds
+	if len(snapshot.Remotes[addr(0)]) != 2 || len(snapshot.Locals[addr(2)]) != 2 {
+		t.Errorf("snapshot modified by the build")
+	}
+}
+
+// Tests that the same snapshot builds the same block across runs, whatever the
+// time the transactions paying the same tip were first seen.
+func TestBuildBlockReproducible(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 8)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	chain := newBuilderTestChain(t, keys)
+	defer chain.Stop()
+
+	parent := chain.CurrentBlock()
+	config := &BuildConfig{Time: parent.Time() + 3, GasCeil: 10000000, Seed: common.HexToHash("0x01")}
+
+	build := func(order []int) *types.Block {
+		snapshot := &PoolSnapshot{Remotes: make(map[common.Address]types.Transactions)}
+		for _, i := range order {
+			snapshot.Remotes[crypto.PubkeyToAddress(keys[i].PublicKey)] = types.Transactions{gweiTransaction(0, 2, keys[i])}
+		}
+		block, _, err := BuildBlock(chain, parent, snapshot, config)
+		if err != nil {
+			t.Fatalf("failed to build block: %v", err)
+		}
+		return block
+	}
+	want := build([]int{0, 1, 2, 3, 4, 5, 6, 7})
+	if len(want.Transactions()) != len(keys) {
+		t.Fatalf("transaction count mismatch: have %d, want %d", len(want.Transactions()), len(keys))
+	}
+	for i := 0; i < 4; i++ {
+		if have := build([]int{7, 6, 5, 4, 3, 2, 1, 0}); have.Hash() != want.Hash() {
+			t.Fatalf("run %d: block mismatch: have %x, want %x", i, have.Hash(), want.Hash())
+		}
+	}
+}
+
+func TestBuildBlockTimestamp(t *testing.T) {
+	chain := newBuilderTestChain(t, nil)
+	defer chain.Stop()
+
+	parent := chain.CurrentBlock()
+	if _, _, err := BuildBlock(chain, parent, &PoolSnapshot{}, &BuildConfig{Time: parent.Time()}); !errors.Is(err, errBuildTimestamp) {
+		t.Fatalf("error mismatch: have %v, want %v", err, errBuildTimestamp)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// receiptOverhead is the approximate memory held by a receipt besides its logs:
+// the struct with its bloom and the slice entries referencing it.
+const receiptOverhead = 512
+
+// ErrBlockMemoryCap is returned if the receipts and logs of a block being processed
+// exceed the configured memory cap. The block isn't deemed bad, the import being
+// aborted to keep the node from running out of memory.
+var ErrBlockMemoryCap = errors.New("block processing memory cap exceeded")
+
+var (
+	blockMemoryGauge  = metrics.NewRegisteredGauge("chain/blockmem/memory", nil)
+	blockSpilledGauge = metrics.NewRegisteredGauge("chain/blockmem/spilled", nil)
+)
+
+// spillLogs enables the accounting of the logs of the state a block is processed
+// on, and the spilling of their data past the configured memory allowance.
+func (bc *BlockChain) spillLogs(statedb *state.StateDB) {
+	if bc.cacheConfig.BlockMemoryLimit == 0 && bc.cacheConfig.BlockMemoryCap == 0 {
+		return
+	}
+	limit := uint64(math.MaxUint64)
+	if bc.cacheConfig.BlockMemoryLimit > 0 {
+		limit = uint64(bc.cacheConfig.BlockMemoryLimit) * 1024 * 1024
+	}
+	statedb.SpillLogs(bc.cacheConfig.BlockSpillDir, limit)
+}
+
+// checkBlockMemory checks the memory held by the receipts and logs of the block
+// being processed against the configured cap.
+func (bc *BlockChain) checkBlockMemory(statedb *state.StateDB, receipts int) error {
+	if bc == nil || (bc.cacheConfig.BlockMemoryLimit == 0 && bc.cacheConfig.BlockMemoryCap == 0) {
+		return nil
+	}
+	memory, spilled := statedb.LogsMemory()
+	memory += uint64(receipts) * receiptOverhead
+
+	blockMemoryGauge.Update(int64(memory))
+	blockSpilledGauge.Update(int64(spilled))
+
+	if limit := uint64(bc.cacheConfig.BlockMemoryCap) * 1024 * 1024; limit > 0 && memory > limit {
+		return fmt.Errorf("%w: %v held, %v spilled, cap %v", ErrBlockMemoryCap,
+			common.StorageSize(memory), common.StorageSize(spilled), common.StorageSize(limit))
+	}
+	return nil
+}
+
+// spilledReceipts is the list of the receipts of a block with spilled logs, the
+// data of which is loaded one receipt at a time to derive the receipts root.
+type spilledReceipts struct {
+	receipts types.Receipts
+	statedb  *state.StateDB
+	err      error // First failure to load the spilled data
+}
+
+// Len implements types.DerivableList.
+func (rs *spilledReceipts) Len() int {
+	return len(rs.receipts)
+}
+
+// EncodeIndex implements types.DerivableList, encoding the receipt along with the
+// data of its spilled logs, dropped once encoded.
+func (rs *spilledReceipts) EncodeIndex(i int, w *bytes.Buffer) {
+	receipt := *rs.receipts[i]
+	receipt.Logs = make([]*types.Log, len(rs.receipts[i].Logs))
+	for j, l := range rs.receipts[i].Logs {
+		cpy := *l
+		data, err := rs.statedb.LogData(l)
+		if err != nil && rs.err == nil {
+			rs.err = err
+		}
+		cpy.Data = data
+		receipt.Logs[j] = &cpy
+	}
+	types.Receipts{&receipt}.EncodeIndex(0, w)
+}
+
+// deriveSpilledReceiptSha derives the receipts root of receipts with spilled logs.
+func deriveSpilledReceiptSha(receipts types.Receipts, statedb *state.StateDB) (common.Hash, error) {
+	list := &spilledReceipts{receipts: receipts, statedb: statedb}
+	root := types.DeriveSha(list, trie.NewStackTrie(nil))
+	if list.err != nil {
+		return common.Hash{}, fmt.Errorf("failed to load spilled logs: %w", list.err)
+	}
+	return root, nil
+}
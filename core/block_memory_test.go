@@ -0,0 +1,71 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the receipts root is derived from the spilled log data, and that the
+// memory cap aborts the processing.
+func TestBlockMemorySpill(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SpillLogs(t.TempDir(), 0)
+
+	var receipts, expected types.Receipts
+	for i := 0; i < 3; i++ {
+		txHash := common.BigToHash(common.Big1)
+		txHash[0] = byte(i)
+		statedb.Prepare(txHash, i)
+
+		receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: uint64(i + 1)}
+		want := *receipt
+		for j := 0; j < 2; j++ {
+			l := &types.Log{Address: common.Address{byte(i)}, Topics: []common.Hash{{byte(j)}}, Data: []byte{byte(i), byte(j), 0xff}}
+			cpy := *l
+			want.Logs = append(want.Logs, &cpy)
+			statedb.AddLog(l)
+		}
+		receipt.Logs = statedb.GetLogs(txHash, common.Hash{})
+		receipts, expected = append(receipts, receipt), append(expected, &want)
+	}
+	if !statedb.LogsSpilled() {
+		t.Fatalf("logs not spilled")
+	}
+	root, err := deriveSpilledReceiptSha(receipts, statedb)
+	if err != nil {
+		t.Fatalf("failed to derive receipts root: %v", err)
+	}
+	if want := types.DeriveSha(expected, trie.NewStackTrie(nil)); root != want {
+		t.Errorf("receipts root mismatch: have %x, want %x", root, want)
+	}
+	// The memory cap counts the held logs and receipts only
+	bc := &BlockChain{cacheConfig: &CacheConfig{BlockMemoryCap: 1}}
+	if err := bc.checkBlockMemory(statedb, len(receipts)); err != nil {
+		t.Errorf("spilled logs exceed the cap: %v", err)
+	}
+	if err := bc.checkBlockMemory(statedb, 4096); !errors.Is(err, ErrBlockMemoryCap) {
+		t.Errorf("cap not enforced: have %v, want %v", err, ErrBlockMemoryCap)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -63,7 +64,7 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
 		return fmt.Errorf("uncle root hash mismatch: have %x, want %x", hash, header.UncleHash)
 	}
-	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
+	if hash := types.DeriveShaParallel(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
 		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
 	}
 	if !v.bc.HasBlockAndState(block.ParentHash(), block.NumberU64()-1) {
@@ -82,7 +83,7 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 func (v *BlockValidator) ValidateState(block *types.Block, statedb *state.StateDB, receipts types.Receipts, usedGas uint64) (err error) {
 	header := block.Header()
 	if block.GasUsed() != usedGas {
-		return fmt.Errorf("invalid gas used (remote: %d local: %d)", block.GasUsed(), usedGas)
+		return &MismatchError{Kind: BadBlockGasUsed, Remote: fmt.Sprint(block.GasUsed()), Local: fmt.Sprint(usedGas)}
 	}
 	// Validate the received block's bloom with the one derived from the generated receipts.
 	// For valid blocks this should always validate to true.
@@ -92,7 +93,7 @@ func (v *BlockValidator) ValidateState(block *types.Block, statedb *state.StateD
 	defer func() {
 		waitCreateBloom.Wait()
 		if rbloom != header.Bloom {
-			err = fmt.Errorf("invalid bloom (remote: %x  local: %x)", header.Bloom, rbloom)
+			err = &MismatchError{Kind: BadBlockBloom, Remote: fmt.Sprintf("%x", header.Bloom), Local: fmt.Sprintf("%x", rbloom)}
 		}
 	}()
 	go func() {
@@ -101,14 +102,21 @@ func (v *BlockValidator) ValidateState(block *types.Block, statedb *state.StateD
 	}()
 
 	// Tre receipt Trie's root (R = (Tr [[H1, R1], ... [Hn, Rn]]))
-	receiptSha := types.DeriveSha(receipts, trie.NewStackTrie(nil))
+	var receiptSha common.Hash
+	if statedb.LogsSpilled() {
+		if receiptSha, err = deriveSpilledReceiptSha(receipts, statedb); err != nil {
+			return err
+		}
+	} else {
+		receiptSha = types.DeriveShaParallel(receipts, trie.NewStackTrie(nil))
+	}
 	if receiptSha != header.ReceiptHash {
-		return fmt.Errorf("invalid receipt root hash (remote: %x local: %x)", header.ReceiptHash, receiptSha)
+		return &MismatchError{Kind: BadBlockReceiptRoot, Remote: fmt.Sprintf("%x", header.ReceiptHash), Local: fmt.Sprintf("%x", receiptSha)}
 	}
 	// Validate the state root against the received state root and throw
 	// an error if they don't match.
 	if root := statedb.IntermediateRoot(v.config.IsEIP158(header.Number)); header.Root != root {
-		return fmt.Errorf("invalid merkle root (remote: %x local: %x)", header.Root, root)
+		return &MismatchError{Kind: BadBlockStateRoot, Remote: fmt.Sprintf("%x", header.Root), Local: fmt.Sprintf("%x", root)}
 	}
 	return nil
 }
@@ -42,6 +42,7 @@ import (
 	"github.com/ethereum/go-ethereum/internal/syncx"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/node/hooks"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
 	lru "github.com/hashicorp/golang-lru"
@@ -130,6 +131,11 @@ type CacheConfig struct {
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
+	WitnessDepth        uint64        // Number of recent blocks to retain garbage collected trie nodes for (pruned nodes only)
+	VersionCacheDepth   uint64        // Number of recent blocks to serve RPC state reads for from the version cache
+	BlockMemoryLimit    int           // Memory allowance (MB) of the logs of a block being processed, past which their data is spilled to disk
+	BlockMemoryCap      int           // Memory limit (MB) of the receipts and logs of a block being processed, past which the import is aborted
+	BlockSpillDir       string        // Directory to spill the log data into, the system temporary directory if empty
 
 	SnapshotWait bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
@@ -167,6 +173,9 @@ type BlockChain struct {
 	triegc *prque.Prque   // Priority queue mapping block numbers to tries to gc
 	gcproc time.Duration  // Accumulates canonical block processing for trie dumping
 
+	witness  *proofWitness       // Store of garbage collected trie nodes for recent state proofs
+	versions *state.VersionCache // Multi-version cache of the recent states served to RPC
+
 	// txLookupLimit is the maximum number of blocks from head whose tx indices
 	// are reserved:
 	//  * 0:   means no limit and regenerate any missing indexes
@@ -181,6 +190,7 @@ type BlockChain struct {
 	chainHeadFeed event.Feed
 	logsFeed      event.Feed
 	blockProcFeed event.Feed
+	reorgFeed     event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
 
@@ -190,6 +200,9 @@ type BlockChain struct {
 
 	currentBlock     atomic.Value // Current head of the block chain
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
+	lastBadBlock     atomic.Value // Report of the last bad block hit, *BadBlockReport
+
+	governor *Governor // Throttling of the heavy rpc queries while the block import lags
 
 	stateCache    state.Database // State database to reuse between imports (contains state cache)
 	bodyCache     *lru.Cache     // Cache for the most recent block bodies
@@ -248,6 +261,21 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		futureBlocks:   futureBlocks,
 		engine:         engine,
 		vmConfig:       vmConfig,
+		governor:       newGovernor(),
+	}
+	if !cacheConfig.TrieDirtyDisabled && cacheConfig.WitnessDepth > 0 {
+		if cacheConfig.WitnessDepth <= TriesInMemory {
+			log.Warn("Proof witness depth within in-memory tries, ignoring", "depth", cacheConfig.WitnessDepth, "tries", TriesInMemory)
+		} else {
+			bc.witness = newProofWitness(db, cacheConfig.WitnessDepth)
+			bc.stateCache.TrieDB().SetGCHook(bc.witness.collect)
+		}
+	}
+	if cacheConfig.VersionCacheDepth > 0 {
+		bc.versions = state.NewVersionCache(cacheConfig.VersionCacheDepth)
+	}
+	if cacheConfig.BlockSpillDir != "" {
+		state.CleanLogSpills(cacheConfig.BlockSpillDir)
 	}
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
@@ -503,6 +531,9 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 	// Track the block number of the requested root hash
 	var rootNumber uint64 // (no root == always 0)
 
+	// Track the head to rewind from, for dropping the indexes above the new head
+	oldHead := bc.CurrentBlock().NumberU64()
+
 	// Retrieve the last pivot block to short circuit rollbacks beyond it and the
 	// current freezer limit to start nuking id underflown
 	pivot := rawdb.ReadLastPivotNumber(bc.db)
@@ -620,6 +651,11 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 		log.Warn("Rewinding blockchain", "target", head)
 		bc.hc.SetHead(head, updateFn, delFn)
 	}
+	if bc.witness != nil {
+		if err := bc.witness.rewind(bc.CurrentBlock().NumberU64(), oldHead); err != nil {
+			log.Error("Failed to rewind proof witness", "err", err)
+		}
+	}
 	// Clear out any stale content from the caches
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
@@ -731,6 +767,13 @@ func (bc *BlockChain) ExportN(w io.Writer, first uint64, last uint64) error {
 	return nil
 }
 
+// writeTxLookupEntries stores the hash and the sender and nonce based lookups of
+// the transactions of a block.
+func (bc *BlockChain) writeTxLookupEntries(db ethdb.KeyValueWriter, block *types.Block) {
+	rawdb.WriteTxLookupEntriesByBlock(db, block)
+	rawdb.WriteTxSenderLookupEntriesByBlock(db, block, types.MakeSigner(bc.chainConfig, block.Number()))
+}
+
 // writeHeadBlock injects a new head block into the current block chain. This method
 // assumes that the block is indeed a true head. It will also reset the head
 // header and the head fast sync block to this very same block if they are older
@@ -744,7 +787,7 @@ func (bc *BlockChain) writeHeadBlock(block *types.Block) {
 	// Add the block to the canonical chain number scheme and mark as the head
 	batch := bc.db.NewBatch()
 	rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
-	rawdb.WriteTxLookupEntriesByBlock(batch, block)
+	bc.writeTxLookupEntries(batch, block)
 	rawdb.WriteHeadBlockHash(batch, block.Hash())
 
 	// If the block is better than our head or is on a different chain, force update heads
@@ -828,6 +871,11 @@ func (bc *BlockChain) Stop() {
 		for !bc.triegc.Empty() {
 			triedb.Dereference(bc.triegc.PopItem().(common.Hash))
 		}
+		if bc.witness != nil {
+			if err := bc.witness.commit(bc.CurrentBlock().NumberU64()); err != nil {
+				log.Error("Failed to write proof witness", "err", err)
+			}
+		}
 		if size, _ := triedb.Size(); size != 0 {
 			log.Error("Dangling trie nodes after full cleanup")
 		}
@@ -848,6 +896,39 @@ func (bc *BlockChain) StopInsert() {
 	atomic.StoreInt32(&bc.procInterrupt, 1)
 }
 
+// Checkpoint pauses the chain modifications, flushes the state of the current
+// head to disk and runs fn, so that the database content fn observes is a
+// consistent point with the head state fully persisted. Chain insertion is held
+// until fn returns, which is expected to only take a database snapshot or
+// iterator and to return quickly.
+func (bc *BlockChain) Checkpoint(fn func(head *types.Block) error) error {
+	if !bc.chainmu.TryLock() {
+		return errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	// Wait for the async state commit to finish, then flush the head state
+	triedb := bc.stateCache.TrieDB()
+	triedb.FlushLatch.Wait()
+
+	head := bc.CurrentBlock()
+	if err := triedb.Commit(head.Root(), false, nil); err != nil {
+		return err
+	}
+	return fn(head)
+}
+
+// EnableBlockSTM turns on the experimental Block-STM executor, which executes
+// the processed blocks again in the background and checks its results against
+// the serial ones, dumping the divergences into dumpDir. It's a research mode,
+// to be enabled before the chain processes any block.
+func (bc *BlockChain) EnableBlockSTM(dumpDir string) {
+	if processor, ok := bc.processor.(*StateProcessor); ok {
+		processor.stm = newBlockSTM(bc.chainConfig, bc, dumpDir)
+		log.Warn("Enabled experimental Block-STM cross-validation", "dumps", dumpDir)
+	}
+}
+
 // insertStopped returns true after StopInsert has been called.
 func (bc *BlockChain) insertStopped() bool {
 	return atomic.LoadInt32(&bc.procInterrupt) == 1
@@ -994,9 +1075,9 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 		var batch = bc.db.NewBatch()
 		for _, block := range blockChain {
 			if bc.txLookupLimit == 0 || ancientLimit <= bc.txLookupLimit || block.NumberU64() >= ancientLimit-bc.txLookupLimit {
-				rawdb.WriteTxLookupEntriesByBlock(batch, block)
+				bc.writeTxLookupEntries(batch, block)
 			} else if rawdb.ReadTxIndexTail(bc.db) != nil {
-				rawdb.WriteTxLookupEntriesByBlock(batch, block)
+				bc.writeTxLookupEntries(batch, block)
 			}
 			stats.processed++
 		}
@@ -1080,7 +1161,7 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 			// Write all the data out into the database
 			rawdb.WriteBody(batch, block.Hash(), block.NumberU64(), block.Body())
 			rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receiptChain[i])
-			rawdb.WriteTxLookupEntriesByBlock(batch, block) // Always write tx indices for live blocks, we assume they are needed
+			bc.writeTxLookupEntries(batch, block) // Always write tx indices for live blocks, we assume they are needed
 
 			// Write everything belongs to the blocks into the database. So that
 			// we can ensure all components of body is completed(body, receipts,
@@ -1199,6 +1280,10 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	if bc.insertStopped() {
 		return NonStatTy, errInsertionInterrupted
 	}
+	// Load back the data of the logs spilled while processing, to store and emit it
+	if err := state.RestoreLogs(); err != nil {
+		return NonStatTy, err
+	}
 
 	// Calculate the total difficulty of the block
 	ptd := bc.GetTd(block.ParentHash(), block.NumberU64()-1)
@@ -1214,6 +1299,10 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	//
 	// Note all the components of block(td, hash->number map, header, body, receipts)
 	// should be written atomically. BlockBatch is used for containing all components.
+	// The root was computed by the processing, so the state growth is complete
+	growth := state.Growth()
+	supply := bc.supplyDelta(block, receipts, state)
+
 	var waitBlockBatchWrite sync.WaitGroup
 	waitBlockBatchWrite.Add(1)
 	go func() {
@@ -1222,6 +1311,8 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 		rawdb.WriteBlock(blockBatch, block)
 		rawdb.WriteReceipts(blockBatch, block.Hash(), block.NumberU64(), receipts)
 		rawdb.WritePreimages(blockBatch, state.Preimages())
+		rawdb.WriteStateGrowth(blockBatch, block.Hash(), block.NumberU64(), growth)
+		rawdb.WriteSupplyDelta(blockBatch, block.Hash(), block.NumberU64(), supply)
 		if err := blockBatch.Write(); err != nil {
 			log.Crit("Failed to write block into disk", "err", err)
 		}
@@ -1291,6 +1382,11 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 					triedb.Dereference(root.(common.Hash))
 				}
 			}
+			if bc.witness != nil {
+				if err := bc.witness.commit(blockNumber); err != nil {
+					log.Error("Failed to write proof witness", "number", blockNumber, "err", err)
+				}
+			}
 		}
 	}
 
@@ -1332,10 +1428,14 @@ func (bc *BlockChain) writeBlockWithState(block *types.Block, receipts []*types.
 	// Set new head.
 	if status == CanonStatTy {
 		bc.writeHeadBlock(block)
+		if bc.versions != nil {
+			bc.versions.Commit(block.NumberU64(), block.Hash(), block.ParentHash(), block.Root(), state)
+		}
 	}
 	bc.futureBlocks.Remove(block.Hash())
 
 	if status == CanonStatTy {
+		hooks.BlockImported(block, receipts)
 		bc.chainFeed.Send(ChainEvent{Block: block, Hash: block.Hash(), Logs: logs})
 		if len(logs) > 0 {
 			bc.logsFeed.Send(logs)
@@ -1436,6 +1536,8 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 	signer := types.MakeSigner(bc.chainConfig, chain[0].Number())
 	go senderCacher.recoverFromBlocks(signer, chain)
 
+	defer bc.governor.importIdle()
+
 	var (
 		stats     = insertStats{startTime: mclock.Now()}
 		lastCanon *types.Block
@@ -1529,7 +1631,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 	case err != nil && !errors.Is(err, ErrKnownBlock):
 		bc.futureBlocks.Remove(block.Hash())
 		stats.ignored += len(it.chain)
-		bc.reportBlock(block, nil, err)
+		bc.reportBlock(block, nil, BadBlockHeader, err)
 		return it.index, err
 	}
 	// No validation errors for the first block (or chain prefix skipped)
@@ -1552,7 +1654,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		}
 		// If the header is a banned one, straight out abort
 		if BadHashes[block.Hash()] {
-			bc.reportBlock(block, nil, ErrBannedHash)
+			bc.reportBlock(block, nil, BadBlockBanned, ErrBannedHash)
 			return it.index, ErrBannedHash
 		}
 		// If the block is known (in the middle of the chain), it's a special case for
@@ -1597,6 +1699,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 
 		// Retrieve the parent block and it's state to execute on top
 		start := time.Now()
+		bc.governor.importStarted(start)
 		parent := it.previous()
 		if parent == nil {
 			parent = bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
@@ -1605,6 +1708,10 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		if err != nil {
 			return it.index, err
 		}
+		if bc.versions != nil {
+			statedb.RecordVersions()
+		}
+		bc.spillLogs(statedb)
 
 		// Enable prefetching to pull in trie node paths while processing transactions
 		statedb.StartPrefetcher("chain")
@@ -1631,8 +1738,14 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		// Process block using the parent state as reference point
 		substart := time.Now()
 		receipts, logs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig)
+		if errors.Is(err, ErrBlockMemoryCap) {
+			log.Error("Aborted import of block exceeding the memory cap", "number", block.Number(), "hash", block.Hash(),
+				"txs", len(block.Transactions()), "err", err)
+			atomic.StoreUint32(&followupInterrupt, 1)
+			return it.index, err
+		}
 		if err != nil {
-			bc.reportBlock(block, receipts, err)
+			bc.reportBlock(block, receipts, BadBlockProcessing, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
 			return it.index, err
 		}
@@ -1657,7 +1770,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		// Validate the state using the default validator
 		substart = time.Now()
 		if err := bc.validator.ValidateState(block, statedb, receipts, usedGas); err != nil {
-			bc.reportBlock(block, receipts, err)
+			bc.reportBlock(block, receipts, BadBlockProcessing, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
 			return it.index, err
 		}
@@ -1687,6 +1800,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, er
 		log.Info("metric", "method", "writeBlock", "hash", block.Header().Hash().String(), "number", block.Header().Number.Uint64(),
 			"cost", time.Since(substart)-statedb.AccountCommits-statedb.StorageCommits-statedb.SnapshotCommits)
 		blockInsertTimer.UpdateSince(start)
+		bc.governor.importDone(time.Since(start))
 
 		switch status {
 		case CanonStatTy:
@@ -1868,6 +1982,9 @@ func (bc *BlockChain) insertSideChain(block *types.Block, it *insertIterator) (i
 // potential missing transactions and post an event about them.
 func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	var (
+		oldHead = oldBlock.Header()
+		newHead = newBlock.Header()
+
 		newChain    types.Blocks
 		oldChain    types.Blocks
 		commonBlock *types.Block
@@ -1997,8 +2114,14 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	// Delete useless indexes right now which includes the non-canonical
 	// transaction indexes, canonical chain indexes which above the head.
 	indexesBatch := bc.db.NewBatch()
+	signer := types.LatestSigner(bc.chainConfig)
 	for _, tx := range types.TxDifference(deletedTxs, addedTxs) {
 		rawdb.DeleteTxLookupEntry(indexesBatch, tx.Hash())
+
+		// Keep the sender and nonce lookup if the new chain reused the nonce
+		if from, err := types.Sender(signer, tx); err == nil && rawdb.ReadTxSenderLookupEntry(bc.db, from, tx.Nonce()) == tx.Hash() {
+			rawdb.DeleteTxSenderLookupEntry(indexesBatch, from, tx.Nonce())
+		}
 	}
 	// Delete any canonical number assignments above the new head
 	number := bc.CurrentBlock().NumberU64()
@@ -2012,6 +2135,12 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	if err := indexesBatch.Write(); err != nil {
 		log.Crit("Failed to delete useless indexes", "err", err)
 	}
+	// Drop the proof witness indexes of the reorged out blocks above the new head
+	if bc.witness != nil && newHead.Number.Uint64() < oldHead.Number.Uint64() {
+		if err := bc.witness.rewind(newHead.Number.Uint64(), oldHead.Number.Uint64()); err != nil {
+			log.Error("Failed to rewind proof witness", "err", err)
+		}
+	}
 	// If any logs need to be fired, do it now. In theory we could avoid creating
 	// this goroutine if there are no events to fire, but realistcally that only
 	// ever happens if we're reorging empty blocks, which will only happen on idle
@@ -2026,10 +2155,42 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		for i := len(oldChain) - 1; i >= 0; i-- {
 			bc.chainSideFeed.Send(ChainSideEvent{Block: oldChain[i]})
 		}
+		// The new head block is written by the caller, so its transactions are
+		// not collected above yet
+		if len(newChain) > 0 {
+			addedTxs = append(addedTxs, newChain[0].Transactions()...)
+		}
+		bc.reorgFeed.Send(newReorgEvent(oldHead, newHead, commonBlock.Header(), uint64(len(oldChain)), deletedTxs, addedTxs))
 	}
+	hooks.Reorg(oldHead, newHead, oldChain, newChain)
 	return nil
 }
 
+// newReorgEvent creates a reorg event, splitting the transactions of the dropped
+// blocks into the removed and the re-included ones.
+func newReorgEvent(oldHead, newHead, ancestor *types.Header, depth uint64, deletedTxs, addedTxs types.Transactions) ReorgEvent {
+	ev := ReorgEvent{
+		OldHead:    oldHead,
+		NewHead:    newHead,
+		Common:     ancestor,
+		Depth:      depth,
+		Removed:    []common.Hash{},
+		Reincluded: []common.Hash{},
+	}
+	added := make(map[common.Hash]struct{}, len(addedTxs))
+	for _, tx := range addedTxs {
+		added[tx.Hash()] = struct{}{}
+	}
+	for _, tx := range deletedTxs {
+		if _, ok := added[tx.Hash()]; ok {
+			ev.Reincluded = append(ev.Reincluded, tx.Hash())
+		} else {
+			ev.Removed = append(ev.Removed, tx.Hash())
+		}
+	}
+	return ev
+}
+
 // futureBlocksLoop processes the 'future block' queue.
 func (bc *BlockChain) futureBlocksLoop() {
 	defer bc.wg.Done()
@@ -2175,10 +2336,15 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 	}
 }
 
-// reportBlock logs a bad block error.
-func (bc *BlockChain) reportBlock(block *types.Block, receipts types.Receipts, err error) {
+// reportBlock logs a bad block error, the stage being the category of the errors
+// hit during the given import stage.
+func (bc *BlockChain) reportBlock(block *types.Block, receipts types.Receipts, stage BadBlockKind, err error) {
 	rawdb.WriteBadBlock(bc.db, block)
 
+	report := bc.newBadBlockReport(block, receipts, stage, err)
+	bc.lastBadBlock.Store(report)
+	badBlockCounters[report.Kind].Inc(1)
+
 	var receiptString string
 	for i, receipt := range receipts {
 		receiptString += fmt.Sprintf("\t %d: cumulative: %v gas: %v contract: %v status: %v tx: %v logs: %v bloom: %x state: %x\n",
@@ -2193,9 +2359,10 @@ Number: %v
 Hash: 0x%x
 %v
 
+Kind: %v
 Error: %v
 ##############################
-`, bc.chainConfig, block.Number(), block.Hash(), receiptString, err))
+`, bc.chainConfig, block.Number(), block.Hash(), receiptString, report.Kind, err))
 }
 
 // InsertHeaderChain attempts to insert the given header chain in to the local
@@ -302,6 +302,20 @@ func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
 	return state.New(root, bc.stateCache, bc.snaps)
 }
 
+// VersionedStateAt returns a read-only state of the given block, served from the
+// multi-version cache of the recent states if enabled. The state may be modified,
+// but must not be committed.
+func (bc *BlockChain) VersionedStateAt(header *types.Header) (*state.StateDB, error) {
+	statedb, err := state.New(header.Root, bc.stateCache, bc.snaps)
+	if err != nil {
+		return nil, err
+	}
+	if bc.versions != nil {
+		statedb.UseVersionCache(bc.versions, header.Number.Uint64())
+	}
+	return statedb, nil
+}
+
 // Config retrieves the chain's fork configuration.
 func (bc *BlockChain) Config() *params.ChainConfig { return bc.chainConfig }
 
@@ -323,6 +337,12 @@ func (bc *BlockChain) Processor() Processor {
 	return bc.processor
 }
 
+// Governor returns the throttling of the heavy rpc queries while the block import
+// lags.
+func (bc *BlockChain) Governor() *Governor {
+	return bc.governor
+}
+
 // StateCache returns the caching database underpinning the blockchain instance.
 func (bc *BlockChain) StateCache() state.Database {
 	return bc.stateCache
@@ -375,6 +395,11 @@ func (bc *BlockChain) SubscribeChainSideEvent(ch chan<- ChainSideEvent) event.Su
 	return bc.scope.Track(bc.chainSideFeed.Subscribe(ch))
 }
 
+// SubscribeReorgEvent registers a subscription of ReorgEvent.
+func (bc *BlockChain) SubscribeReorgEvent(ch chan<- ReorgEvent) event.Subscription {
+	return bc.scope.Track(bc.reorgFeed.Subscribe(ch))
+}
+
 // SubscribeLogsEvent registers a subscription of []*types.Log.
 func (bc *BlockChain) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return bc.scope.Track(bc.logsFeed.Subscribe(ch))
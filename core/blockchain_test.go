@@ -159,12 +159,12 @@ func testBlockChainImport(chain types.Blocks, blockchain *BlockChain) error {
 		}
 		receipts, _, usedGas, err := blockchain.processor.Process(block, statedb, vm.Config{})
 		if err != nil {
-			blockchain.reportBlock(block, receipts, err)
+			blockchain.reportBlock(block, receipts, BadBlockProcessing, err)
 			return err
 		}
 		err = blockchain.validator.ValidateState(block, statedb, receipts, usedGas)
 		if err != nil {
-			blockchain.reportBlock(block, receipts, err)
+			blockchain.reportBlock(block, receipts, BadBlockProcessing, err)
 			return err
 		}
 
@@ -948,6 +948,9 @@ func TestChainTxReorgs(t *testing.T) {
 		if rcpt, _, _, _ := rawdb.ReadReceipt(db, tx.Hash(), blockchain.Config()); rcpt != nil {
 			t.Errorf("drop %d: receipt %v found while shouldn't have been", i, rcpt)
 		}
+		if hash := rawdb.ReadTxSenderLookupEntry(db, addr2, tx.Nonce()); hash != (common.Hash{}) {
+			t.Errorf("drop %d: sender lookup %x found while shouldn't have been", i, hash)
+		}
 	}
 	// added tx
 	for i, tx := range (types.Transactions{pastAdd, freshAdd, futureAdd}) {
@@ -957,6 +960,9 @@ func TestChainTxReorgs(t *testing.T) {
 		if rcpt, _, _, _ := rawdb.ReadReceipt(db, tx.Hash(), blockchain.Config()); rcpt == nil {
 			t.Errorf("add %d: expected receipt to be found", i)
 		}
+		if hash := rawdb.ReadTxSenderLookupEntry(db, addr3, tx.Nonce()); hash != tx.Hash() {
+			t.Errorf("add %d: sender lookup mismatch: have %x, want %x", i, hash, tx.Hash())
+		}
 	}
 	// shared tx
 	for i, tx := range (types.Transactions{postponed, swapped}) {
@@ -966,6 +972,9 @@ func TestChainTxReorgs(t *testing.T) {
 		if rcpt, _, _, _ := rawdb.ReadReceipt(db, tx.Hash(), blockchain.Config()); rcpt == nil {
 			t.Errorf("share %d: expected receipt to be found", i)
 		}
+		if hash := rawdb.ReadTxSenderLookupEntry(db, addr1, tx.Nonce()); hash != tx.Hash() {
+			t.Errorf("share %d: sender lookup mismatch: have %x, want %x", i, hash, tx.Hash())
+		}
 	}
 }
 
@@ -3336,3 +3345,22 @@ func TestEIP1559Transition(t *testing.T) {
 		t.Fatalf("sender balance incorrect: expected %d, got %d", expected, actual)
 	}
 }
+
+// Tests that the transactions of the dropped blocks are split correctly into the
+// removed and the re-included ones.
+func TestReorgEventTxDiff(t *testing.T) {
+	var (
+		tx1 = types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(1), nil)
+		tx2 = types.NewTransaction(1, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(1), nil)
+		tx3 = types.NewTransaction(2, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(1), nil)
+	)
+	head := &types.Header{Number: big.NewInt(10)}
+	ev := newReorgEvent(head, head, head, 1, types.Transactions{tx1, tx2}, types.Transactions{tx2, tx3})
+
+	if len(ev.Removed) != 1 || ev.Removed[0] != tx1.Hash() {
+		t.Errorf("removed transactions mismatch: have %v, want [%x]", ev.Removed, tx1.Hash())
+	}
+	if len(ev.Reincluded) != 1 || ev.Reincluded[0] != tx2.Hash() {
+		t.Errorf("re-included transactions mismatch: have %v, want [%x]", ev.Reincluded, tx2.Hash())
+	}
+}
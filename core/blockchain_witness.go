@@ -0,0 +1,192 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	witnessWriteMeter = metrics.NewRegisteredMeter("chain/witness/write", nil)
+	witnessPruneMeter = metrics.NewRegisteredMeter("chain/witness/prune", nil)
+
+	// errWitnessUnavailable is returned when the state of a block is neither
+	// available in the trie database nor covered by the proof witness store.
+	errWitnessUnavailable = errors.New("state not retained by proof witness store")
+)
+
+// proofWitness retains the trie nodes a pruned node garbage collects from its
+// in-memory trie database, so that the state of the recent blocks can still
+// be resolved (and proven) after it has been dropped from memory.
+//
+// Any node of a historical state is either still referenced in memory, already
+// flushed to disk, or was dropped by garbage collection at some later block.
+// Keeping the dropped ones for depth blocks is therefore enough to reconstruct
+// every state within depth blocks of the head.
+type proofWitness struct {
+	db    ethdb.Database
+	depth uint64
+
+	pending map[common.Hash][]byte // Nodes collected since the last commit
+	lock    sync.RWMutex
+}
+
+// newProofWitness creates a witness store retaining nodes for depth blocks.
+func newProofWitness(db ethdb.Database, depth uint64) *proofWitness {
+	return &proofWitness{
+		db:      db,
+		depth:   depth,
+		pending: make(map[common.Hash][]byte),
+	}
+}
+
+// collect is the garbage collection hook of the trie database, stashing the
+// dropped node until the next commit.
+func (w *proofWitness) collect(hash common.Hash, blob []byte) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.pending[hash] = common.CopyBytes(blob)
+}
+
+// commit persists the nodes collected while processing the given block and
+// drops the ones which fell out of the retention window.
+func (w *proofWitness) commit(number uint64) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	batch := w.db.NewBatch()
+	if len(w.pending) > 0 {
+		// A reorg may revisit the same height, extend the existing index
+		hashes := rawdb.ReadWitnessIndex(w.db, number)
+		for hash, blob := range w.pending {
+			rawdb.WriteWitnessNode(batch, hash, number, blob)
+			hashes = append(hashes, hash)
+		}
+		rawdb.WriteWitnessIndex(batch, number, hashes)
+		witnessWriteMeter.Mark(int64(len(w.pending)))
+	}
+	if number > w.depth {
+		stale := number - w.depth - 1
+		for _, hash := range rawdb.ReadWitnessIndex(w.db, stale) {
+			// Nodes collected again later carry a newer expiry, keep those
+			if _, expiry := rawdb.ReadWitnessNode(w.db, hash); expiry <= stale {
+				rawdb.DeleteWitnessNode(batch, hash)
+				witnessPruneMeter.Mark(1)
+			}
+		}
+		rawdb.DeleteWitnessIndex(batch, stale)
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	w.pending = make(map[common.Hash][]byte)
+	return nil
+}
+
+// rewind drops the indexes of the blocks above the new head, e.g. reorged out or
+// rewound by SetHead, up to the old head. Their nodes were garbage collected from
+// the state of older blocks, which may still be canonical, so they are indexed
+// again at the new head instead, expiring once it falls out of the window.
+func (w *proofWitness) rewind(head, old uint64) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	var (
+		batch  = w.db.NewBatch()
+		hashes = rawdb.ReadWitnessIndex(w.db, head)
+		moved  = len(hashes)
+	)
+	for number := head + 1; number <= old; number++ {
+		for _, hash := range rawdb.ReadWitnessIndex(w.db, number) {
+			// Nodes collected again past the old head keep their expiry
+			if blob, expiry := rawdb.ReadWitnessNode(w.db, hash); len(blob) > 0 && expiry > head && expiry <= old {
+				rawdb.WriteWitnessNode(batch, hash, head, blob)
+				hashes = append(hashes, hash)
+			}
+		}
+		rawdb.DeleteWitnessIndex(batch, number)
+	}
+	if len(hashes) > moved {
+		rawdb.WriteWitnessIndex(batch, head, hashes)
+	}
+	return batch.Write()
+}
+
+// node retrieves a retained trie node, either still pending or persisted.
+func (w *proofWitness) node(hash common.Hash) []byte {
+	w.lock.RLock()
+	blob, ok := w.pending[hash]
+	w.lock.RUnlock()
+
+	if ok {
+		return blob
+	}
+	blob, _ = rawdb.ReadWitnessNode(w.db, hash)
+	return blob
+}
+
+// witnessDatabase is a database wrapper resolving trie nodes from the live trie
+// database first, falling back to the proof witness store.
+type witnessDatabase struct {
+	ethdb.Database
+
+	live    *trie.Database
+	witness *proofWitness
+}
+
+// Get retrieves the given key, treating hash sized keys as trie nodes.
+func (db *witnessDatabase) Get(key []byte) ([]byte, error) {
+	if len(key) != common.HashLength {
+		return db.Database.Get(key)
+	}
+	hash := common.BytesToHash(key)
+	if blob, err := db.live.Node(hash); err == nil {
+		return blob, nil
+	}
+	if blob := db.witness.node(hash); len(blob) > 0 {
+		return blob, nil
+	}
+	return db.Database.Get(key)
+}
+
+// WitnessStateAt returns a state database for the given header, backed by the
+// nodes retained by the proof witness store. It is meant for serving state of
+// recent blocks which were already garbage collected on pruned nodes.
+func (bc *BlockChain) WitnessStateAt(header *types.Header) (*state.StateDB, error) {
+	if bc.witness == nil {
+		return nil, errWitnessUnavailable
+	}
+	if head := bc.CurrentBlock().NumberU64(); head > header.Number.Uint64()+bc.witness.depth {
+		return nil, errWitnessUnavailable
+	}
+	db := &witnessDatabase{
+		Database: bc.db,
+		live:     bc.stateCache.TrieDB(),
+		witness:  bc.witness,
+	}
+	return state.New(header.Root, state.NewDatabase(db), nil)
+}
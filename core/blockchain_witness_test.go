@@ -0,0 +1,181 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that a pruned node retaining proof witnesses can still resolve and
+// prove the state of garbage collected blocks within the configured depth.
+func TestProofWitness(t *testing.T) {
+	var (
+		engine  = ethash.NewFaker()
+		gendb   = rawdb.NewMemoryDatabase()
+		genesis = (&Genesis{BaseFee: big.NewInt(params.InitialBaseFee)}).MustCommit(gendb)
+	)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, engine, gendb, 3*TriesInMemory, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{byte(i), byte(i >> 8)})
+	})
+	db := rawdb.NewMemoryDatabase()
+	(&Genesis{BaseFee: big.NewInt(params.InitialBaseFee)}).MustCommit(db)
+
+	config := *defaultCacheConfig
+	config.WitnessDepth = 2 * TriesInMemory
+
+	chain, err := NewBlockChain(db, &config, params.TestChainConfig, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// A block garbage collected from memory, but within the witness depth
+	block := blocks[len(blocks)-1-TriesInMemory-TriesInMemory/2]
+	if _, err := chain.StateAt(block.Root()); err == nil {
+		t.Fatalf("block %d: state unexpectedly available without witness", block.NumberU64())
+	}
+	have, err := chain.WitnessStateAt(block.Header())
+	if err != nil {
+		t.Fatalf("block %d: failed to open witness state: %v", block.NumberU64(), err)
+	}
+	want, err := state.New(block.Root(), state.NewDatabase(gendb), nil)
+	if err != nil {
+		t.Fatalf("block %d: failed to open reference state: %v", block.NumberU64(), err)
+	}
+	for _, addr := range []common.Address{block.Coinbase(), blocks[0].Coinbase(), {0xff}} {
+		haveProof, err := have.GetProof(addr)
+		if err != nil {
+			t.Fatalf("block %d: failed to prove %x: %v", block.NumberU64(), addr, err)
+		}
+		wantProof, _ := want.GetProof(addr)
+		if !reflect.DeepEqual(haveProof, wantProof) {
+			t.Errorf("block %d: proof mismatch for %x", block.NumberU64(), addr)
+		}
+		if have.GetBalance(addr).Cmp(want.GetBalance(addr)) != 0 {
+			t.Errorf("block %d: balance mismatch for %x: have %v, want %v", block.NumberU64(), addr, have.GetBalance(addr), want.GetBalance(addr))
+		}
+	}
+	// A block beyond the witness depth
+	stale := blocks[len(blocks)-1-int(config.WitnessDepth)-1]
+	if _, err := chain.WitnessStateAt(stale.Header()); err != errWitnessUnavailable {
+		t.Fatalf("block %d: witness state error mismatch: have %v, want %v", stale.NumberU64(), err, errWitnessUnavailable)
+	}
+	// Rewinding the chain drops the indexes above the new head, keeping the nodes
+	head := chain.CurrentBlock().NumberU64()
+	if err := chain.SetHead(head - 10); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	for number := head - 9; number <= head; number++ {
+		if hashes := rawdb.ReadWitnessIndex(db, number); len(hashes) != 0 {
+			t.Errorf("block %d: witness index retained above the head: %d nodes", number, len(hashes))
+		}
+	}
+	if _, err := chain.WitnessStateAt(block.Header()); err != nil {
+		t.Fatalf("block %d: failed to open witness state after rewind: %v", block.NumberU64(), err)
+	}
+}
+
+// Tests that witness nodes are dropped once they fall out of the retention
+// window, unless they were collected again at a later block.
+func TestProofWitnessPrune(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		witness = newProofWitness(db, 2)
+		a, b    = common.Hash{0x01}, common.Hash{0x02}
+	)
+	witness.collect(a, []byte{0x0a})
+	witness.collect(b, []byte{0x0b})
+	if blob := witness.node(a); !reflect.DeepEqual(blob, []byte{0x0a}) {
+		t.Fatalf("pending node mismatch: have %x, want %x", blob, []byte{0x0a})
+	}
+	if err := witness.commit(1); err != nil {
+		t.Fatalf("failed to commit witness: %v", err)
+	}
+	witness.collect(b, []byte{0x0b})
+	if err := witness.commit(2); err != nil {
+		t.Fatalf("failed to commit witness: %v", err)
+	}
+	if err := witness.commit(3); err != nil {
+		t.Fatalf("failed to commit witness: %v", err)
+	}
+	if blob := witness.node(a); blob == nil {
+		t.Fatalf("node pruned within retention window")
+	}
+	if err := witness.commit(4); err != nil {
+		t.Fatalf("failed to commit witness: %v", err)
+	}
+	if blob := witness.node(a); blob != nil {
+		t.Fatalf("node retained beyond retention window: %x", blob)
+	}
+	if blob := witness.node(b); blob == nil {
+		t.Fatalf("recollected node pruned with its stale index")
+	}
+	if hashes := rawdb.ReadWitnessIndex(db, 1); len(hashes) != 0 {
+		t.Fatalf("stale witness index retained: %v", hashes)
+	}
+}
+
+// Tests that rewinding the witness store drops the indexes above the new head,
+// indexing their nodes at the new head instead.
+func TestProofWitnessRewind(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		witness = newProofWitness(db, 2)
+		a, b, c = common.Hash{0x01}, common.Hash{0x02}, common.Hash{0x03}
+	)
+	for number, hash := range map[uint64]common.Hash{1: a, 3: b, 4: c} {
+		witness.collect(hash, hash[:1])
+		if err := witness.commit(number); err != nil {
+			t.Fatalf("failed to commit witness: %v", err)
+		}
+	}
+	if err := witness.rewind(2, 4); err != nil {
+		t.Fatalf("failed to rewind witness: %v", err)
+	}
+	for _, number := range []uint64{3, 4} {
+		if hashes := rawdb.ReadWitnessIndex(db, number); len(hashes) != 0 {
+			t.Fatalf("block %d: witness index retained above the head: %v", number, hashes)
+		}
+	}
+	if hashes := rawdb.ReadWitnessIndex(db, 2); !reflect.DeepEqual(hashes, []common.Hash{b, c}) && !reflect.DeepEqual(hashes, []common.Hash{c, b}) {
+		t.Fatalf("rewound witness index mismatch: have %v, want %v", hashes, []common.Hash{b, c})
+	}
+	for _, hash := range []common.Hash{b, c} {
+		if blob, expiry := rawdb.ReadWitnessNode(db, hash); len(blob) == 0 || expiry != 2 {
+			t.Fatalf("node %x: have expiry %d, want %d", hash, expiry, 2)
+		}
+	}
+	// The rewound nodes expire with the new head
+	if err := witness.commit(5); err != nil {
+		t.Fatalf("failed to commit witness: %v", err)
+	}
+	if blob := witness.node(b); blob != nil {
+		t.Fatalf("rewound node retained beyond retention window: %x", blob)
+	}
+}
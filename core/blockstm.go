@@ -0,0 +1,460 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	blockSTMBlockMeter      = metrics.NewRegisteredMeter("chain/blockstm/blocks", nil)
+	blockSTMSkipMeter       = metrics.NewRegisteredMeter("chain/blockstm/skipped", nil)
+	blockSTMTxMeter         = metrics.NewRegisteredMeter("chain/blockstm/txs", nil)
+	blockSTMReexecMeter     = metrics.NewRegisteredMeter("chain/blockstm/reexecuted", nil)
+	blockSTMDivergenceMeter = metrics.NewRegisteredMeter("chain/blockstm/divergences", nil)
+	blockSTMTimer           = metrics.NewRegisteredTimer("chain/blockstm/execution", nil)
+)
+
+// blockSTM is an experimental optimistic concurrency executor in the style of
+// Block-STM, run in research mode only: the blocks imported by the serial state
+// processor are executed again in the background, and the results are checked
+// against the serial ones, any divergence being logged along with a dump to
+// reproduce it. The serial results are always the ones the chain goes on with.
+//
+// The transactions are all executed in parallel against the state preceding
+// them, recording the accounts and slots they read and write. They're then
+// validated in order: a transaction none of the reads of which were written by
+// the preceding ones has its writes merged into the block state, the others are
+// executed again on top of it. The balance changes are merged as deltas, so the
+// fee payments to the same recipient don't conflict with each other. The
+// transactions creating or destroying accounts are always executed again.
+type blockSTM struct {
+	config  *params.ChainConfig
+	chain   ChainContext
+	workers int
+	dumpDir string // Directory to write the divergence dumps into, none if empty
+
+	busy int32 // Whether a block is being executed, the next ones being skipped
+}
+
+func newBlockSTM(config *params.ChainConfig, chain ChainContext, dumpDir string) *blockSTM {
+	return &blockSTM{
+		config:  config,
+		chain:   chain,
+		workers: runtime.NumCPU(),
+		dumpDir: dumpDir,
+	}
+}
+
+// stmJob is a block processed serially, to be cross-validated.
+type stmJob struct {
+	block     *types.Block
+	validator types.EvmExtraValidator // Extra validator of the serial processing, read only
+	base      *state.StateDB          // State preceding the transactions
+	serial    *state.StateDB          // State following the serial transactions
+	txs       []int                   // Indices of the transactions executed, system ones excluded
+	receipts  types.Receipts          // Receipts of the serial executions
+}
+
+// stmTx is a transaction of the block, along with its latest execution.
+type stmTx struct {
+	index int
+	tx    *types.Transaction
+	msg   types.Message
+
+	state   *state.StateDB // State of the optimistic execution
+	access  *state.StateAccess
+	receipt *types.Receipt
+	err     error
+	reexec  bool // Whether the transaction was executed again after validation
+}
+
+// idle reports whether no block is being executed.
+func (e *blockSTM) idle() bool {
+	return atomic.LoadInt32(&e.busy) == 0
+}
+
+// shadow cross-validates the block in the background, unless a previous one is
+// still being executed, in which case the block is skipped.
+func (e *blockSTM) shadow(job *stmJob) {
+	if !atomic.CompareAndSwapInt32(&e.busy, 0, 1) {
+		blockSTMSkipMeter.Mark(1)
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&e.busy, 0)
+		e.verify(job)
+	}()
+}
+
+// verify executes the block and compares the results with the serial ones.
+func (e *blockSTM) verify(job *stmJob) {
+	var (
+		start   = time.Now()
+		deletes = e.config.IsEIP158(job.block.Number())
+	)
+	receipts, final, txs, err := e.execute(job)
+	elapsed := time.Since(start)
+
+	blockSTMBlockMeter.Mark(1)
+	blockSTMTimer.Update(elapsed)
+	blockSTMTxMeter.Mark(int64(len(txs)))
+
+	reexec := 0
+	for _, tx := range txs {
+		if tx.reexec {
+			reexec++
+		}
+	}
+	blockSTMReexecMeter.Mark(int64(reexec))
+
+	serialRoot := job.serial.IntermediateRoot(deletes)
+	var stmRoot common.Hash
+	if final != nil {
+		stmRoot = final.IntermediateRoot(deletes)
+	}
+	mismatch := -1
+	if err == nil {
+		for i := range receipts {
+			if !stmReceiptsEqual(receipts[i], job.receipts[i]) {
+				mismatch = i
+				break
+			}
+		}
+	}
+	if err == nil && mismatch < 0 && stmRoot == serialRoot {
+		log.Debug("Block-STM execution matches serial", "number", job.block.Number(), "hash", job.block.Hash(),
+			"txs", len(txs), "reexecuted", reexec, "elapsed", common.PrettyDuration(elapsed))
+		return
+	}
+	blockSTMDivergenceMeter.Mark(1)
+
+	path, dumpErr := e.dump(job, receipts, final, txs, err, mismatch, serialRoot, stmRoot)
+	if dumpErr != nil {
+		log.Warn("Failed to dump Block-STM divergence", "err", dumpErr)
+	}
+	log.Error("Block-STM execution diverged from serial", "number", job.block.Number(), "hash", job.block.Hash(),
+		"err", err, "mismatch", mismatch, "serialroot", serialRoot, "stmroot", stmRoot, "dump", path)
+}
+
+// execute runs the transactions of the job optimistically, returning their
+// receipts and the resulting state.
+func (e *blockSTM) execute(job *stmJob) (types.Receipts, *state.StateDB, []*stmTx, error) {
+	var (
+		block  = job.block
+		header = block.Header()
+		signer = types.MakeSigner(e.config, header.Number)
+		txs    = make([]*stmTx, len(job.txs))
+	)
+	for i, index := range job.txs {
+		tx := block.Transactions()[index]
+		msg, err := tx.AsMessage(signer, header.BaseFee)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("could not convert tx %d [%v]: %w", index, tx.Hash().Hex(), err)
+		}
+		txs[i] = &stmTx{index: index, tx: tx, msg: msg, state: job.base.Copy()}
+	}
+	// Execute all the transactions against the base state
+	var (
+		next int32
+		wg   sync.WaitGroup
+	)
+	workers := e.workers
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt32(&next, 1)) - 1
+				if i >= len(txs) {
+					return
+				}
+				e.run(job, txs[i], txs[i].state)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Validate the executions in order, merging the valid ones and executing
+	// the others again on top of the block state
+	var (
+		final     = job.base.Copy()
+		written   = state.NewAccessSet()
+		recreated = make(map[common.Address]struct{})
+		receipts  = make(types.Receipts, 0, len(txs))
+		usedGas   uint64
+	)
+	for _, tx := range txs {
+		if tx.err != nil || e.conflicts(tx, written, recreated) || !e.merge(tx, job.base, final) {
+			tx.reexec = true
+			e.run(job, tx, final)
+			if tx.err != nil {
+				return receipts, final, txs, fmt.Errorf("could not apply tx %d [%v]: %w", tx.index, tx.tx.Hash().Hex(), tx.err)
+			}
+			// Accounts written by the executions on the block state may have been
+			// created or destroyed, their storage is no longer the base one
+			for addr := range tx.access.Writes.Accounts {
+				recreated[addr] = struct{}{}
+			}
+		}
+		written.Merge(tx.access.Writes)
+
+		usedGas += tx.receipt.GasUsed
+		tx.receipt.CumulativeGasUsed = usedGas
+		receipts = append(receipts, tx.receipt)
+	}
+	return receipts, final, txs, nil
+}
+
+// run executes the transaction on the state, recording its accesses.
+func (e *blockSTM) run(job *stmJob, tx *stmTx, statedb *state.StateDB) {
+	var (
+		header  = job.block.Header()
+		context = NewEVMBlockContext(header, e.chain, nil)
+		usedGas uint64
+	)
+	context.ExtraValidator = job.validator
+	evm := vm.NewEVM(context, vm.TxContext{}, statedb, e.config, vm.Config{})
+
+	statedb.RecordAccess()
+	statedb.Prepare(tx.tx.Hash(), tx.index)
+	tx.receipt, tx.err = applyTransaction(tx.msg, e.config, e.chain, nil, new(GasPool).AddGas(header.GasLimit), statedb,
+		header.Number, job.block.Hash(), tx.tx, &usedGas, evm)
+	tx.access = statedb.TakeAccess()
+}
+
+// conflicts reports whether the optimistic execution read any account or slot
+// written by the preceding transactions.
+func (e *blockSTM) conflicts(tx *stmTx, written *state.AccessSet, recreated map[common.Address]struct{}) bool {
+	for addr := range tx.access.Reads.Accounts {
+		if written.HasAccount(addr) {
+			return true
+		}
+	}
+	for addr, slots := range tx.access.Reads.Slots {
+		if _, ok := recreated[addr]; ok {
+			return true
+		}
+		for key := range slots {
+			if written.HasSlot(addr, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// merge applies the writes of the optimistic execution to the block state, the
+// balances as deltas from the base state. It refuses the executions creating or
+// destroying accounts, or deploying code, which have to be executed again.
+func (e *blockSTM) merge(tx *stmTx, base, final *state.StateDB) bool {
+	writes := tx.access.Writes
+	for addr := range writes.Accounts {
+		if tx.state.Exist(addr) != base.Exist(addr) || tx.state.GetCodeHash(addr) != base.GetCodeHash(addr) {
+			return false
+		}
+	}
+	for addr := range writes.Slots {
+		if tx.state.Exist(addr) != base.Exist(addr) {
+			return false
+		}
+	}
+	for addr := range writes.Accounts {
+		delta := new(big.Int).Sub(tx.state.GetBalance(addr), base.GetBalance(addr))
+		switch delta.Sign() {
+		case 1:
+			final.AddBalance(addr, delta)
+		case -1:
+			final.SubBalance(addr, delta.Neg(delta))
+		}
+		if nonce := tx.state.GetNonce(addr); nonce != base.GetNonce(addr) {
+			final.SetNonce(addr, nonce)
+		}
+	}
+	for addr, slots := range writes.Slots {
+		for key := range slots {
+			final.SetState(addr, key, tx.state.GetState(addr, key))
+		}
+	}
+	final.Finalise(e.config.IsEIP158(tx.receipt.BlockNumber))
+	return true
+}
+
+// stmReceiptsEqual reports whether the receipts match in their consensus fields,
+// gas used and contract address.
+func stmReceiptsEqual(a, b *types.Receipt) bool {
+	if a.GasUsed != b.GasUsed || a.ContractAddress != b.ContractAddress {
+		return false
+	}
+	blobA, errA := a.MarshalBinary()
+	blobB, errB := b.MarshalBinary()
+	return errA == nil && errB == nil && bytes.Equal(blobA, blobB)
+}
+
+// stmDivergence is the dump of a block the Block-STM execution of which diverged
+// from the serial one. The block and the state of its parent are enough to replay
+// it, the rest narrowing down the faulty transaction.
+type stmDivergence struct {
+	Number     uint64      `json:"number"`
+	Hash       common.Hash `json:"hash"`
+	ParentHash common.Hash `json:"parentHash"`
+	Error      string      `json:"error,omitempty"`
+	SerialRoot common.Hash `json:"serialRoot"`
+	STMRoot    common.Hash `json:"stmRoot"`
+	Mismatch   int         `json:"mismatch"` // Position of the first mismatching receipt, -1 if none
+
+	Txs            []stmTxDump      `json:"txs"`
+	Accounts       []stmAccountDiff `json:"accounts"`
+	SerialReceipts types.Receipts   `json:"serialReceipts"`
+	STMReceipts    types.Receipts   `json:"stmReceipts"`
+	Block          hexutil.Bytes    `json:"block"`
+}
+
+type stmTxDump struct {
+	Index      int         `json:"index"`
+	Hash       common.Hash `json:"hash"`
+	Reexecuted bool        `json:"reexecuted"`
+	Reads      int         `json:"reads"`
+	Writes     int         `json:"writes"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// stmAccountDiff is an account written in the block, the fields of which differ
+// between the serial and the Block-STM states.
+type stmAccountDiff struct {
+	Address common.Address                 `json:"address"`
+	Balance [2]*hexutil.Big                `json:"balance,omitempty"`
+	Nonce   [2]uint64                      `json:"nonce,omitempty"`
+	Code    [2]common.Hash                 `json:"codeHash,omitempty"`
+	Storage map[common.Hash][2]common.Hash `json:"storage,omitempty"`
+}
+
+// dump writes the divergence of the block into the dump directory.
+func (e *blockSTM) dump(job *stmJob, receipts types.Receipts, final *state.StateDB, txs []*stmTx, err error, mismatch int, serialRoot, stmRoot common.Hash) (string, error) {
+	if e.dumpDir == "" {
+		return "", nil
+	}
+	block := job.block
+	div := &stmDivergence{
+		Number:         block.NumberU64(),
+		Hash:           block.Hash(),
+		ParentHash:     block.ParentHash(),
+		SerialRoot:     serialRoot,
+		STMRoot:        stmRoot,
+		Mismatch:       mismatch,
+		SerialReceipts: job.receipts,
+		STMReceipts:    receipts,
+	}
+	if err != nil {
+		div.Error = err.Error()
+	}
+	written := state.NewAccessSet()
+	for _, tx := range txs {
+		if tx == nil {
+			continue
+		}
+		entry := stmTxDump{Index: tx.index, Hash: tx.tx.Hash(), Reexecuted: tx.reexec}
+		if tx.access != nil {
+			entry.Reads, entry.Writes = tx.access.Reads.Len(), tx.access.Writes.Len()
+			written.Merge(tx.access.Writes)
+		}
+		if tx.err != nil {
+			entry.Error = tx.err.Error()
+		}
+		div.Txs = append(div.Txs, entry)
+	}
+	if final != nil {
+		div.Accounts = stmDiffAccounts(written, job.serial, final)
+	}
+	blob, rlpErr := rlp.EncodeToBytes(block)
+	if rlpErr != nil {
+		return "", rlpErr
+	}
+	div.Block = blob
+
+	if err := os.MkdirAll(e.dumpDir, 0755); err != nil {
+		return "", err
+	}
+	out, err := json.MarshalIndent(div, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(e.dumpDir, fmt.Sprintf("%d-%x.json", block.NumberU64(), block.Hash().Bytes()[:4]))
+	return path, ioutil.WriteFile(path, out, 0644)
+}
+
+// stmDiffAccounts returns the written accounts differing between the states.
+func stmDiffAccounts(written *state.AccessSet, serial, final *state.StateDB) []stmAccountDiff {
+	addrs := make(map[common.Address]struct{})
+	for addr := range written.Accounts {
+		addrs[addr] = struct{}{}
+	}
+	for addr := range written.Slots {
+		addrs[addr] = struct{}{}
+	}
+	var diffs []stmAccountDiff
+	for addr := range addrs {
+		var (
+			diff    = stmAccountDiff{Address: addr}
+			differs bool
+		)
+		if a, b := serial.GetBalance(addr), final.GetBalance(addr); a.Cmp(b) != 0 {
+			diff.Balance, differs = [2]*hexutil.Big{(*hexutil.Big)(a), (*hexutil.Big)(b)}, true
+		}
+		if a, b := serial.GetNonce(addr), final.GetNonce(addr); a != b {
+			diff.Nonce, differs = [2]uint64{a, b}, true
+		}
+		if a, b := serial.GetCodeHash(addr), final.GetCodeHash(addr); a != b {
+			diff.Code, differs = [2]common.Hash{a, b}, true
+		}
+		for key := range written.Slots[addr] {
+			if a, b := serial.GetState(addr, key), final.GetState(addr, key); a != b {
+				if diff.Storage == nil {
+					diff.Storage = make(map[common.Hash][2]common.Hash)
+				}
+				diff.Storage[key], differs = [2]common.Hash{a, b}, true
+			}
+		}
+		if differs {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,158 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newSTMTestJob creates a block of transfers and its serial execution, returning
+// the job cross-validating it.
+func newSTMTestJob(t *testing.T) (*stmJob, *BlockChain) {
+	var (
+		keys   = make([]*ecdsa.PrivateKey, 3)
+		alloc  = make(GenesisAlloc)
+		funds  = big.NewInt(params.Ether)
+		recvA  = common.HexToAddress("0xaaaa")
+		recvB  = common.HexToAddress("0xbbbb")
+		config = params.TestChainConfig
+		db     = rawdb.NewMemoryDatabase()
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		alloc[crypto.PubkeyToAddress(keys[i].PublicKey)] = GenesisAccount{Balance: funds}
+	}
+	alloc[recvA] = GenesisAccount{Balance: common.Big1}
+	alloc[recvB] = GenesisAccount{Balance: common.Big1}
+	genesis := (&Genesis{Config: config, Alloc: alloc}).MustCommit(db)
+
+	chain, err := NewBlockChain(db, nil, config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	signer := types.LatestSigner(config)
+	blocks, _ := GenerateChain(config, genesis, ethash.NewFaker(), db, 1, func(i int, b *BlockGen) {
+		transfer := func(key *ecdsa.PrivateKey, to common.Address) {
+			from := crypto.PubkeyToAddress(key.PublicKey)
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(from), to, big.NewInt(1000), params.TxGas, b.BaseFee(), nil), signer, key)
+			b.AddTx(tx)
+		}
+		transfer(keys[0], recvA) // first, never conflicting
+		transfer(keys[1], recvB) // independent of the first
+		transfer(keys[0], recvB) // same sender as the first
+		transfer(keys[2], recvA) // same recipient as the first
+	})
+	block := blocks[0]
+
+	statedb, _ := state.New(genesis.Root(), state.NewDatabase(db), nil)
+	base := statedb.Copy()
+
+	var (
+		gp       = new(GasPool).AddGas(block.GasLimit())
+		usedGas  uint64
+		receipts types.Receipts
+		txs      []int
+	)
+	for i, tx := range block.Transactions() {
+		statedb.Prepare(tx.Hash(), i)
+		receipt, err := ApplyTransaction(config, chain, nil, gp, statedb, block.Header(), tx, &usedGas, vm.Config{}, nil)
+		if err != nil {
+			t.Fatalf("failed to apply tx %d: %v", i, err)
+		}
+		receipts = append(receipts, receipt)
+		txs = append(txs, i)
+	}
+	return &stmJob{block: block, base: base, serial: statedb, txs: txs, receipts: receipts}, chain
+}
+
+// Tests that the Block-STM execution matches the serial one, executing again
+// only the transactions reading the writes of the preceding ones.
+func TestBlockSTMExecute(t *testing.T) {
+	job, chain := newSTMTestJob(t)
+	defer chain.Stop()
+
+	stm := newBlockSTM(chain.Config(), chain, "")
+	receipts, final, txs, err := stm.execute(job)
+	if err != nil {
+		t.Fatalf("failed to execute: %v", err)
+	}
+	for i, want := range []bool{false, false, true, true} {
+		if txs[i].reexec != want {
+			t.Errorf("tx %d: re-execution mismatch: have %v, want %v", i, txs[i].reexec, want)
+		}
+	}
+	for i := range receipts {
+		if !stmReceiptsEqual(receipts[i], job.receipts[i]) {
+			t.Errorf("tx %d: receipt mismatch: have %+v, want %+v", i, receipts[i], job.receipts[i])
+		}
+	}
+	deletes := chain.Config().IsEIP158(job.block.Number())
+	if have, want := final.IntermediateRoot(deletes), job.serial.IntermediateRoot(deletes); have != want {
+		t.Errorf("state root mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that the divergences are dumped along with the faulty transaction.
+func TestBlockSTMDivergence(t *testing.T) {
+	job, chain := newSTMTestJob(t)
+	defer chain.Stop()
+
+	// Tamper with the serial receipt of the second transaction
+	job.receipts[1].GasUsed++
+
+	dir := t.TempDir()
+	newBlockSTM(chain.Config(), chain, dir).verify(job)
+
+	blob, err := ioutil.ReadFile(filepath.Join(dir, "1-"+common.Bytes2Hex(job.block.Hash().Bytes()[:4])+".json"))
+	if err != nil {
+		t.Fatalf("failed to read dump: %v", err)
+	}
+	var dump struct {
+		Mismatch   int           `json:"mismatch"`
+		SerialRoot common.Hash   `json:"serialRoot"`
+		STMRoot    common.Hash   `json:"stmRoot"`
+		Txs        []stmTxDump   `json:"txs"`
+		Block      hexutil.Bytes `json:"block"`
+	}
+	if err := json.Unmarshal(blob, &dump); err != nil {
+		t.Fatalf("failed to decode dump: %v", err)
+	}
+	if dump.Mismatch != 1 {
+		t.Errorf("mismatch position: have %d, want 1", dump.Mismatch)
+	}
+	if dump.SerialRoot != dump.STMRoot {
+		t.Errorf("state roots differ: serial %x, stm %x", dump.SerialRoot, dump.STMRoot)
+	}
+	if len(dump.Txs) != 4 || len(dump.Block) == 0 {
+		t.Errorf("incomplete dump: %d txs, %d bytes of block", len(dump.Txs), len(dump.Block))
+	}
+}
@@ -200,7 +200,7 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 		config = params.TestChainConfig
 	}
 	blocks, receipts := make(types.Blocks, n), make([]types.Receipts, n)
-	chainreader := &fakeChainReader{config: config, engine: engine}
+	chainreader := &generatedChainReader{fakeChainReader: &fakeChainReader{config: config, engine: engine}, parent: parent, blocks: blocks}
 	genblock := func(i int, parent *types.Block, statedb *state.StateDB) (*types.Block, types.Receipts) {
 		b := &BlockGen{i: i, chain: blocks, parent: parent, statedb: statedb, config: config, engine: engine}
 		b.header = makeHeader(chainreader, parent, statedb, b.engine)
@@ -269,15 +269,10 @@ func makeHeader(chain consensus.ChainReader, parent *types.Block, state *state.S
 		Root:       state.IntermediateRoot(chain.Config().IsEIP158(parent.Number())),
 		ParentHash: parent.Hash(),
 		Coinbase:   parent.Coinbase(),
-		Difficulty: engine.CalcDifficulty(chain, time, &types.Header{
-			Number:     parent.Number(),
-			Time:       time - 10,
-			Difficulty: parent.Difficulty(),
-			UncleHash:  parent.UncleHash(),
-		}),
-		GasLimit: parent.GasLimit(),
-		Number:   new(big.Int).Add(parent.Number(), common.Big1),
-		Time:     time,
+		Difficulty: engine.CalcDifficulty(chain, time, parent.Header()),
+		GasLimit:   parent.GasLimit(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		Time:       time,
 	}
 	if chain.Config().IsLondon(header.Number) {
 		header.BaseFee = misc.CalcBaseFee(chain.Config(), parent.Header())
@@ -326,3 +321,55 @@ func (cr *fakeChainReader) GetHeaderByNumber(number uint64) *types.Header
 func (cr *fakeChainReader) GetHeaderByHash(hash common.Hash) *types.Header          { return nil }
 func (cr *fakeChainReader) GetHeader(hash common.Hash, number uint64) *types.Header { return nil }
 func (cr *fakeChainReader) GetBlock(hash common.Hash, number uint64) *types.Block   { return nil }
+
+// generatedChainReader is a fakeChainReader serving the blocks generated so far and
+// their parent, as needed by the engines tracking the past signers, e.g. congress.
+type generatedChainReader struct {
+	*fakeChainReader
+	parent *types.Block
+	blocks []*types.Block // Generated blocks, nil from the one being generated on
+}
+
+// block returns the generated block or the parent of the given number, if any.
+func (cr *generatedChainReader) block(number uint64) *types.Block {
+	if number == cr.parent.NumberU64() {
+		return cr.parent
+	}
+	if number < cr.parent.NumberU64() || number-cr.parent.NumberU64() > uint64(len(cr.blocks)) {
+		return nil
+	}
+	return cr.blocks[number-cr.parent.NumberU64()-1]
+}
+
+func (cr *generatedChainReader) GetHeaderByNumber(number uint64) *types.Header {
+	if block := cr.block(number); block != nil {
+		return block.Header()
+	}
+	return nil
+}
+
+func (cr *generatedChainReader) GetHeaderByHash(hash common.Hash) *types.Header {
+	if hash == cr.parent.Hash() {
+		return cr.parent.Header()
+	}
+	for _, block := range cr.blocks {
+		if block != nil && block.Hash() == hash {
+			return block.Header()
+		}
+	}
+	return nil
+}
+
+func (cr *generatedChainReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if block := cr.GetBlock(hash, number); block != nil {
+		return block.Header()
+	}
+	return nil
+}
+
+func (cr *generatedChainReader) GetBlock(hash common.Hash, number uint64) *types.Block {
+	if block := cr.block(number); block != nil && block.Hash() == hash {
+		return block
+	}
+	return nil
+}
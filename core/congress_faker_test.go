@@ -0,0 +1,114 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// The congress engine depends on core, so its chains are tested from outside.
+package core_test
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the chains generated with the congress faker, unsigned, are imported
+// by a blockchain running it, transactions and system contracts included.
+func TestCongressFakerChain(t *testing.T) {
+	var (
+		key, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		validator = common.Address{0x01}
+		recipient = common.Address{0x02}
+		config    = &params.ChainConfig{
+			ChainID:             big.NewInt(1337),
+			HomesteadBlock:      big.NewInt(0),
+			EIP150Block:         big.NewInt(0),
+			EIP155Block:         big.NewInt(0),
+			EIP158Block:         big.NewInt(0),
+			ByzantiumBlock:      big.NewInt(0),
+			ConstantinopleBlock: big.NewInt(0),
+			PetersburgBlock:     big.NewInt(0),
+			IstanbulBlock:       big.NewInt(0),
+			Congress:            &params.CongressConfig{Period: 3, Epoch: 200},
+		}
+		extra   = append(append(make([]byte, 32), validator.Bytes()...), make([]byte, crypto.SignatureLength)...)
+		genesis = &core.Genesis{
+			Config:     config,
+			ExtraData:  extra,
+			GasLimit:   params.GenesisGasLimit,
+			Difficulty: big.NewInt(1),
+			Alloc:      core.DefaultGenesisBlock().Alloc,
+		}
+		signer = types.LatestSigner(config)
+	)
+	genesis.Alloc[sender] = core.GenesisAccount{Balance: big.NewInt(params.Ether)}
+
+	// The blocks are stamped 10 seconds apart, run the clock well after them
+	clock := congress.WithClock(func() time.Time { return time.Unix(math.MaxInt32, 0) })
+
+	// The blocks are generated and imported by the same engine, running on the chain
+	db := rawdb.NewMemoryDatabase()
+	genesis.MustCommit(db)
+
+	ref := new(congress.ChainRef)
+	engine := congress.NewFaker(config, db, ref, clock)
+	engine.Authorize(validator, nil, nil)
+
+	chain, err := core.NewBlockChain(db, nil, config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	ref.BlockChain = chain
+
+	blocks, _ := core.GenerateChain(config, chain.Genesis(), engine, db, 16, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(validator)
+		b.SetExtra(make([]byte, 32+crypto.SignatureLength))
+
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(sender), recipient, big.NewInt(1), params.TxGas, big.NewInt(params.GWei), nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	for i, block := range blocks {
+		if block == nil {
+			t.Fatalf("block %d: failed to generate", i+1)
+		}
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import block %d: %v", blocks[n].NumberU64(), err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("head mismatch: have #%d %x, want #%d %x", head.NumberU64(), head.Hash(), len(blocks), blocks[len(blocks)-1].Hash())
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to open head state: %v", err)
+	}
+	if balance := statedb.GetBalance(recipient); balance.Cmp(big.NewInt(int64(len(blocks)))) != 0 {
+		t.Errorf("recipient balance mismatch: have %v, want %d", balance, len(blocks))
+	}
+}
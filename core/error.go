@@ -103,4 +103,28 @@ var (
 	ErrUnauthorizedDeveloper = errors.New("unauthorized developer")
 	// ErrSenderNoEOA is returned if the sender of a transaction is a contract.
 	ErrSenderNoEOA = errors.New("sender not an eoa")
+
+	// ErrTxTooLarge is returned if the encoded transaction is larger than the max
+	// size allowed by the chain config.
+	ErrTxTooLarge = errors.New("transaction size exceeds limit")
+
+	// ErrCalldataTooLarge is returned if the input data of a transaction is larger
+	// than the max size allowed by the chain config.
+	ErrCalldataTooLarge = errors.New("calldata size exceeds limit")
+
+	// ErrInitCodeTooLarge is returned if the contract creation code of a transaction
+	// is larger than the max size allowed by the chain config.
+	ErrInitCodeTooLarge = errors.New("init code size exceeds limit")
+
+	// ErrUnprotectedTx is returned if a legacy transaction is not replay-protected
+	// by EIP155 after the chain config disallowed it.
+	ErrUnprotectedTx = errors.New("unprotected (non-EIP155) transaction")
+
+	// ErrFeelessNotAllowed is returned if a transaction paying no gas price is sent
+	// by an account not allowed to by the chain config.
+	ErrFeelessNotAllowed = errors.New("sender not allowed to pay no gas price")
+
+	// ErrFeelessCapReached is returned if a transaction paying no gas price exceeds
+	// the per block caps of the chain config.
+	ErrFeelessCapReached = errors.New("feeless transactions cap reached")
 )
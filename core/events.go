@@ -41,3 +41,21 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// ReorgEvent is posted when the canonical chain is reorganised.
+type ReorgEvent struct {
+	OldHead    *types.Header // Head of the dropped chain
+	NewHead    *types.Header // Head of the new canonical chain
+	Common     *types.Header // Common ancestor of the two chains
+	Depth      uint64        // Number of dropped blocks
+	Removed    []common.Hash // Transactions of the dropped blocks not included by the new chain
+	Reincluded []common.Hash // Transactions of the dropped blocks included again by the new chain
+}
+
+// GasPricesEvent is posted when the gas price prediction tiers change, or the
+// jam index of the pool moves to another level.
+type GasPricesEvent struct {
+	Prices   []uint // Predicted gas prices in gwei: fast, median and low
+	JamIndex int    // Current jam index of the transaction pool
+	JamLevel int    // Number of jam thresholds reached by the jam index
+}
@@ -53,16 +53,17 @@ func NewEVMBlockContext(header *types.Header, chain ChainContext, author *common
 		baseFee = new(big.Int).Set(header.BaseFee)
 	}
 	return vm.BlockContext{
-		CanTransfer: CanTransfer,
-		Transfer:    Transfer,
-		GetHash:     GetHashFn(header, chain),
-		Coinbase:    beneficiary,
-		BlockNumber: new(big.Int).Set(header.Number),
-		Time:        new(big.Int).SetUint64(header.Time),
-		Difficulty:  new(big.Int).Set(header.Difficulty),
-		BaseFee:     baseFee,
-		GasLimit:    header.GasLimit,
-		CanCreate:   GetCanCreateFn(chain),
+		CanTransfer:  CanTransfer,
+		Transfer:     Transfer,
+		GetHash:      GetHashFn(header, chain),
+		Coinbase:     beneficiary,
+		BlockNumber:  new(big.Int).Set(header.Number),
+		Time:         new(big.Int).SetUint64(header.Time),
+		Difficulty:   new(big.Int).Set(header.Difficulty),
+		BaseFee:      baseFee,
+		GasLimit:     header.GasLimit,
+		CanCreate:    GetCanCreateFn(chain),
+		FeeBurnRatio: GetFeeBurnRatioFn(chain),
 	}
 }
 
@@ -136,3 +137,17 @@ func GetCanCreateFn(chain ChainContext) vm.CanCreateFunc {
 		return true
 	}
 }
+
+// GetFeeBurnRatioFn returns the fee burn ratio function of a PoSA engine, which
+// may be tuned by its system governance, nil otherwise.
+func GetFeeBurnRatioFn(chain ChainContext) vm.FeeBurnRatioFunc {
+	if reflect2.IsNil(chain) || chain.Engine() == nil {
+		return nil
+	}
+	if posa, isPoSA := chain.Engine().(consensus.PoSA); isPoSA {
+		return func(db vm.StateDB, height *big.Int) uint64 {
+			return posa.FeeBurnRatio(db, height)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// IsFeeless reports whether the transaction pays no gas price at the base fee.
+func IsFeeless(tx *types.Transaction, baseFee *big.Int) bool {
+	if baseFee != nil && baseFee.Sign() > 0 {
+		return false
+	}
+	return tx.EffectiveGasTipValue(baseFee).Sign() <= 0
+}
+
+// FeelessCounter enforces the rules of the transactions paying no gas price of
+// the chain config over the transactions of a block, the system transactions
+// being left out.
+type FeelessCounter struct {
+	rules   *params.FeelessRules // Rules in force at the block, nil if unrestricted
+	baseFee *big.Int
+
+	txs uint64 // Transactions paying no gas price included so far
+	gas uint64 // Total gas limit of the transactions paying no gas price included so far
+}
+
+// NewFeelessCounter creates a counter for the block of the given header.
+func NewFeelessCounter(config *params.ChainConfig, header *types.Header) *FeelessCounter {
+	counter := &FeelessCounter{baseFee: header.BaseFee}
+	if config.Congress != nil {
+		counter.rules = config.Congress.FeelessAt(header.Number)
+	}
+	return counter
+}
+
+// Check checks whether the transaction of the sender may be included next in the
+// block, without counting it.
+func (c *FeelessCounter) Check(sender common.Address, tx *types.Transaction) error {
+	if c.rules == nil || !IsFeeless(tx, c.baseFee) {
+		return nil
+	}
+	if !c.rules.Allowed(sender) {
+		return fmt.Errorf("%w: address %v", ErrFeelessNotAllowed, sender.Hex())
+	}
+	if c.rules.MaxTxs != 0 && c.txs >= c.rules.MaxTxs {
+		return fmt.Errorf("%w: %d transactions, limit %d", ErrFeelessCapReached, c.txs+1, c.rules.MaxTxs)
+	}
+	if c.rules.MaxGas != 0 && c.gas+tx.Gas() > c.rules.MaxGas {
+		return fmt.Errorf("%w: gas %d, limit %d", ErrFeelessCapReached, c.gas+tx.Gas(), c.rules.MaxGas)
+	}
+	return nil
+}
+
+// Add counts the transaction included in the block.
+func (c *FeelessCounter) Add(tx *types.Transaction) {
+	if c.rules == nil || !IsFeeless(tx, c.baseFee) {
+		return
+	}
+	c.txs++
+	c.gas += tx.Gas()
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the transactions paying no gas price of a block are restricted to
+// the allowed senders and capped, the paying ones being left alone.
+func TestFeelessCounter(t *testing.T) {
+	allowed, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(allowed.PublicKey)
+
+	config := &params.ChainConfig{Congress: &params.CongressConfig{
+		FeelessSenders: []common.Address{sender},
+		MaxFeelessTxs:  2,
+		MaxFeelessGas:  50000,
+		FeelessBlock:   big.NewInt(10),
+	}}
+	// Unrestricted before the fork
+	counter := NewFeelessCounter(config, &types.Header{Number: big.NewInt(9), BaseFee: new(big.Int)})
+	if err := counter.Check(crypto.PubkeyToAddress(other.PublicKey), pricedTransaction(0, 21000, common.Big0, other)); err != nil {
+		t.Fatalf("feeless tx rejected before the fork: %v", err)
+	}
+	counter = NewFeelessCounter(config, &types.Header{Number: big.NewInt(10), BaseFee: new(big.Int)})
+	if err := counter.Check(crypto.PubkeyToAddress(other.PublicKey), pricedTransaction(0, 21000, common.Big0, other)); !errors.Is(err, ErrFeelessNotAllowed) {
+		t.Fatalf("disallowed sender error mismatch: have %v, want %v", err, ErrFeelessNotAllowed)
+	}
+	if err := counter.Check(crypto.PubkeyToAddress(other.PublicKey), pricedTransaction(0, 21000, common.Big1, other)); err != nil {
+		t.Fatalf("paying tx rejected: %v", err)
+	}
+	for i := uint64(0); i < 2; i++ {
+		tx := pricedTransaction(i, 21000, common.Big0, allowed)
+		if err := counter.Check(sender, tx); err != nil {
+			t.Fatalf("feeless tx %d rejected: %v", i, err)
+		}
+		counter.Add(tx)
+	}
+	if err := counter.Check(sender, pricedTransaction(2, 21000, common.Big0, allowed)); !errors.Is(err, ErrFeelessCapReached) {
+		t.Fatalf("tx cap error mismatch: have %v, want %v", err, ErrFeelessCapReached)
+	}
+	// The gas cap applies to the total gas limit
+	counter = NewFeelessCounter(config, &types.Header{Number: big.NewInt(10), BaseFee: new(big.Int)})
+	counter.Add(pricedTransaction(0, 30000, common.Big0, allowed))
+	if err := counter.Check(sender, pricedTransaction(1, 21000, common.Big0, allowed)); !errors.Is(err, ErrFeelessCapReached) {
+		t.Fatalf("gas cap error mismatch: have %v, want %v", err, ErrFeelessCapReached)
+	}
+}
@@ -42,6 +42,11 @@ var (
 	// checksum does not match any local checksum variation, signalling that the
 	// two chains have diverged in the past at some point (possibly at genesis).
 	ErrLocalIncompatibleOrStale = errors.New("local incompatible or needs update")
+
+	// ErrMismatchingSchedule is returned by the strict validator if a remote is in
+	// the same fork state as the local node, but announces a different next fork,
+	// e.g. a node not upgraded for an upcoming hard fork.
+	ErrMismatchingSchedule = errors.New("mismatching fork schedule")
 )
 
 // Blockchain defines all necessary method to build a forkID.
@@ -105,6 +110,34 @@ func NewFilter(chain Blockchain) Filter {
 	)
 }
 
+// NewStrictFilter creates a filter that, on top of the checks of NewFilter, also
+// rejects the remotes in the same fork state as the local chain but scheduling a
+// different next fork.
+func NewStrictFilter(chain Blockchain) Filter {
+	return newStrictFilter(
+		chain.Config(),
+		chain.Genesis().Hash(),
+		func() uint64 {
+			return chain.CurrentHeader().Number.Uint64()
+		},
+	)
+}
+
+// newStrictFilter is the internal version of NewStrictFilter, taking closures as
+// its arguments instead of a chain.
+func newStrictFilter(config *params.ChainConfig, genesis common.Hash, headfn func() uint64) Filter {
+	filter := newFilter(config, genesis, headfn)
+	return func(id ID) error {
+		if err := filter(id); err != nil {
+			return err
+		}
+		if local := NewID(config, genesis, headfn()); id.Hash == local.Hash && id.Next != local.Next {
+			return ErrMismatchingSchedule
+		}
+		return nil
+	}
+}
+
 // NewStaticFilter creates a filter at block zero.
 func NewStaticFilter(config *params.ChainConfig, genesis common.Hash) Filter {
 	head := func() uint64 { return 0 }
@@ -19,6 +19,7 @@ package forkid
 import (
 	"bytes"
 	"math"
+	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -161,6 +162,36 @@ func TestValidation(t *testing.T) {
 	}
 }
 
+// TestStrictValidation tests that the strict filter rejects remotes in the same
+// fork state announcing a different next fork.
+func TestStrictValidation(t *testing.T) {
+	config := *params.MainnetChainConfig
+	config.SophonBlock = big.NewInt(math.MaxInt64)
+
+	head := config.SophonBlock.Uint64() - 1
+	local := NewID(&config, params.MainnetGenesisHash, head)
+
+	tests := []struct {
+		id  ID
+		err error
+	}{
+		// Remote announces the same upcoming fork
+		{local, nil},
+		// Remote is not upgraded for the upcoming fork
+		{ID{Hash: local.Hash, Next: 0}, ErrMismatchingSchedule},
+		// Remote schedules the upcoming fork at a different block
+		{ID{Hash: local.Hash, Next: local.Next + 1}, ErrMismatchingSchedule},
+		// Remote is rejected by the regular rules too
+		{ID{Hash: checksumToBytes(0xdeadbeef), Next: 0}, ErrLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		filter := newStrictFilter(&config, params.MainnetGenesisHash, func() uint64 { return head })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
 // Tests that IDs are properly RLP encoded (specifically important because we
 // use uint32 to store the hash, but we need to encode it as [4]byte).
 func TestEncoding(t *testing.T) {
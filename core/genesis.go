@@ -42,7 +42,58 @@ import (
 //go:generate gencodec -type Genesis -field-override genesisSpecMarshaling -out gen_genesis.go
 //go:generate gencodec -type GenesisAccount -field-override genesisAccountMarshaling -out gen_genesis_account.go
 
-var errGenesisNoConfig = errors.New("genesis has no chain configuration")
+var (
+	errGenesisNoConfig = errors.New("genesis has no chain configuration")
+	errOverrideMainnet = errors.New("congress fork overrides are not allowed on mainnet")
+)
+
+// ChainOverrides contains the changes to the chain configuration, trialing the
+// forks at other heights than the bundled or genesis ones.
+type ChainOverrides struct {
+	ArrowGlacier *big.Int // Arrow Glacier block override (TODO: remove after the fork)
+	RedCoast     *big.Int // RedCoast block override
+	Sophon       *big.Int // Sophon block override
+	ProxyCheck   *big.Int // ProxyCheck block override
+	Taurus       *big.Int // Taurus block override
+}
+
+// apply returns a copy of the chain configuration with the overrides applied,
+// refusing to change the congress forks of the mainnet.
+func (o *ChainOverrides) apply(cfg *params.ChainConfig) (*params.ChainConfig, error) {
+	if o == nil {
+		return cfg, nil
+	}
+	congress := o.RedCoast != nil || o.Sophon != nil || o.ProxyCheck != nil || o.Taurus != nil
+	if congress && cfg.ChainID != nil && cfg.ChainID.Cmp(params.MainnetChainConfig.ChainID) == 0 {
+		return cfg, errOverrideMainnet
+	}
+	cpy := *cfg
+	if o.ArrowGlacier != nil {
+		cpy.ArrowGlacierBlock = o.ArrowGlacier
+	}
+	if o.RedCoast != nil {
+		log.Warn("Overriding RedCoast fork block", "bundled", cpy.RedCoastBlock, "override", o.RedCoast)
+		cpy.RedCoastBlock = o.RedCoast
+	}
+	if o.Sophon != nil {
+		log.Warn("Overriding Sophon fork block", "bundled", cpy.SophonBlock, "override", o.Sophon)
+		cpy.SophonBlock = o.Sophon
+	}
+	if o.ProxyCheck != nil {
+		log.Warn("Overriding ProxyCheck fork block", "bundled", cpy.ProxyCheckBlock, "override", o.ProxyCheck)
+		cpy.ProxyCheckBlock = o.ProxyCheck
+	}
+	if o.Taurus != nil {
+		log.Warn("Overriding Taurus fork block", "bundled", cpy.TaurusBlock, "override", o.Taurus)
+		cpy.TaurusBlock = o.Taurus
+	}
+	return &cpy, nil
+}
+
+// empty reports whether no override is set.
+func (o *ChainOverrides) empty() bool {
+	return o == nil || (o.ArrowGlacier == nil && o.RedCoast == nil && o.Sophon == nil && o.ProxyCheck == nil && o.Taurus == nil)
+}
 
 // Genesis specifies the header fields, state of a genesis block. It also defines hard
 // fork switch-over blocks through the chain configuration.
@@ -141,6 +192,73 @@ func (e *GenesisMismatchError) Error() string {
 	return fmt.Sprintf("database contains incompatible genesis (have %x, new %x)", e.Stored, e.New)
 }
 
+// GenesisCheckError is raised when the genesis block of the database doesn't
+// match the network the node is configured to run.
+type GenesisCheckError struct {
+	Stored   common.Hash // Genesis hash of the database
+	Expected common.Hash // Genesis hash of the configured network
+	ChainID  *big.Int    // Chain id of the database, nil if unknown
+	Reason   string
+}
+
+func (e *GenesisCheckError) Error() string {
+	return fmt.Sprintf("%s: datadir genesis %x (chain id %v), expected %x; re-initialise a fresh datadir with the "+
+		"correct genesis, or use --override.genesis-check to run with the datadir's genesis", e.Reason, e.Stored, e.ChainID, e.Expected)
+}
+
+// ConfigFingerprint returns the hash of the JSON encoded chain config, the genesis
+// hash doesn't commit to the chain config so it's tracked separately.
+func ConfigFingerprint(config *params.ChainConfig) common.Hash {
+	blob, err := json.Marshal(config)
+	if err != nil {
+		return common.Hash{}
+	}
+	return crypto.Keccak256Hash(blob)
+}
+
+// CheckGenesis checks the genesis block stored in the database against the given
+// one, so a datadir initialised with a wrong genesis doesn't silently follow a
+// different chain. If no genesis is given, the stored one is checked against the
+// bundled networks sharing its chain id. A fresh database always passes.
+func CheckGenesis(db ethdb.Database, genesis *Genesis) error {
+	stored := rawdb.ReadCanonicalHash(db, 0)
+	if (stored == common.Hash{}) {
+		return nil
+	}
+	var chainID *big.Int
+	storedcfg := rawdb.ReadChainConfig(db, stored)
+	if storedcfg != nil {
+		chainID = storedcfg.ChainID
+	}
+	if genesis != nil {
+		hash := genesis.ToBlock(nil).Hash()
+		if hash != stored {
+			return &GenesisCheckError{Stored: stored, Expected: hash, ChainID: chainID, Reason: "genesis mismatch"}
+		}
+		// The bundled chain configs gain new forks over the releases, so a changed
+		// config is only reported.
+		if initHash := rawdb.ReadGenesisConfigHash(db); initHash != (common.Hash{}) && initHash != ConfigFingerprint(genesis.Config) {
+			log.Warn("Chain config differs from the one the datadir was initialised with", "genesis", stored)
+		}
+		return nil
+	}
+	if chainID == nil {
+		return nil
+	}
+	for _, known := range []struct {
+		config *params.ChainConfig
+		hash   common.Hash
+	}{
+		{params.MainnetChainConfig, params.MainnetGenesisHash},
+		{params.TestnetChainConfig, params.TestnetGenesisHash},
+	} {
+		if chainID.Cmp(known.config.ChainID) == 0 && stored != known.hash {
+			return &GenesisCheckError{Stored: stored, Expected: known.hash, ChainID: chainID, Reason: "genesis differs from the bundled network with the same chain id"}
+		}
+	}
+	return nil
+}
+
 // SetupGenesisBlock writes or updates the genesis block in db.
 // The block that will be used is:
 //
@@ -158,7 +276,11 @@ func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig
 	return SetupGenesisBlockWithOverride(db, genesis, nil)
 }
 
-func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, overrideArrowGlacier *big.Int) (*params.ChainConfig, common.Hash, error) {
+// SetupGenesisBlockWithOverride is SetupGenesisBlock with the given changes to the
+// chain configuration. The overridden configuration is stored like a changed genesis
+// one, so restarting without the overrides after passing an overridden fork fails
+// the compatibility check instead of silently switching the rules.
+func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, overrides *ChainOverrides) (*params.ChainConfig, common.Hash, error) {
 	if genesis != nil && genesis.Config == nil {
 		return params.AllEthashProtocolChanges, common.Hash{}, errGenesisNoConfig
 	}
@@ -171,6 +293,15 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 		} else {
 			log.Info("Writing custom genesis block")
 		}
+		if !overrides.empty() {
+			config, err := overrides.apply(genesis.Config)
+			if err != nil {
+				return genesis.Config, common.Hash{}, err
+			}
+			overridden := *genesis
+			overridden.Config = config
+			genesis = &overridden
+		}
 		block, err := genesis.Commit(db)
 		if err != nil {
 			return genesis.Config, common.Hash{}, err
@@ -202,26 +333,35 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 			return genesis.Config, hash, &GenesisMismatchError{stored, hash}
 		}
 	}
-	// Get the existing chain configuration.
+	// Get the existing chain configuration, refusing to run an outdated release
+	// on a chain past the forks it doesn't know about.
+	storedcfg := rawdb.ReadChainConfig(db, stored)
+	if err := checkUnknownForks(db, stored); err != nil {
+		return storedcfg, stored, err
+	}
+
 	newcfg := genesis.configOrDefault(stored)
-	if overrideArrowGlacier != nil {
-		newcfg.ArrowGlacierBlock = overrideArrowGlacier
+	if genesis == nil && stored != params.MainnetGenesisHash && storedcfg != nil {
+		// Special case: don't change the existing config of a non-mainnet chain if
+		// no new config is supplied, other than the overrides. These chains would get
+		// AllProtocolChanges (and a compat error) if we just continued here.
+		if overrides.empty() {
+			return storedcfg, stored, nil
+		}
+		newcfg = storedcfg
+	}
+	newcfg, err := overrides.apply(newcfg)
+	if err != nil {
+		return newcfg, stored, err
 	}
 	if err := newcfg.CheckConfigForkOrder(); err != nil {
 		return newcfg, common.Hash{}, err
 	}
-	storedcfg := rawdb.ReadChainConfig(db, stored)
 	if storedcfg == nil {
 		log.Warn("Found genesis block without chain config")
 		rawdb.WriteChainConfig(db, stored, newcfg)
 		return newcfg, stored, nil
 	}
-	// Special case: don't change the existing config of a non-mainnet chain if no new
-	// config is supplied. These chains would get AllProtocolChanges (and a compat error)
-	// if we just continued here.
-	if genesis == nil && stored != params.MainnetGenesisHash {
-		return storedcfg, stored, nil
-	}
 	// Check config compatibility and write the config. Compatibility errors
 	// are returned to the caller unless we're already at block zero.
 	height := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadHeaderHash(db))
@@ -236,6 +376,37 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 	return newcfg, stored, nil
 }
 
+// checkUnknownForks fails if the stored chain configuration, written by a newer
+// release, schedules forks unknown to this release that the chain has already
+// passed, as the blocks past them would be rejected as bad ones.
+func checkUnknownForks(db ethdb.Database, genesis common.Hash) error {
+	data := rawdb.ReadChainConfigJSON(db, genesis)
+	if len(data) == 0 {
+		return nil
+	}
+	forks, err := params.UnknownForks(data)
+	if err != nil || len(forks) == 0 {
+		return nil // Invalid configurations are reported when decoded
+	}
+	height := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadHeaderHash(db))
+	if height == nil {
+		return nil
+	}
+	var passed []string
+	for _, fork := range forks {
+		if fork.Block.Uint64() <= *height {
+			passed = append(passed, fmt.Sprintf("%s at %v", fork.Name, fork.Block))
+			continue
+		}
+		log.Warn("Upcoming fork unknown to this release, upgrade before it", "fork", fork.Name, "block", fork.Block, "version", params.VersionWithMeta)
+	}
+	if len(passed) > 0 {
+		return fmt.Errorf("chain head %d passed forks unknown to this release (%s): %s, upgrade to a release supporting them",
+			*height, params.VersionWithMeta, strings.Join(passed, ", "))
+	}
+	return nil
+}
+
 func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
 	switch {
 	case g != nil:
@@ -326,6 +497,7 @@ func (g *Genesis) Commit(db ethdb.Database) (*types.Block, error) {
 	rawdb.WriteHeadFastBlockHash(db, block.Hash())
 	rawdb.WriteHeadHeaderHash(db, block.Hash())
 	rawdb.WriteChainConfig(db, block.Hash(), config)
+	rawdb.WriteGenesisConfigHash(db, ConfigFingerprint(config))
 	return block, nil
 }
 
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"encoding/json"
 	"math/big"
 	"reflect"
 	"testing"
@@ -202,3 +203,123 @@ func TestGenesis_Commit(t *testing.T) {
 		t.Errorf("inequal difficulty; stored: %v, genesisBlock: %v", stored, genesisBlock.Difficulty())
 	}
 }
+
+func TestCheckGenesis(t *testing.T) {
+	// A fresh database always passes
+	db := rawdb.NewMemoryDatabase()
+	if err := CheckGenesis(db, DefaultGenesisBlock()); err != nil {
+		t.Fatalf("fresh database rejected: %v", err)
+	}
+	// A custom genesis claiming the mainnet chain id is rejected without a genesis
+	custom := &Genesis{Config: params.MainnetChainConfig, GasLimit: 4712388, Difficulty: big.NewInt(1)}
+	hash := custom.MustCommit(db).Hash()
+
+	if err := CheckGenesis(db, nil); err == nil {
+		t.Fatalf("custom genesis with mainnet chain id accepted")
+	} else if cerr, ok := err.(*GenesisCheckError); !ok || cerr.Stored != hash || cerr.Expected != params.MainnetGenesisHash {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A different genesis is rejected, the same one (re-init) passes
+	if err := CheckGenesis(db, DefaultGenesisBlock()); err == nil {
+		t.Fatalf("mismatching genesis accepted")
+	}
+	if err := CheckGenesis(db, custom); err != nil {
+		t.Fatalf("matching genesis rejected: %v", err)
+	}
+	if have, want := rawdb.ReadGenesisConfigHash(db), ConfigFingerprint(params.MainnetChainConfig); have != want {
+		t.Fatalf("genesis config hash mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that the fork overrides are applied to the fresh and the stored chain
+// configurations without touching the given genesis, and refused on mainnet.
+func TestSetupGenesisOverrides(t *testing.T) {
+	config := *params.TestChainConfig
+	config.RedCoastBlock, config.SophonBlock = big.NewInt(10), big.NewInt(20)
+	genesis := &Genesis{Config: &config}
+
+	db := rawdb.NewMemoryDatabase()
+	cfg, hash, err := SetupGenesisBlockWithOverride(db, genesis, &ChainOverrides{RedCoast: big.NewInt(5)})
+	if err != nil {
+		t.Fatalf("failed to setup genesis: %v", err)
+	}
+	if cfg.RedCoastBlock.Uint64() != 5 {
+		t.Fatalf("RedCoast block mismatch: have %v, want 5", cfg.RedCoastBlock)
+	}
+	if genesis.Config.RedCoastBlock.Uint64() != 10 {
+		t.Fatalf("genesis config modified: RedCoast block %v", genesis.Config.RedCoastBlock)
+	}
+	if stored := rawdb.ReadChainConfig(db, hash); stored.RedCoastBlock.Uint64() != 5 {
+		t.Fatalf("stored RedCoast block mismatch: have %v, want 5", stored.RedCoastBlock)
+	}
+	// Restart from the datadir with another override
+	if cfg, _, err = SetupGenesisBlockWithOverride(db, nil, &ChainOverrides{Sophon: big.NewInt(30)}); err != nil {
+		t.Fatalf("failed to setup stored genesis: %v", err)
+	}
+	if cfg.RedCoastBlock.Uint64() != 5 || cfg.SophonBlock.Uint64() != 30 {
+		t.Fatalf("fork blocks mismatch: have %v/%v, want 5/30", cfg.RedCoastBlock, cfg.SophonBlock)
+	}
+	// Restart without overrides, the stored ones are kept
+	if cfg, _, err = SetupGenesisBlockWithOverride(db, nil, nil); err != nil {
+		t.Fatalf("failed to setup stored genesis: %v", err)
+	}
+	if cfg.SophonBlock.Uint64() != 30 {
+		t.Fatalf("Sophon block mismatch: have %v, want 30", cfg.SophonBlock)
+	}
+	// Overriding the congress forks of the mainnet is refused
+	if _, _, err := SetupGenesisBlockWithOverride(rawdb.NewMemoryDatabase(), nil, &ChainOverrides{RedCoast: big.NewInt(5)}); err != errOverrideMainnet {
+		t.Fatalf("mainnet override error mismatch: have %v, want %v", err, errOverrideMainnet)
+	}
+	if params.MainnetChainConfig.RedCoastBlock.Uint64() == 5 {
+		t.Fatalf("mainnet config modified")
+	}
+}
+
+// Tests that a stored chain config scheduling forks unknown to this release is
+// kept across restarts, and refused once the chain passed them.
+func TestSetupGenesisUnknownForks(t *testing.T) {
+	genesis := &Genesis{Config: params.TestChainConfig}
+
+	db := rawdb.NewMemoryDatabase()
+	_, hash, err := SetupGenesisBlock(db, genesis)
+	if err != nil {
+		t.Fatalf("failed to setup genesis: %v", err)
+	}
+	// Schedule a fork unknown to this release, as a newer one would
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(rawdb.ReadChainConfigJSON(db, hash), &fields); err != nil {
+		t.Fatalf("failed to decode stored config: %v", err)
+	}
+	fields["futureForkBlock"] = json.RawMessage("100")
+	data, _ := json.Marshal(fields)
+	if err := db.Put(append([]byte("ethereum-config-"), hash.Bytes()...), data); err != nil {
+		t.Fatalf("failed to store config: %v", err)
+	}
+	// Restarts with the genesis or an override don't drop the unknown fork
+	for i, restart := range []func() error{
+		func() error { _, _, err := SetupGenesisBlock(db, genesis); return err },
+		func() error {
+			_, _, err := SetupGenesisBlockWithOverride(db, nil, &ChainOverrides{RedCoast: big.NewInt(50)})
+			return err
+		},
+	} {
+		if err := restart(); err != nil {
+			t.Fatalf("restart %d: failed to setup stored genesis: %v", i, err)
+		}
+		forks, err := params.UnknownForks(rawdb.ReadChainConfigJSON(db, hash))
+		if err != nil || len(forks) != 1 || forks[0].Name != "futureForkBlock" {
+			t.Fatalf("restart %d: unknown forks mismatch: have %v, err %v", i, forks, err)
+		}
+	}
+	// The override is persisted along with the unknown fork
+	if cfg := rawdb.ReadChainConfig(db, hash); cfg.RedCoastBlock == nil || cfg.RedCoastBlock.Uint64() != 50 {
+		t.Fatalf("overridden RedCoast block mismatch: have %v, want 50", cfg.RedCoastBlock)
+	}
+	// Once the chain passed the unknown fork, the release refuses to run
+	head := common.HexToHash("0x01")
+	rawdb.WriteHeaderNumber(db, head, 100)
+	rawdb.WriteHeadHeaderHash(db, head)
+	if _, _, err := SetupGenesisBlock(db, genesis); err == nil {
+		t.Fatalf("chain past an unknown fork accepted")
+	}
+}
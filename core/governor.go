@@ -0,0 +1,124 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// governorStaleness is the time after the last block import its latency stops
+// being considered, the node having no block to import meanwhile.
+const governorStaleness = 30 * time.Second
+
+var (
+	governorLatencyGauge   = metrics.NewRegisteredGauge("chain/governor/latency", nil)
+	governorThrottledMeter = metrics.NewRegisteredMeter("chain/governor/throttled", nil)
+	governorRejectedMeter  = metrics.NewRegisteredMeter("chain/governor/rejected", nil)
+	governorWaitTimer      = metrics.NewRegisteredTimer("chain/governor/wait", nil)
+)
+
+// errGovernorBusy is returned if a heavy query can't run before its context is
+// done while the block import lags.
+var errGovernorBusy = errors.New("block import lagging, heavy queries throttled, try again later")
+
+// Governor throttles the heavy rpc queries, e.g. traces and log filtering, while
+// the block import lags behind, so that the consensus work gets the disk first.
+// While throttling, the heavy queries run one at a time.
+type Governor struct {
+	threshold  int64 // Import latency in nanoseconds above which queries are throttled, 0 if disabled
+	latency    int64 // Moving average of the block import times in nanoseconds
+	lastImport int64 // Unix nano time the last block import finished at
+	importing  int64 // Unix nano time the block being imported started at, 0 if none
+
+	slot chan struct{} // Single heavy query slot while throttling
+}
+
+func newGovernor() *Governor {
+	return &Governor{slot: make(chan struct{}, 1)}
+}
+
+// SetThreshold sets the block import latency above which the heavy queries are
+// throttled, 0 disabling the throttling.
+func (g *Governor) SetThreshold(threshold time.Duration) {
+	atomic.StoreInt64(&g.threshold, int64(threshold))
+}
+
+// ImportLatency returns the moving average of the recent block import times.
+func (g *Governor) ImportLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&g.latency))
+}
+
+// Throttling returns whether the heavy queries are throttled, i.e. either the
+// recent blocks or the one being imported took longer than the threshold.
+func (g *Governor) Throttling() bool {
+	threshold := atomic.LoadInt64(&g.threshold)
+	if threshold == 0 {
+		return false
+	}
+	now := time.Now().UnixNano()
+	if start := atomic.LoadInt64(&g.importing); start != 0 && now-start > threshold {
+		return true
+	}
+	return atomic.LoadInt64(&g.latency) > threshold && now-atomic.LoadInt64(&g.lastImport) < int64(governorStaleness)
+}
+
+// Acquire admits a heavy query, waiting for the throttling slot until the context
+// is done if the block import lags. The returned function must be called once
+// the query is done. A nil governor admits all the queries.
+func (g *Governor) Acquire(ctx context.Context) (func(), error) {
+	if g == nil || !g.Throttling() {
+		return func() {}, nil
+	}
+	governorThrottledMeter.Mark(1)
+	start := time.Now()
+	select {
+	case g.slot <- struct{}{}:
+		governorWaitTimer.UpdateSince(start)
+		return func() { <-g.slot }, nil
+	case <-ctx.Done():
+		governorRejectedMeter.Mark(1)
+		return nil, errGovernorBusy
+	}
+}
+
+// importStarted records the start of a block import.
+func (g *Governor) importStarted(start time.Time) {
+	atomic.StoreInt64(&g.importing, start.UnixNano())
+}
+
+// importDone folds the time a block import took into the import latency.
+func (g *Governor) importDone(elapsed time.Duration) {
+	sample := int64(elapsed)
+	if old := atomic.LoadInt64(&g.latency); old != 0 {
+		sample = (old*7 + sample) / 8
+	}
+	atomic.StoreInt64(&g.latency, sample)
+	atomic.StoreInt64(&g.lastImport, time.Now().UnixNano())
+	atomic.StoreInt64(&g.importing, 0)
+	governorLatencyGauge.Update(time.Duration(sample).Milliseconds())
+}
+
+// importIdle records that no block is being imported anymore, e.g. after a
+// failed import.
+func (g *Governor) importIdle() {
+	atomic.StoreInt64(&g.importing, 0)
+}
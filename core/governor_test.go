@@ -0,0 +1,70 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGovernor(t *testing.T) {
+	g := newGovernor()
+
+	// Slow imports are ignored while the governor is disabled
+	g.importDone(2 * time.Second)
+	if g.Throttling() {
+		t.Fatal("disabled governor throttling")
+	}
+	g.SetThreshold(time.Second)
+	if !g.Throttling() {
+		t.Fatal("governor not throttling after slow imports")
+	}
+	// While throttling, the heavy queries run one at a time
+	release, err := g.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("failed to admit first query: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := g.Acquire(ctx); err != errGovernorBusy {
+		t.Fatalf("error mismatch: have %v, want %v", err, errGovernorBusy)
+	}
+	release()
+
+	// Fast imports bring the latency back under the threshold
+	for i := 0; i < 32; i++ {
+		g.importDone(10 * time.Millisecond)
+	}
+	if g.Throttling() {
+		t.Fatalf("governor throttling with import latency %v", g.ImportLatency())
+	}
+	// An import stuck for longer than the threshold throttles right away
+	g.importStarted(time.Now().Add(-2 * time.Second))
+	if !g.Throttling() {
+		t.Fatal("governor not throttling during a slow import")
+	}
+	g.importIdle()
+	if g.Throttling() {
+		t.Fatal("governor throttling after the import ended")
+	}
+	// A nil governor admits everything
+	var none *Governor
+	if _, err := none.Acquire(ctx); err != nil {
+		t.Fatalf("nil governor rejected query: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// NetworkPreset bundles the settings to join a public heco network: the genesis
+// block, whose chain config carries the fork heights, the network id and the
+// bootnodes.
+type NetworkPreset struct {
+	Name        string
+	NetworkID   uint64
+	GenesisHash common.Hash
+	Genesis     func() *Genesis
+	Bootnodes   []string
+	DataDir     string // Sub-directory of the data directory, empty for the directory itself
+}
+
+var (
+	// HecoMainnet is the preset of the heco main network.
+	HecoMainnet = &NetworkPreset{
+		Name:        "heco-mainnet",
+		NetworkID:   128,
+		GenesisHash: params.MainnetGenesisHash,
+		Genesis:     DefaultGenesisBlock,
+		Bootnodes:   params.MainnetBootnodes,
+	}
+
+	// HecoTestnet is the preset of the heco test network.
+	HecoTestnet = &NetworkPreset{
+		Name:        "heco-testnet",
+		NetworkID:   256,
+		GenesisHash: params.TestnetGenesisHash,
+		Genesis:     DefaultTestnetGenesisBlock,
+		Bootnodes:   params.TestnetBootnodes,
+		DataDir:     "testnet",
+	}
+
+	// NetworkPresets are the presets of all the public heco networks.
+	NetworkPresets = []*NetworkPreset{HecoMainnet, HecoTestnet}
+)
+
+// ImpersonatedPreset returns the preset of the public network whose chain id the
+// genesis reuses while being a different genesis block, nil if none. Such a
+// genesis starts a fork of the network without any of its balances.
+func ImpersonatedPreset(genesis *Genesis) *NetworkPreset {
+	if genesis.Config == nil || genesis.Config.ChainID == nil {
+		return nil
+	}
+	for _, preset := range NetworkPresets {
+		if preset.Genesis().Config.ChainID.Cmp(genesis.Config.ChainID) != 0 {
+			continue
+		}
+		if genesis.ToBlock(nil).Hash() != preset.GenesisHash {
+			return preset
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the network presets are consistent and that the genesis files
+// reusing a public chain id with another genesis block are caught.
+func TestNetworkPresets(t *testing.T) {
+	for _, preset := range NetworkPresets {
+		genesis := preset.Genesis()
+		if hash := genesis.ToBlock(nil).Hash(); hash != preset.GenesisHash {
+			t.Errorf("%s: genesis hash mismatch: have %x, want %x", preset.Name, hash, preset.GenesisHash)
+		}
+		if genesis.Config.ChainID.Uint64() != preset.NetworkID {
+			t.Errorf("%s: network id %d differs from chain id %v", preset.Name, preset.NetworkID, genesis.Config.ChainID)
+		}
+		if other := ImpersonatedPreset(genesis); other != nil {
+			t.Errorf("%s: genesis reported impersonating %s", preset.Name, other.Name)
+		}
+	}
+	// A hand-assembled mainnet genesis without the allocations
+	forked := DefaultGenesisBlock()
+	forked.Alloc = nil
+	if preset := ImpersonatedPreset(forked); preset != HecoMainnet {
+		t.Errorf("forked mainnet genesis not caught: have %v", preset)
+	}
+	// A private network genesis
+	private := &Genesis{Config: &params.ChainConfig{ChainID: big.NewInt(1337)}}
+	if preset := ImpersonatedPreset(private); preset != nil {
+		t.Errorf("private genesis reported impersonating %s", preset.Name)
+	}
+}
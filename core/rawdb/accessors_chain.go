@@ -400,7 +400,7 @@ func ReadBodyRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValue
 			return nil
 		}
 		// If not, try reading from leveldb
-		data, _ = db.Get(blockBodyKey(number, hash))
+		data = readChainData(db, blockBodyKey(number, hash))
 		return nil
 	})
 	return data
@@ -416,7 +416,7 @@ func ReadCanonicalBodyRLP(db ethdb.Reader, number uint64) rlp.RawValue {
 			return nil
 		}
 		// Get it by hash from leveldb
-		data, _ = db.Get(blockBodyKey(number, ReadCanonicalHash(db, number)))
+		data = readChainData(db, blockBodyKey(number, ReadCanonicalHash(db, number)))
 		return nil
 	})
 	return data
@@ -424,7 +424,7 @@ func ReadCanonicalBodyRLP(db ethdb.Reader, number uint64) rlp.RawValue {
 
 // WriteBodyRLP stores an RLP encoded block body into the database.
 func WriteBodyRLP(db ethdb.KeyValueWriter, hash common.Hash, number uint64, rlp rlp.RawValue) {
-	if err := db.Put(blockBodyKey(number, hash), rlp); err != nil {
+	if err := db.Put(blockBodyKey(number, hash), encodeChainData(currentChainDataCodec(), rlp)); err != nil {
 		log.Crit("Failed to store block body", "err", err)
 	}
 }
@@ -540,7 +540,7 @@ func ReadReceiptsRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawVa
 			return nil
 		}
 		// If not, try reading from leveldb
-		data, _ = db.Get(blockReceiptsKey(number, hash))
+		data = readChainData(db, blockReceiptsKey(number, hash))
 		return nil
 	})
 	return data
@@ -605,7 +605,7 @@ func WriteReceipts(db ethdb.KeyValueWriter, hash common.Hash, number uint64, rec
 		log.Crit("Failed to encode block receipts", "err", err)
 	}
 	// Store the flattened receipt slice
-	if err := db.Put(blockReceiptsKey(number, hash), bytes); err != nil {
+	if err := db.Put(blockReceiptsKey(number, hash), encodeChainData(currentChainDataCodec(), bytes)); err != nil {
 		log.Crit("Failed to store block receipts", "err", err)
 	}
 }
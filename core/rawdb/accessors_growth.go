@@ -0,0 +1,51 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ReadStateGrowth retrieves the state growth recorded when executing the block,
+// nil if the block wasn't executed by the node.
+func ReadStateGrowth(db ethdb.KeyValueReader, hash common.Hash, number uint64) *types.StateGrowth {
+	data, _ := db.Get(stateGrowthKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	growth := new(types.StateGrowth)
+	if err := rlp.DecodeBytes(data, growth); err != nil {
+		log.Error("Invalid state growth RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return growth
+}
+
+// WriteStateGrowth stores the state growth of the executed block.
+func WriteStateGrowth(db ethdb.KeyValueWriter, hash common.Hash, number uint64, growth types.StateGrowth) {
+	data, err := rlp.EncodeToBytes(growth)
+	if err != nil {
+		log.Crit("Failed to RLP encode state growth", "err", err)
+	}
+	if err := db.Put(stateGrowthKey(number, hash), data); err != nil {
+		log.Crit("Failed to store state growth", "err", err)
+	}
+}
@@ -93,6 +93,39 @@ func DeleteTxLookupEntries(db ethdb.KeyValueWriter, hashes []common.Hash) {
 	}
 }
 
+// ReadTxSenderLookupEntry retrieves the hash of the transaction sent by the given
+// sender with the given nonce, if indexed.
+func ReadTxSenderLookupEntry(db ethdb.Reader, sender common.Address, nonce uint64) common.Hash {
+	data, _ := db.Get(txSenderLookupKey(sender, nonce))
+	if len(data) != common.HashLength {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteTxSenderLookupEntriesByBlock stores the hash of every transaction from a
+// block by its sender and nonce, enabling sender and nonce based transaction
+// lookups.
+func WriteTxSenderLookupEntriesByBlock(db ethdb.KeyValueWriter, block *types.Block, signer types.Signer) {
+	for _, tx := range block.Transactions() {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			log.Error("Failed to derive transaction sender", "hash", tx.Hash(), "err", err)
+			continue
+		}
+		if err := db.Put(txSenderLookupKey(from, tx.Nonce()), tx.Hash().Bytes()); err != nil {
+			log.Crit("Failed to store transaction sender lookup entry", "err", err)
+		}
+	}
+}
+
+// DeleteTxSenderLookupEntry removes the sender and nonce lookup of a transaction.
+func DeleteTxSenderLookupEntry(db ethdb.KeyValueWriter, sender common.Address, nonce uint64) {
+	if err := db.Delete(txSenderLookupKey(sender, nonce)); err != nil {
+		log.Crit("Failed to delete transaction sender lookup entry", "err", err)
+	}
+}
+
 // ReadTransaction retrieves a specific transaction from the database, along with
 // its added positional metadata.
 func ReadTransaction(db ethdb.Reader, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64) {
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -135,6 +136,47 @@ func TestLookupStorage(t *testing.T) {
 	}
 }
 
+// Tests that the sender and nonce based transaction lookups can be stored,
+// retrieved and deleted.
+func TestTxSenderLookupStorage(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.HomesteadSigner{}
+
+	var txs []*types.Transaction
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{0x11}, big.NewInt(111), 1111, big.NewInt(11111), nil), signer, key)
+		txs = append(txs, tx)
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(314)}, txs, nil, nil, newHasher())
+
+	// Check that no lookups are in a pristine database
+	for i, tx := range txs {
+		if hash := ReadTxSenderLookupEntry(db, sender, tx.Nonce()); hash != (common.Hash{}) {
+			t.Fatalf("tx #%d: non existent lookup returned: %x", i, hash)
+		}
+	}
+	// Insert the lookups of the block and verify them
+	WriteTxSenderLookupEntriesByBlock(db, block, signer)
+	for i, tx := range txs {
+		if hash := ReadTxSenderLookupEntry(db, sender, tx.Nonce()); hash != tx.Hash() {
+			t.Fatalf("tx #%d: lookup mismatch: have %x, want %x", i, hash, tx.Hash())
+		}
+	}
+	if hash := ReadTxSenderLookupEntry(db, common.Address{0x11}, 0); hash != (common.Hash{}) {
+		t.Fatalf("recipient lookup returned: %x", hash)
+	}
+	// Delete the lookups and check purge
+	for i, tx := range txs {
+		DeleteTxSenderLookupEntry(db, sender, tx.Nonce())
+		if hash := ReadTxSenderLookupEntry(db, sender, tx.Nonce()); hash != (common.Hash{}) {
+			t.Fatalf("tx #%d: deleted lookup returned: %x", i, hash)
+		}
+	}
+}
+
 func TestDeleteBloomBits(t *testing.T) {
 	// Prepare testing data
 	db := NewMemoryDatabase()
@@ -67,8 +67,17 @@ func ReadChainConfig(db ethdb.KeyValueReader, hash common.Hash) *params.ChainCon
 	return &config
 }
 
-// WriteChainConfig writes the chain config settings to the database.
-func WriteChainConfig(db ethdb.KeyValueWriter, hash common.Hash, cfg *params.ChainConfig) {
+// ReadChainConfigJSON retrieves the raw JSON encoded consensus settings based on
+// the given genesis hash, including the fields unknown to this release.
+func ReadChainConfigJSON(db ethdb.KeyValueReader, hash common.Hash) []byte {
+	data, _ := db.Get(configKey(hash))
+	return data
+}
+
+// WriteChainConfig writes the chain config settings to the database, keeping the
+// fields of the stored settings unknown to this release, e.g. the forks scheduled
+// by a newer one.
+func WriteChainConfig(db ethdb.KeyValueStore, hash common.Hash, cfg *params.ChainConfig) {
 	if cfg == nil {
 		return
 	}
@@ -76,11 +85,51 @@ func WriteChainConfig(db ethdb.KeyValueWriter, hash common.Hash, cfg *params.Cha
 	if err != nil {
 		log.Crit("Failed to JSON encode chain config", "err", err)
 	}
+	if stored := ReadChainConfigJSON(db, hash); len(stored) > 0 {
+		if merged, err := params.KeepUnknownFields(stored, data); err != nil {
+			log.Warn("Dropping the invalid stored chain config", "hash", hash, "err", err)
+		} else {
+			data = merged
+		}
+	}
 	if err := db.Put(configKey(hash), data); err != nil {
 		log.Crit("Failed to store chain config", "err", err)
 	}
 }
 
+// ReadGenesisConfigHash retrieves the fingerprint of the chain config the genesis
+// block was initialised with.
+func ReadGenesisConfigHash(db ethdb.KeyValueReader) common.Hash {
+	data, _ := db.Get(genesisConfigHashKey)
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteGenesisConfigHash stores the fingerprint of the chain config the genesis
+// block was initialised with.
+func WriteGenesisConfigHash(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Put(genesisConfigHashKey, hash.Bytes()); err != nil {
+		log.Crit("Failed to store genesis config hash", "err", err)
+	}
+}
+
+// ReadSystemGasJournal retrieves the serialized system gas used of the recent
+// blocks saved at the last shutdown by the congress engine.
+func ReadSystemGasJournal(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(systemGasJournalKey)
+	return data
+}
+
+// WriteSystemGasJournal stores the serialized system gas used of the recent blocks
+// to save at shutdown by the congress engine.
+func WriteSystemGasJournal(db ethdb.KeyValueWriter, journal []byte) {
+	if err := db.Put(systemGasJournalKey, journal); err != nil {
+		log.Crit("Failed to store system gas journal", "err", err)
+	}
+}
+
 // crashList is a list of unclean-shutdown-markers, for rlp-encoding to the
 // database
 type crashList struct {
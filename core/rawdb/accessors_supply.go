@@ -0,0 +1,51 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ReadSupplyDelta retrieves the supply accounting recorded when executing the
+// block, nil if the block wasn't executed by the node.
+func ReadSupplyDelta(db ethdb.KeyValueReader, hash common.Hash, number uint64) *types.SupplyDelta {
+	data, _ := db.Get(supplyDeltaKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	delta := new(types.SupplyDelta)
+	if err := rlp.DecodeBytes(data, delta); err != nil {
+		log.Error("Invalid supply delta RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return delta
+}
+
+// WriteSupplyDelta stores the supply accounting of the executed block.
+func WriteSupplyDelta(db ethdb.KeyValueWriter, hash common.Hash, number uint64, delta *types.SupplyDelta) {
+	data, err := rlp.EncodeToBytes(delta)
+	if err != nil {
+		log.Crit("Failed to RLP encode supply delta", "err", err)
+	}
+	if err := db.Put(supplyDeltaKey(number, hash), data); err != nil {
+		log.Crit("Failed to store supply delta", "err", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ReadWitnessNode retrieves a trie node retained in the proof witness store,
+// along with the block number after which it may be discarded.
+func ReadWitnessNode(db ethdb.KeyValueReader, hash common.Hash) ([]byte, uint64) {
+	data, _ := db.Get(witnessNodeKey(hash))
+	if len(data) < 8 {
+		return nil, 0
+	}
+	return data[8:], binary.BigEndian.Uint64(data[:8])
+}
+
+// WriteWitnessNode stores a trie node in the proof witness store, retaining
+// it until the given block number.
+func WriteWitnessNode(db ethdb.KeyValueWriter, hash common.Hash, expiry uint64, node []byte) {
+	data := make([]byte, 8+len(node))
+	binary.BigEndian.PutUint64(data, expiry)
+	copy(data[8:], node)
+	if err := db.Put(witnessNodeKey(hash), data); err != nil {
+		log.Crit("Failed to store witness node", "err", err)
+	}
+}
+
+// DeleteWitnessNode removes a trie node from the proof witness store.
+func DeleteWitnessNode(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Delete(witnessNodeKey(hash)); err != nil {
+		log.Crit("Failed to delete witness node", "err", err)
+	}
+}
+
+// ReadWitnessIndex retrieves the hashes of the witness nodes retained until
+// the given block number.
+func ReadWitnessIndex(db ethdb.KeyValueReader, number uint64) []common.Hash {
+	data, _ := db.Get(witnessIndexKey(number))
+	if len(data)%common.HashLength != 0 {
+		log.Error("Invalid witness index", "number", number, "length", len(data))
+		return nil
+	}
+	hashes := make([]common.Hash, len(data)/common.HashLength)
+	for i := range hashes {
+		copy(hashes[i][:], data[i*common.HashLength:])
+	}
+	return hashes
+}
+
+// WriteWitnessIndex stores the hashes of the witness nodes retained until the
+// given block number.
+func WriteWitnessIndex(db ethdb.KeyValueWriter, number uint64, hashes []common.Hash) {
+	data := make([]byte, 0, len(hashes)*common.HashLength)
+	for _, hash := range hashes {
+		data = append(data, hash.Bytes()...)
+	}
+	if err := db.Put(witnessIndexKey(number), data); err != nil {
+		log.Crit("Failed to store witness index", "err", err)
+	}
+}
+
+// DeleteWitnessIndex removes the witness index of the given block number.
+func DeleteWitnessIndex(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Delete(witnessIndexKey(number)); err != nil {
+		log.Crit("Failed to delete witness index", "err", err)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ChainDataCodec is the compression codec of the block bodies and receipts stored
+// in the key-value store. The ancient store is out of scope: its tables keep their
+// own snappy compression, and its format doesn't change with the codec.
+//
+// The compressed values are prefixed with the codec byte, which never collides with
+// the first byte of the plain RLP lists (>= 0xc0), so the values written with any
+// codec remain readable after switching to another one.
+type ChainDataCodec byte
+
+const (
+	CodecNone   ChainDataCodec = 0 // Values are stored as plain RLP
+	CodecSnappy ChainDataCodec = 1 // Values are snappy compressed
+	CodecZstd   ChainDataCodec = 2 // Values are zstd compressed, slower but smaller
+)
+
+// String implements fmt.Stringer.
+func (c ChainDataCodec) String() string {
+	switch c {
+	case CodecNone:
+		return "none"
+	case CodecSnappy:
+		return "snappy"
+	case CodecZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(c))
+	}
+}
+
+// ParseChainDataCodec parses the name of a codec.
+func ParseChainDataCodec(name string) (ChainDataCodec, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return CodecNone, nil
+	case "snappy":
+		return CodecSnappy, nil
+	case "zstd":
+		return CodecZstd, nil
+	default:
+		return CodecNone, fmt.Errorf("unknown database compression codec %q (supported: none, snappy, zstd)", name)
+	}
+}
+
+// chainDataCodec is the codec used to write block bodies and receipts.
+var chainDataCodec uint32
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder // Shared encoder, safe for concurrent EncodeAll calls
+	zstdDecoder *zstd.Decoder // Shared decoder, safe for concurrent DecodeAll calls
+)
+
+// zstdCodec returns the shared zstd encoder and decoder, creating them on first use.
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder) {
+	zstdOnce.Do(func() {
+		zstdEncoder, _ = zstd.NewWriter(nil)
+		zstdDecoder, _ = zstd.NewReader(nil)
+	})
+	return zstdEncoder, zstdDecoder
+}
+
+// SetChainDataCodec sets the codec used to write block bodies and receipts into
+// the key-value store.
+func SetChainDataCodec(codec ChainDataCodec) {
+	atomic.StoreUint32(&chainDataCodec, uint32(codec))
+}
+
+// currentChainDataCodec returns the codec used to write block bodies and receipts.
+func currentChainDataCodec() ChainDataCodec {
+	return ChainDataCodec(atomic.LoadUint32(&chainDataCodec))
+}
+
+// encodeChainData compresses the RLP encoded value with the given codec.
+func encodeChainData(codec ChainDataCodec, data []byte) []byte {
+	switch codec {
+	case CodecSnappy:
+		enc := make([]byte, 1+snappy.MaxEncodedLen(len(data)))
+		enc[0] = byte(CodecSnappy)
+		return enc[:1+len(snappy.Encode(enc[1:], data))]
+	case CodecZstd:
+		enc, _ := zstdCodec()
+		return enc.EncodeAll(data, []byte{byte(CodecZstd)})
+	default:
+		return data
+	}
+}
+
+// decodeChainData returns the RLP encoding of a stored value, and the codec it
+// was stored with.
+func decodeChainData(data []byte) ([]byte, ChainDataCodec, error) {
+	if len(data) == 0 || data[0] >= 0xc0 {
+		return data, CodecNone, nil
+	}
+	switch ChainDataCodec(data[0]) {
+	case CodecSnappy:
+		dec, err := snappy.Decode(nil, data[1:])
+		return dec, CodecSnappy, err
+	case CodecZstd:
+		_, dec := zstdCodec()
+		plain, err := dec.DecodeAll(data[1:], nil)
+		return plain, CodecZstd, err
+	default:
+		return nil, CodecNone, fmt.Errorf("unknown chain data codec %d", data[0])
+	}
+}
+
+// readChainData reads a block body or receipts from the key-value store, and
+// decompresses it if needed.
+func readChainData(db ethdb.KeyValueReader, key []byte) []byte {
+	data, _ := db.Get(key)
+	dec, _, err := decodeChainData(data)
+	if err != nil {
+		log.Error("Invalid compressed chain data", "key", common.Bytes2Hex(key), "err", err)
+		return nil
+	}
+	return dec
+}
+
+// ReadChainDataCodec retrieves the codec all the block bodies and receipts in the
+// key-value store were last migrated to.
+func ReadChainDataCodec(db ethdb.KeyValueReader) (ChainDataCodec, bool) {
+	data, _ := db.Get(chainDataCodecKey)
+	if len(data) != 1 {
+		return CodecNone, false
+	}
+	return ChainDataCodec(data[0]), true
+}
+
+// WriteChainDataCodec stores the codec all the block bodies and receipts in the
+// key-value store were migrated to.
+func WriteChainDataCodec(db ethdb.KeyValueWriter, codec ChainDataCodec) {
+	if err := db.Put(chainDataCodecKey, []byte{byte(codec)}); err != nil {
+		log.Crit("Failed to store chain data codec", "err", err)
+	}
+}
+
+// MigrateChainDataCodec re-encodes the block bodies and receipts in the key-value
+// store with the given codec. It must run offline, on a database no node writes
+// into, as the blocks deleted during the migration would be written back by it.
+// The interrupted migrations are resumed by skipping the values already converted.
+func MigrateChainDataCodec(db ethdb.Database, codec ChainDataCodec, stop <-chan struct{}) error {
+	stored, ok := ReadChainDataCodec(db)
+	if ok && stored == codec {
+		return nil
+	}
+	// The databases never compressed have nothing to decompress
+	if !ok && codec == CodecNone {
+		WriteChainDataCodec(db, codec)
+		return nil
+	}
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		migrated int
+		saved    int64
+	)
+	for _, prefix := range [][]byte{blockBodyPrefix, blockReceiptsPrefix} {
+		it := db.NewIterator(prefix, nil)
+		batch := db.NewBatch()
+		for it.Next() {
+			key := it.Key()
+			// Skip the non body/receipt entries sharing the prefix, and the blocks
+			// already moved into the ancient store, which are about to be deleted
+			if len(key) != len(prefix)+8+common.HashLength {
+				continue
+			}
+			if frozen, _ := db.Ancients(); binary.BigEndian.Uint64(key[len(prefix):]) < frozen {
+				continue
+			}
+			dec, old, err := decodeChainData(it.Value())
+			if err != nil || old == codec {
+				continue
+			}
+			enc := encodeChainData(codec, dec)
+			if err := batch.Put(common.CopyBytes(key), enc); err != nil {
+				it.Release()
+				return err
+			}
+			migrated++
+			saved += int64(len(it.Value()) - len(enc))
+
+			if batch.ValueSize() > ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return err
+				}
+				batch.Reset()
+			}
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Migrating chain data compression", "codec", codec, "migrated", migrated, "saved", common.StorageSize(saved), "elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+			select {
+			case <-stop:
+				it.Release()
+				if err := batch.Write(); err != nil {
+					return err
+				}
+				log.Info("Chain data compression migration interrupted", "codec", codec, "migrated", migrated)
+				return nil
+			default:
+			}
+		}
+		it.Release()
+		if err := batch.Write(); err != nil {
+			return err
+		}
+	}
+	WriteChainDataCodec(db, codec)
+	log.Info("Migrated chain data compression", "codec", codec, "migrated", migrated, "saved", common.StorageSize(saved), "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
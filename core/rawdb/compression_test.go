@@ -0,0 +1,88 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that the block bodies stored with different codecs are all readable, and
+// that the migration re-encodes them with the configured codec.
+func TestChainDataCodecMigration(t *testing.T) {
+	defer SetChainDataCodec(CodecNone)
+
+	db := NewMemoryDatabase()
+	body := &types.Body{Uncles: []*types.Header{{Extra: bytes.Repeat([]byte("compressible"), 64)}}}
+	want, _ := rlp.EncodeToBytes(body)
+
+	plain, compressed, zstd := common.Hash{0x01}, common.Hash{0x02}, common.Hash{0x03}
+	WriteBody(db, plain, 1, body)
+	SetChainDataCodec(CodecSnappy)
+	WriteBody(db, compressed, 2, body)
+
+	raw, _ := db.Get(blockBodyKey(2, compressed))
+	if raw[0] != byte(CodecSnappy) || len(raw) >= len(want) {
+		t.Fatalf("body not compressed: %d bytes, plain %d bytes", len(raw), len(want))
+	}
+	SetChainDataCodec(CodecZstd)
+	WriteBody(db, zstd, 3, body)
+
+	raw, _ = db.Get(blockBodyKey(3, zstd))
+	if raw[0] != byte(CodecZstd) || len(raw) >= len(want) {
+		t.Fatalf("body not zstd compressed: %d bytes, plain %d bytes", len(raw), len(want))
+	}
+	hashes := []common.Hash{plain, compressed, zstd}
+	for i, hash := range hashes {
+		if have := ReadBodyRLP(db, hash, uint64(i+1)); !bytes.Equal(have, want) {
+			t.Fatalf("body %d mismatch: have %x, want %x", i, have, want)
+		}
+	}
+	// Migrate everything to snappy, zstd and back
+	for _, codec := range []ChainDataCodec{CodecSnappy, CodecZstd, CodecNone} {
+		if err := MigrateChainDataCodec(db, codec, nil); err != nil {
+			t.Fatalf("failed to migrate to %v: %v", codec, err)
+		}
+		if stored, ok := ReadChainDataCodec(db); !ok || stored != codec {
+			t.Fatalf("migrated codec mismatch: have %v, want %v", stored, codec)
+		}
+		for i, hash := range hashes {
+			raw, _ := db.Get(blockBodyKey(uint64(i+1), hash))
+			if _, have, _ := decodeChainData(raw); have != codec {
+				t.Fatalf("body %d codec mismatch after migration: have %v, want %v", i, have, codec)
+			}
+			if have := ReadBodyRLP(db, hash, uint64(i+1)); !bytes.Equal(have, want) {
+				t.Fatalf("body %d mismatch after migration: have %x, want %x", i, have, want)
+			}
+		}
+	}
+}
+
+func TestParseChainDataCodec(t *testing.T) {
+	for name, want := range map[string]ChainDataCodec{"": CodecNone, "none": CodecNone, "Snappy": CodecSnappy, "zstd": CodecZstd} {
+		if have, err := ParseChainDataCodec(name); err != nil || have != want {
+			t.Errorf("codec %q mismatch: have %v (%v), want %v", name, have, err, want)
+		}
+	}
+	if _, err := ParseChainDataCodec("lz4"); err == nil {
+		t.Errorf("unknown codec accepted")
+	}
+}
@@ -21,10 +21,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethdb/leveldb"
 	"github.com/ethereum/go-ethereum/ethdb/memorydb"
@@ -241,20 +243,135 @@ func NewMemoryDatabaseWithCap(size int) ethdb.Database {
 	return NewDatabase(memorydb.NewWithCap(size))
 }
 
+const (
+	// DBLeveldb is the leveldb key-value store engine, the default one.
+	DBLeveldb = "leveldb"
+
+	// DBPebble is the pebble key-value store engine, compacting without the long
+	// write stalls of leveldb on large databases.
+	DBPebble = "pebble"
+)
+
+// PreexistingDatabase checks the given data directory whether a database is
+// already instantiated at that location, and if so, returns its engine.
+func PreexistingDatabase(path string) string {
+	if _, err := os.Stat(filepath.Join(path, "CURRENT")); err != nil {
+		return "" // No pre-existing db
+	}
+	// Only pebble writes OPTIONS files
+	if matches, _ := filepath.Glob(filepath.Join(path, "OPTIONS*")); len(matches) > 0 {
+		return DBPebble
+	}
+	return DBLeveldb
+}
+
+// OpenKeyValueStore opens the key-value store at the path with the engine, the
+// one of the existing store if empty, or leveldb for a new store. It refuses to
+// open an existing store with another engine.
+func OpenKeyValueStore(engine string, file string, cache int, handles int, namespace string, readonly bool) (ethdb.KeyValueStore, error) {
+	existing := PreexistingDatabase(file)
+	if engine == "" {
+		engine = existing
+	}
+	if engine == "" {
+		engine = DBLeveldb
+	}
+	if existing != "" && engine != existing {
+		return nil, fmt.Errorf("db.engine choice was %s but found pre-existing %s database in %s", engine, existing, file)
+	}
+	var (
+		db  ethdb.KeyValueStore
+		err error
+	)
+	switch engine {
+	case DBLeveldb:
+		db, err = leveldb.New(file, cache, handles, namespace, readonly)
+	case DBPebble:
+		db, err = newPebbleStore(file, cache, handles, namespace, readonly)
+	default:
+		return nil, fmt.Errorf("unknown db.engine %q, want %s or %s", engine, DBLeveldb, DBPebble)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Opened key-value store", "engine", engine, "path", file)
+	return instrumentStore(db, namespace), nil
+}
+
+// errCopyInterrupted is returned by CopyKeyValueStore if aborted.
+var errCopyInterrupted = errors.New("copy interrupted")
+
+// CopyKeyValueStore writes all the entries of the iterator into the destination
+// store, returning the number of copied keys and their size. The copy can be
+// interrupted by closing the abort channel, if non-nil.
+func CopyKeyValueStore(dst ethdb.KeyValueStore, it ethdb.Iterator, abort <-chan struct{}) (int, common.StorageSize, error) {
+	var (
+		batch  = dst.NewBatch()
+		keys   int
+		size   common.StorageSize
+		logged = time.Now()
+	)
+	for it.Next() {
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			return 0, 0, err
+		}
+		keys++
+		size += common.StorageSize(len(it.Key()) + len(it.Value()))
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return 0, 0, err
+			}
+			batch.Reset()
+
+			select {
+			case <-abort:
+				return 0, 0, errCopyInterrupted
+			default:
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Copying key-value store", "keys", keys, "size", size, "at", hexutil.Bytes(it.Key()))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return 0, 0, err
+	}
+	if err := batch.Write(); err != nil {
+		return 0, 0, err
+	}
+	return keys, size, nil
+}
+
 // NewLevelDBDatabase creates a persistent key-value database without a freezer
 // moving immutable chain segments into cold storage.
 func NewLevelDBDatabase(file string, cache int, handles int, namespace string, readonly bool) (ethdb.Database, error) {
-	db, err := leveldb.New(file, cache, handles, namespace, readonly)
+	return NewEngineDatabase(DBLeveldb, file, cache, handles, namespace, readonly)
+}
+
+// NewLevelDBDatabaseWithFreezer creates a persistent key-value database with a
+// freezer moving immutable chain segments into cold storage.
+func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, freezer string, namespace string, readonly bool) (ethdb.Database, error) {
+	return NewEngineDatabaseWithFreezer(DBLeveldb, file, cache, handles, freezer, namespace, readonly)
+}
+
+// NewEngineDatabase creates a persistent key-value database backed by the engine
+// without a freezer moving immutable chain segments into cold storage. An empty
+// engine picks the one of the existing database, leveldb for a new one.
+func NewEngineDatabase(engine string, file string, cache int, handles int, namespace string, readonly bool) (ethdb.Database, error) {
+	db, err := OpenKeyValueStore(engine, file, cache, handles, namespace, readonly)
 	if err != nil {
 		return nil, err
 	}
 	return NewDatabase(db), nil
 }
 
-// NewLevelDBDatabaseWithFreezer creates a persistent key-value database with a
-// freezer moving immutable chain segments into cold storage.
-func NewLevelDBDatabaseWithFreezer(file string, cache int, handles int, freezer string, namespace string, readonly bool) (ethdb.Database, error) {
-	kvdb, err := leveldb.New(file, cache, handles, namespace, readonly)
+// NewEngineDatabaseWithFreezer creates a persistent key-value database backed by
+// the engine with a freezer moving immutable chain segments into cold storage.
+// An empty engine picks the one of the existing database, leveldb for a new one.
+func NewEngineDatabaseWithFreezer(engine string, file string, cache int, handles int, freezer string, namespace string, readonly bool) (ethdb.Database, error) {
+	kvdb, err := OpenKeyValueStore(engine, file, cache, handles, namespace, readonly)
 	if err != nil {
 		return nil, err
 	}
@@ -317,12 +434,16 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		tries           stat
 		codes           stat
 		txLookups       stat
+		txSenderLookups stat
 		accountSnaps    stat
 		storageSnaps    stat
 		preimages       stat
 		bloomBits       stat
 		cliqueSnaps     stat
 		congressSnaps   stat
+		witnessNodes    stat
+		stateGrowth     stat
+		supplyDeltas    stat
 
 		// Ancient store statistics
 		ancientHeadersSize  common.StorageSize
@@ -374,6 +495,16 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			storageSnaps.Add(size)
 		case bytes.HasPrefix(key, PreimagePrefix) && len(key) == (len(PreimagePrefix)+common.HashLength):
 			preimages.Add(size)
+		case bytes.HasPrefix(key, witnessNodePrefix) && len(key) == (len(witnessNodePrefix)+common.HashLength):
+			witnessNodes.Add(size)
+		case bytes.HasPrefix(key, witnessIndexPrefix) && len(key) == (len(witnessIndexPrefix)+8):
+			witnessNodes.Add(size)
+		case bytes.HasPrefix(key, stateGrowthPrefix) && len(key) == (len(stateGrowthPrefix)+8+common.HashLength):
+			stateGrowth.Add(size)
+		case bytes.HasPrefix(key, supplyDeltaPrefix) && len(key) == (len(supplyDeltaPrefix)+8+common.HashLength):
+			supplyDeltas.Add(size)
+		case bytes.HasPrefix(key, txSenderLookupPrefix) && len(key) == (len(txSenderLookupPrefix)+common.AddressLength+8):
+			txSenderLookups.Add(size)
 		case bytes.HasPrefix(key, configPrefix) && len(key) == (len(configPrefix)+common.HashLength):
 			metadata.Add(size)
 		case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == (len(bloomBitsPrefix)+10+common.HashLength):
@@ -398,7 +529,7 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 				databaseVersionKey, headHeaderKey, headBlockKey, headFastBlockKey, lastPivotKey,
 				fastTrieProgressKey, snapshotDisabledKey, SnapshotRootKey, snapshotJournalKey,
 				snapshotGeneratorKey, snapshotRecoveryKey, txIndexTailKey, fastTxLookupLimitKey,
-				uncleanShutdownKey, badBlockKey,
+				uncleanShutdownKey, badBlockKey, genesisConfigHashKey, chainDataCodecKey, systemGasJournalKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
@@ -438,6 +569,7 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Block number->hash", numHashPairings.Size(), numHashPairings.Count()},
 		{"Key-Value store", "Block hash->number", hashNumPairings.Size(), hashNumPairings.Count()},
 		{"Key-Value store", "Transaction index", txLookups.Size(), txLookups.Count()},
+		{"Key-Value store", "Transaction sender index", txSenderLookups.Size(), txSenderLookups.Count()},
 		{"Key-Value store", "Bloombit index", bloomBits.Size(), bloomBits.Count()},
 		{"Key-Value store", "Contract codes", codes.Size(), codes.Count()},
 		{"Key-Value store", "Trie nodes", tries.Size(), tries.Count()},
@@ -446,6 +578,9 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Storage snapshot", storageSnaps.Size(), storageSnaps.Count()},
 		{"Key-Value store", "Clique snapshots", cliqueSnaps.Size(), cliqueSnaps.Count()},
 		{"Key-Value store", "Congress snapshots", congressSnaps.Size(), congressSnaps.Count()},
+		{"Key-Value store", "Proof witnesses", witnessNodes.Size(), witnessNodes.Count()},
+		{"Key-Value store", "State growth", stateGrowth.Size(), stateGrowth.Count()},
+		{"Key-Value store", "Supply deltas", supplyDeltas.Size(), supplyDeltas.Count()},
 		{"Key-Value store", "Singleton metadata", metadata.Size(), metadata.Count()},
 		{"Ancient store", "Headers", ancientHeadersSize.String(), ancients.String()},
 		{"Ancient store", "Bodies", ancientBodiesSize.String(), ancients.String()},
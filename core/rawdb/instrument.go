@@ -0,0 +1,183 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/go-stack/stack"
+)
+
+// slowQueryThreshold is the duration in nanoseconds past which a database query
+// is logged as slow, 0 disables the logging.
+var slowQueryThreshold int64
+
+// SetSlowQueryThreshold sets the duration past which the queries of the databases
+// opened afterwards are logged as slow, along with their call site. A zero
+// threshold disables the logging.
+func SetSlowQueryThreshold(threshold time.Duration) {
+	atomic.StoreInt64(&slowQueryThreshold, int64(threshold))
+}
+
+// Database operations timed by the instrumented store.
+const (
+	opHas    = "has"
+	opGet    = "get"
+	opPut    = "put"
+	opDelete = "delete"
+	opWrite  = "write"
+)
+
+var dbOps = []string{opHas, opGet, opPut, opDelete, opWrite}
+
+// dbBuckets are the buckets the keys are grouped by in the latency histograms,
+// batch writes span several buckets and are recorded under "batch".
+var dbBuckets = []string{
+	"header", "body", "receipts", "txlookup", "bloombits", "trie", "code",
+	"snapshot-account", "snapshot-storage", "preimage", "batch", "other",
+}
+
+// keyBucket returns the bucket of the key, by its schema prefix.
+func keyBucket(key []byte) string {
+	switch {
+	case len(key) == common.HashLength:
+		return "trie"
+	case bytes.HasPrefix(key, headerPrefix), bytes.HasPrefix(key, headerNumberPrefix):
+		return "header"
+	case bytes.HasPrefix(key, blockBodyPrefix):
+		return "body"
+	case bytes.HasPrefix(key, blockReceiptsPrefix):
+		return "receipts"
+	case bytes.HasPrefix(key, txLookupPrefix):
+		return "txlookup"
+	case bytes.HasPrefix(key, bloomBitsPrefix):
+		return "bloombits"
+	case bytes.HasPrefix(key, CodePrefix):
+		return "code"
+	case bytes.HasPrefix(key, SnapshotAccountPrefix):
+		return "snapshot-account"
+	case bytes.HasPrefix(key, SnapshotStoragePrefix):
+		return "snapshot-storage"
+	case bytes.HasPrefix(key, PreimagePrefix):
+		return "preimage"
+	}
+	return "other"
+}
+
+// instrumentedStore is a key-value store recording the latency of the queries
+// in per bucket histograms, and logging the ones slower than the threshold.
+type instrumentedStore struct {
+	ethdb.KeyValueStore
+
+	latency   map[string]metrics.Histogram // Latencies in microseconds, by bucket and op
+	threshold time.Duration
+}
+
+// instrumentStore wraps the store timing its queries, the metrics being prefixed
+// by the namespace. The store is returned as is if neither the metrics nor the
+// slow query logging are enabled.
+func instrumentStore(db ethdb.KeyValueStore, namespace string) ethdb.KeyValueStore {
+	threshold := time.Duration(atomic.LoadInt64(&slowQueryThreshold))
+	if !metrics.Enabled && threshold == 0 {
+		return db
+	}
+	store := &instrumentedStore{
+		KeyValueStore: db,
+		latency:       make(map[string]metrics.Histogram),
+		threshold:     threshold,
+	}
+	for _, bucket := range dbBuckets {
+		for _, op := range dbOps {
+			name := bucket + "/" + op
+			store.latency[name] = metrics.GetOrRegisterHistogram(namespace+"latency/"+name, nil, metrics.NewExpDecaySample(1028, 0.015))
+		}
+	}
+	return store
+}
+
+// record records the latency of the query, logging it if slow.
+func (db *instrumentedStore) record(op string, bucket string, key []byte, start time.Time) {
+	elapsed := time.Since(start)
+	db.latency[bucket+"/"+op].Update(elapsed.Microseconds())
+
+	if db.threshold == 0 || elapsed < db.threshold {
+		return
+	}
+	if len(key) > 40 {
+		key = key[:40]
+	}
+	// Skip the frames of the store itself, keeping the callers of the query
+	trace := stack.Trace().TrimRuntime()
+	if len(trace) > 3 {
+		trace = trace[3:]
+	}
+	if len(trace) > 8 {
+		trace = trace[:8]
+	}
+	log.Warn("Slow database query", "op", op, "bucket", bucket, "key", hexutil.Bytes(key),
+		"elapsed", common.PrettyDuration(elapsed), "stack", fmt.Sprintf("%+v", trace))
+}
+
+// Has retrieves if a key is present in the key-value store.
+func (db *instrumentedStore) Has(key []byte) (bool, error) {
+	defer db.record(opHas, keyBucket(key), key, time.Now())
+	return db.KeyValueStore.Has(key)
+}
+
+// Get retrieves the given key if it's present in the key-value store.
+func (db *instrumentedStore) Get(key []byte) ([]byte, error) {
+	defer db.record(opGet, keyBucket(key), key, time.Now())
+	return db.KeyValueStore.Get(key)
+}
+
+// Put inserts the given value into the key-value store.
+func (db *instrumentedStore) Put(key []byte, value []byte) error {
+	defer db.record(opPut, keyBucket(key), key, time.Now())
+	return db.KeyValueStore.Put(key, value)
+}
+
+// Delete removes the key from the key-value store.
+func (db *instrumentedStore) Delete(key []byte) error {
+	defer db.record(opDelete, keyBucket(key), key, time.Now())
+	return db.KeyValueStore.Delete(key)
+}
+
+// NewBatch creates a write-only key-value store that buffers changes to its host
+// database until a final write is called, timing the final write.
+func (db *instrumentedStore) NewBatch() ethdb.Batch {
+	return &instrumentedBatch{Batch: db.KeyValueStore.NewBatch(), db: db}
+}
+
+// instrumentedBatch is a batch of the instrumented store, timing its writes.
+type instrumentedBatch struct {
+	ethdb.Batch
+	db *instrumentedStore
+}
+
+// Write flushes any accumulated data to disk.
+func (b *instrumentedBatch) Write() error {
+	defer b.db.record(opWrite, "batch", nil, time.Now())
+	return b.Batch.Write()
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+func TestKeyBucket(t *testing.T) {
+	hash := common.HexToHash("0x01")
+	tests := []struct {
+		key    []byte
+		bucket string
+	}{
+		{headerKey(1, hash), "header"},
+		{headerNumberKey(hash), "header"},
+		{blockBodyKey(1, hash), "body"},
+		{blockReceiptsKey(1, hash), "receipts"},
+		{txLookupKey(hash), "txlookup"},
+		{bloomBitsKey(1, 2, hash), "bloombits"},
+		{hash.Bytes(), "trie"},
+		{codeKey(hash), "code"},
+		{accountSnapshotKey(hash), "snapshot-account"},
+		{storageSnapshotKey(hash, hash), "snapshot-storage"},
+		{preimageKey(hash), "preimage"},
+		{databaseVersionKey, "other"},
+	}
+	for i, tt := range tests {
+		if bucket := keyBucket(tt.key); bucket != tt.bucket {
+			t.Errorf("test %d: bucket mismatch: have %s, want %s", i, bucket, tt.bucket)
+		}
+	}
+}
+
+func TestInstrumentedStore(t *testing.T) {
+	// The histograms only record if the metrics are enabled
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	SetSlowQueryThreshold(time.Nanosecond)
+	defer SetSlowQueryThreshold(0)
+
+	db := instrumentStore(memorydb.New(), "test/instrument/")
+	store, ok := db.(*instrumentedStore)
+	if !ok {
+		t.Fatalf("store not instrumented")
+	}
+	key := headerHashKey(1)
+	if err := db.Put(key, []byte{1}); err != nil {
+		t.Fatalf("failed to put: %v", err)
+	}
+	if v, err := db.Get(key); err != nil || len(v) != 1 {
+		t.Fatalf("failed to get: %v", err)
+	}
+	batch := db.NewBatch()
+	batch.Put(codeKey(common.Hash{}), []byte{2})
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	for _, name := range []string{"header/put", "header/get", "batch/write"} {
+		if count := store.latency[name].Count(); count != 1 {
+			t.Errorf("%s: latency count mismatch: have %d, want 1", name, count)
+		}
+	}
+}
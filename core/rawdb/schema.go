@@ -75,6 +75,15 @@ var (
 	// uncleanShutdownKey tracks the list of local crashes
 	uncleanShutdownKey = []byte("unclean-shutdown") // config prefix for the db
 
+	// genesisConfigHashKey tracks the fingerprint of the chain config the genesis was initialised with.
+	genesisConfigHashKey = []byte("GenesisConfigHash")
+
+	// chainDataCodecKey tracks the codec all the block bodies and receipts were migrated to.
+	chainDataCodecKey = []byte("ChainDataCodec")
+
+	// systemGasJournalKey tracks the system gas used of the recent congress blocks across restarts.
+	systemGasJournalKey = []byte("SystemGasJournal")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
@@ -93,6 +102,14 @@ var (
 	PreimagePrefix = []byte("secure-key-")      // PreimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
+	witnessNodePrefix  = []byte("witness-n-") // witnessNodePrefix + hash -> expiry num (uint64 big endian) + trie node
+	witnessIndexPrefix = []byte("witness-i-") // witnessIndexPrefix + num (uint64 big endian) -> hashes of witness nodes expiring at num
+
+	stateGrowthPrefix = []byte("state-growth-") // stateGrowthPrefix + num (uint64 big endian) + hash -> state growth of the block
+	supplyDeltaPrefix = []byte("supply-delta-") // supplyDeltaPrefix + num (uint64 big endian) + hash -> supply accounting of the block
+
+	txSenderLookupPrefix = []byte("tx-sender-") // txSenderLookupPrefix + sender + nonce (uint64 big endian) -> transaction hash
+
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 
@@ -182,6 +199,11 @@ func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
 }
 
+// txSenderLookupKey = txSenderLookupPrefix + sender + nonce (uint64 big endian)
+func txSenderLookupKey(sender common.Address, nonce uint64) []byte {
+	return append(append(txSenderLookupPrefix, sender.Bytes()...), encodeBlockNumber(nonce)...)
+}
+
 // accountSnapshotKey = SnapshotAccountPrefix + hash
 func accountSnapshotKey(hash common.Hash) []byte {
 	return append(SnapshotAccountPrefix, hash.Bytes()...)
@@ -226,6 +248,26 @@ func IsCodeKey(key []byte) (bool, []byte) {
 	return false, nil
 }
 
+// witnessNodeKey = witnessNodePrefix + hash
+func witnessNodeKey(hash common.Hash) []byte {
+	return append(witnessNodePrefix, hash.Bytes()...)
+}
+
+// witnessIndexKey = witnessIndexPrefix + num (uint64 big endian)
+func witnessIndexKey(number uint64) []byte {
+	return append(witnessIndexPrefix, encodeBlockNumber(number)...)
+}
+
+// stateGrowthKey = stateGrowthPrefix + num (uint64 big endian) + hash
+func stateGrowthKey(number uint64, hash common.Hash) []byte {
+	return append(append(stateGrowthPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// supplyDeltaKey = supplyDeltaPrefix + num (uint64 big endian) + hash
+func supplyDeltaKey(number uint64, hash common.Hash) []byte {
+	return append(append(supplyDeltaPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
 // configKey = configPrefix + hash
 func configKey(hash common.Hash) []byte {
 	return append(configPrefix, hash.Bytes()...)
@@ -0,0 +1,139 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/gopool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	accessListAccountLoadMeter = metrics.NewRegisteredMeter("state/accesslist/account/load", nil)
+	accessListSlotLoadMeter    = metrics.NewRegisteredMeter("state/accesslist/slot/load", nil)
+	accessListSlotHitMeter     = metrics.NewRegisteredMeter("state/accesslist/slot/hit", nil)
+)
+
+// prefetchedSlot is a storage slot of an access list read from the snapshot.
+type prefetchedSlot struct {
+	obj   *stateObject
+	key   common.Hash
+	value common.Hash
+}
+
+// PrefetchAccessList loads the accounts and the storage slots listed by the access
+// list from the snapshot in parallel, ahead of the execution reading them one by
+// one. The slots read afterwards are counted as hits, giving the usefulness of the
+// lists. It's a noop without a snapshot.
+func (s *StateDB) PrefetchAccessList(list types.AccessList) {
+	if s.snap == nil || len(list) == 0 {
+		return
+	}
+	if metrics.EnabledExpensive {
+		defer func(start time.Time) {
+			s.SnapshotStorageReads += time.Since(start)
+		}(time.Now())
+	}
+	// Load the listed accounts not known yet
+	var missing []common.Address
+	for _, tuple := range list {
+		if _, ok := s.stateObjects[tuple.Address]; !ok {
+			missing = append(missing, tuple.Address)
+		}
+	}
+	if len(missing) > 0 {
+		objsChan := make(chan *stateObject, len(missing))
+		for _, addr := range missing {
+			addr := addr
+			gopool.Preload.Run(func() {
+				objsChan <- s.preloadAccountFromSnap(addr)
+			})
+		}
+		for range missing {
+			if obj := <-objsChan; obj != nil {
+				if _, ok := s.stateObjects[obj.Address()]; !ok {
+					s.setStateObject(obj)
+					accessListAccountLoadMeter.Mark(1)
+				}
+			}
+		}
+	}
+	// Load the listed slots of the accounts with a storage, not known yet
+	var tasks []prefetchedSlot
+	for _, tuple := range list {
+		obj := s.stateObjects[tuple.Address]
+		if obj == nil || obj.fakeStorage != nil || obj.data.Root == emptyRoot {
+			continue
+		}
+		if _, destructed := s.snapDestructs[obj.addrHash]; destructed {
+			continue
+		}
+		for _, key := range tuple.StorageKeys {
+			if _, ok := obj.pendingStorage[key]; ok {
+				continue
+			}
+			if _, ok := obj.originStorage[key]; ok {
+				continue
+			}
+			tasks = append(tasks, prefetchedSlot{obj: obj, key: key})
+		}
+	}
+	var (
+		slots = make(chan prefetchedSlot, len(tasks))
+		wg    sync.WaitGroup
+	)
+	for _, task := range tasks {
+		task := task
+		read := func() {
+			defer wg.Done()
+			enc, err := s.snap.Storage(task.obj.addrHash, crypto.Keccak256Hash(task.key[:]))
+			if err != nil {
+				return // Leave it to the execution to read it from the trie
+			}
+			if len(enc) > 0 {
+				_, content, _, err := rlp.Split(enc)
+				if err != nil {
+					return
+				}
+				task.value.SetBytes(content)
+			}
+			slots <- task
+		}
+		wg.Add(1)
+		gopool.Preload.Run(read)
+	}
+	wg.Wait()
+	close(slots)
+
+	for slot := range slots {
+		if _, ok := slot.obj.originStorage[slot.key]; ok {
+			continue // Duplicate key in the list
+		}
+		slot.obj.originStorage[slot.key] = slot.value
+		if slot.obj.prefetched == nil {
+			slot.obj.prefetched = make(map[common.Hash]struct{})
+		}
+		slot.obj.prefetched[slot.key] = struct{}{}
+		accessListSlotLoadMeter.Mark(1)
+	}
+}
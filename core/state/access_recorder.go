@@ -0,0 +1,129 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import "github.com/ethereum/go-ethereum/common"
+
+// AccessSet is a set of accounts and storage slots.
+type AccessSet struct {
+	Accounts map[common.Address]struct{}
+	Slots    map[common.Address]map[common.Hash]struct{}
+}
+
+// NewAccessSet creates an empty access set.
+func NewAccessSet() *AccessSet {
+	return &AccessSet{
+		Accounts: make(map[common.Address]struct{}),
+		Slots:    make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+// AddAccount adds the account to the set.
+func (s *AccessSet) AddAccount(addr common.Address) {
+	s.Accounts[addr] = struct{}{}
+}
+
+// AddSlot adds the storage slot of the account to the set.
+func (s *AccessSet) AddSlot(addr common.Address, key common.Hash) {
+	slots, ok := s.Slots[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		s.Slots[addr] = slots
+	}
+	slots[key] = struct{}{}
+}
+
+// HasAccount reports whether the account is in the set.
+func (s *AccessSet) HasAccount(addr common.Address) bool {
+	_, ok := s.Accounts[addr]
+	return ok
+}
+
+// HasSlot reports whether the storage slot of the account is in the set.
+func (s *AccessSet) HasSlot(addr common.Address, key common.Hash) bool {
+	_, ok := s.Slots[addr][key]
+	return ok
+}
+
+// Merge adds all the accounts and slots of the other set.
+func (s *AccessSet) Merge(other *AccessSet) {
+	for addr := range other.Accounts {
+		s.AddAccount(addr)
+	}
+	for addr, slots := range other.Slots {
+		for key := range slots {
+			s.AddSlot(addr, key)
+		}
+	}
+}
+
+// Len returns the number of accounts and slots in the set.
+func (s *AccessSet) Len() int {
+	n := len(s.Accounts)
+	for _, slots := range s.Slots {
+		n += len(slots)
+	}
+	return n
+}
+
+// StateAccess is the read and write sets of the executions on a state, as
+// needed to validate the executions run optimistically against a stale state.
+// Account reads and writes cover the balance, nonce, code and existence of the
+// account, not its storage. The writes include the ones reverted afterwards.
+type StateAccess struct {
+	Reads  *AccessSet
+	Writes *AccessSet
+}
+
+// RecordAccess starts recording the accounts and storage slots read and written
+// through the state into a fresh access set, replacing any previous one. The
+// copies of the state don't record.
+func (s *StateDB) RecordAccess() {
+	s.access = &StateAccess{Reads: NewAccessSet(), Writes: NewAccessSet()}
+}
+
+// TakeAccess returns the accesses recorded since RecordAccess and stops the
+// recording, nil if not recording.
+func (s *StateDB) TakeAccess() *StateAccess {
+	access := s.access
+	s.access = nil
+	return access
+}
+
+func (s *StateDB) readAccount(addr common.Address) {
+	if s.access != nil {
+		s.access.Reads.AddAccount(addr)
+	}
+}
+
+func (s *StateDB) readSlot(addr common.Address, key common.Hash) {
+	if s.access != nil {
+		s.access.Reads.AddSlot(addr, key)
+	}
+}
+
+func (s *StateDB) writeAccount(addr common.Address) {
+	if s.access != nil {
+		s.access.Writes.AddAccount(addr)
+	}
+}
+
+func (s *StateDB) writeSlot(addr common.Address, key common.Hash) {
+	if s.access != nil {
+		s.access.Writes.AddSlot(addr, key)
+	}
+}
@@ -17,13 +17,17 @@
 package state
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
@@ -233,3 +237,129 @@ func (s *StateDB) IteratorDump(opts *DumpConfig) IteratorDump {
 	iterator.Next = s.DumpToCollector(iterator, opts)
 	return *iterator
 }
+
+// errNoSnapshot is returned if the state is dumped from the snapshot but there's
+// no snapshot of the state root.
+var errNoSnapshot = errors.New("state snapshot not available")
+
+// snapshotSeek converts a trie iteration start key into a snapshot iteration seek
+// position, the shorter keys being padded like the trie iterator does.
+func snapshotSeek(start []byte) common.Hash {
+	if len(start) > common.HashLength {
+		start = start[:common.HashLength]
+	}
+	return common.BytesToHash(common.RightPadBytes(start, common.HashLength))
+}
+
+// SnapshotIteratorDump dumps out a batch of accounts starting with the given start
+// key like IteratorDump, but iterating the flat state snapshot instead of the trie,
+// which is far cheaper for the large ranges. It fails if there's no snapshot of the
+// state, e.g. while the snapshot is still being generated.
+func (s *StateDB) SnapshotIteratorDump(opts *DumpConfig) (IteratorDump, error) {
+	if opts == nil {
+		opts = new(DumpConfig)
+	}
+	if s.snaps == nil {
+		return IteratorDump{}, errNoSnapshot
+	}
+	it, err := s.snaps.AccountIterator(s.originalRoot, snapshotSeek(opts.Start))
+	if err != nil {
+		return IteratorDump{}, fmt.Errorf("%w: %v", errNoSnapshot, err)
+	}
+	defer it.Release()
+
+	dump := IteratorDump{
+		Root:     fmt.Sprintf("%x", s.originalRoot),
+		Accounts: make(map[common.Address]DumpAccount),
+	}
+	var accounts uint64
+	for it.Next() {
+		if opts.Max > 0 && accounts >= opts.Max {
+			dump.Next = it.Hash().Bytes()
+			break
+		}
+		data, err := snapshot.FullAccount(it.Account())
+		if err != nil {
+			return IteratorDump{}, err
+		}
+		account := DumpAccount{
+			Balance:   data.Balance.String(),
+			Nonce:     data.Nonce,
+			Root:      data.Root,
+			CodeHash:  data.CodeHash,
+			SecureKey: it.Hash().Bytes(),
+		}
+		addrBytes := s.trie.GetKey(it.Hash().Bytes())
+		if addrBytes == nil && opts.OnlyWithAddresses {
+			continue
+		}
+		if !opts.SkipCode && !bytes.Equal(data.CodeHash, emptyCodeHash) {
+			if account.Code, err = s.db.ContractCode(it.Hash(), common.BytesToHash(data.CodeHash)); err != nil {
+				return IteratorDump{}, err
+			}
+		}
+		if !opts.SkipStorage {
+			account.Storage = make(map[common.Hash]string)
+			slots, err := s.snaps.StorageIterator(s.originalRoot, it.Hash(), common.Hash{})
+			if err != nil {
+				return IteratorDump{}, err
+			}
+			for slots.Next() {
+				_, content, _, err := rlp.Split(slots.Slot())
+				if err != nil {
+					slots.Release()
+					return IteratorDump{}, err
+				}
+				account.Storage[common.BytesToHash(s.trie.GetKey(slots.Hash().Bytes()))] = common.Bytes2Hex(content)
+			}
+			err = slots.Error()
+			slots.Release()
+			if err != nil {
+				return IteratorDump{}, err
+			}
+		}
+		dump.Accounts[common.BytesToAddress(addrBytes)] = account
+		accounts++
+	}
+	return dump, it.Error()
+}
+
+// SnapshotStorageSlot is a storage slot read from the state snapshot.
+type SnapshotStorageSlot struct {
+	Hash  common.Hash // Hash of the slot key
+	Key   []byte      // Slot key, nil if its preimage is missing
+	Value common.Hash
+}
+
+// SnapshotStorageRange returns at most max storage slots of the account, starting
+// with the given start key, from the flat state snapshot, along with the hash of
+// the slot following them, nil if there's none. It fails if there's no snapshot of
+// the state.
+func (s *StateDB) SnapshotStorageRange(addr common.Address, start []byte, max int) ([]SnapshotStorageSlot, *common.Hash, error) {
+	if s.snaps == nil {
+		return nil, nil, errNoSnapshot
+	}
+	it, err := s.snaps.StorageIterator(s.originalRoot, crypto.Keccak256Hash(addr.Bytes()), snapshotSeek(start))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errNoSnapshot, err)
+	}
+	defer it.Release()
+
+	var slots []SnapshotStorageSlot
+	for it.Next() {
+		if len(slots) >= max {
+			next := it.Hash()
+			return slots, &next, nil
+		}
+		_, content, _, err := rlp.Split(it.Slot())
+		if err != nil {
+			return nil, nil, err
+		}
+		slots = append(slots, SnapshotStorageSlot{
+			Hash:  it.Hash(),
+			Key:   s.trie.GetKey(it.Hash().Bytes()),
+			Value: common.BytesToHash(content),
+		})
+	}
+	return slots, nil, it.Error()
+}
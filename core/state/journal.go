@@ -138,6 +138,11 @@ type (
 		address *common.Address
 		slot    *common.Hash
 	}
+	// Changes to the transient storage
+	transientStorageChange struct {
+		account       *common.Address
+		key, prevalue common.Hash
+	}
 
 	eraseChange struct {
 		account            *common.Address
@@ -229,6 +234,9 @@ func (ch refundChange) dirtied() *common.Address {
 
 func (ch addLogChange) revert(s *StateDB) {
 	logs := s.logs[ch.txhash]
+	if s.logSpill != nil {
+		s.logSpill.remove(logs[len(logs)-1])
+	}
 	if len(logs) == 1 {
 		delete(s.logs, ch.txhash)
 	} else {
@@ -274,6 +282,14 @@ func (ch accessListAddSlotChange) dirtied() *common.Address {
 	return nil
 }
 
+func (ch transientStorageChange) revert(s *StateDB) {
+	s.setTransientState(*ch.account, ch.key, ch.prevalue)
+}
+
+func (ch transientStorageChange) dirtied() *common.Address {
+	return nil
+}
+
 func (ch eraseChange) revert(s *StateDB) {
 	obj := s.getStateObject(*ch.account)
 	obj.revertErase(common.BytesToHash(ch.prevhash), ch.prevcode, ch.prevroot)
@@ -0,0 +1,213 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// logOverhead is the approximate memory held by a log besides its data: the
+	// struct, the topic slice header and the map and slice entries referencing it.
+	logOverhead = 256
+
+	// logSpillPattern is the name pattern of the log spill files.
+	logSpillPattern = "logspill-*"
+)
+
+var (
+	logSpillMeter     = metrics.NewRegisteredMeter("state/logspill/bytes", nil)
+	logSpillFailMeter = metrics.NewRegisteredMeter("state/logspill/fail", nil)
+)
+
+// logSpan is the location of the data of a spilled log in the spill file.
+type logSpan struct {
+	offset int64
+	size   int
+}
+
+// logSpill moves the data of the logs emitted past a memory allowance into a
+// temporary file, bounding the memory held by the logs of huge blocks. The logs
+// keep their place in the state and the receipts, with a nil data until loaded
+// back.
+type logSpill struct {
+	dir   string
+	limit uint64 // Allowance of the log data held in memory
+
+	memory  uint64 // Memory held by the logs, spilled data excluded
+	spilled uint64 // Size of the spilled data
+
+	file  *os.File
+	size  int64 // Size of the spill file
+	spans map[*types.Log]logSpan
+	hold  bool // Whether to hold the data in memory, once restored or failing to spill
+}
+
+// add accounts the log, spilling its data if past the allowance.
+func (s *logSpill) add(l *types.Log) {
+	s.memory += logOverhead + uint64(len(l.Topics))*common.HashLength
+	if s.memory+uint64(len(l.Data)) <= s.limit || len(l.Data) == 0 || s.hold {
+		s.memory += uint64(len(l.Data))
+		return
+	}
+	if err := s.write(l); err != nil {
+		log.Warn("Failed to spill log data, holding it in memory", "dir", s.dir, "err", err)
+		logSpillFailMeter.Mark(1)
+		s.hold = true
+		s.memory += uint64(len(l.Data))
+	}
+}
+
+// write appends the data of the log to the spill file, dropping it from memory.
+func (s *logSpill) write(l *types.Log) error {
+	if s.file == nil {
+		if err := os.MkdirAll(s.dir, 0700); err != nil {
+			return err
+		}
+		file, err := ioutil.TempFile(s.dir, logSpillPattern)
+		if err != nil {
+			return err
+		}
+		// Unlink the file right away where allowed, so an abandoned state doesn't
+		// leave it behind, CleanLogSpills removing it otherwise
+		os.Remove(file.Name())
+		s.file = file
+		s.spans = make(map[*types.Log]logSpan)
+	}
+	if _, err := s.file.WriteAt(l.Data, s.size); err != nil {
+		return err
+	}
+	s.spans[l] = logSpan{offset: s.size, size: len(l.Data)}
+	s.size += int64(len(l.Data))
+	s.spilled += uint64(len(l.Data))
+	logSpillMeter.Mark(int64(len(l.Data)))
+
+	l.Data = nil
+	return nil
+}
+
+// remove drops the accounting of a reverted log.
+func (s *logSpill) remove(l *types.Log) {
+	s.memory -= logOverhead + uint64(len(l.Topics))*common.HashLength
+	if span, ok := s.spans[l]; ok {
+		delete(s.spans, l)
+		s.spilled -= uint64(span.size)
+		return
+	}
+	s.memory -= uint64(len(l.Data))
+}
+
+// load reads the data of the log, from the spill file if spilled.
+func (s *logSpill) load(l *types.Log) ([]byte, error) {
+	span, ok := s.spans[l]
+	if !ok {
+		return l.Data, nil
+	}
+	data := make([]byte, span.size)
+	if _, err := s.file.ReadAt(data, span.offset); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// restore loads the data of all the spilled logs back into them, and releases
+// the spill file.
+func (s *logSpill) restore() error {
+	for l, span := range s.spans {
+		data := make([]byte, span.size)
+		if _, err := s.file.ReadAt(data, span.offset); err != nil {
+			return err
+		}
+		l.Data = data
+		s.memory += uint64(span.size)
+		delete(s.spans, l)
+	}
+	s.spilled = 0
+	return s.close()
+}
+
+// close releases the spill file.
+func (s *logSpill) close() error {
+	if s.file == nil {
+		return nil
+	}
+	name := s.file.Name()
+	err := s.file.Close()
+	s.file = nil
+	os.Remove(name)
+	return err
+}
+
+// CleanLogSpills removes the spill files left behind in the directory, e.g. on
+// platforms not allowing to unlink open files.
+func CleanLogSpills(dir string) {
+	files, _ := filepath.Glob(filepath.Join(dir, logSpillPattern))
+	for _, file := range files {
+		os.Remove(file)
+	}
+}
+
+// SpillLogs moves the data of the logs emitted into a temporary file of the
+// directory once the memory held by the logs exceeds the limit, to process huge
+// blocks within a bounded memory. The spilled data reads as nil until the logs
+// are restored, LogData loading it in the meantime. Copies of the state don't
+// carry the spilled data.
+func (s *StateDB) SpillLogs(dir string, limit uint64) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	s.logSpill = &logSpill{dir: dir, limit: limit}
+}
+
+// LogsMemory returns the memory held by the logs of the state, the spilled data
+// excluded, and the size of the spilled data. Only accounted if spilling.
+func (s *StateDB) LogsMemory() (memory uint64, spilled uint64) {
+	if s.logSpill == nil {
+		return 0, 0
+	}
+	return s.logSpill.memory, s.logSpill.spilled
+}
+
+// LogsSpilled reports whether the data of any log is spilled.
+func (s *StateDB) LogsSpilled() bool {
+	return s.logSpill != nil && len(s.logSpill.spans) > 0
+}
+
+// LogData returns the data of the log, loaded from the spill file if spilled.
+func (s *StateDB) LogData(l *types.Log) ([]byte, error) {
+	if s.logSpill == nil {
+		return l.Data, nil
+	}
+	return s.logSpill.load(l)
+}
+
+// RestoreLogs loads the data of the spilled logs back into them, and releases
+// the spill file. The logs emitted afterwards aren't spilled anymore.
+func (s *StateDB) RestoreLogs() error {
+	if s.logSpill == nil {
+		return nil
+	}
+	s.logSpill.hold = true
+	return s.logSpill.restore()
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestLogSpill(t *testing.T) {
+	dir := t.TempDir()
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	state.SpillLogs(dir, 2*logOverhead+100)
+	state.Prepare(common.HexToHash("0x01"), 0)
+
+	var logs []*types.Log
+	emit := func(size int) {
+		l := &types.Log{Data: bytes.Repeat([]byte{byte(len(logs) + 1)}, size)}
+		logs = append(logs, l)
+		state.AddLog(l)
+	}
+	// The first logs fit in the allowance, the followings are spilled
+	emit(50)
+	emit(50)
+	emit(50)
+	snap := state.Snapshot()
+	emit(70)
+	if logs[0].Data == nil || logs[1].Data == nil {
+		t.Fatalf("logs within the allowance spilled")
+	}
+	if logs[2].Data != nil || logs[3].Data != nil || !state.LogsSpilled() {
+		t.Fatalf("logs past the allowance held")
+	}
+	if memory, spilled := state.LogsMemory(); memory != 4*logOverhead+100 || spilled != 120 {
+		t.Fatalf("memory mismatch: have %d held, %d spilled", memory, spilled)
+	}
+	data, err := state.LogData(logs[2])
+	if err != nil || !bytes.Equal(data, bytes.Repeat([]byte{3}, 50)) {
+		t.Fatalf("spilled data mismatch: have %x, %v", data, err)
+	}
+	// Reverted logs are dropped from the accounting
+	state.RevertToSnapshot(snap)
+	if memory, spilled := state.LogsMemory(); memory != 3*logOverhead+100 || spilled != 50 {
+		t.Fatalf("memory mismatch after revert: have %d held, %d spilled", memory, spilled)
+	}
+	// Restored logs get their data back, and the spill file is released
+	if err := state.RestoreLogs(); err != nil {
+		t.Fatalf("failed to restore logs: %v", err)
+	}
+	for i, l := range logs[:3] {
+		if !bytes.Equal(l.Data, bytes.Repeat([]byte{byte(i + 1)}, 50)) {
+			t.Errorf("log %d: restored data mismatch: have %x", i, l.Data)
+		}
+	}
+	if state.LogsSpilled() {
+		t.Errorf("logs still spilled")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("spill file left behind")
+	}
+}
@@ -85,6 +85,8 @@ type stateObject struct {
 	dirtyStorage   Storage // Storage entries that have been modified in the current transaction execution
 	fakeStorage    Storage // Fake storage which constructed by caller for debugging purpose.
 
+	prefetched map[common.Hash]struct{} // Slots prefetched from an access list and not read yet
+
 	// Cache flags.
 	// When an object is marked suicided it will be delete from the trie
 	// during the "update" phase of the state transition.
@@ -92,6 +94,11 @@ type stateObject struct {
 	suicided  bool
 	deleted   bool
 
+	// State growth counters, flushed into the StateDB when the object is updated
+	created      bool // true if the account didn't exist in the state
+	slotsCreated uint64
+	slotsDeleted uint64
+
 	// only used between StateDB.preUpdateStateObject and StateDB.updateStateObject
 	accountRLP     []byte
 	rlpErr         error
@@ -203,8 +210,21 @@ func (s *stateObject) GetCommittedState(db Database, key common.Hash) common.Has
 		return value
 	}
 	if value, cached := s.originStorage[key]; cached {
+		if _, ok := s.prefetched[key]; ok {
+			delete(s.prefetched, key)
+			accessListSlotHitMeter.Mark(1)
+		}
 		return value
 	}
+	// If the block of the state is cached, serve the slot from there. Accounts
+	// created or erased afterwards have an empty storage, never cached.
+	cached := s.db.versions != nil && s.data.Root != emptyRoot
+	if cached {
+		if value, ok := s.db.versions.storageAt(s.db.versionNumber, s.db.originalRoot, s.address, key); ok {
+			s.originStorage[key] = value
+			return value
+		}
+	}
 	// If no live objects are available, attempt to use snapshots
 	var (
 		enc   []byte
@@ -261,6 +281,9 @@ func (s *stateObject) GetCommittedState(db Database, key common.Hash) common.Has
 		}
 		value.SetBytes(content)
 	}
+	if cached {
+		s.db.versions.addStorage(s.db.versionNumber, s.db.originalRoot, s.address, key, value)
+	}
 	s.originStorage[key] = value
 	return value
 }
@@ -340,7 +363,8 @@ func (s *stateObject) updateTrieConcurrencySafe(db Database) Trie {
 	usedStorage := make([][]byte, 0, len(s.pendingStorage))
 	for key, value := range s.pendingStorage {
 		// Skip noop changes, persist actual changes
-		if value == s.originStorage[key] {
+		origin := s.originStorage[key]
+		if value == origin {
 			continue
 		}
 		s.originStorage[key] = value
@@ -349,11 +373,15 @@ func (s *stateObject) updateTrieConcurrencySafe(db Database) Trie {
 		if (value == common.Hash{}) {
 			s.setError(tr.TryDelete(key[:]))
 			s.db.StorageDeleted += 1
+			s.slotsDeleted++
 		} else {
 			// Encoding []byte cannot fail, ok to ignore the error.
 			v, _ = rlp.EncodeToBytes(common.TrimLeftZeroes(value[:]))
 			s.setError(tr.TryUpdate(key[:], v))
 			s.db.StorageUpdated += 1
+			if (origin == common.Hash{}) {
+				s.slotsCreated++
+			}
 		}
 		// If state snapshotting is active, cache the data til commit
 		if s.db.snap != nil {
@@ -477,6 +505,9 @@ func (s *stateObject) deepCopy(db *StateDB) *stateObject {
 	stateObject.suicided = s.suicided
 	stateObject.dirtyCode = s.dirtyCode
 	stateObject.deleted = s.deleted
+	stateObject.created = s.created
+	stateObject.slotsCreated = s.slotsCreated
+	stateObject.slotsDeleted = s.slotsDeleted
 	return stateObject
 }
 
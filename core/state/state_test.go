@@ -19,10 +19,13 @@ package state
 import (
 	"bytes"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 )
@@ -90,6 +93,105 @@ func TestDump(t *testing.T) {
 	}
 }
 
+// Tests that the accounts dumped from the state snapshot, in one go or page by
+// page, are the same as the ones dumped from the state trie.
+func TestSnapshotIteratorDump(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	sdb := NewDatabaseWithConfig(db, nil)
+	state, _ := New(common.Hash{}, sdb, nil)
+	for i := byte(1); i <= 4; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		state.AddBalance(addr, big.NewInt(int64(i)))
+		state.SetState(addr, common.Hash{i}, common.Hash{i, i})
+	}
+	state.SetCode(common.BytesToAddress([]byte{0x02}), []byte{3, 3, 3})
+	root, _ := state.Commit(false)
+	if err := sdb.TrieDB().Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to commit the trie: %v", err)
+	}
+	snaps, err := snapshot.New(db, sdb.TrieDB(), 16, root, false, true, false)
+	if err != nil {
+		t.Fatalf("failed to create the snapshot: %v", err)
+	}
+	state, _ = New(root, sdb, snaps)
+
+	want := state.IteratorDump(&DumpConfig{OnlyWithAddresses: true})
+	have, err := state.SnapshotIteratorDump(&DumpConfig{OnlyWithAddresses: true})
+	if err != nil {
+		t.Fatalf("failed to dump the snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("snapshot dump mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+	var (
+		pages = 0
+		start []byte
+	)
+	for {
+		page, err := state.SnapshotIteratorDump(&DumpConfig{Start: start, Max: 1})
+		if err != nil {
+			t.Fatalf("failed to dump the snapshot page %d: %v", pages, err)
+		}
+		for addr, account := range page.Accounts {
+			if !reflect.DeepEqual(account, want.Accounts[addr]) {
+				t.Fatalf("account %x mismatch: have %+v, want %+v", addr, account, want.Accounts[addr])
+			}
+		}
+		pages++
+		if start = page.Next; start == nil {
+			break
+		}
+	}
+	if pages != len(want.Accounts) {
+		t.Fatalf("page count mismatch: have %d, want %d", pages, len(want.Accounts))
+	}
+	nosnap, _ := New(root, sdb, nil)
+	if _, err := nosnap.SnapshotIteratorDump(nil); err == nil {
+		t.Fatalf("dumped the snapshot of a state without one")
+	}
+}
+
+// Tests that the slots listed by an access list are prefetched from the snapshot
+// with their committed values, and that reading them counts the hits.
+func TestPrefetchAccessList(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	sdb := NewDatabaseWithConfig(db, nil)
+	state, _ := New(common.Hash{}, sdb, nil)
+	addr := common.BytesToAddress([]byte{0x01})
+	state.SetState(addr, common.Hash{0x01}, common.Hash{0x11})
+	state.SetState(addr, common.Hash{0x02}, common.Hash{0x22})
+	root, _ := state.Commit(false)
+	if err := sdb.TrieDB().Commit(root, false, nil); err != nil {
+		t.Fatalf("failed to commit the trie: %v", err)
+	}
+	snaps, err := snapshot.New(db, sdb.TrieDB(), 16, root, false, true, false)
+	if err != nil {
+		t.Fatalf("failed to create the snapshot: %v", err)
+	}
+	state, _ = New(root, sdb, snaps)
+	state.PrefetchAccessList(types.AccessList{
+		{Address: addr, StorageKeys: []common.Hash{{0x01}, {0x02}, {0x03}, {0x01}}},
+		{Address: common.BytesToAddress([]byte{0x02}), StorageKeys: []common.Hash{{0x01}}},
+	})
+	obj := state.stateObjects[addr]
+	if obj == nil {
+		t.Fatalf("listed account not prefetched")
+	}
+	want := Storage{{0x01}: {0x11}, {0x02}: {0x22}, {0x03}: {}}
+	if !reflect.DeepEqual(obj.originStorage, want) {
+		t.Fatalf("prefetched slots mismatch: have %v, want %v", obj.originStorage, want)
+	}
+	if len(obj.prefetched) != 3 {
+		t.Fatalf("prefetched slot count mismatch: have %d, want %d", len(obj.prefetched), 3)
+	}
+	if value := state.GetState(addr, common.Hash{0x02}); value != (common.Hash{0x22}) {
+		t.Fatalf("prefetched slot value mismatch: have %x, want %x", value, common.Hash{0x22})
+	}
+	if _, ok := obj.prefetched[common.Hash{0x02}]; ok || len(obj.prefetched) != 2 {
+		t.Fatalf("prefetched slot hit not recorded: %v", obj.prefetched)
+	}
+}
+
 func TestNull(t *testing.T) {
 	s := newStateTest()
 	address := common.HexToAddress("0x823140710bf13990e4500136726d8b55")
@@ -81,6 +81,15 @@ type StateDB struct {
 	stateObjectsPending map[common.Address]struct{} // State objects finalized but not yet written to the trie
 	stateObjectsDirty   map[common.Address]struct{} // State objects modified in the current execution
 
+	// Version cache serving the committed reads of the state of a block, and the
+	// writes recorded for it while processing a block.
+	versions      *VersionCache
+	versionNumber uint64
+	versionWrites *versionWrites
+
+	// Accounts and slots accessed through the state, nil if not recording
+	access *StateAccess
+
 	// DB error.
 	// State objects are used by the consensus core and VM which are
 	// unable to deal with database-level errors. Any error that occurs
@@ -91,16 +100,20 @@ type StateDB struct {
 	// The refund counter, also used by state transitioning.
 	refund uint64
 
-	thash   common.Hash
-	txIndex int
-	logs    map[common.Hash][]*types.Log
-	logSize uint
+	thash    common.Hash
+	txIndex  int
+	logs     map[common.Hash][]*types.Log
+	logSize  uint
+	logSpill *logSpill // Spill of the log data past a memory allowance, nil if disabled
 
 	preimages map[common.Hash][]byte
 
 	// Per-transaction access list
 	accessList *accessList
 
+	// Per-transaction transient storage
+	transientStorage transientStorage
+
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        *journal
@@ -124,6 +137,8 @@ type StateDB struct {
 	StorageUpdated int
 	AccountDeleted int
 	StorageDeleted int
+
+	growth types.StateGrowth // State entries created and deleted since the state was opened
 }
 
 // New creates a new state from a given trie.
@@ -144,6 +159,7 @@ func New(root common.Hash, db Database, snaps *snapshot.Tree) (*StateDB, error)
 		preimages:           make(map[common.Hash][]byte),
 		journal:             newJournal(),
 		accessList:          newAccessList(),
+		transientStorage:    newTransientStorage(),
 		hasher:              crypto.NewKeccakState(),
 	}
 	if sdb.snaps != nil {
@@ -197,6 +213,10 @@ func (s *StateDB) AddLog(log *types.Log) {
 	log.Index = s.logSize
 	s.logs[s.thash] = append(s.logs[s.thash], log)
 	s.logSize++
+
+	if s.logSpill != nil {
+		s.logSpill.add(log)
+	}
 }
 
 func (s *StateDB) GetLogs(hash common.Hash, blockHash common.Hash) []*types.Log {
@@ -249,18 +269,21 @@ func (s *StateDB) SubRefund(gas uint64) {
 // Exist reports whether the given account address exists in the state.
 // Notably this also returns true for suicided accounts.
 func (s *StateDB) Exist(addr common.Address) bool {
+	s.readAccount(addr)
 	return s.getStateObject(addr) != nil
 }
 
 // Empty returns whether the state object is either non-existent
 // or empty according to the EIP161 specification (balance = nonce = code = 0)
 func (s *StateDB) Empty(addr common.Address) bool {
+	s.readAccount(addr)
 	so := s.getStateObject(addr)
 	return so == nil || so.empty()
 }
 
 // GetBalance retrieves the balance from the given address or 0 if object not found
 func (s *StateDB) GetBalance(addr common.Address) *big.Int {
+	s.readAccount(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Balance()
@@ -269,6 +292,7 @@ func (s *StateDB) GetBalance(addr common.Address) *big.Int {
 }
 
 func (s *StateDB) GetNonce(addr common.Address) uint64 {
+	s.readAccount(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Nonce()
@@ -283,6 +307,7 @@ func (s *StateDB) TxIndex() int {
 }
 
 func (s *StateDB) GetCode(addr common.Address) []byte {
+	s.readAccount(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Code(s.db)
@@ -291,6 +316,7 @@ func (s *StateDB) GetCode(addr common.Address) []byte {
 }
 
 func (s *StateDB) GetCodeSize(addr common.Address) int {
+	s.readAccount(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.CodeSize(s.db)
@@ -299,6 +325,7 @@ func (s *StateDB) GetCodeSize(addr common.Address) int {
 }
 
 func (s *StateDB) GetCodeHash(addr common.Address) common.Hash {
+	s.readAccount(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject == nil {
 		return common.Hash{}
@@ -308,6 +335,7 @@ func (s *StateDB) GetCodeHash(addr common.Address) common.Hash {
 
 // GetState retrieves a value from the given account's storage trie.
 func (s *StateDB) GetState(addr common.Address, hash common.Hash) common.Hash {
+	s.readSlot(addr, hash)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.GetState(s.db, hash)
@@ -340,6 +368,7 @@ func (s *StateDB) GetStorageProof(a common.Address, key common.Hash) ([][]byte,
 
 // GetCommittedState retrieves a value from the given account's committed storage trie.
 func (s *StateDB) GetCommittedState(addr common.Address, hash common.Hash) common.Hash {
+	s.readSlot(addr, hash)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.GetCommittedState(s.db, hash)
@@ -365,6 +394,7 @@ func (s *StateDB) StorageTrie(addr common.Address) Trie {
 }
 
 func (s *StateDB) HasSuicided(addr common.Address) bool {
+	s.readAccount(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.suicided
@@ -378,6 +408,7 @@ func (s *StateDB) HasSuicided(addr common.Address) bool {
 
 // AddBalance adds amount to the account associated with addr.
 func (s *StateDB) AddBalance(addr common.Address, amount *big.Int) {
+	s.writeAccount(addr)
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.AddBalance(amount)
@@ -386,6 +417,7 @@ func (s *StateDB) AddBalance(addr common.Address, amount *big.Int) {
 
 // SubBalance subtracts amount from the account associated with addr.
 func (s *StateDB) SubBalance(addr common.Address, amount *big.Int) {
+	s.writeAccount(addr)
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SubBalance(amount)
@@ -393,6 +425,7 @@ func (s *StateDB) SubBalance(addr common.Address, amount *big.Int) {
 }
 
 func (s *StateDB) SetBalance(addr common.Address, amount *big.Int) {
+	s.writeAccount(addr)
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetBalance(amount)
@@ -400,6 +433,7 @@ func (s *StateDB) SetBalance(addr common.Address, amount *big.Int) {
 }
 
 func (s *StateDB) SetNonce(addr common.Address, nonce uint64) {
+	s.writeAccount(addr)
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetNonce(nonce)
@@ -407,6 +441,7 @@ func (s *StateDB) SetNonce(addr common.Address, nonce uint64) {
 }
 
 func (s *StateDB) SetCode(addr common.Address, code []byte) {
+	s.writeAccount(addr)
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetCode(crypto.Keccak256Hash(code), code)
@@ -414,6 +449,7 @@ func (s *StateDB) SetCode(addr common.Address, code []byte) {
 }
 
 func (s *StateDB) SetState(addr common.Address, key, value common.Hash) {
+	s.writeSlot(addr, key)
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetState(s.db, key, value)
@@ -435,6 +471,7 @@ func (s *StateDB) SetStorage(addr common.Address, storage map[common.Hash]common
 // The account's state object is still available until the state is committed,
 // getStateObject will return a non-nil account after Suicide.
 func (s *StateDB) Suicide(addr common.Address) bool {
+	s.writeAccount(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject == nil {
 		return false
@@ -455,12 +492,15 @@ func (s *StateDB) Suicide(addr common.Address) bool {
 //
 // The account is still available, and with it's balance unchanged.
 func (s *StateDB) Erase(addr common.Address) bool {
+	s.writeAccount(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject == nil {
 		return false
 	}
 	stateObject.erase()
-
+	if s.versionWrites != nil {
+		s.versionWrites.wipe(addr)
+	}
 	return true
 }
 
@@ -563,7 +603,7 @@ func (s *StateDB) PreloadAccounts(block *types.Block, signer types.Signer) {
 	objsChan := make(chan *stateObject, len(objsForPreload))
 	for addr := range objsForPreload {
 		addr := addr
-		gopool.Submit(func() {
+		gopool.Preload.Run(func() {
 			objsChan <- s.preloadAccountFromSnap(addr)
 		})
 	}
@@ -612,6 +652,17 @@ func (s *StateDB) getDeletedStateObject(addr common.Address) *stateObject {
 	if obj := s.stateObjects[addr]; obj != nil {
 		return obj
 	}
+	// If the block of the state is cached, serve the account from there
+	if s.versions != nil {
+		if data, ok := s.versions.account(s.versionNumber, s.originalRoot, addr); ok {
+			if data == nil {
+				return nil
+			}
+			obj := newObject(s, addr, *data)
+			s.setStateObject(obj)
+			return obj
+		}
+	}
 	// If no live objects are available, attempt to use snapshots
 	var (
 		data *types.StateAccount
@@ -659,6 +710,9 @@ func (s *StateDB) getDeletedStateObject(addr common.Address) *stateObject {
 			return nil
 		}
 	}
+	if s.versions != nil {
+		s.versions.addAccount(s.versionNumber, s.originalRoot, addr, data)
+	}
 	// Insert into the live set
 	obj := newObject(s, addr, *data)
 	s.setStateObject(obj)
@@ -690,7 +744,11 @@ func (s *StateDB) createObject(addr common.Address) (newobj, prev *stateObject)
 			s.snapDestructs[prev.addrHash] = struct{}{}
 		}
 	}
+	if s.versionWrites != nil && prev != nil {
+		s.versionWrites.wipe(addr)
+	}
 	newobj = newObject(s, addr, types.StateAccount{})
+	newobj.created = prev == nil || prev.created
 	if prev == nil {
 		s.journal.append(createObjectChange{account: &addr})
 	} else {
@@ -714,6 +772,7 @@ func (s *StateDB) createObject(addr common.Address) (newobj, prev *stateObject)
 //
 // Carrying over the balance ensures that Ether doesn't disappear.
 func (s *StateDB) CreateAccount(addr common.Address) {
+	s.writeAccount(addr)
 	newObj, prev := s.createObject(addr)
 	if prev != nil {
 		newObj.setBalance(prev.data.Balance)
@@ -765,6 +824,7 @@ func (s *StateDB) Copy() *StateDB {
 		preimages:           make(map[common.Hash][]byte, len(s.preimages)),
 		journal:             newJournal(),
 		hasher:              crypto.NewKeccakState(),
+		growth:              s.growth,
 	}
 	// Copy the dirty states, logs, and preimages
 	for addr := range s.journal.dirties {
@@ -814,6 +874,7 @@ func (s *StateDB) Copy() *StateDB {
 	// However, it doesn't cost us much to copy an empty list, so we do it anyway
 	// to not blow up if we ever decide copy it in the middle of a transaction
 	state.accessList = s.accessList.Copy()
+	state.transientStorage = s.transientStorage.Copy()
 
 	// If there's a prefetcher running, make an inactive copy of it that can
 	// only access data but does not actively preload (since the user will not
@@ -821,6 +882,11 @@ func (s *StateDB) Copy() *StateDB {
 	if s.prefetcher != nil {
 		state.prefetcher = s.prefetcher.copy()
 	}
+	if s.versionWrites != nil {
+		state.versionWrites = s.versionWrites.copy()
+	}
+	state.versions, state.versionNumber = s.versions, s.versionNumber
+
 	if s.snaps != nil {
 		// In order for the miner to be able to use and make additions
 		// to the snapshot tree, we need to copy that aswell.
@@ -896,6 +962,9 @@ func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 		}
 		if obj.suicided || (deleteEmptyObjects && obj.empty()) {
 			obj.deleted = true
+			if s.versionWrites != nil {
+				s.versionWrites.wipe(addr)
+			}
 
 			// If state snapshotting is active, also mark the destruction there.
 			// Note, we can't do this only at the end of a block because multiple
@@ -907,6 +976,9 @@ func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 				delete(s.snapStorage, obj.addrHash)        // Clear out any previously updated storage data (may be recreated via a ressurrect)
 			}
 		} else {
+			if s.versionWrites != nil {
+				s.versionWrites.setStorage(addr, obj.dirtyStorage)
+			}
 			obj.finalise(true) // Prefetch slots in the background
 		}
 		s.stateObjectsPending[addr] = struct{}{}
@@ -922,6 +994,9 @@ func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 	}
 	// Invalidate journal because reverting across transactions is not allowed.
 	s.clearJournalAndRefund()
+
+	// The state moved past the block, the cached values don't apply anymore
+	s.versions = nil
 }
 
 // IntermediateRoot computes the current root hash of the state trie.
@@ -955,7 +1030,7 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 		if obj := s.stateObjects[addr]; !obj.deleted {
 			obj.finalise(false)
 			wg.Add(1)
-			gopool.Submit(func() {
+			gopool.Run(func() {
 				s.preUpdateStateObject(obj)
 				wg.Done()
 			})
@@ -985,9 +1060,17 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 		if obj := s.stateObjects[addr]; obj.deleted {
 			s.deleteStateObject(obj)
 			s.AccountDeleted += 1
+			s.countGrowth(obj)
+			if s.versionWrites != nil {
+				s.versionWrites.setAccount(addr, nil)
+			}
 		} else {
 			s.updateStateObject(obj)
 			s.AccountUpdated += 1
+			s.countGrowth(obj)
+			if s.versionWrites != nil {
+				s.versionWrites.setAccount(addr, &obj.data)
+			}
 		}
 		usedAddrs = append(usedAddrs, common.CopyBytes(addr[:])) // Copy needed for closure
 	}
@@ -1004,12 +1087,62 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 	return s.trie.Hash()
 }
 
+// countGrowth flushes the state growth counters of an updated object, the ones
+// of a deleted object being dropped.
+func (s *StateDB) countGrowth(obj *stateObject) {
+	if !obj.deleted {
+		if obj.created {
+			s.growth.NewAccounts++
+		}
+		s.growth.NewSlots += obj.slotsCreated
+		s.growth.DeletedSlots += obj.slotsDeleted
+	}
+	obj.created = false
+	obj.slotsCreated, obj.slotsDeleted = 0, 0
+}
+
+// Growth returns the state entries created and deleted since the state was
+// opened, as of the last computed root.
+func (s *StateDB) Growth() types.StateGrowth {
+	return s.growth
+}
+
+// PendingChanges returns the accounts modified by the finalised executions but
+// not yet written to the trie, along with their modified storage slots.
+func (s *StateDB) PendingChanges() map[common.Address][]common.Hash {
+	changes := make(map[common.Address][]common.Hash, len(s.stateObjectsPending))
+	for addr := range s.stateObjectsPending {
+		var slots []common.Hash
+		if obj := s.stateObjects[addr]; obj != nil && !obj.deleted {
+			for key := range obj.pendingStorage {
+				slots = append(slots, key)
+			}
+		}
+		changes[addr] = slots
+	}
+	return changes
+}
+
+// UseVersionCache serves the committed reads of the state, which must be the one
+// of the given block, from the version cache while the state isn't modified past
+// the block. Only read-only states, e.g. the ones opened for RPC, may use it.
+func (s *StateDB) UseVersionCache(cache *VersionCache, number uint64) {
+	s.versions, s.versionNumber = cache, number
+}
+
+// RecordVersions records the writes made to the state, for the version cache to
+// be updated once the processed block is canonical.
+func (s *StateDB) RecordVersions() {
+	s.versionWrites = newVersionWrites()
+}
+
 // Prepare sets the current transaction hash and index which are
 // used when the EVM emits new state logs.
 func (s *StateDB) Prepare(thash common.Hash, ti int) {
 	s.thash = thash
 	s.txIndex = ti
 	s.accessList = newAccessList()
+	s.transientStorage = newTransientStorage()
 }
 
 func (s *StateDB) clearJournalAndRefund() {
@@ -1170,6 +1303,32 @@ func (s *StateDB) SlotInAccessList(addr common.Address, slot common.Hash) (addre
 	return s.accessList.Contains(addr, slot)
 }
 
+// SetTransientState sets the transient storage slot of the address, the change
+// being journaled to be reverted along with the call.
+func (s *StateDB) SetTransientState(addr common.Address, key, value common.Hash) {
+	prev := s.GetTransientState(addr, key)
+	if prev == value {
+		return
+	}
+	s.journal.append(transientStorageChange{
+		account:  &addr,
+		key:      key,
+		prevalue: prev,
+	})
+	s.setTransientState(addr, key, value)
+}
+
+// setTransientState is a lower level setter for the transient storage, used by
+// the journal reverts as well.
+func (s *StateDB) setTransientState(addr common.Address, key, value common.Hash) {
+	s.transientStorage.Set(addr, key, value)
+}
+
+// GetTransientState returns the transient storage slot of the address.
+func (s *StateDB) GetTransientState(addr common.Address, key common.Hash) common.Hash {
+	return s.transientStorage.Get(addr, key)
+}
+
 func (s *StateDB) AsyncCommit(deleteEmptyObjects bool, afterCommit func(common.Hash)) error {
 	if s.dbErr != nil {
 		return fmt.Errorf("commit aborted due to earlier error: %v", s.dbErr)
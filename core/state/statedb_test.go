@@ -972,3 +972,42 @@ func TestErase(t *testing.T) {
 		t.Fatal("erase should not change balance")
 	}
 }
+
+// Tests that the accounts created and the storage slots set and cleared by the
+// execution are counted, once, whatever the number of roots computed.
+func TestStateGrowth(t *testing.T) {
+	db := NewDatabase(rawdb.NewMemoryDatabase())
+	state, _ := New(common.Hash{}, db, nil)
+
+	addr := common.BytesToAddress([]byte("so"))
+	state.SetBalance(addr, big.NewInt(1))
+	state.SetState(addr, common.HexToHash("01"), common.HexToHash("aa"))
+	state.SetState(addr, common.HexToHash("02"), common.HexToHash("bb"))
+
+	root, _ := state.Commit(false)
+	if have, want := state.Growth(), (types.StateGrowth{NewAccounts: 1, NewSlots: 2}); have != want {
+		t.Fatalf("initial growth mismatch: have %+v, want %+v", have, want)
+	}
+	state, _ = New(root, db, nil)
+
+	created := common.BytesToAddress([]byte("created"))
+	touched := common.BytesToAddress([]byte("touched"))
+	state.SetBalance(created, big.NewInt(1))
+	state.AddBalance(touched, new(big.Int))                              // Deleted as empty
+	state.SetState(addr, common.HexToHash("01"), common.HexToHash("cc")) // Updated, not created
+	state.SetState(addr, common.HexToHash("02"), common.Hash{})
+	state.SetState(addr, common.HexToHash("03"), common.HexToHash("dd"))
+	state.IntermediateRoot(true)
+
+	// A copy carries the counters over and a further root doesn't count twice
+	cpy := state.Copy()
+	cpy.IntermediateRoot(true)
+
+	want := types.StateGrowth{NewAccounts: 1, NewSlots: 1, DeletedSlots: 1}
+	if have := state.Growth(); have != want {
+		t.Errorf("growth mismatch: have %+v, want %+v", have, want)
+	}
+	if have := cpy.Growth(); have != want {
+		t.Errorf("copy growth mismatch: have %+v, want %+v", have, want)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// StorageSlotDiff is a storage slot of an account holding different values in two
+// states. Unset slots hold the zero value.
+type StorageSlotDiff struct {
+	Hash common.Hash  `json:"hash"`          // Hash of the slot key
+	Key  *common.Hash `json:"key,omitempty"` // Slot key, nil if its preimage is missing
+	From common.Hash  `json:"from"`
+	To   common.Hash  `json:"to"`
+}
+
+// storageTrie returns the storage trie of the account, an empty one if the account
+// doesn't exist.
+func (s *StateDB) storageTrie(addr common.Address) (Trie, error) {
+	if tr := s.StorageTrie(addr); tr != nil {
+		return tr, nil
+	}
+	return s.db.OpenStorageTrie(crypto.Keccak256Hash(addr.Bytes()), emptyRoot)
+}
+
+// DiffStorage returns the storage slots of the account changed from the state
+// from to the state to, ordered by the hash of their keys, at most max of them if
+// max is positive. The second return value tells whether the list is complete.
+//
+// Only the trie nodes differing between the two storage tries are visited, so the
+// cost is proportional to the size of the change, not of the storage. A missing
+// account is handled as one with empty storage.
+func DiffStorage(from, to *StateDB, addr common.Address, max int) ([]StorageSlotDiff, bool, error) {
+	a, err := from.storageTrie(addr)
+	if err != nil {
+		return nil, false, err
+	}
+	b, err := to.storageTrie(addr)
+	if err != nil {
+		return nil, false, err
+	}
+	if a.Hash() == b.Hash() {
+		return nil, true, nil
+	}
+	// The leaves of b missing from a are the set or changed slots, the ones of a
+	// missing from b the cleared or changed ones. Walk both in key order and merge.
+	removedDiff, _ := trie.NewDifferenceIterator(b.NodeIterator(nil), a.NodeIterator(nil))
+	addedDiff, _ := trie.NewDifferenceIterator(a.NodeIterator(nil), b.NodeIterator(nil))
+	var (
+		removed    = trie.NewIterator(removedDiff)
+		added      = trie.NewIterator(addedDiff)
+		hasRemoved = removed.Next()
+		hasAdded   = added.Next()
+		diffs      []StorageSlotDiff
+	)
+	for hasRemoved || hasAdded {
+		var (
+			key      []byte
+			fromBlob []byte
+			toBlob   []byte
+		)
+		switch {
+		case !hasAdded || (hasRemoved && bytes.Compare(removed.Key, added.Key) < 0):
+			key, fromBlob = removed.Key, removed.Value
+			hasRemoved = removed.Next()
+		case !hasRemoved || bytes.Compare(added.Key, removed.Key) < 0:
+			key, toBlob = added.Key, added.Value
+			hasAdded = added.Next()
+		default:
+			key, fromBlob, toBlob = added.Key, removed.Value, added.Value
+			hasRemoved, hasAdded = removed.Next(), added.Next()
+		}
+		// A leaf may differ only in its path encoding after its siblings changed,
+		// skip the ones holding the same value.
+		if bytes.Equal(fromBlob, toBlob) {
+			continue
+		}
+		if max > 0 && len(diffs) >= max {
+			return diffs, false, nil
+		}
+		diff := StorageSlotDiff{Hash: common.BytesToHash(key)}
+		if diff.From, err = decodeStorageValue(fromBlob); err != nil {
+			return nil, false, err
+		}
+		if diff.To, err = decodeStorageValue(toBlob); err != nil {
+			return nil, false, err
+		}
+		preimage := b.GetKey(key)
+		if preimage == nil {
+			preimage = a.GetKey(key)
+		}
+		if preimage != nil {
+			slot := common.BytesToHash(preimage)
+			diff.Key = &slot
+		}
+		diffs = append(diffs, diff)
+	}
+	if removed.Err != nil {
+		return nil, false, removed.Err
+	}
+	if added.Err != nil {
+		return nil, false, added.Err
+	}
+	return diffs, true, nil
+}
+
+// decodeStorageValue decodes the rlp encoded value of a storage trie leaf, nil
+// being the zero value.
+func decodeStorageValue(blob []byte) (common.Hash, error) {
+	if len(blob) == 0 {
+		return common.Hash{}, nil
+	}
+	_, content, _, err := rlp.Split(blob)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(content), nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that the storage diff of an account lists exactly the set, changed and
+// cleared slots, in key hash order, and can be capped.
+func TestDiffStorage(t *testing.T) {
+	var (
+		db    = NewDatabase(rawdb.NewMemoryDatabase())
+		addr  = common.HexToAddress("0xf000")
+		other = common.HexToAddress("0xf001")
+	)
+	slot := func(n int64) common.Hash { return common.BigToHash(big.NewInt(n)) }
+
+	// Start from slots 1..64, then change 2, clear 3 and set 100
+	a, _ := New(common.Hash{}, db, nil)
+	for i := int64(1); i <= 64; i++ {
+		a.SetState(addr, slot(i), slot(i))
+	}
+	a.SetState(other, slot(1), slot(1))
+	rootA, _ := a.Commit(false)
+
+	b, _ := New(rootA, db, nil)
+	b.SetState(addr, slot(2), slot(200))
+	b.SetState(addr, slot(3), common.Hash{})
+	b.SetState(addr, slot(100), slot(100))
+	b.SetState(other, slot(1), slot(2))
+	rootB, _ := b.Commit(false)
+
+	from, _ := New(rootA, db, nil)
+	to, _ := New(rootB, db, nil)
+
+	want := map[common.Hash][2]common.Hash{
+		slot(2):   {slot(2), slot(200)},
+		slot(3):   {slot(3), {}},
+		slot(100): {{}, slot(100)},
+	}
+	diffs, complete, err := DiffStorage(from, to, addr, 0)
+	if err != nil {
+		t.Fatalf("failed to diff storage: %v", err)
+	}
+	if !complete {
+		t.Errorf("uncapped diff incomplete")
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("diff length mismatch: have %d, want %d: %+v", len(diffs), len(want), diffs)
+	}
+	for i, diff := range diffs {
+		if diff.Key == nil {
+			t.Fatalf("diff %d: missing key preimage", i)
+		}
+		values, ok := want[*diff.Key]
+		if !ok {
+			t.Fatalf("diff %d: unexpected slot %x", i, *diff.Key)
+		}
+		if diff.Hash != crypto.Keccak256Hash(diff.Key.Bytes()) {
+			t.Errorf("diff %d: hash mismatch: have %x", i, diff.Hash)
+		}
+		if diff.From != values[0] || diff.To != values[1] {
+			t.Errorf("diff %d: values mismatch: have %x -> %x, want %x -> %x", i, diff.From, diff.To, values[0], values[1])
+		}
+		if i > 0 && diffs[i-1].Hash.Big().Cmp(diff.Hash.Big()) >= 0 {
+			t.Errorf("diff %d: not ordered by key hash", i)
+		}
+	}
+	// Capped diffs are a prefix of the full one
+	capped, complete, err := DiffStorage(from, to, addr, 2)
+	if err != nil {
+		t.Fatalf("failed to diff capped storage: %v", err)
+	}
+	if complete || len(capped) != 2 || capped[0].Hash != diffs[0].Hash || capped[1].Hash != diffs[1].Hash {
+		t.Errorf("capped diff mismatch: complete %v, have %+v", complete, capped)
+	}
+	// The reverse diff swaps the values, a missing account diffs as empty storage
+	if reverse, _, _ := DiffStorage(to, from, addr, 0); len(reverse) != len(want) || reverse[0].From != diffs[0].To {
+		t.Errorf("reverse diff mismatch: have %+v", reverse)
+	}
+	empty, _ := New(common.Hash{}, db, nil)
+	if created, _, err := DiffStorage(empty, from, addr, 0); err != nil || len(created) != 64 {
+		t.Errorf("created account diff mismatch: have %d slots, err %v", len(created), err)
+	}
+	if same, complete, err := DiffStorage(from, from, addr, 0); err != nil || len(same) != 0 || !complete {
+		t.Errorf("identical state diff mismatch: have %+v, complete %v, err %v", same, complete, err)
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxVersionEntries is the number of cached values past which the version cache
+// is flushed.
+const maxVersionEntries = 4 * 1024 * 1024
+
+var (
+	versionAccountHitMeter  = metrics.NewRegisteredMeter("state/versions/account/hit", nil)
+	versionAccountMissMeter = metrics.NewRegisteredMeter("state/versions/account/miss", nil)
+	versionStorageHitMeter  = metrics.NewRegisteredMeter("state/versions/storage/hit", nil)
+	versionStorageMissMeter = metrics.NewRegisteredMeter("state/versions/storage/miss", nil)
+)
+
+// accountVersion is the value of an account from a block on, until the next
+// version. A nil account doesn't exist.
+type accountVersion struct {
+	number  uint64
+	account *types.StateAccount
+}
+
+// slotVersion is the value of a storage slot from a block on, until the next
+// version.
+type slotVersion struct {
+	number uint64
+	value  common.Hash
+}
+
+// versionWrites are the account and storage writes of a block, recorded by the
+// state processing it for the version cache.
+type versionWrites struct {
+	accounts map[common.Address]*types.StateAccount
+	storage  map[common.Address]map[common.Hash]common.Hash
+	wiped    map[common.Address]struct{} // Accounts whose storage was cleared
+}
+
+func newVersionWrites() *versionWrites {
+	return &versionWrites{
+		accounts: make(map[common.Address]*types.StateAccount),
+		storage:  make(map[common.Address]map[common.Hash]common.Hash),
+		wiped:    make(map[common.Address]struct{}),
+	}
+}
+
+// setAccount records the final value of the account, nil if deleted.
+func (w *versionWrites) setAccount(addr common.Address, account *types.StateAccount) {
+	if account == nil {
+		w.accounts[addr] = nil
+		return
+	}
+	w.accounts[addr] = copyAccount(account)
+}
+
+// setStorage records the storage slots written by a transaction.
+func (w *versionWrites) setStorage(addr common.Address, storage Storage) {
+	if len(storage) == 0 {
+		return
+	}
+	slots := w.storage[addr]
+	if slots == nil {
+		slots = make(map[common.Hash]common.Hash, len(storage))
+		w.storage[addr] = slots
+	}
+	for key, value := range storage {
+		slots[key] = value
+	}
+}
+
+// wipe records the storage of the account being cleared, dropping the slots
+// written before.
+func (w *versionWrites) wipe(addr common.Address) {
+	delete(w.storage, addr)
+	w.wiped[addr] = struct{}{}
+}
+
+func (w *versionWrites) copy() *versionWrites {
+	cpy := newVersionWrites()
+	for addr, account := range w.accounts {
+		cpy.accounts[addr] = account
+	}
+	for addr, slots := range w.storage {
+		cpy.setStorage(addr, slots)
+	}
+	for addr := range w.wiped {
+		cpy.wiped[addr] = struct{}{}
+	}
+	return cpy
+}
+
+// VersionCache is a read-only multi-version cache of the accounts and storage
+// slots of the recent canonical states, serving the committed reads of the
+// states opened for RPC without resolving the tries.
+//
+// Every value is tagged with the block from which on it holds, a read at a block
+// returning the latest version not newer than it. The writes of all the canonical
+// blocks are recorded, so the values read from the tries can be cached as well.
+// A block not extending the cached chain flushes the cache.
+type VersionCache struct {
+	depth uint64 // Number of recent blocks served
+
+	head     common.Hash                                      // Hash of the last recorded block
+	roots    map[uint64]common.Hash                           // State roots of the served blocks
+	accounts map[common.Address][]accountVersion              // Account versions, oldest first
+	storage  map[common.Address]map[common.Hash][]slotVersion // Storage slot versions, oldest first
+	wiped    map[common.Address]uint64                        // Block of the last storage clearing of the accounts
+	entries  int                                              // Number of cached values
+
+	lock sync.RWMutex
+}
+
+// NewVersionCache creates a cache serving the given number of recent blocks.
+func NewVersionCache(depth uint64) *VersionCache {
+	c := &VersionCache{depth: depth}
+	c.reset()
+	return c
+}
+
+func (c *VersionCache) reset() {
+	c.roots = make(map[uint64]common.Hash)
+	c.accounts = make(map[common.Address][]accountVersion)
+	c.storage = make(map[common.Address]map[common.Hash][]slotVersion)
+	c.wiped = make(map[common.Address]uint64)
+	c.entries = 0
+}
+
+// Commit records the writes of a new canonical block, made by the state which
+// processed it. The cache is flushed if the block doesn't extend the last one or
+// if the writes weren't recorded.
+func (c *VersionCache) Commit(number uint64, hash, parent, root common.Hash, statedb *StateDB) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if parent != c.head || statedb.versionWrites == nil {
+		if len(c.roots) > 0 {
+			log.Debug("Flushing state version cache", "number", number, "hash", hash)
+		}
+		c.reset()
+	}
+	c.head = hash
+	writes := statedb.versionWrites
+	if writes == nil {
+		return
+	}
+	for addr, account := range writes.accounts {
+		c.accounts[addr] = appendAccountVersion(c.accounts[addr], accountVersion{number, account})
+		c.entries++
+	}
+	for addr := range writes.wiped {
+		for _, versions := range c.storage[addr] {
+			c.entries -= len(versions)
+		}
+		delete(c.storage, addr)
+		c.wiped[addr] = number
+	}
+	for addr, slots := range writes.storage {
+		if c.storage[addr] == nil {
+			c.storage[addr] = make(map[common.Hash][]slotVersion, len(slots))
+		}
+		for key, value := range slots {
+			c.storage[addr][key] = appendSlotVersion(c.storage[addr][key], slotVersion{number, value})
+			c.entries++
+		}
+	}
+	c.roots[number] = root
+	if number >= c.depth {
+		delete(c.roots, number-c.depth)
+	}
+	if number%c.depth == 0 {
+		c.prune(number)
+	}
+}
+
+// prune drops the versions superseded before the oldest served block, flushing
+// the cache if it's still too large.
+func (c *VersionCache) prune(number uint64) {
+	if number+1 < c.depth {
+		return
+	}
+	oldest := number + 1 - c.depth
+
+	c.entries = 0
+	for addr, versions := range c.accounts {
+		versions = trimAccountVersions(versions, oldest)
+		c.accounts[addr] = versions
+		c.entries += len(versions)
+	}
+	for _, slots := range c.storage {
+		for key, versions := range slots {
+			versions = trimSlotVersions(versions, oldest)
+			slots[key] = versions
+			c.entries += len(versions)
+		}
+	}
+	for addr, wiped := range c.wiped {
+		if wiped < oldest {
+			delete(c.wiped, addr)
+		}
+	}
+	if c.entries > maxVersionEntries {
+		log.Debug("Flushing oversized state version cache", "entries", c.entries)
+		c.reset()
+	}
+}
+
+// account retrieves the account at the given block, nil if it doesn't exist.
+// The boolean reports whether the block is served and the account cached.
+func (c *VersionCache) account(number uint64, root common.Hash, addr common.Address) (*types.StateAccount, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if served, ok := c.roots[number]; !ok || served != root {
+		return nil, false
+	}
+	versions := c.accounts[addr]
+	i := sort.Search(len(versions), func(i int) bool { return versions[i].number > number })
+	if i == 0 {
+		versionAccountMissMeter.Mark(1)
+		return nil, false
+	}
+	versionAccountHitMeter.Mark(1)
+	if versions[i-1].account == nil {
+		return nil, true
+	}
+	return copyAccount(versions[i-1].account), true
+}
+
+// addAccount caches the account read from the trie of the given block.
+func (c *VersionCache) addAccount(number uint64, root common.Hash, addr common.Address, account *types.StateAccount) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if served, ok := c.roots[number]; !ok || served != root || c.entries >= maxVersionEntries {
+		return
+	}
+	// The read is only valid up to the next write, which is recorded already
+	versions := c.accounts[addr]
+	if len(versions) > 0 && versions[0].number <= number {
+		return
+	}
+	c.accounts[addr] = append([]accountVersion{{number, copyAccount(account)}}, versions...)
+	c.entries++
+}
+
+// storageAt retrieves the storage slot of the account at the given block. The
+// boolean reports whether the block is served and the slot cached.
+func (c *VersionCache) storageAt(number uint64, root common.Hash, addr common.Address, key common.Hash) (common.Hash, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if served, ok := c.roots[number]; !ok || served != root {
+		return common.Hash{}, false
+	}
+	versions := c.storage[addr][key]
+	i := sort.Search(len(versions), func(i int) bool { return versions[i].number > number })
+	if i == 0 {
+		versionStorageMissMeter.Mark(1)
+		return common.Hash{}, false
+	}
+	versionStorageHitMeter.Mark(1)
+	return versions[i-1].value, true
+}
+
+// addStorage caches the storage slot read from the trie of the given block.
+func (c *VersionCache) addStorage(number uint64, root common.Hash, addr common.Address, key, value common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if served, ok := c.roots[number]; !ok || served != root || c.entries >= maxVersionEntries {
+		return
+	}
+	// The versions before the last clearing of the storage were dropped
+	if wiped, ok := c.wiped[addr]; ok && wiped > number {
+		return
+	}
+	slots := c.storage[addr]
+	if slots == nil {
+		slots = make(map[common.Hash][]slotVersion)
+		c.storage[addr] = slots
+	}
+	versions := slots[key]
+	if len(versions) > 0 && versions[0].number <= number {
+		return
+	}
+	slots[key] = append([]slotVersion{{number, value}}, versions...)
+	c.entries++
+}
+
+// appendAccountVersion appends the version written at a block, replacing the one
+// of the same block if any.
+func appendAccountVersion(versions []accountVersion, version accountVersion) []accountVersion {
+	if n := len(versions); n > 0 && versions[n-1].number == version.number {
+		versions[n-1] = version
+		return versions
+	}
+	return append(versions, version)
+}
+
+// appendSlotVersion appends the version written at a block, replacing the one of
+// the same block if any.
+func appendSlotVersion(versions []slotVersion, version slotVersion) []slotVersion {
+	if n := len(versions); n > 0 && versions[n-1].number == version.number {
+		versions[n-1] = version
+		return versions
+	}
+	return append(versions, version)
+}
+
+// trimAccountVersions drops the versions superseded before the given block.
+func trimAccountVersions(versions []accountVersion, oldest uint64) []accountVersion {
+	i := sort.Search(len(versions), func(i int) bool { return versions[i].number > oldest })
+	if i <= 1 {
+		return versions
+	}
+	return append([]accountVersion(nil), versions[i-1:]...)
+}
+
+// trimSlotVersions drops the versions superseded before the given block.
+func trimSlotVersions(versions []slotVersion, oldest uint64) []slotVersion {
+	i := sort.Search(len(versions), func(i int) bool { return versions[i].number > oldest })
+	if i <= 1 {
+		return versions
+	}
+	return append([]slotVersion(nil), versions[i-1:]...)
+}
+
+func copyAccount(account *types.StateAccount) *types.StateAccount {
+	cpy := *account
+	if account.Balance != nil {
+		cpy.Balance = new(big.Int).Set(account.Balance)
+	}
+	cpy.CodeHash = common.CopyBytes(account.CodeHash)
+	return &cpy
+}
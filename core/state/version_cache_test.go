@@ -0,0 +1,123 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// Tests that the version cache serves the reads of the recent states with the
+// values in force at their blocks, and drops them on a non-extending block.
+func TestVersionCache(t *testing.T) {
+	var (
+		db    = NewDatabase(rawdb.NewMemoryDatabase())
+		cache = NewVersionCache(16)
+
+		contract = common.HexToAddress("0x01")
+		idle     = common.HexToAddress("0x02")
+		slot     = common.HexToHash("0x01")
+		unused   = common.HexToHash("0x02")
+
+		roots  []common.Hash
+		hashes = []common.Hash{common.HexToHash("0xa0"), common.HexToHash("0xa1"), common.HexToHash("0xa2")}
+	)
+	// Process and commit a few blocks, recording their writes
+	process := func(number uint64, parent common.Hash, fn func(*StateDB)) {
+		statedb, _ := New(parent, db, nil)
+		statedb.RecordVersions()
+		fn(statedb)
+		root, _ := statedb.Commit(true)
+		var parentHash common.Hash
+		if number > 0 {
+			parentHash = hashes[number-1]
+		}
+		cache.Commit(number, hashes[number], parentHash, root, statedb)
+		roots = append(roots, root)
+	}
+	process(0, common.Hash{}, func(s *StateDB) {
+		s.SetBalance(contract, big.NewInt(1))
+		s.SetState(contract, slot, common.HexToHash("0x11"))
+		s.SetState(contract, unused, common.HexToHash("0x22"))
+		s.SetBalance(idle, big.NewInt(7))
+	})
+	process(1, roots[0], func(s *StateDB) {
+		s.SetState(contract, slot, common.HexToHash("0x12"))
+		s.AddBalance(contract, big.NewInt(1))
+	})
+	process(2, roots[1], func(s *StateDB) {
+		s.Suicide(contract)
+	})
+	reader := func(number uint64) *StateDB {
+		statedb, _ := New(roots[number], db, nil)
+		statedb.UseVersionCache(cache, number)
+		return statedb
+	}
+	for i, want := range []struct {
+		balance int64
+		slot    common.Hash
+		exist   bool
+	}{
+		{1, common.HexToHash("0x11"), true},
+		{2, common.HexToHash("0x12"), true},
+		{0, common.Hash{}, false},
+	} {
+		statedb := reader(uint64(i))
+		if have := statedb.GetBalance(contract); have.Cmp(big.NewInt(want.balance)) != 0 {
+			t.Errorf("block %d: balance mismatch: have %v, want %d", i, have, want.balance)
+		}
+		if have := statedb.GetState(contract, slot); have != want.slot {
+			t.Errorf("block %d: slot mismatch: have %x, want %x", i, have, want.slot)
+		}
+		if have := statedb.Exist(contract); have != want.exist {
+			t.Errorf("block %d: existence mismatch: have %v, want %v", i, have, want.exist)
+		}
+		if have := statedb.GetBalance(idle); have.Cmp(big.NewInt(7)) != 0 {
+			t.Errorf("block %d: idle balance mismatch: have %v, want 7", i, have)
+		}
+	}
+	// The slot wiped at block 2 isn't served from the block 0 version
+	if value, ok := cache.storageAt(2, roots[2], contract, unused); ok {
+		t.Errorf("wiped slot served: %x", value)
+	}
+	if have := reader(1).GetState(contract, unused); have != common.HexToHash("0x22") {
+		t.Errorf("slot mismatch: have %x, want 0x22", have)
+	}
+	// Reads of a mismatching root aren't served
+	if _, ok := cache.account(1, roots[0], idle); ok {
+		t.Errorf("account served for a mismatching root")
+	}
+	// Values read from the trie are cached, and modified states stop using the cache
+	cache.accounts = make(map[common.Address][]accountVersion)
+	statedb := reader(1)
+	statedb.GetBalance(idle)
+	if _, ok := cache.account(1, roots[1], idle); !ok {
+		t.Errorf("account read from the trie not cached")
+	}
+	statedb.Finalise(true)
+	if statedb.versions != nil {
+		t.Errorf("finalised state still using the cache")
+	}
+	// A block not extending the last one flushes the cache
+	cache.Commit(3, common.HexToHash("0xb3"), common.HexToHash("0xb2"), roots[2], &StateDB{})
+	if _, ok := cache.account(2, roots[2], idle); ok {
+		t.Errorf("account served after a reorg")
+	}
+}
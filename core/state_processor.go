@@ -40,6 +40,8 @@ type StateProcessor struct {
 	config *params.ChainConfig // Chain configuration options
 	bc     *BlockChain         // Canonical block chain
 	engine consensus.Engine    // Consensus engine used for block rewards
+
+	stm *blockSTM // Experimental executor cross-validating the processed blocks, nil if disabled
 }
 
 // NewStateProcessor initialises a new StateProcessor.
@@ -90,12 +92,34 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 			return nil, nil, 0, err
 		}
 
-		vmenv.Context.ExtraValidator = posa.CreateEvmExtraValidator(header, statedb)
+		extraValidator, err := posa.CreateEvmExtraValidator(header, statedb)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		vmenv.Context.ExtraValidator = extraValidator
+	}
+
+	// Keep the state preceding the transactions for the Block-STM cross-validation
+	var (
+		stmBase *state.StateDB
+		stmTxs  []int
+	)
+	if p.stm != nil && p.stm.idle() && len(block.Transactions()) > 0 {
+		stmBase = statedb.Copy()
+		stmBase.StopPrefetcher()
 	}
 
 	// preload from and to of txs
 	signer := types.MakeSigner(p.config, header.Number)
 	statedb.PreloadAccounts(block, signer)
+	// prefetch the accounts and slots listed by the access lists of the txs
+	var accessList types.AccessList
+	for _, tx := range block.Transactions() {
+		accessList = append(accessList, tx.AccessList()...)
+	}
+	statedb.PrefetchAccessList(accessList)
+	// derive the tx hashes of large blocks up front, instead of one by one
+	types.CacheTxHashes(block.Transactions())
 
 	var bloomWg sync.WaitGroup
 	returnErrBeforeWaitGroup := true
@@ -107,6 +131,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 
 	commonTxs := make([]*types.Transaction, 0, len(block.Transactions()))
 	systemTxs := make([]*types.Transaction, 0)
+	feeless := NewFeelessCounter(p.config, header)
 	for i, tx := range block.Transactions() {
 		if isPoSA {
 			sender, err := types.Sender(signer, tx)
@@ -130,6 +155,10 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		if err != nil {
 			return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
 		}
+		if err := feeless.Check(msg.From(), tx); err != nil {
+			return nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		}
+		feeless.Add(tx)
 		statedb.Prepare(tx.Hash(), i)
 		receipt, err := applyTransaction(msg, p.config, p.bc, nil, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv, CreatingBloomParallel(&bloomWg))
 		if err != nil {
@@ -138,10 +167,30 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		receipts = append(receipts, receipt)
 		allLogs = append(allLogs, receipt.Logs...)
 		commonTxs = append(commonTxs, tx)
+		if err := p.bc.checkBlockMemory(statedb, len(receipts)); err != nil {
+			return nil, nil, 0, err
+		}
+		if stmBase != nil {
+			stmTxs = append(stmTxs, i)
+		}
 	}
 	bloomWg.Wait()
 	returnErrBeforeWaitGroup = false
 
+	// The receipts of spilled logs can't be compared, skip the cross-validation
+	if stmBase != nil && len(stmTxs) > 0 && !statedb.LogsSpilled() {
+		serial := statedb.Copy()
+		serial.StopPrefetcher()
+		p.stm.shadow(&stmJob{
+			block:     block,
+			validator: vmenv.Context.ExtraValidator,
+			base:      stmBase,
+			serial:    serial,
+			txs:       stmTxs,
+			receipts:  receipts[:len(receipts):len(receipts)],
+		})
+	}
+
 	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
 	if err := p.engine.Finalize(p.bc, header, statedb, &commonTxs, block.Uncles(), &receipts, systemTxs); err != nil {
 		return nil, nil, 0, err
@@ -150,7 +199,32 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	return receipts, allLogs, *usedGas, nil
 }
 
+// CheckTxSizeLimits checks the transaction against the size limits of the chain
+// config in force at the given block.
+func CheckTxSizeLimits(config *params.ChainConfig, number *big.Int, tx *types.Transaction) error {
+	if config.Congress == nil {
+		return nil
+	}
+	limits := config.Congress.TxSizeLimitsAt(number)
+	if size := uint64(tx.Size()); limits.Tx != 0 && size > limits.Tx {
+		return fmt.Errorf("%w: size %d, limit %d", ErrTxTooLarge, size, limits.Tx)
+	}
+	if size := uint64(len(tx.Data())); limits.Calldata != 0 && size > limits.Calldata {
+		return fmt.Errorf("%w: size %d, limit %d", ErrCalldataTooLarge, size, limits.Calldata)
+	}
+	if size := uint64(len(tx.Data())); tx.To() == nil && limits.InitCode != 0 && size > limits.InitCode {
+		return fmt.Errorf("%w: size %d, limit %d", ErrInitCodeTooLarge, size, limits.InitCode)
+	}
+	return nil
+}
+
 func applyTransaction(msg types.Message, config *params.ChainConfig, bc ChainContext, author *common.Address, gp *GasPool, statedb *state.StateDB, blockNumber *big.Int, blockHash common.Hash, tx *types.Transaction, usedGas *uint64, evm *vm.EVM, modOptions ...ModifyProcessOptionFunc) (*types.Receipt, error) {
+	if err := CheckTxSizeLimits(config, blockNumber, tx); err != nil {
+		return nil, err
+	}
+	if config.IsReplayProtection(blockNumber) && !tx.Protected() {
+		return nil, ErrUnprotectedTx
+	}
 	// Create a new context to be used in the EVM environment.
 	txContext := NewEVMTxContext(msg)
 	evm.Reset(txContext, statedb)
@@ -200,7 +274,7 @@ func applyTransaction(msg types.Message, config *params.ChainConfig, bc ChainCon
 		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
 	} else {
 		processOp.bloomWg.Add(1)
-		gopool.Submit(func() {
+		gopool.Bloom.Run(func() {
 			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
 			processOp.bloomWg.Done()
 		})
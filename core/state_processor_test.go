@@ -18,6 +18,7 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"math/big"
 	"testing"
 
@@ -299,6 +300,35 @@ func TestStateProcessorErrors(t *testing.T) {
 	}
 }
 
+func TestCheckTxSizeLimits(t *testing.T) {
+	config := *params.AllCongressProtocolChanges
+	config.Congress = &params.CongressConfig{
+		Epoch:             30000,
+		MaxTxSize:         1024,
+		MaxCalldataSize:   512,
+		MaxInitCodeSize:   256,
+		TxSizeLimitsBlock: big.NewInt(10),
+	}
+	to := common.HexToAddress("0x01")
+	tests := []struct {
+		number int64
+		tx     *types.Transaction
+		err    error
+	}{
+		{9, types.NewTransaction(0, to, common.Big0, 1000000, common.Big1, make([]byte, 2048)), nil},
+		{10, types.NewTransaction(0, to, common.Big0, 1000000, common.Big1, make([]byte, 2048)), ErrTxTooLarge},
+		{10, types.NewTransaction(0, to, common.Big0, 1000000, common.Big1, make([]byte, 513)), ErrCalldataTooLarge},
+		{10, types.NewTransaction(0, to, common.Big0, 1000000, common.Big1, make([]byte, 300)), nil},
+		{10, types.NewContractCreation(0, common.Big0, 1000000, common.Big1, make([]byte, 300)), ErrInitCodeTooLarge},
+		{10, types.NewContractCreation(0, common.Big0, 1000000, common.Big1, make([]byte, 256)), nil},
+	}
+	for i, tt := range tests {
+		if err := CheckTxSizeLimits(&config, big.NewInt(tt.number), tt.tx); !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
 // GenerateBadBlock constructs a "block" which contains the transactions. The transactions are not expected to be
 // valid, and no proper post-state can be made. But from the perspective of the blockchain, the block is sufficiently
 // valid to be considered for import:
@@ -90,6 +90,11 @@ type ExecutionResult struct {
 	UsedGas    uint64 // Total used gas but include the refunded gas
 	Err        error  // Any error encountered during the execution(listed in core/vm/errors.go)
 	ReturnData []byte // Returned data from evm(function result or data supplied with revert opcode)
+
+	MetaFee *types.MetaFeeSettlement // Fee split between the fee payer and the sender of a meta transaction
+
+	IntrinsicGas uint64 // Intrinsic gas charged ahead of the execution, access list included
+	RefundedGas  uint64 // Gas refunded after the execution, already deducted from UsedGas
 }
 
 // Unwrap returns the internal evm error which allows us for further
@@ -120,7 +125,7 @@ func (result *ExecutionResult) Revert() []byte {
 }
 
 // IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
-func IntrinsicGas(data []byte, accessList types.AccessList, isContractCreation bool, isHomestead, isEIP2028 bool) (uint64, error) {
+func IntrinsicGas(data []byte, accessList types.AccessList, isContractCreation bool, isHomestead, isEIP2028, isEIP3860 bool) (uint64, error) {
 	// Set the starting gas for the raw transaction
 	var gas uint64
 	if isContractCreation && isHomestead {
@@ -152,6 +157,14 @@ func IntrinsicGas(data []byte, accessList types.AccessList, isContractCreation b
 			return 0, ErrGasUintOverflow
 		}
 		gas += z * params.TxDataZeroGas
+
+		if isContractCreation && isEIP3860 {
+			lenWords := toWordSize(uint64(len(data)))
+			if (math.MaxUint64-gas)/params.InitCodeWordGas < lenWords {
+				return 0, ErrGasUintOverflow
+			}
+			gas += lenWords * params.InitCodeWordGas
+		}
 	}
 	if accessList != nil {
 		gas += uint64(len(accessList)) * params.TxAccessListAddressGas
@@ -160,6 +173,14 @@ func IntrinsicGas(data []byte, accessList types.AccessList, isContractCreation b
 	return gas, nil
 }
 
+// toWordSize returns the ceiled word size required for init code payment calculation.
+func toWordSize(size uint64) uint64 {
+	if size > math.MaxUint64-31 {
+		return math.MaxUint64/32 + 1
+	}
+	return (size + 31) / 32
+}
+
 // NewStateTransition initialises and returns a new state transition object.
 func NewStateTransition(evm *vm.EVM, msg Message, gp *GasPool) *StateTransition {
 	return &StateTransition{
@@ -219,8 +240,7 @@ func (st *StateTransition) buyGas() error {
 func (st *StateTransition) buyGasMeta() error {
 
 	mgval := new(big.Int).Mul(new(big.Int).SetUint64(st.msg.Gas()), st.gasPrice)
-	mgFeeAddrVal := new(big.Int).Div(new(big.Int).Mul(mgval, new(big.Int).SetUint64(st.feePercent)), types.BIG10000)                      //value deduct from fee address
-	mgSelfVal := new(big.Int).Div(new(big.Int).Mul(mgval, new(big.Int).SetUint64(types.BIG10000.Uint64()-st.feePercent)), types.BIG10000) //value deduct from sender address
+	mgFeeAddrVal, mgSelfVal := types.SplitMetaFee(mgval, st.feePercent) //values deducted from the fee address and the sender
 
 	if st.state.GetBalance(st.feeAddress).Cmp(mgFeeAddrVal) < 0 || st.state.GetBalance(st.msg.From()).Cmp(mgSelfVal) < 0 {
 		return ErrInsufficientFunds
@@ -349,10 +369,11 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	homestead := st.evm.ChainConfig().IsHomestead(st.evm.Context.BlockNumber)
 	istanbul := st.evm.ChainConfig().IsIstanbul(st.evm.Context.BlockNumber)
 	london := st.evm.ChainConfig().IsLondon(st.evm.Context.BlockNumber)
+	taurus := st.evm.ChainConfig().IsTaurus(st.evm.Context.BlockNumber)
 	contractCreation := msg.To() == nil
 
 	// Check clauses 4-5, subtract intrinsic gas if everything is correct
-	gas, err := IntrinsicGas(st.data, st.msg.AccessList(), contractCreation, homestead, istanbul)
+	gas, err := IntrinsicGas(st.data, st.msg.AccessList(), contractCreation, homestead, istanbul, taurus)
 	if err != nil {
 		return nil, err
 	}
@@ -366,6 +387,11 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 		return nil, fmt.Errorf("%w: address %v", ErrInsufficientFundsForTransfer, msg.From().Hex())
 	}
 
+	// Check whether the init code size has been exceeded (EIP-3860)
+	if taurus && contractCreation && len(st.data) > params.MaxInitCodeSize {
+		return nil, fmt.Errorf("%w: size %d, limit %d", ErrInitCodeTooLarge, len(st.data), params.MaxInitCodeSize)
+	}
+
 	// Set up the initial access list.
 	if rules := st.evm.ChainConfig().Rules(st.evm.Context.BlockNumber); rules.IsBerlin {
 		st.state.PrepareAccessList(msg.From(), msg.To(), vm.ActivePrecompiles(rules), msg.AccessList())
@@ -389,32 +415,68 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 		ret, st.gas, vmerr = st.evm.Call(sender, st.to(), st.data, st.gas, st.value)
 	}
 
+	var refund uint64
 	if !london {
 		// Before EIP-3529: refunds were capped to gasUsed / 2
-		st.refundGas(params.RefundQuotient)
+		refund = st.refundGas(params.RefundQuotient)
 	} else {
 		// After EIP-3529: refunds are capped to gasUsed / 5
-		st.refundGas(params.RefundQuotientEIP3529)
+		refund = st.refundGas(params.RefundQuotientEIP3529)
 	}
 	effectiveTip := st.gasPrice
 	if london {
 		effectiveTip = cmath.BigMin(st.gasTipCap, new(big.Int).Sub(st.gasFeeCap, st.evm.Context.BaseFee))
 	}
 	tip := new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), effectiveTip)
-	if st.evm.ChainConfig().Congress != nil {
+	if congress := st.evm.ChainConfig().Congress; congress != nil {
+		// Past the fee burn fork, the whole fee paid is split between the burn
+		// address and the validators, the base fee being burnt before it
+		if congress.IsFeeBurn(st.evm.Context.BlockNumber) {
+			if london && st.gasPrice.Cmp(st.evm.Context.BaseFee) >= 0 {
+				tip.Add(tip, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.evm.Context.BaseFee))
+			}
+			if burnt := burntFee(tip, st.feeBurnRatio()); burnt.Sign() > 0 {
+				st.state.AddBalance(consensus.BurnAddress, burnt)
+				tip.Sub(tip, burnt)
+			}
+		}
 		st.state.AddBalance(consensus.FeeRecoder, tip)
 	} else {
 		st.state.AddBalance(st.evm.Context.Coinbase, tip)
 	}
 
-	return &ExecutionResult{
-		UsedGas:    st.gasUsed(),
-		Err:        vmerr,
-		ReturnData: ret,
-	}, nil
+	result := &ExecutionResult{
+		UsedGas:      st.gasUsed(),
+		Err:          vmerr,
+		ReturnData:   ret,
+		IntrinsicGas: gas,
+		RefundedGas:  refund,
+	}
+	if st.isMeta {
+		result.MetaFee = types.SettleMetaFee(st.feeAddress, st.feePercent, st.initialGas, st.gasUsed(), st.gasPrice)
+	}
+	return result, nil
+}
+
+// feeBurnRatio returns the per mille of the transaction fees burnt, as tuned by
+// the consensus engine if any.
+func (st *StateTransition) feeBurnRatio() uint64 {
+	if st.evm.Context.FeeBurnRatio != nil {
+		return st.evm.Context.FeeBurnRatio(st.state, st.evm.Context.BlockNumber)
+	}
+	return st.evm.ChainConfig().Congress.FeeBurnRatioAt(st.evm.Context.BlockNumber)
+}
+
+// burntFee returns the part of a fee burnt at the given per mille ratio.
+func burntFee(fee *big.Int, ratio uint64) *big.Int {
+	if ratio >= params.CongressFeeBurnDenominator {
+		return new(big.Int).Set(fee)
+	}
+	burnt := new(big.Int).Mul(fee, new(big.Int).SetUint64(ratio))
+	return burnt.Div(burnt, big.NewInt(params.CongressFeeBurnDenominator))
 }
 
-func (st *StateTransition) refundGas(refundQuotient uint64) {
+func (st *StateTransition) refundGas(refundQuotient uint64) uint64 {
 	// Apply refund counter, capped to a refund quotient
 	refund := st.gasUsed() / refundQuotient
 	if refund > st.state.GetRefund() {
@@ -426,8 +488,7 @@ func (st *StateTransition) refundGas(refundQuotient uint64) {
 	remaining := new(big.Int).Mul(new(big.Int).SetUint64(st.gas), st.gasPrice)
 
 	if st.isMeta {
-		mgFeeAddrVal := new(big.Int).Div(new(big.Int).Mul(remaining, new(big.Int).SetUint64(st.feePercent)), types.BIG10000)
-		mgSelfVal := new(big.Int).Div(new(big.Int).Mul(remaining, new(big.Int).SetUint64(types.BIG10000.Uint64()-st.feePercent)), types.BIG10000)
+		mgFeeAddrVal, mgSelfVal := types.SplitMetaFee(remaining, st.feePercent)
 		st.state.AddBalance(st.feeAddress, mgFeeAddrVal)
 		st.state.AddBalance(st.msg.From(), mgSelfVal)
 		st.data = st.realPayload
@@ -438,6 +499,7 @@ func (st *StateTransition) refundGas(refundQuotient uint64) {
 	// Also return remaining gas to the block gas counter so it is
 	// available for the next transaction.
 	st.gp.AddGas(st.gas)
+	return refund
 }
 
 // gasUsed returns the amount of gas used up by the state transition.
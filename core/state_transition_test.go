@@ -0,0 +1,105 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the transaction fees are split between the burn address and the fee
+// recorder once the congress fee burn fork is reached, at the ratio of the chain
+// config or the one tuned by the consensus engine.
+func TestFeeBurn(t *testing.T) {
+	congress := *params.AllCongressProtocolChanges
+	congress.BerlinBlock, congress.LondonBlock = big.NewInt(0), big.NewInt(0)
+	congress.Congress = &params.CongressConfig{Period: 3, Epoch: 200, FeeBurnRatio: 300, FeeBurnBlock: big.NewInt(10)}
+
+	var (
+		sender   = common.Address{0x01}
+		coinbase = common.Address{0x02}
+		gwei     = big.NewInt(params.GWei)
+		tuned    = func(db vm.StateDB, height *big.Int) uint64 { return 1000 }
+	)
+	tests := []struct {
+		config   *params.ChainConfig
+		number   int64
+		baseFee  *big.Int
+		ratio    vm.FeeBurnRatioFunc
+		coinbase int64 // Gwei paid to the coinbase
+		recorder int64 // Gwei collected into the fee recorder
+		burnt    int64 // Gwei sent to the burn address
+	}{
+		// Non congress chains pay the coinbase
+		{params.TestChainConfig, 10, new(big.Int), nil, 42000, 0, 0},
+		// The fees are collected whole before the fork, the base fee destroyed
+		{&congress, 9, new(big.Int), nil, 0, 42000, 0},
+		{&congress, 9, gwei, nil, 0, 21000, 0},
+		// The fees are split at the chain config ratio, base fee included
+		{&congress, 10, new(big.Int), nil, 0, 29400, 12600},
+		{&congress, 10, gwei, nil, 0, 29400, 12600},
+		// The ratio tuned by the engine overrides the chain config one
+		{&congress, 10, new(big.Int), tuned, 0, 0, 42000},
+	}
+	for i, tt := range tests {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.AddBalance(sender, big.NewInt(params.Ether))
+
+		context := vm.BlockContext{
+			CanTransfer:  CanTransfer,
+			Transfer:     Transfer,
+			Coinbase:     coinbase,
+			BlockNumber:  big.NewInt(tt.number),
+			Time:         new(big.Int),
+			Difficulty:   new(big.Int),
+			BaseFee:      tt.baseFee,
+			GasLimit:     math.MaxUint64,
+			FeeBurnRatio: tt.ratio,
+		}
+		price := big.NewInt(2 * params.GWei)
+		msg := types.NewMessage(sender, &common.Address{0x03}, 0, new(big.Int), params.TxGas, price, price, price, nil, nil, false)
+		evm := vm.NewEVM(context, NewEVMTxContext(msg), statedb, tt.config, vm.Config{})
+
+		if _, err := ApplyMessage(evm, msg, new(GasPool).AddGas(math.MaxUint64)); err != nil {
+			t.Fatalf("test %d: failed to apply message: %v", i, err)
+		}
+		balances := []struct {
+			name string
+			addr common.Address
+			want int64
+		}{
+			{"coinbase", coinbase, tt.coinbase},
+			{"fee recorder", consensus.FeeRecoder, tt.recorder},
+			{"burn address", consensus.BurnAddress, tt.burnt},
+		}
+		for _, balance := range balances {
+			want := new(big.Int).Mul(big.NewInt(balance.want), gwei)
+			if have := statedb.GetBalance(balance.addr); have.Cmp(want) != 0 {
+				t.Errorf("test %d: %s balance mismatch: have %v, want %v", i, balance.name, have, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// supplyDelta accounts the native token flows of an executed block: the burnt
+// fees, the fees collected into the fee recorder and the fees distributed from
+// it. The running totals carry on from the parent's ones, and restart with the
+// block if the parent wasn't accounted, e.g. after a fast sync.
+func (bc *BlockChain) supplyDelta(block *types.Block, receipts types.Receipts, statedb *state.StateDB) *types.SupplyDelta {
+	var (
+		header       = block.Header()
+		signer       = types.MakeSigner(bc.chainConfig, header.Number)
+		posa, isPoSA = bc.engine.(consensus.PoSA)
+		feeBurn      = bc.chainConfig.Congress != nil && bc.chainConfig.Congress.IsFeeBurn(header.Number)
+		ratio        uint64
+		burnt        = new(big.Int)
+		fees         = new(big.Int)
+	)
+	if feeBurn {
+		ratio = bc.feeBurnRatio(posa, header)
+	}
+	for i, tx := range block.Transactions() {
+		if i >= len(receipts) {
+			break
+		}
+		if isPoSA {
+			sender, _ := types.Sender(signer, tx)
+			if ok, _ := posa.IsSysTransaction(sender, tx, header); ok {
+				continue // system transactions pay no fees
+			}
+		}
+		gasUsed := new(big.Int).SetUint64(receipts[i].GasUsed)
+		fee := new(big.Int).Mul(gasUsed, tx.EffectiveGasTipValue(header.BaseFee))
+		if header.BaseFee != nil {
+			baseFee := new(big.Int).Mul(gasUsed, header.BaseFee)
+			switch {
+			case !feeBurn:
+				burnt.Add(burnt, baseFee)
+			case tx.GasFeeCap().Cmp(header.BaseFee) >= 0:
+				fee.Add(fee, baseFee)
+			}
+		}
+		if feeBurn {
+			burn := burntFee(fee, ratio)
+			burnt.Add(burnt, burn)
+			fee.Sub(fee, burn)
+		}
+		fees.Add(fees, fee)
+	}
+	parent := rawdb.ReadSupplyDelta(bc.db, block.ParentHash(), block.NumberU64()-1)
+	return accountSupply(parent, block.NumberU64(), burnt, fees, statedb.GetBalance(consensus.FeeRecoder))
+}
+
+// feeBurnRatio returns the fee burn ratio paid by the transactions of a block,
+// the one in force in its parent state, or the chain config one if that state is
+// gone.
+func (bc *BlockChain) feeBurnRatio(posa consensus.PoSA, header *types.Header) uint64 {
+	if posa != nil {
+		if parent := bc.GetHeader(header.ParentHash, header.Number.Uint64()-1); parent != nil {
+			if statedb, err := bc.StateAt(parent.Root); err == nil {
+				return posa.FeeBurnRatio(statedb, header.Number)
+			}
+		}
+	}
+	return bc.chainConfig.Congress.FeeBurnRatioAt(header.Number)
+}
+
+// accountSupply assembles the supply accounting of a block on top of its parent's
+// one, nil if the parent wasn't accounted. The distributed rewards are derived from
+// the change of the fee recorder balance.
+func accountSupply(parent *types.SupplyDelta, number uint64, burnt, fees, pending *big.Int) *types.SupplyDelta {
+	delta := &types.SupplyDelta{
+		Burnt:        burnt,
+		Fees:         fees,
+		Pending:      new(big.Int).Set(pending),
+		Since:        number,
+		TotalBurnt:   new(big.Int).Set(burnt),
+		TotalFees:    new(big.Int).Set(fees),
+		TotalRewards: new(big.Int),
+	}
+	delta.Rewards = new(big.Int).Sub(fees, pending)
+	if parent != nil {
+		delta.Rewards.Add(delta.Rewards, parent.Pending)
+
+		delta.Since = parent.Since
+		delta.TotalBurnt.Add(delta.TotalBurnt, parent.TotalBurnt)
+		delta.TotalFees.Add(delta.TotalFees, parent.TotalFees)
+		delta.TotalRewards.Add(delta.TotalRewards, parent.TotalRewards)
+	}
+	delta.TotalRewards.Add(delta.TotalRewards, delta.Rewards)
+	return delta
+}
@@ -0,0 +1,51 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+)
+
+// Tests that the supply accounting carries the running totals over from the
+// parent block, derives the rewards from the fee recorder balance and restarts
+// on unaccounted parents.
+func TestAccountSupply(t *testing.T) {
+	// The first block accounted starts the running totals
+	first := accountSupply(nil, 10, big.NewInt(100), big.NewInt(30), big.NewInt(5))
+	if first.Since != 10 {
+		t.Fatalf("since mismatch: have %d, want 10", first.Since)
+	}
+	if first.Rewards.Int64() != 25 || first.TotalRewards.Int64() != 25 {
+		t.Fatalf("rewards mismatch: have %v/%v, want 25/25", first.Rewards, first.TotalRewards)
+	}
+	// The next block distributes its fees along with the parent's pending ones
+	second := accountSupply(first, 11, big.NewInt(50), big.NewInt(20), big.NewInt(0))
+	if second.Since != 10 {
+		t.Fatalf("since mismatch: have %d, want 10", second.Since)
+	}
+	if second.Rewards.Int64() != 25 {
+		t.Fatalf("rewards mismatch: have %v, want 25", second.Rewards)
+	}
+	if second.TotalBurnt.Int64() != 150 || second.TotalFees.Int64() != 50 || second.TotalRewards.Int64() != 50 {
+		t.Fatalf("totals mismatch: have %v/%v/%v, want 150/50/50", second.TotalBurnt, second.TotalFees, second.TotalRewards)
+	}
+	// The parent's totals are left untouched
+	if first.TotalBurnt.Int64() != 100 || first.TotalFees.Int64() != 30 {
+		t.Fatalf("parent totals modified: %v/%v", first.TotalBurnt, first.TotalFees)
+	}
+}
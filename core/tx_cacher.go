@@ -18,13 +18,23 @@ package core
 
 import (
 	"runtime"
+	"sync"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/gopool"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
 )
 
 // senderCacher is a concurrent transaction sender recoverer and cacher.
 var senderCacher = newTxSenderCacher(runtime.NumCPU())
 
+var (
+	senderHitMeter  = metrics.NewRegisteredMeter("txpool/sender/hit", nil)
+	senderMissMeter = metrics.NewRegisteredMeter("txpool/sender/miss", nil)
+)
+
 // txSenderCacherRequest is a request for recovering transaction senders with a
 // specific signature scheme and caching it into the transactions themselves.
 //
@@ -103,3 +113,93 @@ func (cacher *txSenderCacher) recoverFromBlocks(signer types.Signer, blocks []*t
 	}
 	cacher.recover(signer, txs)
 }
+
+const (
+	// minParallelRecovery is the number of transactions from which their senders
+	// are recovered in parallel on admission.
+	minParallelRecovery = 16
+
+	// senderCacheSize is the number of recovered senders kept by the admission cache.
+	senderCacheSize = 8192
+)
+
+// txSenderEntry is a sender recovered on admission, along with the signer used.
+type txSenderEntry struct {
+	signer types.Signer
+	from   common.Address
+}
+
+// txSenderCache is a micro-cache of the senders recovered on admission keyed by
+// transaction hash. The same transaction is usually delivered by many peers at
+// around the same time, each delivery decoding its own copy with no sender cached,
+// so the cache avoids recovering it for every copy.
+type txSenderCache struct {
+	cache *lru.Cache
+}
+
+func newTxSenderCache(size int) *txSenderCache {
+	cache, _ := lru.New(size)
+	return &txSenderCache{cache: cache}
+}
+
+// recover recovers the senders of a batch of transactions being admitted, caching
+// them into the transactions themselves. The senders are looked up in the cache
+// first, and the missing ones recovered in parallel on the shared goroutine pool
+// if there are enough of them. The returned errors are aligned with the batch.
+func (c *txSenderCache) recover(signer types.Signer, txs []*types.Transaction) []error {
+	var (
+		errs   = make([]error, len(txs))
+		misses = make([]int, 0, len(txs))
+	)
+	for i, tx := range txs {
+		if cached, ok := c.cache.Get(tx.Hash()); ok {
+			if entry := cached.(txSenderEntry); entry.signer.Equal(signer) {
+				types.CacheSender(signer, tx, entry.from)
+				senderHitMeter.Mark(1)
+				continue
+			}
+		}
+		misses = append(misses, i)
+	}
+	if len(misses) == 0 {
+		return errs
+	}
+	senderMissMeter.Mark(int64(len(misses)))
+
+	recoverSenders := func(indexes []int) {
+		for _, i := range indexes {
+			_, errs[i] = types.Sender(signer, txs[i])
+		}
+	}
+	if len(misses) < minParallelRecovery {
+		recoverSenders(misses)
+	} else {
+		// Split the misses evenly among the CPUs, in chunks of at least 4
+		chunk := (len(misses) + runtime.NumCPU() - 1) / runtime.NumCPU()
+		if chunk < 4 {
+			chunk = 4
+		}
+		var wg sync.WaitGroup
+		for start := 0; start < len(misses); start += chunk {
+			end := start + chunk
+			if end > len(misses) {
+				end = len(misses)
+			}
+			indexes := misses[start:end]
+
+			wg.Add(1)
+			gopool.Verification.Run(func() {
+				defer wg.Done()
+				recoverSenders(indexes)
+			})
+		}
+		wg.Wait()
+	}
+	for _, i := range misses {
+		if errs[i] == nil {
+			from, _ := types.Sender(signer, txs[i])
+			c.cache.Add(txs[i].Hash(), txSenderEntry{signer: signer, from: from})
+		}
+	}
+	return errs
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"fmt"
 	"math/big"
 	"sort"
 	"sync"
@@ -17,6 +18,15 @@ var (
 
 var oneGwei = big.NewInt(1e9)
 
+// jamHistoryLimit is the number of recent chain heads the jam index is kept for.
+const jamHistoryLimit = 1024
+
+// JamRecord is the jam index in force when a block became the chain head.
+type JamRecord struct {
+	Number   uint64
+	JamIndex int
+}
+
 var DefaultJamConfig = TxJamConfig{
 	PeriodsSecs:         3,
 	JamSecs:             15,
@@ -34,6 +44,20 @@ type TxJamConfig struct {
 	MaxValidPendingSecs int //
 }
 
+// Validate checks the configured values, zero meaning the default.
+func (c *TxJamConfig) Validate() error {
+	if c.PeriodsSecs < 0 || c.JamSecs < 0 || c.UnderPricedFactor < 0 || c.PendingFactor < 0 || c.MaxValidPendingSecs < 0 {
+		return fmt.Errorf("negative tx jam config value: %+v", *c)
+	}
+	if c.JamSecs != 0 && c.JamSecs < 3 {
+		return fmt.Errorf("tx jam seconds %d below 3", c.JamSecs)
+	}
+	if c.MaxValidPendingSecs != 0 && c.JamSecs != 0 && c.MaxValidPendingSecs <= c.JamSecs {
+		return fmt.Errorf("tx jam max valid pending seconds %d not above the jam seconds %d", c.MaxValidPendingSecs, c.JamSecs)
+	}
+	return nil
+}
+
 func (c *TxJamConfig) sanity() TxJamConfig {
 	cfg := *c
 	if cfg.PeriodsSecs < 1 {
@@ -67,12 +91,14 @@ type txJamIndexer struct {
 
 	undCounter      *underPricedCounter
 	currentJamIndex int
+	history         []JamRecord // Jam index of the recent chain heads, the oldest first
 
 	pendingLock sync.Mutex
 	jamLock     sync.RWMutex
 
 	quit        chan struct{}
 	chainHeadCh chan *types.Header
+	configCh    chan TxJamConfig
 }
 
 func newTxJamIndexer(cfg TxJamConfig, pool *TxPool) *txJamIndexer {
@@ -84,6 +110,7 @@ func newTxJamIndexer(cfg TxJamConfig, pool *TxPool) *txJamIndexer {
 		undCounter:  newUnderPricedCounter(cfg.PeriodsSecs),
 		quit:        make(chan struct{}),
 		chainHeadCh: make(chan *types.Header, 1),
+		configCh:    make(chan TxJamConfig),
 	}
 
 	go indexer.updateLoop()
@@ -104,6 +131,34 @@ func (indexer *txJamIndexer) JamIndex() int {
 	return indexer.currentJamIndex
 }
 
+// JamHistory returns the jam index of the chain heads since the given block, the
+// oldest first, as far as the recent history goes.
+func (indexer *txJamIndexer) JamHistory(from uint64) []JamRecord {
+	indexer.jamLock.RLock()
+	defer indexer.jamLock.RUnlock()
+
+	i := sort.Search(len(indexer.history), func(i int) bool {
+		return indexer.history[i].Number >= from
+	})
+	return append([]JamRecord(nil), indexer.history[i:]...)
+}
+
+// recordHead records the current jam index for the new chain head, dropping the
+// records of the blocks reorged out.
+func (indexer *txJamIndexer) recordHead(h *types.Header) {
+	indexer.jamLock.Lock()
+	defer indexer.jamLock.Unlock()
+
+	number := h.Number.Uint64()
+	for len(indexer.history) > 0 && indexer.history[len(indexer.history)-1].Number >= number {
+		indexer.history = indexer.history[:len(indexer.history)-1]
+	}
+	if len(indexer.history) >= jamHistoryLimit {
+		indexer.history = append(indexer.history[:0], indexer.history[len(indexer.history)-jamHistoryLimit+1:]...)
+	}
+	indexer.history = append(indexer.history, JamRecord{Number: number, JamIndex: indexer.currentJamIndex})
+}
+
 func (indexer *txJamIndexer) updateLoop() {
 	tick := time.NewTicker(time.Second * time.Duration(indexer.cfg.PeriodsSecs))
 	defer tick.Stop()
@@ -112,6 +167,16 @@ func (indexer *txJamIndexer) updateLoop() {
 		select {
 		case h := <-indexer.chainHeadCh:
 			indexer.head = h
+			indexer.recordHead(h)
+		case cfg := <-indexer.configCh:
+			// The refresh period sizes the underpriced counter, so it's kept as is
+			if cfg.PeriodsSecs != indexer.cfg.PeriodsSecs {
+				log.Warn("Tx jam refresh period can't be reloaded, restart required", "have", indexer.cfg.PeriodsSecs, "want", cfg.PeriodsSecs)
+				cfg.PeriodsSecs = indexer.cfg.PeriodsSecs
+			}
+			indexer.cfg = cfg
+			log.Info("Tx jam indexer reconfigured", "jamSecs", cfg.JamSecs, "underPricedFactor", cfg.UnderPricedFactor,
+				"pendingFactor", cfg.PendingFactor, "maxValidPendingSecs", cfg.MaxValidPendingSecs)
 		case <-tick.C:
 			d := indexer.undCounter.Sum()
 			pendings := indexer.pool.Pending(true)
@@ -182,6 +247,16 @@ func (indexer *txJamIndexer) updateLoop() {
 	}
 }
 
+// SetConfig updates the configuration of the jam index evaluation, except the
+// refresh period which can't be changed at runtime.
+func (indexer *txJamIndexer) SetConfig(cfg TxJamConfig) {
+	cfg = (&cfg).sanity()
+	select {
+	case indexer.configCh <- cfg:
+	case <-indexer.quit:
+	}
+}
+
 func (indexer *txJamIndexer) UpdateHeader(h *types.Header) {
 	indexer.chainHeadCh <- h
 }
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -31,6 +32,50 @@ import (
 // into the journal, but no such file is currently open.
 var errNoActiveJournal = errors.New("no active journal")
 
+// journalEntry is a journaled transaction along with the time it was first seen
+// locally, in unix milliseconds. The entries are RLP lists of two items, which are
+// told apart from the bare transactions of the older journals, being either byte
+// strings (typed transactions) or lists of nine items (legacy transactions).
+type journalEntry struct {
+	Tx   *types.Transaction
+	Seen uint64
+}
+
+// encodeJournalEntry writes the transaction along with its first seen time.
+func encodeJournalEntry(w io.Writer, tx *types.Transaction) error {
+	seen := tx.LocalSeenTime().UnixNano() / int64(time.Millisecond)
+	if seen < 0 {
+		seen = 0
+	}
+	return rlp.Encode(w, &journalEntry{Tx: tx, Seen: uint64(seen)})
+}
+
+// decodeJournalEntry parses a journaled transaction, restoring its first seen time
+// if it was journaled along with it.
+func decodeJournalEntry(raw []byte) (*types.Transaction, error) {
+	kind, content, _, err := rlp.Split(raw)
+	if err != nil {
+		return nil, err
+	}
+	if kind == rlp.List {
+		if n, err := rlp.CountValues(content); err == nil && n == 2 {
+			var entry journalEntry
+			if err := rlp.DecodeBytes(raw, &entry); err != nil {
+				return nil, err
+			}
+			if entry.Seen != 0 {
+				entry.Tx.SetLocalSeenTime(time.Unix(0, int64(entry.Seen)*int64(time.Millisecond)))
+			}
+			return entry.Tx, nil
+		}
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
 // devNull is a WriteCloser that just discards anything written into it. Its
 // goal is to allow the transaction journal to write into a fake journal when
 // loading transactions on startup without printing warnings due to no file
@@ -93,8 +138,14 @@ func (journal *txJournal) load(add func([]*types.Transaction) []error) error {
 	)
 	for {
 		// Parse the next transaction and terminate on error
-		tx := new(types.Transaction)
-		if err = stream.Decode(tx); err != nil {
+		var (
+			raw []byte
+			tx  *types.Transaction
+		)
+		if raw, err = stream.Raw(); err == nil {
+			tx, err = decodeJournalEntry(raw)
+		}
+		if err != nil {
 			if err != io.EOF {
 				failure = err
 			}
@@ -121,7 +172,7 @@ func (journal *txJournal) insert(tx *types.Transaction) error {
 	if journal.writer == nil {
 		return errNoActiveJournal
 	}
-	if err := rlp.Encode(journal.writer, tx); err != nil {
+	if err := encodeJournalEntry(journal.writer, tx); err != nil {
 		return err
 	}
 	return nil
@@ -145,7 +196,7 @@ func (journal *txJournal) rotate(all map[common.Address]types.Transactions) erro
 	journaled := 0
 	for _, txs := range all {
 		for _, tx := range txs {
-			if err = rlp.Encode(replacement, tx); err != nil {
+			if err = encodeJournalEntry(replacement, tx); err != nil {
 				replacement.Close()
 				return err
 			}
@@ -0,0 +1,89 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that the journal restores the first seen time of the transactions, and
+// still loads the bare transactions written by the older journals.
+func TestJournalSeenTime(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	var (
+		legacy  = transaction(0, 100000, key)
+		dynamic = dynamicFeeTx(1, 100000, big.NewInt(1), big.NewInt(1), key)
+		old     = transaction(2, 100000, key)
+		seen    = time.Unix(1600000000, 123000000)
+	)
+	legacy.SetLocalSeenTime(seen)
+	dynamic.SetLocalSeenTime(seen.Add(time.Second))
+
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	var buf bytes.Buffer
+	for _, tx := range []*types.Transaction{legacy, dynamic} {
+		if err := encodeJournalEntry(&buf, tx); err != nil {
+			t.Fatalf("failed to journal transaction: %v", err)
+		}
+	}
+	if err := rlp.Encode(&buf, old); err != nil {
+		t.Fatalf("failed to journal bare transaction: %v", err)
+	}
+	file.Write(buf.Bytes())
+	file.Close()
+
+	var loaded []*types.Transaction
+	journal := newTxJournal(file.Name())
+	if err := journal.load(func(txs []*types.Transaction) []error {
+		loaded = append(loaded, txs...)
+		return make([]error, len(txs))
+	}); err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	if len(loaded) != 3 {
+		t.Fatalf("loaded transaction count mismatch: have %d, want 3", len(loaded))
+	}
+	for i, want := range []*types.Transaction{legacy, dynamic, old} {
+		if loaded[i].Hash() != want.Hash() {
+			t.Errorf("tx %d: hash mismatch: have %x, want %x", i, loaded[i].Hash(), want.Hash())
+		}
+	}
+	if have := loaded[0].LocalSeenTime(); !have.Equal(seen) {
+		t.Errorf("legacy seen time mismatch: have %v, want %v", have, seen)
+	}
+	if have := loaded[1].LocalSeenTime(); !have.Equal(seen.Add(time.Second)) {
+		t.Errorf("dynamic fee seen time mismatch: have %v, want %v", have, seen.Add(time.Second))
+	}
+	if have := loaded[2].LocalSeenTime(); have.Before(old.LocalSeenTime()) {
+		t.Errorf("bare transaction seen time restored: %v", have)
+	}
+}
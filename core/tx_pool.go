@@ -18,6 +18,7 @@ package core
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"math/big"
 	"sort"
@@ -33,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/node/hooks"
 	"github.com/ethereum/go-ethereum/params"
 )
 
@@ -170,7 +172,8 @@ type TxPoolConfig struct {
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
 
-	JamConfig TxJamConfig
+	JamConfig      TxJamConfig
+	ResubmitConfig TxResubmitConfig
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -189,7 +192,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 
 	Lifetime: 3 * time.Hour,
 
-	JamConfig: DefaultJamConfig,
+	JamConfig:      DefaultJamConfig,
+	ResubmitConfig: DefaultResubmitConfig,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -246,11 +250,16 @@ type TxPool struct {
 	txFeed      event.Feed
 	scope       event.SubscriptionScope
 	signer      types.Signer
+	senders     *txSenderCache // Senders recovered on admission, keyed by tx hash
 	mu          sync.RWMutex
 
 	istanbul bool // Fork indicator whether we are in the istanbul stage.
 	eip2718  bool // Fork indicator whether we are using EIP-2718 type transactions.
 	eip1559  bool // Fork indicator whether we are using EIP-1559 type transactions.
+	taurus   bool // Fork indicator whether we are in the taurus stage.
+
+	nextBlock *big.Int             // Number of the next pending block, for the chain config size limits
+	feeless   *params.FeelessRules // Rules of the transactions paying no gas price at the next pending block
 
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
@@ -265,7 +274,9 @@ type TxPool struct {
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price
 
-	jamIndexer *txJamIndexer // tx jam indexer
+	jamIndexer  *txJamIndexer  // tx jam indexer
+	resubmitter *txResubmitter // local tx resubmission service, nil if disabled
+	traces      *txTraces      // Histories of the transactions submitted via RPC
 
 	txValidator    exTxValidator // A specific consensus can use this to do some extra validation to a transaction
 	nextFakeHeader *types.Header // A fake header of next block for extra transaction validation
@@ -304,6 +315,8 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		chainconfig:     chainconfig,
 		chain:           chain,
 		signer:          types.LatestSigner(chainconfig),
+		senders:         newTxSenderCache(senderCacheSize),
+		traces:          newTxTraces(),
 		pending:         make(map[common.Address]*txList),
 		queue:           make(map[common.Address]*txList),
 		beats:           make(map[common.Address]time.Time),
@@ -325,6 +338,9 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 	}
 	pool.priced = newTxPricedList(pool.all)
 	pool.reset(nil, chain.CurrentBlock().Header())
+	if config.ResubmitConfig.Enabled && !config.NoLocals {
+		pool.resubmitter = newTxResubmitter(config.ResubmitConfig, pool)
+	}
 
 	// Start the reorg loop early so it can handle requests generated during journal loading.
 	pool.wg.Add(1)
@@ -385,6 +401,9 @@ func (pool *TxPool) loop() {
 				pool.requestReset(head.Header(), ev.Block.Header())
 				head = ev.Block
 				pool.jamIndexer.UpdateHeader(head.Header())
+				if pool.resubmitter != nil {
+					pool.resubmitter.UpdateHeader(head.Header())
+				}
 			}
 
 		// System shutdown.
@@ -418,6 +437,7 @@ func (pool *TxPool) loop() {
 					for _, tx := range list {
 						pool.removeTx(tx.Hash(), true)
 					}
+					pool.traces.recordAll(list, "dropped", "queued past the lifetime")
 					queuedEvictionMeter.Mark(int64(len(list)))
 				}
 			}
@@ -446,6 +466,9 @@ func (pool *TxPool) Stop() {
 	pool.wg.Wait()
 
 	pool.jamIndexer.Stop()
+	if pool.resubmitter != nil {
+		pool.resubmitter.Stop()
+	}
 
 	if pool.journal != nil {
 		pool.journal.close()
@@ -479,10 +502,16 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 	if price.Cmp(old) > 0 {
 		// pool.priced is sorted by GasFeeCap, so we have to iterate through pool.all instead
 		drop := pool.all.RemotesBelowTip(price)
+		dropped := 0
 		for _, tx := range drop {
+			if from, _ := types.Sender(pool.signer, tx); pool.allowedFeeless(from, tx) {
+				continue
+			}
 			pool.removeTx(tx.Hash(), false)
+			pool.traces.record(tx.Hash(), "dropped", "below the minimum gas price")
+			dropped++
 		}
-		pool.priced.Removed(len(drop))
+		pool.priced.Removed(dropped)
 	}
 
 	log.Info("Transaction pool price threshold updated", "price", price)
@@ -562,17 +591,38 @@ func (pool *TxPool) ContentFrom(addr common.Address) (types.Transactions, types.
 // transactions and only return those whose **effective** tip is large enough in
 // the next pending execution environment.
 func (pool *TxPool) Pending(enforceTips bool) map[common.Address]types.Transactions {
+	return pool.PendingAt(enforceTips, nil)
+}
+
+// PendingAt retrieves the processable transactions like Pending, but measures the
+// effective tips at the given base fee of the block the transactions are selected
+// for, instead of the one the pool tracks, which lags behind a new chain head until
+// the pool is reset. A nil base fee means the tracked one.
+//
+// With a given base fee, the transactions unable to pay it are cut off along with
+// the ones following them for all the accounts, the local ones included, as they
+// can't be included in the block anyway.
+func (pool *TxPool) PendingAt(enforceTips bool, baseFee *big.Int) map[common.Address]types.Transactions {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
+	capFees := baseFee != nil
+	if !capFees {
+		baseFee = pool.priced.urgent.baseFee
+	}
 	pending := make(map[common.Address]types.Transactions)
 	for addr, list := range pool.pending {
 		txs := list.Flatten()
 
 		// If the miner requests tip enforcement, cap the lists now
-		if enforceTips && !pool.locals.contains(addr) {
+		enforce := enforceTips && !pool.locals.contains(addr)
+		if enforce || capFees {
 			for i, tx := range txs {
-				if tx.EffectiveGasTipIntCmp(pool.gasPrice, pool.priced.urgent.baseFee) < 0 {
+				if capFees && tx.GasFeeCapIntCmp(baseFee) < 0 {
+					txs = txs[:i]
+					break
+				}
+				if enforce && tx.EffectiveGasTipIntCmp(pool.gasPrice, baseFee) < 0 && !pool.allowedFeelessAt(addr, tx, baseFee) {
 					txs = txs[:i]
 					break
 				}
@@ -593,11 +643,138 @@ func (pool *TxPool) Locals() []common.Address {
 	return pool.locals.flatten()
 }
 
+// Snapshot freezes the pending transactions the miner would include in the next
+// block, split into the local and the remote ones, for a reproducible building of
+// the block with BuildBlock.
+func (pool *TxPool) Snapshot() *PoolSnapshot {
+	pending := pool.Pending(true)
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	snapshot := &PoolSnapshot{
+		Locals:  make(map[common.Address]types.Transactions),
+		Remotes: make(map[common.Address]types.Transactions),
+	}
+	for addr, txs := range pending {
+		if pool.locals.contains(addr) {
+			snapshot.Locals[addr] = txs
+		} else {
+			snapshot.Remotes[addr] = txs
+		}
+	}
+	return snapshot
+}
+
 // JamIndex returns the jam index which is evaluated by current pending transactions.
 func (pool *TxPool) JamIndex() int {
 	return pool.jamIndexer.JamIndex()
 }
 
+// JamHistory returns the jam index in force when the recent chain heads since the
+// given block were imported, the oldest first.
+func (pool *TxPool) JamHistory(from uint64) []JamRecord {
+	return pool.jamIndexer.JamHistory(from)
+}
+
+// SetJamConfig updates the configuration of the tx jam index evaluation.
+func (pool *TxPool) SetJamConfig(cfg TxJamConfig) {
+	pool.jamIndexer.SetConfig(cfg)
+}
+
+// SetResubmitSigner sets the callback used to re-sign fee bumped local transactions,
+// fee bumping is skipped if it's never set.
+func (pool *TxPool) SetResubmitSigner(fn TxResignFn) {
+	if pool.resubmitter != nil {
+		pool.resubmitter.setResignFn(fn)
+	}
+}
+
+// ResubmitManaged returns the local transactions managed by the resubmission service,
+// or nil if the service is disabled.
+func (pool *TxPool) ResubmitManaged() []*ResubmitTxInfo {
+	if pool.resubmitter == nil {
+		return nil
+	}
+	return pool.resubmitter.Managed()
+}
+
+// Reasons of the queued transactions held back from promotion.
+const (
+	BlockedByNonceGap     = "nonce gap"
+	BlockedByFunds        = "insufficient funds"
+	BlockedByGasLimit     = "exceeds block gas limit"
+	BlockedAwaitPromotion = "awaiting promotion"
+)
+
+// maxReportedNonceGaps is the maximum number of missing nonces reported by NonceGaps.
+const maxReportedNonceGaps = 1024
+
+// BlockedTx is a queued transaction held back from promotion, with the reason.
+type BlockedTx struct {
+	Tx     *types.Transaction
+	Reason string
+}
+
+// NonceGaps is the diagnosis of the transactions of an account stuck in the queue.
+type NonceGaps struct {
+	ChainNonce   uint64      // Nonce of the account in the head state
+	PendingNonce uint64      // Next nonce after the pending (executable) transactions
+	LowestQueued *uint64     // Lowest nonce in the queue, nil if nothing is queued
+	Missing      []uint64    // Nonces missing to promote the whole queue
+	Blocked      []BlockedTx // Queued transactions held back, sorted by nonce
+}
+
+// NonceGaps diagnoses why the queued transactions of the account are not promoted
+// to pending, which is the usual cause of the "stuck" transactions.
+func (pool *TxPool) NonceGaps(addr common.Address) *NonceGaps {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	gaps := &NonceGaps{
+		ChainNonce:   pool.currentState.GetNonce(addr),
+		PendingNonce: pool.pendingNonces.get(addr),
+	}
+	list := pool.queue[addr]
+	if list == nil || list.Empty() {
+		return gaps
+	}
+	var (
+		balance = pool.currentState.GetBalance(addr)
+		next    = gaps.PendingNonce
+		held    = BlockedAwaitPromotion // Reason holding back all the later transactions
+	)
+	for _, tx := range list.Flatten() {
+		nonce := tx.Nonce()
+		if gaps.LowestQueued == nil {
+			gaps.LowestQueued = &nonce
+		}
+		for ; next < nonce; next++ {
+			if len(gaps.Missing) >= maxReportedNonceGaps {
+				next = nonce
+				break
+			}
+			gaps.Missing = append(gaps.Missing, next)
+		}
+		reason := held
+		switch {
+		case len(gaps.Missing) > 0:
+			reason = BlockedByNonceGap
+		case held != BlockedAwaitPromotion:
+		case tx.Cost().Cmp(balance) > 0:
+			reason = BlockedByFunds
+		case tx.Gas() > pool.currentMaxGas:
+			reason = BlockedByGasLimit
+		}
+		held = reason
+		gaps.Blocked = append(gaps.Blocked, BlockedTx{Tx: tx, Reason: reason})
+		if nonce >= next {
+			next = nonce + 1
+		}
+	}
+	return gaps
+}
+
 // local retrieves all currently known local transactions, grouped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -629,6 +806,18 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if uint64(tx.Size()) > txMaxSize {
 		return ErrOversizedData
 	}
+	// Reject transactions over the size limits of the chain config
+	if err := CheckTxSizeLimits(pool.chainconfig, pool.nextBlock, tx); err != nil {
+		return err
+	}
+	// Reject unprotected transactions once the chain config disallows them
+	if pool.chainconfig.IsReplayProtection(pool.nextBlock) && !tx.Protected() {
+		return ErrUnprotectedTx
+	}
+	// Check whether the init code size has been exceeded (EIP-3860)
+	if pool.taurus && tx.To() == nil && len(tx.Data()) > params.MaxInitCodeSize {
+		return fmt.Errorf("%w: size %d, limit %d", ErrInitCodeTooLarge, len(tx.Data()), params.MaxInitCodeSize)
+	}
 	// Transactions can't be negative. This may never happen using RLP decoded
 	// transactions but may occur if you create a transaction using the RPC.
 	if tx.Value().Sign() < 0 {
@@ -654,9 +843,18 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if err != nil {
 		return ErrInvalidSender
 	}
-	// Drop non-local transactions under our own minimal accepted gas price or tip.
+	// Accept the transactions paying no gas price from the allowed senders only,
+	// within the per block gas cap of the chain config
 	pendingBaseFee := pool.priced.urgent.baseFee
-	if !local && tx.EffectiveGasTipIntCmp(pool.gasPrice, pendingBaseFee) < 0 {
+	feeless := pool.feeless != nil && IsFeeless(tx, pendingBaseFee)
+	if feeless && !pool.feeless.Allowed(from) {
+		return ErrFeelessNotAllowed
+	}
+	if feeless && pool.feeless.MaxGas != 0 && tx.Gas() > pool.feeless.MaxGas {
+		return ErrFeelessCapReached
+	}
+	// Drop non-local transactions under our own minimal accepted gas price or tip.
+	if !local && !feeless && tx.EffectiveGasTipIntCmp(pool.gasPrice, pendingBaseFee) < 0 {
 		return ErrUnderpriced
 	}
 	// Ensure the transaction adheres to nonce ordering
@@ -669,7 +867,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		return ErrInsufficientFunds
 	}
 	// Ensure the transaction has more gas than the basic tx fee.
-	intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, true, pool.istanbul)
+	intrGas, err := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, true, pool.istanbul, pool.taurus)
 	if err != nil {
 		return err
 	}
@@ -716,6 +914,12 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		invalidTxMeter.Mark(1)
 		return false, err
 	}
+	// Give the plugins a chance to reject the transaction
+	if err := hooks.TxAdmission(tx, isLocal); err != nil {
+		log.Trace("Discarding transaction rejected by plugin", "hash", hash, "err", err)
+		invalidTxMeter.Mark(1)
+		return false, err
+	}
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
@@ -753,6 +957,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			underpricedTxMeter.Mark(1)
 			pool.jamIndexer.UnderPricedInc()
 			pool.removeTx(tx.Hash(), false)
+			pool.traces.record(tx.Hash(), "dropped", "underpriced, pool full")
 		}
 	}
 	// Try to replace an existing transaction in the pending pool
@@ -769,12 +974,14 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed(1)
 			pendingReplaceMeter.Mark(1)
+			pool.traces.record(old.Hash(), "replaced", "by "+hash.Hex())
 		}
 		pool.all.Add(tx, isLocal)
 		pool.priced.Put(tx, isLocal)
 		pool.journalTx(from, tx)
 		pool.queueTxEvent(tx)
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
+		pool.traces.record(hash, "pooled", "pending")
 
 		// Successful promotion, bump the heartbeat
 		pool.beats[from] = time.Now()
@@ -797,6 +1004,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	pool.journalTx(from, tx)
 
 	log.Trace("Pooled new future transaction", "hash", hash, "from", from, "to", tx.To())
+	pool.traces.record(hash, "pooled", "queued")
 	return replaced, nil
 }
 
@@ -820,6 +1028,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction, local boo
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
+		pool.traces.record(old.Hash(), "replaced", "by "+hash.Hex())
 	} else {
 		// Nothing was replaced, bump the queued counter
 		queuedGauge.Inc(1)
@@ -869,6 +1078,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.all.Remove(hash)
 		pool.priced.Removed(1)
 		pendingDiscardMeter.Mark(1)
+		pool.traces.record(hash, "dropped", "better priced pending transaction with the same nonce")
 		return false
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -876,12 +1086,14 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		pendingReplaceMeter.Mark(1)
+		pool.traces.record(old.Hash(), "replaced", "by "+hash.Hex())
 	} else {
 		// Nothing was replaced, bump the pending counter
 		pendingGauge.Inc(1)
 	}
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.pendingNonces.set(addr, tx.Nonce()+1)
+	pool.traces.record(hash, "promoted", "")
 
 	// Successful promotion, bump the heartbeat
 	pool.beats[addr] = time.Now()
@@ -894,7 +1106,17 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 // This method is used to add transactions from the RPC API and performs synchronous pool
 // reorganization and event propagation.
 func (pool *TxPool) AddLocals(txs []*types.Transaction) []error {
-	return pool.addTxs(txs, !pool.config.NoLocals, true)
+	errs := pool.addTxs(txs, !pool.config.NoLocals, true)
+	if pool.resubmitter != nil {
+		added := make([]*types.Transaction, 0, len(txs))
+		for i, err := range errs {
+			if err == nil {
+				added = append(added, txs[i])
+			}
+		}
+		pool.resubmitter.track(added)
+	}
+	return errs
 }
 
 // AddLocal enqueues a single local transaction into the pool if it is valid. This is
@@ -940,6 +1162,7 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 		errs = make([]error, len(txs))
 		news = make([]*types.Transaction, 0, len(txs))
 	)
+	unknowns := make([]int, 0, len(txs))
 	for i, tx := range txs {
 		// If the transaction is known, pre-set the error slot
 		if pool.all.Get(tx.Hash()) != nil {
@@ -947,17 +1170,25 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local, sync bool) []error {
 			knownTxMeter.Mark(1)
 			continue
 		}
-		// Exclude transactions with invalid signatures as soon as
-		// possible and cache senders in transactions before
-		// obtaining lock
-		_, err := types.Sender(pool.signer, tx)
+		unknowns = append(unknowns, i)
+	}
+	if len(unknowns) == 0 {
+		return errs
+	}
+	// Exclude transactions with invalid signatures as soon as possible and cache
+	// senders in transactions before obtaining lock, recovering them in batch
+	batch := make([]*types.Transaction, len(unknowns))
+	for j, i := range unknowns {
+		batch[j] = txs[i]
+	}
+	for j, err := range pool.senders.recover(pool.signer, batch) {
 		if err != nil {
-			errs[i] = ErrInvalidSender
+			errs[unknowns[j]] = ErrInvalidSender
 			invalidTxMeter.Mark(1)
 			continue
 		}
 		// Accumulate all unknown transactions for deeper processing
-		news = append(news, tx)
+		news = append(news, batch[j])
 	}
 	if len(news) == 0 {
 		return errs
@@ -992,6 +1223,9 @@ func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error,
 	for i, tx := range txs {
 		replaced, err := pool.add(tx, local)
 		errs[i] = err
+		if err != nil {
+			pool.traces.record(tx.Hash(), "rejected", err.Error())
+		}
 		if err == nil && !replaced {
 			dirty.addTx(tx)
 		}
@@ -1200,6 +1434,9 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 		// the flatten operation can be avoided.
 		promoteAddrs = dirtyAccounts.flatten()
 	}
+	if reset != nil {
+		pool.traces.included(pool.chain, reset.oldHead, reset.newHead)
+	}
 	pool.mu.Lock()
 	if reset != nil {
 		// Reset from the old head to the new, rescheduling any reorged transactions
@@ -1357,9 +1594,50 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.istanbul = pool.chainconfig.IsIstanbul(next)
 	pool.eip2718 = pool.chainconfig.IsBerlin(next)
 	pool.eip1559 = pool.chainconfig.IsLondon(next)
+	pool.taurus = pool.chainconfig.IsTaurus(next)
+
+	// Drop the unprotected transactions when crossing the replay protection fork
+	if pool.chainconfig.IsReplayProtection(next) && !pool.chainconfig.IsReplayProtection(pool.nextBlock) {
+		pool.dropUnprotected()
+	}
+	pool.nextBlock = next
+	if pool.chainconfig.Congress != nil {
+		pool.feeless = pool.chainconfig.Congress.FeelessAt(next)
+	}
 
 }
 
+// allowedFeeless reports whether the transaction pays no gas price and is sent by
+// an account allowed to by the chain config. The caller must hold pool.mu.
+func (pool *TxPool) allowedFeeless(from common.Address, tx *types.Transaction) bool {
+	return pool.allowedFeelessAt(from, tx, pool.priced.urgent.baseFee)
+}
+
+// allowedFeelessAt is allowedFeeless at the given base fee.
+func (pool *TxPool) allowedFeelessAt(from common.Address, tx *types.Transaction, baseFee *big.Int) bool {
+	return pool.feeless != nil && pool.feeless.Allowed(from) && IsFeeless(tx, baseFee)
+}
+
+// dropUnprotected removes all the unprotected (non-EIP155) transactions from
+// the pool. The caller must hold pool.mu.
+func (pool *TxPool) dropUnprotected() {
+	var hashes []common.Hash
+	pool.all.Range(func(hash common.Hash, tx *types.Transaction, local bool) bool {
+		if !tx.Protected() {
+			hashes = append(hashes, hash)
+		}
+		return true
+	}, true, true)
+
+	for _, hash := range hashes {
+		pool.removeTx(hash, true)
+		pool.traces.record(hash, "dropped", "not replay-protected")
+	}
+	if len(hashes) > 0 {
+		log.Info("Dropped unprotected transactions", "count", len(hashes))
+	}
+}
+
 func (pool *TxPool) makeFakeHeader(currHead *types.Header) {
 	next := new(big.Int).Add(currHead.Number, big.NewInt(1))
 	pool.nextFakeHeader = &types.Header{
@@ -1390,6 +1668,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 			hash := tx.Hash()
 			pool.all.Remove(hash)
 		}
+		pool.traces.recordAll(forwards, "removed", "nonce already used")
 		log.Trace("Removed old queued transactions", "count", len(forwards))
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -1397,6 +1676,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 			hash := tx.Hash()
 			pool.all.Remove(hash)
 		}
+		pool.traces.recordAll(drops, "dropped", "insufficient funds or gas limit exceeded")
 		log.Trace("Removed unpayable queued transactions", "count", len(drops))
 		queuedNofundsMeter.Mark(int64(len(drops)))
 
@@ -1420,6 +1700,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 				pool.all.Remove(hash)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
+			pool.traces.recordAll(caps, "dropped", "account queue limit exceeded")
 			queuedRateLimitMeter.Mark(int64(len(caps)))
 		}
 		// Mark all the items dropped as removed
@@ -1485,6 +1766,7 @@ func (pool *TxPool) truncatePending() {
 						pool.pendingNonces.setIfLower(offenders[i], tx.Nonce())
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 					}
+					pool.traces.recordAll(caps, "dropped", "pending limit exceeded")
 					pool.priced.Removed(len(caps))
 					pendingGauge.Dec(int64(len(caps)))
 					if pool.locals.contains(offenders[i]) {
@@ -1512,6 +1794,7 @@ func (pool *TxPool) truncatePending() {
 					pool.pendingNonces.setIfLower(addr, tx.Nonce())
 					log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
 				}
+				pool.traces.recordAll(caps, "dropped", "pending limit exceeded")
 				pool.priced.Removed(len(caps))
 				pendingGauge.Dec(int64(len(caps)))
 				if pool.locals.contains(addr) {
@@ -1554,6 +1837,7 @@ func (pool *TxPool) truncateQueue() {
 		if size := uint64(list.Len()); size <= drop {
 			for _, tx := range list.Flatten() {
 				pool.removeTx(tx.Hash(), true)
+				pool.traces.record(tx.Hash(), "dropped", "queue limit exceeded")
 			}
 			drop -= size
 			queuedRateLimitMeter.Mark(int64(size))
@@ -1563,6 +1847,7 @@ func (pool *TxPool) truncateQueue() {
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 			pool.removeTx(txs[i].Hash(), true)
+			pool.traces.record(txs[i].Hash(), "dropped", "queue limit exceeded")
 			drop--
 			queuedRateLimitMeter.Mark(1)
 		}
@@ -1588,6 +1873,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			pool.all.Remove(hash)
 			log.Trace("Removed old pending transaction", "hash", hash)
 		}
+		pool.traces.recordAll(olds, "removed", "nonce already used")
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
 		for _, tx := range drops {
@@ -1595,11 +1881,13 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
 		}
+		pool.traces.recordAll(drops, "dropped", "insufficient funds or gas limit exceeded")
 		pendingNofundsMeter.Mark(int64(len(drops)))
 
 		for _, tx := range invalids {
 			hash := tx.Hash()
 			log.Trace("Demoting pending transaction", "hash", hash)
+			pool.traces.record(hash, "demoted", "")
 
 			// Internal shuffle shouldn't touch the lookup set.
 			pool.enqueueTx(hash, tx, false, false)
@@ -1616,6 +1904,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			for _, tx := range gapped {
 				hash := tx.Hash()
 				log.Error("Demoting invalidated transaction", "hash", hash)
+				pool.traces.record(hash, "demoted", "nonce gap")
 
 				// Internal shuffle shouldn't touch the lookup set.
 				pool.enqueueTx(hash, tx, false, false)
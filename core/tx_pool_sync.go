@@ -0,0 +1,118 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// PoolEntry is a transaction of the pool along with its pool metadata, exported
+// to warm up the pool of another node of the same trust domain.
+type PoolEntry struct {
+	Tx      hexutil.Bytes  `json:"tx"`      // Signed transaction in its binary encoding
+	Local   bool           `json:"local"`   // Whether the sender is local to the exporting node
+	Pending bool           `json:"pending"` // Whether the transaction is executable, queued otherwise
+	Seen    hexutil.Uint64 `json:"seen"`    // Time first seen by the exporting node, unix milliseconds
+}
+
+// PoolImportResult is the outcome of an import of exported pool entries.
+type PoolImportResult struct {
+	Imported int                    `json:"imported"`
+	Known    int                    `json:"known"`            // Entries already in the pool
+	Errors   map[common.Hash]string `json:"errors,omitempty"` // Entries rejected by the pool
+}
+
+// Export returns the pending and the queued transactions of the pool along with
+// their metadata, grouped by account and ordered by nonce.
+func (pool *TxPool) Export() ([]*PoolEntry, error) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	var entries []*PoolEntry
+	export := func(addr common.Address, txs types.Transactions, pending bool) error {
+		local := pool.locals.contains(addr)
+		for _, tx := range txs {
+			raw, err := tx.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			seen := tx.LocalSeenTime().UnixNano() / int64(time.Millisecond)
+			if seen < 0 {
+				seen = 0
+			}
+			entries = append(entries, &PoolEntry{Tx: raw, Local: local, Pending: pending, Seen: hexutil.Uint64(seen)})
+		}
+		return nil
+	}
+	for addr, list := range pool.pending {
+		if err := export(addr, list.Flatten(), true); err != nil {
+			return nil, err
+		}
+	}
+	for addr, list := range pool.queue {
+		if err := export(addr, list.Flatten(), false); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Import adds the exported pool entries to the pool, restoring the time they
+// were first seen at. The entries local to the exporting node are added as local
+// ones, the others as remote ones, the pool validating all of them.
+func (pool *TxPool) Import(entries []*PoolEntry) (*PoolImportResult, error) {
+	var locals, remotes []*types.Transaction
+	for _, entry := range entries {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(entry.Tx); err != nil {
+			return nil, err
+		}
+		if entry.Seen != 0 {
+			tx.SetLocalSeenTime(time.Unix(0, int64(entry.Seen)*int64(time.Millisecond)))
+		}
+		if entry.Local {
+			locals = append(locals, tx)
+		} else {
+			remotes = append(remotes, tx)
+		}
+	}
+	res := &PoolImportResult{Errors: make(map[common.Hash]string)}
+	tally := func(txs []*types.Transaction, errs []error) {
+		for i, err := range errs {
+			switch {
+			case err == nil:
+				res.Imported++
+			case errors.Is(err, ErrAlreadyKnown):
+				res.Known++
+			default:
+				res.Errors[txs[i].Hash()] = err.Error()
+			}
+		}
+	}
+	if len(locals) > 0 {
+		tally(locals, pool.AddLocals(locals))
+	}
+	if len(remotes) > 0 {
+		tally(remotes, pool.AddRemotesSync(remotes))
+	}
+	return res, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that the pool exported by a node is imported by another one with the
+// local flags, the pending and queued states and the first seen times intact.
+func TestTxPoolExportImport(t *testing.T) {
+	t.Parallel()
+
+	src, _ := setupTxPool()
+	defer src.Stop()
+	dst, _ := setupTxPool()
+	defer dst.Stop()
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+	for _, pool := range []*TxPool{src, dst} {
+		testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+		testAddBalance(pool, crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+	}
+	seen := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+
+	tx := pricedTransaction(0, 100000, big.NewInt(1), local)
+	tx.SetLocalSeenTime(seen)
+	if err := src.AddLocal(tx); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := src.AddLocal(pricedTransaction(1, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if errs := src.AddRemotesSync([]*types.Transaction{pricedTransaction(2, 100000, big.NewInt(1), remote)}); errs[0] != nil {
+		t.Fatalf("failed to add remote transaction: %v", errs[0])
+	}
+	entries, err := src.Export()
+	if err != nil {
+		t.Fatalf("failed to export the pool: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("exported entries mismatch: have %d, want %d", len(entries), 3)
+	}
+	res, err := dst.Import(entries)
+	if err != nil {
+		t.Fatalf("failed to import the pool: %v", err)
+	}
+	if res.Imported != 3 || res.Known != 0 || len(res.Errors) != 0 {
+		t.Fatalf("import result mismatch: %+v", res)
+	}
+	if pending, queued := dst.Stats(); pending != 2 || queued != 1 {
+		t.Fatalf("imported pool mismatch: have %d/%d pending/queued, want %d/%d", pending, queued, 2, 1)
+	}
+	if locals := dst.Locals(); len(locals) != 1 || locals[0] != crypto.PubkeyToAddress(local.PublicKey) {
+		t.Fatalf("imported locals mismatch: %v", locals)
+	}
+	if have := dst.Get(tx.Hash()).LocalSeenTime(); !have.Equal(seen) {
+		t.Fatalf("first seen time mismatch: have %v, want %v", have, seen)
+	}
+	// Importing the same entries again should be a noop
+	if res, err = dst.Import(entries); err != nil {
+		t.Fatalf("failed to import the pool again: %v", err)
+	}
+	if res.Imported != 0 || res.Known != 3 {
+		t.Fatalf("reimport result mismatch: %+v", res)
+	}
+}
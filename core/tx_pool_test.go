@@ -414,6 +414,127 @@ func TestTransactionVeryHighValues(t *testing.T) {
 	}
 }
 
+// Tests that the pending transactions retrieved at the base fee of the block they
+// are selected for are cut off at the first one unable to pay it, for the local
+// accounts too.
+func TestTransactionPendingAtBaseFee(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPoolWithConfig(eip1559Config)
+	defer pool.Stop()
+
+	local, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000000000000))
+
+	gwei := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(params.GWei)) }
+	for i, err := range pool.AddRemotesSync([]*types.Transaction{
+		dynamicFeeTx(0, 21000, gwei(3), gwei(1), key),
+		dynamicFeeTx(1, 21000, gwei(2), gwei(1), key),
+		dynamicFeeTx(2, 21000, gwei(5), gwei(2), key),
+	}) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add remote transaction: %v", i, err)
+		}
+	}
+	if err := pool.AddLocal(dynamicFeeTx(0, 21000, gwei(3), gwei(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	remote, own := crypto.PubkeyToAddress(key.PublicKey), crypto.PubkeyToAddress(local.PublicKey)
+
+	// At the base fee tracked by the pool all of them are pending
+	pending := pool.Pending(true)
+	if len(pending[remote]) != 3 || len(pending[own]) != 1 {
+		t.Fatalf("pending mismatch: have %d remote, %d local, want 3, 1", len(pending[remote]), len(pending[own]))
+	}
+	// A higher base fee cuts off the remote transactions at the first underpaying one
+	for _, enforce := range []bool{true, false} {
+		pending = pool.PendingAt(enforce, new(big.Int).Add(gwei(2), big.NewInt(1)))
+		if len(pending[remote]) != 1 || len(pending[own]) != 1 {
+			t.Errorf("enforce %v: pending mismatch: have %d remote, %d local, want 1, 1", enforce, len(pending[remote]), len(pending[own]))
+		}
+	}
+	// Above all the fee caps nothing is pending, the local transactions included
+	if pending = pool.PendingAt(false, gwei(4)); len(pending) != 0 {
+		t.Errorf("pending mismatch: have %d accounts, want none", len(pending))
+	}
+}
+
+// Tests that unprotected transactions are rejected once the replay protection
+// fork is active, and dropped from the pool when crossing it.
+func TestTransactionReplayProtection(t *testing.T) {
+	t.Parallel()
+
+	config := *params.TestChainConfig
+	config.ReplayProtectionBlock = common.Big0
+
+	pool, key := setupTxPoolWithConfig(&config)
+	defer pool.Stop()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(1), key)); !errors.Is(err, ErrUnprotectedTx) {
+		t.Errorf("unprotected tx error mismatch: have %v, want %v", err, ErrUnprotectedTx)
+	}
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(100), 100000, big.NewInt(1), nil), types.NewEIP155Signer(config.ChainID), key)
+	if err := pool.AddRemote(tx); err != nil {
+		t.Errorf("protected tx rejected: %v", err)
+	}
+
+	// Unprotected transactions admitted before the fork are dropped
+	pool, key = setupTxPool()
+	defer pool.Stop()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(1), key)); err != nil {
+		t.Fatalf("unprotected tx rejected before the fork: %v", err)
+	}
+	pool.mu.Lock()
+	pool.dropUnprotected()
+	pool.mu.Unlock()
+
+	if pending, queued := pool.Stats(); pending+queued != 0 {
+		t.Errorf("unprotected txs retained: pending %d, queued %d", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the transactions paying no gas price are only accepted from the
+// senders allowed by the chain config, and kept by the miner's view of the pool.
+func TestTransactionFeeless(t *testing.T) {
+	t.Parallel()
+
+	allowed, _ := crypto.GenerateKey()
+	config := *params.TestChainConfig
+	config.Congress = &params.CongressConfig{
+		FeelessSenders: []common.Address{crypto.PubkeyToAddress(allowed.PublicKey)},
+		MaxFeelessGas:  50000,
+	}
+	pool, key := setupTxPoolWithConfig(&config)
+	defer pool.Stop()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(allowed.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.AddRemote(pricedTransaction(0, 21000, common.Big0, key)); !errors.Is(err, ErrFeelessNotAllowed) {
+		t.Errorf("disallowed feeless tx error mismatch: have %v, want %v", err, ErrFeelessNotAllowed)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, 100000, common.Big0, allowed)); !errors.Is(err, ErrFeelessCapReached) {
+		t.Errorf("feeless tx over the gas cap error mismatch: have %v, want %v", err, ErrFeelessCapReached)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(0, 21000, common.Big0, allowed)); err != nil {
+		t.Fatalf("allowed feeless tx rejected: %v", err)
+	}
+	// Raising the price threshold neither drops nor hides the allowed transaction
+	pool.SetGasPrice(big.NewInt(2))
+	if pending := pool.Pending(true); len(pending[crypto.PubkeyToAddress(allowed.PublicKey)]) != 1 {
+		t.Errorf("allowed feeless tx not pending")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 func TestTransactionChainFork(t *testing.T) {
 	t.Parallel()
 
@@ -815,6 +936,52 @@ func TestTransactionGapFilling(t *testing.T) {
 
 // Tests that if the transaction count belonging to a single account goes above
 // some threshold, the higher transactions are dropped to prevent DOS attacks.
+// Tests that the nonce gaps holding back the queued transactions of an account
+// are reported.
+func TestTransactionNonceGaps(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, account, big.NewInt(1000000))
+
+	for _, nonce := range []uint64{0, 3, 4} {
+		if err := pool.addRemoteSync(transaction(nonce, 100000, key)); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", nonce, err)
+		}
+	}
+	gaps := pool.NonceGaps(account)
+	if gaps.ChainNonce != 0 || gaps.PendingNonce != 1 {
+		t.Fatalf("nonce mismatch: have chain %d pending %d, want 0 and 1", gaps.ChainNonce, gaps.PendingNonce)
+	}
+	if gaps.LowestQueued == nil || *gaps.LowestQueued != 3 {
+		t.Fatalf("lowest queued nonce mismatch: have %v, want 3", gaps.LowestQueued)
+	}
+	if len(gaps.Missing) != 2 || gaps.Missing[0] != 1 || gaps.Missing[1] != 2 {
+		t.Fatalf("missing nonces mismatch: have %v, want [1 2]", gaps.Missing)
+	}
+	if len(gaps.Blocked) != 2 {
+		t.Fatalf("blocked transactions mismatch: have %d, want 2", len(gaps.Blocked))
+	}
+	for i, blocked := range gaps.Blocked {
+		if blocked.Tx.Nonce() != uint64(3+i) || blocked.Reason != BlockedByNonceGap {
+			t.Errorf("blocked tx %d mismatch: have nonce %d reason %q", i, blocked.Tx.Nonce(), blocked.Reason)
+		}
+	}
+	// Fill the gaps and ensure nothing is reported anymore
+	for _, nonce := range []uint64{1, 2} {
+		if err := pool.addRemoteSync(transaction(nonce, 100000, key)); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", nonce, err)
+		}
+	}
+	gaps = pool.NonceGaps(account)
+	if gaps.PendingNonce != 5 || gaps.LowestQueued != nil || len(gaps.Missing) != 0 || len(gaps.Blocked) != 0 {
+		t.Fatalf("gaps reported after filling: %+v", gaps)
+	}
+}
+
 func TestTransactionQueueAccountLimiting(t *testing.T) {
 	t.Parallel()
 
@@ -2561,3 +2728,116 @@ func BenchmarkPoolMultiAccountBatchInsert(b *testing.B) {
 		pool.AddRemotesSync([]*types.Transaction{tx})
 	}
 }
+
+// copyTransaction decodes a fresh copy of the transaction with no sender cached,
+// the same as a transaction delivered by another peer.
+func copyTransaction(tx *types.Transaction) *types.Transaction {
+	blob, _ := tx.MarshalBinary()
+	cpy := new(types.Transaction)
+	cpy.UnmarshalBinary(blob)
+	return cpy
+}
+
+// Tests that the senders of the admitted transactions are recovered in batch, and
+// served from the cache for the copies of already recovered transactions.
+func TestTxSenderCache(t *testing.T) {
+	t.Parallel()
+
+	var (
+		cache  = newTxSenderCache(senderCacheSize)
+		signer = types.HomesteadSigner{}
+		txs    = make([]*types.Transaction, 2*minParallelRecovery)
+		froms  = make([]common.Address, len(txs))
+	)
+	for i := range txs {
+		key, _ := crypto.GenerateKey()
+		txs[i], froms[i] = transaction(0, 100000, key), crypto.PubkeyToAddress(key.PublicKey)
+	}
+	// Invalidate the signature of a transaction
+	invalid, _ := types.NewTransaction(0, common.Address{}, big.NewInt(100), 100000, big.NewInt(1), nil).WithSignature(signer, make([]byte, 65))
+	txs[5] = invalid
+
+	errs := cache.recover(signer, txs)
+	for i, tx := range txs {
+		if i == 5 {
+			if errs[i] == nil {
+				t.Errorf("tx %d: invalid signature accepted", i)
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Fatalf("tx %d: failed to recover sender: %v", i, errs[i])
+		}
+		if from, _ := types.Sender(signer, tx); from != froms[i] {
+			t.Errorf("tx %d: sender mismatch: have %x, want %x", i, from, froms[i])
+		}
+	}
+	if cache.cache.Len() != len(txs)-1 {
+		t.Errorf("cached sender count mismatch: have %d, want %d", cache.cache.Len(), len(txs)-1)
+	}
+	// Check a copy of a known transaction is served from the cache
+	fake := common.Address{0xff}
+	cache.cache.Add(txs[0].Hash(), txSenderEntry{signer: signer, from: fake})
+
+	cpy := copyTransaction(txs[0])
+	if errs := cache.recover(signer, []*types.Transaction{cpy}); errs[0] != nil {
+		t.Fatalf("failed to recover cached sender: %v", errs[0])
+	}
+	if from, _ := types.Sender(signer, cpy); from != fake {
+		t.Errorf("cached sender mismatch: have %x, want %x", from, fake)
+	}
+	// Check the cached senders of another signer are not used
+	cpy = copyTransaction(txs[0])
+	if errs := cache.recover(types.NewEIP155Signer(big.NewInt(1)), []*types.Transaction{cpy}); errs[0] != nil {
+		t.Fatalf("failed to recover sender: %v", errs[0])
+	}
+	if from, _ := types.Sender(types.NewEIP155Signer(big.NewInt(1)), cpy); from != froms[0] {
+		t.Errorf("sender mismatch: have %x, want %x", from, froms[0])
+	}
+}
+
+// Benchmarks the admission of batches of transactions from many accounts, which
+// is bound by the sender recovery.
+func BenchmarkPoolAdmission100(b *testing.B)   { benchmarkPoolAdmission(b, 100, 1) }
+func BenchmarkPoolAdmission1000(b *testing.B)  { benchmarkPoolAdmission(b, 1000, 1) }
+func BenchmarkPoolAdmission10000(b *testing.B) { benchmarkPoolAdmission(b, 10000, 1) }
+
+// Benchmarks the admission of batches of transactions delivered by many peers,
+// each peer delivering its own copy of the same transactions.
+func BenchmarkPoolAdmissionDuplicates4(b *testing.B)  { benchmarkPoolAdmission(b, 1000, 4) }
+func BenchmarkPoolAdmissionDuplicates16(b *testing.B) { benchmarkPoolAdmission(b, 1000, 16) }
+
+func benchmarkPoolAdmission(b *testing.B, size int, peers int) {
+	keys := make([]*ecdsa.PrivateKey, size)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	// Generate the batches delivered by the peers upfront
+	batches := make([][]types.Transactions, b.N)
+	for i := 0; i < b.N; i++ {
+		batch := make(types.Transactions, size)
+		for j, key := range keys {
+			batch[j] = transaction(uint64(i), 100000, key)
+		}
+		batches[i] = make([]types.Transactions, peers)
+		for p := 0; p < peers; p++ {
+			batches[i][p] = make(types.Transactions, size)
+			for j, tx := range batch {
+				batches[i][p][j] = copyTransaction(tx)
+			}
+		}
+	}
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	for _, key := range keys {
+		testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for _, deliveries := range batches {
+		for _, batch := range deliveries {
+			pool.AddRemotes(batch)
+		}
+	}
+}
@@ -0,0 +1,506 @@
+package core
+
+import (
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	resubmitTrackedGauge = metrics.NewRegisteredGauge("txpool/resubmit/tracked", nil)
+	resubmitMeter        = metrics.NewRegisteredMeter("txpool/resubmit/rebroadcast", nil)
+	resubmitBumpMeter    = metrics.NewRegisteredMeter("txpool/resubmit/bump", nil)
+	resubmitReaddMeter   = metrics.NewRegisteredMeter("txpool/resubmit/readd", nil)
+)
+
+var DefaultResubmitConfig = TxResubmitConfig{
+	Enabled:     false,
+	Blocks:      20,
+	PriceBump:   10,
+	MaxBumps:    3,
+	MaxGasPrice: 500, // gwei
+	MaxTracked:  1024,
+}
+
+// TxResubmitConfig are the configuration parameters of the local transaction
+// resubmission service. The tracking state of the transactions is persisted next to
+// the local journal, if enabled, as it changes to survive node restarts and crashes.
+type TxResubmitConfig struct {
+	Enabled     bool   // Whether local transactions should be tracked and resubmitted
+	Blocks      uint64 // How many blocks a local tx can stay unmined before it gets resubmitted
+	PriceBump   uint64 // Fee bump percentage for a resubmission, 0 means rebroadcast only
+	MaxBumps    int    // Maximum number of fee bumps for a single tx
+	MaxGasPrice uint64 // Upper limit of a bumped gas price (fee cap), in gwei
+	MaxTracked  int    // Maximum number of local txs being tracked at the same time
+}
+
+func (c *TxResubmitConfig) sanity(minBump uint64) TxResubmitConfig {
+	cfg := *c
+	if cfg.Blocks < 1 {
+		log.Info("ResubmitConfig sanity Blocks", "old", cfg.Blocks, "new", DefaultResubmitConfig.Blocks)
+		cfg.Blocks = DefaultResubmitConfig.Blocks
+	}
+	// a bump lower than the pool's replacement threshold will always be rejected
+	if cfg.PriceBump > 0 && cfg.PriceBump < minBump {
+		log.Info("ResubmitConfig sanity PriceBump", "old", cfg.PriceBump, "new", minBump)
+		cfg.PriceBump = minBump
+	}
+	if cfg.MaxBumps < 0 {
+		log.Info("ResubmitConfig sanity MaxBumps", "old", cfg.MaxBumps, "new", 0)
+		cfg.MaxBumps = 0
+	}
+	if cfg.MaxTracked < 1 {
+		log.Info("ResubmitConfig sanity MaxTracked", "old", cfg.MaxTracked, "new", DefaultResubmitConfig.MaxTracked)
+		cfg.MaxTracked = DefaultResubmitConfig.MaxTracked
+	}
+	return cfg
+}
+
+// TxResignFn is the callback used to re-sign a fee bumped local transaction.
+type TxResignFn func(from common.Address, tx *types.Transaction) (*types.Transaction, error)
+
+// ResubmitTxInfo describes a local transaction managed by the resubmission service.
+type ResubmitTxInfo struct {
+	Hash       common.Hash
+	Origin     common.Hash // hash of the tx when it was first tracked
+	From       common.Address
+	Nonce      uint64
+	GasPrice   *big.Int // gas price or fee cap
+	TrackedAt  uint64   // block number when the tx was first tracked
+	LastSubmit uint64   // block number of the last (re)submission
+	Resubmits  int
+	Bumps      int
+}
+
+type resubmitKey struct {
+	from  common.Address
+	nonce uint64
+}
+
+type resubmitEntry struct {
+	tx   *types.Transaction
+	info ResubmitTxInfo
+}
+
+// resubmitRecord is the persisted tracking state of a local transaction, restored
+// when the transaction is loaded back from the local journal after a restart.
+type resubmitRecord struct {
+	Hash      common.Hash
+	Origin    common.Hash
+	From      common.Address
+	Nonce     uint64
+	TrackedAt uint64
+	Resubmits uint64
+	Bumps     uint64
+}
+
+// txResubmitter tracks the local transactions, rebroadcasts the ones that are not
+// mined within a configured number of blocks, and optionally bumps their fees.
+type txResubmitter struct {
+	cfg      TxResubmitConfig
+	pool     *TxPool
+	resignFn TxResignFn
+	head     uint64
+
+	entries  map[resubmitKey]*resubmitEntry
+	restored map[resubmitKey]*resubmitRecord // Tracking state saved by the last run, until re-tracked
+	path     string                          // Filesystem path to persist the tracking state at, empty if disabled
+	lock     sync.RWMutex
+
+	quit        chan struct{}
+	chainHeadCh chan *types.Header
+	wg          sync.WaitGroup
+}
+
+func newTxResubmitter(cfg TxResubmitConfig, pool *TxPool) *txResubmitter {
+	cfg = (&cfg).sanity(pool.config.PriceBump)
+
+	r := &txResubmitter{
+		cfg:         cfg,
+		pool:        pool,
+		entries:     make(map[resubmitKey]*resubmitEntry),
+		restored:    make(map[resubmitKey]*resubmitRecord),
+		quit:        make(chan struct{}),
+		chainHeadCh: make(chan *types.Header, 1),
+	}
+	if head := pool.chain.CurrentBlock(); head != nil {
+		r.head = head.NumberU64()
+	}
+	// The tracking state is kept next to the local journal, the transactions
+	// themselves being restored from there.
+	if pool.config.Journal != "" {
+		r.path = pool.config.Journal + ".resubmit"
+		if err := r.load(); err != nil {
+			log.Warn("Failed to load local transaction resubmission state", "err", err)
+		}
+	}
+
+	r.wg.Add(1)
+	go r.loop()
+
+	return r
+}
+
+// Stop stops the loop goroutine of this resubmitter and persists its tracking state.
+func (r *txResubmitter) Stop() {
+	close(r.quit)
+	r.wg.Wait()
+
+	r.persist()
+}
+
+// persist saves the tracking state of the managed transactions, logging the
+// failures.
+func (r *txResubmitter) persist() {
+	if err := r.save(); err != nil {
+		log.Warn("Failed to save local transaction resubmission state", "err", err)
+	}
+}
+
+// load reads the tracking state persisted by the last run.
+func (r *txResubmitter) load() error {
+	input, err := os.Open(r.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	stream := rlp.NewStream(input, 0)
+	for {
+		record := new(resubmitRecord)
+		if err := stream.Decode(record); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		r.restored[resubmitKey{record.From, record.Nonce}] = record
+	}
+}
+
+// save persists the tracking state of the managed transactions, replacing the
+// one saved by the last run.
+func (r *txResubmitter) save() error {
+	if r.path == "" {
+		return nil
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	replacement, err := os.OpenFile(r.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	for _, e := range r.entries {
+		record := &resubmitRecord{
+			Hash:      e.info.Hash,
+			Origin:    e.info.Origin,
+			From:      e.info.From,
+			Nonce:     e.info.Nonce,
+			TrackedAt: e.info.TrackedAt,
+			Resubmits: uint64(e.info.Resubmits),
+			Bumps:     uint64(e.info.Bumps),
+		}
+		if err := rlp.Encode(replacement, record); err != nil {
+			replacement.Close()
+			return err
+		}
+	}
+	if err := replacement.Close(); err != nil {
+		return err
+	}
+	return os.Rename(r.path+".new", r.path)
+}
+
+func (r *txResubmitter) setResignFn(fn TxResignFn) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.resignFn = fn
+}
+
+// UpdateHeader notifies the resubmitter a new chain head, it never blocks the caller,
+// a head will be skipped if the previous one is still in processing.
+func (r *txResubmitter) UpdateHeader(h *types.Header) {
+	select {
+	case r.chainHeadCh <- h:
+	default:
+	}
+}
+
+// track starts to manage the given local transactions.
+func (r *txResubmitter) track(txs []*types.Transaction) {
+	if len(txs) == 0 {
+		return
+	}
+	r.lock.Lock()
+	for _, tx := range txs {
+		from, err := types.Sender(r.pool.signer, tx)
+		if err != nil {
+			continue
+		}
+		key := resubmitKey{from, tx.Nonce()}
+		if e, ok := r.entries[key]; ok {
+			// replaced by the user or by ourselves
+			e.tx = tx
+			e.info.Hash = tx.Hash()
+			e.info.GasPrice = tx.GasFeeCap()
+			e.info.LastSubmit = r.head
+			r.restore(key, e)
+			continue
+		}
+		if len(r.entries) >= r.cfg.MaxTracked {
+			log.Debug("Too many local txs for resubmission, skipped", "hash", tx.Hash())
+			continue
+		}
+		e := &resubmitEntry{
+			tx: tx,
+			info: ResubmitTxInfo{
+				Hash:       tx.Hash(),
+				Origin:     tx.Hash(),
+				From:       from,
+				Nonce:      tx.Nonce(),
+				GasPrice:   tx.GasFeeCap(),
+				TrackedAt:  r.head,
+				LastSubmit: r.head,
+			},
+		}
+		r.restore(key, e)
+		r.entries[key] = e
+	}
+	resubmitTrackedGauge.Update(int64(len(r.entries)))
+	r.lock.Unlock()
+
+	r.persist()
+}
+
+// restore carries on from the tracking state of the last run if it managed the
+// very tx of the entry, so the bumps stay capped across restarts. The journal may
+// hold the replaced txs too, loaded before their replacements. The lock must be held.
+func (r *txResubmitter) restore(key resubmitKey, e *resubmitEntry) {
+	record, ok := r.restored[key]
+	if !ok || record.Hash != e.info.Hash {
+		return
+	}
+	e.info.Origin = record.Origin
+	e.info.TrackedAt = record.TrackedAt
+	e.info.Resubmits = int(record.Resubmits)
+	e.info.Bumps = int(record.Bumps)
+	delete(r.restored, key)
+}
+
+// Managed returns all the transactions currently managed by the resubmitter,
+// sorted by sender and nonce.
+func (r *txResubmitter) Managed() []*ResubmitTxInfo {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	infos := make([]*ResubmitTxInfo, 0, len(r.entries))
+	for _, e := range r.entries {
+		info := e.info
+		info.GasPrice = new(big.Int).Set(e.info.GasPrice)
+		infos = append(infos, &info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].From != infos[j].From {
+			return infos[i].From.Hex() < infos[j].From.Hex()
+		}
+		return infos[i].Nonce < infos[j].Nonce
+	})
+	return infos
+}
+
+func (r *txResubmitter) loop() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case h := <-r.chainHeadCh:
+			r.onNewHead(h)
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+func (r *txResubmitter) onNewHead(h *types.Header) {
+	r.lock.Lock()
+	r.head = h.Number.Uint64()
+
+	// drop the mined ones, and collect the stale ones
+	r.pool.mu.RLock()
+	var (
+		stales []*resubmitEntry
+		mined  int
+	)
+	for key, e := range r.entries {
+		if r.pool.currentState.GetNonce(key.from) > key.nonce {
+			delete(r.entries, key)
+			mined++
+			continue
+		}
+		if r.head >= e.info.LastSubmit+r.cfg.Blocks {
+			stales = append(stales, e)
+		}
+	}
+	r.pool.mu.RUnlock()
+	resubmitTrackedGauge.Update(int64(len(r.entries)))
+
+	var (
+		rebroadcasts []*types.Transaction
+		readds       []*types.Transaction
+		readded      []*resubmitEntry
+		bumped       []bool
+	)
+	for _, e := range stales {
+		e.info.LastSubmit = r.head
+
+		if tx := r.bump(e); tx != nil {
+			readds, readded, bumped = append(readds, tx), append(readded, e), append(bumped, true)
+			continue
+		}
+		if r.pool.Has(e.tx.Hash()) {
+			rebroadcasts = append(rebroadcasts, e.tx)
+			e.info.Resubmits++
+		} else {
+			// dropped from the pool, e.g. evicted during congestion
+			readds, readded, bumped = append(readds, e.tx), append(readded, e), append(bumped, false)
+		}
+	}
+	r.lock.Unlock()
+
+	if len(rebroadcasts) > 0 {
+		log.Debug("Rebroadcasting stale local transactions", "count", len(rebroadcasts), "number", r.head)
+		resubmitMeter.Mark(int64(len(rebroadcasts)))
+		r.pool.txFeed.Send(NewTxsEvent{rebroadcasts})
+	}
+	if len(readds) > 0 {
+		// Only the transactions accepted by the pool count as resubmitted, the
+		// rejected bumps don't use up the bump allowance. The txs the bumps failed
+		// to replace are rebroadcast instead.
+		errs := r.pool.AddLocals(readds)
+
+		r.lock.Lock()
+		var (
+			added     int
+			underpaid []*types.Transaction
+		)
+		for i, err := range errs {
+			if err == ErrReplaceUnderpriced && bumped[i] && r.pool.Has(readded[i].tx.Hash()) {
+				underpaid = append(underpaid, readded[i].tx)
+				readded[i].info.Resubmits++
+				continue
+			}
+			if err != nil && err != ErrAlreadyKnown {
+				log.Debug("Failed to resubmit local transaction", "hash", readds[i].Hash(), "err", err)
+				continue
+			}
+			added++
+			readded[i].info.Resubmits++
+			if bumped[i] {
+				readded[i].info.Bumps++
+				resubmitBumpMeter.Mark(1)
+				log.Info("Bumped fee of stale local transaction", "from", readded[i].info.From, "nonce", readds[i].Nonce(), "origin", readded[i].info.Origin, "new", readds[i].Hash())
+			}
+		}
+		r.lock.Unlock()
+
+		log.Debug("Resubmitted local transactions", "count", added, "failed", len(readds)-added, "number", r.head)
+		resubmitReaddMeter.Mark(int64(added))
+
+		if len(underpaid) > 0 {
+			log.Debug("Rebroadcasting local transactions of underpriced bumps", "count", len(underpaid), "number", r.head)
+			resubmitMeter.Mark(int64(len(underpaid)))
+			r.pool.txFeed.Send(NewTxsEvent{underpaid})
+		}
+	}
+	if mined > 0 || len(stales) > 0 {
+		r.persist()
+	}
+}
+
+// bump returns a re-signed copy of the entry's tx with bumped fees, or nil if the
+// bumping is disabled, exhausted or failed, or if the price cap keeps the fees
+// below the full bump, which the pool would reject. The lock must be held.
+func (r *txResubmitter) bump(e *resubmitEntry) *types.Transaction {
+	if r.cfg.PriceBump == 0 || e.info.Bumps >= r.cfg.MaxBumps || r.resignFn == nil {
+		return nil
+	}
+	maxPrice := new(big.Int).Mul(new(big.Int).SetUint64(r.cfg.MaxGasPrice), big.NewInt(1e9))
+	bumpFn := func(v *big.Int) *big.Int {
+		n := new(big.Int).Mul(v, new(big.Int).SetUint64(100+r.cfg.PriceBump))
+		n.Div(n, big.NewInt(100))
+		if n.Cmp(maxPrice) > 0 {
+			return nil
+		}
+		return n
+	}
+
+	var inner types.TxData
+	tx := e.tx
+	switch tx.Type() {
+	case types.LegacyTxType:
+		price := bumpFn(tx.GasPrice())
+		if price == nil || price.Cmp(tx.GasPrice()) <= 0 {
+			return nil
+		}
+		inner = &types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: price,
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		}
+	case types.AccessListTxType:
+		price := bumpFn(tx.GasPrice())
+		if price == nil || price.Cmp(tx.GasPrice()) <= 0 {
+			return nil
+		}
+		inner = &types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasPrice:   price,
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}
+	case types.DynamicFeeTxType:
+		feeCap, tipCap := bumpFn(tx.GasFeeCap()), bumpFn(tx.GasTipCap())
+		if feeCap == nil || tipCap == nil || feeCap.Cmp(tx.GasFeeCap()) <= 0 {
+			return nil
+		}
+		inner = &types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  tipCap,
+			GasFeeCap:  feeCap,
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}
+	default:
+		return nil
+	}
+
+	signed, err := r.resignFn(e.info.From, types.NewTx(inner))
+	if err != nil {
+		log.Debug("Failed to re-sign bumped local transaction", "hash", tx.Hash(), "err", err)
+		return nil
+	}
+	return signed
+}
@@ -0,0 +1,234 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// setupResubmitTxPool creates a pool resubmitting the local transactions of the
+// returned key, stale after 2 blocks and bumped by 10% up to 12 gwei.
+func setupResubmitTxPool(blockchain *testBlockChain, journal string, maxBumps int) (*TxPool, *ecdsa.PrivateKey) {
+	config := testTxPoolConfig
+	config.Journal = journal
+	config.ResubmitConfig = TxResubmitConfig{
+		Enabled:     true,
+		Blocks:      2,
+		PriceBump:   10,
+		MaxBumps:    maxBumps,
+		MaxGasPrice: 12,
+		MaxTracked:  16,
+	}
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	<-pool.initDoneCh
+
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2b96dbc2b50291")
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1e18))
+	pool.SetResubmitSigner(func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		return types.SignTx(tx, pool.signer, key)
+	})
+	return pool, key
+}
+
+// resubmitHead feeds the resubmitter a new head synchronously.
+func resubmitHead(pool *TxPool, number uint64) {
+	pool.resubmitter.onNewHead(&types.Header{Number: new(big.Int).SetUint64(number)})
+}
+
+// checkResubmitted checks the tracking state of the single managed transaction.
+func checkResubmitted(t *testing.T, pool *TxPool, origin common.Hash, price int64, resubmits, bumps int) *ResubmitTxInfo {
+	t.Helper()
+
+	managed := pool.ResubmitManaged()
+	if len(managed) != 1 {
+		t.Fatalf("managed transactions mismatch: have %d, want 1", len(managed))
+	}
+	info := managed[0]
+	if info.Origin != origin {
+		t.Errorf("origin mismatch: have %x, want %x", info.Origin, origin)
+	}
+	if info.GasPrice.Cmp(big.NewInt(price)) != 0 {
+		t.Errorf("gas price mismatch: have %v, want %v", info.GasPrice, price)
+	}
+	if info.Resubmits != resubmits || info.Bumps != bumps {
+		t.Errorf("resubmissions mismatch: have %d resubmits %d bumps, want %d resubmits %d bumps", info.Resubmits, info.Bumps, resubmits, bumps)
+	}
+	if !pool.Has(info.Hash) {
+		t.Errorf("managed transaction %x missing from the pool", info.Hash)
+	}
+	return info
+}
+
+// Tests that the stale local transactions get their fees bumped up to the price
+// cap, the bumps short of the full bump being skipped for a rebroadcast.
+func TestResubmitBump(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	pool, key := setupResubmitTxPool(&testBlockChain{1000000, statedb, new(event.Feed)}, "", 3)
+	defer pool.Stop()
+
+	tx := pricedTransaction(0, 100000, big.NewInt(10*params.GWei), key)
+	if err := pool.AddLocal(tx); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	// Not stale yet, then bumped by 10%
+	resubmitHead(pool, 1)
+	checkResubmitted(t, pool, tx.Hash(), 10*params.GWei, 0, 0)
+
+	resubmitHead(pool, 2)
+	bumped := checkResubmitted(t, pool, tx.Hash(), 11*params.GWei, 1, 1)
+	if pool.Has(tx.Hash()) {
+		t.Errorf("replaced transaction still pooled")
+	}
+	// The next bump would be capped at 12 gwei, below the replacement threshold of
+	// the pool, so it is skipped and the bumped tx rebroadcast
+	resubmitHead(pool, 4)
+	if info := checkResubmitted(t, pool, tx.Hash(), 11*params.GWei, 2, 1); info.Hash != bumped.Hash {
+		t.Errorf("capped bump replaced the transaction: have %x, want %x", info.Hash, bumped.Hash)
+	}
+	// Mined transactions are no more managed
+	testSetNonce(pool, crypto.PubkeyToAddress(key.PublicKey), 1)
+	resubmitHead(pool, 5)
+	if managed := pool.ResubmitManaged(); len(managed) != 0 {
+		t.Errorf("mined transaction still managed: %+v", managed[0])
+	}
+}
+
+// Tests that the stale local transactions are only rebroadcast once the bumps
+// are used up, and re-added if dropped from the pool.
+func TestResubmitRebroadcast(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	pool, key := setupResubmitTxPool(&testBlockChain{1000000, statedb, new(event.Feed)}, "", 0)
+	defer pool.Stop()
+
+	events := make(chan NewTxsEvent, 4)
+	sub := pool.SubscribeNewTxsEvent(events)
+	defer sub.Unsubscribe()
+
+	tx := pricedTransaction(0, 100000, big.NewInt(10*params.GWei), key)
+	if err := pool.AddLocal(tx); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	<-events
+
+	resubmitHead(pool, 2)
+	checkResubmitted(t, pool, tx.Hash(), 10*params.GWei, 1, 0)
+	if ev := <-events; len(ev.Txs) != 1 || ev.Txs[0].Hash() != tx.Hash() {
+		t.Errorf("rebroadcast mismatch: have %v", ev.Txs)
+	}
+	// Drop the tx from the pool, it is re-added as is
+	pool.mu.Lock()
+	pool.removeTx(tx.Hash(), true)
+	pool.mu.Unlock()
+
+	resubmitHead(pool, 4)
+	checkResubmitted(t, pool, tx.Hash(), 10*params.GWei, 2, 0)
+}
+
+// Tests that the stale local transactions whose bump is rejected as underpriced by
+// the pool are rebroadcast instead, the bump allowance being kept.
+func TestResubmitUnderpricedBump(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	pool, key := setupResubmitTxPool(&testBlockChain{1000000, statedb, new(event.Feed)}, "", 3)
+	defer pool.Stop()
+
+	events := make(chan NewTxsEvent, 4)
+	sub := pool.SubscribeNewTxsEvent(events)
+	defer sub.Unsubscribe()
+
+	tx := pricedTransaction(0, 100000, big.NewInt(10*params.GWei), key)
+	if err := pool.AddLocal(tx); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	<-events
+
+	// Raise the replacement threshold of the pool above the bump
+	pool.mu.Lock()
+	pool.config.PriceBump = 50
+	pool.mu.Unlock()
+
+	resubmitHead(pool, 2)
+	checkResubmitted(t, pool, tx.Hash(), 10*params.GWei, 1, 0)
+	if ev := <-events; len(ev.Txs) != 1 || ev.Txs[0].Hash() != tx.Hash() {
+		t.Errorf("rebroadcast mismatch: have %v", ev.Txs)
+	}
+}
+
+// Tests that the tracking state of the local transactions survives a restart
+// along with the local journal, so the bumps stay capped.
+func TestResubmitRestart(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	journal := filepath.Join(dir, "transactions.rlp")
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed)}
+
+	pool, key := setupResubmitTxPool(blockchain, journal, 1)
+	tx := pricedTransaction(0, 100000, big.NewInt(10*params.GWei), key)
+	if err := pool.AddLocal(tx); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	resubmitHead(pool, 2)
+	bumped := checkResubmitted(t, pool, tx.Hash(), 11*params.GWei, 1, 1)
+
+	// The tracking state is saved as it changes, not only on a clean stop
+	saved := &txResubmitter{path: pool.resubmitter.path, restored: make(map[resubmitKey]*resubmitRecord)}
+	if err := saved.load(); err != nil {
+		t.Fatalf("failed to load the saved tracking state: %v", err)
+	}
+	record := saved.restored[resubmitKey{crypto.PubkeyToAddress(key.PublicKey), 0}]
+	if record == nil || record.Hash != bumped.Hash || record.Bumps != 1 {
+		t.Errorf("saved tracking state mismatch: have %+v", record)
+	}
+	pool.Stop()
+
+	// Restart, the bumped tx is tracked as before and its bump allowance is used up
+	pool, _ = setupResubmitTxPool(blockchain, journal, 1)
+	defer pool.Stop()
+
+	if info := checkResubmitted(t, pool, tx.Hash(), 11*params.GWei, 1, 1); info.Hash != bumped.Hash {
+		t.Errorf("restored transaction mismatch: have %x, want %x", info.Hash, bumped.Hash)
+	}
+	resubmitHead(pool, 4)
+	if info := checkResubmitted(t, pool, tx.Hash(), 11*params.GWei, 2, 1); info.Hash != bumped.Hash {
+		t.Errorf("bump allowance not kept across the restart: have %x, want %x", info.Hash, bumped.Hash)
+	}
+}
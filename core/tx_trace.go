@@ -0,0 +1,179 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// txTraceLimit is the maximum number of transactions traced at the same time,
+	// the least recently updated traces being dropped first.
+	txTraceLimit = 4096
+
+	// txTraceEvents is the maximum number of events recorded per transaction, the
+	// latest ones being kept.
+	txTraceEvents = 64
+
+	// txTraceBlocks is the maximum number of imported blocks scanned for traced
+	// transactions on a pool reset.
+	txTraceBlocks = 64
+)
+
+// TxTraceEvent is a step in the life of a traced transaction.
+type TxTraceEvent struct {
+	Time   time.Time `json:"time"`
+	Event  string    `json:"event"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// TxTrace is the history of a transaction submitted via RPC, from its submission
+// to its inclusion, under a correlation ID also tagging the related log lines.
+type TxTrace struct {
+	ID     string         `json:"id"`
+	Hash   common.Hash    `json:"hash"`
+	Events []TxTraceEvent `json:"events"`
+}
+
+// txTraces tracks the history of the transactions submitted via RPC.
+type txTraces struct {
+	traces *lru.Cache // Traces keyed by tx hash
+	lock   sync.Mutex // Serializes the updates of the traces
+}
+
+// newTxTraces creates an empty transaction trace set.
+func newTxTraces() *txTraces {
+	traces, _ := lru.New(txTraceLimit)
+	return &txTraces{traces: traces}
+}
+
+// start starts tracing the transaction, returning its correlation ID. The ID of
+// an already traced transaction is kept.
+func (t *txTraces) start(hash common.Hash, origin string) string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if trace, ok := t.traces.Get(hash); ok {
+		return trace.(*TxTrace).ID
+	}
+	var id [8]byte
+	rand.Read(id[:])
+
+	trace := &TxTrace{ID: hex.EncodeToString(id[:]), Hash: hash}
+	t.traces.Add(hash, trace)
+	t.append(trace, "received", origin)
+	return trace.ID
+}
+
+// record appends an event to the history of the transaction, if traced. Events
+// repeating the last one are ignored, e.g. the same tx selected for the sealing
+// block on every recommit.
+func (t *txTraces) record(hash common.Hash, event string, detail string) {
+	if t.traces.Len() == 0 {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	trace, ok := t.traces.Get(hash)
+	if !ok {
+		return
+	}
+	t.append(trace.(*TxTrace), event, detail)
+}
+
+// recordAll appends an event to the history of the traced transactions.
+func (t *txTraces) recordAll(txs []*types.Transaction, event string, detail string) {
+	for _, tx := range txs {
+		t.record(tx.Hash(), event, detail)
+	}
+}
+
+// append appends an event to the trace and logs it. The lock must be held.
+func (t *txTraces) append(trace *TxTrace, event string, detail string) {
+	if n := len(trace.Events); n > 0 && trace.Events[n-1].Event == event && trace.Events[n-1].Detail == detail {
+		return
+	}
+	trace.Events = append(trace.Events, TxTraceEvent{Time: time.Now(), Event: event, Detail: detail})
+	if len(trace.Events) > txTraceEvents {
+		trace.Events = append(trace.Events[:0], trace.Events[len(trace.Events)-txTraceEvents:]...)
+	}
+	log.Debug("Traced transaction "+event, "cid", trace.ID, "hash", trace.Hash, "detail", detail)
+}
+
+// get returns a copy of the trace of the transaction, nil if not traced.
+func (t *txTraces) get(hash common.Hash) *TxTrace {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	trace, ok := t.traces.Peek(hash)
+	if !ok {
+		return nil
+	}
+	cpy := *trace.(*TxTrace)
+	cpy.Events = append([]TxTraceEvent(nil), cpy.Events...)
+	return &cpy
+}
+
+// included records the inclusion of the traced transactions of the blocks from
+// the old head, exclusive, to the new one.
+func (t *txTraces) included(chain blockChain, oldHead, newHead *types.Header) {
+	if t.traces.Len() == 0 || newHead == nil {
+		return
+	}
+	var blocks []*types.Block
+	for block := chain.GetBlock(newHead.Hash(), newHead.Number.Uint64()); block != nil && len(blocks) < txTraceBlocks; {
+		if oldHead != nil && block.NumberU64() <= oldHead.Number.Uint64() {
+			break
+		}
+		blocks = append(blocks, block)
+		if oldHead == nil || block.NumberU64() == 0 {
+			break
+		}
+		block = chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		detail := "block " + blocks[i].Number().String() + " " + blocks[i].Hash().Hex()
+		t.recordAll(blocks[i].Transactions(), "included", detail)
+	}
+}
+
+// TraceTx starts tracing a transaction submitted via RPC, before its addition to
+// the pool, returning its correlation ID.
+func (pool *TxPool) TraceTx(hash common.Hash, origin string) string {
+	return pool.traces.start(hash, origin)
+}
+
+// RecordTxTrace appends an event to the history of a transaction if traced, for
+// the subsystems handling the pooled transactions, e.g. the miner.
+func (pool *TxPool) RecordTxTrace(hash common.Hash, event string, detail string) {
+	pool.traces.record(hash, event, detail)
+}
+
+// TxTrace returns the history of a transaction submitted via RPC, or nil if not
+// traced or not anymore.
+func (pool *TxPool) TxTrace(hash common.Hash) *TxTrace {
+	return pool.traces.get(hash)
+}
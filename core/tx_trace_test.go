@@ -0,0 +1,81 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that the pool records the history of the traced transactions only.
+func TestTransactionTrace(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	traced, other := transaction(1, 100000, key), transaction(0, 100000, key)
+	id := pool.TraceTx(traced.Hash(), "rpc")
+	if again := pool.TraceTx(traced.Hash(), "rpc"); again != id {
+		t.Fatalf("correlation id changed: have %s, want %s", again, id)
+	}
+	// Queue the traced tx, promote it, replace it and resubmit it
+	if err := pool.AddLocal(traced); err != nil {
+		t.Fatalf("failed to add traced transaction: %v", err)
+	}
+	if err := pool.AddLocal(other); err != nil {
+		t.Fatalf("failed to add gapped transaction: %v", err)
+	}
+	replacement := pricedTransaction(1, 100000, big.NewInt(2), key)
+	if err := pool.AddLocal(replacement); err != nil {
+		t.Fatalf("failed to add replacement transaction: %v", err)
+	}
+	if err := pool.AddLocal(traced); err != ErrReplaceUnderpriced {
+		t.Fatalf("resubmission error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	trace := pool.TxTrace(traced.Hash())
+	if trace == nil || trace.ID != id || trace.Hash != traced.Hash() {
+		t.Fatalf("trace mismatch: have %+v, want id %s", trace, id)
+	}
+	want := []TxTraceEvent{
+		{Event: "received", Detail: "rpc"},
+		{Event: "pooled", Detail: "queued"},
+		{Event: "promoted"},
+		{Event: "replaced", Detail: "by " + replacement.Hash().Hex()},
+		{Event: "rejected", Detail: ErrReplaceUnderpriced.Error()},
+	}
+	if len(trace.Events) != len(want) {
+		t.Fatalf("event count mismatch: have %+v, want %+v", trace.Events, want)
+	}
+	for i, event := range trace.Events {
+		if event.Event != want[i].Event || event.Detail != want[i].Detail {
+			t.Errorf("event %d mismatch: have %s %q, want %s %q", i, event.Event, event.Detail, want[i].Event, want[i].Detail)
+		}
+	}
+	// Transactions not submitted via RPC aren't traced
+	if trace := pool.TxTrace(other.Hash()); trace != nil {
+		t.Errorf("untraced transaction has a trace: %+v", trace)
+	}
+	pool.RecordTxTrace(replacement.Hash(), "selected", "block 1")
+	if trace := pool.TxTrace(replacement.Hash()); trace != nil {
+		t.Errorf("untraced transaction has a trace: %+v", trace)
+	}
+}
@@ -0,0 +1,19 @@
+package types
+
+import "github.com/ethereum/go-ethereum/common"
+
+// HeadAttestation is a validator's signed announcement of the block it considers
+// canonical at the given height, gossiped so that the nodes can detect when they
+// follow a minority fork.
+type HeadAttestation struct {
+	Number    uint64
+	Hash      common.Hash
+	Validator common.Address
+	Signature []byte // Signature over the congress attestation message
+}
+
+// ID returns the identifier of the attestation used for deduplication, i.e. the
+// hash of everything but the signature.
+func (a *HeadAttestation) ID() common.Hash {
+	return rlpHash([]interface{}{a.Number, a.Hash, a.Validator})
+}
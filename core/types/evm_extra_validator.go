@@ -9,4 +9,10 @@ type EvmExtraValidator interface {
 	IsAddressDenied(address common.Address, cType common.AddressCheckType) bool
 	// IsLogDenied returns whether a log (contract event) is denied.
 	IsLogDenied(log *Log) bool
+	// IsCallTargetDenied returns whether the code of target is denied to run in the
+	// context of caller, i.e. reached through a DELEGATECALL or CALLCODE.
+	IsCallTargetDenied(caller, target common.Address) bool
+	// IsDeployerDenied returns whether an address is denied to deploy contracts
+	// with CREATE2.
+	IsDeployerDenied(deployer common.Address) bool
 }
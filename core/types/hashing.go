@@ -18,9 +18,11 @@ package types
 
 import (
 	"bytes"
+	"runtime"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/gopool"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 	"golang.org/x/crypto/sha3"
@@ -83,31 +85,98 @@ func encodeForDerive(list DerivableList, i int, buf *bytes.Buffer) []byte {
 	return common.CopyBytes(buf.Bytes())
 }
 
+// parallelHashThreshold is the list length from which DeriveShaParallel and
+// CacheTxHashes spread the work over the shared worker pool.
+const parallelHashThreshold = 256
+
 // DeriveSha creates the tree hashes of transactions and receipts in a block header.
 func DeriveSha(list DerivableList, hasher TrieHasher) common.Hash {
-	hasher.Reset()
-
 	valueBuf := encodeBufferPool.Get().(*bytes.Buffer)
 	defer encodeBufferPool.Put(valueBuf)
 
+	return deriveSha(list.Len(), func(i int) []byte {
+		return encodeForDerive(list, i, valueBuf)
+	}, hasher)
+}
+
+// DeriveShaParallel is DeriveSha, but encodes the items of large lists concurrently
+// on the shared worker pool. Only the trie insertion remains sequential.
+func DeriveShaParallel(list DerivableList, hasher TrieHasher) common.Hash {
+	if list.Len() < parallelHashThreshold {
+		return DeriveSha(list, hasher)
+	}
+	values := make([][]byte, list.Len())
+	parallelRange(list.Len(), func(start, end int) {
+		valueBuf := encodeBufferPool.Get().(*bytes.Buffer)
+		defer encodeBufferPool.Put(valueBuf)
+
+		for i := start; i < end; i++ {
+			values[i] = encodeForDerive(list, i, valueBuf)
+		}
+	})
+	return deriveSha(len(values), func(i int) []byte {
+		return values[i]
+	}, hasher)
+}
+
+// deriveSha inserts the n encoded items of a list into the hasher.
+func deriveSha(n int, value func(i int) []byte, hasher TrieHasher) common.Hash {
+	hasher.Reset()
+
 	// StackTrie requires values to be inserted in increasing hash order, which is not the
 	// order that `list` provides hashes in. This insertion sequence ensures that the
 	// order is correct.
 	var indexBuf []byte
-	for i := 1; i < list.Len() && i <= 0x7f; i++ {
+	for i := 1; i < n && i <= 0x7f; i++ {
 		indexBuf = rlp.AppendUint64(indexBuf[:0], uint64(i))
-		value := encodeForDerive(list, i, valueBuf)
-		hasher.Update(indexBuf, value)
+		hasher.Update(indexBuf, value(i))
 	}
-	if list.Len() > 0 {
+	if n > 0 {
 		indexBuf = rlp.AppendUint64(indexBuf[:0], 0)
-		value := encodeForDerive(list, 0, valueBuf)
-		hasher.Update(indexBuf, value)
+		hasher.Update(indexBuf, value(0))
 	}
-	for i := 0x80; i < list.Len(); i++ {
+	for i := 0x80; i < n; i++ {
 		indexBuf = rlp.AppendUint64(indexBuf[:0], uint64(i))
-		value := encodeForDerive(list, i, valueBuf)
-		hasher.Update(indexBuf, value)
+		hasher.Update(indexBuf, value(i))
 	}
 	return hasher.Hash()
 }
+
+// CacheTxHashes computes and caches the hashes of the transactions, doing it
+// concurrently on the shared worker pool for large lists.
+func CacheTxHashes(txs Transactions) {
+	if len(txs) < parallelHashThreshold {
+		for _, tx := range txs {
+			tx.Hash()
+		}
+		return
+	}
+	parallelRange(len(txs), func(start, end int) {
+		for _, tx := range txs[start:end] {
+			tx.Hash()
+		}
+	})
+}
+
+// parallelRange splits [0, n) into a chunk per CPU and runs fn on each of them
+// on the shared worker pool, waiting for all to complete. The chunks the pool
+// rejects are run inline.
+func parallelRange(n int, fn func(start, end int)) {
+	var (
+		chunks = runtime.NumCPU()
+		wg     sync.WaitGroup
+	)
+	if chunks > n {
+		chunks = n
+	}
+	for i := 0; i < chunks; i++ {
+		start, end := i*n/chunks, (i+1)*n/chunks
+
+		wg.Add(1)
+		gopool.Run(func() {
+			defer wg.Done()
+			fn(start, end)
+		})
+	}
+	wg.Wait()
+}
@@ -101,6 +101,109 @@ func BenchmarkDeriveSha200(b *testing.B) {
 	}
 }
 
+func TestDeriveShaParallel(t *testing.T) {
+	txs, err := genTxs(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipts := genReceipts(1000)
+	for _, n := range []int{0, 1, 200, 255, 256, 257, 1000} {
+		exp := types.DeriveSha(txs[:n], trie.NewStackTrie(nil))
+		got := types.DeriveShaParallel(txs[:n], trie.NewStackTrie(nil))
+		if got != exp {
+			t.Errorf("%d txs: got %x exp %x", n, got, exp)
+		}
+		exp = types.DeriveSha(receipts[:n], trie.NewStackTrie(nil))
+		got = types.DeriveShaParallel(receipts[:n], trie.NewStackTrie(nil))
+		if got != exp {
+			t.Errorf("%d receipts: got %x exp %x", n, got, exp)
+		}
+	}
+}
+
+func TestCacheTxHashes(t *testing.T) {
+	txs, err := genTxs(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, _ := rlp.EncodeToBytes(txs)
+
+	var fresh types.Transactions
+	if err := rlp.DecodeBytes(enc, &fresh); err != nil {
+		t.Fatal(err)
+	}
+	types.CacheTxHashes(fresh)
+	for i, tx := range fresh {
+		if tx.Hash() != txs[i].Hash() {
+			t.Fatalf("tx %d: hash mismatch: have %x, want %x", i, tx.Hash(), txs[i].Hash())
+		}
+	}
+}
+
+// Benchmarks the receipt and transaction root derivation of 10k-tx blocks, as
+// done on block assembly and import.
+func BenchmarkDeriveSha10k(b *testing.B) {
+	txs, err := genTxs(10000)
+	if err != nil {
+		b.Fatal(err)
+	}
+	receipts := genReceipts(10000)
+
+	for _, bench := range []struct {
+		name string
+		list types.DerivableList
+	}{{"txs", txs}, {"receipts", receipts}} {
+		list := bench.list
+		b.Run(bench.name+"/sequential", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				types.DeriveSha(list, trie.NewStackTrie(nil))
+			}
+		})
+		b.Run(bench.name+"/parallel", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				types.DeriveShaParallel(list, trie.NewStackTrie(nil))
+			}
+		})
+	}
+}
+
+// Benchmarks the hash derivation of the transactions of a 10k-tx block.
+func BenchmarkCacheTxHashes10k(b *testing.B) {
+	txs, err := genTxs(10000)
+	if err != nil {
+		b.Fatal(err)
+	}
+	enc, _ := rlp.EncodeToBytes(txs)
+
+	decode := func() types.Transactions {
+		var fresh types.Transactions
+		if err := rlp.DecodeBytes(enc, &fresh); err != nil {
+			b.Fatal(err)
+		}
+		return fresh
+	}
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			fresh := decode()
+			b.StartTimer()
+			for _, tx := range fresh {
+				tx.Hash()
+			}
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			fresh := decode()
+			b.StartTimer()
+			types.CacheTxHashes(fresh)
+		}
+	})
+}
+
 func TestFuzzDeriveSha(t *testing.T) {
 	// increase this for longer runs -- it's set to quite low for travis
 	rndSeed := mrand.Int()
@@ -165,6 +268,23 @@ func genTxs(num uint64) (types.Transactions, error) {
 	return txs, nil
 }
 
+func genReceipts(num int) types.Receipts {
+	receipts := make(types.Receipts, num)
+	for i := range receipts {
+		receipts[i] = &types.Receipt{
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: uint64(i+1) * 21000,
+			Logs: []*types.Log{{
+				Address: common.BytesToAddress([]byte{byte(i), byte(i >> 8)}),
+				Topics:  []common.Hash{common.BigToHash(big.NewInt(int64(i)))},
+				Data:    make([]byte, 64),
+			}},
+		}
+		receipts[i].Bloom = types.CreateBloom(types.Receipts{receipts[i]})
+	}
+	return receipts
+}
+
 type dummyDerivableList struct {
 	len  int
 	seed int
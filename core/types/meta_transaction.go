@@ -85,3 +85,51 @@ func (metadata *MetaData) ParseMetaData(nonce uint64, gasPrice *big.Int, gas uin
 	}
 	return addr, nil
 }
+
+// MetaFeeSettlement is the split of the fee paid for a meta transaction between
+// the fee payer, covering FeePercent of it, and the sender paying the rest.
+type MetaFeeSettlement struct {
+	FeePayer       common.Address
+	FeePayerAmount *big.Int
+	SenderAmount   *big.Int
+}
+
+// SplitMetaFee splits an amount between the fee payer covering feePercent of it
+// and the sender, both shares rounded down.
+func SplitMetaFee(amount *big.Int, feePercent uint64) (feePayer *big.Int, sender *big.Int) {
+	feePayer = new(big.Int).Div(new(big.Int).Mul(amount, new(big.Int).SetUint64(feePercent)), BIG10000)
+	sender = new(big.Int).Div(new(big.Int).Mul(amount, new(big.Int).SetUint64(BIG10000.Uint64()-feePercent)), BIG10000)
+	return feePayer, sender
+}
+
+// SettleMetaFee returns the fee shares finally paid for a meta transaction which
+// bought gasLimit gas at gasPrice and used gasUsed of it, the bought gas and the
+// refund of the remaining gas being split separately.
+func SettleMetaFee(feePayer common.Address, feePercent uint64, gasLimit uint64, gasUsed uint64, gasPrice *big.Int) *MetaFeeSettlement {
+	boughtPayer, boughtSender := SplitMetaFee(new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasPrice), feePercent)
+	refundPayer, refundSender := SplitMetaFee(new(big.Int).Mul(new(big.Int).SetUint64(gasLimit-gasUsed), gasPrice), feePercent)
+
+	return &MetaFeeSettlement{
+		FeePayer:       feePayer,
+		FeePayerAmount: boughtPayer.Sub(boughtPayer, refundPayer),
+		SenderAmount:   boughtSender.Sub(boughtSender, refundSender),
+	}
+}
+
+// MetaFeeSettlementOf returns the fee shares paid for an included transaction,
+// or nil if it isn't a meta transaction. The gas price is the one the sender was
+// charged at, the effective one after London.
+func MetaFeeSettlementOf(tx *Transaction, from common.Address, number *big.Int, chainID *big.Int, gasPrice *big.Int, gasUsed uint64) (*MetaFeeSettlement, error) {
+	if !IsMetaTransaction(tx.Data()) {
+		return nil, nil
+	}
+	metaData, err := DecodeMetaData(tx.Data(), number)
+	if err != nil {
+		return nil, err
+	}
+	feePayer, err := metaData.ParseMetaData(tx.Nonce(), gasPrice, tx.Gas(), tx.To(), tx.Value(), metaData.Payload, from, chainID)
+	if err != nil {
+		return nil, err
+	}
+	return SettleMetaFee(feePayer, metaData.FeePercent, tx.Gas(), gasUsed, gasPrice), nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSettleMetaFee(t *testing.T) {
+	payer := common.HexToAddress("0x01")
+	tests := []struct {
+		feePercent, gasLimit, gasUsed, gasPrice uint64
+		payerAmount, senderAmount               int64
+	}{
+		{0, 100000, 21000, 3, 0, 63000},
+		{10000, 100000, 21000, 3, 63000, 0},
+		{2500, 100000, 21000, 3, 15750, 47250},
+		// The bought gas and the refund are rounded down separately
+		{3333, 10, 7, 1, 3, 4},
+	}
+	for i, tt := range tests {
+		fee := SettleMetaFee(payer, tt.feePercent, tt.gasLimit, tt.gasUsed, new(big.Int).SetUint64(tt.gasPrice))
+		if fee.FeePayer != payer {
+			t.Errorf("test %d: fee payer mismatch: have %x, want %x", i, fee.FeePayer, payer)
+		}
+		if fee.FeePayerAmount.Cmp(big.NewInt(tt.payerAmount)) != 0 {
+			t.Errorf("test %d: fee payer amount mismatch: have %v, want %v", i, fee.FeePayerAmount, tt.payerAmount)
+		}
+		if fee.SenderAmount.Cmp(big.NewInt(tt.senderAmount)) != 0 {
+			t.Errorf("test %d: sender amount mismatch: have %v, want %v", i, fee.SenderAmount, tt.senderAmount)
+		}
+	}
+}
+
+func TestMetaFeeSettlementOfPlainTx(t *testing.T) {
+	tx := NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil)
+	fee, err := MetaFeeSettlementOf(tx, common.Address{}, big.NewInt(1), big.NewInt(1), big.NewInt(1), 21000)
+	if err != nil || fee != nil {
+		t.Fatalf("plain transaction settled: %v, %v", fee, err)
+	}
+}
@@ -30,3 +30,11 @@ type StateAccount struct {
 	Root     common.Hash // merkle root of the storage trie
 	CodeHash []byte
 }
+
+// StateGrowth counts the state entries created and deleted by the execution of
+// a block. Slots cleared by self-destructs are not counted.
+type StateGrowth struct {
+	NewAccounts  uint64 // Accounts not in the state before the block
+	NewSlots     uint64 // Storage slots set from zero
+	DeletedSlots uint64 // Storage slots reset to zero
+}
@@ -0,0 +1,34 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import "math/big"
+
+// SupplyDelta is the native token accounting of an executed block, along with
+// the running totals since the first block the node accounted for. The chain has
+// no block rewards, so the supply only changes by the burnt base fees.
+type SupplyDelta struct {
+	Burnt   *big.Int // Base fees burnt by the transactions
+	Fees    *big.Int // Tips and unburnt base fees collected into the fee recorder
+	Rewards *big.Int // Fees distributed from the fee recorder to the validators
+	Pending *big.Int // Fee recorder balance left after the block
+
+	Since        uint64   // First block of the running totals
+	TotalBurnt   *big.Int // Burnt base fees since the first block, inclusive
+	TotalFees    *big.Int // Collected fees since the first block, inclusive
+	TotalRewards *big.Int // Distributed fees since the first block, inclusive
+}
@@ -284,6 +284,11 @@ func (tx *Transaction) Nonce() uint64 { return tx.inner.nonce() }
 
 func (tx *Transaction) LocalSeenTime() time.Time { return tx.time }
 
+// SetLocalSeenTime overrides the time the transaction was first seen locally, e.g.
+// with the one restored from the journal or announced by the peers. It must be
+// called before the transaction is shared with other goroutines.
+func (tx *Transaction) SetLocalSeenTime(t time.Time) { tx.time = t }
+
 // To returns the recipient address of the transaction.
 // For contract-creation transactions, To returns nil.
 func (tx *Transaction) To() *common.Address {
@@ -450,6 +455,7 @@ func (s TxByNonce) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 type TxWithMinerFee struct {
 	tx       *Transaction
 	minerFee *big.Int
+	tie      []byte // Seeded price tie breaker, nil to break ties by the first seen time
 }
 
 // NewTxWithMinerFee creates a wrapped transaction, calculating the effective
@@ -466,6 +472,18 @@ func NewTxWithMinerFee(tx *Transaction, baseFee *big.Int) (*TxWithMinerFee, erro
 	}, nil
 }
 
+// newTxWithMinerFee creates a wrapped transaction, with the price tie breaker
+// derived from the seed if any.
+func newTxWithMinerFee(tx *Transaction, baseFee *big.Int, seed *common.Hash) (*TxWithMinerFee, error) {
+	wrapped, err := NewTxWithMinerFee(tx, baseFee)
+	if err != nil || seed == nil {
+		return wrapped, err
+	}
+	hash := tx.Hash()
+	wrapped.tie = crypto.Keccak256(seed[:], hash[:])
+	return wrapped, nil
+}
+
 // TxByPriceAndTime implements both the sort and the heap interface, making it useful
 // for all at once sorting as well as individually adding and removing elements.
 type TxByPriceAndTime []*TxWithMinerFee
@@ -476,6 +494,9 @@ func (s TxByPriceAndTime) Less(i, j int) bool {
 	// deterministic sorting
 	cmp := s[i].minerFee.Cmp(s[j].minerFee)
 	if cmp == 0 {
+		if s[i].tie != nil && s[j].tie != nil {
+			return bytes.Compare(s[i].tie, s[j].tie) < 0
+		}
 		return s[i].tx.time.Before(s[j].tx.time)
 	}
 	return cmp > 0
@@ -502,6 +523,7 @@ type TransactionsByPriceAndNonce struct {
 	heads   TxByPriceAndTime                // Next transaction for each unique account (price heap)
 	signer  Signer                          // Signer for the set of transactions
 	baseFee *big.Int                        // Current base fee
+	seed    *common.Hash                    // Price tie breaker seed, nil to use the first seen time
 }
 
 // NewTransactionsByPriceAndNonce creates a transaction set that can retrieve
@@ -510,11 +532,24 @@ type TransactionsByPriceAndNonce struct {
 // Note, the input map is reowned so the caller should not interact any more with
 // if after providing it to the constructor.
 func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transactions, baseFee *big.Int) *TransactionsByPriceAndNonce {
+	return newTransactionsByPriceAndNonce(signer, txs, baseFee, nil)
+}
+
+// NewTransactionsByPriceAndSeed creates a transaction set like the one returned by
+// NewTransactionsByPriceAndNonce, but breaking the price ties by the hashes of the
+// transactions mixed with the seed instead of the time they were first seen. The
+// order only depends on the given transactions, making it reproducible across
+// nodes and runs.
+func NewTransactionsByPriceAndSeed(signer Signer, txs map[common.Address]Transactions, baseFee *big.Int, seed common.Hash) *TransactionsByPriceAndNonce {
+	return newTransactionsByPriceAndNonce(signer, txs, baseFee, &seed)
+}
+
+func newTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transactions, baseFee *big.Int, seed *common.Hash) *TransactionsByPriceAndNonce {
 	// Initialize a price and received time based heap with the head transactions
 	heads := make(TxByPriceAndTime, 0, len(txs))
 	for from, accTxs := range txs {
 		acc, _ := Sender(signer, accTxs[0])
-		wrapped, err := NewTxWithMinerFee(accTxs[0], baseFee)
+		wrapped, err := newTxWithMinerFee(accTxs[0], baseFee, seed)
 		// Remove transaction if sender doesn't match from, or if wrapping fails.
 		if acc != from || err != nil {
 			delete(txs, from)
@@ -531,6 +566,7 @@ func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transa
 		heads:   heads,
 		signer:  signer,
 		baseFee: baseFee,
+		seed:    seed,
 	}
 }
 
@@ -546,7 +582,7 @@ func (t *TransactionsByPriceAndNonce) Peek() *Transaction {
 func (t *TransactionsByPriceAndNonce) Shift() {
 	acc, _ := Sender(t.signer, t.heads[0].tx)
 	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
-		if wrapped, err := NewTxWithMinerFee(txs[0], t.baseFee); err == nil {
+		if wrapped, err := newTxWithMinerFee(txs[0], t.baseFee, t.seed); err == nil {
 			t.heads[0], t.txs[acc] = wrapped, txs[1:]
 			heap.Fix(&t.heads, 0)
 			return
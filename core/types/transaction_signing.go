@@ -147,6 +147,13 @@ func Sender(signer Signer, tx *Transaction) (common.Address, error) {
 	return addr, nil
 }
 
+// CacheSender caches the sender of a transaction derived with the given signer from
+// another copy of the same transaction, so that Sender doesn't need to recover it
+// again. The sender must have been derived from a transaction with the same hash.
+func CacheSender(signer Signer, tx *Transaction, from common.Address) {
+	tx.from.Store(sigCache{signer: signer, from: from})
+}
+
 // Signer encapsulates transaction signature handling. The name of this type is slightly
 // misleading because Signers don't actually sign, they're just for validating and
 // processing of signatures.
@@ -42,6 +42,8 @@ type (
 	GetHashFunc func(uint64) common.Hash
 	// CanCreateFunc is the signature of a contract creation guard function
 	CanCreateFunc func(db StateDB, address common.Address, height *big.Int) bool
+	// FeeBurnRatioFunc returns the per mille of the transaction fees burnt
+	FeeBurnRatioFunc func(db StateDB, height *big.Int) uint64
 )
 
 func (evm *EVM) precompile(addr common.Address) (PrecompiledContract, bool) {
@@ -72,6 +74,9 @@ type BlockContext struct {
 	GetHash GetHashFunc
 	// CanCreate returns whether a given address can create a new contract
 	CanCreate CanCreateFunc
+	// FeeBurnRatio returns the per mille of the transaction fees burnt, the chain
+	// config one applying if nil
+	FeeBurnRatio FeeBurnRatioFunc
 	// ExtraValidator do some extra validation to a message during it's execution
 	ExtraValidator types.EvmExtraValidator
 
@@ -127,6 +132,9 @@ type EVM struct {
 	// available gas is calculated in gasCall* according to the 63/64 rule and later
 	// applied in opCall*.
 	callGasTemp uint64
+	// coldAccessGas is the gas charged for the cold state accesses of EIP-2929
+	// on top of the warm access costs, since the last reset.
+	coldAccessGas uint64
 }
 
 // NewEVM returns a new EVM. The returned EVM is not thread safe and should
@@ -149,6 +157,13 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 func (evm *EVM) Reset(txCtx TxContext, statedb StateDB) {
 	evm.TxContext = txCtx
 	evm.StateDB = statedb
+	evm.coldAccessGas = 0
+}
+
+// ColdAccessGas returns the gas charged for the cold state accesses of EIP-2929
+// on top of the warm access costs, since the EVM creation or the last reset.
+func (evm *EVM) ColdAccessGas() uint64 {
+	return evm.coldAccessGas
 }
 
 // Cancel cancels any running EVM operation. This may be called concurrently and
@@ -278,10 +293,12 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 		return nil, gas, ErrDepth
 	}
 
-	// Check whether the involved addresses are denied if needed
+	// Check whether the involved addresses are denied if needed, the target code runs
+	// in the context of the caller, so it's checked as well to close the proxy loophole.
 	if evm.Context.ExtraValidator != nil {
 		if evm.Context.ExtraValidator.IsAddressDenied(caller.Address(), common.CheckFrom) ||
-			evm.Context.ExtraValidator.IsAddressDenied(addr, common.CheckTo) {
+			evm.Context.ExtraValidator.IsAddressDenied(addr, common.CheckTo) ||
+			evm.Context.ExtraValidator.IsCallTargetDenied(caller.Address(), addr) {
 			return nil, gas, types.ErrAddressDenied
 		}
 	}
@@ -338,10 +355,12 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 		return nil, gas, ErrDepth
 	}
 
-	// Check whether the involved addresses are denied if needed
+	// Check whether the involved addresses are denied if needed, the target code runs
+	// in the context of the caller, so it's checked as well to close the proxy loophole.
 	if evm.Context.ExtraValidator != nil {
 		if evm.Context.ExtraValidator.IsAddressDenied(caller.Address(), common.CheckFrom) ||
-			evm.Context.ExtraValidator.IsAddressDenied(addr, common.CheckTo) {
+			evm.Context.ExtraValidator.IsAddressDenied(addr, common.CheckTo) ||
+			evm.Context.ExtraValidator.IsCallTargetDenied(caller.Address(), addr) {
 			return nil, gas, types.ErrAddressDenied
 		}
 	}
@@ -571,6 +590,10 @@ func (evm *EVM) Create(caller ContractRef, code []byte, gas uint64, value *big.I
 // The different between Create2 with Create is Create2 uses sha3(0xff ++ msg.sender ++ salt ++ sha3(init_code))[12:]
 // instead of the usual sender-and-nonce-hash as the address where the contract is initialized at.
 func (evm *EVM) Create2(caller ContractRef, code []byte, gas uint64, endowment *big.Int, salt *uint256.Int) (ret []byte, contractAddr common.Address, leftOverGas uint64, err error) {
+	// Check whether the deployer is denied if needed
+	if evm.Context.ExtraValidator != nil && evm.Context.ExtraValidator.IsDeployerDenied(caller.Address()) {
+		return nil, common.Address{}, gas, types.ErrAddressDenied
+	}
 	codeAndHash := &codeAndHash{code: code}
 	contractAddr = crypto.CreateAddress2(caller.Address(), salt.Bytes32(), codeAndHash.Hash().Bytes())
 	return evm.create(caller, codeAndHash, gas, endowment, contractAddr, CREATE2)
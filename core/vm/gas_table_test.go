@@ -105,3 +105,33 @@ func TestEIP2200(t *testing.T) {
 		}
 	}
 }
+
+func TestColdAccessGas(t *testing.T) {
+	address := common.BytesToAddress([]byte("contract"))
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.CreateAccount(address)
+	// SLOAD(0), SLOAD(0), BALANCE(0xff), BALANCE(0xff), SSTORE(1, 1)
+	statedb.SetCode(address, hexutil.MustDecode("0x600054506000545060ff315060ff31506001600155"))
+	statedb.AddAddressToAccessList(address)
+
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: new(big.Int),
+	}
+	vmenv := NewEVM(vmctx, TxContext{}, statedb, params.AllEthashProtocolChanges, Config{})
+	if _, _, err := vmenv.Call(AccountRef(common.Address{}), address, nil, math.MaxUint64, new(big.Int)); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	want := (params.ColdSloadCostEIP2929 - params.WarmStorageReadCostEIP2929) +
+		(params.ColdAccountAccessCostEIP2929 - params.WarmStorageReadCostEIP2929) +
+		params.ColdSloadCostEIP2929
+	if have := vmenv.ColdAccessGas(); have != want {
+		t.Errorf("cold access gas mismatch: have %d, want %d", have, want)
+	}
+	vmenv.Reset(TxContext{}, statedb)
+	if have := vmenv.ColdAccessGas(); have != 0 {
+		t.Errorf("cold access gas not reset: have %d", have)
+	}
+}
@@ -19,6 +19,7 @@ package vm
 import (
 	"hash"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
@@ -32,6 +33,7 @@ type Config struct {
 	NoRecursion             bool      // Disables call, callcode, delegate call and create
 	NoBaseFee               bool      // Forces the EIP-1559 baseFee to 0 (needed for 0 price calls)
 	EnablePreimageRecording bool      // Enables recording of SHA3/keccak preimages
+	Profiler                *Profiler // Aggregates the gas and time per opcode and contract if set
 
 	JumpTable [256]*operation // EVM instruction table, automatically populated if unset
 
@@ -74,6 +76,8 @@ func NewEVMInterpreter(evm *EVM, cfg Config) *EVMInterpreter {
 	if cfg.JumpTable[STOP] == nil {
 		var jt JumpTable
 		switch {
+		case evm.chainRules.IsTaurus:
+			jt = taurusInstructionSet
 		case evm.chainRules.IsLondon:
 			jt = londonInstructionSet
 		case evm.chainRules.IsBerlin:
@@ -165,6 +169,14 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	}()
 	contract.Input = input
 
+	profiler := in.cfg.Profiler
+	var opStart time.Time // start of the opcode execution, for profiling
+	if profiler != nil {
+		start, gas := time.Now(), contract.Gas
+		defer func() {
+			profiler.captureContract(contract.Address(), gas-contract.Gas, time.Since(start))
+		}()
+	}
 	if in.cfg.Debug {
 		defer func() {
 			if err != nil {
@@ -258,7 +270,13 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		}
 
 		// execute the operation
+		if profiler != nil {
+			opStart = time.Now()
+		}
 		res, err = operation.execute(&pc, in, callContext)
+		if profiler != nil {
+			profiler.captureOp(op, cost, time.Since(opStart))
+		}
 		// if the operation clears the return data (e.g. it has returning data)
 		// set the last return to the result of the operation.
 		if operation.returns {
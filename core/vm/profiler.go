@@ -0,0 +1,188 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Profiler aggregates the gas and the execution time spent per opcode and per
+// contract address over a sampling window, to find the contracts responsible for
+// slow block executions. The figures of the call and create opcodes, as well as
+// the ones of the contracts, include the nested calls. It is safe for concurrent
+// use.
+type Profiler struct {
+	window time.Duration
+
+	current  *profile // Profile of the ongoing window
+	previous *profile // Profile of the last complete window, nil if none yet
+	lock     sync.Mutex
+}
+
+// profile is the aggregation of a single sampling window.
+type profile struct {
+	start     time.Time
+	ops       [256]opStats
+	contracts map[common.Address]*contractStats
+}
+
+type opStats struct {
+	count uint64
+	gas   uint64
+	time  time.Duration
+}
+
+type contractStats struct {
+	calls uint64
+	gas   uint64
+	time  time.Duration
+}
+
+// NewProfiler creates a profiler aggregating over the given sampling window.
+func NewProfiler(window time.Duration) *Profiler {
+	return &Profiler{
+		window:  window,
+		current: newProfile(time.Now()),
+	}
+}
+
+func newProfile(start time.Time) *profile {
+	return &profile{start: start, contracts: make(map[common.Address]*contractStats)}
+}
+
+// rotate starts a new window if the current one is over. The lock is assumed held.
+func (p *Profiler) rotate(now time.Time) {
+	if now.Sub(p.current.start) < p.window {
+		return
+	}
+	p.previous, p.current = p.current, newProfile(now)
+}
+
+// captureOp accounts an executed opcode with its charged gas.
+func (p *Profiler) captureOp(op OpCode, gas uint64, elapsed time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.rotate(time.Now())
+	stats := &p.current.ops[op]
+	stats.count++
+	stats.gas += gas
+	stats.time += elapsed
+}
+
+// captureContract accounts a finished execution of a contract's code.
+func (p *Profiler) captureContract(addr common.Address, gas uint64, elapsed time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.rotate(time.Now())
+	stats := p.current.contracts[addr]
+	if stats == nil {
+		stats = new(contractStats)
+		p.current.contracts[addr] = stats
+	}
+	stats.calls++
+	stats.gas += gas
+	stats.time += elapsed
+}
+
+// OpcodeProfile is the aggregation of an opcode over a sampling window.
+type OpcodeProfile struct {
+	Op    string        `json:"op"`
+	Count uint64        `json:"count"`
+	Gas   uint64        `json:"gas"`
+	Time  time.Duration `json:"time"` // Execution time in nanoseconds
+}
+
+// ContractProfile is the aggregation of a contract over a sampling window.
+type ContractProfile struct {
+	Address common.Address `json:"address"`
+	Calls   uint64         `json:"calls"`
+	Gas     uint64         `json:"gas"`
+	Time    time.Duration  `json:"time"` // Execution time in nanoseconds
+}
+
+// ProfileReport is the aggregation of a sampling window, ordered by the time spent.
+type ProfileReport struct {
+	Start     time.Time         `json:"start"`
+	End       time.Time         `json:"end"`
+	Opcodes   []OpcodeProfile   `json:"opcodes"`
+	Contracts []ContractProfile `json:"contracts"`
+}
+
+// Report returns the aggregation of the ongoing window and of the last complete
+// one, nil if none yet, listing the top contracts by the time spent.
+func (p *Profiler) Report(top int) (current *ProfileReport, previous *ProfileReport) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := time.Now()
+	p.rotate(now)
+
+	current = p.current.report(now, top)
+	if p.previous != nil {
+		previous = p.previous.report(p.current.start, top)
+	}
+	return current, previous
+}
+
+// report flattens the window aggregation ended at the given time.
+func (pr *profile) report(end time.Time, top int) *ProfileReport {
+	report := &ProfileReport{
+		Start:     pr.start,
+		End:       end,
+		Opcodes:   []OpcodeProfile{},
+		Contracts: make([]ContractProfile, 0, len(pr.contracts)),
+	}
+	for op, stats := range pr.ops {
+		if stats.count == 0 {
+			continue
+		}
+		report.Opcodes = append(report.Opcodes, OpcodeProfile{
+			Op:    OpCode(op).String(),
+			Count: stats.count,
+			Gas:   stats.gas,
+			Time:  stats.time,
+		})
+	}
+	sort.SliceStable(report.Opcodes, func(i, j int) bool {
+		return report.Opcodes[i].Time > report.Opcodes[j].Time
+	})
+	for addr, stats := range pr.contracts {
+		report.Contracts = append(report.Contracts, ContractProfile{
+			Address: addr,
+			Calls:   stats.calls,
+			Gas:     stats.gas,
+			Time:    stats.time,
+		})
+	}
+	sort.Slice(report.Contracts, func(i, j int) bool {
+		if report.Contracts[i].Time != report.Contracts[j].Time {
+			return report.Contracts[i].Time > report.Contracts[j].Time
+		}
+		return bytes.Compare(report.Contracts[i].Address[:], report.Contracts[j].Address[:]) < 0
+	})
+	if top > 0 && len(report.Contracts) > top {
+		report.Contracts = report.Contracts[:top]
+	}
+	return report
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestProfiler(t *testing.T) {
+	address := common.BytesToAddress([]byte("contract"))
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.CreateAccount(address)
+	statedb.SetCode(address, hexutil.MustDecode("0x6001600055")) // PUSH1 1, PUSH1 0, SSTORE, implicit STOP
+
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+	}
+	profiler := NewProfiler(time.Hour)
+	vmenv := NewEVM(vmctx, TxContext{}, statedb, params.AllEthashProtocolChanges, Config{Profiler: profiler})
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := vmenv.Call(AccountRef(common.Address{}), address, nil, 100000, new(big.Int)); err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+	current, previous := profiler.Report(0)
+	if previous != nil {
+		t.Fatalf("previous window reported before the first one is over")
+	}
+	counts := make(map[string]uint64)
+	var gas uint64
+	for _, op := range current.Opcodes {
+		counts[op.Op] = op.Count
+		gas += op.Gas
+	}
+	if len(counts) != 3 || counts["PUSH1"] != 4 || counts["SSTORE"] != 2 || counts["STOP"] != 2 {
+		t.Fatalf("opcode counts mismatch: have %v", counts)
+	}
+	if len(current.Contracts) != 1 {
+		t.Fatalf("contract count mismatch: have %d, want 1", len(current.Contracts))
+	}
+	if have := current.Contracts[0]; have.Address != address || have.Calls != 2 || have.Gas != gas {
+		t.Fatalf("contract profile mismatch: have %+v, want %d gas", have, gas)
+	}
+
+	// Once the window is over, the profile moves to the previous window
+	profiler.lock.Lock()
+	profiler.current.start = profiler.current.start.Add(-time.Hour)
+	profiler.lock.Unlock()
+
+	current, previous = profiler.Report(0)
+	if previous == nil || len(previous.Contracts) != 1 {
+		t.Fatalf("previous window not reported: %+v", previous)
+	}
+	if len(current.Opcodes) != 0 || len(current.Contracts) != 0 {
+		t.Fatalf("new window not empty: %+v", current)
+	}
+}
+
+func TestProfilerTop(t *testing.T) {
+	profiler := NewProfiler(time.Hour)
+	for i := 1; i <= 5; i++ {
+		profiler.captureContract(common.BytesToAddress([]byte{byte(i)}), 1, time.Duration(i))
+	}
+	current, _ := profiler.Report(2)
+	if len(current.Contracts) != 2 {
+		t.Fatalf("top contracts mismatch: have %d, want 2", len(current.Contracts))
+	}
+	for i, want := range []byte{5, 4} {
+		if have := current.Contracts[i].Address; have != common.BytesToAddress([]byte{want}) {
+			t.Errorf("contract %d mismatch: have %x, want %x", i, have, want)
+		}
+	}
+}
@@ -105,6 +105,35 @@ func TestExecute(t *testing.T) {
 	}
 }
 
+// TestTaurusOpcodes checks the PUSH0 and transient storage opcodes are only
+// available once the Taurus fork is active.
+func TestTaurusOpcodes(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 42,
+		byte(vm.PUSH0),
+		byte(vm.TSTORE),
+		byte(vm.PUSH0),
+		byte(vm.TLOAD),
+		byte(vm.PUSH0),
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH0),
+		byte(vm.RETURN),
+	}
+	if _, _, err := Execute(code, nil, nil); err == nil {
+		t.Fatal("expected an invalid opcode error before taurus")
+	}
+	config := *params.AllEthashProtocolChanges
+	config.TaurusBlock = new(big.Int)
+	ret, _, err := Execute(code, nil, &Config{ChainConfig: &config})
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if num := new(big.Int).SetBytes(ret); num.Cmp(big.NewInt(42)) != 0 {
+		t.Error("Expected 42, got", num)
+	}
+}
+
 func TestCall(t *testing.T) {
 	state, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
 	address := common.HexToAddress("0x0a")
@@ -25,15 +25,18 @@ import (
 	"math/big"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -198,6 +201,18 @@ func (api *PrivateAdminAPI) ExportChain(file string, first *uint64, last *uint64
 	return true, nil
 }
 
+// ExportTxPool exports the pending and queued transactions of the pool along with
+// their metadata, for another node of the same trust domain to warm up its pool
+// with ImportTxPool instead of waiting for the transaction gossip.
+func (api *PrivateAdminAPI) ExportTxPool() ([]*core.PoolEntry, error) {
+	return api.eth.TxPool().Export()
+}
+
+// ImportTxPool adds the pool entries exported by another node to the pool.
+func (api *PrivateAdminAPI) ImportTxPool(entries []*core.PoolEntry) (*core.PoolImportResult, error) {
+	return api.eth.TxPool().Import(entries)
+}
+
 func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	for _, b := range bs {
 		if !chain.HasBlock(b.Hash(), b.NumberU64()) {
@@ -257,6 +272,56 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// ForkPeerGroup is a group of peers advertising the same fork ID.
+type ForkPeerGroup struct {
+	Hash     hexutil.Bytes `json:"hash"`     // Fork checksum of the peers
+	Next     uint64        `json:"next"`     // Next fork block scheduled by the peers, 0 if none
+	Peers    int           `json:"peers"`    // Number of peers in the group
+	Upgraded bool          `json:"upgraded"` // Whether the peers schedule the same next fork as the local node
+}
+
+// ForkPeersResult is the summary of the connected peers by fork ID.
+type ForkPeersResult struct {
+	Head   uint64          `json:"head"`   // Local head block number
+	Hash   hexutil.Bytes   `json:"hash"`   // Local fork checksum
+	Next   uint64          `json:"next"`   // Next fork block scheduled locally, 0 if none
+	Peers  int             `json:"peers"`  // Total number of peers
+	Ready  int             `json:"ready"`  // Number of peers scheduling the same next fork
+	Groups []ForkPeerGroup `json:"groups"` // Peers grouped by fork ID, largest group first
+}
+
+// ForkPeers summarizes the connected peers by the fork ID they advertised, so
+// operators can see how much of the network has upgraded before a fork block.
+func (api *PrivateAdminAPI) ForkPeers() *ForkPeersResult {
+	var (
+		head  = api.eth.blockchain.CurrentHeader().Number.Uint64()
+		local = forkid.NewIDWithChain(api.eth.blockchain)
+	)
+	res := &ForkPeersResult{
+		Head:   head,
+		Hash:   local.Hash[:],
+		Next:   local.Next,
+		Groups: []ForkPeerGroup{},
+	}
+	for id, peers := range api.eth.handler.peers.forkIDs() {
+		upgraded := id == local
+		res.Groups = append(res.Groups, ForkPeerGroup{
+			Hash:     common.CopyBytes(id.Hash[:]),
+			Next:     id.Next,
+			Peers:    peers,
+			Upgraded: upgraded,
+		})
+		res.Peers += peers
+		if upgraded {
+			res.Ready += peers
+		}
+	}
+	sort.Slice(res.Groups, func(i, j int) bool {
+		return res.Groups[i].Peers > res.Groups[j].Peers
+	})
+	return res
+}
+
 // PublicDebugAPI is the collection of Ethereum full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -298,6 +363,14 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 	return stateDb.RawDump(opts), nil
 }
 
+// TxTrace returns the history of a transaction submitted via RPC to this node,
+// from its submission to the pool up to its inclusion, along with the correlation
+// ID tagging the related debug log lines. Nil is returned for the transactions not
+// submitted via RPC, or not traced anymore.
+func (api *PublicDebugAPI) TxTrace(hash common.Hash) *core.TxTrace {
+	return api.eth.txPool.TxTrace(hash)
+}
+
 // PrivateDebugAPI is the collection of Ethereum full node APIs exposed over
 // the private debugging endpoint.
 type PrivateDebugAPI struct {
@@ -355,48 +428,65 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs,
 	return results, nil
 }
 
+// LastBadBlockReport returns the classified report of the last bad block hit since
+// the node started, with the mismatching values and the locally derived receipts,
+// nil if there was none.
+func (api *PrivateDebugAPI) LastBadBlockReport() *core.BadBlockReport {
+	return api.eth.blockchain.LastBadBlockReport()
+}
+
 // AccountRangeMaxResults is the maximum number of results to be returned per call
 const AccountRangeMaxResults = 256
 
-// AccountRange enumerates all accounts in the given block and start point in paging request
-func (api *PublicDebugAPI) AccountRange(blockNrOrHash rpc.BlockNumberOrHash, start []byte, maxResults int, nocode, nostorage, incompletes bool) (state.IteratorDump, error) {
-	var stateDb *state.StateDB
-	var err error
+// StorageRangeMaxResults is the maximum number of storage slots to be returned per
+// call of debug_storageRange.
+const StorageRangeMaxResults = 1024
 
+// stateAtNumberOrHash returns the state of the block, the pending state is served
+// by the miner.
+func (eth *Ethereum) stateAtNumberOrHash(blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, error) {
 	if number, ok := blockNrOrHash.Number(); ok {
 		if number == rpc.PendingBlockNumber {
 			// If we're dumping the pending state, we need to request
 			// both the pending block as well as the pending state from
 			// the miner and operate on those
-			_, stateDb = api.eth.miner.Pending()
+			_, stateDb := eth.miner.Pending()
+			return stateDb, nil
+		}
+		var block *types.Block
+		if number == rpc.LatestBlockNumber {
+			block = eth.blockchain.CurrentBlock()
 		} else {
-			var block *types.Block
-			if number == rpc.LatestBlockNumber {
-				block = api.eth.blockchain.CurrentBlock()
-			} else {
-				block = api.eth.blockchain.GetBlockByNumber(uint64(number))
-			}
-			if block == nil {
-				return state.IteratorDump{}, fmt.Errorf("block #%d not found", number)
-			}
-			stateDb, err = api.eth.BlockChain().StateAt(block.Root())
-			if err != nil {
-				return state.IteratorDump{}, err
-			}
+			block = eth.blockchain.GetBlockByNumber(uint64(number))
 		}
-	} else if hash, ok := blockNrOrHash.Hash(); ok {
-		block := api.eth.blockchain.GetBlockByHash(hash)
 		if block == nil {
-			return state.IteratorDump{}, fmt.Errorf("block %s not found", hash.Hex())
+			return nil, fmt.Errorf("block #%d not found", number)
 		}
-		stateDb, err = api.eth.BlockChain().StateAt(block.Root())
-		if err != nil {
-			return state.IteratorDump{}, err
+		return eth.BlockChain().StateAt(block.Root())
+	} else if hash, ok := blockNrOrHash.Hash(); ok {
+		block := eth.blockchain.GetBlockByHash(hash)
+		if block == nil {
+			return nil, fmt.Errorf("block %s not found", hash.Hex())
 		}
-	} else {
-		return state.IteratorDump{}, errors.New("either block number or block hash must be specified")
+		return eth.BlockChain().StateAt(block.Root())
 	}
+	return nil, errors.New("either block number or block hash must be specified")
+}
 
+// AccountRange enumerates all accounts in the given block and start point in paging request.
+// The accounts are read from the state snapshot if there's one of the block, falling back
+// to the much slower state trie otherwise.
+func (api *PublicDebugAPI) AccountRange(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, start []byte, maxResults int, nocode, nostorage, incompletes bool) (state.IteratorDump, error) {
+	release, err := api.eth.APIBackend.HeavyCallLimiter().Acquire(ctx)
+	if err != nil {
+		return state.IteratorDump{}, err
+	}
+	defer release()
+
+	stateDb, err := api.eth.stateAtNumberOrHash(blockNrOrHash)
+	if err != nil {
+		return state.IteratorDump{}, err
+	}
 	opts := &state.DumpConfig{
 		SkipCode:          nocode,
 		SkipStorage:       nostorage,
@@ -407,6 +497,9 @@ func (api *PublicDebugAPI) AccountRange(blockNrOrHash rpc.BlockNumberOrHash, sta
 	if maxResults > AccountRangeMaxResults || maxResults <= 0 {
 		opts.Max = AccountRangeMaxResults
 	}
+	if dump, err := stateDb.SnapshotIteratorDump(opts); err == nil {
+		return dump, nil
+	}
 	return stateDb.IteratorDump(opts), nil
 }
 
@@ -424,7 +517,13 @@ type storageEntry struct {
 }
 
 // StorageRangeAt returns the storage at the given block height and transaction index.
-func (api *PrivateDebugAPI) StorageRangeAt(blockHash common.Hash, txIndex int, contractAddress common.Address, keyStart hexutil.Bytes, maxResult int) (StorageRangeResult, error) {
+func (api *PrivateDebugAPI) StorageRangeAt(ctx context.Context, blockHash common.Hash, txIndex int, contractAddress common.Address, keyStart hexutil.Bytes, maxResult int) (StorageRangeResult, error) {
+	release, err := api.eth.APIBackend.HeavyCallLimiter().Acquire(ctx)
+	if err != nil {
+		return StorageRangeResult{}, err
+	}
+	defer release()
+
 	// Retrieve the block
 	block := api.eth.blockchain.GetBlockByHash(blockHash)
 	if block == nil {
@@ -441,6 +540,45 @@ func (api *PrivateDebugAPI) StorageRangeAt(blockHash common.Hash, txIndex int, c
 	return storageRangeAt(st, keyStart, maxResult)
 }
 
+// StorageRange returns the storage of the contract at the end of the given block,
+// paginated like StorageRangeAt. The storage is read from the state snapshot if
+// there's one of the block, falling back to the much slower state trie otherwise.
+func (api *PrivateDebugAPI) StorageRange(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, contractAddress common.Address, keyStart hexutil.Bytes, maxResult int) (StorageRangeResult, error) {
+	release, err := api.eth.APIBackend.HeavyCallLimiter().Acquire(ctx)
+	if err != nil {
+		return StorageRangeResult{}, err
+	}
+	defer release()
+
+	statedb, err := api.eth.stateAtNumberOrHash(blockNrOrHash)
+	if err != nil {
+		return StorageRangeResult{}, err
+	}
+	if maxResult > StorageRangeMaxResults || maxResult <= 0 {
+		maxResult = StorageRangeMaxResults
+	}
+	if !statedb.Exist(contractAddress) {
+		return StorageRangeResult{}, fmt.Errorf("account %x doesn't exist", contractAddress)
+	}
+	if slots, next, err := statedb.SnapshotStorageRange(contractAddress, keyStart, maxResult); err == nil {
+		result := StorageRangeResult{Storage: storageMap{}, NextKey: next}
+		for _, slot := range slots {
+			e := storageEntry{Value: slot.Value}
+			if slot.Key != nil {
+				key := common.BytesToHash(slot.Key)
+				e.Key = &key
+			}
+			result.Storage[slot.Hash] = e
+		}
+		return result, nil
+	}
+	st := statedb.StorageTrie(contractAddress)
+	if st == nil {
+		return StorageRangeResult{}, fmt.Errorf("account %x doesn't exist", contractAddress)
+	}
+	return storageRangeAt(st, keyStart, maxResult)
+}
+
 func storageRangeAt(st state.Trie, start []byte, maxResult int) (StorageRangeResult, error) {
 	it := trie.NewIterator(st.NodeIterator(start))
 	result := StorageRangeResult{Storage: storageMap{}}
@@ -464,6 +602,52 @@ func storageRangeAt(st state.Trie, start []byte, maxResult int) (StorageRangeRes
 	return result, nil
 }
 
+// StorageDiffMaxResults is the maximum number of storage slots to be returned per
+// call of debug_storageDiff.
+const StorageDiffMaxResults = 4096
+
+// StorageDiffResult is the result of a debug_storageDiff API call.
+type StorageDiffResult struct {
+	FromCodeHash common.Hash             `json:"fromCodeHash"`
+	ToCodeHash   common.Hash             `json:"toCodeHash"`
+	Slots        []state.StorageSlotDiff `json:"slots"`
+	Complete     bool                    `json:"complete"` // false if the slots were capped at StorageDiffMaxResults
+}
+
+// StorageDiff returns the storage slots of the contract changed between the ends of
+// the two blocks, with their values in both, along with the code hashes of the
+// contract in both. Only the trie nodes differing between the two storage tries
+// are visited, e.g. to audit what a governance upgrade changed on chain.
+func (api *PrivateDebugAPI) StorageDiff(ctx context.Context, contractAddress common.Address, blockA, blockB rpc.BlockNumberOrHash) (*StorageDiffResult, error) {
+	release, err := api.eth.APIBackend.HeavyCallLimiter().Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	from, err := api.eth.stateAtNumberOrHash(blockA)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.eth.stateAtNumberOrHash(blockB)
+	if err != nil {
+		return nil, err
+	}
+	slots, complete, err := state.DiffStorage(from, to, contractAddress, StorageDiffMaxResults)
+	if err != nil {
+		return nil, err
+	}
+	if slots == nil {
+		slots = []state.StorageSlotDiff{}
+	}
+	return &StorageDiffResult{
+		FromCodeHash: from.GetCodeHash(contractAddress),
+		ToCodeHash:   to.GetCodeHash(contractAddress),
+		Slots:        slots,
+		Complete:     complete,
+	}, nil
+}
+
 // GetModifiedAccountsByNumber returns all accounts that have changed between the
 // two blocks specified. A change is defined as a difference in nonce, balance,
 // code hash, or storage hash.
@@ -607,3 +791,93 @@ func (api *PrivateDebugAPI) GetAccessibleState(from, to rpc.BlockNumber) (uint64
 	}
 	return 0, fmt.Errorf("No state found")
 }
+
+// EVMProfileResult is the EVM profile of the ongoing sampling window and of the
+// last complete one.
+type EVMProfileResult struct {
+	Current  *vm.ProfileReport `json:"current"`
+	Previous *vm.ProfileReport `json:"previous"`
+}
+
+// EvmProfile returns the gas and the time spent per opcode and per contract by
+// the block executions, over the sampling window configured by --vm.profile,
+// listing the top contracts by the time spent (20 if unset, 0 for all).
+func (api *PrivateDebugAPI) EvmProfile(top *int) (*EVMProfileResult, error) {
+	profiler := api.eth.blockchain.GetVMConfig().Profiler
+	if profiler == nil {
+		return nil, errors.New("EVM profiling disabled, enable it with --vm.profile")
+	}
+	limit := 20
+	if top != nil {
+		limit = *top
+	}
+	current, previous := profiler.Report(limit)
+	return &EVMProfileResult{Current: current, Previous: previous}, nil
+}
+
+// maxStateGrowthRange is the max number of blocks debug_stateGrowth reports on.
+const maxStateGrowthRange = 10000
+
+// BlockStateGrowth is the state growth of a single block.
+type BlockStateGrowth struct {
+	Number       hexutil.Uint64 `json:"number"`
+	NewAccounts  hexutil.Uint64 `json:"newAccounts"`
+	NewSlots     hexutil.Uint64 `json:"newSlots"`
+	DeletedSlots hexutil.Uint64 `json:"deletedSlots"`
+}
+
+// StateGrowthResult is the state growth of a range of canonical blocks, the blocks
+// not executed by the node, e.g. fast synced ones, being left out.
+type StateGrowthResult struct {
+	From         hexutil.Uint64      `json:"from"`
+	To           hexutil.Uint64      `json:"to"`
+	NewAccounts  hexutil.Uint64      `json:"newAccounts"`
+	NewSlots     hexutil.Uint64      `json:"newSlots"`
+	DeletedSlots hexutil.Uint64      `json:"deletedSlots"`
+	Blocks       []*BlockStateGrowth `json:"blocks"`
+}
+
+// StateGrowth returns the accounts and storage slots created and the slots deleted
+// by the canonical blocks in the given inclusive range, along with the totals.
+func (api *PrivateDebugAPI) StateGrowth(from, to rpc.BlockNumber) (*StateGrowthResult, error) {
+	head := api.eth.blockchain.CurrentBlock().NumberU64()
+	resolveNum := func(num rpc.BlockNumber) uint64 {
+		if num.Int64() < 0 || uint64(num.Int64()) > head {
+			return head
+		}
+		return uint64(num.Int64())
+	}
+	start, end := resolveNum(from), resolveNum(to)
+	if start > end {
+		return nil, fmt.Errorf("invalid range: from %d after to %d", start, end)
+	}
+	if end-start >= maxStateGrowthRange {
+		return nil, fmt.Errorf("range of %d blocks exceeds the limit of %d", end-start+1, maxStateGrowthRange)
+	}
+	result := &StateGrowthResult{
+		From:   hexutil.Uint64(start),
+		To:     hexutil.Uint64(end),
+		Blocks: make([]*BlockStateGrowth, 0),
+	}
+	db := api.eth.ChainDb()
+	for number := start; number <= end; number++ {
+		hash := rawdb.ReadCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			continue
+		}
+		growth := rawdb.ReadStateGrowth(db, hash, number)
+		if growth == nil {
+			continue
+		}
+		result.Blocks = append(result.Blocks, &BlockStateGrowth{
+			Number:       hexutil.Uint64(number),
+			NewAccounts:  hexutil.Uint64(growth.NewAccounts),
+			NewSlots:     hexutil.Uint64(growth.NewSlots),
+			DeletedSlots: hexutil.Uint64(growth.DeletedSlots),
+		})
+		result.NewAccounts += hexutil.Uint64(growth.NewAccounts)
+		result.NewSlots += hexutil.Uint64(growth.NewSlots)
+		result.DeletedSlots += hexutil.Uint64(growth.DeletedSlots)
+	}
+	return result, nil
+}
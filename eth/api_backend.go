@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -47,6 +48,8 @@ type EthAPIBackend struct {
 	eth                 *Ethereum
 	gpo                 *gasprice.Oracle
 	gpp                 *gasprice.Prediction
+	heavyCalls          *ethapi.HeavyCallLimiter
+	responses           *ethapi.ResponseCache
 }
 
 // ChainConfig returns the active chain configuration.
@@ -153,10 +156,25 @@ func (b *EthAPIBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.B
 	if header == nil {
 		return nil, nil, errors.New("header not found")
 	}
-	stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+	stateDb, err := b.stateAt(header)
 	return stateDb, header, err
 }
 
+// stateAt returns the state of the given header, served from the version cache
+// of the recent states if enabled. If it was already garbage collected on a pruned
+// node, it falls back to the proof witness store, which retains the trie nodes of
+// the recent blocks (e.g. for serving eth_getProof).
+func (b *EthAPIBackend) stateAt(header *types.Header) (*state.StateDB, error) {
+	stateDb, err := b.eth.BlockChain().VersionedStateAt(header)
+	if err == nil {
+		return stateDb, nil
+	}
+	if witnessDb, werr := b.eth.BlockChain().WitnessStateAt(header); werr == nil {
+		return witnessDb, nil
+	}
+	return nil, err
+}
+
 func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
 	if blockNr, ok := blockNrOrHash.Number(); ok {
 		return b.StateAndHeaderByNumber(ctx, blockNr)
@@ -172,7 +190,7 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 		if blockNrOrHash.RequireCanonical && b.eth.blockchain.GetCanonicalHash(header.Number.Uint64()) != hash {
 			return nil, nil, errors.New("hash is not currently canonical")
 		}
-		stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+		stateDb, err := b.stateAt(header)
 		return stateDb, header, err
 	}
 	return nil, nil, errors.New("invalid arguments; neither block nor hash specified")
@@ -205,7 +223,10 @@ func (b *EthAPIBackend) GetTd(ctx context.Context, hash common.Hash) *big.Int {
 func (b *EthAPIBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
 	vmError := func() error { return nil }
 	if vmConfig == nil {
-		vmConfig = b.eth.blockchain.GetVMConfig()
+		// Calls from the RPC are left out of the block execution profile
+		config := *b.eth.blockchain.GetVMConfig()
+		config.Profiler = nil
+		vmConfig = &config
 	}
 	txContext := core.NewEVMTxContext(msg)
 	context := core.NewEVMBlockContext(header, b.eth.BlockChain(), nil)
@@ -219,7 +240,9 @@ func (b *EthAPIBackend) GetEVM(ctx context.Context, msg core.Message, state *sta
 		if err != nil {
 			return nil, vmError, err
 		}
-		context.ExtraValidator = b.eth.posa.CreateEvmExtraValidator(header, parentState)
+		if context.ExtraValidator, err = b.eth.posa.CreateEvmExtraValidator(header, parentState); err != nil {
+			return nil, vmError, err
+		}
 	}
 	return vm.NewEVM(context, txContext, state, b.eth.blockchain.Config(), *vmConfig), vmError, nil
 }
@@ -228,6 +251,10 @@ func (b *EthAPIBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEven
 	return b.eth.BlockChain().SubscribeRemovedLogsEvent(ch)
 }
 
+func (b *EthAPIBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return b.eth.BlockChain().SubscribeReorgEvent(ch)
+}
+
 func (b *EthAPIBackend) SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription {
 	return b.eth.miner.SubscribePendingLogs(ch)
 }
@@ -249,9 +276,19 @@ func (b *EthAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 }
 
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	if b.eth.config.TxPrivacy {
+		b.eth.txPool.TraceTx(signedTx.Hash(), "rpc private")
+		return b.eth.SendPrivateTransaction(signedTx)
+	}
+	b.eth.txPool.TraceTx(signedTx.Hash(), "rpc")
 	return b.eth.txPool.AddLocal(signedTx)
 }
 
+func (b *EthAPIBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction) error {
+	b.eth.txPool.TraceTx(signedTx.Hash(), "rpc private")
+	return b.eth.SendPrivateTransaction(signedTx)
+}
+
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
 	pending := b.eth.txPool.Pending(false)
 	var txs types.Transactions
@@ -290,6 +327,14 @@ func (b *EthAPIBackend) JamIndex() int {
 	return b.eth.TxPool().JamIndex()
 }
 
+func (b *EthAPIBackend) ResubmitManaged() []*core.ResubmitTxInfo {
+	return b.eth.TxPool().ResubmitManaged()
+}
+
+func (b *EthAPIBackend) TxPoolNonceGaps(addr common.Address) *core.NonceGaps {
+	return b.eth.TxPool().NonceGaps(addr)
+}
+
 func (b *EthAPIBackend) TxPool() *core.TxPool {
 	return b.eth.TxPool()
 }
@@ -314,6 +359,10 @@ func (b *EthAPIBackend) PricePrediction(ctx context.Context) ([]uint, error) {
 	return b.gpp.CurrentPrices(), nil
 }
 
+func (b *EthAPIBackend) SubscribeGasPricesEvent(ch chan<- core.GasPricesEvent) event.Subscription {
+	return b.gpp.SubscribeGasPricesEvent(ch)
+}
+
 func (b *EthAPIBackend) ChainDb() ethdb.Database {
 	return b.eth.ChainDb()
 }
@@ -342,6 +391,41 @@ func (b *EthAPIBackend) RPCEVMTimeout() time.Duration {
 	return b.eth.config.RPCEVMTimeout
 }
 
+func (b *EthAPIBackend) RPCEstimateGasCap() uint64 {
+	if b.eth.config.RPCEstimateGasCap != 0 {
+		return b.eth.config.RPCEstimateGasCap
+	}
+	return b.eth.config.RPCGasCap
+}
+
+func (b *EthAPIBackend) RPCEstimateEVMTimeout() time.Duration {
+	return b.eth.config.RPCEstimateEVMTimeout
+}
+
+func (b *EthAPIBackend) RPCTraceGasCap() uint64 {
+	if b.eth.config.RPCTraceGasCap != 0 {
+		return b.eth.config.RPCTraceGasCap
+	}
+	return b.eth.config.RPCGasCap
+}
+
+func (b *EthAPIBackend) RPCTraceEVMTimeout() time.Duration {
+	return b.eth.config.RPCTraceEVMTimeout
+}
+
+func (b *EthAPIBackend) HeavyCallLimiter() *ethapi.HeavyCallLimiter {
+	return b.heavyCalls
+}
+
+func (b *EthAPIBackend) ResponseCache() *ethapi.ResponseCache {
+	return b.responses
+}
+
+// Governor returns the throttling of the heavy queries while the block import lags.
+func (b *EthAPIBackend) Governor() *core.Governor {
+	return b.eth.blockchain.Governor()
+}
+
 func (b *EthAPIBackend) RPCTxFeeCap() float64 {
 	return b.eth.config.RPCTxFeeCap
 }
@@ -0,0 +1,183 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"runtime"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// proposalVoteGas is the gas limit of the proposal votes, well above the cost
+// of the vote which also passes the proposal.
+const proposalVoteGas = 500000
+
+var errVanityTooLong = errors.New("vanity longer than 32 bytes")
+
+// PrivateCongressAPI provides the congress validator operator APIs, managing the
+// local validator on top of the consensus engine ones.
+type PrivateCongressAPI struct {
+	e *Ethereum
+}
+
+// NewPrivateCongressAPI creates a new congress operator API.
+func NewPrivateCongressAPI(e *Ethereum) *PrivateCongressAPI {
+	return &PrivateCongressAPI{e}
+}
+
+// SealingEnabled starts or stops sealing blocks with the local validator, and
+// returns whether sealing was enabled. The miner applies the switch asynchronously,
+// and defers the start until the node is synced, so eth.mining may lag behind.
+func (api *PrivateCongressAPI) SealingEnabled(enabled bool) (bool, error) {
+	if _, ok := api.e.engine.(*congress.Congress); !ok {
+		return false, errNotCongress
+	}
+	if enabled {
+		if err := api.e.StartMining(runtime.NumCPU()); err != nil {
+			return false, err
+		}
+	} else {
+		api.e.StopMining()
+	}
+	return enabled, nil
+}
+
+// SetVanity sets the vanity prefix of the extra-data of the blocks sealed by the
+// local validator.
+func (api *PrivateCongressAPI) SetVanity(vanity hexutil.Bytes) (bool, error) {
+	if _, ok := api.e.engine.(*congress.Congress); !ok {
+		return false, errNotCongress
+	}
+	if len(vanity) > 32 {
+		return false, errVanityTooLong
+	}
+	if err := api.e.Miner().SetExtra(vanity); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ProposalVote votes for or against a validator proposal from the local validator
+// account, which must be unlocked, and returns the hash of the vote transaction.
+func (api *PrivateCongressAPI) ProposalVote(ctx context.Context, id common.Hash, auth bool) (common.Hash, error) {
+	if _, ok := api.e.engine.(*congress.Congress); !ok {
+		return common.Hash{}, errNotCongress
+	}
+	validator, err := api.e.Etherbase()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	data, err := systemcontract.GetInteractiveABI()[systemcontract.ProposalContractName].Pack("voteProposal", id, auth)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	gasPrice, err := api.e.APIBackend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if head := api.e.blockchain.CurrentHeader(); head.BaseFee != nil {
+		gasPrice.Add(gasPrice, head.BaseFee)
+	}
+	tx := types.NewTransaction(api.e.txPool.Nonce(validator), systemcontract.ProposalAddr, common.Big0, proposalVoteGas, gasPrice, data)
+
+	account := accounts.Account{Address: validator}
+	wallet, err := api.e.accountManager.Find(account)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	signed, err := wallet.SignTx(account, tx, api.e.blockchain.Config().ChainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := api.e.txPool.AddLocal(signed); err != nil {
+		return common.Hash{}, err
+	}
+	return signed.Hash(), nil
+}
+
+// SealedBlockEarnings is the revenue of a block sealed by the local validator.
+type SealedBlockEarnings struct {
+	Number    hexutil.Uint64 `json:"number"`
+	Hash      common.Hash    `json:"hash"`
+	Validator common.Address `json:"validator"`
+	GasUsed   hexutil.Uint64 `json:"gasUsed"`
+	TxCount   hexutil.Uint   `json:"txCount"`          // Transactions sealed, the system ones excluded
+	Fees      *hexutil.Big   `json:"fees,omitempty"`   // Tips and unburnt base fees collected by the block, if accounted
+	Burnt     *hexutil.Big   `json:"burnt,omitempty"`  // Base fees burnt by the block, if accounted
+	Reward    *hexutil.Big   `json:"reward,omitempty"` // Fees routed to the validators by distributeBlockReward, if accounted
+}
+
+// sealedBlockEarnings assembles the revenue of the sealed block, out of the supply
+// accounting written along with it.
+func (api *PrivateCongressAPI) sealedBlockEarnings(block *types.Block) *SealedBlockEarnings {
+	var (
+		header   = block.Header()
+		signer   = types.MakeSigner(api.e.blockchain.Config(), header.Number)
+		earnings = &SealedBlockEarnings{
+			Number:    hexutil.Uint64(header.Number.Uint64()),
+			Hash:      block.Hash(),
+			Validator: header.Coinbase,
+			GasUsed:   hexutil.Uint64(header.GasUsed),
+		}
+	)
+	for _, tx := range block.Transactions() {
+		if api.e.isPoSA {
+			sender, _ := types.Sender(signer, tx)
+			if ok, _ := api.e.posa.IsSysTransaction(sender, tx, header); ok {
+				continue
+			}
+		}
+		earnings.TxCount++
+	}
+	if delta := rawdb.ReadSupplyDelta(api.e.ChainDb(), block.Hash(), block.NumberU64()); delta != nil {
+		earnings.Fees = (*hexutil.Big)(delta.Fees)
+		earnings.Burnt = (*hexutil.Big)(delta.Burnt)
+		earnings.Reward = (*hexutil.Big)(delta.Rewards)
+	}
+	return earnings
+}
+
+// SealedBlocks creates a subscription notified with the earnings of every block
+// the local validator seals, for real time revenue tracking by its operator.
+func (api *PrivateCongressAPI) SealedBlocks(ctx context.Context) (*rpc.Subscription, error) {
+	if _, ok := api.e.engine.(*congress.Congress); !ok {
+		return nil, errNotCongress
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	// Subscribe before returning, not to miss the blocks sealed right after
+	sub := api.e.eventMux.Subscribe(core.NewMinedBlockEvent{})
+	go func() {
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case obj, ok := <-sub.Chan():
+				if !ok {
+					return
+				}
+				if ev, ok := obj.Data.(core.NewMinedBlockEvent); ok {
+					notifier.Notify(rpcSub.ID, api.sealedBlockEarnings(ev.Block))
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
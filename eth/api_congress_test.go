@@ -0,0 +1,116 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/consensus/congress/systemcontract"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the sealedBlocks subscription notifies the earnings of the sealed
+// blocks, the system transactions excluded and the fees read from the supply
+// accounting if written.
+func TestSealedBlocks(t *testing.T) {
+	var (
+		db     = rawdb.NewMemoryDatabase()
+		config = params.AllCongressProtocolChanges
+		ref    = new(congress.ChainRef)
+		engine = congress.New(config, db, ref)
+		extra  = make([]byte, 32+common.AddressLength+65)
+	)
+	copy(extra[32:], testAddr.Bytes())
+	(&core.Genesis{Config: config, ExtraData: extra}).MustCommit(db)
+
+	chain, err := core.NewBlockChain(db, nil, config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	ref.BlockChain = chain
+
+	e := &Ethereum{blockchain: chain, chainDb: db, engine: engine, eventMux: new(event.TypeMux), posa: engine, isPoSA: true}
+	defer e.eventMux.Stop()
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("congress", NewPrivateCongressAPI(e)); err != nil {
+		t.Fatalf("failed to register API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	earnings := make(chan *SealedBlockEarnings)
+	sub, err := client.Subscribe(context.Background(), "congress", earnings, "sealedBlocks")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// Seal a block with a user and a system transaction, and one without supply
+	// accounting
+	signer := types.MakeSigner(config, common.Big1)
+	user, _ := types.SignTx(types.NewTransaction(0, common.Address{0x01}, common.Big0, params.TxGas, big.NewInt(params.GWei), nil), signer, testKey)
+	system, _ := types.SignTx(types.NewTransaction(1, systemcontract.SysGovToAddr, common.Big0, params.TxGas, common.Big0, nil), signer, testKey)
+
+	header := &types.Header{Number: common.Big1, Coinbase: testAddr, GasUsed: 2 * params.TxGas}
+	block := types.NewBlock(header, types.Transactions{user, system}, nil, nil, trie.NewStackTrie(nil))
+	rawdb.WriteSupplyDelta(db, block.Hash(), 1, &types.SupplyDelta{Burnt: big.NewInt(1), Fees: big.NewInt(2), Rewards: big.NewInt(3)})
+
+	unaccounted := types.NewBlockWithHeader(&types.Header{Number: common.Big2, Coinbase: testAddr})
+
+	for _, block := range []*types.Block{block, unaccounted} {
+		if err := e.eventMux.Post(core.NewMinedBlockEvent{Block: block}); err != nil {
+			t.Fatalf("failed to post sealed block: %v", err)
+		}
+	}
+	next := func() *SealedBlockEarnings {
+		select {
+		case ev := <-earnings:
+			return ev
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(time.Second):
+			t.Fatalf("sealed block notification timeout")
+		}
+		return nil
+	}
+	have := next()
+	if have.Number != 1 || have.Hash != block.Hash() || have.Validator != testAddr || have.GasUsed != 2*21000 || have.TxCount != 1 {
+		t.Errorf("earnings mismatch: have %+v", have)
+	}
+	if have.Burnt.ToInt().Int64() != 1 || have.Fees.ToInt().Int64() != 2 || have.Reward.ToInt().Int64() != 3 {
+		t.Errorf("accounted fees mismatch: have burnt %v fees %v reward %v", have.Burnt, have.Fees, have.Reward)
+	}
+	if have = next(); have.Number != 2 || have.TxCount != 0 || have.Fees != nil || have.Burnt != nil || have.Reward != nil {
+		t.Errorf("unaccounted earnings mismatch: have %+v", have)
+	}
+}
+
+// Tests that the sealedBlocks subscription is refused by the nodes not running
+// the congress engine.
+func TestSealedBlocksNotCongress(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("congress", NewPrivateCongressAPI(&Ethereum{engine: ethash.NewFaker()})); err != nil {
+		t.Fatalf("failed to register API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	if _, err := client.Subscribe(context.Background(), "congress", make(chan *SealedBlockEarnings), "sealedBlocks"); err == nil || err.Error() != errNotCongress.Error() {
+		t.Errorf("subscription error mismatch: have %v, want %v", err, errNotCongress)
+	}
+}
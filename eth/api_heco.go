@@ -0,0 +1,408 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	errNotCongress  = errors.New("consensus engine is not congress")
+	errNotSealing   = errors.New("node is not sealing")
+	errNotInTurn    = errors.New("node is not the in-turn validator of the pending block")
+	errPendingStale = errors.New("pending block is not on top of the current head")
+	errTxNotPending = errors.New("transaction not selected into the pending block")
+	errEmptyWindow  = errors.New("empty fee stats window")
+)
+
+// maxFeeStatsWindow is the max number of blocks the fee statistics aggregate.
+const maxFeeStatsWindow = 1024
+
+// feeStatsPercentiles are the tip percentiles reported by the fee statistics.
+var feeStatsPercentiles = []float64{10, 25, 50, 75, 90}
+
+// Preconfirmation is a signed commitment of the in-turn validator to include a
+// transaction in the block it's about to seal.
+type Preconfirmation struct {
+	TxHash      common.Hash    `json:"txHash"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	ParentHash  common.Hash    `json:"parentHash"`
+	Validator   common.Address `json:"validator"`
+	Signature   hexutil.Bytes  `json:"signature"` // Signature over keccak256 of congress.PreconfirmMessage
+}
+
+// Attestation is a validator's signed announcement of its canonical block at a
+// given height, gathered from the network.
+type Attestation struct {
+	Hash      common.Hash    `json:"hash"`
+	Validator common.Address `json:"validator"`
+	Signature hexutil.Bytes  `json:"signature"` // Signature over keccak256 of congress.AttestationMessage
+	Canonical bool           `json:"canonical"` // Whether the attested block is canonical locally
+}
+
+// CanonicalAttestations are the validators' attestations of a block height along
+// with the locally canonical block, to detect when the node follows a minority fork.
+type CanonicalAttestations struct {
+	Number       hexutil.Uint64 `json:"number"`
+	Hash         *common.Hash   `json:"hash"` // Local canonical hash, nil if the height isn't reached
+	Agreeing     int            `json:"agreeing"`
+	Conflicting  int            `json:"conflicting"`
+	Attestations []*Attestation `json:"attestations"`
+}
+
+// PublicHecoAPI provides the heco specific APIs of a sealing validator.
+type PublicHecoAPI struct {
+	e *Ethereum
+}
+
+// NewPublicHecoAPI creates a new heco API.
+func NewPublicHecoAPI(e *Ethereum) *PublicHecoAPI {
+	return &PublicHecoAPI{e}
+}
+
+// Preconfirm returns a signed "will include" preconfirmation for the transaction,
+// if this node is the in-turn validator and the transaction has been selected into
+// the pending block.
+func (api *PublicHecoAPI) Preconfirm(txHash common.Hash) (*Preconfirmation, error) {
+	engine, ok := api.e.engine.(*congress.Congress)
+	if !ok {
+		return nil, errNotCongress
+	}
+	if !api.e.IsMining() {
+		return nil, errNotSealing
+	}
+	pending := api.e.miner.PendingBlock()
+	if pending == nil {
+		return nil, errNotSealing
+	}
+	if pending.ParentHash() != api.e.blockchain.CurrentBlock().Hash() {
+		return nil, errPendingStale
+	}
+	if !engine.InTurn(pending.Header()) {
+		return nil, errNotInTurn
+	}
+	if pending.Transaction(txHash) == nil {
+		return nil, errTxNotPending
+	}
+	msg := congress.PreconfirmMessage(api.e.blockchain.Config().ChainID, pending.NumberU64(), pending.ParentHash(), txHash)
+	validator, sig, err := engine.SignPreconfirmation(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &Preconfirmation{
+		TxHash:      txHash,
+		BlockNumber: hexutil.Uint64(pending.NumberU64()),
+		ParentHash:  pending.ParentHash(),
+		Validator:   validator,
+		Signature:   sig,
+	}, nil
+}
+
+// GetCanonicalAttestations returns the recent validators' signed head attestations
+// of the given height gathered from the network, along with how many agree with
+// the local canonical block.
+func (api *PublicHecoAPI) GetCanonicalAttestations(number rpc.BlockNumber) (*CanonicalAttestations, error) {
+	if _, ok := api.e.engine.(*congress.Congress); !ok {
+		return nil, errNotCongress
+	}
+	var height uint64
+	switch number {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		height = api.e.blockchain.CurrentBlock().NumberU64()
+	case rpc.EarliestBlockNumber:
+		height = 0
+	default:
+		height = uint64(number.Int64())
+	}
+	result := &CanonicalAttestations{
+		Number:       hexutil.Uint64(height),
+		Attestations: []*Attestation{},
+	}
+	if header := api.e.blockchain.GetHeaderByNumber(height); header != nil {
+		hash := header.Hash()
+		result.Hash = &hash
+	}
+	for _, att := range api.e.handler.attestations.Get(height) {
+		canonical := result.Hash != nil && att.Hash == *result.Hash
+		if canonical {
+			result.Agreeing++
+		} else {
+			result.Conflicting++
+		}
+		result.Attestations = append(result.Attestations, &Attestation{
+			Hash:      att.Hash,
+			Validator: att.Validator,
+			Signature: att.Signature,
+			Canonical: canonical,
+		})
+	}
+	return result, nil
+}
+
+// FeeStats aggregates the fee market of a window of recent blocks, the per block
+// series being ordered from the oldest block.
+type FeeStats struct {
+	OldestBlock  hexutil.Uint64 `json:"oldestBlock"`
+	BaseFee      []*hexutil.Big `json:"baseFeePerGas"`
+	GasUsedRatio []float64      `json:"gasUsedRatio"`
+	JamIndex     []*int         `json:"jamIndex"` // Jam index when the block was imported, nil if not recorded
+	Percentiles  []float64      `json:"percentiles"`
+	Tips         []*hexutil.Big `json:"tips"` // Gas weighted tip percentiles over the window, system transactions excluded
+}
+
+// txGasAndTip is the gas used by a transaction along with the tip it paid.
+type txGasAndTip struct {
+	gasUsed uint64
+	tip     *big.Int
+}
+
+// FeeStats returns the fee market statistics of the given number of recent blocks:
+// the base fee trajectory, the gas used ratios, the jam index history and the tip
+// percentiles, in a single call.
+func (api *PublicHecoAPI) FeeStats(ctx context.Context, window rpc.DecimalOrHex) (*FeeStats, error) {
+	if window == 0 {
+		return nil, errEmptyWindow
+	}
+	if window > maxFeeStatsWindow {
+		window = maxFeeStatsWindow
+	}
+	var (
+		head   = api.e.blockchain.CurrentBlock().NumberU64()
+		blocks = uint64(window)
+	)
+	if blocks > head+1 {
+		blocks = head + 1
+	}
+	oldest := head + 1 - blocks
+
+	jams := make(map[uint64]int)
+	for _, record := range api.e.txPool.JamHistory(oldest) {
+		jams[record.Number] = record.JamIndex
+	}
+	stats := &FeeStats{
+		OldestBlock:  hexutil.Uint64(oldest),
+		BaseFee:      make([]*hexutil.Big, 0, blocks),
+		GasUsedRatio: make([]float64, 0, blocks),
+		JamIndex:     make([]*int, 0, blocks),
+		Percentiles:  feeStatsPercentiles,
+	}
+	var (
+		tips     []txGasAndTip
+		totalGas uint64
+	)
+	for number := oldest; number <= head; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := api.e.blockchain.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		header := block.Header()
+
+		baseFee := header.BaseFee
+		if baseFee == nil {
+			baseFee = new(big.Int)
+		}
+		stats.BaseFee = append(stats.BaseFee, (*hexutil.Big)(baseFee))
+		stats.GasUsedRatio = append(stats.GasUsedRatio, float64(header.GasUsed)/float64(header.GasLimit))
+		if jam, ok := jams[number]; ok {
+			stats.JamIndex = append(stats.JamIndex, &jam)
+		} else {
+			stats.JamIndex = append(stats.JamIndex, nil)
+		}
+		receipts := api.e.blockchain.GetReceiptsByHash(block.Hash())
+		signer := types.MakeSigner(api.e.blockchain.Config(), block.Number())
+		for i, tx := range block.Transactions() {
+			if i >= len(receipts) {
+				break
+			}
+			if api.e.isPoSA {
+				sender, _ := types.Sender(signer, tx)
+				if ok, _ := api.e.posa.IsSysTransaction(sender, tx, header); ok {
+					continue
+				}
+			}
+			tips = append(tips, txGasAndTip{gasUsed: receipts[i].GasUsed, tip: tx.EffectiveGasTipValue(header.BaseFee)})
+			totalGas += receipts[i].GasUsed
+		}
+	}
+	stats.Tips = tipPercentiles(tips, totalGas, feeStatsPercentiles)
+	return stats, nil
+}
+
+// tipPercentiles returns the gas weighted percentiles of the tips, zero if there
+// are no transactions.
+func tipPercentiles(tips []txGasAndTip, totalGas uint64, percentiles []float64) []*hexutil.Big {
+	result := make([]*hexutil.Big, len(percentiles))
+	if len(tips) == 0 {
+		for i := range result {
+			result[i] = new(hexutil.Big)
+		}
+		return result
+	}
+	sort.Slice(tips, func(i, j int) bool {
+		return tips[i].tip.Cmp(tips[j].tip) < 0
+	})
+	var (
+		index  int
+		sumGas = tips[0].gasUsed
+	)
+	for i, p := range percentiles {
+		threshold := uint64(float64(totalGas) * p / 100)
+		for sumGas < threshold && index < len(tips)-1 {
+			index++
+			sumGas += tips[index].gasUsed
+		}
+		result[i] = (*hexutil.Big)(tips[index].tip)
+	}
+	return result
+}
+
+// ContractGasPrices are the gas price suggestions in gwei of the transactions
+// calling a contract.
+type ContractGasPrices struct {
+	Fast   uint `json:"fast"`
+	Median uint `json:"median"`
+	Low    uint `json:"low"`
+	Hot    bool `json:"hot"` // Whether the contract crowds the pending pool and is priced on its own
+}
+
+// GasPriceFor returns the suggestion for gas prices of fast, median, low of the
+// transactions calling the given contract. During congestion on a contract, such
+// as an NFT mint, its transactions clear at prices far from the rest of the chain.
+func (api *PublicHecoAPI) GasPriceFor(to common.Address) *ContractGasPrices {
+	prices, hot := api.e.APIBackend.gpp.PricesFor(to)
+	return &ContractGasPrices{
+		Fast:   prices[0],
+		Median: prices[1],
+		Low:    prices[2],
+		Hot:    hot,
+	}
+}
+
+// SupplyDeltaResult is the native token accounting of a range of canonical blocks.
+type SupplyDeltaResult struct {
+	From    hexutil.Uint64 `json:"from"`
+	To      hexutil.Uint64 `json:"to"`
+	Burnt   *hexutil.Big   `json:"burnt"`   // Base fees burnt
+	Fees    *hexutil.Big   `json:"fees"`    // Tips and unburnt base fees collected into the fee recorder
+	Rewards *hexutil.Big   `json:"rewards"` // Fees distributed to the validators
+	Delta   *hexutil.Big   `json:"delta"`   // Net supply change
+}
+
+// GetSupplyDelta returns the native token supply changes over the given inclusive
+// range of canonical blocks, out of the running totals kept while importing them.
+// All the blocks must have been executed by the node, i.e. not fast synced.
+func (api *PublicHecoAPI) GetSupplyDelta(from, to rpc.BlockNumber) (*SupplyDeltaResult, error) {
+	head := api.e.blockchain.CurrentBlock().NumberU64()
+	resolveNum := func(num rpc.BlockNumber) uint64 {
+		if num.Int64() < 0 || uint64(num.Int64()) > head {
+			return head
+		}
+		return uint64(num.Int64())
+	}
+	start, end := resolveNum(from), resolveNum(to)
+	if start > end {
+		return nil, fmt.Errorf("invalid range: from %d after to %d", start, end)
+	}
+	db := api.e.ChainDb()
+	readDelta := func(number uint64) (*types.SupplyDelta, error) {
+		if delta := rawdb.ReadSupplyDelta(db, rawdb.ReadCanonicalHash(db, number), number); delta != nil {
+			return delta, nil
+		}
+		return nil, fmt.Errorf("supply of block #%d not accounted by the node", number)
+	}
+	first, err := readDelta(start)
+	if err != nil {
+		return nil, err
+	}
+	last, err := readDelta(end)
+	if err != nil {
+		return nil, err
+	}
+	if first.Since != last.Since {
+		return nil, fmt.Errorf("supply accounting restarted at block #%d within the range", last.Since)
+	}
+	// The running totals include the first block of the range
+	sum := func(first, last, own *big.Int) *hexutil.Big {
+		return (*hexutil.Big)(new(big.Int).Add(new(big.Int).Sub(last, first), own))
+	}
+	burnt := sum(first.TotalBurnt, last.TotalBurnt, first.Burnt)
+	return &SupplyDeltaResult{
+		From:    hexutil.Uint64(start),
+		To:      hexutil.Uint64(end),
+		Burnt:   burnt,
+		Fees:    sum(first.TotalFees, last.TotalFees, first.Fees),
+		Rewards: sum(first.TotalRewards, last.TotalRewards, first.Rewards),
+		Delta:   (*hexutil.Big)(new(big.Int).Neg(burnt.ToInt())),
+	}, nil
+}
+
+// FeeBurnPolicy is the split of the transaction fees between burning and the
+// validators in force at a block, along with the block's accounted fee flows.
+type FeeBurnPolicy struct {
+	Number    hexutil.Uint64 `json:"number"`
+	BaseFee   *hexutil.Big   `json:"baseFee,omitempty"`
+	BurnRatio hexutil.Uint64 `json:"burnRatio"`           // Per mille of the transaction fees burnt
+	ForkBlock *hexutil.Big   `json:"forkBlock,omitempty"` // Activation block of the ratio, nil if none burnt
+	Burnt     *hexutil.Big   `json:"burnt,omitempty"`     // Fees burnt by the block, if accounted
+	Fees      *hexutil.Big   `json:"fees,omitempty"`      // Fees collected into the fee recorder by the block, if accounted
+}
+
+// GetFeeBurnPolicy returns the fee burn policy in force at the given block, as
+// tuned by the system governance in its parent state, and the fees the block
+// burnt and collected if the node accounted its supply.
+func (api *PublicHecoAPI) GetFeeBurnPolicy(number rpc.BlockNumber) (*FeeBurnPolicy, error) {
+	config := api.e.blockchain.Config()
+	if config.Congress == nil {
+		return nil, errNotCongress
+	}
+	var header *types.Header
+	switch number {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		header = api.e.blockchain.CurrentHeader()
+	case rpc.EarliestBlockNumber:
+		header = api.e.blockchain.GetHeaderByNumber(0)
+	default:
+		header = api.e.blockchain.GetHeaderByNumber(uint64(number.Int64()))
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	ratio := config.Congress.FeeBurnRatioAt(header.Number)
+	if posa, ok := api.e.engine.(consensus.PoSA); ok && header.Number.Sign() > 0 && config.Congress.IsFeeBurn(header.Number) {
+		parent := api.e.blockchain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+		if parent == nil {
+			return nil, fmt.Errorf("parent of block #%d not found", header.Number)
+		}
+		statedb, err := api.e.blockchain.StateAt(parent.Root)
+		if err != nil {
+			return nil, err
+		}
+		ratio = posa.FeeBurnRatio(statedb, header.Number)
+	}
+	policy := &FeeBurnPolicy{
+		Number:    hexutil.Uint64(header.Number.Uint64()),
+		BaseFee:   (*hexutil.Big)(header.BaseFee),
+		BurnRatio: hexutil.Uint64(ratio),
+	}
+	if config.Congress.FeeBurnBlock != nil {
+		policy.ForkBlock = (*hexutil.Big)(config.Congress.FeeBurnBlock)
+	}
+	if delta := rawdb.ReadSupplyDelta(api.e.ChainDb(), header.Hash(), header.Number.Uint64()); delta != nil {
+		policy.Burnt = (*hexutil.Big)(delta.Burnt)
+		policy.Fees = (*hexutil.Big)(delta.Fees)
+	}
+	return policy, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the fee statistics aggregate the base fees, the gas used, the jam
+// index and the gas weighted tips of the blocks of the window.
+func TestFeeStats(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		config  = params.TestChainConfig
+		engine  = ethash.NewFaker()
+		signer  = types.LatestSigner(config)
+		genesis = (&core.Genesis{Config: config, Alloc: core.GenesisAlloc{testAddr: {Balance: big.NewInt(1e18)}}}).MustCommit(db)
+	)
+	// Block n holds a tx tipping n gwei, block 8 another one using twice the gas
+	// and tipping 1 gwei
+	var nonce uint64
+	blocks, _ := core.GenerateChain(config, genesis, engine, db, 10, func(i int, gen *core.BlockGen) {
+		send := func(gas uint64, tip int64, data []byte) {
+			tx, _ := types.SignNewTx(testKey, signer, &types.DynamicFeeTx{
+				ChainID:   config.ChainID,
+				Nonce:     nonce,
+				GasTipCap: big.NewInt(tip * params.GWei),
+				GasFeeCap: big.NewInt(100 * params.GWei),
+				Gas:       gas,
+				To:        &common.Address{0x01},
+				Data:      data,
+			})
+			gen.AddTx(tx)
+			nonce++
+		}
+		send(params.TxGas, int64(i+1), nil)
+		if i+1 == 8 {
+			send(2*params.TxGas, 1, make([]byte, params.TxGas/params.TxDataZeroGas))
+		}
+	})
+	chain, err := core.NewBlockChain(db, nil, config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	poolConfig := core.DefaultTxPoolConfig
+	poolConfig.Journal = ""
+	pool := core.NewTxPool(poolConfig, config, chain)
+	defer pool.Stop()
+
+	// Import the blocks one by one, so the jam index is recorded for each head
+	for _, block := range blocks {
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			t.Fatalf("failed to insert block %d: %v", block.NumberU64(), err)
+		}
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+			if history := pool.JamHistory(block.NumberU64()); len(history) == 1 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("jam index of block %d not recorded", block.NumberU64())
+			}
+		}
+	}
+	api := NewPublicHecoAPI(&Ethereum{blockchain: chain, txPool: pool})
+
+	stats, err := api.FeeStats(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("failed to aggregate fee stats: %v", err)
+	}
+	if stats.OldestBlock != 7 {
+		t.Fatalf("oldest block mismatch: have %d, want 7", stats.OldestBlock)
+	}
+	if len(stats.BaseFee) != 4 || len(stats.GasUsedRatio) != 4 || len(stats.JamIndex) != 4 {
+		t.Fatalf("series length mismatch: have %d base fees, %d ratios, %d jam indexes, want 4", len(stats.BaseFee), len(stats.GasUsedRatio), len(stats.JamIndex))
+	}
+	for i := 0; i < 4; i++ {
+		header := blocks[6+i].Header()
+		if stats.BaseFee[i].ToInt().Cmp(header.BaseFee) != 0 {
+			t.Errorf("block %d: base fee mismatch: have %v, want %v", 7+i, stats.BaseFee[i], header.BaseFee)
+		}
+		if want := float64(header.GasUsed) / float64(header.GasLimit); stats.GasUsedRatio[i] != want {
+			t.Errorf("block %d: gas used ratio mismatch: have %v, want %v", 7+i, stats.GasUsedRatio[i], want)
+		}
+		if stats.JamIndex[i] == nil {
+			t.Errorf("block %d: jam index missing", 7+i)
+		}
+	}
+	// The window holds tips of 7, 8, 9 and 10 gwei for 21000 gas each and of 1 gwei
+	// for 42000 gas used, i.e. the 1 gwei tip covers the lowest third of the gas
+	want := []int64{1, 1, 7, 9, 10}
+	for i, tip := range stats.Tips {
+		if tip.ToInt().Cmp(big.NewInt(want[i]*params.GWei)) != 0 {
+			t.Errorf("tip percentile %v mismatch: have %v, want %d gwei", stats.Percentiles[i], tip, want[i])
+		}
+	}
+	// The window is clamped to the chain, and can't be empty
+	if stats, err := api.FeeStats(context.Background(), 100); err != nil || stats.OldestBlock != 0 || len(stats.BaseFee) != 11 {
+		t.Errorf("clamped window mismatch: have %+v, err %v", stats, err)
+	}
+	if _, err := api.FeeStats(context.Background(), 0); err != errEmptyWindow {
+		t.Errorf("empty window error mismatch: have %v, want %v", err, errEmptyWindow)
+	}
+}
@@ -21,12 +21,14 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/external"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
@@ -43,6 +45,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/heco"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
@@ -87,12 +90,18 @@ type Ethereum struct {
 	bloomIndexer      *core.ChainIndexer             // Bloom indexer operating during block imports
 	closeBloomHandler chan struct{}
 
+	backup *chainBackup // Periodic chain data backups, nil if disabled
+
+	blacklistWatch *congress.BlacklistWatch // Expected blacklist divergence alarm, nil if disabled
+
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
 	gasPrice  *big.Int
 	etherbase common.Address
 
+	validatorSigner *external.FailoverSigner // Remote signer of the congress validator, nil to use the local wallets
+
 	networkID     uint64
 	netRPCService *ethapi.PublicNetAPI
 
@@ -131,11 +140,38 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	ethashConfig.NotifyFull = config.Miner.NotifyFull
 
 	// Assemble the Ethereum object
+	if config.DatabaseSlowQuery > 0 {
+		rawdb.SetSlowQueryThreshold(config.DatabaseSlowQuery)
+		log.Info("Enabled slow database query logging", "threshold", config.DatabaseSlowQuery)
+	}
 	chainDb, err := stack.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, "eth/db/chaindata/", false)
 	if err != nil {
 		return nil, err
 	}
-	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlockWithOverride(chainDb, config.Genesis, config.OverrideArrowGlacier)
+	if config.DatabaseCompression != "" {
+		codec, err := rawdb.ParseChainDataCodec(config.DatabaseCompression)
+		if err != nil {
+			return nil, err
+		}
+		rawdb.SetChainDataCodec(codec)
+		log.Info("Configured chain data compression", "codec", codec)
+
+		// The existing bodies and receipts are only re-encoded offline
+		if stored, ok := rawdb.ReadChainDataCodec(chainDb); stored != codec && (ok || codec != rawdb.CodecNone) {
+			log.Warn("Stored chain data not migrated to the configured compression, run geth db compress", "codec", codec)
+		}
+	}
+	genesis := config.Genesis
+	if err := core.CheckGenesis(chainDb, genesis); err != nil {
+		if !config.OverrideGenesisCheck {
+			return nil, err
+		}
+		log.Warn("Ignoring genesis check failure, falling back to the datadir's genesis", "err", err)
+		genesis = nil
+	}
+	// Keep the stored chain config for the fork self-test, before it's updated
+	storedConfig := rawdb.ReadChainConfig(chainDb, rawdb.ReadCanonicalHash(chainDb, 0))
+	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlockWithOverride(chainDb, genesis, config.ChainOverrides())
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
 	}
@@ -144,12 +180,13 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err := pruner.RecoverPruning(stack.ResolvePath(""), chainDb, stack.ResolvePath(config.TrieCleanCacheJournal)); err != nil {
 		log.Error("Failed to recover state", "error", err)
 	}
+	chainRef := new(congress.ChainRef)
 	eth := &Ethereum{
 		config:            config,
 		chainDb:           chainDb,
 		eventMux:          stack.EventMux(),
 		accountManager:    stack.AccountManager(),
-		engine:            ethconfig.CreateConsensusEngine(stack, chainConfig, &ethashConfig, config.Miner.Notify, config.Miner.Noverify, chainDb),
+		engine:            ethconfig.CreateConsensusEngine(stack, chainConfig, &ethashConfig, config.Miner.Notify, config.Miner.Noverify, chainDb, chainRef),
 		closeBloomHandler: make(chan struct{}),
 		networkID:         config.NetworkId,
 		gasPrice:          config.Miner.GasPrice,
@@ -191,12 +228,25 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			TrieTimeLimit:       config.TrieTimeout,
 			SnapshotLimit:       config.SnapshotCache,
 			Preimages:           config.Preimages,
+			WitnessDepth:        config.ProofWitnessDepth,
+			VersionCacheDepth:   config.RPCStateCacheDepth,
+			BlockMemoryLimit:    config.BlockMemoryLimit,
+			BlockMemoryCap:      config.BlockMemoryCap,
+			BlockSpillDir:       stack.ResolvePath("logspill"),
 		}
 	)
+	if config.EVMProfileWindow > 0 {
+		vmConfig.Profiler = vm.NewProfiler(config.EVMProfileWindow)
+	}
 	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, chainConfig, eth.engine, vmConfig, eth.shouldPreserve, &config.TxLookupLimit)
 	if err != nil {
 		return nil, err
 	}
+	chainRef.BlockChain = eth.blockchain // The congress engine runs on it from now on
+
+	if config.BlockSTMResearch {
+		eth.blockchain.EnableBlockSTM(stack.ResolvePath("blockstm"))
+	}
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
@@ -205,19 +255,77 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
 
+	if config.BackupInterval > 0 {
+		root := stack.ResolvePath("chaindata")
+		if root == "" {
+			log.Warn("Chain data backups disabled, no data directory")
+		} else {
+			ancients := config.DatabaseFreezer
+			switch {
+			case ancients == "":
+				ancients = filepath.Join(root, "ancient")
+			case !filepath.IsAbs(ancients):
+				ancients = stack.ResolvePath(ancients)
+			}
+			dir := config.BackupDir
+			switch {
+			case dir == "":
+				dir = stack.ResolvePath("backups")
+			case !filepath.IsAbs(dir):
+				dir = stack.ResolvePath(dir)
+			}
+			eth.backup = newChainBackup(eth.blockchain, chainDb, rawdb.PreexistingDatabase(root), ancients, dir, config.BackupInterval, config.BackupKeep)
+		}
+	}
+
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
 	eth.txPool = core.NewTxPool(config.TxPool, chainConfig, eth.blockchain)
+	eth.txPool.SetResubmitSigner(eth.resignLocalTx)
 
 	// do some extra work if consensus engine is congress.
 	if congressEngine, ok := eth.engine.(*congress.Congress); ok {
-		// set state fn
-		congressEngine.SetStateFn(eth.blockchain.StateAt)
+		// set the checkpoint validators cross-check mode
+		mode, err := congress.ParseEpochCheckMode(config.CongressEpochCheck)
+		if err != nil {
+			return nil, err
+		}
+		congressEngine.SetEpochCheckMode(mode)
+		// verify the system contract code, sealing is refused on mismatch in halt mode
+		sysCodeMode, err := congress.ParseSysCodeCheckMode(config.CongressSysCodeCheck)
+		if err != nil {
+			return nil, err
+		}
+		congressEngine.SetSysCodeCheckMode(sysCodeMode)
+		congressEngine.CheckSystemContracts(eth.blockchain.CurrentHeader())
+		// self-test the fork gated behaviors, failing fast on a chain past an unknown fork
+		if err := congressEngine.CheckForks(eth.blockchain.CurrentHeader(), storedConfig); err != nil {
+			return nil, err
+		}
+		congressEngine.SetMaxParentAge(config.CongressMaxParentAge)
+		// notify the configured webhook of the validator punishments
+		if config.CongressPunishWebhook != nil {
+			webhook, err := congress.NewPunishWebhook(*config.CongressPunishWebhook)
+			if err != nil {
+				return nil, err
+			}
+			congressEngine.AddPunishHook(webhook)
+		}
+		// watch the on-chain blacklist against the expected one
+		if config.CongressBlacklistWatch != nil {
+			if eth.blacklistWatch, err = congress.NewBlacklistWatch(*config.CongressBlacklistWatch, congressEngine); err != nil {
+				return nil, fmt.Errorf("blacklist watch: %v", err)
+			}
+		}
+		// connect to the remote validator signers if configured
+		if len(config.ValidatorSigner.Endpoints) > 0 {
+			if eth.validatorSigner, err = external.NewFailoverSigner(config.ValidatorSigner); err != nil {
+				return nil, fmt.Errorf("validator signer: %v", err)
+			}
+		}
 		// set consensus-related transaction validator
 		eth.txPool.InitExTxValidator(congressEngine)
-		//
-		congressEngine.SetChain(eth.blockchain)
 	}
 
 	// Permit the downloader to use the trie cache allowance during fast sync
@@ -226,6 +334,22 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if checkpoint == nil {
 		checkpoint = params.TrustedCheckpoints[genesisHash]
 	}
+	privatePeers := make([]*enode.Node, 0, len(config.PrivateTxPeers))
+	for _, url := range config.PrivateTxPeers {
+		peer, err := enode.Parse(enode.ValidSchemes, url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private tx peer %q: %v", url, err)
+		}
+		privatePeers = append(privatePeers, peer)
+	}
+	validatorPeers := make([]*enode.Node, 0, len(config.ValidatorPeers))
+	for _, url := range config.ValidatorPeers {
+		peer, err := enode.Parse(enode.ValidSchemes, url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validator peer %q: %v", url, err)
+		}
+		validatorPeers = append(validatorPeers, peer)
+	}
 	if eth.handler, err = newHandler(&handlerConfig{
 		Database:   chainDb,
 		Chain:      eth.blockchain,
@@ -236,14 +360,41 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		EventMux:   eth.eventMux,
 		Checkpoint: checkpoint,
 		Whitelist:  config.Whitelist,
+		SyncTarget: config.SyncTarget,
+		TxFetcher:  config.TxFetcher,
+		KnownTxs:   config.KnownTxsCacheSize,
+		StrictFork: config.StrictForkID,
+
+		PrivateTxPeers: privatePeers,
+		ValidatorPeers: validatorPeers,
+		BlockCap:       config.EgressBlockCap * 1024,
+		TxCap:          config.EgressTxCap * 1024,
+
+		LatencyRatio:     config.LatencyPeerRatio,
+		LatencyThreshold: config.LatencyThreshold,
 	}); err != nil {
 		return nil, err
 	}
+	if congressEngine, ok := eth.engine.(*congress.Congress); ok {
+		if config.CongressPreannounce {
+			congressEngine.SetDelayedSealFn(eth.handler.announceDelayedBlock)
+		}
+		congressEngine.SetPeerHeadFn(eth.handler.peerHeads)
+	}
 
 	eth.miner = miner.New(eth, &config.Miner, chainConfig, eth.EventMux(), eth.engine, eth.isLocalBlock)
-	eth.miner.SetExtra(makeExtraData(config.Miner.ExtraData))
+	extra := makeExtraData(config.Miner.ExtraData)
+	if _, ok := eth.engine.(*congress.Congress); ok && config.CongressVanity {
+		if extra, err = congress.VanityTag(params.Version, config.CongressVanityTag); err != nil {
+			return nil, err
+		}
+	}
+	eth.miner.SetExtra(extra)
 
-	eth.APIBackend = &EthAPIBackend{stack.Config().ExtRPCEnabled(), stack.Config().AllowUnprotectedTxs, eth, nil, nil}
+	eth.APIBackend = &EthAPIBackend{stack.Config().ExtRPCEnabled(), stack.Config().AllowUnprotectedTxs, eth, nil, nil, ethapi.NewHeavyCallLimiter(config.RPCHeavyCallLimit, eth.blockchain.Governor()), ethapi.NewResponseCache(config.RPCResponseCache, config.RPCResponseCacheTTL)}
+	if config.Governor {
+		eth.blockchain.Governor().SetThreshold(config.GovernorThreshold)
+	}
 	if eth.APIBackend.allowUnprotectedTxs {
 		log.Info("Unprotected transactions allowed")
 	}
@@ -402,6 +553,15 @@ func (s *Ethereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   s.netRPCService,
 			Public:    true,
+		}, {
+			Namespace: "heco",
+			Version:   "1.0",
+			Service:   NewPublicHecoAPI(s),
+			Public:    true,
+		}, {
+			Namespace: "congress",
+			Version:   "1.0",
+			Service:   NewPrivateCongressAPI(s),
 		},
 	}...)
 }
@@ -537,12 +697,25 @@ func (s *Ethereum) StartMining(threads int) error {
 			clique.Authorize(eb, wallet.SignData)
 		}
 		if congress, ok := s.engine.(*congress.Congress); ok {
-			wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
-			if wallet == nil || err != nil {
-				log.Error("Etherbase account unavailable locally", "err", err)
-				return fmt.Errorf("signer missing: %v", err)
+			if signer := s.validatorSigner; signer != nil {
+				found, err := signer.Contains(accounts.Account{Address: eb})
+				if err != nil {
+					log.Error("Remote signer unavailable", "err", err)
+					return fmt.Errorf("signer missing: %v", err)
+				}
+				if !found {
+					log.Error("Etherbase account unavailable in the remote signer", "etherbase", eb)
+					return fmt.Errorf("signer missing: %x not in the remote signer", eb)
+				}
+				congress.Authorize(eb, signer.SignData, signer.SignTx)
+			} else {
+				wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
+				if wallet == nil || err != nil {
+					log.Error("Etherbase account unavailable locally", "err", err)
+					return fmt.Errorf("signer missing: %v", err)
+				}
+				congress.Authorize(eb, wallet.SignData, wallet.SignTx)
 			}
-			congress.Authorize(eb, wallet.SignData, wallet.SignTx)
 		}
 		// If mining is started, we can disable the transaction rejection mechanism
 		// introduced to speed sync times.
@@ -570,6 +743,49 @@ func (s *Ethereum) StopMining() {
 func (s *Ethereum) IsMining() bool      { return s.miner.Mining() }
 func (s *Ethereum) Miner() *miner.Miner { return s.miner }
 
+// resignLocalTx signs a fee bumped local transaction with the sender's wallet,
+// it only works if the account is unlocked.
+func (s *Ethereum) resignLocalTx(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	account := accounts.Account{Address: from}
+	wallet, err := s.accountManager.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := wallet.SignTx(account, tx, s.blockchain.Config().ChainID)
+	if err != nil {
+		return nil, err
+	}
+	// Keep the replacements of the private transactions private
+	if s.handler.privateTxs.containsNonce(from, tx.Nonce()) {
+		s.handler.privateTxs.add(from, signed)
+	}
+	return signed, nil
+}
+
+// SendPrivateTransaction adds a local transaction to the pool, only sending it to
+// the private peers instead of gossiping it to all the peers.
+func (s *Ethereum) SendPrivateTransaction(tx *types.Transaction) error {
+	from, err := types.Sender(types.LatestSigner(s.blockchain.Config()), tx)
+	if err != nil {
+		return err
+	}
+	s.handler.privateTxs.add(from, tx)
+	if err := s.txPool.AddLocal(tx); err != nil {
+		s.handler.privateTxs.remove(tx.Hash())
+		return err
+	}
+	return nil
+}
+
+// ReloadConfig applies the runtime reloadable options of the configuration, the
+// tx jam index and the gas price prediction settings.
+func (s *Ethereum) ReloadConfig(config *ethconfig.Config) {
+	s.txPool.SetJamConfig(config.TxPool.JamConfig)
+
+	gpoParams := config.GPO
+	s.APIBackend.gpp.SetPredConfig(checkPricePredictionConfig(&gpoParams).PredConfig)
+}
+
 func (s *Ethereum) AccountManager() *accounts.Manager  { return s.accountManager }
 func (s *Ethereum) BlockChain() *core.BlockChain       { return s.blockchain }
 func (s *Ethereum) TxPool() *core.TxPool               { return s.txPool }
@@ -589,6 +805,7 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 	if s.config.SnapshotCache > 0 {
 		protos = append(protos, snap.MakeProtocols((*snapHandler)(s.handler), s.snapDialCandidates)...)
 	}
+	protos = append(protos, heco.MakeProtocols((*hecoHandler)(s.handler))...)
 	return protos
 }
 
@@ -600,6 +817,13 @@ func (s *Ethereum) Start() error {
 	// Start the bloom bits servicing goroutines
 	s.startBloomHandlers(params.BloomBitsBlocks)
 
+	// Start the periodic chain data backups if requested
+	if s.backup != nil {
+		s.backup.start()
+	}
+	if s.blacklistWatch != nil {
+		s.blacklistWatch.Start(s.blockchain)
+	}
 	// Figure out a max peers count based on the server limits
 	maxPeers := s.p2pServer.MaxPeers
 	if s.config.LightServ > 0 {
@@ -626,6 +850,12 @@ func (s *Ethereum) Stop() error {
 	close(s.closeBloomHandler)
 	s.txPool.Stop()
 	s.miner.Close()
+	if s.backup != nil {
+		s.backup.stop()
+	}
+	if s.blacklistWatch != nil {
+		s.blacklistWatch.Stop()
+	}
 	s.blockchain.Stop()
 	s.engine.Close()
 	rawdb.PopUncleanShutdownMarker(s.chainDb)
@@ -0,0 +1,338 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	backupManifestFile = "manifest.json" // Manifest file of a completed backup
+	backupTmpSuffix    = ".tmp"          // Suffix of the backup being written
+)
+
+var errBackupInterrupted = errors.New("backup interrupted")
+
+// BackupManifest describes a chain data backup, the head state of which is fully
+// present in the backed up database.
+type BackupManifest struct {
+	Number   uint64             `json:"number"`
+	Hash     common.Hash        `json:"hash"`
+	Root     common.Hash        `json:"root"`
+	Time     uint64             `json:"time"`     // Timestamp of the head block
+	Engine   string             `json:"engine"`   // Key-value store engine
+	Keys     int                `json:"keys"`     // Number of key-value store entries
+	Size     common.StorageSize `json:"size"`     // Size of the key-value store entries
+	Ancients uint64             `json:"ancients"` // Minimum number of items of the ancient store
+	Created  time.Time          `json:"created"`
+}
+
+// chainBackup periodically exports a consistent point-in-time copy of the chain
+// database into a backup directory, the copy being a regular datadir database
+// that can be archived or synced elsewhere as is.
+//
+// The key-value store is copied from a database iterator taken while the chain
+// insertion is paused and the head state flushed, the iterator reading a
+// snapshot of the store, so the compactions and the imports don't need to be
+// stopped for the duration of the copy. The ancient store, being append only,
+// is copied afterwards, and so holds at least the items frozen at the snapshot.
+// The in-memory state snapshot layers aren't journalled, a node started from the
+// backup regenerating the snapshot as needed.
+type chainBackup struct {
+	chain    *core.BlockChain
+	db       ethdb.Database
+	engine   string // Key-value store engine of the chain database
+	ancients string // Ancient store directory of the chain database
+	dir      string // Directory to write the backups into
+	interval time.Duration
+	keep     int // Number of backups to keep, older ones being removed
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+func newChainBackup(chain *core.BlockChain, db ethdb.Database, engine, ancients, dir string, interval time.Duration, keep int) *chainBackup {
+	if keep < 1 {
+		keep = 1
+	}
+	return &chainBackup{
+		chain:    chain,
+		db:       db,
+		engine:   engine,
+		ancients: ancients,
+		dir:      dir,
+		interval: interval,
+		keep:     keep,
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (b *chainBackup) start() {
+	log.Info("Started chain data backups", "dir", b.dir, "interval", b.interval, "keep", b.keep)
+	go b.loop()
+}
+
+// stop interrupts any running backup and waits for the scheduler to exit.
+func (b *chainBackup) stop() {
+	close(b.quit)
+	<-b.done
+}
+
+func (b *chainBackup) loop() {
+	defer close(b.done)
+
+	timer := time.NewTimer(b.interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			start := time.Now()
+			manifest, err := b.backup()
+			switch {
+			case err == errBackupInterrupted:
+				return
+			case err != nil:
+				log.Error("Failed to back up chain data", "err", err)
+			default:
+				log.Info("Backed up chain data", "number", manifest.Number, "hash", manifest.Hash,
+					"keys", manifest.Keys, "size", manifest.Size, "elapsed", common.PrettyDuration(time.Since(start)))
+				if err := b.prune(); err != nil {
+					log.Warn("Failed to remove old chain data backups", "err", err)
+				}
+			}
+			timer.Reset(b.interval)
+
+		case <-b.quit:
+			return
+		}
+	}
+}
+
+// backup writes a backup of the chain database, named by the head number and hash.
+func (b *chainBackup) backup() (*BackupManifest, error) {
+	var (
+		it       ethdb.Iterator
+		head     *types.Block
+		ancients uint64
+	)
+	err := b.chain.Checkpoint(func(block *types.Block) error {
+		head, it = block, b.db.NewIterator(nil, nil)
+		ancients, _ = b.db.Ancients()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Release()
+
+	var (
+		name = fmt.Sprintf("%d-%x", head.NumberU64(), head.Hash().Bytes()[:4])
+		path = filepath.Join(b.dir, name)
+		tmp  = path + backupTmpSuffix
+	)
+	if common.FileExist(path) {
+		return nil, fmt.Errorf("backup %s already exists", path)
+	}
+	if err := os.RemoveAll(tmp); err != nil {
+		return nil, err
+	}
+	manifest, err := b.export(tmp, it, head)
+	if err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	manifest.Ancients = ancients
+
+	blob, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, backupManifestFile), blob, 0644); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// export copies the key-value store entries of the iterator and the ancient store
+// into the chaindata directory of the backup.
+func (b *chainBackup) export(dir string, it ethdb.Iterator, head *types.Block) (*BackupManifest, error) {
+	chaindata := filepath.Join(dir, "chaindata")
+	if err := os.MkdirAll(chaindata, 0755); err != nil {
+		return nil, err
+	}
+	kv, err := rawdb.OpenKeyValueStore(b.engine, chaindata, 16, 16, "", false)
+	if err != nil {
+		return nil, err
+	}
+	keys, size, err := rawdb.CopyKeyValueStore(kv, it, b.quit)
+	if err != nil {
+		kv.Close()
+		if isClosed(b.quit) {
+			return nil, errBackupInterrupted
+		}
+		return nil, err
+	}
+	if err := kv.Close(); err != nil {
+		return nil, err
+	}
+	if b.ancients != "" && common.FileExist(b.ancients) {
+		if err := b.copyAncients(filepath.Join(chaindata, "ancient")); err != nil {
+			return nil, err
+		}
+	}
+	return &BackupManifest{
+		Number:  head.NumberU64(),
+		Hash:    head.Hash(),
+		Root:    head.Root(),
+		Time:    head.Time(),
+		Engine:  b.engine,
+		Keys:    keys,
+		Size:    size,
+		Created: time.Now().UTC(),
+	}, nil
+}
+
+// copyAncients copies the ancient store files while the freezer keeps appending
+// to them. The index files are copied before the data files, so every copied
+// index entry points into copied data, the freezer truncating the tables to
+// their shortest common length when opening the backup.
+func (b *chainBackup) copyAncients(dst string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(b.ancients)
+	if err != nil {
+		return err
+	}
+	var index, data []string
+	for _, file := range files {
+		switch name := file.Name(); {
+		case file.IsDir(), name == "FLOCK":
+		case strings.HasSuffix(name, "idx"):
+			index = append(index, name)
+		default:
+			data = append(data, name)
+		}
+	}
+	for _, name := range append(index, data...) {
+		if isClosed(b.quit) {
+			return errBackupInterrupted
+		}
+		if err := copyFile(filepath.Join(b.ancients, name), filepath.Join(dst, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prune removes the completed backups beyond the number to keep, and the ones
+// left over unfinished.
+func (b *chainBackup) prune() error {
+	files, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		return err
+	}
+	var backups []os.FileInfo
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(file.Name(), backupTmpSuffix) {
+			if err := os.RemoveAll(filepath.Join(b.dir, file.Name())); err != nil {
+				return err
+			}
+			continue
+		}
+		if common.FileExist(filepath.Join(b.dir, file.Name(), backupManifestFile)) {
+			backups = append(backups, file)
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ModTime().After(backups[j].ModTime())
+	})
+	for len(backups) > b.keep {
+		stale := backups[len(backups)-1]
+		if err := os.RemoveAll(filepath.Join(b.dir, stale.Name())); err != nil {
+			return err
+		}
+		log.Info("Removed old chain data backup", "name", stale.Name())
+		backups = backups[:len(backups)-1]
+	}
+	return nil
+}
+
+// copyFile copies the current content of the source file to the destination.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	stat, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	// Only copy the size at open, the file might still be appended to
+	if _, err := io.CopyN(out, in, stat.Size()); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// isClosed reports whether the channel is closed.
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestChainBackup(t *testing.T) {
+	handler := newTestHandlerWithBlocks(5)
+	defer handler.close()
+
+	// Create a fake ancient store, the lock file of which must not be copied
+	var (
+		ancients = t.TempDir()
+		dir      = t.TempDir()
+		files    = map[string][]byte{"headers.cidx": {1, 2, 3}, "headers.0000.cdat": {4, 5, 6, 7}}
+	)
+	for name, blob := range files {
+		if err := ioutil.WriteFile(filepath.Join(ancients, name), blob, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(ancients, "FLOCK"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	backup := newChainBackup(handler.chain, handler.db, rawdb.DBLeveldb, ancients, dir, time.Hour, 1)
+
+	manifest, err := backup.backup()
+	if err != nil {
+		t.Fatalf("failed to back up: %v", err)
+	}
+	head := handler.chain.CurrentBlock()
+	if manifest.Number != head.NumberU64() || manifest.Hash != head.Hash() || manifest.Root != head.Root() {
+		t.Fatalf("manifest head mismatch: have %d %x, want %d %x", manifest.Number, manifest.Hash, head.NumberU64(), head.Hash())
+	}
+	path := filepath.Join(dir, "5-"+common.Bytes2Hex(head.Hash().Bytes()[:4]))
+
+	// The manifest is stored along the backup
+	blob, err := ioutil.ReadFile(filepath.Join(path, backupManifestFile))
+	if err != nil {
+		t.Fatalf("failed to read manifest: %v", err)
+	}
+	var stored BackupManifest
+	if err := json.Unmarshal(blob, &stored); err != nil {
+		t.Fatalf("failed to decode manifest: %v", err)
+	}
+	if stored.Hash != head.Hash() || stored.Keys != manifest.Keys {
+		t.Errorf("stored manifest mismatch: have %+v, want %+v", stored, manifest)
+	}
+	// The backed up database holds the head block and its state
+	db, err := rawdb.NewLevelDBDatabase(filepath.Join(path, "chaindata"), 16, 16, "", true)
+	if err != nil {
+		t.Fatalf("failed to open backup: %v", err)
+	}
+	if hash := rawdb.ReadHeadBlockHash(db); hash != head.Hash() {
+		t.Errorf("head mismatch: have %x, want %x", hash, head.Hash())
+	}
+	if ok, _ := db.Has(head.Root().Bytes()); !ok {
+		t.Errorf("head state root missing")
+	}
+	db.Close()
+
+	// The ancient store files are copied, but the lock
+	for name, want := range files {
+		have, err := ioutil.ReadFile(filepath.Join(path, "chaindata", "ancient", name))
+		if err != nil || !bytes.Equal(have, want) {
+			t.Errorf("ancient file %s mismatch: have %x, want %x, err %v", name, have, want, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(path, "chaindata", "ancient", "FLOCK")); !os.IsNotExist(err) {
+		t.Errorf("ancient lock file copied")
+	}
+	// A new backup replaces the previous one
+	blocks, _ := core.GenerateChain(handler.chain.Config(), head, ethash.NewFaker(), handler.db, 1, nil)
+	if _, err := handler.chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	if _, err := backup.backup(); err != nil {
+		t.Fatalf("failed to back up: %v", err)
+	}
+	if err := backup.prune(); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	entries, _ := ioutil.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "6-"+common.Bytes2Hex(blocks[0].Hash().Bytes()[:4]) {
+		t.Errorf("backups mismatch after prune: %v", entries)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/heco"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// maxReleaseDelay is the furthest in the future a block sent ahead may be released
+// at, well beyond the wiggle delay of the largest validator sets.
+const maxReleaseDelay = 30 * time.Second
+
+var (
+	delayedBlockSentMeter     = metrics.NewRegisteredMeter("eth/delayedblock/sent", nil)
+	delayedBlockReceivedMeter = metrics.NewRegisteredMeter("eth/delayedblock/received", nil)
+
+	delayedBlockCancelledMeter = metrics.NewRegisteredMeter("eth/delayedblock/cancelled", nil)
+)
+
+// delayedBlockKey identifies the slot of a block sent ahead of its release.
+type delayedBlockKey struct {
+	signer common.Address
+	number uint64
+}
+
+// delayedBlock is a block sent ahead of its release, waiting for it.
+type delayedBlock struct {
+	hash  common.Hash
+	timer *time.Timer
+}
+
+// delayedBlockSet holds the blocks sent ahead of their release until then, at most
+// one per signer and height: a block resealed by its validator replaces the one
+// sent before, so that the two are never both imported.
+type delayedBlockSet struct {
+	blocks map[delayedBlockKey]*delayedBlock
+	lock   sync.Mutex
+}
+
+// newDelayedBlockSet creates an empty set of blocks waiting for their release.
+func newDelayedBlockSet() *delayedBlockSet {
+	return &delayedBlockSet{blocks: make(map[delayedBlockKey]*delayedBlock)}
+}
+
+// add schedules the release of a block after the given wait, replacing the block
+// of the same signer and height if any.
+func (s *delayedBlockSet) add(block *types.Block, wait time.Duration, release func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := delayedBlockKey{signer: block.Coinbase(), number: block.NumberU64()}
+	if prev, ok := s.blocks[key]; ok {
+		if prev.hash == block.Hash() {
+			return
+		}
+		prev.timer.Stop()
+		log.Debug("Replacing delayed block", "number", key.number, "signer", key.signer, "old", prev.hash, "new", block.Hash())
+	}
+	entry := &delayedBlock{hash: block.Hash()}
+	entry.timer = time.AfterFunc(wait, func() {
+		s.lock.Lock()
+		current := s.blocks[key] == entry
+		if current {
+			delete(s.blocks, key)
+		}
+		s.lock.Unlock()
+
+		if current {
+			release()
+		}
+	})
+	s.blocks[key] = entry
+}
+
+// remove drops the block with the given hash, withdrawn before its release. It
+// returns whether the block was waiting.
+func (s *delayedBlockSet) remove(hash common.Hash) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for key, entry := range s.blocks {
+		if entry.hash == hash {
+			entry.timer.Stop()
+			delete(s.blocks, key)
+			return true
+		}
+	}
+	return false
+}
+
+// announceDelayedBlock sends a block sealed out of turn to the trusted `heco` peers while
+// it waits out its wiggle delay, so that they have it at hand the instant it's
+// released instead of waiting for its propagation. It returns the function
+// withdrawing the block from the same peers, if it's replaced before its release.
+func (h *handler) announceDelayedBlock(block *types.Block, release time.Time) func() {
+	var peers []*ethPeer
+	for _, peer := range h.peers.allPeers() {
+		if peer.hecoExt != nil && peer.Peer.Peer.Info().Network.Trusted && !peer.KnownBlock(block.Hash()) {
+			peers = append(peers, peer)
+		}
+	}
+	for _, peer := range peers {
+		go func(peer *ethPeer) {
+			// Mark the block as known, it's not propagated again on release
+			peer.MarkBlock(block.Hash())
+			if err := peer.hecoExt.SendDelayedBlock(block, release); err != nil {
+				peer.Log().Debug("Failed to send delayed block", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+			}
+		}(peer)
+	}
+	delayedBlockSentMeter.Mark(int64(len(peers)))
+	log.Debug("Sent delayed block ahead", "number", block.NumberU64(), "hash", block.Hash(), "release", release, "peers", len(peers))
+
+	return func() {
+		for _, peer := range peers {
+			go func(peer *ethPeer) {
+				if err := peer.hecoExt.SendDelayedBlockCancel(block.Hash(), block.NumberU64()); err != nil {
+					peer.Log().Debug("Failed to withdraw delayed block", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+				}
+			}(peer)
+		}
+		delayedBlockCancelledMeter.Mark(int64(len(peers)))
+		log.Debug("Withdrew delayed block", "number", block.NumberU64(), "hash", block.Hash(), "peers", len(peers))
+	}
+}
+
+// handleDelayedBlock is invoked from a peer's message handler when it sends a block
+// sealed out of turn ahead of its release. The blocks of trusted peers are queued
+// for import at their release, the others are ignored.
+func (h *hecoHandler) handleDelayedBlock(peer *heco.Peer, block *types.Block, release uint64) error {
+	if !peer.Peer.Info().Network.Trusted {
+		return nil
+	}
+	// Mark the `eth` peer as owning the block
+	if p := h.peers.peer(peer.ID()); p != nil {
+		p.MarkBlock(block.Hash())
+	}
+	wait := time.Until(time.Unix(0, int64(release)*int64(time.Millisecond)))
+	if wait > maxReleaseDelay {
+		peer.Log().Debug("Ignoring delayed block released too late", "number", block.NumberU64(), "hash", block.Hash(), "wait", wait)
+		return nil
+	}
+	delayedBlockReceivedMeter.Mark(1)
+	h.delayedBlocks.add(block, wait, func() {
+		// Skip the block if a competing one was imported in the meantime
+		if h.chain.CurrentBlock().NumberU64() >= block.NumberU64() {
+			return
+		}
+		block.ReceivedAt = time.Now()
+		h.blockFetcher.Enqueue(peer.ID(), block)
+	})
+	return nil
+}
+
+// handleDelayedBlockCancel is invoked from a peer's message handler when it
+// withdraws a block sent ahead of its release, replaced by its validator.
+func (h *hecoHandler) handleDelayedBlockCancel(peer *heco.Peer, hash common.Hash) error {
+	if !peer.Peer.Info().Network.Trusted {
+		return nil
+	}
+	if h.delayedBlocks.remove(hash) {
+		delayedBlockCancelledMeter.Mark(1)
+		peer.Log().Debug("Dropped withdrawn delayed block", "hash", hash)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/heco"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the blocks sent ahead of their release are only imported from the
+// trusted peers, at their release, and only if neither withdrawn, nor replaced by
+// their signer, nor competing with a block imported in the meantime.
+func TestDelayedBlock(t *testing.T) {
+	t.Parallel()
+
+	handler := newTestHandlerWithBlocks(1)
+	defer handler.close()
+
+	// Create a trusted and an untrusted peer sending the delayed blocks
+	newPeer := func(id byte, trusted bool) *heco.Peer {
+		app, net := p2p.MsgPipe()
+		t.Cleanup(func() { app.Close(); net.Close() })
+
+		peer := p2p.NewPeerPipe(enode.ID{id}, "", nil, app)
+		if trusted {
+			peer = p2p.NewTrustedPeerPipe(enode.ID{id}, "", nil, app)
+		}
+		hecoPeer := heco.NewPeer(heco.HECO1, peer, app)
+		t.Cleanup(hecoPeer.Close)
+		return hecoPeer
+	}
+	trusted, untrusted := newPeer(1, true), newPeer(2, false)
+
+	// Generate the next block, a resealed one and a competing one mined by someone else
+	next := func(coinbase common.Address, extra byte) *types.Block {
+		blocks, _ := core.GenerateChain(params.TestChainConfig, handler.chain.CurrentBlock(), ethash.NewFaker(), handler.db, 1, func(i int, gen *core.BlockGen) {
+			gen.SetCoinbase(coinbase)
+			gen.SetExtra([]byte{extra})
+		})
+		return blocks[0]
+	}
+	block, resealed, competing := next(common.Address{0x01}, 0), next(common.Address{0x01}, 1), next(common.Address{0x02}, 0)
+
+	deliver := func(peer *heco.Peer, block *types.Block, release time.Time) {
+		t.Helper()
+
+		packet := &heco.DelayedBlockPacket{Block: block, Release: uint64(release.UnixNano() / int64(time.Millisecond))}
+		if err := (*hecoHandler)(handler.handler).Handle(peer, packet); err != nil {
+			t.Fatalf("failed to handle delayed block: %v", err)
+		}
+	}
+	cancel := func(peer *heco.Peer, block *types.Block) {
+		t.Helper()
+
+		packet := &heco.DelayedBlockCancelPacket{Hash: block.Hash(), Number: block.NumberU64()}
+		if err := (*hecoHandler)(handler.handler).Handle(peer, packet); err != nil {
+			t.Fatalf("failed to handle delayed block withdrawal: %v", err)
+		}
+	}
+	imported := func(block *types.Block, want bool) {
+		t.Helper()
+
+		// Wait for the import if expected, give it a chance to happen otherwise
+		if want {
+			for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+				if handler.chain.HasBlock(block.Hash(), block.NumberU64()) {
+					break
+				}
+			}
+		} else {
+			time.Sleep(200 * time.Millisecond)
+		}
+		if have := handler.chain.HasBlock(block.Hash(), block.NumberU64()); have != want {
+			t.Fatalf("block %d %x import mismatch: have %v, want %v", block.NumberU64(), block.Hash(), have, want)
+		}
+	}
+	// The untrusted peers and the releases too far ahead are ignored
+	deliver(untrusted, block, time.Now())
+	imported(block, false)
+
+	deliver(trusted, block, time.Now().Add(maxReleaseDelay+time.Minute))
+	imported(block, false)
+
+	// The blocks withdrawn before their release are dropped
+	deliver(trusted, resealed, time.Now().Add(100*time.Millisecond))
+	cancel(trusted, resealed)
+	imported(resealed, false)
+
+	// The blocks of the trusted peers are held until their release, the last one of
+	// a signer at a height replacing the ones before
+	deliver(trusted, resealed, time.Now().Add(100*time.Millisecond))
+	deliver(trusted, block, time.Now().Add(300*time.Millisecond))
+	if handler.chain.HasBlock(block.Hash(), block.NumberU64()) {
+		t.Fatalf("delayed block imported ahead of its release")
+	}
+	imported(block, true)
+	if handler.chain.HasBlock(resealed.Hash(), resealed.NumberU64()) {
+		t.Fatalf("replaced delayed block imported")
+	}
+
+	// A competing block is dropped once the head reached its number
+	deliver(trusted, competing, time.Now())
+	imported(competing, false)
+}
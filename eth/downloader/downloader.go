@@ -83,6 +83,7 @@ var (
 	errNoSyncActive            = errors.New("no sync active")
 	errTooOld                  = errors.New("peer's protocol version too old")
 	errNoAncestorFound         = errors.New("no common ancestor found")
+	errSyncTargetMismatch      = errors.New("chain doesn't contain the sync target")
 )
 
 type Downloader struct {
@@ -94,6 +95,9 @@ type Downloader struct {
 	queue      *queue   // Scheduler for selecting the hashes to download
 	peers      *peerSet // Set of active peers from which download can proceed
 
+	syncTarget       common.Hash // Trusted block hash the synced chain must contain, zero if none
+	syncTargetNumber uint64      // Number of the sync target, zero until resolved
+
 	stateDB    ethdb.Database  // Database to state sync into (and deduplicate via)
 	stateBloom *trie.SyncBloom // Bloom filter for fast trie node and contract code existence checks
 
@@ -242,7 +246,8 @@ func New(checkpoint uint64, stateDb ethdb.Database, stateBloom *trie.SyncBloom,
 // or header sync is currently at; and the latest known block which the sync targets.
 //
 // In addition, during the state download phase of fast synchronisation the number
-// of processed and the total number of known states are also returned. Otherwise
+// of processed and the total number of known states are also returned, and during
+// snap synchronisation the statistics of the state download and healing. Otherwise
 // these are zero.
 func (d *Downloader) Progress() ethereum.SyncProgress {
 	// Lock the current stats and return the progress
@@ -261,13 +266,31 @@ func (d *Downloader) Progress() ethereum.SyncProgress {
 	default:
 		log.Error("Unknown downloader chain/mode combo", "light", d.lightchain != nil, "full", d.blockchain != nil, "mode", mode)
 	}
-	return ethereum.SyncProgress{
+	progress := ethereum.SyncProgress{
 		StartingBlock: d.syncStatsChainOrigin,
 		CurrentBlock:  current,
 		HighestBlock:  d.syncStatsChainHeight,
 		PulledStates:  d.syncStatsState.processed,
 		KnownStates:   d.syncStatsState.processed + d.syncStatsState.pending,
 	}
+	if mode == FastSync && d.snapSync {
+		stats := d.SnapSyncer.Progress()
+
+		progress.SyncedAccounts = stats.AccountSynced
+		progress.SyncedAccountBytes = uint64(stats.AccountBytes)
+		progress.SyncedBytecodes = stats.BytecodeSynced
+		progress.SyncedBytecodeBytes = uint64(stats.BytecodeBytes)
+		progress.SyncedStorage = stats.StorageSynced
+		progress.SyncedStorageBytes = uint64(stats.StorageBytes)
+		progress.HealingState = stats.Healing
+		progress.HealedTrienodes = stats.TrienodeHealSynced
+		progress.HealedTrienodeBytes = uint64(stats.TrienodeHealBytes)
+		progress.HealedBytecodes = stats.BytecodeHealSynced
+		progress.HealedBytecodeBytes = uint64(stats.BytecodeHealBytes)
+		progress.HealingPending = stats.HealPending
+		progress.StateSyncETA = stats.ETA
+	}
+	return progress
 }
 
 // Synchronising returns whether the downloader is currently retrieving blocks.
@@ -331,7 +354,8 @@ func (d *Downloader) Synchronise(id string, head common.Hash, td *big.Int, mode
 	}
 	if errors.Is(err, errInvalidChain) || errors.Is(err, errBadPeer) || errors.Is(err, errTimeout) ||
 		errors.Is(err, errStallingPeer) || errors.Is(err, errUnsyncedPeer) || errors.Is(err, errEmptyHeaderSet) ||
-		errors.Is(err, errPeersUnavailable) || errors.Is(err, errTooOld) || errors.Is(err, errInvalidAncestor) {
+		errors.Is(err, errPeersUnavailable) || errors.Is(err, errTooOld) || errors.Is(err, errInvalidAncestor) ||
+		errors.Is(err, errSyncTargetMismatch) {
 		log.Warn("Synchronisation failed, dropping peer", "peer", id, "err", err)
 		if d.dropPeer == nil {
 			// The dropPeer method is nil when `--copydb` is used for a local copy.
@@ -476,6 +500,9 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 	if err != nil {
 		return err
 	}
+	if err := d.checkSyncTarget(p, latest, origin); err != nil {
+		return err
+	}
 	d.syncStatsLock.Lock()
 	if d.syncStatsChainHeight <= origin || d.syncStatsChainOrigin > origin {
 		d.syncStatsChainOrigin = origin
@@ -699,6 +726,107 @@ func (d *Downloader) fetchHead(p *peerConnection) (head *types.Header, pivot *ty
 	}
 }
 
+// SetSyncTarget pins the synchronisation to the chains containing the trusted block,
+// protecting from the long-range attacks of the peers feeding a fake chain. It must
+// be set before the synchronisation starts.
+func (d *Downloader) SetSyncTarget(hash common.Hash) {
+	d.syncTarget = hash
+}
+
+// checkSyncTarget ensures the chain of the remote peer contains the sync target, if
+// any. Once the target is imported, the peer must not fork off below it, otherwise
+// it must have the target on its canonical chain.
+func (d *Downloader) checkSyncTarget(p *peerConnection, remoteHeader *types.Header, origin uint64) error {
+	if d.syncTarget == (common.Hash{}) {
+		return nil
+	}
+	if header := d.lightchain.GetHeaderByHash(d.syncTarget); header != nil {
+		number := header.Number.Uint64()
+		d.syncTargetNumber = number
+		if origin < number {
+			return fmt.Errorf("%w: ancestor %d below target %d", errSyncTargetMismatch, origin, number)
+		}
+		return nil
+	}
+	p.log.Debug("Retrieving sync target", "hash", d.syncTarget)
+
+	target, err := d.fetchHeader(p, func() error { return p.peer.RequestHeadersByHash(d.syncTarget, 1, 0, false) })
+	if err != nil {
+		return err
+	}
+	if target == nil || target.Hash() != d.syncTarget {
+		return fmt.Errorf("%w: target %x unknown to the peer", errSyncTargetMismatch, d.syncTarget)
+	}
+	number := target.Number.Uint64()
+	if number > remoteHeader.Number.Uint64() {
+		return fmt.Errorf("%w: remote head %d below target %d", errUnsyncedPeer, remoteHeader.Number, number)
+	}
+	canonical, err := d.fetchHeader(p, func() error { return p.peer.RequestHeadersByNumber(number, 1, 0, false) })
+	if err != nil {
+		return err
+	}
+	if canonical == nil || canonical.Hash() != d.syncTarget {
+		return fmt.Errorf("%w: target %x not canonical on the peer", errSyncTargetMismatch, d.syncTarget)
+	}
+	d.syncTargetNumber = number
+	p.log.Debug("Sync target verified", "number", number, "hash", d.syncTarget)
+	return nil
+}
+
+// verifySyncTarget ensures the sync target is part of the chain the header chunk
+// belongs to. The peer can't swap the chain once checked as the headers are linked.
+func (d *Downloader) verifySyncTarget(chunk []*types.Header) error {
+	if d.syncTargetNumber == 0 {
+		return nil
+	}
+	first, last := chunk[0].Number.Uint64(), chunk[len(chunk)-1].Number.Uint64()
+	if d.syncTargetNumber < first || d.syncTargetNumber > last {
+		return nil
+	}
+	if header := chunk[d.syncTargetNumber-first]; header.Hash() != d.syncTarget {
+		return fmt.Errorf("%w: header %d hash %x", errSyncTargetMismatch, d.syncTargetNumber, header.Hash())
+	}
+	return nil
+}
+
+// fetchHeader requests a single header from a remote peer, returning nil if the
+// peer doesn't have it.
+func (d *Downloader) fetchHeader(p *peerConnection, request func() error) (*types.Header, error) {
+	go request()
+
+	ttl := d.peers.rates.TargetTimeout()
+	timeout := time.After(ttl)
+	for {
+		select {
+		case <-d.cancelCh:
+			return nil, errCanceled
+
+		case packet := <-d.headerCh:
+			// Discard anything not from the origin peer
+			if packet.PeerId() != p.id {
+				log.Debug("Received headers from incorrect peer", "peer", packet.PeerId())
+				break
+			}
+			headers := packet.(*headerPack).headers
+			if len(headers) > 1 {
+				return nil, fmt.Errorf("%w: returned headers %d != requested 1", errBadPeer, len(headers))
+			}
+			if len(headers) == 0 {
+				return nil, nil
+			}
+			return headers[0], nil
+
+		case <-timeout:
+			p.log.Debug("Waiting for header timed out", "elapsed", ttl)
+			return nil, errTimeout
+
+		case <-d.bodyCh:
+		case <-d.receiptCh:
+			// Out of bounds delivery, ignore
+		}
+	}
+}
+
 // calculateRequestSpan calculates what headers to request from a peer when trying to determine the
 // common ancestor.
 // It returns parameters to be used for peer.RequestHeadersByNumber:
@@ -1610,6 +1738,11 @@ func (d *Downloader) processHeaders(origin uint64, td *big.Int) error {
 				}
 				chunk := headers[:limit]
 
+				// Refuse the chain if it doesn't contain the sync target
+				if err := d.verifySyncTarget(chunk); err != nil {
+					rollbackErr = err
+					return err
+				}
 				// In case of header only syncing, validate the chunk immediately
 				if mode == FastSync || mode == LightSync {
 					// If we're importing pure headers, verify based on their recentness
@@ -1620,3 +1620,41 @@ func testCheckpointEnforcement(t *testing.T, protocol uint, mode SyncMode) {
 		assertOwnChain(t, tester, chain.len())
 	}
 }
+
+// Tests that peers serving a chain without the trusted sync target are refused,
+// before and after the target is imported.
+func TestSyncTarget66Full(t *testing.T)  { testSyncTarget(t, eth.ETH66, FullSync) }
+func TestSyncTarget66Fast(t *testing.T)  { testSyncTarget(t, eth.ETH66, FastSync) }
+func TestSyncTarget66Light(t *testing.T) { testSyncTarget(t, eth.ETH66, LightSync) }
+
+func testSyncTarget(t *testing.T, protocol uint, mode SyncMode) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	chainA := testChainForkLightA.shorten(testChainBase.len() + 80)
+	chainB := testChainForkLightB.shorten(testChainBase.len() + 80)
+	tester.newPeer("trusted", protocol, chainA)
+	tester.newPeer("fake", protocol, chainB)
+
+	tester.downloader.SetSyncTarget(chainA.chain[testChainBase.len()+10])
+
+	// The fake chain is refused while the target isn't imported yet
+	if err := tester.sync("fake", nil, mode); !errors.Is(err, errSyncTargetMismatch) {
+		t.Fatalf("fake sync error mismatch: have %v, want %v", err, errSyncTargetMismatch)
+	}
+	assertOwnChain(t, tester, 1)
+
+	// The chain containing the target is synced
+	if err := tester.sync("trusted", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, chainA.len())
+
+	// The fake chain forking off below the imported target is still refused
+	if err := tester.sync("fake", nil, mode); !errors.Is(err, errSyncTargetMismatch) {
+		t.Fatalf("fake sync error mismatch: have %v, want %v", err, errSyncTargetMismatch)
+	}
+	assertOwnChain(t, tester, chainA.len())
+}
@@ -18,6 +18,8 @@
 package ethconfig
 
 import (
+	"errors"
+	"fmt"
 	"math/big"
 	"os"
 	"os/user"
@@ -25,6 +27,7 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/external"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
@@ -32,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/fetcher"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
@@ -94,6 +98,8 @@ var Defaults = Config{
 	LightPeers:              100,
 	UltraLightFraction:      75,
 	DatabaseCache:           512,
+	BackupKeep:              2,
+	BlockMemoryLimit:        256,
 	TrieCleanCache:          154,
 	TrieCleanCacheJournal:   "triecache",
 	TrieCleanCacheRejournal: 60 * time.Minute,
@@ -105,11 +111,18 @@ var Defaults = Config{
 		GasPrice: big.NewInt(params.GWei),
 		Recommit: 3 * time.Second,
 	},
-	TxPool:        core.DefaultTxPoolConfig,
-	RPCGasCap:     50000000,
-	RPCEVMTimeout: 5 * time.Second,
-	GPO:           FullNodeGPO,
-	RPCTxFeeCap:   1, // 1 ether
+	TxPool:          core.DefaultTxPoolConfig,
+	TxFetcher:       fetcher.DefaultTxFetcherConfig,
+	ValidatorSigner: external.DefaultFailoverConfig,
+	RPCGasCap:       50000000,
+	RPCEVMTimeout:   5 * time.Second,
+	GPO:             FullNodeGPO,
+	RPCTxFeeCap:     1, // 1 ether
+
+	RPCResponseCacheTTL: 10 * time.Minute,
+
+	LatencyThreshold:  100 * time.Millisecond,
+	GovernorThreshold: time.Second,
 }
 
 func init() {
@@ -155,9 +168,21 @@ type Config struct {
 
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
 
+	// Number of recent blocks for which a pruned node retains garbage collected
+	// trie nodes, allowing it to serve state proofs of those blocks.
+	ProofWitnessDepth uint64 `toml:",omitempty"`
+
+	// Number of recent blocks for which the RPC state reads are served from the
+	// multi-version state cache.
+	RPCStateCacheDepth uint64 `toml:",omitempty"`
+
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
 
+	// Trusted block hash the synced chain must contain, refusing the peers serving
+	// a chain without it
+	SyncTarget common.Hash `toml:",omitempty"`
+
 	// Light client options
 	LightServ          int  `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightIngress       int  `toml:",omitempty"` // Incoming bandwidth limit for light servers
@@ -178,6 +203,22 @@ type Config struct {
 	DatabaseCache      int
 	DatabaseFreezer    string
 
+	// DatabaseCompression is the codec of the block bodies and receipts written into
+	// the key-value store (none, snappy or zstd), the existing ones being migrated
+	// offline by geth db compress.
+	DatabaseCompression string `toml:",omitempty"`
+
+	// DatabaseSlowQuery is the duration past which the database queries are logged
+	// as slow along with their call site, 0 disables the logging.
+	DatabaseSlowQuery time.Duration `toml:",omitempty"`
+
+	// Periodic point-in-time backups of the chain database, 0 interval disables
+	// them. The backups are written into BackupDir, the datadir's backups folder
+	// if empty, keeping the BackupKeep most recent ones.
+	BackupInterval time.Duration `toml:",omitempty"`
+	BackupDir      string        `toml:",omitempty"`
+	BackupKeep     int           `toml:",omitempty"`
+
 	TrieCleanCache          int
 	TrieCleanCacheJournal   string        `toml:",omitempty"` // Disk journal directory for trie cache to survive node restarts
 	TrieCleanCacheRejournal time.Duration `toml:",omitempty"` // Time interval to regenerate the journal for clean cache
@@ -186,6 +227,12 @@ type Config struct {
 	SnapshotCache           int
 	Preimages               bool
 
+	// Memory allowance (MB) of the logs of a block being imported, past which
+	// their data is spilled to disk, and memory limit (MB) of its receipts and
+	// logs, past which the import is aborted. Zero disables either.
+	BlockMemoryLimit int `toml:",omitempty"`
+	BlockMemoryCap   int `toml:",omitempty"`
+
 	// Mining options
 	Miner miner.Config
 
@@ -195,12 +242,58 @@ type Config struct {
 	// Transaction pool options
 	TxPool core.TxPoolConfig
 
+	// TxPrivacy makes the transactions submitted over RPC only sent to the private
+	// peers, as eth_sendPrivateTransaction does, instead of gossiped to all peers.
+	TxPrivacy bool `toml:",omitempty"`
+
+	// PrivateTxPeers are the enode URLs of the peers the private transactions are
+	// sent to, on top of the trusted peers.
+	PrivateTxPeers []string `toml:",omitempty"`
+
+	// Transaction announcement fetcher options
+	TxFetcher fetcher.TxFetcherConfig
+
+	// KnownTxsCacheSize is the size of the per-peer known transactions cache, 0 for default.
+	KnownTxsCacheSize int `toml:",omitempty"`
+
+	// ValidatorSigner is the remote signer of the congress validator, the local
+	// wallets are used if no endpoints are configured.
+	ValidatorSigner external.FailoverConfig
+
+	// StrictForkID drops the peers in the same fork state but scheduling a different
+	// next fork on handshake, e.g. the ones not upgraded for an upcoming hard fork.
+	StrictForkID bool `toml:",omitempty"`
+
+	// ValidatorPeers are the enode URLs of the peers labeled as validators, which
+	// blocks are propagated to first and which are exempt from the egress caps.
+	ValidatorPeers []string `toml:",omitempty"`
+
+	// EgressBlockCap and EgressTxCap are the per-peer bandwidth caps in KB/s of the
+	// block and transaction traffic sent to the ordinary peers, 0 for uncapped. The
+	// block propagations are never delayed, the chain sync replies make up for them.
+	EgressBlockCap uint64 `toml:",omitempty"`
+	EgressTxCap    uint64 `toml:",omitempty"`
+
+	// LatencyPeerRatio is the percentage of the peer slots preferably held by the
+	// peers with a ping round trip time under LatencyThreshold, the slowest peer
+	// being dropped once in a while below it. 0 disables the preference.
+	LatencyPeerRatio int           `toml:",omitempty"`
+	LatencyThreshold time.Duration `toml:",omitempty"`
+
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// EVMProfileWindow is the sampling window of the opcode and contract
+	// profiling of the EVM executions, zero disabling it.
+	EVMProfileWindow time.Duration `toml:",omitempty"`
+
+	// BlockSTMResearch enables the experimental Block-STM executor, which checks
+	// the processed blocks against the serial execution in background.
+	BlockSTMResearch bool `toml:",omitempty"`
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 
@@ -210,6 +303,36 @@ type Config struct {
 	// RPCEVMTimeout is the global timeout for eth-call.
 	RPCEVMTimeout time.Duration
 
+	// RPCEstimateGasCap is the gas cap for eth_estimateGas, RPCGasCap is used if it's 0.
+	RPCEstimateGasCap uint64
+
+	// RPCEstimateEVMTimeout is the timeout for a whole eth_estimateGas, 0 means no timeout.
+	RPCEstimateEVMTimeout time.Duration
+
+	// RPCTraceGasCap is the gas cap for debug_traceCall, RPCGasCap is used if it's 0.
+	RPCTraceGasCap uint64
+
+	// RPCTraceEVMTimeout is the default and also the maximum timeout for tracing
+	// a single transaction, the tracer's default is used if it's 0.
+	RPCTraceEVMTimeout time.Duration
+
+	// RPCHeavyCallLimit is the maximum number of concurrent heavy calls (traces and
+	// gas estimations), 0 means unlimited.
+	RPCHeavyCallLimit int
+
+	// RPCResponseCache is the maximum number of immutable rpc responses cached, i.e.
+	// blocks by hash, receipts and code of final blocks, 0 disables the cache.
+	RPCResponseCache int
+
+	// RPCResponseCacheTTL is the time a cached rpc response is kept for at most, 0
+	// keeps it until evicted.
+	RPCResponseCacheTTL time.Duration
+
+	// Governor throttles the heavy calls and the log queries, running them one at
+	// a time, while the block import latency exceeds GovernorThreshold.
+	Governor          bool
+	GovernorThreshold time.Duration
+
 	// RPCTxFeeCap is the global transaction fee(price * gaslimit) cap for
 	// send-transction variants. The unit is ether.
 	RPCTxFeeCap float64
@@ -222,17 +345,57 @@ type Config struct {
 
 	// Arrow Glacier block override (TODO: remove after the fork)
 	OverrideArrowGlacier *big.Int `toml:",omitempty"`
+
+	// Congress fork block overrides, refused on mainnet
+	OverrideRedCoast   *big.Int `toml:",omitempty"`
+	OverrideSophon     *big.Int `toml:",omitempty"`
+	OverrideProxyCheck *big.Int `toml:",omitempty"`
+	OverrideTaurus     *big.Int `toml:",omitempty"`
+
+	// OverrideGenesisCheck skips the check of the datadir genesis against the
+	// configured network, running with the datadir's genesis instead.
+	OverrideGenesisCheck bool `toml:",omitempty"`
+
+	// CongressEpochCheck is the mode of cross-checking the checkpoint validators
+	// against the contract state on header import: off, log or halt.
+	CongressEpochCheck string `toml:",omitempty"`
+
+	// CongressSysCodeCheck is the mode of verifying the system contract code
+	// against the versions bundled with the binary: off, warn or halt.
+	CongressSysCodeCheck string `toml:",omitempty"`
+
+	// CongressPreannounce sends the blocks sealed out of turn to the trusted peers
+	// while they wait out their wiggle delay.
+	CongressPreannounce bool `toml:",omitempty"`
+
+	// CongressMaxParentAge is the maximum age, against the wall clock, of the parent
+	// of the blocks to seal, refusing to extend a stale chain unless the connected
+	// peers report no heavier head, 0 for unlimited.
+	CongressMaxParentAge time.Duration `toml:",omitempty"`
+
+	// CongressVanity tags the vanity prefix of the sealed blocks with the client
+	// version, followed by the operator tag CongressVanityTag if set.
+	CongressVanity    bool   `toml:",omitempty"`
+	CongressVanityTag string `toml:",omitempty"`
+
+	// CongressPunishWebhook is the endpoint notified of the validator punishments.
+	CongressPunishWebhook *congress.PunishWebhookConfig `toml:",omitempty"`
+
+	// CongressBlacklistWatch is the expected blacklist the on-chain one is watched
+	// against, alarming on divergence.
+	CongressBlacklistWatch *congress.BlacklistWatchConfig `toml:",omitempty"`
 }
 
-// CreateConsensusEngine creates a consensus engine for the given chain configuration.
-func CreateConsensusEngine(stack *node.Node, chainConfig *params.ChainConfig, config *ethash.Config, notify []string, noverify bool, db ethdb.Database) consensus.Engine {
+// CreateConsensusEngine creates a consensus engine for the given chain configuration,
+// the congress one running on the given chain.
+func CreateConsensusEngine(stack *node.Node, chainConfig *params.ChainConfig, config *ethash.Config, notify []string, noverify bool, db ethdb.Database, chain congress.ChainBackend) consensus.Engine {
 	// If proof-of-authority is requested, set it up
 	if chainConfig.Clique != nil {
 		return clique.New(chainConfig.Clique, db)
 	}
 	// If proof-of-stake-authority is requested, set it up
 	if chainConfig.Congress != nil {
-		return congress.New(chainConfig, db)
+		return congress.New(chainConfig, db, chain)
 	}
 	// Otherwise assume proof-of-work
 	switch config.PowMode {
@@ -258,3 +421,43 @@ func CreateConsensusEngine(stack *node.Node, chainConfig *params.ChainConfig, co
 	engine.SetThreads(-1) // Disable CPU mining
 	return engine
 }
+
+// ChainOverrides returns the configured changes to the chain configuration.
+func (c *Config) ChainOverrides() *core.ChainOverrides {
+	return &core.ChainOverrides{
+		ArrowGlacier: c.OverrideArrowGlacier,
+		RedCoast:     c.OverrideRedCoast,
+		Sophon:       c.OverrideSophon,
+		ProxyCheck:   c.OverrideProxyCheck,
+		Taurus:       c.OverrideTaurus,
+	}
+}
+
+// Validate checks the options of the subsystems only configurable through the
+// configuration file, which are otherwise silently sanitized at startup.
+func (c *Config) Validate() error {
+	if err := c.TxPool.JamConfig.Validate(); err != nil {
+		return fmt.Errorf("Eth.TxPool.JamConfig: %v", err)
+	}
+	if err := c.GPO.PredConfig.Validate(); err != nil {
+		return fmt.Errorf("Eth.GPO: %v", err)
+	}
+	if _, err := congress.ParseEpochCheckMode(c.CongressEpochCheck); err != nil {
+		return fmt.Errorf("Eth.CongressEpochCheck: %v", err)
+	}
+	if _, err := congress.ParseSysCodeCheckMode(c.CongressSysCodeCheck); err != nil {
+		return fmt.Errorf("Eth.CongressSysCodeCheck: %v", err)
+	}
+	if c.CongressVanity {
+		if _, err := congress.VanityTag(params.Version, c.CongressVanityTag); err != nil {
+			return fmt.Errorf("Eth.CongressVanityTag: %v", err)
+		}
+	}
+	if c.CongressPunishWebhook != nil && c.CongressPunishWebhook.URL == "" {
+		return errors.New("Eth.CongressPunishWebhook: URL missing")
+	}
+	if c.CongressBlacklistWatch != nil && c.CongressBlacklistWatch.File == "" {
+		return errors.New("Eth.CongressBlacklistWatch: File missing")
+	}
+	return nil
+}
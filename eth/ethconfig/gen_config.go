@@ -6,10 +6,13 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/external"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/fetcher"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/miner"
 	"github.com/ethereum/go-ethereum/params"
@@ -26,7 +29,10 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		NoPruning               bool
 		NoPrefetch              bool
 		TxLookupLimit           uint64                 `toml:",omitempty"`
+		ProofWitnessDepth       uint64                 `toml:",omitempty"`
+		RPCStateCacheDepth      uint64                 `toml:",omitempty"`
 		Whitelist               map[uint64]common.Hash `toml:"-"`
+		SyncTarget              common.Hash            `toml:",omitempty"`
 		LightServ               int                    `toml:",omitempty"`
 		LightIngress            int                    `toml:",omitempty"`
 		LightEgress             int                    `toml:",omitempty"`
@@ -41,6 +47,11 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		DatabaseHandles         int                    `toml:"-"`
 		DatabaseCache           int
 		DatabaseFreezer         string
+		DatabaseCompression     string        `toml:",omitempty"`
+		DatabaseSlowQuery       time.Duration `toml:",omitempty"`
+		BackupInterval          time.Duration `toml:",omitempty"`
+		BackupDir               string        `toml:",omitempty"`
+		BackupKeep              int           `toml:",omitempty"`
 		TrieCleanCache          int
 		TrieCleanCacheJournal   string        `toml:",omitempty"`
 		TrieCleanCacheRejournal time.Duration `toml:",omitempty"`
@@ -48,18 +59,55 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		TrieTimeout             time.Duration
 		SnapshotCache           int
 		Preimages               bool
+		BlockMemoryLimit        int `toml:",omitempty"`
+		BlockMemoryCap          int `toml:",omitempty"`
 		Miner                   miner.Config
 		Ethash                  ethash.Config
 		TxPool                  core.TxPoolConfig
+		TxPrivacy               bool     `toml:",omitempty"`
+		PrivateTxPeers          []string `toml:",omitempty"`
+		TxFetcher               fetcher.TxFetcherConfig
+		KnownTxsCacheSize       int `toml:",omitempty"`
+		ValidatorSigner         external.FailoverConfig
+		StrictForkID            bool          `toml:",omitempty"`
+		ValidatorPeers          []string      `toml:",omitempty"`
+		EgressBlockCap          uint64        `toml:",omitempty"`
+		EgressTxCap             uint64        `toml:",omitempty"`
+		LatencyPeerRatio        int           `toml:",omitempty"`
+		LatencyThreshold        time.Duration `toml:",omitempty"`
 		GPO                     gasprice.Config
 		EnablePreimageRecording bool
-		DocRoot                 string `toml:"-"`
+		EVMProfileWindow        time.Duration `toml:",omitempty"`
+		BlockSTMResearch        bool          `toml:",omitempty"`
+		DocRoot                 string        `toml:"-"`
 		RPCGasCap               uint64
 		RPCEVMTimeout           time.Duration
+		RPCEstimateGasCap       uint64
+		RPCEstimateEVMTimeout   time.Duration
+		RPCTraceGasCap          uint64
+		RPCTraceEVMTimeout      time.Duration
+		RPCHeavyCallLimit       int
+		RPCResponseCache        int
+		RPCResponseCacheTTL     time.Duration
+		Governor                bool
+		GovernorThreshold       time.Duration
 		RPCTxFeeCap             float64
 		Checkpoint              *params.TrustedCheckpoint      `toml:",omitempty"`
 		CheckpointOracle        *params.CheckpointOracleConfig `toml:",omitempty"`
 		OverrideArrowGlacier    *big.Int                       `toml:",omitempty"`
+		OverrideRedCoast        *big.Int                       `toml:",omitempty"`
+		OverrideSophon          *big.Int                       `toml:",omitempty"`
+		OverrideProxyCheck      *big.Int                       `toml:",omitempty"`
+		OverrideTaurus          *big.Int                       `toml:",omitempty"`
+		OverrideGenesisCheck    bool                           `toml:",omitempty"`
+		CongressEpochCheck      string                         `toml:",omitempty"`
+		CongressSysCodeCheck    string                         `toml:",omitempty"`
+		CongressPreannounce     bool                           `toml:",omitempty"`
+		CongressMaxParentAge    time.Duration                  `toml:",omitempty"`
+		CongressVanity          bool                           `toml:",omitempty"`
+		CongressVanityTag       string                         `toml:",omitempty"`
+		CongressPunishWebhook   *congress.PunishWebhookConfig  `toml:",omitempty"`
+		CongressBlacklistWatch  *congress.BlacklistWatchConfig `toml:",omitempty"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -70,7 +118,10 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
 	enc.TxLookupLimit = c.TxLookupLimit
+	enc.ProofWitnessDepth = c.ProofWitnessDepth
+	enc.RPCStateCacheDepth = c.RPCStateCacheDepth
 	enc.Whitelist = c.Whitelist
+	enc.SyncTarget = c.SyncTarget
 	enc.LightServ = c.LightServ
 	enc.LightIngress = c.LightIngress
 	enc.LightEgress = c.LightEgress
@@ -85,6 +136,11 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
 	enc.DatabaseFreezer = c.DatabaseFreezer
+	enc.DatabaseCompression = c.DatabaseCompression
+	enc.DatabaseSlowQuery = c.DatabaseSlowQuery
+	enc.BackupInterval = c.BackupInterval
+	enc.BackupDir = c.BackupDir
+	enc.BackupKeep = c.BackupKeep
 	enc.TrieCleanCache = c.TrieCleanCache
 	enc.TrieCleanCacheJournal = c.TrieCleanCacheJournal
 	enc.TrieCleanCacheRejournal = c.TrieCleanCacheRejournal
@@ -92,18 +148,55 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.TrieTimeout = c.TrieTimeout
 	enc.SnapshotCache = c.SnapshotCache
 	enc.Preimages = c.Preimages
+	enc.BlockMemoryLimit = c.BlockMemoryLimit
+	enc.BlockMemoryCap = c.BlockMemoryCap
 	enc.Miner = c.Miner
 	enc.Ethash = c.Ethash
 	enc.TxPool = c.TxPool
+	enc.TxPrivacy = c.TxPrivacy
+	enc.PrivateTxPeers = c.PrivateTxPeers
+	enc.TxFetcher = c.TxFetcher
+	enc.KnownTxsCacheSize = c.KnownTxsCacheSize
+	enc.ValidatorSigner = c.ValidatorSigner
+	enc.StrictForkID = c.StrictForkID
+	enc.ValidatorPeers = c.ValidatorPeers
+	enc.EgressBlockCap = c.EgressBlockCap
+	enc.EgressTxCap = c.EgressTxCap
+	enc.LatencyPeerRatio = c.LatencyPeerRatio
+	enc.LatencyThreshold = c.LatencyThreshold
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
+	enc.EVMProfileWindow = c.EVMProfileWindow
+	enc.BlockSTMResearch = c.BlockSTMResearch
 	enc.DocRoot = c.DocRoot
 	enc.RPCGasCap = c.RPCGasCap
 	enc.RPCEVMTimeout = c.RPCEVMTimeout
+	enc.RPCEstimateGasCap = c.RPCEstimateGasCap
+	enc.RPCEstimateEVMTimeout = c.RPCEstimateEVMTimeout
+	enc.RPCTraceGasCap = c.RPCTraceGasCap
+	enc.RPCTraceEVMTimeout = c.RPCTraceEVMTimeout
+	enc.RPCHeavyCallLimit = c.RPCHeavyCallLimit
+	enc.RPCResponseCache = c.RPCResponseCache
+	enc.RPCResponseCacheTTL = c.RPCResponseCacheTTL
+	enc.Governor = c.Governor
+	enc.GovernorThreshold = c.GovernorThreshold
 	enc.RPCTxFeeCap = c.RPCTxFeeCap
 	enc.Checkpoint = c.Checkpoint
 	enc.CheckpointOracle = c.CheckpointOracle
 	enc.OverrideArrowGlacier = c.OverrideArrowGlacier
+	enc.OverrideRedCoast = c.OverrideRedCoast
+	enc.OverrideSophon = c.OverrideSophon
+	enc.OverrideProxyCheck = c.OverrideProxyCheck
+	enc.OverrideTaurus = c.OverrideTaurus
+	enc.OverrideGenesisCheck = c.OverrideGenesisCheck
+	enc.CongressEpochCheck = c.CongressEpochCheck
+	enc.CongressSysCodeCheck = c.CongressSysCodeCheck
+	enc.CongressPreannounce = c.CongressPreannounce
+	enc.CongressMaxParentAge = c.CongressMaxParentAge
+	enc.CongressVanity = c.CongressVanity
+	enc.CongressVanityTag = c.CongressVanityTag
+	enc.CongressPunishWebhook = c.CongressPunishWebhook
+	enc.CongressBlacklistWatch = c.CongressBlacklistWatch
 	return &enc, nil
 }
 
@@ -118,7 +211,10 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		NoPruning               *bool
 		NoPrefetch              *bool
 		TxLookupLimit           *uint64                `toml:",omitempty"`
+		ProofWitnessDepth       *uint64                `toml:",omitempty"`
+		RPCStateCacheDepth      *uint64                `toml:",omitempty"`
 		Whitelist               map[uint64]common.Hash `toml:"-"`
+		SyncTarget              *common.Hash           `toml:",omitempty"`
 		LightServ               *int                   `toml:",omitempty"`
 		LightIngress            *int                   `toml:",omitempty"`
 		LightEgress             *int                   `toml:",omitempty"`
@@ -133,6 +229,11 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		DatabaseHandles         *int                   `toml:"-"`
 		DatabaseCache           *int
 		DatabaseFreezer         *string
+		DatabaseCompression     *string        `toml:",omitempty"`
+		DatabaseSlowQuery       *time.Duration `toml:",omitempty"`
+		BackupInterval          *time.Duration `toml:",omitempty"`
+		BackupDir               *string        `toml:",omitempty"`
+		BackupKeep              *int           `toml:",omitempty"`
 		TrieCleanCache          *int
 		TrieCleanCacheJournal   *string        `toml:",omitempty"`
 		TrieCleanCacheRejournal *time.Duration `toml:",omitempty"`
@@ -140,18 +241,55 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		TrieTimeout             *time.Duration
 		SnapshotCache           *int
 		Preimages               *bool
+		BlockMemoryLimit        *int `toml:",omitempty"`
+		BlockMemoryCap          *int `toml:",omitempty"`
 		Miner                   *miner.Config
 		Ethash                  *ethash.Config
 		TxPool                  *core.TxPoolConfig
+		TxPrivacy               *bool    `toml:",omitempty"`
+		PrivateTxPeers          []string `toml:",omitempty"`
+		TxFetcher               *fetcher.TxFetcherConfig
+		KnownTxsCacheSize       *int `toml:",omitempty"`
+		ValidatorSigner         *external.FailoverConfig
+		StrictForkID            *bool          `toml:",omitempty"`
+		ValidatorPeers          []string       `toml:",omitempty"`
+		EgressBlockCap          *uint64        `toml:",omitempty"`
+		EgressTxCap             *uint64        `toml:",omitempty"`
+		LatencyPeerRatio        *int           `toml:",omitempty"`
+		LatencyThreshold        *time.Duration `toml:",omitempty"`
 		GPO                     *gasprice.Config
 		EnablePreimageRecording *bool
-		DocRoot                 *string `toml:"-"`
+		EVMProfileWindow        *time.Duration `toml:",omitempty"`
+		BlockSTMResearch        *bool          `toml:",omitempty"`
+		DocRoot                 *string        `toml:"-"`
 		RPCGasCap               *uint64
 		RPCEVMTimeout           *time.Duration
+		RPCEstimateGasCap       *uint64
+		RPCEstimateEVMTimeout   *time.Duration
+		RPCTraceGasCap          *uint64
+		RPCTraceEVMTimeout      *time.Duration
+		RPCHeavyCallLimit       *int
+		RPCResponseCache        *int
+		RPCResponseCacheTTL     *time.Duration
+		Governor                *bool
+		GovernorThreshold       *time.Duration
 		RPCTxFeeCap             *float64
 		Checkpoint              *params.TrustedCheckpoint      `toml:",omitempty"`
 		CheckpointOracle        *params.CheckpointOracleConfig `toml:",omitempty"`
 		OverrideArrowGlacier    *big.Int                       `toml:",omitempty"`
+		OverrideRedCoast        *big.Int                       `toml:",omitempty"`
+		OverrideSophon          *big.Int                       `toml:",omitempty"`
+		OverrideProxyCheck      *big.Int                       `toml:",omitempty"`
+		OverrideTaurus          *big.Int                       `toml:",omitempty"`
+		OverrideGenesisCheck    *bool                          `toml:",omitempty"`
+		CongressEpochCheck      *string                        `toml:",omitempty"`
+		CongressSysCodeCheck    *string                        `toml:",omitempty"`
+		CongressPreannounce     *bool                          `toml:",omitempty"`
+		CongressMaxParentAge    *time.Duration                 `toml:",omitempty"`
+		CongressVanity          *bool                          `toml:",omitempty"`
+		CongressVanityTag       *string                        `toml:",omitempty"`
+		CongressPunishWebhook   *congress.PunishWebhookConfig  `toml:",omitempty"`
+		CongressBlacklistWatch  *congress.BlacklistWatchConfig `toml:",omitempty"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -181,9 +319,18 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TxLookupLimit != nil {
 		c.TxLookupLimit = *dec.TxLookupLimit
 	}
+	if dec.ProofWitnessDepth != nil {
+		c.ProofWitnessDepth = *dec.ProofWitnessDepth
+	}
+	if dec.RPCStateCacheDepth != nil {
+		c.RPCStateCacheDepth = *dec.RPCStateCacheDepth
+	}
 	if dec.Whitelist != nil {
 		c.Whitelist = dec.Whitelist
 	}
+	if dec.SyncTarget != nil {
+		c.SyncTarget = *dec.SyncTarget
+	}
 	if dec.LightServ != nil {
 		c.LightServ = *dec.LightServ
 	}
@@ -226,6 +373,21 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DatabaseFreezer != nil {
 		c.DatabaseFreezer = *dec.DatabaseFreezer
 	}
+	if dec.DatabaseCompression != nil {
+		c.DatabaseCompression = *dec.DatabaseCompression
+	}
+	if dec.DatabaseSlowQuery != nil {
+		c.DatabaseSlowQuery = *dec.DatabaseSlowQuery
+	}
+	if dec.BackupInterval != nil {
+		c.BackupInterval = *dec.BackupInterval
+	}
+	if dec.BackupDir != nil {
+		c.BackupDir = *dec.BackupDir
+	}
+	if dec.BackupKeep != nil {
+		c.BackupKeep = *dec.BackupKeep
+	}
 	if dec.TrieCleanCache != nil {
 		c.TrieCleanCache = *dec.TrieCleanCache
 	}
@@ -247,6 +409,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.Preimages != nil {
 		c.Preimages = *dec.Preimages
 	}
+	if dec.BlockMemoryLimit != nil {
+		c.BlockMemoryLimit = *dec.BlockMemoryLimit
+	}
+	if dec.BlockMemoryCap != nil {
+		c.BlockMemoryCap = *dec.BlockMemoryCap
+	}
 	if dec.Miner != nil {
 		c.Miner = *dec.Miner
 	}
@@ -256,12 +424,51 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TxPool != nil {
 		c.TxPool = *dec.TxPool
 	}
+	if dec.TxPrivacy != nil {
+		c.TxPrivacy = *dec.TxPrivacy
+	}
+	if dec.PrivateTxPeers != nil {
+		c.PrivateTxPeers = dec.PrivateTxPeers
+	}
+	if dec.TxFetcher != nil {
+		c.TxFetcher = *dec.TxFetcher
+	}
+	if dec.KnownTxsCacheSize != nil {
+		c.KnownTxsCacheSize = *dec.KnownTxsCacheSize
+	}
+	if dec.ValidatorSigner != nil {
+		c.ValidatorSigner = *dec.ValidatorSigner
+	}
+	if dec.StrictForkID != nil {
+		c.StrictForkID = *dec.StrictForkID
+	}
+	if dec.ValidatorPeers != nil {
+		c.ValidatorPeers = dec.ValidatorPeers
+	}
+	if dec.EgressBlockCap != nil {
+		c.EgressBlockCap = *dec.EgressBlockCap
+	}
+	if dec.EgressTxCap != nil {
+		c.EgressTxCap = *dec.EgressTxCap
+	}
+	if dec.LatencyPeerRatio != nil {
+		c.LatencyPeerRatio = *dec.LatencyPeerRatio
+	}
+	if dec.LatencyThreshold != nil {
+		c.LatencyThreshold = *dec.LatencyThreshold
+	}
 	if dec.GPO != nil {
 		c.GPO = *dec.GPO
 	}
 	if dec.EnablePreimageRecording != nil {
 		c.EnablePreimageRecording = *dec.EnablePreimageRecording
 	}
+	if dec.EVMProfileWindow != nil {
+		c.EVMProfileWindow = *dec.EVMProfileWindow
+	}
+	if dec.BlockSTMResearch != nil {
+		c.BlockSTMResearch = *dec.BlockSTMResearch
+	}
 	if dec.DocRoot != nil {
 		c.DocRoot = *dec.DocRoot
 	}
@@ -271,6 +478,33 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.RPCEVMTimeout != nil {
 		c.RPCEVMTimeout = *dec.RPCEVMTimeout
 	}
+	if dec.RPCEstimateGasCap != nil {
+		c.RPCEstimateGasCap = *dec.RPCEstimateGasCap
+	}
+	if dec.RPCEstimateEVMTimeout != nil {
+		c.RPCEstimateEVMTimeout = *dec.RPCEstimateEVMTimeout
+	}
+	if dec.RPCTraceGasCap != nil {
+		c.RPCTraceGasCap = *dec.RPCTraceGasCap
+	}
+	if dec.RPCTraceEVMTimeout != nil {
+		c.RPCTraceEVMTimeout = *dec.RPCTraceEVMTimeout
+	}
+	if dec.RPCHeavyCallLimit != nil {
+		c.RPCHeavyCallLimit = *dec.RPCHeavyCallLimit
+	}
+	if dec.RPCResponseCache != nil {
+		c.RPCResponseCache = *dec.RPCResponseCache
+	}
+	if dec.RPCResponseCacheTTL != nil {
+		c.RPCResponseCacheTTL = *dec.RPCResponseCacheTTL
+	}
+	if dec.Governor != nil {
+		c.Governor = *dec.Governor
+	}
+	if dec.GovernorThreshold != nil {
+		c.GovernorThreshold = *dec.GovernorThreshold
+	}
 	if dec.RPCTxFeeCap != nil {
 		c.RPCTxFeeCap = *dec.RPCTxFeeCap
 	}
@@ -283,5 +517,44 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.OverrideArrowGlacier != nil {
 		c.OverrideArrowGlacier = dec.OverrideArrowGlacier
 	}
+	if dec.OverrideRedCoast != nil {
+		c.OverrideRedCoast = dec.OverrideRedCoast
+	}
+	if dec.OverrideSophon != nil {
+		c.OverrideSophon = dec.OverrideSophon
+	}
+	if dec.OverrideProxyCheck != nil {
+		c.OverrideProxyCheck = dec.OverrideProxyCheck
+	}
+	if dec.OverrideTaurus != nil {
+		c.OverrideTaurus = dec.OverrideTaurus
+	}
+	if dec.OverrideGenesisCheck != nil {
+		c.OverrideGenesisCheck = *dec.OverrideGenesisCheck
+	}
+	if dec.CongressEpochCheck != nil {
+		c.CongressEpochCheck = *dec.CongressEpochCheck
+	}
+	if dec.CongressSysCodeCheck != nil {
+		c.CongressSysCodeCheck = *dec.CongressSysCodeCheck
+	}
+	if dec.CongressPreannounce != nil {
+		c.CongressPreannounce = *dec.CongressPreannounce
+	}
+	if dec.CongressMaxParentAge != nil {
+		c.CongressMaxParentAge = *dec.CongressMaxParentAge
+	}
+	if dec.CongressVanity != nil {
+		c.CongressVanity = *dec.CongressVanity
+	}
+	if dec.CongressVanityTag != nil {
+		c.CongressVanityTag = *dec.CongressVanityTag
+	}
+	if dec.CongressPunishWebhook != nil {
+		c.CongressPunishWebhook = dec.CongressPunishWebhook
+	}
+	if dec.CongressBlacklistWatch != nil {
+		c.CongressBlacklistWatch = dec.CongressBlacklistWatch
+	}
 	return nil
 }
@@ -66,6 +66,11 @@ var (
 	// txFetchTimeout is the maximum allotted time to return an explicitly
 	// requested transaction.
 	txFetchTimeout = 5 * time.Second
+
+	// txFetchBackoffMax is the maximum time a peer timing out on consecutive
+	// requests is excluded from the retrievals. The backoff starts at the fetch
+	// timeout and doubles with every further timeout.
+	txFetchBackoffMax = time.Minute
 )
 
 var (
@@ -89,12 +94,18 @@ var (
 	txReplyUnderpricedMeter = metrics.NewRegisteredMeter("eth/fetcher/transaction/replies/underpriced", nil)
 	txReplyOtherRejectMeter = metrics.NewRegisteredMeter("eth/fetcher/transaction/replies/otherreject", nil)
 
+	txHoleInMeter       = metrics.NewRegisteredMeter("eth/fetcher/transaction/holes/in", nil)
+	txHoleWaitingMeter  = metrics.NewRegisteredMeter("eth/fetcher/transaction/holes/waiting", nil)
+	txHoleFetchingMeter = metrics.NewRegisteredMeter("eth/fetcher/transaction/holes/fetching", nil)
+
 	txFetcherWaitingPeers   = metrics.NewRegisteredGauge("eth/fetcher/transaction/waiting/peers", nil)
 	txFetcherWaitingHashes  = metrics.NewRegisteredGauge("eth/fetcher/transaction/waiting/hashes", nil)
 	txFetcherQueueingPeers  = metrics.NewRegisteredGauge("eth/fetcher/transaction/queueing/peers", nil)
 	txFetcherQueueingHashes = metrics.NewRegisteredGauge("eth/fetcher/transaction/queueing/hashes", nil)
 	txFetcherFetchingPeers  = metrics.NewRegisteredGauge("eth/fetcher/transaction/fetching/peers", nil)
 	txFetcherFetchingHashes = metrics.NewRegisteredGauge("eth/fetcher/transaction/fetching/hashes", nil)
+	txFetcherPriorityHashes = metrics.NewRegisteredGauge("eth/fetcher/transaction/priority/hashes", nil)
+	txFetcherBackoffPeers   = metrics.NewRegisteredGauge("eth/fetcher/transaction/backoff/peers", nil)
 )
 
 // txAnnounce is the notification of the availability of a batch
@@ -107,9 +118,10 @@ type txAnnounce struct {
 // txRequest represents an in-flight transaction retrieval request destined to
 // a specific peers.
 type txRequest struct {
-	hashes []common.Hash            // Transactions having been requested
-	stolen map[common.Hash]struct{} // Deliveries by someone else (don't re-request)
-	time   mclock.AbsTime           // Timestamp of the request
+	hashes  []common.Hash            // Transactions having been requested
+	stolen  map[common.Hash]struct{} // Deliveries by someone else (don't re-request)
+	time    mclock.AbsTime           // Timestamp of the request
+	release mclock.AbsTime           // Time when a timed out request is abandoned
 }
 
 // txDelivery is the notification that a batch of transactions have been added
@@ -142,13 +154,20 @@ type txDrop struct {
 //   - Each peer that announced transactions may be scheduled retrievals, but
 //     only ever one concurrently. This ensures we can immediately know what is
 //     missing from a reply and reschedule it.
+//
+// Transactions referenced by the blocks received from the network, but missing
+// from the pool while announced (holes), skip the waiting list and are fetched
+// ahead of the others.
 type TxFetcher struct {
-	notify  chan *txAnnounce
-	cleanup chan *txDelivery
-	drop    chan *txDrop
-	quit    chan struct{}
+	notify    chan *txAnnounce
+	reconcile chan *txAnnounce
+	cleanup   chan *txDelivery
+	drop      chan *txDrop
+	quit      chan struct{}
 
-	underpriced mapset.Set // Transactions discarded as too cheap (don't re-fetch)
+	cfg         TxFetcherConfig // Tunable limits of the fetcher
+	underpriced mapset.Set      // Transactions discarded as too cheap (don't re-fetch)
+	peers       *txPeerTracker  // Per-peer throughput statistics and flood protection
 
 	// Stage 1: Waiting lists for newly discovered transactions that might be
 	// broadcast without needing explicit request/reply round trips.
@@ -168,6 +187,9 @@ type TxFetcher struct {
 	requests   map[string]*txRequest               // In-flight transaction retrievals
 	alternates map[common.Hash]map[string]struct{} // In-flight transaction alternate origins if retrieval fails
 
+	priority map[common.Hash]struct{} // Queued or fetching transactions referenced by blocks (holes)
+	backoffs map[string]time.Duration // Current backoff of the peers timing out on requests
+
 	// Callbacks
 	hasTx    func(common.Hash) bool             // Retrieves a tx from the local txpool
 	addTxs   func([]*types.Transaction) []error // Insert a batch of transactions into local txpool
@@ -181,7 +203,15 @@ type TxFetcher struct {
 // NewTxFetcher creates a transaction fetcher to retrieve transaction
 // based on hash announcements.
 func NewTxFetcher(hasTx func(common.Hash) bool, addTxs func([]*types.Transaction) []error, fetchTxs func(string, []common.Hash) error) *TxFetcher {
-	return NewTxFetcherForTests(hasTx, addTxs, fetchTxs, mclock.System{}, nil)
+	return NewTxFetcherWithConfig(DefaultTxFetcherConfig, hasTx, addTxs, fetchTxs)
+}
+
+// NewTxFetcherWithConfig creates a transaction fetcher with the given limits.
+func NewTxFetcherWithConfig(cfg TxFetcherConfig, hasTx func(common.Hash) bool, addTxs func([]*types.Transaction) []error, fetchTxs func(string, []common.Hash) error) *TxFetcher {
+	f := NewTxFetcherForTests(hasTx, addTxs, fetchTxs, mclock.System{}, nil)
+	f.cfg = cfg.sanitize()
+	f.peers = newTxPeerTracker(f.cfg, f.clock)
+	return f
 }
 
 // NewTxFetcherForTests is a testing method to mock out the realtime clock with
@@ -191,6 +221,7 @@ func NewTxFetcherForTests(
 	clock mclock.Clock, rand *mrand.Rand) *TxFetcher {
 	return &TxFetcher{
 		notify:      make(chan *txAnnounce),
+		reconcile:   make(chan *txAnnounce),
 		cleanup:     make(chan *txDelivery),
 		drop:        make(chan *txDrop),
 		quit:        make(chan struct{}),
@@ -202,7 +233,11 @@ func NewTxFetcherForTests(
 		fetching:    make(map[common.Hash]string),
 		requests:    make(map[string]*txRequest),
 		alternates:  make(map[common.Hash]map[string]struct{}),
+		priority:    make(map[common.Hash]struct{}),
+		backoffs:    make(map[string]time.Duration),
+		cfg:         DefaultTxFetcherConfig,
 		underpriced: mapset.NewSet(),
+		peers:       newTxPeerTracker(DefaultTxFetcherConfig, clock),
 		hasTx:       hasTx,
 		addTxs:      addTxs,
 		fetchTxs:    fetchTxs,
@@ -241,6 +276,12 @@ func (f *TxFetcher) Notify(peer string, hashes []common.Hash) error {
 	txAnnounceKnownMeter.Mark(duplicate)
 	txAnnounceUnderpricedMeter.Mark(underpriced)
 
+	// Drop the whole announcement if the peer keeps flooding us with transactions
+	// already proven underpriced.
+	if !f.peers.announced(peer, len(hashes), int(duplicate), int(underpriced)) {
+		return nil
+	}
+
 	// If anything's left to announce, push it into the internal loop
 	if len(unknowns) == 0 {
 		return nil
@@ -257,6 +298,28 @@ func (f *TxFetcher) Notify(peer string, hashes []common.Hash) error {
 	}
 }
 
+// Reconcile notifies the fetcher of the transactions referenced by a block received
+// from the peer. The ones missing from the pool, but announced and not retrieved
+// yet, are holes left by the announcements outpacing the retrievals: they are
+// fetched ahead of any other transaction, from the peer too.
+func (f *TxFetcher) Reconcile(peer string, hashes []common.Hash) error {
+	missing := make([]common.Hash, 0, len(hashes))
+	for _, hash := range hashes {
+		if !f.hasTx(hash) && !f.underpriced.Contains(hash) {
+			missing = append(missing, hash)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	select {
+	case f.reconcile <- &txAnnounce{origin: peer, hashes: missing}:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
 // Enqueue imports a batch of received transaction into the transaction pool
 // and the fetcher. This method may be called by both transaction broadcasts and
 // direct request replies. The differentiation is important so the fetcher can
@@ -275,6 +338,7 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 		duplicate   int64
 		underpriced int64
 		otherreject int64
+		accepted    int
 	)
 	errs := f.addTxs(txs)
 	for i, err := range errs {
@@ -282,14 +346,15 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 		// Avoid re-request this transaction when we receive another
 		// announcement.
 		if errors.Is(err, core.ErrUnderpriced) || errors.Is(err, core.ErrReplaceUnderpriced) {
-			for f.underpriced.Cardinality() >= maxTxUnderpricedSetSize {
+			for f.underpriced.Cardinality() >= f.cfg.UnderpricedSetSize {
 				f.underpriced.Pop()
 			}
 			f.underpriced.Add(txs[i].Hash())
 		}
 		// Track a few interesting failure types
 		switch {
-		case err == nil: // Noop, but need to handle to not count these
+		case err == nil:
+			accepted++
 
 		case errors.Is(err, core.ErrAlreadyKnown):
 			duplicate++
@@ -311,6 +376,8 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 		txBroadcastUnderpricedMeter.Mark(underpriced)
 		txBroadcastOtherRejectMeter.Mark(otherreject)
 	}
+	f.peers.delivered(peer, len(txs), accepted, int(duplicate), int(underpriced))
+
 	select {
 	case f.cleanup <- &txDelivery{origin: peer, hashes: added, direct: direct}:
 		return nil
@@ -322,6 +389,8 @@ func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool)
 // Drop should be called when a peer disconnects. It cleans up all the internal
 // data structures of the given node.
 func (f *TxFetcher) Drop(peer string) error {
+	f.peers.drop(peer)
+
 	select {
 	case f.drop <- &txDrop{peer: peer}:
 		return nil
@@ -330,6 +399,12 @@ func (f *TxFetcher) Drop(peer string) error {
 	}
 }
 
+// PeerStats returns the transaction throughput statistics of the peer, or nil if
+// the peer is unknown.
+func (f *TxFetcher) PeerStats(peer string) *TxPeerStats {
+	return f.peers.stats(peer)
+}
+
 // Start boots up the announcement based synchroniser, accepting and processing
 // hash notifications and block fetches until termination requested.
 func (f *TxFetcher) Start() {
@@ -358,7 +433,7 @@ func (f *TxFetcher) loop() {
 			// the probability of something arriving between this call and the pre-
 			// filter outside is essentially zero.
 			used := len(f.waitslots[ann.origin]) + len(f.announces[ann.origin])
-			if used >= maxTxAnnounces {
+			if used >= f.cfg.MaxAnnounces {
 				// This can happen if a set of transactions are requested but not
 				// all fulfilled, so the remainder are rescheduled without the cap
 				// check. Should be fine as the limit is in the thousands and the
@@ -367,9 +442,9 @@ func (f *TxFetcher) loop() {
 				break
 			}
 			want := used + len(ann.hashes)
-			if want > maxTxAnnounces {
-				txAnnounceDOSMeter.Mark(int64(want - maxTxAnnounces))
-				ann.hashes = ann.hashes[:want-maxTxAnnounces]
+			if want > f.cfg.MaxAnnounces {
+				txAnnounceDOSMeter.Mark(int64(want - f.cfg.MaxAnnounces))
+				ann.hashes = ann.hashes[:want-f.cfg.MaxAnnounces]
 			}
 			// All is well, schedule the remainder of the transactions
 			idleWait := len(f.waittime) == 0
@@ -436,6 +511,65 @@ func (f *TxFetcher) loop() {
 				f.scheduleFetches(timeoutTimer, timeoutTrigger, map[string]struct{}{ann.origin: {}})
 			}
 
+		case rec := <-f.reconcile:
+			// Prioritize the holes, moving the waiting ones straight to the queue.
+			// The block's origin is tracked as a source too, it surely has them.
+			var (
+				actives                 = make(map[string]struct{})
+				holes, waiting, pending int64
+			)
+			for _, hash := range rec.hashes {
+				switch {
+				case f.alternates[hash] != nil:
+					// Already being retrieved, prioritize it if rescheduled
+					f.alternates[hash][rec.origin] = struct{}{}
+					pending++
+
+				case f.announced[hash] != nil:
+					f.announced[hash][rec.origin] = struct{}{}
+					for peer := range f.announced[hash] {
+						actives[peer] = struct{}{}
+					}
+
+				case f.waitlist[hash] != nil:
+					f.announced[hash] = f.waitlist[hash]
+					f.announced[hash][rec.origin] = struct{}{}
+					for peer := range f.waitlist[hash] {
+						delete(f.waitslots[peer], hash)
+						if len(f.waitslots[peer]) == 0 {
+							delete(f.waitslots, peer)
+						}
+						if announces := f.announces[peer]; announces != nil {
+							announces[hash] = struct{}{}
+						} else {
+							f.announces[peer] = map[common.Hash]struct{}{hash: {}}
+						}
+						actives[peer] = struct{}{}
+					}
+					delete(f.waitlist, hash)
+					delete(f.waittime, hash)
+					waiting++
+
+				default:
+					// Never announced, not a retrieval hole
+					continue
+				}
+				if announces := f.announces[rec.origin]; announces != nil {
+					announces[hash] = struct{}{}
+				} else {
+					f.announces[rec.origin] = map[common.Hash]struct{}{hash: {}}
+				}
+				f.priority[hash] = struct{}{}
+				holes++
+			}
+			txHoleInMeter.Mark(holes)
+			txHoleWaitingMeter.Mark(waiting)
+			txHoleFetchingMeter.Mark(pending)
+
+			if len(actives) > 0 {
+				f.scheduleFetches(timeoutTimer, timeoutTrigger, actives)
+			}
+
 		case <-waitTrigger:
 			// At least one transaction's waiting time ran out, push all expired
 			// ones into the retrieval queues
@@ -478,6 +612,14 @@ func (f *TxFetcher) loop() {
 			// could also penalize (Drop), but there's nothing to gain, and if could
 			// possibly further increase the load on it.
 			for peer, req := range f.requests {
+				// Abandon the timed out requests once the peer's backoff is over,
+				// a late reply being matched against the next request if any
+				if req.hashes == nil {
+					if f.clock.Now()+mclock.AbsTime(txGatherSlack) >= req.release {
+						delete(f.requests, peer)
+					}
+					continue
+				}
 				if time.Duration(f.clock.Now()-req.time)+txGatherSlack > txFetchTimeout {
 					txRequestTimeoutMeter.Mark(int64(len(req.hashes)))
 
@@ -507,8 +649,19 @@ func (f *TxFetcher) loop() {
 					if len(f.announces[peer]) == 0 {
 						delete(f.announces, peer)
 					}
-					// Keep track of the request as dangling, but never expire
+					// Keep track of the request as dangling until the backoff ends,
+					// doubling it on consecutive timeouts
+					backoff := f.backoffs[peer] * 2
+					if backoff == 0 {
+						backoff = txFetchTimeout
+					}
+					if backoff > txFetchBackoffMax {
+						backoff = txFetchBackoffMax
+					}
+					f.backoffs[peer] = backoff
+
 					f.requests[peer].hashes = nil
+					f.requests[peer].release = f.clock.Now() + mclock.AbsTime(backoff)
 				}
 			}
 			// Schedule a new transaction retrieval
@@ -568,6 +721,7 @@ func (f *TxFetcher) loop() {
 					break
 				}
 				delete(f.requests, delivery.origin)
+				delete(f.backoffs, delivery.origin)
 
 				// Anything not delivered should be re-scheduled (with or without
 				// this peer, depending on the response cutoff)
@@ -613,6 +767,7 @@ func (f *TxFetcher) loop() {
 
 		case drop := <-f.drop:
 			// A peer was dropped, remove all traces of it
+			delete(f.backoffs, drop.peer)
 			if _, ok := f.waitslots[drop.peer]; ok {
 				for hash := range f.waitslots[drop.peer] {
 					delete(f.waitlist[hash], drop.peer)
@@ -667,6 +822,16 @@ func (f *TxFetcher) loop() {
 		case <-f.quit:
 			return
 		}
+		// Forget the priority of the holes not tracked anymore
+		for hash := range f.priority {
+			if _, ok := f.announced[hash]; ok {
+				continue
+			}
+			if _, ok := f.fetching[hash]; ok {
+				continue
+			}
+			delete(f.priority, hash)
+		}
 		// No idea what happened, but bump some sanity metrics
 		txFetcherWaitingPeers.Update(int64(len(f.waitslots)))
 		txFetcherWaitingHashes.Update(int64(len(f.waitlist)))
@@ -674,6 +839,8 @@ func (f *TxFetcher) loop() {
 		txFetcherQueueingHashes.Update(int64(len(f.announced)))
 		txFetcherFetchingPeers.Update(int64(len(f.requests)))
 		txFetcherFetchingHashes.Update(int64(len(f.fetching)))
+		txFetcherPriorityHashes.Update(int64(len(f.priority)))
+		txFetcherBackoffPeers.Update(int64(len(f.backoffs)))
 
 		// Loop did something, ping the step notifier if needed (tests)
 		if f.step != nil {
@@ -770,7 +937,7 @@ func (f *TxFetcher) scheduleFetches(timer *mclock.Timer, timeout chan struct{},
 			return // continue in the for-each
 		}
 		hashes := make([]common.Hash, 0, maxTxRetrievals)
-		f.forEachHash(f.announces[peer], func(hash common.Hash) bool {
+		allocate := func(hash common.Hash) bool {
 			if _, ok := f.fetching[hash]; !ok {
 				// Mark the hash as fetching and stash away possible alternates
 				f.fetching[hash] = peer
@@ -788,7 +955,19 @@ func (f *TxFetcher) scheduleFetches(timer *mclock.Timer, timeout chan struct{},
 				}
 			}
 			return true // continue in the for-each
-		})
+		}
+		// Allocate the holes announced by the peer first, then anything else
+		if len(f.priority) > 0 {
+			f.forEachHash(f.priority, func(hash common.Hash) bool {
+				if _, ok := f.announces[peer][hash]; !ok {
+					return true // continue in the for-each
+				}
+				return allocate(hash)
+			})
+		}
+		if len(hashes) < maxTxRetrievals {
+			f.forEachHash(f.announces[peer], allocate)
+		}
 		// If any hashes were allocated, request them from the peer
 		if len(hashes) > 0 {
 			f.requests[peer] = &txRequest{hashes: hashes, time: f.clock.Now()}
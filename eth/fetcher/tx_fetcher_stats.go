@@ -0,0 +1,179 @@
+package fetcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	txAnnounceFloodMeter = metrics.NewRegisteredMeter("eth/fetcher/transaction/announces/flood", nil)
+	txFloodMutedGauge    = metrics.NewRegisteredGauge("eth/fetcher/transaction/flood/muted", nil)
+)
+
+// TxFetcherConfig is the tunable limits of the transaction fetcher.
+type TxFetcherConfig struct {
+	MaxAnnounces       int // Maximum number of unique transactions a peer can have announced in flight
+	UnderpricedSetSize int // Size of the set tracking recently rejected underpriced transactions
+
+	// FloodLimit is the number of repeated announcements of transactions already proven
+	// underpriced that a peer can send within FloodWindow, all the further announcements
+	// of the peer will be dropped until the window ends. 0 disables the flood protection.
+	FloodLimit  int
+	FloodWindow time.Duration
+}
+
+// DefaultTxFetcherConfig is the default limits of the transaction fetcher.
+var DefaultTxFetcherConfig = TxFetcherConfig{
+	MaxAnnounces:       maxTxAnnounces,
+	UnderpricedSetSize: maxTxUnderpricedSetSize,
+	FloodLimit:         1024,
+	FloodWindow:        time.Minute,
+}
+
+func (c TxFetcherConfig) sanitize() TxFetcherConfig {
+	if c.MaxAnnounces < maxTxRetrievals {
+		c.MaxAnnounces = DefaultTxFetcherConfig.MaxAnnounces
+	}
+	if c.UnderpricedSetSize <= 0 {
+		c.UnderpricedSetSize = DefaultTxFetcherConfig.UnderpricedSetSize
+	}
+	if c.FloodLimit < 0 {
+		c.FloodLimit = 0
+	}
+	if c.FloodWindow <= 0 {
+		c.FloodWindow = DefaultTxFetcherConfig.FloodWindow
+	}
+	return c
+}
+
+// TxPeerStats is the transaction throughput statistics of a single peer.
+type TxPeerStats struct {
+	Announced   uint64 `json:"announced"`   // Transaction hashes announced by the peer
+	Duplicate   uint64 `json:"duplicate"`   // Announced or delivered transactions already known
+	Underpriced uint64 `json:"underpriced"` // Announced or delivered transactions proven underpriced
+	Received    uint64 `json:"received"`    // Transactions delivered by broadcasts or replies
+	Accepted    uint64 `json:"accepted"`    // Delivered transactions accepted by the pool
+	Flooded     uint64 `json:"flooded"`     // Announcements dropped by the flood protection
+	Muted       bool   `json:"muted"`       // Whether the peer's announcements are currently dropped
+}
+
+// txPeerState tracks the statistics and the flood protection state of a peer.
+type txPeerState struct {
+	stats TxPeerStats
+
+	floodStart mclock.AbsTime // Start time of the current flood window
+	floodCount int            // Repeated underpriced announcements in the current window
+}
+
+// txPeerTracker tracks the transaction statistics of all the peers, it's accessed
+// from the peer goroutines concurrently.
+type txPeerTracker struct {
+	cfg   TxFetcherConfig
+	clock mclock.Clock
+	peers map[string]*txPeerState
+	muted int
+	lock  sync.Mutex
+}
+
+func newTxPeerTracker(cfg TxFetcherConfig, clock mclock.Clock) *txPeerTracker {
+	return &txPeerTracker{
+		cfg:   cfg,
+		clock: clock,
+		peers: make(map[string]*txPeerState),
+	}
+}
+
+// state returns the state of the peer, creating it if needed. The lock must be held.
+func (t *txPeerTracker) state(peer string) *txPeerState {
+	s, ok := t.peers[peer]
+	if !ok {
+		s = &txPeerState{floodStart: t.clock.Now()}
+		t.peers[peer] = s
+	}
+	return s
+}
+
+// muteCheck rolls the flood window of the peer if needed, and reports whether the
+// announcements of the peer should be dropped. The lock must be held.
+func (t *txPeerTracker) muteCheck(s *txPeerState) bool {
+	if t.cfg.FloodLimit == 0 {
+		return false
+	}
+	if now := t.clock.Now(); time.Duration(now-s.floodStart) >= t.cfg.FloodWindow {
+		s.floodStart, s.floodCount = now, 0
+		if s.stats.Muted {
+			s.stats.Muted = false
+			t.muted--
+			txFloodMutedGauge.Update(int64(t.muted))
+		}
+	}
+	return s.stats.Muted
+}
+
+// announced records an announcement of the peer, and returns false if the whole
+// announcement should be dropped due to flooding.
+func (t *txPeerTracker) announced(peer string, total, duplicate, underpriced int) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s := t.state(peer)
+	if t.muteCheck(s) {
+		s.stats.Flooded += uint64(total)
+		txAnnounceFloodMeter.Mark(int64(total))
+		return false
+	}
+	s.stats.Announced += uint64(total)
+	s.stats.Duplicate += uint64(duplicate)
+	s.stats.Underpriced += uint64(underpriced)
+
+	if t.cfg.FloodLimit > 0 && underpriced > 0 {
+		s.floodCount += underpriced
+		if s.floodCount > t.cfg.FloodLimit {
+			s.stats.Muted = true
+			t.muted++
+			txFloodMutedGauge.Update(int64(t.muted))
+		}
+	}
+	return true
+}
+
+// delivered records a batch of transactions delivered by the peer.
+func (t *txPeerTracker) delivered(peer string, total, accepted, duplicate, underpriced int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s := t.state(peer)
+	s.stats.Received += uint64(total)
+	s.stats.Accepted += uint64(accepted)
+	s.stats.Duplicate += uint64(duplicate)
+	s.stats.Underpriced += uint64(underpriced)
+}
+
+// drop removes the peer.
+func (t *txPeerTracker) drop(peer string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if s, ok := t.peers[peer]; ok && s.stats.Muted {
+		t.muted--
+		txFloodMutedGauge.Update(int64(t.muted))
+	}
+	delete(t.peers, peer)
+}
+
+// stats returns the statistics of the peer, or nil if it's unknown.
+func (t *txPeerTracker) stats(peer string) *TxPeerStats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	s, ok := t.peers[peer]
+	if !ok {
+		return nil
+	}
+	t.muteCheck(s)
+	stats := s.stats
+	return &stats
+}
@@ -0,0 +1,42 @@
+package fetcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+// Tests that a peer repeatedly announcing underpriced transactions gets muted
+// until the flood window ends.
+func TestTxPeerTrackerFloodProtection(t *testing.T) {
+	clock := new(mclock.Simulated)
+	cfg := TxFetcherConfig{FloodLimit: 10, FloodWindow: time.Minute}.sanitize()
+	tracker := newTxPeerTracker(cfg, clock)
+
+	if !tracker.announced("A", 8, 0, 8) {
+		t.Fatalf("peer muted below the flood limit")
+	}
+	if !tracker.announced("A", 4, 0, 4) {
+		t.Fatalf("announcement crossing the limit should still be accepted")
+	}
+	if tracker.announced("A", 5, 0, 0) {
+		t.Fatalf("peer not muted after exceeding the flood limit")
+	}
+	if !tracker.announced("B", 5, 0, 0) {
+		t.Fatalf("other peer muted")
+	}
+	stats := tracker.stats("A")
+	if !stats.Muted || stats.Flooded != 5 || stats.Announced != 12 || stats.Underpriced != 12 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	clock.Run(time.Minute)
+	if !tracker.announced("A", 1, 0, 0) {
+		t.Fatalf("peer still muted after the flood window")
+	}
+	tracker.drop("A")
+	if tracker.stats("A") != nil {
+		t.Fatalf("stats of dropped peer still present")
+	}
+}
@@ -45,6 +45,10 @@ type doTxNotify struct {
 	peer   string
 	hashes []common.Hash
 }
+type doTxReconcile struct {
+	peer   string
+	hashes []common.Hash
+}
 type doTxEnqueue struct {
 	peer   string
 	txs    []*types.Transaction
@@ -706,6 +710,135 @@ func TestTransactionFetcherTimeoutRescheduling(t *testing.T) {
 	})
 }
 
+// Tests that peers timing out on requests are excluded from the retrievals for
+// an increasing backoff, and scheduled again afterwards.
+func TestTransactionFetcherTimeoutBackoff(t *testing.T) {
+	testTransactionFetcherParallel(t, txFetcherTest{
+		init: func() *TxFetcher {
+			return NewTxFetcher(
+				func(common.Hash) bool { return false },
+				nil,
+				func(string, []common.Hash) error { return nil },
+			)
+		},
+		steps: []interface{}{
+			// Time out a first request and queue up a new announcement
+			doTxNotify{peer: "A", hashes: []common.Hash{{0x01}}},
+			doWait{time: txArriveTimeout, step: true},
+			doWait{time: txFetchTimeout, step: true},
+			doTxNotify{peer: "A", hashes: []common.Hash{{0x02}}},
+			doWait{time: txArriveTimeout, step: true},
+			isScheduled{
+				tracking: map[string][]common.Hash{
+					"A": {{0x02}},
+				},
+				dangling: map[string][]common.Hash{
+					"A": {},
+				},
+			},
+			// Once the backoff is over, the peer should be requested again
+			doWait{time: txFetchTimeout, step: true},
+			isScheduled{
+				tracking: map[string][]common.Hash{
+					"A": {{0x02}},
+				},
+				fetching: map[string][]common.Hash{
+					"A": {{0x02}},
+				},
+			},
+			// Time out once more, the backoff should be doubled
+			doWait{time: txFetchTimeout, step: true},
+			doTxNotify{peer: "A", hashes: []common.Hash{{0x03}}},
+			doWait{time: txArriveTimeout, step: true},
+			doWait{time: txFetchTimeout - txArriveTimeout, step: true},
+			isScheduled{
+				tracking: map[string][]common.Hash{
+					"A": {{0x03}},
+				},
+				dangling: map[string][]common.Hash{
+					"A": {},
+				},
+			},
+			doWait{time: txFetchTimeout, step: true},
+			isScheduled{
+				tracking: map[string][]common.Hash{
+					"A": {{0x03}},
+				},
+				fetching: map[string][]common.Hash{
+					"A": {{0x03}},
+				},
+			},
+		},
+	})
+}
+
+// Tests that announced transactions referenced by a block skip the waiting list
+// and are fetched ahead of the others, from the block's origin too.
+func TestTransactionFetcherReconcile(t *testing.T) {
+	var fetcher *TxFetcher
+
+	testTransactionFetcherParallel(t, txFetcherTest{
+		init: func() *TxFetcher {
+			fetcher = NewTxFetcher(
+				func(common.Hash) bool { return false },
+				func(txs []*types.Transaction) []error {
+					return make([]error, len(txs))
+				},
+				func(string, []common.Hash) error { return nil },
+			)
+			return fetcher
+		},
+		steps: []interface{}{
+			// Get a transaction into fetching mode and queue up a few others
+			doTxNotify{peer: "A", hashes: []common.Hash{testTxsHashes[0]}},
+			doWait{time: txArriveTimeout, step: true},
+			doTxNotify{peer: "A", hashes: []common.Hash{testTxsHashes[1], testTxsHashes[2]}},
+			doTxNotify{peer: "B", hashes: []common.Hash{testTxsHashes[3]}},
+			isWaiting(map[string][]common.Hash{
+				"A": {testTxsHashes[1], testTxsHashes[2]},
+				"B": {testTxsHashes[3]},
+			}),
+			// Reconcile a block with a waiting hole and an unannounced transaction,
+			// the hole should be queued right away and fetched from the idle origin
+			doTxReconcile{peer: "C", hashes: []common.Hash{testTxsHashes[2], {0x01}}},
+			isWaiting(map[string][]common.Hash{
+				"A": {testTxsHashes[1]},
+				"B": {testTxsHashes[3]},
+			}),
+			isScheduled{
+				tracking: map[string][]common.Hash{
+					"A": {testTxsHashes[0], testTxsHashes[2]},
+					"C": {testTxsHashes[2]},
+				},
+				fetching: map[string][]common.Hash{
+					"A": {testTxsHashes[0]},
+					"C": {testTxsHashes[2]},
+				},
+			},
+			doFunc(func() {
+				if _, ok := fetcher.priority[testTxsHashes[2]]; !ok || len(fetcher.priority) != 1 {
+					t.Errorf("hole priority mismatch: %v", fetcher.priority)
+				}
+			}),
+			// Deliver the hole, the fetcher should forget about its priority
+			doTxEnqueue{peer: "C", txs: []*types.Transaction{testTxs[2]}, direct: true},
+			isScheduled{
+				tracking: map[string][]common.Hash{
+					"A": {testTxsHashes[0]},
+				},
+				fetching: map[string][]common.Hash{
+					"A": {testTxsHashes[0]},
+				},
+			},
+			doFunc(func() {
+				if len(fetcher.priority) != 0 {
+					t.Errorf("delivered hole still prioritized: %v", fetcher.priority)
+				}
+			}),
+		},
+	})
+}
+
 // Tests that the fetching timeout timers properly reset and reschedule.
 func TestTransactionFetcherTimeoutTimerResets(t *testing.T) {
 	testTransactionFetcherParallel(t, txFetcherTest{
@@ -1277,6 +1410,12 @@ func testTransactionFetcher(t *testing.T, tt txFetcherTest) {
 			case <-time.After(time.Millisecond):
 			}
 
+		case doTxReconcile:
+			if err := fetcher.Reconcile(step.peer, step.hashes); err != nil {
+				t.Errorf("step %d: %v", i, err)
+			}
+			<-wait // Fetcher needs to process this, wait until it's done
+
 		case doTxEnqueue:
 			if err := fetcher.Enqueue(step.peer, step.txs, step.direct); err != nil {
 				t.Errorf("step %d: %v", i, err)
@@ -28,9 +28,11 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -58,6 +60,12 @@ type PublicFilterAPI struct {
 	timeout   time.Duration
 }
 
+// governedBackend is implemented by the backends throttling the heavy queries
+// while the block import lags.
+type governedBackend interface {
+	Governor() *core.Governor
+}
+
 // NewPublicFilterAPI returns a new PublicFilterAPI instance.
 func NewPublicFilterAPI(backend Backend, lightMode bool, timeout time.Duration) *PublicFilterAPI {
 	api := &PublicFilterAPI{
@@ -240,7 +248,65 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 	return rpcSub, nil
 }
 
+// ReorgNotification is the notification of a chain reorganisation.
+type ReorgNotification struct {
+	OldHead      common.Hash    `json:"oldHead"`
+	OldNumber    hexutil.Uint64 `json:"oldNumber"`
+	NewHead      common.Hash    `json:"newHead"`
+	NewNumber    hexutil.Uint64 `json:"newNumber"`
+	CommonHash   common.Hash    `json:"commonHash"`
+	CommonNumber hexutil.Uint64 `json:"commonNumber"`
+	Depth        hexutil.Uint64 `json:"depth"`
+	Removed      []common.Hash  `json:"removed"`    // Transactions dropped from the canonical chain
+	Reincluded   []common.Hash  `json:"reincluded"` // Transactions moved to another block of the new chain
+}
+
+// Reorg sends a notification each time the canonical chain is reorganised, with
+// the transactions removed from or re-included into the canonical chain.
+func (api *PublicFilterAPI) Reorg(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		reorgs := make(chan core.ReorgEvent, 10)
+		reorgsSub := api.backend.SubscribeReorgEvent(reorgs)
+		defer reorgsSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-reorgs:
+				notifier.Notify(rpcSub.ID, &ReorgNotification{
+					OldHead:      ev.OldHead.Hash(),
+					OldNumber:    hexutil.Uint64(ev.OldHead.Number.Uint64()),
+					NewHead:      ev.NewHead.Hash(),
+					NewNumber:    hexutil.Uint64(ev.NewHead.Number.Uint64()),
+					CommonHash:   ev.Common.Hash(),
+					CommonNumber: hexutil.Uint64(ev.Common.Number.Uint64()),
+					Depth:        hexutil.Uint64(ev.Depth),
+					Removed:      ev.Removed,
+					Reincluded:   ev.Reincluded,
+				})
+			case <-reorgsSub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
+// If the criteria start at a past block, the matching logs from there up to the
+// head are replayed first, then the live ones follow without gap or duplicate.
+// The logs of the blocks reorganised out are sent again with the removed flag set.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
@@ -252,24 +318,71 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 		matchedLogs = make(chan []*types.Log)
 	)
 
+	// Subscribe to the live logs before looking up the head to replay up to, so
+	// that no block falls in between
 	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery(crit), matchedLogs)
 	if err != nil {
 		return nil, err
 	}
+	var (
+		replayCtx, cancel = context.WithCancel(context.Background())
+		replayed          chan []*types.Log // Past logs being replayed, nil if none (left)
+		replayErr         = make(chan error, 1)
+		dedup             *replayDedup
+		pending           [][]*types.Log // Live logs received while replaying
+	)
+	if crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 {
+		if head, _ := api.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber); head != nil {
+			from, to := crit.FromBlock.Uint64(), head.Number.Uint64()
+			if crit.ToBlock != nil && crit.ToBlock.Sign() >= 0 && crit.ToBlock.Uint64() < to {
+				to = crit.ToBlock.Uint64()
+			}
+			if from <= to {
+				replayed = make(chan []*types.Log)
+				dedup = newReplayDedup(to)
+				go func() { replayErr <- api.replayLogs(replayCtx, crit, from, to, replayed) }()
+			}
+		}
+	}
+	notify := func(logs []*types.Log) {
+		for _, log := range logs {
+			notifier.Notify(rpcSub.ID, &log)
+		}
+	}
 
 	go func() {
+		defer cancel()
+		defer logsSub.Unsubscribe()
 
 		for {
 			select {
+			case logs, ok := <-replayed:
+				if !ok {
+					if err := <-replayErr; err != nil {
+						// Stop rather than carry on live with a gap
+						log.Warn("Log subscription replay failed", "id", rpcSub.ID, "err", err)
+						return
+					}
+					for _, logs := range pending {
+						notify(dedup.live(logs))
+					}
+					replayed, pending = nil, nil
+					continue
+				}
+				dedup.replayed(logs)
+				notify(logs)
 			case logs := <-matchedLogs:
-				for _, log := range logs {
-					notifier.Notify(rpcSub.ID, &log)
+				switch {
+				case replayed != nil:
+					pending = append(pending, logs)
+				case dedup != nil:
+					notify(dedup.live(logs))
+				default:
+					notify(logs)
 				}
 			case <-rpcSub.Err(): // client send an unsubscribe request
-				logsSub.Unsubscribe()
 				return
 			case <-notifier.Closed(): // connection dropped
-				logsSub.Unsubscribe()
 				return
 			}
 		}
@@ -331,6 +444,13 @@ func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 //
 // https://eth.wiki/json-rpc/API#eth_getlogs
 func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
+	if b, ok := api.backend.(governedBackend); ok {
+		release, err := b.Governor().Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
 	var filter *Filter
 	if crit.BlockHash != nil {
 		// Block filter requested, construct a single-shot filter
@@ -0,0 +1,224 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/time/rate"
+)
+
+var (
+	errInvalidCursor   = errors.New("invalid cursor, want <block>:<index>")
+	errExportBlockHash = errors.New("block hash filter not supported by the log export")
+	errExportRange     = errors.New("fromBlock larger than toBlock")
+)
+
+// ExportConfig is the configuration of the log export endpoint.
+type ExportConfig struct {
+	BlockRate  int // Maximum number of blocks scanned per second by each export
+	MaxStreams int // Maximum number of concurrent exports
+}
+
+// DefaultExportConfig contains the default settings of the log export endpoint.
+var DefaultExportConfig = ExportConfig{
+	BlockRate:  50000,
+	MaxStreams: 4,
+}
+
+// exportEntry is a line of the NDJSON export stream. Each line carries the cursor
+// to resume the export from right after it, a log, the end-of-range marker or the
+// error aborting the export.
+type exportEntry struct {
+	Cursor string     `json:"cursor"`
+	Log    *types.Log `json:"log,omitempty"`
+	Done   bool       `json:"done,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// exportCursor is the position of an export, the first log index to be exported
+// in the block.
+type exportCursor struct {
+	block uint64
+	index uint
+}
+
+func (c exportCursor) String() string {
+	return fmt.Sprintf("%d:%d", c.block, c.index)
+}
+
+// parseExportCursor parses a cursor returned by a previous export.
+func parseExportCursor(s string) (exportCursor, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return exportCursor{}, errInvalidCursor
+	}
+	block, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return exportCursor{}, errInvalidCursor
+	}
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return exportCursor{}, errInvalidCursor
+	}
+	return exportCursor{block: block, index: uint(index)}, nil
+}
+
+// ExportHandler streams the logs matching a filter over an arbitrarily large block
+// range as newline delimited JSON, scanning the range in windows at a limited rate.
+//
+// The filter criteria is posted as the JSON body, the same as for eth_getLogs. An
+// interrupted export is resumed by passing the last received cursor as the cursor
+// query parameter along with the same criteria.
+type ExportHandler struct {
+	backend Backend
+	config  ExportConfig
+	streams chan struct{} // Semaphore limiting the concurrent exports
+}
+
+// NewExportHandler creates a log export handler.
+func NewExportHandler(backend Backend, config ExportConfig) *ExportHandler {
+	if config.BlockRate <= 0 {
+		config.BlockRate = DefaultExportConfig.BlockRate
+	}
+	if config.MaxStreams <= 0 {
+		config.MaxStreams = DefaultExportConfig.MaxStreams
+	}
+	return &ExportHandler{
+		backend: backend,
+		config:  config,
+		streams: make(chan struct{}, config.MaxStreams),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *ExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var crit FilterCriteria
+	if err := json.NewDecoder(r.Body).Decode(&crit); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if crit.BlockHash != nil {
+		http.Error(w, errExportBlockHash.Error(), http.StatusBadRequest)
+		return
+	}
+	header, err := h.backend.HeaderByNumber(r.Context(), rpc.LatestBlockNumber)
+	if err != nil || header == nil {
+		http.Error(w, "head block unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	// Resolve the range, the missing or symbolic ends meaning the genesis and the
+	// head at the start of the export
+	from, to := uint64(0), header.Number.Uint64()
+	if crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 {
+		from = crit.FromBlock.Uint64()
+	}
+	if crit.ToBlock != nil && crit.ToBlock.Sign() >= 0 && crit.ToBlock.Uint64() < to {
+		to = crit.ToBlock.Uint64()
+	}
+	cursor := exportCursor{block: from}
+	if s := r.URL.Query().Get("cursor"); s != "" {
+		if cursor, err = parseExportCursor(s); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if cursor.block < from {
+			cursor = exportCursor{block: from}
+		}
+	}
+	if cursor.block > to+1 || from > to {
+		http.Error(w, errExportRange.Error(), http.StatusBadRequest)
+		return
+	}
+	select {
+	case h.streams <- struct{}{}:
+		defer func() { <-h.streams }()
+	default:
+		http.Error(w, "too many concurrent exports", http.StatusTooManyRequests)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	if err := h.export(w, r, &crit, cursor, to); err != nil {
+		log.Debug("Log export aborted", "from", from, "to", to, "err", err)
+	}
+}
+
+// export streams the logs from the cursor up to the given block inclusive.
+func (h *ExportHandler) export(w http.ResponseWriter, r *http.Request, crit *FilterCriteria, cursor exportCursor, to uint64) error {
+	var (
+		ctx        = r.Context()
+		enc        = json.NewEncoder(w)
+		flusher, _ = w.(http.Flusher)
+		window     = uint64(maxFilterBlockRange)
+	)
+	if cursor.block > to {
+		return enc.Encode(&exportEntry{Cursor: cursor.String(), Done: true})
+	}
+	if uint64(h.config.BlockRate) < window {
+		window = uint64(h.config.BlockRate)
+	}
+	limiter := rate.NewLimiter(rate.Limit(h.config.BlockRate), int(window))
+
+	for cursor.block <= to {
+		end := cursor.block + window - 1
+		if end > to {
+			end = to
+		}
+		if err := limiter.WaitN(ctx, int(end-cursor.block+1)); err != nil {
+			return err
+		}
+		logs, err := NewRangeFilter(h.backend, int64(cursor.block), int64(end), crit.Addresses, crit.Topics).Logs(ctx)
+		if err != nil {
+			enc.Encode(&exportEntry{Cursor: cursor.String(), Error: err.Error()})
+			return err
+		}
+		for _, l := range logs {
+			// Skip the logs already exported before the resumption
+			if l.BlockNumber == cursor.block && l.Index < cursor.index {
+				continue
+			}
+			cursor = exportCursor{block: l.BlockNumber, index: l.Index + 1}
+			if err := enc.Encode(&exportEntry{Cursor: cursor.String(), Log: l}); err != nil {
+				return err
+			}
+		}
+		// Report the progress even if the window has no logs, so that the export
+		// can be resumed without rescanning it
+		cursor = exportCursor{block: end + 1}
+		if err := enc.Encode(&exportEntry{Cursor: cursor.String(), Done: end == to}); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	return nil
+}
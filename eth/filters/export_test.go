@@ -0,0 +1,161 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"bufio"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newExportTestBackend creates a backend with a short chain holding two logs in
+// block 2 and one in each of the blocks 5 and 8.
+func newExportTestBackend(t *testing.T) (*testBackend, common.Address) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		addr    = common.HexToAddress("0x1234")
+	)
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {
+		var logs []*types.Log
+		switch i + 1 {
+		case 2:
+			logs = []*types.Log{{Address: addr}, {Address: addr}}
+		case 5, 8:
+			logs = []*types.Log{{Address: addr}}
+		default:
+			return
+		}
+		receipt := types.NewReceipt(nil, false, 0)
+		receipt.Logs = logs
+		gen.AddUncheckedReceipt(receipt)
+		gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x1"), big.NewInt(1), 1, gen.BaseFee(), nil))
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	return backend, addr
+}
+
+// runExport posts a log export request and decodes the streamed entries.
+func runExport(t *testing.T, handler http.Handler, method, query, body string) (int, []exportEntry) {
+	req := httptest.NewRequest(method, "/logs/export"+query, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var entries []exportEntry
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var entry exportEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("failed to decode export line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return rec.Code, entries
+}
+
+func TestLogExport(t *testing.T) {
+	backend, addr := newExportTestBackend(t)
+	handler := NewExportHandler(backend, ExportConfig{BlockRate: 4})
+	body := `{"fromBlock":"0x1","address":"` + addr.Hex() + `"}`
+
+	// Export the whole range in windows of 4 blocks
+	code, entries := runExport(t, handler, http.MethodPost, "", body)
+	if code != http.StatusOK {
+		t.Fatalf("status mismatch: have %d, want %d", code, http.StatusOK)
+	}
+	var (
+		blocks  []uint64
+		cursors []string
+	)
+	for _, entry := range entries {
+		if entry.Log != nil {
+			blocks = append(blocks, entry.Log.BlockNumber)
+		}
+		cursors = append(cursors, entry.Cursor)
+	}
+	if want := []uint64{2, 2, 5, 8}; len(blocks) != len(want) || blocks[0] != want[0] || blocks[1] != want[1] || blocks[2] != want[2] || blocks[3] != want[3] {
+		t.Fatalf("exported blocks mismatch: have %v, want %v", blocks, want)
+	}
+	want := []string{"2:1", "2:2", "5:0", "5:1", "8:1", "9:0", "11:0"}
+	if strings.Join(cursors, ",") != strings.Join(want, ",") {
+		t.Fatalf("cursors mismatch: have %v, want %v", cursors, want)
+	}
+	if last := entries[len(entries)-1]; !last.Done {
+		t.Fatalf("export not marked done: %+v", last)
+	}
+	// Resume in the middle of block 2 and check the exported log is not repeated
+	code, entries = runExport(t, handler, http.MethodPost, "?cursor=2:1", body)
+	if code != http.StatusOK {
+		t.Fatalf("status mismatch: have %d, want %d", code, http.StatusOK)
+	}
+	var logs int
+	for _, entry := range entries {
+		if entry.Log != nil {
+			logs++
+		}
+	}
+	if logs != 3 {
+		t.Fatalf("resumed log count mismatch: have %d, want 3", logs)
+	}
+	// Resume at the end of the range
+	if _, entries = runExport(t, handler, http.MethodPost, "?cursor=11:0", body); len(entries) != 1 || !entries[0].Done {
+		t.Fatalf("finished export mismatch: have %+v", entries)
+	}
+}
+
+func TestInvalidLogExport(t *testing.T) {
+	backend, _ := newExportTestBackend(t)
+	handler := NewExportHandler(backend, DefaultExportConfig)
+
+	tests := []struct {
+		method string
+		query  string
+		body   string
+		code   int
+	}{
+		{http.MethodGet, "", `{}`, http.StatusMethodNotAllowed},
+		{http.MethodPost, "", `{"blockHash":"0x0000000000000000000000000000000000000000000000000000000000000001"}`, http.StatusBadRequest},
+		{http.MethodPost, "", `{"fromBlock":"0x5","toBlock":"0x2"}`, http.StatusBadRequest},
+		{http.MethodPost, "?cursor=five", `{}`, http.StatusBadRequest},
+		{http.MethodPost, "?cursor=20:0", `{}`, http.StatusBadRequest},
+	}
+	for i, tt := range tests {
+		if code, _ := runExport(t, handler, tt.method, tt.query, tt.body); code != tt.code {
+			t.Errorf("test %d: status mismatch: have %d, want %d", i, code, tt.code)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// replayChunk is the number of blocks the past logs of a subscription are
+// filtered in at once, bounding the memory held by a replay.
+const replayChunk = 2048
+
+// replayLogs filters the past logs matching the criteria in the given inclusive
+// block range chunk by chunk, sending the matches of each chunk to the channel,
+// which is closed once the range is done. The heavy query throttling applies to
+// each chunk.
+func (api *PublicFilterAPI) replayLogs(ctx context.Context, crit FilterCriteria, from, to uint64, out chan<- []*types.Log) error {
+	defer close(out)
+
+	for start := from; start <= to; start += replayChunk {
+		end := start + replayChunk - 1
+		if end > to {
+			end = to
+		}
+		logs, err := api.filterChunk(ctx, crit, start, end)
+		if err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			continue
+		}
+		select {
+		case out <- logs:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// filterChunk runs the range filter over a chunk of the replayed blocks.
+func (api *PublicFilterAPI) filterChunk(ctx context.Context, crit FilterCriteria, from, to uint64) ([]*types.Log, error) {
+	if b, ok := api.backend.(governedBackend); ok {
+		release, err := b.Governor().Acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+	return NewRangeFilter(api.backend, int64(from), int64(to), crit.Addresses, crit.Topics).Logs(ctx)
+}
+
+// replayDedup reconciles the live logs with the replayed ones, so that the
+// subscriber sees each block's logs exactly once, and their removal only if it
+// saw them, whichever way the live events and the replay interleaved around the
+// head. The live logs past the replayed range are passed through as is.
+type replayDedup struct {
+	end  uint64               // Last block of the replayed range
+	seen map[common.Hash]bool // Whether the logs of a replayed range block are live at the subscriber
+}
+
+func newReplayDedup(end uint64) *replayDedup {
+	return &replayDedup{end: end, seen: make(map[common.Hash]bool)}
+}
+
+// replayed records the logs delivered by the replay.
+func (d *replayDedup) replayed(logs []*types.Log) {
+	for _, log := range logs {
+		d.seen[log.BlockHash] = true
+	}
+}
+
+// live filters a batch of live logs down to the ones to deliver: the added logs
+// of the blocks not delivered yet and the removed logs of the delivered ones.
+func (d *replayDedup) live(logs []*types.Log) []*types.Log {
+	var deliver []*types.Log
+	for _, log := range logs {
+		if log.BlockNumber > d.end || d.seen[log.BlockHash] == log.Removed {
+			deliver = append(deliver, log)
+		}
+	}
+	for _, log := range logs {
+		if log.BlockNumber <= d.end {
+			d.seen[log.BlockHash] = !log.Removed
+		}
+	}
+	return deliver
+}
@@ -0,0 +1,54 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that the live logs received around a replay are delivered exactly once,
+// and their removals only if the subscriber saw them.
+func TestReplayDedup(t *testing.T) {
+	newLog := func(number uint64, hash byte, removed bool) *types.Log {
+		return &types.Log{BlockNumber: number, BlockHash: common.Hash{hash}, Removed: removed}
+	}
+	dedup := newReplayDedup(10)
+	dedup.replayed([]*types.Log{newLog(9, 0x09, false), newLog(10, 0x0a, false)})
+
+	// Live logs of replayed blocks are dropped, the ones of new blocks delivered
+	if logs := dedup.live([]*types.Log{newLog(10, 0x0a, false), newLog(10, 0x0a, false)}); len(logs) != 0 {
+		t.Fatalf("replayed block logs delivered again: %d", len(logs))
+	}
+	if logs := dedup.live([]*types.Log{newLog(11, 0x0b, false)}); len(logs) != 1 {
+		t.Fatalf("new block logs not delivered: %d", len(logs))
+	}
+	// Removals of delivered blocks are delivered, all the logs of the block
+	if logs := dedup.live([]*types.Log{newLog(10, 0x0a, true), newLog(10, 0x0a, true)}); len(logs) != 2 {
+		t.Fatalf("removed logs not delivered: %d", len(logs))
+	}
+	// Removals of blocks the subscriber never saw are dropped
+	if logs := dedup.live([]*types.Log{newLog(10, 0xaa, true)}); len(logs) != 0 {
+		t.Fatalf("removal of unseen block delivered: %d", len(logs))
+	}
+	// Blocks reorged back in are delivered again
+	if logs := dedup.live([]*types.Log{newLog(10, 0x0a, false)}); len(logs) != 1 {
+		t.Fatalf("reincluded block logs not delivered: %d", len(logs))
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	floorClampMeter = metrics.NewRegisteredMeter("gasprice/clamp/floor", nil)
+	ceilClampMeter  = metrics.NewRegisteredMeter("gasprice/clamp/cap", nil)
+)
+
+// priceBounds returns the bounds of the suggested prices, the tighter ones of the
+// operator configured bounds and the bounds set by the governance in the chain
+// config. A nil bound means unbounded.
+func priceBounds(floor, ceil *big.Int, config *params.ChainConfig) (*big.Int, *big.Int) {
+	if config != nil && config.Congress != nil {
+		if gov := config.Congress.MinSuggestedGasPrice; gov != nil && (floor == nil || gov.Cmp(floor) > 0) {
+			floor = gov
+		}
+		if gov := config.Congress.MaxSuggestedGasPrice; gov != nil && (ceil == nil || gov.Cmp(ceil) < 0) {
+			ceil = gov
+		}
+	}
+	return floor, ceil
+}
+
+// clampPrice clamps the price into the bounds, the cap taking precedence over the
+// floor if they conflict.
+func clampPrice(price, floor, ceil *big.Int) *big.Int {
+	if ceil != nil && price.Cmp(ceil) > 0 {
+		ceilClampMeter.Mark(1)
+		return new(big.Int).Set(ceil)
+	}
+	if floor != nil && price.Cmp(floor) < 0 {
+		floorClampMeter.Mark(1)
+		return new(big.Int).Set(floor)
+	}
+	return price
+}
+
+// clampGwei clamps the price in gwei into the bounds.
+func clampGwei(price uint, floor, ceil *big.Int) uint {
+	if ceil != nil && price > wei2GWei(ceil) {
+		ceilClampMeter.Mark(1)
+		return wei2GWei(ceil)
+	}
+	if floor != nil && price < wei2GWei(floor) {
+		floorClampMeter.Mark(1)
+		return wei2GWei(floor)
+	}
+	return price
+}
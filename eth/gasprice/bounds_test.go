@@ -0,0 +1,71 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestPriceBounds(t *testing.T) {
+	gov := &params.ChainConfig{Congress: &params.CongressConfig{
+		MinSuggestedGasPrice: big.NewInt(2 * params.GWei),
+		MaxSuggestedGasPrice: big.NewInt(100 * params.GWei),
+	}}
+	tests := []struct {
+		floor, ceil *big.Int
+		config      *params.ChainConfig
+		price       int64
+		want        int64
+	}{
+		{nil, nil, params.TestChainConfig, 1000, 1000},
+		{big.NewInt(10), big.NewInt(20), params.TestChainConfig, 5, 10},
+		{big.NewInt(10), big.NewInt(20), params.TestChainConfig, 25, 20},
+		{nil, big.NewInt(500 * params.GWei), gov, 200 * params.GWei, 100 * params.GWei},
+		{big.NewInt(params.GWei), nil, gov, 1, 2 * params.GWei},
+		{big.NewInt(5 * params.GWei), big.NewInt(50 * params.GWei), gov, 3 * params.GWei, 5 * params.GWei},
+		{big.NewInt(5 * params.GWei), big.NewInt(50 * params.GWei), gov, 70 * params.GWei, 50 * params.GWei},
+	}
+	for i, tt := range tests {
+		floor, ceil := priceBounds(tt.floor, tt.ceil, tt.config)
+		if have := clampPrice(big.NewInt(tt.price), floor, ceil); have.Int64() != tt.want {
+			t.Errorf("test %d: clamped price mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+}
+
+func TestSuggestTipCapBounds(t *testing.T) {
+	backend := newTestBackend(t, nil, false)
+
+	// The gas price sampled is 30G, which is raised to the floor
+	oracle := NewOracle(backend, Config{
+		Blocks:     3,
+		Percentile: 60,
+		Default:    big.NewInt(params.GWei),
+		MinPrice:   big.NewInt(40 * params.GWei),
+	})
+	got, err := oracle.SuggestTipCap(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to retrieve recommended gas price: %v", err)
+	}
+	if want := big.NewInt(40 * params.GWei); got.Cmp(want) != 0 {
+		t.Fatalf("Gas price mismatch, want %d, got %d", want, got)
+	}
+}
@@ -1,5 +1,7 @@
 package gasprice
 
+import "fmt"
+
 type PredConfig struct {
 	PredictIntervalSecs int
 	MinTxCntPerBlock    int // minimum tx cnt per block for caculations.
@@ -14,3 +16,18 @@ type PredConfig struct {
 
 	MaxValidPendingSecs int
 }
+
+// Validate checks the configured values, zero meaning the default.
+func (c *PredConfig) Validate() error {
+	if c.PredictIntervalSecs < 0 || c.MinTxCntPerBlock < 0 || c.FastFactor < 0 || c.MedianFactor < 0 || c.LowFactor < 0 ||
+		c.MinMedianIndex < 0 || c.MinLowIndex < 0 || c.MaxValidPendingSecs < 0 {
+		return fmt.Errorf("negative price prediction config value: %+v", *c)
+	}
+	if c.FastPercentile < 0 || c.FastPercentile > 100 {
+		return fmt.Errorf("fast percentile %d out of [0, 100]", c.FastPercentile)
+	}
+	if c.MeidanPercentile < 0 || c.MeidanPercentile > 100 {
+		return fmt.Errorf("median percentile %d out of [0, 100]", c.MeidanPercentile)
+	}
+	return nil
+}
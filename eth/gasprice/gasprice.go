@@ -45,6 +45,7 @@ type Config struct {
 	MaxHeaderHistory int
 	MaxBlockHistory  int
 	Default          *big.Int `toml:",omitempty"`
+	MinPrice         *big.Int `toml:",omitempty"`
 	MaxPrice         *big.Int `toml:",omitempty"`
 	IgnorePrice      *big.Int `toml:",omitempty"`
 
@@ -67,6 +68,7 @@ type Oracle struct {
 	backend     OracleBackend
 	lastHead    common.Hash
 	lastPrice   *big.Int
+	minPrice    *big.Int
 	maxPrice    *big.Int
 	ignorePrice *big.Int
 	cacheLock   sync.RWMutex
@@ -98,6 +100,10 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 		maxPrice = DefaultMaxPrice
 		log.Warn("Sanitizing invalid gasprice oracle price cap", "provided", params.MaxPrice, "updated", maxPrice)
 	}
+	minPrice := params.MinPrice
+	if minPrice != nil && minPrice.Sign() <= 0 {
+		minPrice = nil
+	}
 	ignorePrice := params.IgnorePrice
 	if ignorePrice == nil || ignorePrice.Int64() <= 0 {
 		ignorePrice = DefaultIgnorePrice
@@ -132,6 +138,7 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 	return &Oracle{
 		backend:          backend,
 		lastPrice:        params.Default,
+		minPrice:         minPrice,
 		maxPrice:         maxPrice,
 		ignorePrice:      ignorePrice,
 		checkBlocks:      blocks,
@@ -212,9 +219,8 @@ func (oracle *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
 		sort.Sort(bigIntArray(results))
 		price = results[(len(results)-1)*oracle.percentile/100]
 	}
-	if price.Cmp(oracle.maxPrice) > 0 {
-		price = new(big.Int).Set(oracle.maxPrice)
-	}
+	floor, ceil := priceBounds(oracle.minPrice, oracle.maxPrice, oracle.backend.ChainConfig())
+	price = clampPrice(price, floor, ceil)
 	oracle.cacheLock.Lock()
 	oracle.lastHead = headHash
 	oracle.lastPrice = price
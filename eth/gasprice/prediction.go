@@ -1,13 +1,17 @@
 package gasprice
 
 import (
+	"bytes"
 	"context"
 	"math/big"
 	"sort"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -18,6 +22,16 @@ var (
 	gwei      = big.NewInt(1e9)
 )
 
+// jamThresholds are the jam index levels which trigger a gas prices notification
+// when crossed in either direction, even if the predicted prices didn't change.
+var jamThresholds = []int{50, 150, 300, 600}
+
+const (
+	hotTargetMinTxs   = 50 // min pending transactions calling a contract for it to be hot
+	hotTargetMinShare = 10 // min percentage of the pending pool calling a contract for it to be hot
+	maxHotTargets     = 16 // max number of hot contracts priced separately
+)
+
 type Prediction struct {
 	cfg          *Config
 	txCnts       *Stats // tx count statistics of few latest blocks
@@ -30,6 +44,16 @@ type Prediction struct {
 	lockPredis    sync.RWMutex
 	wg            sync.WaitGroup
 	blockGasLimit uint64
+
+	hot map[common.Address][]uint // gas price prediction of the hot contracts, same order as predis
+
+	nextBaseFee *big.Int // expected base fee of the next block, nil before london
+
+	jamLevel   int // jam level of the last notification, only accessed by the loop
+	pricesFeed event.Feed
+
+	configCh chan PredConfig
+	quit     chan struct{}
 }
 
 func NewPrediction(cfg Config, backend OracleBackend, pool *core.TxPool) *Prediction {
@@ -44,6 +68,8 @@ func NewPrediction(cfg Config, backend OracleBackend, pool *core.TxPool) *Predic
 		backend:     backend,
 		chainHeadCh: make(chan core.ChainHeadEvent),
 		pool:        pool,
+		configCh:    make(chan PredConfig),
+		quit:        make(chan struct{}),
 	}
 	price := wei2GWei(cfg.Default)
 	p.predis = []uint{price * 2, price, price}
@@ -68,10 +94,23 @@ func (p *Prediction) Stop() {
 		return
 	}
 	p.chainHeadSub.Unsubscribe()
+	close(p.quit)
 	p.wg.Wait()
 	log.Info("prediction quit")
 }
 
+// SetPredConfig updates the configuration of the prediction, taking effect from
+// the next update.
+func (p *Prediction) SetPredConfig(pred PredConfig) {
+	if p.chainHeadSub == nil {
+		return
+	}
+	select {
+	case p.configCh <- pred:
+	case <-p.quit:
+	}
+}
+
 // CurrentPrices returns the current prediction about gas price in gwei;
 // the results should be readonly, and the reason didn't do a copy is that there's no necessary
 func (p *Prediction) CurrentPrices() []uint {
@@ -81,6 +120,43 @@ func (p *Prediction) CurrentPrices() []uint {
 	return prices
 }
 
+// PricesFor returns the current prediction about gas price in gwei of the
+// transactions calling the given contract, and whether the contract is hot. The
+// prices of the contracts which aren't hot are the general ones.
+func (p *Prediction) PricesFor(to common.Address) ([]uint, bool) {
+	p.lockPredis.RLock()
+	defer p.lockPredis.RUnlock()
+	if prices, ok := p.hot[to]; ok {
+		return prices, true
+	}
+	return p.predis, false
+}
+
+// NextBaseFee returns the expected base fee of the block following the current
+// head, nil if it has none. The pending transactions are priced at it.
+func (p *Prediction) NextBaseFee() *big.Int {
+	p.lockPredis.RLock()
+	defer p.lockPredis.RUnlock()
+	return p.nextBaseFee
+}
+
+// updateNextBaseFee forecasts the base fee of the block following the head.
+func (p *Prediction) updateNextBaseFee(head *types.Header) {
+	var baseFee *big.Int
+	if config := p.backend.ChainConfig(); config.IsLondon(new(big.Int).Add(head.Number, common.Big1)) {
+		baseFee = misc.CalcBaseFee(config, head)
+	}
+	p.lockPredis.Lock()
+	p.nextBaseFee = baseFee
+	p.lockPredis.Unlock()
+}
+
+// SubscribeGasPricesEvent registers a subscription of GasPricesEvent, fired when
+// the predicted prices change or the jam index crosses a threshold.
+func (p *Prediction) SubscribeGasPricesEvent(ch chan<- core.GasPricesEvent) event.Subscription {
+	return p.pricesFeed.Subscribe(ch)
+}
+
 func (p *Prediction) initTxCnts() {
 	cnts := make([]int, p.cfg.Blocks)
 	ctx := context.Background()
@@ -113,6 +189,7 @@ func (p *Prediction) initTxCnts() {
 
 	//gas limit
 	p.blockGasLimit = head.GasLimit
+	p.updateNextBaseFee(head)
 }
 
 func (p *Prediction) loop() {
@@ -120,18 +197,30 @@ func (p *Prediction) loop() {
 	p.update()
 
 	tick := time.NewTicker(time.Duration(p.cfg.PredictIntervalSecs) * time.Second)
-	defer tick.Stop()
+	defer func() { tick.Stop() }()
 	defer p.wg.Done()
 
 	for {
 		select {
 		case <-tick.C:
 			p.update()
+		case pred := <-p.configCh:
+			cfg := *p.cfg
+			cfg.PredConfig = pred
+			if pred.PredictIntervalSecs != p.cfg.PredictIntervalSecs {
+				tick.Stop()
+				tick = time.NewTicker(time.Duration(pred.PredictIntervalSecs) * time.Second)
+			}
+			p.cfg = &cfg
+			log.Info("Prediction reconfigured", "Interval", cfg.PredictIntervalSecs, "ff", cfg.FastFactor, "mf", cfg.MedianFactor,
+				"lf", cfg.LowFactor, "minMi", cfg.MinMedianIndex, "minLi", cfg.MinLowIndex, "fp", cfg.FastPercentile,
+				"mp", cfg.MeidanPercentile, "minCnt", cfg.MinTxCntPerBlock)
 		case ev := <-p.chainHeadCh:
 			head := ev.Block
 			txcnt := len(head.Transactions())
 			p.txCnts.Add(txcnt)
 			p.blockGasLimit = head.GasLimit()
+			p.updateNextBaseFee(head.Header())
 		case <-p.chainHeadSub.Err():
 			log.Warn("prediction loop quitting")
 			return
@@ -140,13 +229,16 @@ func (p *Prediction) loop() {
 }
 
 func (p *Prediction) update() {
-	txs := p.pool.Pending(true)
+	// price the transactions the way the miner selects them for the next block,
+	// by their effective tips at its base fee, leaving out the ones unable to pay it
+	baseFee := p.NextBaseFee()
+	txs := p.pool.PendingAt(true, baseFee)
 	byprice := make(TxByPrice, 0, len(txs))
 	for _, ts := range txs {
 		byprice = append(byprice, ts...)
 	}
-	byprice = p.filteroutInvalid(byprice)
-	sort.Sort(byprice)
+	byprice = p.filteroutInvalid(byprice, baseFee)
+	sort.Sort(txsByEffectiveTip{byprice, baseFee})
 
 	minPrice := wei2GWei(p.pool.GasPrice())
 	prices := make([]uint, 3)
@@ -155,6 +247,7 @@ func (p *Prediction) update() {
 	if pendingCnt == 0 {
 		// no pending tx, use minimum prices
 		prices = []uint{minPrice, minPrice, minPrice}
+		p.updateHot(nil)
 		p.updatePredis(prices)
 		return
 	}
@@ -163,13 +256,36 @@ func (p *Prediction) update() {
 	if avgTxCnt < p.cfg.MinTxCntPerBlock {
 		avgTxCnt = p.cfg.MinTxCntPerBlock
 	}
+	prices = p.tiers(byprice, baseFee, avgTxCnt, minPrice)
+
+	// price the hot targets on their own pending transactions, never below the
+	// rest of the chain
+	hot := make(map[common.Address][]uint)
+	for to, txs := range hotTargets(byprice) {
+		tiers := p.tiers(txs, baseFee, avgTxCnt, minPrice)
+		for i := range tiers {
+			if tiers[i] < prices[i] {
+				tiers[i] = prices[i]
+			}
+		}
+		hot[to] = tiers
+	}
+	p.updateHot(hot)
+	p.updatePredis(prices)
+}
+
+// tiers computes the fast, median and low prices of the pending transactions,
+// sorted descending by their effective tips at the base fee.
+func (p *Prediction) tiers(byprice TxByPrice, baseFee *big.Int, avgTxCnt int, minPrice uint) []uint {
+	prices := make([]uint, 3)
+	pendingCnt := len(byprice)
 
 	// fast price
 	fi := p.cfg.FastFactor * avgTxCnt
 	if pendingCnt <= fi {
 		fi = pendingCnt * p.cfg.FastPercentile / 100
 	}
-	prices[0] = wei2GWei(byprice[fi].GasPrice()) // fast price
+	prices[0] = wei2GWei(effectivePrice(byprice[fi], baseFee)) // fast price
 	// if the fast price is 1 gwei, and there are lots of pending transactions,
 	// then raise the fast price to 2 gwei.
 	if prices[0] == 1 && pendingCnt > fi {
@@ -180,14 +296,14 @@ func (p *Prediction) update() {
 	if pendingCnt <= mi {
 		mi = pendingCnt * p.cfg.MeidanPercentile / 100
 	}
-	prices[1] = wei2GWei(byprice[mi].GasPrice())
+	prices[1] = wei2GWei(effectivePrice(byprice[mi], baseFee))
 
 	// low price, notice the differentce
 	li := max(p.cfg.LowFactor*avgTxCnt, p.cfg.MinLowIndex)
 	if pendingCnt <= li {
 		prices[2] = minPrice
 	} else {
-		prices[2] = wei2GWei(byprice[li].GasPrice())
+		prices[2] = wei2GWei(effectivePrice(byprice[li], baseFee))
 	}
 	// make it more moderation
 	if pendingCnt > mi &&
@@ -195,11 +311,42 @@ func (p *Prediction) update() {
 		prices[1] == prices[2] {
 		prices[1]++
 	}
+	return prices
+}
 
-	p.updatePredis(prices)
+// hotTargets groups the pending transactions, sorted descending by price, by the
+// contracts they call, keeping the most crowded ones holding a large enough share
+// of the pool. The groups stay sorted.
+func hotTargets(byprice TxByPrice) map[common.Address]TxByPrice {
+	groups := make(map[common.Address]TxByPrice)
+	for _, tx := range byprice {
+		if to := tx.To(); to != nil && len(tx.Data()) > 0 {
+			groups[*to] = append(groups[*to], tx)
+		}
+	}
+	var targets []common.Address
+	for to, txs := range groups {
+		if len(txs) >= hotTargetMinTxs && len(txs)*100 >= len(byprice)*hotTargetMinShare {
+			targets = append(targets, to)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if len(groups[targets[i]]) != len(groups[targets[j]]) {
+			return len(groups[targets[i]]) > len(groups[targets[j]])
+		}
+		return bytes.Compare(targets[i][:], targets[j][:]) < 0
+	})
+	if len(targets) > maxHotTargets {
+		targets = targets[:maxHotTargets]
+	}
+	hot := make(map[common.Address]TxByPrice, len(targets))
+	for _, to := range targets {
+		hot[to] = groups[to]
+	}
+	return hot
 }
 
-func (p *Prediction) filteroutInvalid(txs TxByPrice) TxByPrice {
+func (p *Prediction) filteroutInvalid(txs TxByPrice, baseFee *big.Int) TxByPrice {
 	maxgas := (p.blockGasLimit / 10) * 6
 	maxlive := time.Duration(p.cfg.MaxValidPendingSecs) * time.Second
 	i, j := 0, len(txs)
@@ -207,7 +354,7 @@ func (p *Prediction) filteroutInvalid(txs TxByPrice) TxByPrice {
 		tx := txs[i]
 		if tx.Gas() > maxgas ||
 			time.Since(tx.LocalSeenTime()) > maxlive ||
-			tx.GasTipCapIntCmp(gwei) < 0 {
+			tx.EffectiveGasTipIntCmp(gwei, baseFee) < 0 {
 			j--
 			txs[i], txs[j] = txs[j], txs[i]
 			continue
@@ -219,11 +366,57 @@ func (p *Prediction) filteroutInvalid(txs TxByPrice) TxByPrice {
 }
 
 func (p *Prediction) updatePredis(prices []uint) {
+	// keep the predictions within the operator and governance bounds, so that a
+	// handful of high bidding pending transactions can't spike them
+	floor, ceil := priceBounds(p.cfg.MinPrice, p.cfg.MaxPrice, p.backend.ChainConfig())
+	for i := range prices {
+		prices[i] = clampGwei(prices[i], floor, ceil)
+	}
+	changed := false
 	p.lockPredis.Lock()
 	for i := 0; i < 3; i++ {
+		if p.predis[i] != prices[i] {
+			changed = true
+		}
 		p.predis[i] = prices[i]
 	}
 	p.lockPredis.Unlock()
+
+	jam := p.pool.JamIndex()
+	if level := jamLevel(jam); level != p.jamLevel {
+		p.jamLevel = level
+		changed = true
+	}
+	if changed {
+		p.pricesFeed.Send(core.GasPricesEvent{
+			Prices:   []uint{prices[0], prices[1], prices[2]},
+			JamIndex: jam,
+			JamLevel: p.jamLevel,
+		})
+	}
+}
+
+// updateHot replaces the predictions of the hot targets, kept within the same
+// bounds as the general ones.
+func (p *Prediction) updateHot(hot map[common.Address][]uint) {
+	floor, ceil := priceBounds(p.cfg.MinPrice, p.cfg.MaxPrice, p.backend.ChainConfig())
+	for _, prices := range hot {
+		for i := range prices {
+			prices[i] = clampGwei(prices[i], floor, ceil)
+		}
+	}
+	p.lockPredis.Lock()
+	p.hot = hot
+	p.lockPredis.Unlock()
+}
+
+// jamLevel returns the number of jam thresholds reached by the jam index.
+func jamLevel(jam int) int {
+	level := 0
+	for level < len(jamThresholds) && jam >= jamThresholds[level] {
+		level++
+	}
+	return level
 }
 
 func max(a, b int) int {
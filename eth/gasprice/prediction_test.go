@@ -0,0 +1,117 @@
+package gasprice
+
+import (
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestJamLevel(t *testing.T) {
+	tests := []struct {
+		jam, level int
+	}{
+		{0, 0},
+		{jamThresholds[0] - 1, 0},
+		{jamThresholds[0], 1},
+		{jamThresholds[1] + 1, 2},
+		{jamThresholds[len(jamThresholds)-1], len(jamThresholds)},
+		{jamThresholds[len(jamThresholds)-1] * 10, len(jamThresholds)},
+	}
+	for i, tt := range tests {
+		if level := jamLevel(tt.jam); level != tt.level {
+			t.Errorf("test %d: jam level mismatch: have %d, want %d", i, level, tt.level)
+		}
+	}
+}
+
+func TestPredConfigValidate(t *testing.T) {
+	tests := []struct {
+		cfg PredConfig
+		ok  bool
+	}{
+		{PredConfig{}, true},
+		{PredConfig{PredictIntervalSecs: 3, FastFactor: 2, FastPercentile: 75, MeidanPercentile: 90}, true},
+		{PredConfig{PredictIntervalSecs: -1}, false},
+		{PredConfig{LowFactor: -1}, false},
+		{PredConfig{FastPercentile: 101}, false},
+		{PredConfig{MeidanPercentile: -1}, false},
+	}
+	for i, tt := range tests {
+		if err := tt.cfg.Validate(); (err == nil) != tt.ok {
+			t.Errorf("test %d: validation mismatch: have %v, want ok %v", i, err, tt.ok)
+		}
+	}
+}
+
+func TestHotTargets(t *testing.T) {
+	var (
+		hot   = common.HexToAddress("0x01")
+		cold  = common.HexToAddress("0x02")
+		plain = common.HexToAddress("0x03")
+		txs   TxByPrice
+	)
+	call := func(to common.Address, data []byte, gwei int64) *types.Transaction {
+		return types.NewTransaction(0, to, common.Big0, 21000, new(big.Int).Mul(big.NewInt(gwei), big.NewInt(1e9)), data)
+	}
+	for i := 0; i < hotTargetMinTxs; i++ {
+		txs = append(txs, call(hot, []byte{0x01}, 100))
+		txs = append(txs, call(plain, nil, 10))
+	}
+	txs = append(txs, call(cold, []byte{0x01}, 5))
+	sort.Sort(txs)
+
+	targets := hotTargets(txs)
+	if len(targets) != 1 || len(targets[hot]) != hotTargetMinTxs {
+		t.Fatalf("hot targets mismatch: have %d targets, %d hot txs", len(targets), len(targets[hot]))
+	}
+
+	p := &Prediction{cfg: &Config{PredConfig: PredConfig{
+		FastFactor: 1, MedianFactor: 6, LowFactor: 8, MinMedianIndex: 10, MinLowIndex: 20,
+		FastPercentile: 75, MeidanPercentile: 90,
+	}}}
+	general := p.tiers(txs, nil, 10, 1)
+	contract := p.tiers(targets[hot], nil, 10, 1)
+	if top := wei2GWei(targets[hot][0].GasPrice()); contract[0] != top || contract[1] != top {
+		t.Errorf("hot contract prices mismatch: have %v", contract)
+	}
+	if general[1] >= contract[1] {
+		t.Errorf("general median %d not below the hot contract one %d", general[1], contract[1])
+	}
+}
+
+func TestEffectiveTipOrder(t *testing.T) {
+	var (
+		to      = common.HexToAddress("0x01")
+		baseFee = new(big.Int).Mul(big.NewInt(10), gwei)
+	)
+	dynamic := func(feeCap, tip int64) *types.Transaction {
+		return types.NewTx(&types.DynamicFeeTx{
+			To:        &to,
+			Gas:       21000,
+			GasFeeCap: new(big.Int).Mul(big.NewInt(feeCap), gwei),
+			GasTipCap: new(big.Int).Mul(big.NewInt(tip), gwei),
+		})
+	}
+	var (
+		legacy  = types.NewTransaction(0, to, common.Big0, 21000, new(big.Int).Mul(big.NewInt(12), gwei), nil)
+		capped  = dynamic(13, 5)  // effective tip 3 gwei
+		overpay = dynamic(100, 1) // effective tip 1 gwei, despite the high fee cap
+		txs     = TxByPrice{overpay, legacy, capped}
+	)
+	sort.Sort(txsByEffectiveTip{txs, baseFee})
+	if txs[0] != capped || txs[1] != legacy || txs[2] != overpay {
+		t.Fatalf("effective tip order mismatch: have %x, %x, %x", txs[0].Hash(), txs[1].Hash(), txs[2].Hash())
+	}
+	for i, want := range []int64{13, 12, 11} {
+		if have := effectivePrice(txs[i], baseFee); have.Cmp(new(big.Int).Mul(big.NewInt(want), gwei)) != 0 {
+			t.Errorf("tx %d: effective price mismatch: have %v, want %d gwei", i, have, want)
+		}
+	}
+	// Without a base fee the prices are the plain gas prices
+	if have := effectivePrice(overpay, nil); have.Cmp(overpay.GasFeeCap()) != 0 {
+		t.Errorf("price without base fee mismatch: have %v, want %v", have, overpay.GasFeeCap())
+	}
+}
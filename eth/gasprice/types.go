@@ -1,6 +1,11 @@
 package gasprice
 
-import "github.com/ethereum/go-ethereum/core/types"
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+)
 
 // TxByPrice sorts the txs descending by price
 type TxByPrice types.Transactions
@@ -10,3 +15,25 @@ func (s TxByPrice) Less(i, j int) bool {
 	return s[i].GasTipCapCmp(s[j]) > 0 // descending
 }
 func (s TxByPrice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+// txsByEffectiveTip sorts the txs descending by their effective tips at the base
+// fee, i.e. in the order the miner selects them at it.
+type txsByEffectiveTip struct {
+	txs     TxByPrice
+	baseFee *big.Int
+}
+
+func (s txsByEffectiveTip) Len() int { return len(s.txs) }
+func (s txsByEffectiveTip) Less(i, j int) bool {
+	return s.txs[i].EffectiveGasTipCmp(s.txs[j], s.baseFee) > 0 // descending
+}
+func (s txsByEffectiveTip) Swap(i, j int) { s.txs[i], s.txs[j] = s.txs[j], s.txs[i] }
+
+// effectivePrice returns the gas price the tx pays at the base fee, its gas price
+// if there's none.
+func effectivePrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasPrice()
+	}
+	return math.BigMin(tx.GasFeeCap(), new(big.Int).Add(baseFee, tx.GasTipCap()))
+}
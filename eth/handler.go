@@ -25,29 +25,52 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/fetcher"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/heco"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
+	lru "github.com/hashicorp/golang-lru"
 )
 
 const (
 	// txChanSize is the size of channel listening to NewTxsEvent.
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
+
+	// txAgesCacheSize is the number of announced transaction first seen times
+	// kept for the transactions being fetched.
+	txAgesCacheSize = 32768
+
+	// maxAnnouncedTxAge is the maximum transaction age accepted from the peers,
+	// the older announcements are capped to it. It's kept well under the pending
+	// window of the gas price prediction, so that the peers can't age the fresh
+	// transactions out of it.
+	maxAnnouncedTxAge = time.Minute
+
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	// attestationWindow is the number of recent block numbers the validators'
+	// head attestations are kept for.
+	attestationWindow = 128
 )
 
 var (
 	syncChallengeTimeout = 15 * time.Second // Time allowance for a node to reply to the sync progress challenge
+
+	errInvalidAttestation = errors.New("invalid head attestation signature")
 )
 
 // txPool defines the methods needed from a transaction pool implementation to
@@ -85,6 +108,18 @@ type handlerConfig struct {
 	EventMux   *event.TypeMux            // Legacy event mux, deprecate for `feed`
 	Checkpoint *params.TrustedCheckpoint // Hard coded checkpoint for sync challenges
 	Whitelist  map[uint64]common.Hash    // Hard coded whitelist for sync challenged
+	SyncTarget common.Hash               // Trusted block hash the synced chain must contain
+	TxFetcher  fetcher.TxFetcherConfig   // Limits of the transaction announcement fetcher
+	KnownTxs   int                       // Size of the per-peer known transactions cache, 0 for default
+	StrictFork bool                      // Whether to drop peers scheduling a different next fork
+
+	PrivateTxPeers []*enode.Node // Peers the private transactions are sent to, on top of the trusted ones
+	ValidatorPeers []*enode.Node // Peers labeled as validators, prioritized and exempt from the egress caps
+	BlockCap       uint64        // Per-peer block egress cap of the ordinary peers in bytes/s, 0 for uncapped
+	TxCap          uint64        // Per-peer transaction egress cap of the ordinary peers in bytes/s, 0 for uncapped
+
+	LatencyRatio     int           // Percentage of the peer slots preferably held by low latency peers, 0 to disable
+	LatencyThreshold time.Duration // Ping round trip time under which a peer is low latency
 }
 
 type handler struct {
@@ -103,16 +138,27 @@ type handler struct {
 	chain    *core.BlockChain
 	maxPeers int
 
+	latencyRatio     int           // Percentage of the peer slots preferably held by low latency peers
+	latencyThreshold time.Duration // Ping round trip time under which a peer is low latency
+
 	downloader   *downloader.Downloader
 	stateBloom   *trie.SyncBloom
 	blockFetcher *fetcher.BlockFetcher
 	txFetcher    *fetcher.TxFetcher
+	txAges       *lru.Cache // First seen times of the transactions announced along with their ages
+	attestations *core.AttestationSet
 	peers        *peerSet
+	privateTxs   *privateTxSet
+	validators   map[enode.ID]struct{} // Peers labeled as validators
+
+	delayedBlocks *delayedBlockSet // Blocks sent ahead of their release by the trusted peers
 
 	eventMux      *event.TypeMux
 	txsCh         chan core.NewTxsEvent
 	txsSub        event.Subscription
 	minedBlockSub *event.TypeMuxSubscription
+	chainHeadCh   chan core.ChainHeadEvent
+	chainHeadSub  event.Subscription
 
 	whitelist map[uint64]common.Hash
 
@@ -130,17 +176,36 @@ func newHandler(config *handlerConfig) (*handler, error) {
 	if config.EventMux == nil {
 		config.EventMux = new(event.TypeMux) // Nicety initialization for tests
 	}
+	if config.KnownTxs > 0 {
+		eth.SetKnownTxsCacheSize(config.KnownTxs)
+	}
+	eth.SetEgressCaps(eth.EgressCaps{Blocks: config.BlockCap, Txs: config.TxCap})
+	forkFilter := forkid.NewFilter(config.Chain)
+	if config.StrictFork {
+		forkFilter = forkid.NewStrictFilter(config.Chain)
+	}
 	h := &handler{
 		networkID:  config.Network,
-		forkFilter: forkid.NewFilter(config.Chain),
+		forkFilter: forkFilter,
 		eventMux:   config.EventMux,
 		database:   config.Database,
 		txpool:     config.TxPool,
 		chain:      config.Chain,
 		peers:      newPeerSet(),
+		privateTxs: newPrivateTxSet(config.PrivateTxPeers, privateTxLifetime),
+		validators: make(map[enode.ID]struct{}, len(config.ValidatorPeers)),
 		whitelist:  config.Whitelist,
 		quitSync:   make(chan struct{}),
+
+		latencyRatio:     config.LatencyRatio,
+		latencyThreshold: config.LatencyThreshold,
+	}
+	for _, peer := range config.ValidatorPeers {
+		h.validators[peer.ID()] = struct{}{}
 	}
+	h.txAges, _ = lru.New(txAgesCacheSize)
+	h.attestations = core.NewAttestationSet(attestationWindow)
+	h.delayedBlocks = newDelayedBlockSet()
 	if config.Sync == downloader.FullSync {
 		// The database seems empty as the current block is the genesis. Yet the fast
 		// block is ahead, so fast sync was enabled for this node at a certain point.
@@ -183,6 +248,9 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		h.stateBloom = trie.NewSyncBloom(config.BloomCache, config.Database)
 	}
 	h.downloader = downloader.New(h.checkpointNumber, config.Database, h.stateBloom, h.eventMux, h.chain, nil, h.removePeer)
+	if config.SyncTarget != (common.Hash{}) {
+		h.downloader.SetSyncTarget(config.SyncTarget)
+	}
 
 	// Construct the fetcher (short sync)
 	validator := func(header *types.Header) error {
@@ -226,12 +294,12 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		}
 		return p.RequestTxs(hashes)
 	}
-	h.txFetcher = fetcher.NewTxFetcher(h.txpool.Has, h.txpool.AddRemotes, fetchTx)
+	h.txFetcher = fetcher.NewTxFetcherWithConfig(config.TxFetcher, h.txpool.Has, h.txpool.AddRemotes, fetchTx)
 	h.chainSync = newChainSyncer(h)
 	return h, nil
 }
 
-// runEthPeer registers an eth peer into the joint eth/snap peerset, adds it to
+// runEthPeer registers an eth peer into the joint eth/snap/heco peerset, adds it to
 // various subsistems and starts handling messages.
 func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 	// If the peer has a `snap` extension, wait for it to connect so we can have
@@ -241,6 +309,11 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 		peer.Log().Error("Snapshot extension barrier failed", "err", err)
 		return err
 	}
+	hecoExt, err := h.peers.waitHecoExtension(peer)
+	if err != nil {
+		peer.Log().Error("HECO extension barrier failed", "err", err)
+		return err
+	}
 	// TODO(karalabe): Not sure why this is needed
 	if !h.chainSync.handlePeerEvent(peer) {
 		return p2p.DiscQuitting
@@ -278,10 +351,13 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 			return p2p.DiscTooManyPeers
 		}
 	}
-	peer.Log().Debug("Ethereum peer connected", "name", peer.Name())
+	if _, ok := h.validators[peer.Peer.ID()]; ok {
+		peer.SetValidator(true)
+	}
+	peer.Log().Debug("Ethereum peer connected", "name", peer.Name(), "validator", peer.Validator())
 
 	// Register the peer locally
-	if err := h.peers.registerPeer(peer, snap); err != nil {
+	if err := h.peers.registerPeer(peer, snap, hecoExt); err != nil {
 		peer.Log().Error("Ethereum peer registration failed", "err", err)
 		return err
 	}
@@ -306,7 +382,7 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 
 	// Propagate existing transactions. new transactions appearing
 	// after this will be sent via broadcasts.
-	h.syncTransactions(peer)
+	h.syncTransactions(p)
 
 	// If we have a trusted CHT, reject all peers below that (avoid fast sync eclipse)
 	if h.checkpointHash != (common.Hash{}) {
@@ -352,6 +428,21 @@ func (h *handler) runSnapExtension(peer *snap.Peer, handler snap.Handler) error
 	return handler(peer)
 }
 
+// runHecoExtension registers a `heco` peer into the joint eth/snap/heco peerset
+// and starts handling inbound messages. As `heco` is only a satellite protocol
+// to `eth`, all subsystem registrations and lifecycle management will be done
+// by the main `eth` handler to prevent strange races.
+func (h *handler) runHecoExtension(peer *heco.Peer, handler heco.Handler) error {
+	h.peerWG.Add(1)
+	defer h.peerWG.Done()
+
+	if err := h.peers.registerHecoExtension(peer); err != nil {
+		peer.Log().Error("HECO extension registration failed", "err", err)
+		return err
+	}
+	return handler(peer)
+}
+
 // removePeer requests disconnection of a peer.
 func (h *handler) removePeer(id string) {
 	peer := h.peers.peer(id)
@@ -377,7 +468,7 @@ func (h *handler) unregisterPeer(id string) {
 		return
 	}
 	// Remove the `eth` peer if it exists
-	logger.Debug("Removing Ethereum peer", "snap", peer.snapExt != nil)
+	logger.Debug("Removing Ethereum peer", "snap", peer.snapExt != nil, "heco", peer.hecoExt != nil)
 
 	// Remove the `snap` extension if it exists
 	if peer.snapExt != nil {
@@ -405,6 +496,18 @@ func (h *handler) Start(maxPeers int) {
 	h.minedBlockSub = h.eventMux.Subscribe(core.NewMinedBlockEvent{})
 	go h.minedBroadcastLoop()
 
+	// attest the new heads if running as a congress validator
+	h.wg.Add(1)
+	h.chainHeadCh = make(chan core.ChainHeadEvent, chainHeadChanSize)
+	h.chainHeadSub = h.chain.SubscribeChainHeadEvent(h.chainHeadCh)
+	go h.attestationLoop()
+
+	// prefer the low latency peers if configured
+	if h.latencyRatio > 0 {
+		h.wg.Add(1)
+		go h.latencyLoop()
+	}
+
 	// start sync handlers
 	h.wg.Add(1)
 	go h.chainSync.loop()
@@ -413,6 +516,7 @@ func (h *handler) Start(maxPeers int) {
 func (h *handler) Stop() {
 	h.txsSub.Unsubscribe()        // quits txBroadcastLoop
 	h.minedBlockSub.Unsubscribe() // quits blockBroadcastLoop
+	h.chainHeadSub.Unsubscribe()  // quits attestationLoop
 
 	// Quit chainSync and txsync64.
 	// After this is done, no new peers will be accepted.
@@ -445,8 +549,16 @@ func (h *handler) BroadcastBlock(block *types.Block, propagate bool) {
 			log.Error("Propagating dangling block", "number", block.Number(), "hash", hash)
 			return
 		}
-		// Send the block to a subset of our peers
-		transfer := peers[:int(math.Sqrt(float64(len(peers))))]
+		// Send the block to all the validators first, then to a subset of the rest
+		var transfer, others []*ethPeer
+		for _, peer := range peers {
+			if peer.Validator() {
+				transfer = append(transfer, peer)
+			} else {
+				others = append(others, peer)
+			}
+		}
+		transfer = append(transfer, others[:int(math.Sqrt(float64(len(others))))]...)
 		for _, peer := range transfer {
 			log.Info("metric", "method", "broadcastBlock", "peer", peer.ID(), "hash", block.Header().Hash().String(), "number", block.Header().Number.Uint64(), "fullBlock", true)
 			peer.AsyncSendNewBlock(block, td)
@@ -479,6 +591,11 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 		annos = make(map[*ethPeer][]common.Hash) // Set peer->hash to announce
 
 	)
+	// Send the private transactions to the private peers only
+	txs, private := h.privateTxs.split(txs)
+	if len(private) > 0 {
+		h.broadcastPrivateTransactions(private)
+	}
 	// Broadcast transactions to a batch of peers not knowing about it
 	for _, tx := range txs {
 		peers := h.peers.peersWithoutTransaction(tx.Hash())
@@ -501,6 +618,9 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 		annoPeers++
 		annoCount += len(hashes)
 		peer.AsyncSendPooledTransactionHashes(hashes)
+		if peer.hecoExt != nil {
+			peer.hecoExt.AsyncSendTransactionAges(h.transactionAges(hashes))
+		}
 	}
 	log.Debug("Transaction broadcast", "txs", len(txs),
 		"announce packs", annoPeers, "announced hashes", annoCount,
@@ -531,3 +651,64 @@ func (h *handler) txBroadcastLoop() {
 		}
 	}
 }
+
+// BroadcastAttestations propagates a batch of head attestations to all the `heco`
+// peers not known to have them yet.
+func (h *handler) BroadcastAttestations(atts []*types.HeadAttestation) {
+	batches := make(map[*ethPeer][]*types.HeadAttestation)
+	for _, att := range atts {
+		for _, peer := range h.peers.peersWithoutAttestation(att.ID()) {
+			batches[peer] = append(batches[peer], att)
+		}
+	}
+	for peer, batch := range batches {
+		peer.hecoExt.AsyncSendHeadAttestations(batch)
+	}
+	log.Trace("Head attestation broadcast", "attestations", len(atts), "peers", len(batches))
+}
+
+// peerHeads reports the number of connected peers, and whether any of them
+// announced a head heavier than the given parent, for the congress engine to
+// tell an isolated validator from a chain stalled network wide.
+func (h *handler) peerHeads(parent *types.Header) (int, bool) {
+	peers := h.peers.len()
+
+	td := h.chain.GetTd(parent.Hash(), parent.Number.Uint64())
+	if td == nil {
+		return peers, true
+	}
+	if peer := h.peers.peerWithHighestTD(); peer != nil {
+		if _, peerTD := peer.Head(); peerTD.Cmp(td) > 0 {
+			return peers, true
+		}
+	}
+	return peers, false
+}
+
+// attestationLoop signs and propagates an attestation of each new chain head if
+// the node holds congress validator credentials.
+func (h *handler) attestationLoop() {
+	defer h.wg.Done()
+
+	engine, _ := h.chain.Engine().(*congress.Congress)
+	for {
+		select {
+		case ev := <-h.chainHeadCh:
+			if engine == nil || atomic.LoadUint32(&h.acceptTxs) == 0 {
+				continue
+			}
+			number, hash := ev.Block.NumberU64(), ev.Block.Hash()
+			validator, sig, err := engine.SignAttestation(congress.AttestationMessage(h.chain.Config().ChainID, number, hash))
+			if err != nil {
+				log.Trace("Skipped head attestation", "number", number, "hash", hash, "err", err)
+				continue
+			}
+			att := &types.HeadAttestation{Number: number, Hash: hash, Validator: validator, Signature: sig}
+			if h.attestations.Add(att) {
+				h.BroadcastAttestations([]*types.HeadAttestation{att})
+			}
+		case <-h.chainHeadSub.Err():
+			return
+		}
+	}
+}
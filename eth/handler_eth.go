@@ -48,7 +48,9 @@ func (h *ethHandler) RunPeer(peer *eth.Peer, hand eth.Handler) error {
 // PeerInfo retrieves all known `eth` information about a peer.
 func (h *ethHandler) PeerInfo(id enode.ID) interface{} {
 	if p := h.peers.peer(id.String()); p != nil {
-		return p.info()
+		info := p.info()
+		info.Txs = h.txFetcher.PeerStats(p.ID())
+		return info
 	}
 	return nil
 }
@@ -94,9 +96,11 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 		return h.txFetcher.Notify(peer.ID(), *packet)
 
 	case *eth.TransactionsPacket:
+		h.applyTxAges(*packet)
 		return h.txFetcher.Enqueue(peer.ID(), *packet, false)
 
 	case *eth.PooledTransactionsPacket:
+		h.applyTxAges(*packet)
 		return h.txFetcher.Enqueue(peer.ID(), *packet, true)
 
 	default:
@@ -104,6 +108,21 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 	}
 }
 
+// applyTxAges backdates the first seen times of the received transactions to the
+// ones announced by the peers, if any is earlier than the local one.
+func (h *ethHandler) applyTxAges(txs []*types.Transaction) {
+	for _, tx := range txs {
+		seen, ok := h.txAges.Get(tx.Hash())
+		if !ok {
+			continue
+		}
+		h.txAges.Remove(tx.Hash())
+		if seen := seen.(time.Time); seen.Before(tx.LocalSeenTime()) {
+			tx.SetLocalSeenTime(seen)
+		}
+	}
+}
+
 // handleHeaders is invoked from a peer's message handler when it transmits a batch
 // of headers for the local node to process.
 func (h *ethHandler) handleHeaders(peer *eth.Peer, headers []*types.Header) error {
@@ -166,6 +185,12 @@ func (h *ethHandler) handleBodies(peer *eth.Peer, txs [][]*types.Transaction, un
 	// Filter out any explicitly requested bodies, deliver the rest to the downloader
 	filter := len(txs) > 0 || len(uncles) > 0
 	if filter {
+		// Once synced, the bodies are mostly the ones of the announced blocks
+		if h.AcceptTxs() {
+			for _, body := range txs {
+				h.reconcileTxs(peer, body)
+			}
+		}
 		txs, uncles = h.blockFetcher.FilterBodies(peer.ID(), txs, uncles, time.Now())
 	}
 	if len(txs) > 0 || len(uncles) > 0 || !filter {
@@ -202,6 +227,9 @@ func (h *ethHandler) handleBlockAnnounces(peer *eth.Peer, hashes []common.Hash,
 func (h *ethHandler) handleBlockBroadcast(peer *eth.Peer, block *types.Block, td *big.Int) error {
 	// Schedule the block for import
 	h.blockFetcher.Enqueue(peer.ID(), block)
+	if h.AcceptTxs() {
+		h.reconcileTxs(peer, block.Transactions())
+	}
 
 	// Assuming the block is importable by the peer, but possibly not yet done so,
 	// calculate the head hash and TD that the peer truly must have.
@@ -216,3 +244,18 @@ func (h *ethHandler) handleBlockBroadcast(peer *eth.Peer, block *types.Block, td
 	}
 	return nil
 }
+
+// reconcileTxs notifies the transaction fetcher of the transactions of a block
+// received from the peer, to fetch first the announced ones the pool is missing.
+func (h *ethHandler) reconcileTxs(peer *eth.Peer, txs []*types.Transaction) {
+	if len(txs) == 0 {
+		return
+	}
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	if err := h.txFetcher.Reconcile(peer.ID(), hashes); err != nil {
+		peer.Log().Debug("Failed to reconcile block transactions", "err", err)
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/heco"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
@@ -740,3 +741,44 @@ func testBroadcastMalformedBlock(t *testing.T, protocol uint) {
 		}
 	}
 }
+
+// Tests that the transactions received are backdated to the earliest first seen
+// time announced by the peers, capped to the maximum accepted age.
+func TestApplyAnnouncedTxAges(t *testing.T) {
+	handler := newTestHandler()
+	defer handler.close()
+
+	var txs []*types.Transaction
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{}, big.NewInt(0), 100000, big.NewInt(0), nil), types.HomesteadSigner{}, testKey)
+		txs = append(txs, tx)
+	}
+	h := (*ethHandler)(handler.handler)
+	(*hecoHandler)(h).handleTxAges([]heco.TransactionAge{
+		{Hash: txs[0].Hash(), Age: 1000},
+		{Hash: txs[0].Hash(), Age: 30000},
+		{Hash: txs[0].Hash(), Age: 2000},
+		{Hash: txs[1].Hash(), Age: uint64(24 * time.Hour / time.Millisecond)},
+	})
+	// Decode the transactions as received from the network
+	for i, tx := range txs {
+		blob, _ := tx.MarshalBinary()
+		txs[i] = new(types.Transaction)
+		txs[i].UnmarshalBinary(blob)
+	}
+	received := time.Now()
+	h.applyTxAges(txs)
+
+	if age := received.Sub(txs[0].LocalSeenTime()); age < 30*time.Second || age > 35*time.Second {
+		t.Errorf("tx 0: age mismatch: have %v, want ~%v", age, 30*time.Second)
+	}
+	if age := received.Sub(txs[1].LocalSeenTime()); age < maxAnnouncedTxAge || age > maxAnnouncedTxAge+5*time.Second {
+		t.Errorf("tx 1: age mismatch: have %v, want ~%v", age, maxAnnouncedTxAge)
+	}
+	if age := received.Sub(txs[2].LocalSeenTime()); age > 5*time.Second {
+		t.Errorf("tx 2: unannounced transaction backdated by %v", age)
+	}
+	if h.txAges.Len() != 0 {
+		t.Errorf("announced ages not released: %d left", h.txAges.Len())
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/congress"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/heco"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// hecoHandler implements the heco.Backend interface to handle the various network
+// packets that are sent as broadcasts.
+type hecoHandler handler
+
+func (h *hecoHandler) Chain() *core.BlockChain { return h.chain }
+
+// RunPeer is invoked when a peer joins on the `heco` protocol.
+func (h *hecoHandler) RunPeer(peer *heco.Peer, hand heco.Handler) error {
+	return (*handler)(h).runHecoExtension(peer, hand)
+}
+
+// PeerInfo retrieves all known `heco` information about a peer.
+func (h *hecoHandler) PeerInfo(id enode.ID) interface{} {
+	if p := h.peers.peer(id.String()); p != nil {
+		if p.hecoExt != nil {
+			return p.hecoExt.info()
+		}
+	}
+	return nil
+}
+
+// Handle is invoked from a peer's message handler when it receives a new remote
+// message that the handler couldn't consume and serve itself.
+func (h *hecoHandler) Handle(peer *heco.Peer, packet heco.Packet) error {
+	switch packet := packet.(type) {
+	case *heco.TransactionAgesPacket:
+		// Transaction ages are only meaningful along with the announcements
+		if atomic.LoadUint32(&h.acceptTxs) == 0 {
+			return nil
+		}
+		h.handleTxAges(*packet)
+		return nil
+
+	case *heco.HeadAttestationsPacket:
+		// Attestations are only meaningful once we're synced
+		if atomic.LoadUint32(&h.acceptTxs) == 0 {
+			return nil
+		}
+		return h.handleAttestations(peer, *packet)
+
+	case *heco.DelayedBlockPacket:
+		return h.handleDelayedBlock(peer, packet.Block, packet.Release)
+
+	case *heco.DelayedBlockCancelPacket:
+		return h.handleDelayedBlockCancel(peer, packet.Hash)
+
+	default:
+		return fmt.Errorf("unexpected heco packet type: %T", packet)
+	}
+}
+
+// transactionAges assembles the ages of the given pooled transactions, the time
+// since they were first seen locally, to go along with their announcement.
+func (h *handler) transactionAges(hashes []common.Hash) []heco.TransactionAge {
+	var (
+		now  = time.Now()
+		ages = make([]heco.TransactionAge, len(hashes))
+	)
+	for i, hash := range hashes {
+		ages[i].Hash = hash
+		if tx := h.txpool.Get(hash); tx != nil {
+			if age := now.Sub(tx.LocalSeenTime()); age > 0 {
+				ages[i].Age = uint64(age / time.Millisecond)
+			}
+		}
+	}
+	return ages
+}
+
+// handleTxAges records the first seen times of the transactions announced along
+// with their ages, keeping the earliest one across the announcers. The ages are
+// capped, so that a peer can't backdate the transactions by more than that.
+func (h *hecoHandler) handleTxAges(anns []heco.TransactionAge) {
+	now := time.Now()
+	for _, ann := range anns {
+		age := time.Duration(ann.Age) * time.Millisecond
+		if ann.Age > uint64(maxAnnouncedTxAge/time.Millisecond) {
+			age = maxAnnouncedTxAge
+		}
+		seen := now.Add(-age)
+		if prev, ok := h.txAges.Get(ann.Hash); ok && !seen.Before(prev.(time.Time)) {
+			continue
+		}
+		h.txAges.Add(ann.Hash, seen)
+	}
+}
+
+// handleAttestations is invoked from a peer's message handler when it transmits
+// a batch of head attestations. The ones signed by the current validators are
+// stored and relayed to the other peers, a bad signature drops the peer.
+func (h *hecoHandler) handleAttestations(peer *heco.Peer, atts []*types.HeadAttestation) error {
+	engine, ok := h.chain.Engine().(*congress.Congress)
+	if !ok {
+		return nil
+	}
+	var (
+		chainID = h.chain.Config().ChainID
+		limit   = h.chain.CurrentHeader().Number.Uint64() + attestationWindow
+		fresh   []*types.HeadAttestation
+	)
+	for _, att := range atts {
+		signer, err := congress.RecoverAttestation(congress.AttestationMessage(chainID, att.Number, att.Hash), att.Signature)
+		if err != nil || signer != att.Validator {
+			return errInvalidAttestation
+		}
+		// Attestations too far ahead of us or by non-validators are ignored, they
+		// might be fine on the other side of a fork or an epoch.
+		if att.Number > limit || !engine.IsCurrentValidator(h.chain, att.Validator) {
+			continue
+		}
+		if h.attestations.Add(att) {
+			fresh = append(fresh, att)
+		}
+	}
+	if len(fresh) > 0 {
+		(*handler)(h).BroadcastAttestations(fresh)
+	}
+	return nil
+}
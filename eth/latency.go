@@ -0,0 +1,106 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// latencyCheckInterval is the interval the peer set is checked for low latency
+// peers at, at most one peer being dropped per check to bound the churn.
+const latencyCheckInterval = time.Minute
+
+var latencyDropMeter = metrics.NewRegisteredMeter("eth/latency/drops", nil)
+
+// latencyCandidate is a peer the latency preference may drop.
+type latencyCandidate struct {
+	id  string
+	rtt time.Duration // Average ping round trip time, 0 if not measured yet
+}
+
+// latencyLoop drops the slowest ordinary peer once in a while if the peer set is
+// full yet holds less low latency peers than preferred, freeing a slot for the
+// dialer to find a closer one.
+func (h *handler) latencyLoop() {
+	defer h.wg.Done()
+
+	ticker := time.NewTicker(latencyCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if id, rtt := h.latencyDrop(); id != "" {
+				log.Debug("Dropping high latency peer", "id", id, "rtt", rtt)
+				latencyDropMeter.Mark(1)
+				h.removePeer(id)
+			}
+		case <-h.quitSync:
+			return
+		}
+	}
+}
+
+// latencyDrop returns the peer to drop in favour of a lower latency one, if any.
+// Trusted, static and validator peers are kept regardless of their latency.
+func (h *handler) latencyDrop() (string, time.Duration) {
+	peers := h.peers.allPeers()
+	if len(peers) < h.maxPeers {
+		return "", 0 // room left, the dialer is filling it anyway
+	}
+	var (
+		fast       int
+		candidates []latencyCandidate
+	)
+	for _, peer := range peers {
+		rtt := peer.RTT()
+		if rtt != 0 && rtt <= h.latencyThreshold {
+			fast++
+			continue
+		}
+		info := peer.Peer.Info().Network
+		if info.Trusted || info.Static || peer.Validator() {
+			continue
+		}
+		candidates = append(candidates, latencyCandidate{id: peer.ID(), rtt: rtt})
+	}
+	return pickLatencyDrop(candidates, fast, h.maxPeers, h.latencyRatio)
+}
+
+// pickLatencyDrop returns the slowest measured candidate if the low latency peers
+// are less than the preferred ratio of the peer slots, in percent.
+func pickLatencyDrop(candidates []latencyCandidate, fast int, maxPeers int, ratio int) (string, time.Duration) {
+	if fast*100 >= maxPeers*ratio {
+		return "", 0
+	}
+	var slowest *latencyCandidate
+	for i := range candidates {
+		if candidates[i].rtt == 0 {
+			continue // not measured yet, give it a chance
+		}
+		if slowest == nil || candidates[i].rtt > slowest.rtt {
+			slowest = &candidates[i]
+		}
+	}
+	if slowest == nil {
+		return "", 0
+	}
+	return slowest.id, slowest.rtt
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPickLatencyDrop(t *testing.T) {
+	candidates := []latencyCandidate{
+		{id: "a", rtt: 150 * time.Millisecond},
+		{id: "b", rtt: 0},
+		{id: "c", rtt: 400 * time.Millisecond},
+		{id: "d", rtt: 200 * time.Millisecond},
+	}
+	tests := []struct {
+		candidates []latencyCandidate
+		fast       int
+		ratio      int
+		want       string
+	}{
+		// Enough low latency peers, nothing dropped
+		{candidates, 5, 50, ""},
+		{candidates, 6, 50, ""},
+		// Too few low latency peers, the slowest measured one is dropped
+		{candidates, 4, 50, "c"},
+		{candidates, 0, 100, "c"},
+		// Unmeasured peers are never dropped
+		{candidates[1:2], 0, 50, ""},
+		{nil, 0, 50, ""},
+	}
+	for i, tt := range tests {
+		if id, _ := pickLatencyDrop(tt.candidates, tt.fast, 10, tt.ratio); id != tt.want {
+			t.Errorf("test %d: dropped peer mismatch: have %q, want %q", i, id, tt.want)
+		}
+	}
+}
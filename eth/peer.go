@@ -21,7 +21,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/eth/fetcher"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/heco"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 )
 
@@ -31,12 +33,15 @@ type ethPeerInfo struct {
 	Version    uint     `json:"version"`    // Ethereum protocol version negotiated
 	Difficulty *big.Int `json:"difficulty"` // Total difficulty of the peer's blockchain
 	Head       string   `json:"head"`       // Hex hash of the peer's best owned block
+
+	Txs *fetcher.TxPeerStats `json:"txs,omitempty"` // Transaction throughput statistics of the peer
 }
 
 // ethPeer is a wrapper around eth.Peer to maintain a few extra metadata.
 type ethPeer struct {
 	*eth.Peer
 	snapExt *snapPeer // Satellite `snap` connection
+	hecoExt *hecoPeer // Satellite `heco` connection
 
 	syncDrop *time.Timer   // Connection dropper if `eth` sync progress isn't validated in time
 	snapWait chan struct{} // Notification channel for snap connections
@@ -71,3 +76,21 @@ func (p *snapPeer) info() *snapPeerInfo {
 		Version: p.Version(),
 	}
 }
+
+// hecoPeerInfo represents a short summary of the `heco` sub-protocol metadata known
+// about a connected peer.
+type hecoPeerInfo struct {
+	Version uint `json:"version"` // HECO protocol version negotiated
+}
+
+// hecoPeer is a wrapper around heco.Peer to maintain a few extra metadata.
+type hecoPeer struct {
+	*heco.Peer
+}
+
+// info gathers and returns some `heco` protocol metadata known about a peer.
+func (p *hecoPeer) info() *hecoPeerInfo {
+	return &hecoPeerInfo{
+		Version: p.Version(),
+	}
+}
@@ -22,7 +22,9 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/heco"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/p2p"
 )
@@ -43,10 +45,14 @@ var (
 	// errSnapWithoutEth is returned if a peer attempts to connect only on the
 	// snap protocol without advertizing the eth main protocol.
 	errSnapWithoutEth = errors.New("peer connected on snap without compatible eth support")
+
+	// errHecoWithoutEth is returned if a peer attempts to connect only on the
+	// heco protocol without advertizing the eth main protocol.
+	errHecoWithoutEth = errors.New("peer connected on heco without compatible eth support")
 )
 
 // peerSet represents the collection of active peers currently participating in
-// the `eth` protocol, with or without the `snap` extension.
+// the `eth` protocol, with or without the `snap` and `heco` extensions.
 type peerSet struct {
 	peers     map[string]*ethPeer // Peers connected on the `eth` protocol
 	snapPeers int                 // Number of `snap` compatible peers for connection prioritization
@@ -54,6 +60,9 @@ type peerSet struct {
 	snapWait map[string]chan *snap.Peer // Peers connected on `eth` waiting for their snap extension
 	snapPend map[string]*snap.Peer      // Peers connected on the `snap` protocol, but not yet on `eth`
 
+	hecoWait map[string]chan *heco.Peer // Peers connected on `eth` waiting for their heco extension
+	hecoPend map[string]*heco.Peer      // Peers connected on the `heco` protocol, but not yet on `eth`
+
 	lock   sync.RWMutex
 	closed bool
 }
@@ -64,6 +73,8 @@ func newPeerSet() *peerSet {
 		peers:    make(map[string]*ethPeer),
 		snapWait: make(map[string]chan *snap.Peer),
 		snapPend: make(map[string]*snap.Peer),
+		hecoWait: make(map[string]chan *heco.Peer),
+		hecoPend: make(map[string]*heco.Peer),
 	}
 }
 
@@ -131,9 +142,73 @@ func (ps *peerSet) waitSnapExtension(peer *eth.Peer) (*snap.Peer, error) {
 	return <-wait, nil
 }
 
+// registerHecoExtension unblocks an already connected `eth` peer waiting for its
+// `heco` extension, or if no such peer exists, tracks the extension for the time
+// being until the `eth` main protocol starts looking for it.
+func (ps *peerSet) registerHecoExtension(peer *heco.Peer) error {
+	// Reject the peer if it advertises `heco` without `eth` as `heco` is only a
+	// satellite protocol meaningful with the chain selection of `eth`
+	if !peer.RunningCap(eth.ProtocolName, eth.ProtocolVersions) {
+		return errHecoWithoutEth
+	}
+	// Ensure nobody can double connect
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	id := peer.ID()
+	if _, ok := ps.peers[id]; ok {
+		return errPeerAlreadyRegistered // avoid connections with the same id as existing ones
+	}
+	if _, ok := ps.hecoPend[id]; ok {
+		return errPeerAlreadyRegistered // avoid connections with the same id as pending ones
+	}
+	// Inject the peer into an `eth` counterpart is available, otherwise save for later
+	if wait, ok := ps.hecoWait[id]; ok {
+		delete(ps.hecoWait, id)
+		wait <- peer
+		return nil
+	}
+	ps.hecoPend[id] = peer
+	return nil
+}
+
+// waitHecoExtension blocks until the `heco` satellite protocol is connected and
+// tracked by the peerset, if the peer supports it.
+func (ps *peerSet) waitHecoExtension(peer *eth.Peer) (*heco.Peer, error) {
+	// If the peer does not support a compatible `heco`, don't wait
+	if !peer.RunningCap(heco.ProtocolName, heco.ProtocolVersions) {
+		return nil, nil
+	}
+	// Ensure nobody can double connect
+	ps.lock.Lock()
+
+	id := peer.ID()
+	if _, ok := ps.peers[id]; ok {
+		ps.lock.Unlock()
+		return nil, errPeerAlreadyRegistered // avoid connections with the same id as existing ones
+	}
+	if _, ok := ps.hecoWait[id]; ok {
+		ps.lock.Unlock()
+		return nil, errPeerAlreadyRegistered // avoid connections with the same id as pending ones
+	}
+	// If `heco` already connected, retrieve the peer from the pending set
+	if heco, ok := ps.hecoPend[id]; ok {
+		delete(ps.hecoPend, id)
+
+		ps.lock.Unlock()
+		return heco, nil
+	}
+	// Otherwise wait for `heco` to connect concurrently
+	wait := make(chan *heco.Peer)
+	ps.hecoWait[id] = wait
+	ps.lock.Unlock()
+
+	return <-wait, nil
+}
+
 // registerPeer injects a new `eth` peer into the working set, or returns an error
 // if the peer is already known.
-func (ps *peerSet) registerPeer(peer *eth.Peer, ext *snap.Peer) error {
+func (ps *peerSet) registerPeer(peer *eth.Peer, ext *snap.Peer, hecoExt *heco.Peer) error {
 	// Start tracking the new peer
 	ps.lock.Lock()
 	defer ps.lock.Unlock()
@@ -152,6 +227,9 @@ func (ps *peerSet) registerPeer(peer *eth.Peer, ext *snap.Peer) error {
 		eth.snapExt = &snapPeer{ext}
 		ps.snapPeers++
 	}
+	if hecoExt != nil {
+		eth.hecoExt = &hecoPeer{hecoExt}
+	}
 	ps.peers[id] = eth
 	return nil
 }
@@ -211,6 +289,33 @@ func (ps *peerSet) peersWithoutTransaction(hash common.Hash) []*ethPeer {
 	return list
 }
 
+// peersWithoutAttestation retrieves a list of `heco` peers that do not have a
+// given head attestation in their set of known IDs.
+func (ps *peerSet) peersWithoutAttestation(id common.Hash) []*ethPeer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*ethPeer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		if p.hecoExt != nil && !p.hecoExt.KnownAttestation(id) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// allPeers retrieves a flat list of all the `eth` peers in the set.
+func (ps *peerSet) allPeers() []*ethPeer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*ethPeer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		list = append(list, p)
+	}
+	return list
+}
+
 // len returns if the current number of `eth` peers in the set. Since the `snap`
 // peers are tied to the existence of an `eth` connection, that will always be a
 // subset of `eth`.
@@ -229,6 +334,18 @@ func (ps *peerSet) snapLen() int {
 	return ps.snapPeers
 }
 
+// forkIDs counts the peers by the fork ID they advertised in the handshake.
+func (ps *peerSet) forkIDs() map[forkid.ID]int {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	ids := make(map[forkid.ID]int)
+	for _, p := range ps.peers {
+		ids[p.ForkID()]++
+	}
+	return ids
+}
+
 // peerWithHighestTD retrieves the known peer with the currently highest total
 // difficulty.
 func (ps *peerSet) peerWithHighestTD() *eth.Peer {
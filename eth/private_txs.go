@@ -0,0 +1,168 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// privateTxLifetime is the time a private transaction is tracked for, after which
+// it's broadcast as any other one, matching the default pool lifetime.
+const privateTxLifetime = 3 * time.Hour
+
+var (
+	privateTxSentMeter     = metrics.NewRegisteredMeter("eth/privatetx/sent", nil)
+	privateTxWithheldMeter = metrics.NewRegisteredMeter("eth/privatetx/withheld", nil)
+)
+
+// privateTxSet tracks the locally submitted transactions which are only sent to
+// the private peers, the trusted and the configured ones, instead of being gossiped
+// to all the peers. Note the privacy only holds until a private peer gossips them.
+type privateTxSet struct {
+	peers    map[enode.ID]struct{}          // Configured private peers, on top of the trusted ones
+	txs      map[common.Hash]*privateTxInfo // Private transactions being tracked
+	lifetime time.Duration                  // Time to track a private transaction for
+	lock     sync.RWMutex
+}
+
+// privateTxInfo is the metadata of a private transaction, the sender and nonce
+// being kept to keep its fee bumped replacements private as well.
+type privateTxInfo struct {
+	from  common.Address
+	nonce uint64
+	added time.Time
+}
+
+func newPrivateTxSet(peers []*enode.Node, lifetime time.Duration) *privateTxSet {
+	set := &privateTxSet{
+		peers:    make(map[enode.ID]struct{}, len(peers)),
+		txs:      make(map[common.Hash]*privateTxInfo),
+		lifetime: lifetime,
+	}
+	for _, peer := range peers {
+		set.peers[peer.ID()] = struct{}{}
+	}
+	return set
+}
+
+// add marks the transaction private, to be done before it's added to the pool.
+func (s *privateTxSet) add(from common.Address, tx *types.Transaction) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for hash, info := range s.txs {
+		if now.Sub(info.added) > s.lifetime {
+			delete(s.txs, hash)
+		}
+	}
+	s.txs[tx.Hash()] = &privateTxInfo{from: from, nonce: tx.Nonce(), added: now}
+}
+
+// containsNonce reports whether a private transaction of the sender with the
+// nonce is tracked.
+func (s *privateTxSet) containsNonce(from common.Address, nonce uint64) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, info := range s.txs {
+		if info.from == from && info.nonce == nonce {
+			return true
+		}
+	}
+	return false
+}
+
+// remove unmarks the transaction, e.g. if it was rejected by the pool.
+func (s *privateTxSet) remove(hash common.Hash) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.txs, hash)
+}
+
+// contains reports whether the transaction is private.
+func (s *privateTxSet) contains(hash common.Hash) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	_, ok := s.txs[hash]
+	return ok
+}
+
+// isPrivatePeer reports whether the private transactions can be sent to the peer.
+func (s *privateTxSet) isPrivatePeer(peer *eth.Peer) bool {
+	if _, ok := s.peers[peer.Peer.ID()]; ok {
+		return true
+	}
+	return peer.Peer.Info().Network.Trusted
+}
+
+// split separates the private transactions from the public ones.
+func (s *privateTxSet) split(txs types.Transactions) (public types.Transactions, private types.Transactions) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if len(s.txs) == 0 {
+		return txs, nil
+	}
+	for _, tx := range txs {
+		if _, ok := s.txs[tx.Hash()]; ok {
+			private = append(private, tx)
+		} else {
+			public = append(public, tx)
+		}
+	}
+	return public, private
+}
+
+// broadcastPrivateTransactions sends the private transactions directly to the
+// private peers not knowing about them yet.
+func (h *handler) broadcastPrivateTransactions(txs types.Transactions) {
+	var peers []*ethPeer
+	for _, peer := range h.peers.allPeers() {
+		if h.privateTxs.isPrivatePeer(peer.Peer) {
+			peers = append(peers, peer)
+		}
+	}
+	if len(peers) == 0 {
+		privateTxWithheldMeter.Mark(int64(len(txs)))
+		log.Warn("No private peer connected, private transactions withheld", "txs", len(txs))
+		return
+	}
+	for _, peer := range peers {
+		var hashes []common.Hash
+		for _, tx := range txs {
+			if !peer.KnownTransaction(tx.Hash()) {
+				hashes = append(hashes, tx.Hash())
+			}
+		}
+		if len(hashes) > 0 {
+			privateTxSentMeter.Mark(int64(len(hashes)))
+			peer.AsyncSendTransactions(hashes)
+		}
+	}
+	log.Debug("Private transaction broadcast", "txs", len(txs), "peers", len(peers))
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestPrivateTxSet(t *testing.T) {
+	set := newPrivateTxSet(nil, time.Hour)
+
+	var txs types.Transactions
+	for i := uint64(0); i < 4; i++ {
+		txs = append(txs, types.NewTransaction(i, common.Address{}, big.NewInt(0), 21000, big.NewInt(1), nil))
+	}
+	// Nothing private, everything is public
+	public, private := set.split(txs)
+	if len(public) != 4 || len(private) != 0 {
+		t.Fatalf("split mismatch: have %d public %d private, want 4 public", len(public), len(private))
+	}
+	set.add(testAddr, txs[1])
+	set.add(testAddr, txs[3])
+
+	public, private = set.split(txs)
+	if len(public) != 2 || len(private) != 2 || private[0] != txs[1] || private[1] != txs[3] {
+		t.Fatalf("split mismatch: have %d public %d private, want 2 each", len(public), len(private))
+	}
+	if !set.containsNonce(testAddr, 1) || set.containsNonce(testAddr, 2) || set.containsNonce(common.Address{1}, 1) {
+		t.Fatalf("sender nonce tracking mismatch")
+	}
+	// Rejected transactions are unmarked
+	set.remove(txs[1].Hash())
+	if set.contains(txs[1].Hash()) || !set.contains(txs[3].Hash()) {
+		t.Fatalf("removal mismatch")
+	}
+	// Expired transactions are dropped on the next addition
+	set.txs[txs[3].Hash()].added = time.Now().Add(-2 * time.Hour)
+	set.add(testAddr, txs[0])
+	if set.contains(txs[3].Hash()) || !set.contains(txs[0].Hash()) {
+		t.Fatalf("expiry mismatch")
+	}
+}
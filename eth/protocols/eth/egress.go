@@ -0,0 +1,142 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+	"golang.org/x/time/rate"
+)
+
+// EgressCaps are the per-peer bandwidth caps of the traffic sent to the ordinary
+// peers, in bytes per second, 0 meaning uncapped. The validator peers are never
+// capped.
+type EgressCaps struct {
+	Blocks uint64 // Cap of the block propagations and the chain sync replies
+	Txs    uint64 // Cap of the transaction broadcasts, announcements and replies
+}
+
+// egressCaps are the caps of the peers connected afterwards.
+var egressCaps atomic.Value
+
+// SetEgressCaps sets the bandwidth caps of the peers connected afterwards.
+func SetEgressCaps(caps EgressCaps) {
+	egressCaps.Store(caps)
+}
+
+var (
+	egressValidatorMeter = metrics.NewRegisteredMeter("eth/egress/validator", nil)
+	egressBlockMeter     = metrics.NewRegisteredMeter("eth/egress/blocks", nil)
+	egressSyncMeter      = metrics.NewRegisteredMeter("eth/egress/sync", nil)
+	egressTxMeter        = metrics.NewRegisteredMeter("eth/egress/txs", nil)
+
+	egressSyncThrottleTimer = metrics.NewRegisteredTimer("eth/egress/sync/throttle", nil)
+	egressTxThrottleTimer   = metrics.NewRegisteredTimer("eth/egress/txs/throttle", nil)
+)
+
+// egressClass is the kind of traffic a message is shaped as.
+type egressClass int
+
+const (
+	egressBlock egressClass = iota // Block propagations, charged but never delayed
+	egressSync                     // Chain sync replies, delayed by the block cap
+	egressTx                       // Transaction traffic, delayed by the transaction cap
+)
+
+// newEgressLimiter creates the limiter of a cap, allowing bursts of a second
+// worth of traffic, or nil if the cap is disabled.
+func newEgressLimiter(cap uint64) *rate.Limiter {
+	if cap == 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(cap), int(cap))
+}
+
+// SetValidator labels the peer as a validator, exempting it from the egress caps.
+func (p *Peer) SetValidator(validator bool) {
+	var flag uint32
+	if validator {
+		flag = 1
+	}
+	atomic.StoreUint32(&p.validator, flag)
+}
+
+// Validator returns whether the peer is labeled as a validator.
+func (p *Peer) Validator() bool {
+	return atomic.LoadUint32(&p.validator) == 1
+}
+
+// shape charges a message of the given class and size to the peer's egress caps,
+// blocking until it fits in them. Block propagations go through immediately, the
+// bandwidth they use is made up for by delaying the sync replies instead.
+func (p *Peer) shape(class egressClass, size int) {
+	if p.Validator() {
+		egressValidatorMeter.Mark(int64(size))
+		return
+	}
+	var (
+		limiter *rate.Limiter
+		timer   metrics.Timer
+	)
+	switch class {
+	case egressBlock:
+		egressBlockMeter.Mark(int64(size))
+		limiter = p.blockLimiter
+	case egressSync:
+		egressSyncMeter.Mark(int64(size))
+		limiter, timer = p.blockLimiter, egressSyncThrottleTimer
+	case egressTx:
+		egressTxMeter.Mark(int64(size))
+		limiter, timer = p.txLimiter, egressTxThrottleTimer
+	}
+	if limiter == nil {
+		return
+	}
+	// Messages larger than the burst are charged up to the burst, they would be
+	// never allowed otherwise
+	if size > limiter.Burst() {
+		size = limiter.Burst()
+	}
+	delay := limiter.ReserveN(time.Now(), size).Delay()
+	if timer == nil || delay <= 0 {
+		return
+	}
+	timer.Update(delay)
+
+	wait := time.NewTimer(delay)
+	defer wait.Stop()
+
+	select {
+	case <-wait.C:
+	case <-p.term:
+	}
+}
+
+// send encodes a message and writes it to the peer once it fits in the egress
+// caps of its class.
+func (p *Peer) send(class egressClass, msgcode uint64, data interface{}) error {
+	size, r, err := rlp.EncodeToReader(data)
+	if err != nil {
+		return err
+	}
+	p.shape(class, size)
+	return p.rw.WriteMsg(p2p.Msg{Code: msgcode, Size: uint32(size), Payload: r})
+}
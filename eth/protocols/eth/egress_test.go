@@ -0,0 +1,69 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests that block propagations are never delayed by the egress caps, but delay
+// the chain sync replies of ordinary peers, and that validators are exempt.
+func TestEgressShaping(t *testing.T) {
+	newPeer := func() *Peer {
+		return &Peer{
+			term:         make(chan struct{}),
+			blockLimiter: newEgressLimiter(10000),
+			txLimiter:    newEgressLimiter(10000),
+		}
+	}
+	elapsed := func(p *Peer, class egressClass, size int) time.Duration {
+		start := time.Now()
+		p.shape(class, size)
+		return time.Since(start)
+	}
+	// Block propagations use up the burst without waiting, even beyond it
+	peer := newPeer()
+	if d := elapsed(peer, egressBlock, 10000); d > 50*time.Millisecond {
+		t.Fatalf("block propagation delayed by %v", d)
+	}
+	if d := elapsed(peer, egressBlock, 20000); d > 50*time.Millisecond {
+		t.Fatalf("oversized block propagation delayed by %v", d)
+	}
+	// Sync replies wait for the block traffic to be made up for
+	if d := elapsed(peer, egressSync, 1000); d < 900*time.Millisecond {
+		t.Fatalf("sync reply delayed by %v, want at least 1s", d)
+	}
+	// Transactions are capped separately
+	if d := elapsed(peer, egressTx, 5000); d > 50*time.Millisecond {
+		t.Fatalf("transactions delayed by %v", d)
+	}
+	// Validators are never capped
+	peer = newPeer()
+	peer.SetValidator(true)
+	elapsed(peer, egressBlock, 10000)
+	if d := elapsed(peer, egressSync, 10000); d > 50*time.Millisecond {
+		t.Fatalf("validator sync reply delayed by %v", d)
+	}
+	// Waits are aborted when the peer is dropped
+	peer = newPeer()
+	elapsed(peer, egressBlock, 10000)
+	close(peer.term)
+	if d := elapsed(peer, egressSync, 10000); d > 50*time.Millisecond {
+		t.Fatalf("dropped peer sync reply delayed by %v", d)
+	}
+}
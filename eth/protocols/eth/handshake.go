@@ -103,5 +103,8 @@ func (p *Peer) readStatus(network uint64, status *StatusPacket, genesis common.H
 	if err := forkFilter(status.ForkID); err != nil {
 		return fmt.Errorf("%w: %v", errForkIDRejected, err)
 	}
+	p.lock.Lock()
+	p.forkID = status.ForkID
+	p.lock.Unlock()
 	return nil
 }
@@ -0,0 +1,97 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package heco
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// testBackend is a mock backend forwarding the inbound `heco` packets.
+type testBackend struct {
+	packets chan Packet
+}
+
+func (b *testBackend) Chain() *core.BlockChain             { panic("no backing chain") }
+func (b *testBackend) RunPeer(*Peer, Handler) error        { panic("not used in tests") }
+func (b *testBackend) PeerInfo(enode.ID) interface{}       { panic("not used in tests") }
+func (b *testBackend) Handle(peer *Peer, pkt Packet) error { b.packets <- pkt; return nil }
+
+// Tests that the `heco` messages are delivered to the remote backend, and that the
+// head attestations are only propagated once to a peer.
+func TestHandleMessages(t *testing.T) {
+	app, net := p2p.MsgPipe()
+	defer app.Close()
+	defer net.Close()
+
+	src := NewPeer(HECO1, p2p.NewPeerPipe(enode.ID{1}, "", nil, app), app)
+	sink := NewPeer(HECO1, p2p.NewPeerPipe(enode.ID{2}, "", nil, net), net)
+	defer src.Close()
+	defer sink.Close()
+
+	backend := &testBackend{packets: make(chan Packet, 1)}
+	go Handle(backend, sink)
+
+	receive := func() Packet {
+		t.Helper()
+
+		select {
+		case packet := <-backend.packets:
+			return packet
+		case <-time.After(time.Second):
+			t.Fatalf("no packet received")
+		}
+		return nil
+	}
+	// Transaction ages are announced asynchronously
+	ages := []TransactionAge{{Hash: common.Hash{0x01}, Age: 1000}}
+	src.AsyncSendTransactionAges(ages)
+	if packet, ok := receive().(*TransactionAgesPacket); !ok || len(*packet) != 1 || (*packet)[0] != ages[0] {
+		t.Fatalf("transaction ages mismatch: have %v, want %v", packet, ages)
+	}
+	// Head attestations are marked known on both sides
+	att := &types.HeadAttestation{Number: 1, Hash: common.Hash{0x02}, Signature: make([]byte, 65)}
+	src.AsyncSendHeadAttestations([]*types.HeadAttestation{att})
+	if packet, ok := receive().(*HeadAttestationsPacket); !ok || len(*packet) != 1 || (*packet)[0].ID() != att.ID() {
+		t.Fatalf("head attestations mismatch: have %v", packet)
+	}
+	if !src.KnownAttestation(att.ID()) || !sink.KnownAttestation(att.ID()) {
+		t.Fatalf("head attestation not marked known")
+	}
+	// Delayed blocks and their withdrawals
+	block := types.NewBlock(&types.Header{Number: common.Big1, Difficulty: common.Big1}, nil, nil, nil, trie.NewStackTrie(nil))
+	release := time.Now().Add(time.Second)
+	if err := src.SendDelayedBlock(block, release); err != nil {
+		t.Fatalf("failed to send delayed block: %v", err)
+	}
+	if packet, ok := receive().(*DelayedBlockPacket); !ok || packet.Block.Hash() != block.Hash() || packet.Release != uint64(release.UnixNano()/int64(time.Millisecond)) {
+		t.Fatalf("delayed block mismatch: have %v", packet)
+	}
+	if err := src.SendDelayedBlockCancel(block.Hash(), block.NumberU64()); err != nil {
+		t.Fatalf("failed to withdraw delayed block: %v", err)
+	}
+	if packet, ok := receive().(*DelayedBlockCancelPacket); !ok || packet.Hash != block.Hash() || packet.Number != 1 {
+		t.Fatalf("delayed block withdrawal mismatch: have %v", packet)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package heco
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func handleTransactionAges(backend Backend, msg Decoder, peer *Peer) error {
+	// Transaction ages arrived along with an `eth` announcement
+	ages := new(TransactionAgesPacket)
+	if err := msg.Decode(ages); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	return backend.Handle(peer, ages)
+}
+
+func handleHeadAttestations(backend Backend, msg Decoder, peer *Peer) error {
+	atts := new(HeadAttestationsPacket)
+	if err := msg.Decode(atts); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	for _, att := range *atts {
+		if att == nil {
+			return fmt.Errorf("%w: attestation is nil", errDecode)
+		}
+		peer.markAttestation(att.ID())
+	}
+	return backend.Handle(peer, atts)
+}
+
+func handleDelayedBlock(backend Backend, msg Decoder, peer *Peer) error {
+	// Retrieve and decode the block sealed ahead of its release
+	ann := new(DelayedBlockPacket)
+	if err := msg.Decode(ann); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	if err := ann.sanityCheck(); err != nil {
+		return err
+	}
+	if hash := types.CalcUncleHash(ann.Block.Uncles()); hash != ann.Block.UncleHash() {
+		log.Warn("Delayed block has invalid uncles", "have", hash, "exp", ann.Block.UncleHash())
+		return nil
+	}
+	if hash := types.DeriveSha(ann.Block.Transactions(), trie.NewStackTrie(nil)); hash != ann.Block.TxHash() {
+		log.Warn("Delayed block has invalid body", "have", hash, "exp", ann.Block.TxHash())
+		return nil
+	}
+	ann.Block.ReceivedFrom = peer
+
+	return backend.Handle(peer, ann)
+}
+
+func handleDelayedBlockCancel(backend Backend, msg Decoder, peer *Peer) error {
+	// Retrieve and decode the withdrawal of a block sent ahead of its release
+	ann := new(DelayedBlockCancelPacket)
+	if err := msg.Decode(ann); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	return backend.Handle(peer, ann)
+}
@@ -0,0 +1,194 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package heco
+
+import (
+	"time"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+const (
+	// maxKnownAttestations is the maximum head attestations to keep in the known
+	// list before starting to randomly evict them.
+	maxKnownAttestations = 4096
+
+	// maxQueuedAttestations is the maximum number of head attestation batches to
+	// queue up before dropping broadcasts.
+	maxQueuedAttestations = 16
+
+	// maxQueuedTxAges is the maximum number of transaction age batches to queue up
+	// before dropping broadcasts.
+	maxQueuedTxAges = 128
+)
+
+// Peer is a collection of relevant information we have about a `heco` peer.
+type Peer struct {
+	id string // Unique ID for the peer, cached
+
+	*p2p.Peer                   // The embedded P2P package peer
+	rw        p2p.MsgReadWriter // Input/output streams for heco
+	version   uint              // Protocol version negotiated
+
+	knownAtts  mapset.Set                    // Set of head attestation IDs known to be known by this peer
+	queuedAtts chan []*types.HeadAttestation // Queue of head attestations to broadcast to the peer
+	queuedAges chan []TransactionAge         // Queue of transaction ages to announce to the peer
+
+	logger log.Logger    // Contextual logger with the peer id injected
+	term   chan struct{} // Termination channel to stop the broadcasters
+}
+
+// NewPeer create a wrapper for a network connection and negotiated  protocol
+// version.
+func NewPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
+	id := p.ID().String()
+	peer := &Peer{
+		id:         id,
+		Peer:       p,
+		rw:         rw,
+		version:    version,
+		knownAtts:  mapset.NewSet(),
+		queuedAtts: make(chan []*types.HeadAttestation, maxQueuedAttestations),
+		queuedAges: make(chan []TransactionAge, maxQueuedTxAges),
+		logger:     log.New("peer", id[:8]),
+		term:       make(chan struct{}),
+	}
+	go peer.broadcast()
+
+	return peer
+}
+
+// Close signals the broadcast goroutine to terminate. Only ever call this if
+// you created the peer yourself via NewPeer. Otherwise let whoever created it
+// clean it up!
+func (p *Peer) Close() {
+	close(p.term)
+}
+
+// ID retrieves the peer's unique identifier.
+func (p *Peer) ID() string {
+	return p.id
+}
+
+// Version retrieves the peer's negoatiated `heco` protocol version.
+func (p *Peer) Version() uint {
+	return p.version
+}
+
+// Log overrides the P2P logget with the higher level one containing only the id.
+func (p *Peer) Log() log.Logger {
+	return p.logger
+}
+
+// KnownAttestation returns whether peer is known to already have a head attestation.
+func (p *Peer) KnownAttestation(id common.Hash) bool {
+	return p.knownAtts.Contains(id)
+}
+
+// markAttestation marks a head attestation as known for the peer, ensuring that
+// it will never be propagated to this particular peer.
+func (p *Peer) markAttestation(id common.Hash) {
+	// If we reached the memory allowance, drop a previously known attestation
+	for p.knownAtts.Cardinality() >= maxKnownAttestations {
+		p.knownAtts.Pop()
+	}
+	p.knownAtts.Add(id)
+}
+
+// SendTransactionAges announces the ages of a batch of transactions, announced
+// to the peer over `eth` along with it.
+func (p *Peer) SendTransactionAges(ages []TransactionAge) error {
+	return p2p.Send(p.rw, TransactionAgesMsg, TransactionAgesPacket(ages))
+}
+
+// AsyncSendTransactionAges queues the ages of a batch of transactions for
+// announcement to a remote peer. If the peer's announcement queue is full, the
+// batch is silently dropped.
+func (p *Peer) AsyncSendTransactionAges(ages []TransactionAge) {
+	select {
+	case p.queuedAges <- ages:
+	default:
+		p.Log().Debug("Dropping transaction ages", "count", len(ages))
+	}
+}
+
+// SendHeadAttestations propagates a batch of head attestations to a remote peer.
+func (p *Peer) SendHeadAttestations(atts []*types.HeadAttestation) error {
+	for _, att := range atts {
+		p.markAttestation(att.ID())
+	}
+	return p2p.Send(p.rw, HeadAttestationsMsg, HeadAttestationsPacket(atts))
+}
+
+// AsyncSendHeadAttestations queues a batch of head attestations for propagation
+// to a remote peer. If the peer's broadcast queue is full, the batch is silently
+// dropped.
+func (p *Peer) AsyncSendHeadAttestations(atts []*types.HeadAttestation) {
+	select {
+	case p.queuedAtts <- atts:
+		for _, att := range atts {
+			p.markAttestation(att.ID())
+		}
+	default:
+		p.Log().Debug("Dropping head attestations", "count", len(atts))
+	}
+}
+
+// SendDelayedBlock sends a block sealed out of turn to a remote peer ahead of its
+// release.
+func (p *Peer) SendDelayedBlock(block *types.Block, release time.Time) error {
+	return p2p.Send(p.rw, DelayedBlockMsg, &DelayedBlockPacket{
+		Block:   block,
+		Release: uint64(release.UnixNano() / int64(time.Millisecond)),
+	})
+}
+
+// SendDelayedBlockCancel withdraws a block sent ahead of its release from a remote
+// peer.
+func (p *Peer) SendDelayedBlockCancel(hash common.Hash, number uint64) error {
+	return p2p.Send(p.rw, DelayedBlockCancelMsg, &DelayedBlockCancelPacket{
+		Hash:   hash,
+		Number: number,
+	})
+}
+
+// broadcast is a write loop that propagates the head attestations and announces
+// the transaction ages to the remote peer.
+func (p *Peer) broadcast() {
+	for {
+		select {
+		case atts := <-p.queuedAtts:
+			if err := p.SendHeadAttestations(atts); err != nil {
+				return
+			}
+			p.Log().Trace("Propagated head attestations", "count", len(atts))
+
+		case ages := <-p.queuedAges:
+			if err := p.SendTransactionAges(ages); err != nil {
+				return
+			}
+			p.Log().Trace("Announced transaction ages", "count", len(ages))
+
+		case <-p.term:
+			return
+		}
+	}
+}
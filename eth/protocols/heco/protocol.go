@@ -0,0 +1,109 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package heco
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	HECO1 = 1
+)
+
+// ProtocolName is the official short name of the `heco` protocol used during
+// devp2p capability negotiation.
+const ProtocolName = "heco"
+
+// ProtocolVersions are the supported versions of the `heco` protocol (first
+// is primary).
+var ProtocolVersions = []uint{HECO1}
+
+// protocolLengths are the number of implemented message corresponding to
+// different protocol versions.
+var protocolLengths = map[uint]uint64{HECO1: 4}
+
+// maxMessageSize is the maximum cap on the size of a protocol message.
+const maxMessageSize = 10 * 1024 * 1024
+
+const (
+	TransactionAgesMsg    = 0x00
+	HeadAttestationsMsg   = 0x01
+	DelayedBlockMsg       = 0x02
+	DelayedBlockCancelMsg = 0x03
+)
+
+var (
+	errMsgTooLarge    = errors.New("message too long")
+	errDecode         = errors.New("invalid message")
+	errInvalidMsgCode = errors.New("invalid message code")
+)
+
+// Packet represents a p2p message in the `heco` protocol.
+type Packet interface {
+	Name() string // Name returns a string corresponding to the message type.
+	Kind() byte   // Kind returns the message type.
+}
+
+// TransactionAge is the time since a transaction was first seen by the announcer,
+// in milliseconds.
+type TransactionAge struct {
+	Hash common.Hash
+	Age  uint64
+}
+
+// TransactionAgesPacket is the network packet for the ages of the transactions
+// announced over `eth`.
+type TransactionAgesPacket []TransactionAge
+
+// HeadAttestationsPacket is the network packet for the validators' signed head
+// announcements.
+type HeadAttestationsPacket []*types.HeadAttestation
+
+// DelayedBlockPacket is the network packet for a block sealed by a validator out
+// of turn, sent to its trusted peers ahead of its release.
+type DelayedBlockPacket struct {
+	Block   *types.Block
+	Release uint64 // Unix time in milliseconds at which the block is released
+}
+
+// sanityCheck verifies that the values are reasonable, as a DoS protection
+func (request *DelayedBlockPacket) sanityCheck() error {
+	return request.Block.SanityCheck()
+}
+
+// DelayedBlockCancelPacket is the network packet withdrawing a block sent ahead of
+// its release, replaced by its validator before it.
+type DelayedBlockCancelPacket struct {
+	Hash   common.Hash // Hash of the withdrawn block
+	Number uint64      // Number of the withdrawn block
+}
+
+func (*TransactionAgesPacket) Name() string { return "TransactionAges" }
+func (*TransactionAgesPacket) Kind() byte   { return TransactionAgesMsg }
+
+func (*HeadAttestationsPacket) Name() string { return "HeadAttestations" }
+func (*HeadAttestationsPacket) Kind() byte   { return HeadAttestationsMsg }
+
+func (*DelayedBlockPacket) Name() string { return "DelayedBlock" }
+func (*DelayedBlockPacket) Kind() byte   { return DelayedBlockMsg }
+
+func (*DelayedBlockCancelPacket) Name() string { return "DelayedBlockCancel" }
+func (*DelayedBlockCancelPacket) Kind() byte   { return DelayedBlockCancelMsg }
@@ -350,6 +350,28 @@ type syncProgress struct {
 	BytecodeHealNops   uint64             // Number of bytecodes not requested
 }
 
+// Progress is a snapshot of the state sync statistics, reported to the users to
+// tell a working sync apart from a stuck one.
+type Progress struct {
+	// Status report during syncing phase
+	AccountSynced  uint64             // Number of accounts downloaded
+	AccountBytes   common.StorageSize // Number of account trie bytes persisted to disk
+	BytecodeSynced uint64             // Number of bytecodes downloaded
+	BytecodeBytes  common.StorageSize // Number of bytecode bytes downloaded
+	StorageSynced  uint64             // Number of storage slots downloaded
+	StorageBytes   common.StorageSize // Number of storage trie bytes persisted to disk
+
+	// Status report during healing phase
+	Healing            bool               // Whether the sync phase is done and the state is being healed
+	TrienodeHealSynced uint64             // Number of state trie nodes downloaded
+	TrienodeHealBytes  common.StorageSize // Number of state trie bytes persisted to disk
+	BytecodeHealSynced uint64             // Number of bytecodes downloaded
+	BytecodeHealBytes  common.StorageSize // Number of bytecodes persisted to disk
+	HealPending        uint64             // Number of trie nodes and bytecodes pending to be healed
+
+	ETA time.Duration // Estimated remaining time of the syncing phase, zero if unknown
+}
+
 // SyncPeer abstracts out the methods required for a peer to be synced against
 // with the goal of allowing the construction of mock peers without the full
 // blown networking.
@@ -444,6 +466,9 @@ type Syncer struct {
 	startTime time.Time // Time instance when snapshot sync started
 	logTime   time.Time // Time instance when status was last reported
 
+	progress     Progress     // Latest statistics snapshot for the users
+	progressLock sync.RWMutex // Protects the statistics snapshot
+
 	pend sync.WaitGroup // Tracks network request goroutines for graceful shutdown
 	lock sync.RWMutex   // Protects fields that can change outside of sync (peers, reqs, root)
 }
@@ -2767,6 +2792,7 @@ var hashSpace = new(big.Int).Exp(common.Big2, common.Big256, nil)
 
 // report calculates various status reports and provides it to the user.
 func (s *Syncer) report(force bool) {
+	s.updateProgress()
 	if len(s.tasks) > 0 {
 		s.reportSyncProgress(force)
 		return
@@ -2774,16 +2800,49 @@ func (s *Syncer) report(force bool) {
 	s.reportHealProgress(force)
 }
 
-// reportSyncProgress calculates various status reports and provides it to the user.
-func (s *Syncer) reportSyncProgress(force bool) {
-	// Don't report all the events, just occasionally
-	if !force && time.Since(s.logTime) < 8*time.Second {
-		return
+// Progress returns the latest snapshot of the state sync statistics.
+func (s *Syncer) Progress() Progress {
+	s.progressLock.RLock()
+	defer s.progressLock.RUnlock()
+
+	return s.progress
+}
+
+// updateProgress refreshes the statistics snapshot returned to the users.
+func (s *Syncer) updateProgress() {
+	progress := Progress{
+		AccountSynced:      s.accountSynced,
+		AccountBytes:       s.accountBytes,
+		BytecodeSynced:     s.bytecodeSynced,
+		BytecodeBytes:      s.bytecodeBytes,
+		StorageSynced:      s.storageSynced,
+		StorageBytes:       s.storageBytes,
+		Healing:            len(s.tasks) == 0,
+		TrienodeHealSynced: s.trienodeHealSynced,
+		TrienodeHealBytes:  s.trienodeHealBytes,
+		BytecodeHealSynced: s.bytecodeHealSynced,
+		BytecodeHealBytes:  s.bytecodeHealBytes,
 	}
-	// Don't report anything until we have a meaningful progress
+	if s.healer != nil {
+		progress.HealPending = uint64(s.healer.scheduler.Pending())
+	}
+	if !progress.Healing {
+		if _, eta, ok := s.estimateSyncProgress(); ok {
+			progress.ETA = eta
+		}
+	}
+	s.progressLock.Lock()
+	s.progress = progress
+	s.progressLock.Unlock()
+}
+
+// estimateSyncProgress estimates the completed ratio and the remaining time of the
+// syncing phase from the portion of the account hash space already filled.
+func (s *Syncer) estimateSyncProgress() (float64, time.Duration, bool) {
+	// Don't estimate anything until we have a meaningful progress
 	synced := s.accountBytes + s.bytecodeBytes + s.storageBytes
 	if synced == 0 {
-		return
+		return 0, 0, false
 	}
 	accountGaps := new(big.Int)
 	for _, task := range s.tasks {
@@ -2791,9 +2850,8 @@ func (s *Syncer) reportSyncProgress(force bool) {
 	}
 	accountFills := new(big.Int).Sub(hashSpace, accountGaps)
 	if accountFills.BitLen() == 0 {
-		return
+		return 0, 0, false
 	}
-	s.logTime = time.Now()
 	estBytes := float64(new(big.Int).Div(
 		new(big.Int).Mul(new(big.Int).SetUint64(uint64(synced)), hashSpace),
 		accountFills,
@@ -2802,15 +2860,31 @@ func (s *Syncer) reportSyncProgress(force bool) {
 	elapsed := time.Since(s.startTime)
 	estTime := elapsed / time.Duration(synced) * time.Duration(estBytes)
 
+	return float64(synced) / estBytes, estTime - elapsed, true
+}
+
+// reportSyncProgress calculates various status reports and provides it to the user.
+func (s *Syncer) reportSyncProgress(force bool) {
+	// Don't report all the events, just occasionally
+	if !force && time.Since(s.logTime) < 8*time.Second {
+		return
+	}
+	ratio, eta, ok := s.estimateSyncProgress()
+	if !ok {
+		return
+	}
+	s.logTime = time.Now()
+
 	// Create a mega progress report
 	var (
-		progress = fmt.Sprintf("%.2f%%", float64(synced)*100/estBytes)
+		progress = fmt.Sprintf("%.2f%%", ratio*100)
 		accounts = fmt.Sprintf("%v@%v", log.FormatLogfmtUint64(s.accountSynced), s.accountBytes.TerminalString())
 		storage  = fmt.Sprintf("%v@%v", log.FormatLogfmtUint64(s.storageSynced), s.storageBytes.TerminalString())
 		bytecode = fmt.Sprintf("%v@%v", log.FormatLogfmtUint64(s.bytecodeSynced), s.bytecodeBytes.TerminalString())
 	)
-	log.Info("State sync in progress", "synced", progress, "state", synced,
-		"accounts", accounts, "slots", storage, "codes", bytecode, "eta", common.PrettyDuration(estTime-elapsed))
+	log.Info("State sync in progress", "synced", progress, "state", s.accountBytes+s.bytecodeBytes+s.storageBytes,
+		"accounts", accounts, "slots", storage, "codes", bytecode, "elapsed", common.PrettyDuration(time.Since(s.startTime)),
+		"eta", common.PrettyDuration(eta))
 }
 
 // reportHealProgress calculates various status reports and provides it to the user.
@@ -2829,7 +2903,8 @@ func (s *Syncer) reportHealProgress(force bool) {
 		storage  = fmt.Sprintf("%v@%v", log.FormatLogfmtUint64(s.storageHealed), s.storageHealedBytes.TerminalString())
 	)
 	log.Info("State heal in progress", "accounts", accounts, "slots", storage,
-		"codes", bytecode, "nodes", trienode, "pending", s.healer.scheduler.Pending())
+		"codes", bytecode, "nodes", trienode, "pending", s.healer.scheduler.Pending(),
+		"elapsed", common.PrettyDuration(time.Since(s.startTime)))
 }
 
 // estimateRemainingSlots tries to determine roughly how many slots are left in
@@ -651,6 +651,15 @@ func TestSync(t *testing.T) {
 		t.Fatalf("sync failed: %v", err)
 	}
 	verifyTrie(syncer.db, sourceAccountTrie.Hash(), t)
+
+	// Check the statistics reported to the users
+	progress := syncer.Progress()
+	if progress.AccountSynced == 0 || progress.AccountBytes == 0 {
+		t.Errorf("synced accounts not reported: %d@%v", progress.AccountSynced, progress.AccountBytes)
+	}
+	if !progress.Healing || progress.HealPending != 0 {
+		t.Errorf("healing status mismatch: have %v/%d, want true/0", progress.Healing, progress.HealPending)
+	}
 }
 
 // TestSyncTinyTriePanic tests a basic sync with one peer, and a tiny trie. This caused a
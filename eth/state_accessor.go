@@ -188,7 +188,9 @@ func (eth *Ethereum) stateAtTransaction(block *types.Block, txIndex int, reexec
 		header         = block.Header()
 	)
 	if eth.isPoSA {
-		extraValidator = eth.posa.CreateEvmExtraValidator(header, statedb)
+		if extraValidator, err = eth.posa.CreateEvmExtraValidator(header, statedb); err != nil {
+			return nil, vm.BlockContext{}, nil, err
+		}
 	}
 	for idx, tx := range block.Transactions() {
 		// Assemble the transaction call message and return if the requested offset
@@ -35,7 +35,7 @@ const (
 )
 
 // syncTransactions starts sending all currently pending transactions to the given peer.
-func (h *handler) syncTransactions(p *eth.Peer) {
+func (h *handler) syncTransactions(p *ethPeer) {
 	// Assemble the set of transaction to broadcast or announce to the remote
 	// peer. Fun fact, this is quite an expensive operation as it needs to sort
 	// the transactions if the sorting is not cached yet. However, with a random
@@ -47,6 +47,9 @@ func (h *handler) syncTransactions(p *eth.Peer) {
 	for _, batch := range pending {
 		txs = append(txs, batch...)
 	}
+	if !h.privateTxs.isPrivatePeer(p.Peer) {
+		txs, _ = h.privateTxs.split(txs)
+	}
 	if len(txs) == 0 {
 		return
 	}
@@ -58,6 +61,9 @@ func (h *handler) syncTransactions(p *eth.Peer) {
 		hashes[i] = tx.Hash()
 	}
 	p.AsyncSendPooledTransactionHashes(hashes)
+	if p.hecoExt != nil {
+		p.hecoExt.AsyncSendTransactionAges(h.transactionAges(hashes))
+	}
 }
 
 // chainSyncer coordinates blockchain sync components.
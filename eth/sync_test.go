@@ -77,3 +77,44 @@ func testFastSyncDisabling(t *testing.T, protocol uint) {
 		t.Fatalf("fast sync not disabled after successful synchronisation")
 	}
 }
+
+// Tests that the peers announcing a heavier head than a parent are reported, for
+// the congress engine to tell an isolated validator from a stalled network.
+func TestPeerHeads(t *testing.T) {
+	t.Parallel()
+
+	empty := newTestHandler()
+	defer empty.close()
+	full := newTestHandlerWithBlocks(16)
+	defer full.close()
+
+	genesis := empty.chain.Genesis().Header()
+	if peers, ahead := empty.handler.peerHeads(genesis); peers != 0 || ahead {
+		t.Fatalf("peer heads mismatch without peers: have %d/%v, want 0/false", peers, ahead)
+	}
+	emptyPipe, fullPipe := p2p.MsgPipe()
+	defer emptyPipe.Close()
+	defer fullPipe.Close()
+
+	emptyPeer := eth.NewPeer(eth.ETH66, p2p.NewPeer(enode.ID{1}, "", nil), emptyPipe, empty.txpool)
+	fullPeer := eth.NewPeer(eth.ETH66, p2p.NewPeer(enode.ID{2}, "", nil), fullPipe, full.txpool)
+	defer emptyPeer.Close()
+	defer fullPeer.Close()
+
+	go empty.handler.runEthPeer(emptyPeer, func(peer *eth.Peer) error {
+		return eth.Handle((*ethHandler)(empty.handler), peer)
+	})
+	go full.handler.runEthPeer(fullPeer, func(peer *eth.Peer) error {
+		return eth.Handle((*ethHandler)(full.handler), peer)
+	})
+	// Wait a bit for the above handlers to start
+	time.Sleep(250 * time.Millisecond)
+
+	// The full node is ahead of the empty one, but not the other way around
+	if peers, ahead := empty.handler.peerHeads(genesis); peers != 1 || !ahead {
+		t.Fatalf("peer heads mismatch behind the peer: have %d/%v, want 1/true", peers, ahead)
+	}
+	if peers, ahead := full.handler.peerHeads(full.chain.CurrentHeader()); peers != 1 || ahead {
+		t.Fatalf("peer heads mismatch ahead of the peer: have %d/%v, want 1/false", peers, ahead)
+	}
+}
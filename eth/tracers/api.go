@@ -60,8 +60,13 @@ const (
 	// For non-archive nodes, this limit _will_ be overblown, as disk-backed tries
 	// will only be found every ~15K blocks or so.
 	defaultTracechainMemLimit = common.StorageSize(500 * 1024 * 1024)
+
+	// maxTraceCalls is the maximum number of calls traceCallMany accepts at once.
+	maxTraceCalls = 64
 )
 
+var errNoTraceCalls = errors.New("no calls to trace")
+
 // Backend interface provides the common API services (that are provided by
 // both full and light clients) with access to necessary functions.
 type Backend interface {
@@ -71,6 +76,9 @@ type Backend interface {
 	BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error)
 	GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error)
 	RPCGasCap() uint64
+	RPCTraceGasCap() uint64
+	RPCTraceEVMTimeout() time.Duration
+	HeavyCallLimiter() *ethapi.HeavyCallLimiter
 	ChainConfig() *params.ChainConfig
 	Engine() consensus.Engine
 	ChainDb() ethdb.Database
@@ -279,12 +287,17 @@ func (api *API) traceChain(ctx context.Context, start, end *types.Block, config
 				signer := types.MakeSigner(api.backend.ChainConfig(), task.block.Number())
 				header := task.block.Header()
 				blockCtx := core.NewEVMBlockContext(header, api.chainContext(localctx), nil)
+				var validatorErr error
 				if api.isPoSA {
 					_ = api.posa.PreHandle(api.backend.ChainHeaderReader(), header, task.statedb)
-					blockCtx.ExtraValidator = api.posa.CreateEvmExtraValidator(header, task.statedb)
+					blockCtx.ExtraValidator, validatorErr = api.posa.CreateEvmExtraValidator(header, task.statedb)
 				}
 				// Trace all the transactions contained within
 				for i, tx := range task.block.Transactions() {
+					if validatorErr != nil {
+						task.results[i] = &txTraceResult{Error: validatorErr.Error()}
+						continue
+					}
 					msg, _ := tx.AsMessage(signer, task.block.BaseFee())
 					txctx := &Context{
 						BlockHash: task.block.Hash(),
@@ -589,6 +602,12 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 	if block.NumberU64() == 0 {
 		return nil, errors.New("genesis is not traceable")
 	}
+	release, err := api.backend.HeavyCallLimiter().Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	parent, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(block.NumberU64()-1), block.ParentHash())
 	if err != nil {
 		return nil, err
@@ -619,7 +638,9 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 	blockCtx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
 	if api.isPoSA {
 		_ = api.posa.PreHandle(api.backend.ChainHeaderReader(), header, statedb)
-		blockCtx.ExtraValidator = api.posa.CreateEvmExtraValidator(header, statedb)
+		if blockCtx.ExtraValidator, err = api.posa.CreateEvmExtraValidator(header, statedb); err != nil {
+			return nil, err
+		}
 	}
 	blockHash := block.Hash()
 	for th := 0; th < threads; th++ {
@@ -738,7 +759,9 @@ func (api *API) standardTraceBlockToFile(ctx context.Context, block *types.Block
 	)
 	if api.isPoSA {
 		_ = api.posa.PreHandle(api.backend.ChainHeaderReader(), header, statedb)
-		vmctx.ExtraValidator = api.posa.CreateEvmExtraValidator(header, statedb)
+		if vmctx.ExtraValidator, err = api.posa.CreateEvmExtraValidator(header, statedb); err != nil {
+			return nil, err
+		}
 	}
 
 	// Check if there are any overrides: the caller may wish to enable a future
@@ -837,6 +860,12 @@ func containsTx(block *types.Block, hash common.Hash) bool {
 // TraceTransaction returns the structured logs created during the execution of EVM
 // and returns them as a JSON object.
 func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *TraceConfig) (interface{}, error) {
+	release, err := api.backend.HeavyCallLimiter().Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	_, blockHash, blockNumber, index, err := api.backend.GetTransaction(ctx, hash)
 	if err != nil {
 		return nil, err
@@ -877,20 +906,55 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 // top of the provided block and returns them as a JSON object.
 // You can provide -2 as a block number to trace on top of the pending block.
 func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) (interface{}, error) {
+	results, _, err := api.traceCalls(ctx, []ethapi.TransactionArgs{args}, blockNrOrHash, config)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// TraceCallMany lets you trace a list of eth_calls executed sequentially on top
+// of the provided block, each of them seeing the state changes made by the
+// previous ones. The state overrides, if any, are applied before the first call
+// and carried forward. The traces are returned in the order of the calls.
+func (api *API) TraceCallMany(ctx context.Context, args []ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) ([]interface{}, error) {
+	if len(args) == 0 {
+		return nil, errNoTraceCalls
+	}
+	if len(args) > maxTraceCalls {
+		return nil, fmt.Errorf("too many calls to trace: %d > %d", len(args), maxTraceCalls)
+	}
+	results, failed, err := api.traceCalls(ctx, args, blockNrOrHash, config)
+	if err != nil {
+		if failed >= 0 {
+			return nil, fmt.Errorf("call %d: %w", failed, err)
+		}
+		return nil, err
+	}
+	return results, nil
+}
+
+// traceCalls traces the given calls sequentially on top of the provided block.
+// If one of the calls fails, its index is returned along with the error, or -1
+// if the tracing fails before executing any call.
+func (api *API) traceCalls(ctx context.Context, args []ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, config *TraceCallConfig) ([]interface{}, int, error) {
+	release, err := api.backend.HeavyCallLimiter().Acquire(ctx)
+	if err != nil {
+		return nil, -1, err
+	}
+	defer release()
+
 	// Try to retrieve the specified block
-	var (
-		err   error
-		block *types.Block
-	)
+	var block *types.Block
 	if hash, ok := blockNrOrHash.Hash(); ok {
 		block, err = api.blockByHash(ctx, hash)
 	} else if number, ok := blockNrOrHash.Number(); ok {
 		block, err = api.blockByNumber(ctx, number)
 	} else {
-		return nil, errors.New("invalid arguments; neither block nor hash specified")
+		return nil, -1, errors.New("invalid arguments; neither block nor hash specified")
 	}
 	if err != nil {
-		return nil, err
+		return nil, -1, err
 	}
 	// try to recompute the state
 	reexec := defaultTraceReexec
@@ -899,22 +963,19 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 	}
 	statedb, err := api.backend.StateAtBlock(ctx, block, reexec, nil, true, false)
 	if err != nil {
-		return nil, err
+		return nil, -1, err
 	}
 	// Apply the customized state rules if required.
 	if config != nil {
 		if err := config.StateOverrides.Apply(statedb); err != nil {
-			return nil, err
+			return nil, -1, err
 		}
 	}
-	// Execute the trace
-	msg, err := args.ToMessage(api.backend.RPCGasCap(), block.BaseFee())
-	if err != nil {
-		return nil, err
-	}
 	vmctx := core.NewEVMBlockContext(block.Header(), api.chainContext(ctx), nil)
 	if api.isPoSA {
-		vmctx.ExtraValidator = api.posa.CreateEvmExtraValidator(block.Header(), statedb)
+		if vmctx.ExtraValidator, err = api.posa.CreateEvmExtraValidator(block.Header(), statedb); err != nil {
+			return nil, -1, err
+		}
 	}
 
 	var traceConfig *TraceConfig
@@ -926,7 +987,21 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 			Reexec:    config.Reexec,
 		}
 	}
-	return api.traceTx(ctx, msg, new(Context), vmctx, statedb, traceConfig)
+	// Execute the traces, carrying the state forward
+	results := make([]interface{}, len(args))
+	for i, arg := range args {
+		msg, err := arg.ToMessage(api.backend.RPCTraceGasCap(), block.BaseFee())
+		if err != nil {
+			return nil, i, err
+		}
+		res, err := api.traceTx(ctx, msg, &Context{TxIndex: i}, vmctx, statedb, traceConfig)
+		if err != nil {
+			return nil, i, err
+		}
+		results[i] = res
+		statedb.Finalise(api.backend.ChainConfig().IsEIP158(block.Number()))
+	}
+	return results, -1, nil
 }
 
 // traceTx configures a new tracer according to the provided configuration, and
@@ -944,11 +1019,9 @@ func (api *API) traceTx(ctx context.Context, message core.Message, txctx *Contex
 		tracer = vm.NewStructLogger(nil)
 	case config.Tracer != nil:
 		// Define a meaningful timeout of a single transaction trace
-		timeout := defaultTraceTimeout
-		if config.Timeout != nil {
-			if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
-				return nil, err
-			}
+		timeout, err := api.traceTimeout(config)
+		if err != nil {
+			return nil, err
 		}
 		if t, err := New(*config.Tracer, txctx); err != nil {
 			return nil, err
@@ -992,11 +1065,9 @@ func (api *API) tracePoSASysTx(ctx context.Context, sender common.Address, tx *t
 	switch {
 	case config != nil && config.Tracer != nil:
 		// Define a meaningful timeout of a single transaction trace
-		timeout := defaultTraceTimeout
-		if config.Timeout != nil {
-			if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
-				return nil, err
-			}
+		timeout, err := api.traceTimeout(config)
+		if err != nil {
+			return nil, err
 		}
 		// Constuct the JavaScript tracer to execute with
 		if tracer, err = New(*config.Tracer, txctx); err != nil {
@@ -1032,6 +1103,25 @@ func (api *API) tracePoSASysTx(ctx context.Context, sender common.Address, tx *t
 	})
 }
 
+// traceTimeout returns the timeout of a single transaction trace, the one given by
+// the config is capped by the node's configured trace timeout if there's one.
+func (api *API) traceTimeout(config *TraceConfig) (time.Duration, error) {
+	timeout, limit := defaultTraceTimeout, api.backend.RPCTraceEVMTimeout()
+	if limit > 0 {
+		timeout = limit
+	}
+	if config.Timeout != nil {
+		t, err := time.ParseDuration(*config.Timeout)
+		if err != nil {
+			return 0, err
+		}
+		if limit == 0 || t < limit {
+			timeout = t
+		}
+	}
+	return timeout, nil
+}
+
 func (api *API) traceResult(tracer vm.EVMLogger, result *core.ExecutionResult) (interface{}, error) {
 	// Depending on the tracer type, format and return the output.
 	switch tracer := tracer.(type) {
@@ -1041,12 +1131,18 @@ func (api *API) traceResult(tracer vm.EVMLogger, result *core.ExecutionResult) (
 		if len(result.Revert()) > 0 {
 			returnVal = fmt.Sprintf("%x", result.Revert())
 		}
-		return &ethapi.ExecutionResult{
+		res := &ethapi.ExecutionResult{
 			Gas:         result.UsedGas,
 			Failed:      result.Failed(),
 			ReturnValue: returnVal,
 			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
-		}, nil
+		}
+		if fee := result.MetaFee; fee != nil {
+			res.FeePayer = &fee.FeePayer
+			res.FeePayerAmount = (*hexutil.Big)(fee.FeePayerAmount)
+			res.SenderAmount = (*hexutil.Big)(fee.SenderAmount)
+		}
+		return res, nil
 
 	case Tracer:
 		return tracer.GetResult()
@@ -1066,5 +1162,11 @@ func APIs(backend Backend) []rpc.API {
 			Service:   NewAPI(backend),
 			Public:    false,
 		},
+		{
+			Namespace: "trace",
+			Version:   "1.0",
+			Service:   NewTraceAPI(backend),
+			Public:    false,
+		},
 	}
 }
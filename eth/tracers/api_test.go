@@ -26,6 +26,7 @@ import (
 	"math/big"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -126,6 +127,18 @@ func (b *testBackend) RPCGasCap() uint64 {
 	return 25000000
 }
 
+func (b *testBackend) RPCTraceGasCap() uint64 {
+	return 25000000
+}
+
+func (b *testBackend) RPCTraceEVMTimeout() time.Duration {
+	return 0
+}
+
+func (b *testBackend) HeavyCallLimiter() *ethapi.HeavyCallLimiter {
+	return nil
+}
+
 func (b *testBackend) ChainConfig() *params.ChainConfig {
 	return b.chainConfig
 }
@@ -541,6 +554,55 @@ func TestTracingWithOverrides(t *testing.T) {
 	}
 }
 
+func TestTraceCallMany(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{Alloc: core.GenesisAlloc{
+		accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+	}}
+	api := NewAPI(newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {}))
+
+	transfer := func(value int64) ethapi.TransactionArgs {
+		return ethapi.TransactionArgs{
+			From:  &accounts[1].addr,
+			To:    &accounts[0].addr,
+			Value: (*hexutil.Big)(big.NewInt(value)),
+		}
+	}
+	// The overridden balance is carried forward across the calls
+	config := &TraceCallConfig{
+		StateOverrides: &ethapi.StateOverride{
+			accounts[1].addr: ethapi.OverrideAccount{Balance: newRPCBalance(big.NewInt(1000))},
+		},
+	}
+	number := rpc.LatestBlockNumber
+	results, err := api.TraceCallMany(context.Background(), []ethapi.TransactionArgs{transfer(400), transfer(600)}, rpc.BlockNumberOrHash{BlockNumber: &number}, config)
+	if err != nil {
+		t.Fatalf("failed to trace calls: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("result count mismatch: have %d, want 2", len(results))
+	}
+	for i, result := range results {
+		if res := result.(*ethapi.ExecutionResult); res.Failed || res.Gas != params.TxGas {
+			t.Errorf("call %d: unexpected result %+v", i, res)
+		}
+	}
+	// The second call can't spend what the first one already spent
+	_, err = api.TraceCallMany(context.Background(), []ethapi.TransactionArgs{transfer(600), transfer(600)}, rpc.BlockNumberOrHash{BlockNumber: &number}, config)
+	if !errors.Is(err, core.ErrInsufficientFunds) {
+		t.Fatalf("error mismatch: have %v, want %v", err, core.ErrInsufficientFunds)
+	}
+	if !strings.HasPrefix(err.Error(), "call 1:") {
+		t.Fatalf("error not attributed to the second call: %v", err)
+	}
+	if _, err := api.TraceCallMany(context.Background(), nil, rpc.BlockNumberOrHash{BlockNumber: &number}, nil); err != errNoTraceCalls {
+		t.Fatalf("error mismatch: have %v, want %v", err, errNoTraceCalls)
+	}
+}
+
 type Account struct {
 	key  *ecdsa.PrivateKey
 	addr common.Address
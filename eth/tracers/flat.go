@@ -0,0 +1,320 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// flatTracer is the tracer whose call frames are flattened into the parity-style
+// traces, the native call tracer.
+var flatTracer = "callTracer"
+
+// errUnsupportedTraceType is returned if a trace type other than the flat call
+// traces is requested from trace_replayBlockTransactions.
+var errUnsupportedTraceType = errors.New("only the trace type is supported")
+
+// flatErrors maps the evm errors to the ones reported by the parity traces.
+var flatErrors = map[string]string{
+	"execution reverted":       "Reverted",
+	"out of gas":               "Out of gas",
+	"invalid jump destination": "Bad jump destination",
+	"write protection":         "Mutable call in static context",
+	"max call depth exceeded":  "Out of stack",
+}
+
+// callFrame is a call frame of the call tracer result.
+type callFrame struct {
+	Type    string          `json:"type"`
+	From    common.Address  `json:"from"`
+	To      *common.Address `json:"to"`
+	Value   *hexutil.Big    `json:"value"`
+	Gas     hexutil.Uint64  `json:"gas"`
+	GasUsed hexutil.Uint64  `json:"gasUsed"`
+	Input   hexutil.Bytes   `json:"input"`
+	Output  hexutil.Bytes   `json:"output"`
+	Error   string          `json:"error"`
+	Calls   []*callFrame    `json:"calls"`
+}
+
+// FlatTrace is a parity-style flat trace of a call, a contract creation or a
+// self-destruct. The block and transaction fields are left out of the traces of
+// trace_replayBlockTransactions.
+type FlatTrace struct {
+	Action              interface{}  `json:"action"`
+	BlockHash           *common.Hash `json:"blockHash,omitempty"`
+	BlockNumber         *uint64      `json:"blockNumber,omitempty"`
+	Error               string       `json:"error,omitempty"`
+	Result              interface{}  `json:"result"`
+	Subtraces           int          `json:"subtraces"`
+	TraceAddress        []int        `json:"traceAddress"`
+	TransactionHash     *common.Hash `json:"transactionHash,omitempty"`
+	TransactionPosition *uint64      `json:"transactionPosition,omitempty"`
+	Type                string       `json:"type"`
+}
+
+// FlatCallAction is the action of a call trace.
+type FlatCallAction struct {
+	CallType string         `json:"callType"`
+	From     common.Address `json:"from"`
+	Gas      hexutil.Uint64 `json:"gas"`
+	Input    hexutil.Bytes  `json:"input"`
+	To       common.Address `json:"to"`
+	Value    *hexutil.Big   `json:"value"`
+}
+
+// FlatCallResult is the result of a successful call trace.
+type FlatCallResult struct {
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Output  hexutil.Bytes  `json:"output"`
+}
+
+// FlatCreateAction is the action of a contract creation trace.
+type FlatCreateAction struct {
+	From  common.Address `json:"from"`
+	Gas   hexutil.Uint64 `json:"gas"`
+	Init  hexutil.Bytes  `json:"init"`
+	Value *hexutil.Big   `json:"value"`
+}
+
+// FlatCreateResult is the result of a successful contract creation trace.
+type FlatCreateResult struct {
+	Address common.Address `json:"address"`
+	Code    hexutil.Bytes  `json:"code"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+}
+
+// FlatSuicideAction is the action of a self-destruct trace.
+type FlatSuicideAction struct {
+	Address       common.Address `json:"address"`
+	RefundAddress common.Address `json:"refundAddress"`
+	Balance       *hexutil.Big   `json:"balance"`
+}
+
+// TxFlatTraces are the flat traces of a transaction replayed by
+// trace_replayBlockTransactions.
+type TxFlatTraces struct {
+	Output          hexutil.Bytes `json:"output"`
+	StateDiff       interface{}   `json:"stateDiff"` // Unsupported, always null
+	Trace           []*FlatTrace  `json:"trace"`
+	TransactionHash common.Hash   `json:"transactionHash"`
+	VMTrace         interface{}   `json:"vmTrace"` // Unsupported, always null
+}
+
+// TraceAPI is the collection of the parity-style tracing methods exposed over the
+// trace namespace, for the indexers requiring the flat parity trace format. The
+// system transactions of the PoSA engines are traced like the others.
+type TraceAPI struct {
+	api *API
+}
+
+// NewTraceAPI creates a new API definition for the parity-style tracing methods.
+func NewTraceAPI(backend Backend) *TraceAPI {
+	return &TraceAPI{api: NewAPI(backend)}
+}
+
+// Block returns the flat traces of all the transactions of the block.
+func (api *TraceAPI) Block(ctx context.Context, number rpc.BlockNumber) ([]*FlatTrace, error) {
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	frames, err := api.blockFrames(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		hash   = block.Hash()
+		num    = block.NumberU64()
+		traces = make([]*FlatTrace, 0)
+	)
+	for i, tx := range block.Transactions() {
+		txHash, pos := tx.Hash(), uint64(i)
+		for _, trace := range flattenCallFrame(frames[i]) {
+			trace.BlockHash, trace.BlockNumber = &hash, &num
+			trace.TransactionHash, trace.TransactionPosition = &txHash, &pos
+			traces = append(traces, trace)
+		}
+	}
+	return traces, nil
+}
+
+// Transaction returns the flat traces of the transaction, nil if the transaction
+// is unknown.
+func (api *TraceAPI) Transaction(ctx context.Context, hash common.Hash) ([]*FlatTrace, error) {
+	tx, blockHash, blockNumber, index, err := api.api.backend.GetTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, nil
+	}
+	res, err := api.api.TraceTransaction(ctx, hash, &TraceConfig{Tracer: &flatTracer})
+	if err != nil {
+		return nil, err
+	}
+	frame, err := decodeCallFrame(res)
+	if err != nil {
+		return nil, err
+	}
+	traces := flattenCallFrame(frame)
+	for _, trace := range traces {
+		trace.BlockHash, trace.BlockNumber = &blockHash, &blockNumber
+		trace.TransactionHash, trace.TransactionPosition = &hash, &index
+	}
+	return traces, nil
+}
+
+// ReplayBlockTransactions returns the flat traces of all the transactions of the
+// block, grouped by transaction. Only the trace type is supported.
+func (api *TraceAPI) ReplayBlockTransactions(ctx context.Context, number rpc.BlockNumber, traceTypes []string) ([]*TxFlatTraces, error) {
+	for _, typ := range traceTypes {
+		if typ != "trace" {
+			return nil, fmt.Errorf("%w: %s", errUnsupportedTraceType, typ)
+		}
+	}
+	block, err := api.api.blockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	frames, err := api.blockFrames(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*TxFlatTraces, len(frames))
+	for i, tx := range block.Transactions() {
+		results[i] = &TxFlatTraces{
+			Output:          frames[i].Output,
+			Trace:           flattenCallFrame(frames[i]),
+			TransactionHash: tx.Hash(),
+		}
+	}
+	return results, nil
+}
+
+// blockFrames traces the transactions of the block with the call tracer.
+func (api *TraceAPI) blockFrames(ctx context.Context, block *types.Block) ([]*callFrame, error) {
+	results, err := api.api.traceBlock(ctx, block, &TraceConfig{Tracer: &flatTracer})
+	if err != nil {
+		return nil, err
+	}
+	frames := make([]*callFrame, len(results))
+	for i, res := range results {
+		if res.Error != "" {
+			return nil, fmt.Errorf("tracing transaction %d failed: %s", i, res.Error)
+		}
+		if frames[i], err = decodeCallFrame(res.Result); err != nil {
+			return nil, err
+		}
+	}
+	return frames, nil
+}
+
+// decodeCallFrame decodes the result of the call tracer.
+func decodeCallFrame(res interface{}) (*callFrame, error) {
+	raw, ok := res.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected trace result %T", res)
+	}
+	frame := new(callFrame)
+	if err := json.Unmarshal(raw, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// flattenCallFrame flattens the call frame and its inner calls, depth first, into
+// the parity-style traces.
+func flattenCallFrame(frame *callFrame) []*FlatTrace {
+	var traces []*FlatTrace
+	var flatten func(frame *callFrame, address []int)
+	flatten = func(frame *callFrame, address []int) {
+		traces = append(traces, newFlatTrace(frame, address))
+		for i, call := range frame.Calls {
+			flatten(call, append(append(make([]int, 0, len(address)+1), address...), i))
+		}
+	}
+	flatten(frame, []int{})
+	return traces
+}
+
+// newFlatTrace converts a single call frame into a parity-style trace.
+func newFlatTrace(frame *callFrame, address []int) *FlatTrace {
+	trace := &FlatTrace{
+		Subtraces:    len(frame.Calls),
+		TraceAddress: address,
+	}
+	value := frame.Value
+	if value == nil {
+		value = new(hexutil.Big)
+	}
+	var to common.Address
+	if frame.To != nil {
+		to = *frame.To
+	}
+	switch frame.Type {
+	case "CREATE", "CREATE2":
+		trace.Type = "create"
+		trace.Action = &FlatCreateAction{From: frame.From, Gas: frame.Gas, Init: frame.Input, Value: value}
+		if frame.Error == "" {
+			trace.Result = &FlatCreateResult{Address: to, Code: frame.Output, GasUsed: frame.GasUsed}
+		}
+	case "SELFDESTRUCT":
+		trace.Type = "suicide"
+		trace.Action = &FlatSuicideAction{Address: frame.From, RefundAddress: to, Balance: value}
+	default:
+		trace.Type = "call"
+		trace.Action = &FlatCallAction{
+			CallType: strings.ToLower(frame.Type),
+			From:     frame.From,
+			Gas:      frame.Gas,
+			Input:    frame.Input,
+			To:       to,
+			Value:    value,
+		}
+		if frame.Error == "" {
+			trace.Result = &FlatCallResult{GasUsed: frame.GasUsed, Output: frame.Output}
+		}
+	}
+	if frame.Error != "" {
+		trace.Error = flatError(frame.Error)
+	}
+	return trace
+}
+
+// flatError returns the parity error of an evm error.
+func flatError(err string) string {
+	if mapped, ok := flatErrors[err]; ok {
+		return mapped
+	}
+	if strings.HasPrefix(err, "invalid opcode") {
+		return "Bad instruction"
+	}
+	if strings.HasPrefix(err, "stack underflow") || strings.HasPrefix(err, "stack limit reached") {
+		return "Out of stack"
+	}
+	return err
+}
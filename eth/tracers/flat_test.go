@@ -0,0 +1,75 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// Tests that the nested call frames are flattened depth first into the parity
+// traces, with the trace addresses, the types and the errors of parity.
+func TestFlattenCallFrame(t *testing.T) {
+	raw := json.RawMessage(`{
+		"type": "CALL", "from": "0x0000000000000000000000000000000000000001", "to": "0x0000000000000000000000000000000000000002",
+		"value": "0x1", "gas": "0x1000", "gasUsed": "0x100", "input": "0x", "output": "0x",
+		"calls": [
+			{"type": "CREATE", "from": "0x0000000000000000000000000000000000000002", "to": "0x0000000000000000000000000000000000000003",
+			 "gas": "0x800", "gasUsed": "0x80", "input": "0x60", "output": "0x00",
+			 "calls": [{"type": "SELFDESTRUCT", "from": "0x0000000000000000000000000000000000000003", "to": "0x0000000000000000000000000000000000000001", "gas": "0x0", "gasUsed": "0x0", "input": "0x"}]},
+			{"type": "STATICCALL", "from": "0x0000000000000000000000000000000000000002", "to": "0x0000000000000000000000000000000000000004",
+			 "gas": "0x400", "gasUsed": "0x400", "input": "0x", "error": "out of gas"}
+		]
+	}`)
+	frame, err := decodeCallFrame(raw)
+	if err != nil {
+		t.Fatalf("failed to decode the call frame: %v", err)
+	}
+	traces := flattenCallFrame(frame)
+
+	want := []struct {
+		typ       string
+		address   []int
+		subtraces int
+		err       string
+	}{
+		{"call", []int{}, 2, ""},
+		{"create", []int{0}, 1, ""},
+		{"suicide", []int{0, 0}, 0, ""},
+		{"call", []int{1}, 0, "Out of gas"},
+	}
+	if len(traces) != len(want) {
+		t.Fatalf("trace count mismatch: have %d, want %d", len(traces), len(want))
+	}
+	for i, w := range want {
+		trace := traces[i]
+		if trace.Type != w.typ || !reflect.DeepEqual(trace.TraceAddress, w.address) || trace.Subtraces != w.subtraces || trace.Error != w.err {
+			t.Errorf("trace %d mismatch: have %s %v %d %q, want %s %v %d %q", i,
+				trace.Type, trace.TraceAddress, trace.Subtraces, trace.Error, w.typ, w.address, w.subtraces, w.err)
+		}
+	}
+	if action := traces[3].Action.(*FlatCallAction); action.CallType != "staticcall" {
+		t.Errorf("call type mismatch: have %s, want staticcall", action.CallType)
+	}
+	if traces[3].Result != nil {
+		t.Errorf("failed call has a result: %v", traces[3].Result)
+	}
+	if result := traces[1].Result.(*FlatCreateResult); result.Address != *frame.Calls[0].To {
+		t.Errorf("created address mismatch: have %x, want %x", result.Address, *frame.Calls[0].To)
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/congress"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/filters"
@@ -978,10 +979,91 @@ func (b *Block) EstimateGas(ctx context.Context, args struct {
 			return 0, err
 		}
 	}
-	gas, err := ethapi.DoEstimateGas(ctx, b.backend, args.Data, *b.numberOrHash, b.backend.RPCGasCap())
+	gas, err := ethapi.DoEstimateGas(ctx, b.backend, args.Data, *b.numberOrHash, b.backend.RPCEstimateGasCap())
 	return Long(gas), err
 }
 
+func (b *Block) Validator(ctx context.Context) (*common.Address, error) {
+	engine, ok := b.backend.Engine().(*congress.Congress)
+	if !ok {
+		return nil, nil
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The genesis block isn't sealed by any validator
+	if header.Number.Sign() == 0 {
+		return nil, nil
+	}
+	validator, err := engine.Signer(header)
+	if err != nil {
+		return nil, err
+	}
+	return &validator, nil
+}
+
+func (b *Block) InTurn(ctx context.Context) (*bool, error) {
+	if _, ok := b.backend.Engine().(*congress.Congress); !ok {
+		return nil, nil
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inTurn := congress.InTurn(header)
+	return &inTurn, nil
+}
+
+func (b *Block) SystemTransactions(ctx context.Context) (*[]*Transaction, error) {
+	engine, ok := b.backend.Engine().(*congress.Congress)
+	if !ok {
+		return nil, nil
+	}
+	block, err := b.resolve(ctx)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	var (
+		header = block.Header()
+		signer = types.MakeSigner(b.backend.ChainConfig(), header.Number)
+		ret    = make([]*Transaction, 0)
+	)
+	for i, tx := range block.Transactions() {
+		sender, err := types.Sender(signer, tx)
+		if err != nil {
+			return nil, err
+		}
+		if isSys, _ := engine.IsSysTransaction(sender, tx, header); !isSys {
+			continue
+		}
+		ret = append(ret, &Transaction{
+			backend: b.backend,
+			hash:    tx.Hash(),
+			tx:      tx,
+			block:   b,
+			index:   uint64(i),
+		})
+	}
+	return &ret, nil
+}
+
+func (b *Block) ValidatorSet(ctx context.Context) (*[]common.Address, error) {
+	engine, ok := b.backend.Engine().(*congress.Congress)
+	if !ok {
+		return nil, nil
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	validators, ok := engine.CheckpointValidators(header)
+	if !ok {
+		return nil, nil
+	}
+	return &validators, nil
+}
+
 type Pending struct {
 	backend ethapi.Backend
 }
@@ -1043,7 +1125,7 @@ func (p *Pending) EstimateGas(ctx context.Context, args struct {
 	Data ethapi.TransactionArgs
 }) (Long, error) {
 	pendingBlockNr := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
-	gas, err := ethapi.DoEstimateGas(ctx, p.backend, args.Data, pendingBlockNr, p.backend.RPCGasCap())
+	gas, err := ethapi.DoEstimateGas(ctx, p.backend, args.Data, pendingBlockNr, p.backend.RPCEstimateGasCap())
 	return Long(gas), err
 }
 
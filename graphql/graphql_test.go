@@ -143,6 +143,12 @@ func TestGraphQLBlockSerialization(t *testing.T) {
 			want: `{"data":{"block":{"number":10,"call":{"data":"0x","status":1}}}}`,
 			code: 200,
 		},
+		// should return null congress fields on a chain run by another engine
+		{
+			body: `{"query": "{block{number validator inTurn systemTransactions{hash} validatorSet}}"}`,
+			want: `{"data":{"block":{"number":10,"validator":null,"inTurn":null,"systemTransactions":null,"validatorSet":null}}}`,
+			code: 200,
+		},
 	} {
 		resp, err := http.Post(fmt.Sprintf("%s/graphql", stack.HTTPEndpoint()), "application/json", strings.NewReader(tt.body))
 		if err != nil {
@@ -198,6 +204,50 @@ func TestGraphQLBlockSerializationEIP2718(t *testing.T) {
 }
 
 // Tests that a graphQL request is not handled successfully when graphql is not enabled on the specified endpoint
+// Tests that the genesis block of a congress chain, not sealed by any validator,
+// reports a null validator instead of failing to recover its seal.
+func TestGraphQLCongressGenesis(t *testing.T) {
+	stack := createNode(t, false, false)
+	defer stack.Close()
+
+	validator := common.Address{0x01}
+	ethConf := &ethconfig.Config{
+		Genesis: &core.Genesis{
+			Config:     params.AllCongressProtocolChanges,
+			ExtraData:  append(append(make([]byte, 32), validator.Bytes()...), make([]byte, crypto.SignatureLength)...),
+			GasLimit:   11500000,
+			Difficulty: big.NewInt(1),
+		},
+		NetworkId:      1337,
+		TrieCleanCache: 5,
+		TrieDirtyCache: 5,
+		TrieTimeout:    60 * time.Minute,
+		SnapshotCache:  5,
+	}
+	ethBackend, err := eth.New(stack, ethConf)
+	if err != nil {
+		t.Fatalf("could not create eth backend: %v", err)
+	}
+	if err := New(stack, ethBackend.APIBackend, []string{}, []string{}); err != nil {
+		t.Fatalf("could not create graphql service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	body := `{"query": "{block(number:0){number validator}}"}`
+	resp, err := http.Post(fmt.Sprintf("%s/graphql", stack.HTTPEndpoint()), "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("could not post: %v", err)
+	}
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("could not read from response body: %v", err)
+	}
+	if have, want := string(bodyBytes), `{"data":{"block":{"number":0,"validator":null}}}`; have != want {
+		t.Errorf("response mismatch:\nhave:\n%v\nwant:\n%v", have, want)
+	}
+}
+
 func TestGraphQLHTTPOnSamePort_GQLRequest_Unsuccessful(t *testing.T) {
 	stack := createNode(t, false, false)
 	defer stack.Close()
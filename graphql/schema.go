@@ -231,6 +231,19 @@ const schema string = `
         # EstimateGas estimates the amount of gas that will be required for
         # successful execution of a transaction at the current block's state.
         estimateGas(data: CallData!): Long!
+        # Validator is the validator that sealed this block, recovered from the
+        # seal. This field is null for the genesis block, which isn't sealed, and
+        # if the chain isn't run by the congress engine, the same as the other
+        # congress fields.
+        validator: Address
+        # InTurn is whether this block was sealed by the in-turn validator.
+        inTurn: Boolean
+        # SystemTransactions is the list of system transactions in this block,
+        # sent by the validator to the system contracts.
+        systemTransactions: [Transaction!]
+        # ValidatorSet is the validator set stored in this block if it is an
+        # epoch checkpoint, otherwise this field is null.
+        validatorSet: [Address!]
     }
 
     # CallData represents the data associated with a local contract call.
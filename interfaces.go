@@ -21,6 +21,7 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -103,6 +104,21 @@ type SyncProgress struct {
 	HighestBlock  uint64 // Highest alleged block number in the chain
 	PulledStates  uint64 // Number of state trie entries already downloaded
 	KnownStates   uint64 // Total number of state trie entries known about
+
+	// Fields belonging to the snap sync of the state
+	SyncedAccounts      uint64        // Number of accounts downloaded
+	SyncedAccountBytes  uint64        // Number of account trie bytes persisted to disk
+	SyncedBytecodes     uint64        // Number of bytecodes downloaded
+	SyncedBytecodeBytes uint64        // Number of bytecode bytes downloaded
+	SyncedStorage       uint64        // Number of storage slots downloaded
+	SyncedStorageBytes  uint64        // Number of storage trie bytes persisted to disk
+	HealingState        bool          // Whether the downloaded state is being healed
+	HealedTrienodes     uint64        // Number of state trie nodes downloaded
+	HealedTrienodeBytes uint64        // Number of state trie bytes persisted to disk
+	HealedBytecodes     uint64        // Number of bytecodes downloaded
+	HealedBytecodeBytes uint64        // Number of bytecodes persisted to disk
+	HealingPending      uint64        // Number of trie nodes and bytecodes pending to be healed
+	StateSyncETA        time.Duration // Estimated remaining time of the state download, zero if unknown
 }
 
 // ChainSyncReader wraps access to the node's current sync status. If there's no
@@ -23,6 +23,8 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"runtime"
+	"sync"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -138,14 +140,7 @@ func init() {
 func setupLogHandler(ctx *cli.Context) (handler log.Handler) {
 	defer func() {
 		if !ctx.GlobalBool(metricLogFlag.Name) {
-			inner := handler
-			handler = log.FuncHandler(func(r *log.Record) error {
-				if r.Msg == metricKey {
-					return nil
-				}
-
-				return inner.Log(r)
-			})
+			handler = withoutMetrics(handler)
 		}
 	}()
 
@@ -168,18 +163,35 @@ func setupLogHandler(ctx *cli.Context) (handler log.Handler) {
 
 	rConfig := log.NewRotateConfig()
 	rConfig.LogDir = ctx.GlobalString(logPathFlag.Name)
-	handler1 := log.NewFileRotateHandler(rConfig, format)
-	if !ctx.GlobalBool(metricLogFlag.Name) {
-		handler = handler1
-		return
+
+	rotation.format, rotation.metric, rotation.logDir = format, ctx.GlobalBool(metricLogFlag.Name), rConfig.LogDir
+	handler, rotation.files = newRotateHandler(rConfig, format, rotation.metric)
+	return
+}
+
+// rotation is the state of the file logging, kept to apply new rotation settings.
+var rotation struct {
+	format  log.Format
+	metric  bool          // Whether the metric records are logged to their own file
+	logDir  string        // Folder of the log files, from the command line unless reconfigured
+	files   []log.Handler // Handlers of the log files, closed once replaced
+	handler atomic.Value  // Handler of the log records, swapped on reloads
+	lock    sync.Mutex
+}
+
+// newRotateHandler creates the handler writing to the rotated log files, along
+// with the handlers of the individual files.
+func newRotateHandler(config *log.RotateConfig, format log.Format, metric bool) (log.Handler, []log.Handler) {
+	handler1 := log.NewFileRotateHandler(config, format)
+	if !metric {
+		return handler1, []log.Handler{handler1}
 	}
 
-	mConfig := log.NewRotateConfig()
-	mConfig.LogDir = ctx.GlobalString(logPathFlag.Name)
+	mConfig := *config
 	mConfig.Filename = metricLogFile
-	handler2 := log.NewFileRotateHandler(mConfig, log.JSONFormat())
+	handler2 := log.NewFileRotateHandler(&mConfig, log.JSONFormat())
 
-	handler = log.FuncHandler(func(r *log.Record) error {
+	handler := log.FuncHandler(func(r *log.Record) error {
 		if r.Msg == metricKey {
 			r.Ctx = append(r.Ctx, "id", ID)
 			return handler2.Log(r)
@@ -187,15 +199,64 @@ func setupLogHandler(ctx *cli.Context) (handler log.Handler) {
 			return handler1.Log(r)
 		}
 	})
+	return handler, []log.Handler{handler1, handler2}
+}
 
-	return
+// SetLogRotation replaces the log file handlers with ones using the given rotation
+// settings, e.g. loaded from the configuration file. It's a noop if file logging
+// is not enabled.
+func SetLogRotation(config log.RotateConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	rotation.lock.Lock()
+	defer rotation.lock.Unlock()
+
+	if rotation.files == nil {
+		return nil
+	}
+	if config.LogDir == "" {
+		config.LogDir = rotation.logDir
+	}
+	handler, files := newRotateHandler(&config, rotation.format, rotation.metric)
+	if !rotation.metric {
+		handler = withoutMetrics(handler)
+	}
+	rotation.handler.Store(handlerBox{handler})
+	for _, file := range rotation.files {
+		if closer, ok := file.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	rotation.files = files
+	rotation.logDir = config.LogDir
+	log.Info("Log rotation reconfigured", "dir", config.LogDir, "file", config.Filename, "maxsize", config.MaxSize,
+		"maxage", config.MaxAge, "maxbackups", config.MaxBackups)
+	return nil
+}
+
+// handlerBox wraps the handlers for storing different types in the atomic value.
+type handlerBox struct {
+	log.Handler
+}
+
+// withoutMetrics filters the metric records out.
+func withoutMetrics(inner log.Handler) log.Handler {
+	return log.FuncHandler(func(r *log.Record) error {
+		if r.Msg == metricKey {
+			return nil
+		}
+		return inner.Log(r)
+	})
 }
 
 // Setup initializes profiling and logging based on the CLI flags.
 // It should be called as early as possible in the program.
 func Setup(ctx *cli.Context) error {
-	handler := setupLogHandler(ctx)
-	glogger.SetHandler(handler)
+	rotation.handler.Store(handlerBox{setupLogHandler(ctx)})
+	glogger.SetHandler(log.FuncHandler(func(r *log.Record) error {
+		return rotation.handler.Load().(handlerBox).Log(r)
+	}))
 
 	// logging
 	verbosity := ctx.GlobalInt(verbosityFlag.Name)
@@ -36,9 +36,11 @@ import (
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/consensus/congress"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -130,6 +132,53 @@ func (s *PublicEthereumAPI) GasPricePrediction(ctx context.Context) (map[string]
 	}, nil
 }
 
+// GasPricesNotification is the payload of the gasPrices subscription.
+type GasPricesNotification struct {
+	Fast     uint `json:"fast"`
+	Median   uint `json:"median"`
+	Low      uint `json:"low"`
+	JamIndex int  `json:"jamIndex"`
+	JamLevel int  `json:"jamLevel"`
+}
+
+// GasPrices sends a notification each time the gas price suggestions change, or
+// the jam index of the transaction pool crosses a threshold.
+func (s *PublicEthereumAPI) GasPrices(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		prices := make(chan core.GasPricesEvent, 10)
+		pricesSub := s.b.SubscribeGasPricesEvent(prices)
+		defer pricesSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-prices:
+				notifier.Notify(rpcSub.ID, &GasPricesNotification{
+					Fast:     ev.Prices[0],
+					Median:   ev.Prices[1],
+					Low:      ev.Prices[2],
+					JamIndex: ev.JamIndex,
+					JamLevel: ev.JamLevel,
+				})
+			case <-pricesSub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Syncing returns false in case the node is currently not syncing with the network. It can be up to date or has not
 // yet received the latest block headers from its pears. In case it is synchronizing:
 // - startingBlock: block number this node started to synchronise from
@@ -151,6 +200,20 @@ func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 		"highestBlock":  hexutil.Uint64(progress.HighestBlock),
 		"pulledStates":  hexutil.Uint64(progress.PulledStates),
 		"knownStates":   hexutil.Uint64(progress.KnownStates),
+
+		"syncedAccounts":      hexutil.Uint64(progress.SyncedAccounts),
+		"syncedAccountBytes":  hexutil.Uint64(progress.SyncedAccountBytes),
+		"syncedBytecodes":     hexutil.Uint64(progress.SyncedBytecodes),
+		"syncedBytecodeBytes": hexutil.Uint64(progress.SyncedBytecodeBytes),
+		"syncedStorage":       hexutil.Uint64(progress.SyncedStorage),
+		"syncedStorageBytes":  hexutil.Uint64(progress.SyncedStorageBytes),
+		"healingState":        progress.HealingState,
+		"healedTrienodes":     hexutil.Uint64(progress.HealedTrienodes),
+		"healedTrienodeBytes": hexutil.Uint64(progress.HealedTrienodeBytes),
+		"healedBytecodes":     hexutil.Uint64(progress.HealedBytecodes),
+		"healedBytecodeBytes": hexutil.Uint64(progress.HealedBytecodeBytes),
+		"healingPending":      hexutil.Uint64(progress.HealingPending),
+		"stateSyncEta":        hexutil.Uint64(progress.StateSyncETA / time.Second),
 	}, nil
 }
 
@@ -172,11 +235,12 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	}
 	pending, queue := s.b.TxPoolContent()
 	curHeader := s.b.CurrentHeader()
+	now := time.Now()
 	// Flatten the pending transactions
 	for account, txs := range pending {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPoolTransaction(tx, curHeader, s.b.ChainConfig(), now)
 		}
 		content["pending"][account.Hex()] = dump
 	}
@@ -184,7 +248,7 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	for account, txs := range queue {
 		dump := make(map[string]*RPCTransaction)
 		for _, tx := range txs {
-			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+			dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPoolTransaction(tx, curHeader, s.b.ChainConfig(), now)
 		}
 		content["queued"][account.Hex()] = dump
 	}
@@ -196,18 +260,19 @@ func (s *PublicTxPoolAPI) ContentFrom(addr common.Address) map[string]map[string
 	content := make(map[string]map[string]*RPCTransaction, 2)
 	pending, queue := s.b.TxPoolContentFrom(addr)
 	curHeader := s.b.CurrentHeader()
+	now := time.Now()
 
 	// Build the pending transactions
 	dump := make(map[string]*RPCTransaction, len(pending))
 	for _, tx := range pending {
-		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPoolTransaction(tx, curHeader, s.b.ChainConfig(), now)
 	}
 	content["pending"] = dump
 
 	// Build the queued transactions
 	dump = make(map[string]*RPCTransaction, len(queue))
 	for _, tx := range queue {
-		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx, curHeader, s.b.ChainConfig())
+		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPoolTransaction(tx, curHeader, s.b.ChainConfig(), now)
 	}
 	content["queued"] = dump
 
@@ -301,6 +366,86 @@ func (s *PublicTxPoolAPI) JamIndex() int {
 	return s.b.JamIndex()
 }
 
+// RPCResubmitTx represents a local transaction managed by the resubmission service.
+type RPCResubmitTx struct {
+	Hash       common.Hash    `json:"hash"`
+	Origin     common.Hash    `json:"origin"`
+	From       common.Address `json:"from"`
+	Nonce      hexutil.Uint64 `json:"nonce"`
+	GasPrice   *hexutil.Big   `json:"gasPrice"`
+	TrackedAt  hexutil.Uint64 `json:"trackedAt"`
+	LastSubmit hexutil.Uint64 `json:"lastSubmit"`
+	Resubmits  int            `json:"resubmits"`
+	Bumps      int            `json:"bumps"`
+}
+
+// Resubmissions returns the local transactions that are tracked by the resubmission
+// service, it's empty if the service is disabled.
+func (s *PublicTxPoolAPI) Resubmissions() []*RPCResubmitTx {
+	infos := s.b.ResubmitManaged()
+	result := make([]*RPCResubmitTx, 0, len(infos))
+	for _, info := range infos {
+		result = append(result, &RPCResubmitTx{
+			Hash:       info.Hash,
+			Origin:     info.Origin,
+			From:       info.From,
+			Nonce:      hexutil.Uint64(info.Nonce),
+			GasPrice:   (*hexutil.Big)(info.GasPrice),
+			TrackedAt:  hexutil.Uint64(info.TrackedAt),
+			LastSubmit: hexutil.Uint64(info.LastSubmit),
+			Resubmits:  info.Resubmits,
+			Bumps:      info.Bumps,
+		})
+	}
+	return result
+}
+
+// RPCBlockedTx represents a queued transaction held back from promotion.
+type RPCBlockedTx struct {
+	Hash     common.Hash    `json:"hash"`
+	Nonce    hexutil.Uint64 `json:"nonce"`
+	GasPrice *hexutil.Big   `json:"gasPrice"`
+	Reason   string         `json:"reason"`
+}
+
+// RPCNonceGaps is the diagnosis of the queued transactions of an account.
+type RPCNonceGaps struct {
+	ChainNonce   hexutil.Uint64   `json:"chainNonce"`
+	PendingNonce hexutil.Uint64   `json:"pendingNonce"`
+	LowestQueued *hexutil.Uint64  `json:"lowestQueued"`
+	Missing      []hexutil.Uint64 `json:"missing"`
+	Blocked      []*RPCBlockedTx  `json:"blocked"`
+}
+
+// NonceGaps diagnoses why the queued transactions of the account are stuck: it
+// returns the nonce in the head state, the lowest queued nonce, the nonces missing
+// to fill the gaps, and the queued transactions held back with the reasons.
+func (s *PublicTxPoolAPI) NonceGaps(addr common.Address) (*RPCNonceGaps, error) {
+	gaps := s.b.TxPoolNonceGaps(addr)
+	if gaps == nil {
+		return nil, errors.New("transaction pool not available")
+	}
+	result := &RPCNonceGaps{
+		ChainNonce:   hexutil.Uint64(gaps.ChainNonce),
+		PendingNonce: hexutil.Uint64(gaps.PendingNonce),
+		LowestQueued: (*hexutil.Uint64)(gaps.LowestQueued),
+		Missing:      make([]hexutil.Uint64, 0, len(gaps.Missing)),
+		Blocked:      make([]*RPCBlockedTx, 0, len(gaps.Blocked)),
+	}
+	for _, nonce := range gaps.Missing {
+		result.Missing = append(result.Missing, hexutil.Uint64(nonce))
+	}
+	for _, blocked := range gaps.Blocked {
+		result.Blocked = append(result.Blocked, &RPCBlockedTx{
+			Hash:     blocked.Tx.Hash(),
+			Nonce:    hexutil.Uint64(blocked.Tx.Nonce()),
+			GasPrice: (*hexutil.Big)(blocked.Tx.GasFeeCap()),
+			Reason:   blocked.Reason,
+		})
+	}
+	return result, nil
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -667,9 +812,15 @@ func NewPublicBlockChainAPI(b Backend) *PublicBlockChainAPI {
 
 // ChainId is the EIP-155 replay-protection chain id for the current ethereum chain config.
 func (api *PublicBlockChainAPI) ChainId() (*hexutil.Big, error) {
+	cache := api.b.ResponseCache()
+	if chainID := new(hexutil.Big); cache.get(responseKey{kind: cachedChainID}, chainID) {
+		return chainID, nil
+	}
 	// if current block is at or past the EIP-155 replay-protection fork block, return chainID from config
 	if config := api.b.ChainConfig(); config.IsEIP155(api.b.CurrentBlock().Number()) {
-		return (*hexutil.Big)(config.ChainID), nil
+		chainID := (*hexutil.Big)(config.ChainID)
+		cache.add(responseKey{kind: cachedChainID}, chainID)
+		return chainID, nil
 	}
 	return nil, fmt.Errorf("chain not synced beyond EIP-155 replay-protection fork block")
 }
@@ -785,15 +936,22 @@ func (s *PublicBlockChainAPI) GetHeaderByHash(ctx context.Context, hash common.H
 	return nil
 }
 
+// BlockOptions are the optional switches of the block retrieval methods.
+type BlockOptions struct {
+	FullSystemTx bool `json:"fullSystemTx"` // Whether to list the decoded system transactions separately
+}
+
 // GetBlockByNumber returns the requested canonical block.
 // * When blockNr is -1 the chain head is returned.
 // * When blockNr is -2 the pending chain head is returned.
 // * When fullTx is true all transactions in the block are returned, otherwise
 //   only the transaction hash is returned.
-func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+// * When opts.FullSystemTx is true the system transactions of the block are
+//   additionally listed with their decoded governance proposals.
+func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool, opts *BlockOptions) (map[string]interface{}, error) {
 	block, err := s.b.BlockByNumber(ctx, number)
 	if block != nil && err == nil {
-		response, err := s.rpcMarshalBlock(ctx, block, true, fullTx)
+		response, err := s.rpcMarshalBlockWithOptions(ctx, block, fullTx, opts)
 		if err == nil && number == rpc.PendingBlockNumber {
 			// Pending blocks need to nil out a few fields
 			for _, field := range []string{"hash", "nonce", "miner"} {
@@ -806,15 +964,81 @@ func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, number rpc.B
 }
 
 // GetBlockByHash returns the requested block. When fullTx is true all transactions in the block are returned in full
-// detail, otherwise only the transaction hash is returned.
-func (s *PublicBlockChainAPI) GetBlockByHash(ctx context.Context, hash common.Hash, fullTx bool) (map[string]interface{}, error) {
+// detail, otherwise only the transaction hash is returned. When opts.FullSystemTx is true the system transactions
+// of the block are additionally listed with their decoded governance proposals.
+func (s *PublicBlockChainAPI) GetBlockByHash(ctx context.Context, hash common.Hash, fullTx bool, opts *BlockOptions) (map[string]interface{}, error) {
+	// Blocks by hash never change, serve them from the cache if possible
+	cache := s.b.ResponseCache()
+	key := responseKey{kind: cachedBlock, hash: hash, full: fullTx, sys: opts != nil && opts.FullSystemTx}
+	if fields := make(map[string]interface{}); cache.get(key, &fields) {
+		return fields, nil
+	}
 	block, err := s.b.BlockByHash(ctx, hash)
 	if block != nil {
-		return s.rpcMarshalBlock(ctx, block, true, fullTx)
+		fields, err := s.rpcMarshalBlockWithOptions(ctx, block, fullTx, opts)
+		if err == nil {
+			cache.add(key, fields)
+		}
+		return fields, err
 	}
 	return nil, err
 }
 
+// RPCProposal is the RPC representation of a system governance proposal.
+type RPCProposal struct {
+	Id     *hexutil.Big   `json:"id"`
+	Action *hexutil.Big   `json:"action"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Value  *hexutil.Big   `json:"value"`
+	Data   hexutil.Bytes  `json:"data"`
+}
+
+// RPCSystemTransaction labels a system transaction of a block, along with the
+// governance proposal it executes, if any.
+type RPCSystemTransaction struct {
+	Hash             common.Hash    `json:"hash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	Proposal         *RPCProposal   `json:"proposal"`
+}
+
+// rpcMarshalBlockWithOptions marshals a block including its transactions, then
+// adds the optional fields requested by opts.
+func (s *PublicBlockChainAPI) rpcMarshalBlockWithOptions(ctx context.Context, block *types.Block, fullTx bool, opts *BlockOptions) (map[string]interface{}, error) {
+	fields, err := s.rpcMarshalBlock(ctx, block, true, fullTx)
+	if err != nil || opts == nil || !opts.FullSystemTx {
+		return fields, err
+	}
+	sysTxs := make([]*RPCSystemTransaction, 0)
+	if posa, isPoSA := s.b.Engine().(consensus.PoSA); isPoSA {
+		header := block.Header()
+		signer := types.MakeSigner(s.b.ChainConfig(), header.Number)
+		for i, tx := range block.Transactions() {
+			sender, _ := types.Sender(signer, tx)
+			if yes, _ := posa.IsSysTransaction(sender, tx, header); !yes {
+				continue
+			}
+			sysTx := &RPCSystemTransaction{
+				Hash:             tx.Hash(),
+				TransactionIndex: hexutil.Uint64(i),
+			}
+			if prop, err := congress.DecodeProposal(tx); err == nil {
+				sysTx.Proposal = &RPCProposal{
+					Id:     (*hexutil.Big)(prop.Id),
+					Action: (*hexutil.Big)(prop.Action),
+					From:   prop.From,
+					To:     prop.To,
+					Value:  (*hexutil.Big)(prop.Value),
+					Data:   prop.Data,
+				}
+			}
+			sysTxs = append(sysTxs, sysTx)
+		}
+	}
+	fields["systemTransactions"] = sysTxs
+	return fields, nil
+}
+
 func (s *PublicBlockChainAPI) GetSysTransactionsByBlockNumber(ctx context.Context, number rpc.BlockNumber) ([]*RPCTransaction, error) {
 	posa, isPoSA := s.b.Engine().(consensus.PoSA)
 	if !isPoSA {
@@ -908,12 +1132,32 @@ func (s *PublicBlockChainAPI) GetUncleCountByBlockHash(ctx context.Context, bloc
 
 // GetCode returns the code stored at the given address in the state for the given block number.
 func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	// The code at the final blocks never changes, serve it from the cache if possible
+	var (
+		cache = s.b.ResponseCache()
+		key   *responseKey
+	)
+	if cache != nil {
+		if header, err := s.b.HeaderByNumberOrHash(ctx, blockNrOrHash); err == nil && header != nil && cache.final(s.b, header.Number.Uint64()) {
+			key = &responseKey{kind: cachedCode, hash: header.Hash(), addr: address}
+			var code hexutil.Bytes
+			if cache.get(*key, &code) {
+				return code, nil
+			}
+		}
+	}
 	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
 		return nil, err
 	}
 	code := state.GetCode(address)
-	return code, state.Error()
+	if err := state.Error(); err != nil {
+		return code, err
+	}
+	if key != nil {
+		cache.add(*key, hexutil.Bytes(code))
+	}
+	return code, nil
 }
 
 // GetStorageAt returns the storage from the state at the given address, key and
@@ -981,14 +1225,20 @@ func (diff *StateOverride) Apply(state *state.StateDB) error {
 }
 
 func DoCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, timeout time.Duration, globalGasCap uint64) (*core.ExecutionResult, error) {
+	result, _, err := doCall(ctx, b, args, blockNrOrHash, overrides, timeout, globalGasCap)
+	return result, err
+}
+
+// doCall executes the call like DoCall, returning the EVM it was executed in too.
+func doCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *StateOverride, timeout time.Duration, globalGasCap uint64) (*core.ExecutionResult, *vm.EVM, error) {
 	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
 	state, header, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 	if state == nil || err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if err := overrides.Apply(state); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
@@ -1005,11 +1255,11 @@ func DoCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash
 	// Get a new instance of the EVM.
 	msg, err := args.ToMessage(globalGasCap, header.BaseFee)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	evm, vmError, err := b.GetEVM(ctx, msg, state, header, &vm.Config{NoBaseFee: true})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// Wait for the context to be done and cancel the evm. Even if the
 	// EVM has finished, cancelling may be done (repeatedly)
@@ -1022,17 +1272,17 @@ func DoCall(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash
 	gp := new(core.GasPool).AddGas(math.MaxUint64)
 	result, err := core.ApplyMessage(evm, msg, gp)
 	if err := vmError(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// If the timer caused an abort, return an appropriate error message
 	if evm.Cancelled() {
-		return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+		return nil, nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
 	}
 	if err != nil {
-		return result, fmt.Errorf("err: %w (supplied gas %d)", err, msg.Gas())
+		return result, evm, fmt.Errorf("err: %w (supplied gas %d)", err, msg.Gas())
 	}
-	return result, nil
+	return result, evm, nil
 }
 
 func newRevertError(result *core.ExecutionResult) *revertError {
@@ -1180,7 +1430,6 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 		return result.Failed(), result, nil
 	}
 	// Reject the transaction as invalid if it still fails at the highest allowance
-
 	failed, result, err := executable(hi)
 	if err != nil {
 		return 0, err
@@ -1195,7 +1444,22 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 		// Otherwise, the specified gas cap is too low
 		return 0, fmt.Errorf("gas required exceeds allowance (%d)", cap)
 	}
-
+	// The gas consumed ahead of the refund is a lower bound of the requirement, and
+	// usually enough, allowing for the gas withheld from the calls by EIP-150
+	if consumed := result.UsedGas + result.RefundedGas; consumed > lo+1 {
+		lo = consumed - 1
+	}
+	if optimistic := (lo + 1 + params.CallStipend) * 64 / 63; optimistic < hi {
+		failed, _, err := executable(optimistic)
+		if err != nil {
+			return 0, err
+		}
+		if failed {
+			lo = optimistic
+		} else {
+			hi = optimistic
+		}
+	}
 	// Execute the binary search and hone in on an executable gas limit
 	for lo+1 < hi {
 		mid := (hi + lo) / 2
@@ -1212,14 +1476,63 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 		} else {
 			hi = mid
 		}
-		//approximately_estimate_gas
-		if hi-lo < 4000 {
-			break
-		}
 	}
 	return hexutil.Uint64(hi), nil
 }
 
+// GasBreakdown is a gas estimate along with the split of the gas used by the
+// transaction at the estimated limit.
+type GasBreakdown struct {
+	Gas        hexutil.Uint64 `json:"gas"`        // Estimated gas limit
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`    // Gas used at the estimated limit, net of the refund
+	Intrinsic  hexutil.Uint64 `json:"intrinsic"`  // Intrinsic gas, the access list excluded
+	AccessList hexutil.Uint64 `json:"accessList"` // Intrinsic gas of the access list
+	ColdAccess hexutil.Uint64 `json:"coldAccess"` // Surcharge of the cold state accesses of EIP-2929
+	Execution  hexutil.Uint64 `json:"execution"`  // Remaining gas consumed by the execution
+	Refund     hexutil.Uint64 `json:"refund"`     // Gas refunded after the execution
+}
+
+// DoEstimateGasBreakdown estimates the gas needed by the transaction, executing
+// it once more at the estimate to validate it and split the gas it uses.
+func DoEstimateGasBreakdown(ctx context.Context, b Backend, args TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, gasCap uint64) (*GasBreakdown, error) {
+	gas, err := DoEstimateGas(ctx, b, args, blockNrOrHash, gasCap)
+	if err != nil {
+		return nil, err
+	}
+	if args.From == nil {
+		args.From = new(common.Address)
+	}
+	args.Gas = &gas
+
+	result, evm, err := doCall(ctx, b, args, blockNrOrHash, nil, 0, gasCap)
+	if err != nil {
+		return nil, err
+	}
+	if result.Failed() {
+		return nil, fmt.Errorf("execution failed at the estimated gas %d: %v", gas, result.Err)
+	}
+	var accessList uint64
+	if args.AccessList != nil {
+		accessList = uint64(len(*args.AccessList))*params.TxAccessListAddressGas + uint64(args.AccessList.StorageKeys())*params.TxAccessListStorageKeyGas
+	}
+	breakdown := &GasBreakdown{
+		Gas:        gas,
+		GasUsed:    hexutil.Uint64(result.UsedGas),
+		Intrinsic:  hexutil.Uint64(result.IntrinsicGas - accessList),
+		AccessList: hexutil.Uint64(accessList),
+		ColdAccess: hexutil.Uint64(evm.ColdAccessGas()),
+		Refund:     hexutil.Uint64(result.RefundedGas),
+	}
+	// Cold accesses failing for lack of gas in an inner call are counted in full,
+	// while consuming the remaining gas of the call only
+	if execution := hexutil.Uint64(result.UsedGas + result.RefundedGas - result.IntrinsicGas); execution > breakdown.ColdAccess {
+		breakdown.Execution = execution - breakdown.ColdAccess
+	} else {
+		breakdown.ColdAccess = execution
+	}
+	return breakdown, nil
+}
+
 // EstimateGas returns an estimate of the amount of gas needed to execute the
 // given transaction against the current pending block.
 func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
@@ -1227,7 +1540,40 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args TransactionA
 	if blockNrOrHash != nil {
 		bNrOrHash = *blockNrOrHash
 	}
-	return DoEstimateGas(ctx, s.b, args, bNrOrHash, s.b.RPCGasCap())
+	release, err := s.b.HeavyCallLimiter().Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	if timeout := s.b.RPCEstimateEVMTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return DoEstimateGas(ctx, s.b, args, bNrOrHash, s.b.RPCEstimateGasCap())
+}
+
+// EstimateGasBreakdown returns the gas estimate of the given transaction along
+// with the split of the gas it uses between the intrinsic, access and execution
+// costs, and the refund.
+func (s *PublicBlockChainAPI) EstimateGasBreakdown(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*GasBreakdown, error) {
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+	release, err := s.b.HeavyCallLimiter().Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if timeout := s.b.RPCEstimateEVMTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return DoEstimateGasBreakdown(ctx, s.b, args, bNrOrHash, s.b.RPCEstimateGasCap())
 }
 
 // ExecutionResult groups all structured logs emitted by the EVM
@@ -1238,6 +1584,11 @@ type ExecutionResult struct {
 	Failed      bool           `json:"failed"`
 	ReturnValue string         `json:"returnValue"`
 	StructLogs  []StructLogRes `json:"structLogs"`
+
+	// Fee shares of the fee payer and the sender of meta transactions
+	FeePayer       *common.Address `json:"feePayer,omitempty"`
+	FeePayerAmount *hexutil.Big    `json:"feePayerAmount,omitempty"`
+	SenderAmount   *hexutil.Big    `json:"senderAmount,omitempty"`
 }
 
 // StructLogRes stores a structured log emitted by the EVM while replaying a
@@ -1398,6 +1749,7 @@ type RPCTransaction struct {
 	V                *hexutil.Big      `json:"v"`
 	R                *hexutil.Big      `json:"r"`
 	S                *hexutil.Big      `json:"s"`
+	Age              *hexutil.Uint64   `json:"age,omitempty"` // Milliseconds since first seen, only for the pooled transactions
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
@@ -1459,6 +1811,19 @@ func newRPCPendingTransaction(tx *types.Transaction, current *types.Header, conf
 	return newRPCTransaction(tx, common.Hash{}, blockNumber, 0, baseFee, config)
 }
 
+// newRPCPoolTransaction returns a pending transaction of the pool that will
+// serialize to the RPC representation, along with the time since it was first seen.
+func newRPCPoolTransaction(tx *types.Transaction, current *types.Header, config *params.ChainConfig, now time.Time) *RPCTransaction {
+	result := newRPCPendingTransaction(tx, current, config)
+	if age := now.Sub(tx.LocalSeenTime()); age > 0 {
+		ms := hexutil.Uint64(age / time.Millisecond)
+		result.Age = &ms
+	} else {
+		result.Age = new(hexutil.Uint64)
+	}
+	return result
+}
+
 // newRPCTransactionFromBlockIndex returns a transaction that will serialize to the RPC representation.
 func newRPCTransactionFromBlockIndex(b *types.Block, index uint64, config *params.ChainConfig) *RPCTransaction {
 	txs := b.Transactions()
@@ -1694,6 +2059,33 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(ctx context.Context, has
 	return nil, nil
 }
 
+// GetTransactionBySenderAndNonce returns the transaction sent by the given sender with the given nonce.
+// The txpool is consulted first, then the sender and nonce index written along with the transaction
+// lookup entries. It returns nil if no such transaction is known.
+func (s *PublicTransactionPoolAPI) GetTransactionBySenderAndNonce(ctx context.Context, sender common.Address, nonce hexutil.Uint64) (*RPCTransaction, error) {
+	// Try to return a pending transaction
+	pending, queue := s.b.TxPoolContentFrom(sender)
+	for _, tx := range append(pending, queue...) {
+		if tx.Nonce() == uint64(nonce) {
+			return newRPCPendingTransaction(tx, s.b.CurrentHeader(), s.b.ChainConfig()), nil
+		}
+	}
+	// No pending transaction, try to retrieve a finalized one through the index
+	hash := rawdb.ReadTxSenderLookupEntry(s.b.ChainDb(), sender, uint64(nonce))
+	if hash == (common.Hash{}) {
+		return nil, nil
+	}
+	tx, blockHash, blockNumber, index, err := s.b.GetTransaction(ctx, hash)
+	if tx == nil || err != nil {
+		return nil, err
+	}
+	header, err := s.b.HeaderByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return newRPCTransaction(tx, blockHash, blockNumber, index, header.BaseFee, s.b.ChainConfig()), nil
+}
+
 // GetRawTransactionByHash returns the bytes of the transaction for the given hash.
 func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context, hash common.Hash) (hexutil.Bytes, error) {
 	// Retrieve a finalized transaction, or a pooled otherwise
@@ -1713,6 +2105,22 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 
 // GetTransactionReceipt returns the transaction receipt for the given transaction hash.
 func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	// The receipts of the final blocks can't be reorged away, serve them from the
+	// cache if possible
+	cache := s.b.ResponseCache()
+	key := responseKey{kind: cachedReceipt, hash: hash}
+	if fields := make(map[string]interface{}); cache.get(key, &fields) {
+		return fields, nil
+	}
+	fields, err := s.getTransactionReceipt(ctx, hash)
+	if fields != nil && err == nil && cache.final(s.b, uint64(fields["blockNumber"].(hexutil.Uint64))) {
+		cache.add(key, fields)
+	}
+	return fields, err
+}
+
+// getTransactionReceipt assembles the transaction receipt for the given transaction hash.
+func (s *PublicTransactionPoolAPI) getTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
 	tx, blockHash, blockNumber, index, err := s.b.GetTransaction(ctx, hash)
 	if err != nil {
 		return nil, nil
@@ -1746,15 +2154,25 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 		"type":              hexutil.Uint(tx.Type()),
 	}
 	// Assign the effective gas price paid
-	if !s.b.ChainConfig().IsLondon(bigblock) {
-		fields["effectiveGasPrice"] = hexutil.Uint64(tx.GasPrice().Uint64())
-	} else {
+	gasPrice := tx.GasPrice()
+	if s.b.ChainConfig().IsLondon(bigblock) {
 		header, err := s.b.HeaderByHash(ctx, blockHash)
 		if err != nil {
 			return nil, err
 		}
-		gasPrice := new(big.Int).Add(header.BaseFee, tx.EffectiveGasTipValue(header.BaseFee))
-		fields["effectiveGasPrice"] = hexutil.Uint64(gasPrice.Uint64())
+		gasPrice = new(big.Int).Add(header.BaseFee, tx.EffectiveGasTipValue(header.BaseFee))
+	}
+	fields["effectiveGasPrice"] = hexutil.Uint64(gasPrice.Uint64())
+
+	// Assign the fee shares of the fee payer and the sender of meta transactions
+	settlement, err := types.MetaFeeSettlementOf(tx, from, bigblock, s.b.ChainConfig().ChainID, gasPrice, receipt.GasUsed)
+	if err != nil {
+		return nil, err
+	}
+	if settlement != nil {
+		fields["feePayer"] = settlement.FeePayer
+		fields["feePayerAmount"] = (*hexutil.Big)(settlement.FeePayerAmount)
+		fields["senderAmount"] = (*hexutil.Big)(settlement.SenderAmount)
 	}
 	// Assign receipt status or post state.
 	if len(receipt.PostState) > 0 {
@@ -1787,6 +2205,12 @@ func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transacti
 
 // SubmitTransaction is a helper function that submits tx to txPool and logs a message.
 func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (common.Hash, error) {
+	return submitTransaction(ctx, b, tx, false)
+}
+
+// submitTransaction submits tx to txPool, only sent to the private peers if private
+// is set, and logs a message.
+func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction, private bool) (common.Hash, error) {
 	// If the transaction fee cap is already specified, ensure the
 	// fee of the given transaction is _reasonable_.
 	if err := checkTxFee(tx.GasPrice(), tx.Gas(), b.RPCTxFeeCap()); err != nil {
@@ -1796,7 +2220,11 @@ func SubmitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 		// Ensure only eip155 signed transactions are submitted if EIP155Required is set.
 		return common.Hash{}, errors.New("only replay-protected (EIP-155) transactions allowed over RPC")
 	}
-	if err := b.SendTx(ctx, tx); err != nil {
+	send := b.SendTx
+	if private {
+		send = b.SendPrivateTx
+	}
+	if err := send(ctx, tx); err != nil {
 		return common.Hash{}, err
 	}
 	// Print a log with full tx details for manual investigations and interventions
@@ -1877,6 +2305,21 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, input
 	return SubmitTransaction(ctx, s.b, tx)
 }
 
+// SendPrivateTransaction will add the signed transaction to the transaction pool,
+// only sending it to the trusted and configured private peers, e.g. validators,
+// instead of gossiping it to all the peers. The sender is responsible for signing
+// the transaction and using the correct nonce.
+func (s *PublicTransactionPoolAPI) SendPrivateTransaction(ctx context.Context, input hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return common.Hash{}, err
+	}
+	if err := metaTransactionCheck(ctx, tx, s.b); err != nil {
+		return common.Hash{}, err
+	}
+	return submitTransaction(ctx, s.b, tx, true)
+}
+
 /**
 check tx meta transaction format.
 */
@@ -2089,6 +2532,32 @@ func (api *PublicDebugAPI) GetBlockRlp(ctx context.Context, number uint64) (hexu
 	return rlp.EncodeToBytes(block)
 }
 
+// GetRawReceipts retrieves the binary encoded receipts of a single block, typed
+// receipts in their EIP-2718 envelope, system transaction receipts included.
+func (api *PublicDebugAPI) GetRawReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]hexutil.Bytes, error) {
+	hash, ok := blockNrOrHash.Hash()
+	if !ok {
+		block, err := api.b.BlockByNumberOrHash(ctx, blockNrOrHash)
+		if block == nil || err != nil {
+			return nil, fmt.Errorf("block %v not found", blockNrOrHash.String())
+		}
+		hash = block.Hash()
+	}
+	receipts, err := api.b.GetReceipts(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]hexutil.Bytes, len(receipts))
+	for i, receipt := range receipts {
+		b, err := receipt.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		result[i] = b
+	}
+	return result, nil
+}
+
 // TestSignCliqueBlock fetches the given block number, and attempts to sign it as a clique header with the
 // given address, returning the address of the recovered signature
 //
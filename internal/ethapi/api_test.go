@@ -0,0 +1,229 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2b96dbc2b50291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testBalance = big.NewInt(1e18)
+)
+
+// testBackend is a Backend serving a local chain, the methods the tests don't
+// use are left unimplemented.
+type testBackend struct {
+	Backend
+
+	db     ethdb.Database
+	chain  *core.BlockChain
+	pool   types.Transactions
+	pruned uint64 // States below this block are reported missing, as on a non-archive node
+
+	cache     *ResponseCache
+	stateReqs int // Number of the states requested by block number or hash
+}
+
+// newTestBackend creates a backend on a chain of n blocks, the transactions of
+// the test account being added by gen.
+func newTestBackend(t *testing.T, n int, gen func(int, *core.BlockGen)) *testBackend {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		genesis = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{testAddr: {Balance: testBalance}}}
+		engine  = ethash.NewFaker()
+	)
+	block := genesis.MustCommit(db)
+	blocks, _ := core.GenerateChain(genesis.Config, block, engine, db, n, gen)
+
+	chain, err := core.NewBlockChain(db, &core.CacheConfig{TrieDirtyDisabled: true}, genesis.Config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	t.Cleanup(chain.Stop)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	return &testBackend{db: db, chain: chain}
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig { return b.chain.Config() }
+func (b *testBackend) ChainDb() ethdb.Database          { return b.db }
+func (b *testBackend) CurrentHeader() *types.Header     { return b.chain.CurrentHeader() }
+
+func (b *testBackend) header(number rpc.BlockNumber) *types.Header {
+	if number == rpc.LatestBlockNumber {
+		return b.chain.CurrentHeader()
+	}
+	return b.chain.GetHeaderByNumber(uint64(number))
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if header := b.header(number); header != nil {
+		return b.chain.GetBlock(header.Hash(), header.Number.Uint64()), nil
+	}
+	return nil, nil
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	header := b.header(number)
+	if header == nil {
+		return nil, nil, errors.New("header not found")
+	}
+	if header.Number.Uint64() < b.pruned {
+		return nil, nil, errors.New("missing trie node")
+	}
+	statedb, err := b.chain.StateAt(header.Root)
+	return statedb, header, err
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.chain.GetHeaderByHash(hash), nil
+}
+
+func (b *testBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		return b.chain.GetHeaderByHash(hash), nil
+	}
+	number, _ := blockNrOrHash.Number()
+	return b.header(number), nil
+}
+
+func (b *testBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	b.stateReqs++
+	header, _ := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if header == nil {
+		return nil, nil, errors.New("header not found")
+	}
+	return b.StateAndHeaderByNumber(ctx, rpc.BlockNumber(header.Number.Int64()))
+}
+
+func (b *testBackend) ResponseCache() *ResponseCache { return b.cache }
+
+func (b *testBackend) GetTransaction(ctx context.Context, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(b.db, hash)
+	return tx, blockHash, blockNumber, index, nil
+}
+
+func (b *testBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	var pending types.Transactions
+	for _, tx := range b.pool {
+		if from, _ := types.Sender(types.LatestSigner(b.ChainConfig()), tx); from == addr {
+			pending = append(pending, tx)
+		}
+	}
+	return pending, nil
+}
+
+// Tests that the transactions are looked up by sender and nonce in the pool and
+// in the chain, the mined ones without needing the historical states.
+func TestGetTransactionBySenderAndNonce(t *testing.T) {
+	var (
+		signer = types.HomesteadSigner{}
+		to     = common.Address{0x01}
+		nonce  uint64
+	)
+	newTx := func(nonce uint64) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(1), params.TxGas, big.NewInt(params.InitialBaseFee), nil), signer, testKey)
+		return tx
+	}
+	// Send a transaction every third block, from the second one
+	backend := newTestBackend(t, 32, func(i int, gen *core.BlockGen) {
+		if i%3 == 1 {
+			gen.AddTx(newTx(nonce))
+			nonce++
+		}
+	})
+	backend.pool = types.Transactions{newTx(nonce)}
+	api := NewPublicTransactionPoolAPI(backend, nil)
+
+	for i := uint64(0); i < nonce; i++ {
+		tx, err := api.GetTransactionBySenderAndNonce(context.Background(), testAddr, hexutil.Uint64(i))
+		if err != nil {
+			t.Fatalf("nonce %d: failed to look up transaction: %v", i, err)
+		}
+		if tx == nil || uint64(tx.Nonce) != i || tx.BlockNumber == nil || tx.BlockNumber.ToInt().Uint64() != 3*i+2 {
+			t.Fatalf("nonce %d: transaction mismatch: have %+v, want block %d", i, tx, 3*i+2)
+		}
+	}
+	// The next nonce is pooled, the one after is unknown
+	if tx, err := api.GetTransactionBySenderAndNonce(context.Background(), testAddr, hexutil.Uint64(nonce)); err != nil || tx == nil || tx.BlockNumber != nil || tx.Hash != backend.pool[0].Hash() {
+		t.Fatalf("pooled transaction mismatch: have %+v, err %v", tx, err)
+	}
+	if tx, err := api.GetTransactionBySenderAndNonce(context.Background(), testAddr, hexutil.Uint64(nonce+1)); err != nil || tx != nil {
+		t.Fatalf("unknown transaction found: have %+v, err %v", tx, err)
+	}
+	// Without the historical states, the mined transactions are still resolved
+	backend.pruned = backend.chain.CurrentHeader().Number.Uint64()
+	for i := uint64(0); i < nonce; i++ {
+		tx, err := api.GetTransactionBySenderAndNonce(context.Background(), testAddr, hexutil.Uint64(i))
+		if err != nil || tx == nil || tx.BlockNumber == nil || tx.BlockNumber.ToInt().Uint64() != 3*i+2 {
+			t.Fatalf("nonce %d: mined transaction not resolved without the historical states: have %+v, err %v", i, tx, err)
+		}
+	}
+	if tx, err := api.GetTransactionBySenderAndNonce(context.Background(), testAddr, hexutil.Uint64(nonce)); err != nil || tx == nil {
+		t.Fatalf("pooled transaction not resolved without the historical states: have %+v, err %v", tx, err)
+	}
+}
+
+// Tests that the code is only served from the response cache at the blocks deep
+// enough below the head to be final.
+func TestGetCodeCache(t *testing.T) {
+	backend := newTestBackend(t, responseCacheDepth+8, func(i int, gen *core.BlockGen) {})
+	backend.cache = NewResponseCache(16, time.Hour)
+	api := NewPublicBlockChainAPI(backend)
+
+	tests := []struct {
+		number rpc.BlockNumber
+		reqs   int // States requested by two queries
+	}{
+		{1, 1},                     // Final, cached by the first query
+		{8, 1},                     // Final right at the depth
+		{9, 2},                     // Not final yet
+		{rpc.LatestBlockNumber, 2}, // Head, never cached
+	}
+	for _, tt := range tests {
+		backend.stateReqs = 0
+		for i := 0; i < 2; i++ {
+			code, err := api.GetCode(context.Background(), testAddr, rpc.BlockNumberOrHashWithNumber(tt.number))
+			if err != nil || len(code) != 0 {
+				t.Fatalf("block %d: code mismatch: have %x, err %v", tt.number, code, err)
+			}
+		}
+		if backend.stateReqs != tt.reqs {
+			t.Errorf("block %d: state requests mismatch: have %d, want %d", tt.number, backend.stateReqs, tt.reqs)
+		}
+	}
+}
@@ -46,13 +46,18 @@ type Backend interface {
 	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
 	FeeHistory(ctx context.Context, blockCount int, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error)
 	PricePrediction(ctx context.Context) ([]uint, error)
+	SubscribeGasPricesEvent(ch chan<- core.GasPricesEvent) event.Subscription
 	ChainDb() ethdb.Database
 	AccountManager() *accounts.Manager
 	ExtRPCEnabled() bool
-	RPCGasCap() uint64            // global gas cap for eth_call over rpc: DoS protection
-	RPCEVMTimeout() time.Duration // global timeout for eth_call over rpc: DoS protection
-	RPCTxFeeCap() float64         // global tx fee cap for all transaction related APIs
-	UnprotectedAllowed() bool     // allows only for EIP155 transactions.
+	RPCGasCap() uint64                    // global gas cap for eth_call over rpc: DoS protection
+	RPCEVMTimeout() time.Duration         // global timeout for eth_call over rpc: DoS protection
+	RPCEstimateGasCap() uint64            // gas cap for eth_estimateGas over rpc
+	RPCEstimateEVMTimeout() time.Duration // timeout for eth_estimateGas over rpc
+	HeavyCallLimiter() *HeavyCallLimiter  // concurrency limiter of heavy calls, nil for unlimited
+	ResponseCache() *ResponseCache        // cache of the immutable responses, nil if disabled
+	RPCTxFeeCap() float64                 // global tx fee cap for all transaction related APIs
+	UnprotectedAllowed() bool             // allows only for EIP155 transactions.
 
 	// Blockchain API
 	SetHead(number uint64)
@@ -75,6 +80,7 @@ type Backend interface {
 
 	// Transaction pool API
 	SendTx(ctx context.Context, signedTx *types.Transaction) error
+	SendPrivateTx(ctx context.Context, signedTx *types.Transaction) error
 	GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error)
 	GetPoolTransactions() (types.Transactions, error)
 	GetPoolTransaction(txHash common.Hash) *types.Transaction
@@ -84,6 +90,8 @@ type Backend interface {
 	TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions)
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
 	JamIndex() int
+	ResubmitManaged() []*core.ResubmitTxInfo
+	TxPoolNonceGaps(addr common.Address) *core.NonceGaps
 
 	// Filter API
 	BloomStatus() (uint64, uint64)
@@ -92,6 +100,7 @@ type Backend interface {
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
 	SubscribePendingLogsEvent(ch chan<- []*types.Log) event.Subscription
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
+	SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription
 
 	ChainConfig() *params.ChainConfig
 	Engine() consensus.Engine
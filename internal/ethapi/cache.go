@@ -0,0 +1,118 @@
+package ethapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// responseCacheDepth is the number of blocks below the head past which a block
+// is deemed final, i.e. the responses resolved through the canonical chain, like
+// the receipts looked up by transaction hash, can't be reorged away anymore.
+const responseCacheDepth = 64
+
+var (
+	responseCacheHitMeter  = metrics.NewRegisteredMeter("rpc/cache/hit", nil)
+	responseCacheMissMeter = metrics.NewRegisteredMeter("rpc/cache/miss", nil)
+)
+
+// Kinds of the cached responses.
+const (
+	cachedChainID byte = iota
+	cachedBlock
+	cachedReceipt
+	cachedCode
+)
+
+// responseKey identifies a cached response.
+type responseKey struct {
+	kind byte
+	hash common.Hash // Block or transaction hash the response is for
+	addr common.Address
+	full bool // Full transactions of a block
+	sys  bool // System transactions listed along a block
+}
+
+// cachedResponse is the json encoding of a response kept along with the time it
+// expires at. The encoding is never modified, every hit decoding its own copy.
+type cachedResponse struct {
+	blob    []byte
+	expires time.Time // Zero if the response never expires
+}
+
+// ResponseCache keeps the responses of the immutable rpc queries, e.g. the blocks
+// by hash or the receipts of final blocks, saving the database reads of the
+// repeated queries. A nil cache caches nothing.
+type ResponseCache struct {
+	ttl   time.Duration // Time a response is kept for, 0 to keep it until evicted
+	cache *lru.Cache
+}
+
+// NewResponseCache creates a cache of at most size responses, each one kept for
+// ttl at most. It returns nil if size is not positive.
+func NewResponseCache(size int, ttl time.Duration) *ResponseCache {
+	if size <= 0 {
+		return nil
+	}
+	cache, _ := lru.New(size)
+	return &ResponseCache{ttl: ttl, cache: cache}
+}
+
+// get decodes the cached response of the key into value, and reports whether it
+// was cached and not expired. The numbers decoded into interfaces are json.Number,
+// so they're encoded back as they were.
+func (c *ResponseCache) get(key responseKey, value interface{}) bool {
+	if c == nil {
+		return false
+	}
+	item, ok := c.cache.Get(key)
+	if !ok {
+		responseCacheMissMeter.Mark(1)
+		return false
+	}
+	resp := item.(*cachedResponse)
+	if !resp.expires.IsZero() && time.Now().After(resp.expires) {
+		c.cache.Remove(key)
+		responseCacheMissMeter.Mark(1)
+		return false
+	}
+	dec := json.NewDecoder(bytes.NewReader(resp.blob))
+	dec.UseNumber()
+	if err := dec.Decode(value); err != nil {
+		c.cache.Remove(key)
+		responseCacheMissMeter.Mark(1)
+		return false
+	}
+	responseCacheHitMeter.Mark(1)
+	return true
+}
+
+// add caches the json encoding of the response of the key, the response may be
+// modified afterwards.
+func (c *ResponseCache) add(key responseKey, value interface{}) {
+	if c == nil {
+		return
+	}
+	blob, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	resp := &cachedResponse{blob: blob}
+	if c.ttl > 0 {
+		resp.expires = time.Now().Add(c.ttl)
+	}
+	c.cache.Add(key, resp)
+}
+
+// final reports whether the block number is deep enough below the head for the
+// block to be deemed final.
+func (c *ResponseCache) final(b Backend, number uint64) bool {
+	if c == nil {
+		return false
+	}
+	return number+responseCacheDepth <= b.CurrentHeader().Number.Uint64()
+}
@@ -0,0 +1,77 @@
+package ethapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Tests that the cached responses are served as independent copies encoding the
+// same as the original ones.
+func TestResponseCacheHit(t *testing.T) {
+	cache := NewResponseCache(2, 0)
+	key := responseKey{kind: cachedReceipt, hash: common.Hash{0x01}}
+
+	fields := map[string]interface{}{
+		"blockNumber": hexutil.Uint64(100),
+		"logs":        []interface{}{map[string]interface{}{"data": hexutil.Bytes{0x01}}},
+		"status":      hexutil.Uint(1),
+		"index":       7,
+	}
+	want, _ := json.Marshal(fields)
+
+	if cache.get(key, new(map[string]interface{})) {
+		t.Fatalf("response served before cached")
+	}
+	cache.add(key, fields)
+	fields["status"] = hexutil.Uint(0)
+
+	for i := 0; i < 2; i++ {
+		have := make(map[string]interface{})
+		if !cache.get(key, &have) {
+			t.Fatalf("hit %d: cached response not served", i)
+		}
+		if blob, _ := json.Marshal(have); string(blob) != string(want) {
+			t.Fatalf("hit %d: response mismatch: have %s, want %s", i, blob, want)
+		}
+		// Modifying a served copy leaves the cached response intact
+		have["status"] = "0x0"
+		have["logs"].([]interface{})[0].(map[string]interface{})["data"] = "0x"
+	}
+	// The least recently used responses are evicted
+	cache.add(responseKey{kind: cachedReceipt, hash: common.Hash{0x02}}, fields)
+	cache.add(responseKey{kind: cachedReceipt, hash: common.Hash{0x03}}, fields)
+	if cache.get(key, new(map[string]interface{})) {
+		t.Errorf("evicted response served")
+	}
+	// A nil cache caches nothing
+	var disabled *ResponseCache
+	disabled.add(key, fields)
+	if disabled.get(key, new(map[string]interface{})) || disabled.final(nil, 0) {
+		t.Errorf("disabled cache served a response")
+	}
+}
+
+// Tests that the cached responses are dropped once expired.
+func TestResponseCacheExpiry(t *testing.T) {
+	key := responseKey{kind: cachedCode, hash: common.Hash{0x01}, addr: common.Address{0x01}}
+
+	cache := NewResponseCache(16, time.Hour)
+	cache.add(key, hexutil.Bytes{0x60, 0x00})
+	var code hexutil.Bytes
+	if !cache.get(key, &code) || len(code) != 2 {
+		t.Fatalf("live response mismatch: have %x", code)
+	}
+	cache = NewResponseCache(16, time.Nanosecond)
+	cache.add(key, hexutil.Bytes{0x60, 0x00})
+	time.Sleep(time.Millisecond)
+	if cache.get(key, new(hexutil.Bytes)) {
+		t.Fatalf("expired response served")
+	}
+	if cache.cache.Contains(key) {
+		t.Errorf("expired response not dropped")
+	}
+}
@@ -0,0 +1,68 @@
+package ethapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	heavyCallGauge   = metrics.NewRegisteredGauge("rpc/heavycall/running", nil)
+	heavyCallTimeout = metrics.NewRegisteredMeter("rpc/heavycall/rejected", nil)
+)
+
+// errHeavyCallBusy is returned if a heavy call can't get a slot before its context is done.
+var errHeavyCallBusy = errors.New("too many concurrent heavy calls, try again later")
+
+// HeavyCallLimiter restricts the number of concurrently running heavy rpc calls,
+// e.g. traces and gas estimations, so they can't starve the normal eth_call traffic,
+// and throttles them further while the block import lags. A nil limiter means
+// unlimited.
+type HeavyCallLimiter struct {
+	sem      chan struct{}  // Concurrent call slots, nil for unlimited
+	governor *core.Governor // Throttling while the block import lags, nil if none
+}
+
+// NewHeavyCallLimiter creates a limiter allowing at most n concurrent heavy calls,
+// throttled by the governor if any. It returns nil if n is not positive and there
+// is no governor.
+func NewHeavyCallLimiter(n int, governor *core.Governor) *HeavyCallLimiter {
+	if n <= 0 && governor == nil {
+		return nil
+	}
+	l := &HeavyCallLimiter{governor: governor}
+	if n > 0 {
+		l.sem = make(chan struct{}, n)
+	}
+	return l
+}
+
+// Acquire waits for a free slot until the context is done, the returned function
+// must be called to release the slot.
+func (l *HeavyCallLimiter) Acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	admitted, err := l.governor.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if l.sem == nil {
+		return admitted, nil
+	}
+	select {
+	case l.sem <- struct{}{}:
+		heavyCallGauge.Inc(1)
+		return func() {
+			<-l.sem
+			heavyCallGauge.Dec(1)
+			admitted()
+		}, nil
+	case <-ctx.Done():
+		admitted()
+		heavyCallTimeout.Mark(1)
+		return nil, errHeavyCallBusy
+	}
+}
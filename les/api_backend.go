@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/light"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -45,6 +46,7 @@ type LesApiBackend struct {
 	allowUnprotectedTxs bool
 	eth                 *LightEthereum
 	gpo                 *gasprice.Oracle
+	heavyCalls          *ethapi.HeavyCallLimiter
 }
 
 func (b *LesApiBackend) ChainConfig() *params.ChainConfig {
@@ -194,7 +196,11 @@ func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, state *sta
 			// make sure to use parent state to avoid mix up inner cache
 			parent := b.eth.blockchain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
 			parentState := light.NewState(ctx, parent, b.eth.odr)
-			context.ExtraValidator = posa.CreateEvmExtraValidator(header, parentState)
+			extraValidator, err := posa.CreateEvmExtraValidator(header, parentState)
+			if err != nil {
+				return nil, state.Error, err
+			}
+			context.ExtraValidator = extraValidator
 		}
 	}
 	return vm.NewEVM(context, txContext, state, b.eth.chainConfig, *vmConfig), state.Error, nil
@@ -204,6 +210,10 @@ func (b *LesApiBackend) SendTx(ctx context.Context, signedTx *types.Transaction)
 	return b.eth.txPool.Add(ctx, signedTx)
 }
 
+func (b *LesApiBackend) SendPrivateTx(ctx context.Context, signedTx *types.Transaction) error {
+	return errors.New("private transactions not supported in light mode")
+}
+
 func (b *LesApiBackend) RemoveTx(txHash common.Hash) {
 	b.eth.txPool.RemoveTx(txHash)
 }
@@ -240,6 +250,14 @@ func (b *LesApiBackend) JamIndex() int {
 	return 0 // not implement
 }
 
+func (b *LesApiBackend) ResubmitManaged() []*core.ResubmitTxInfo {
+	return nil // not implement
+}
+
+func (b *LesApiBackend) TxPoolNonceGaps(addr common.Address) *core.NonceGaps {
+	return nil // not implement
+}
+
 func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.eth.txPool.SubscribeNewTxsEvent(ch)
 }
@@ -271,6 +289,10 @@ func (b *LesApiBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEven
 	return b.eth.blockchain.SubscribeRemovedLogsEvent(ch)
 }
 
+func (b *LesApiBackend) SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription {
+	return b.eth.blockchain.SubscribeReorgEvent(ch)
+}
+
 func (b *LesApiBackend) SyncProgress() ethereum.SyncProgress {
 	return b.eth.Downloader().Progress()
 }
@@ -291,6 +313,12 @@ func (b *LesApiBackend) PricePrediction(ctx context.Context) ([]uint, error) {
 	return nil, errors.New("not implement")
 }
 
+// SubscribeGasPricesEvent returns an empty subscription, the light client has no
+// price prediction.
+func (b *LesApiBackend) SubscribeGasPricesEvent(ch chan<- core.GasPricesEvent) event.Subscription {
+	return new(event.Feed).Subscribe(ch)
+}
+
 func (b *LesApiBackend) ChainDb() ethdb.Database {
 	return b.eth.chainDb
 }
@@ -315,6 +343,38 @@ func (b *LesApiBackend) RPCEVMTimeout() time.Duration {
 	return b.eth.config.RPCEVMTimeout
 }
 
+func (b *LesApiBackend) RPCEstimateGasCap() uint64 {
+	if b.eth.config.RPCEstimateGasCap != 0 {
+		return b.eth.config.RPCEstimateGasCap
+	}
+	return b.eth.config.RPCGasCap
+}
+
+func (b *LesApiBackend) RPCEstimateEVMTimeout() time.Duration {
+	return b.eth.config.RPCEstimateEVMTimeout
+}
+
+func (b *LesApiBackend) RPCTraceGasCap() uint64 {
+	if b.eth.config.RPCTraceGasCap != 0 {
+		return b.eth.config.RPCTraceGasCap
+	}
+	return b.eth.config.RPCGasCap
+}
+
+func (b *LesApiBackend) RPCTraceEVMTimeout() time.Duration {
+	return b.eth.config.RPCTraceEVMTimeout
+}
+
+func (b *LesApiBackend) HeavyCallLimiter() *ethapi.HeavyCallLimiter {
+	return b.heavyCalls
+}
+
+// ResponseCache returns nil, the light client's responses being retrieved on
+// demand are not cached.
+func (b *LesApiBackend) ResponseCache() *ethapi.ResponseCache {
+	return nil
+}
+
 func (b *LesApiBackend) RPCTxFeeCap() float64 {
 	return b.eth.config.RPCTxFeeCap
 }
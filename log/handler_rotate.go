@@ -17,6 +17,7 @@
 package log
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path"
@@ -65,11 +66,37 @@ func NewFileRotateHandler(config *RotateConfig, format Format) Handler {
 		Compress:   true, // disabled by default
 	}
 
-	h := StreamHandler(&log, format)
+	return &rotateHandler{h: StreamHandler(&log, format), w: &log}
+}
+
+// rotateHandler is a handler writing to a rotated log file, which can be closed
+// once replaced.
+type rotateHandler struct {
+	h Handler
+	w *lumberjack.Logger
+}
 
-	return FuncHandler(func(r *Record) error {
-		return h.Log(r)
-	})
+func (h *rotateHandler) Log(r *Record) error {
+	return h.h.Log(r)
+}
+
+// Close closes the current log file.
+func (h *rotateHandler) Close() error {
+	return h.w.Close()
+}
+
+// Validate checks the rotation settings.
+func (c *RotateConfig) Validate() error {
+	if c.Filename == "" {
+		return errors.New("log file name missing")
+	}
+	if c.MaxSize <= 0 {
+		return fmt.Errorf("invalid log file max size %d", c.MaxSize)
+	}
+	if c.MaxAge < 0 || c.MaxBackups < 0 {
+		return fmt.Errorf("negative log file max age %d or backups %d", c.MaxAge, c.MaxBackups)
+	}
+	return nil
 }
 
 func (c *RotateConfig) setup() error {
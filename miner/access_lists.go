@@ -0,0 +1,135 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	accessListGeneratedMeter = metrics.NewRegisteredMeter("miner/accesslist/generated", nil)
+	accessListUsedMeter      = metrics.NewRegisteredMeter("miner/accesslist/used", nil)
+)
+
+// accessListGenerator generates the access lists of the pending transactions in
+// the background, executing them ahead of the block assembly on a throwaway state,
+// so their accounts and slots get prefetched in parallel right before they are
+// applied to the sealing block. A nil generator generates nothing.
+type accessListGenerator struct {
+	lists     sync.Map // Access lists by transaction hash
+	interrupt uint32   // Set to stop generating
+}
+
+// newAccessListGenerator starts generating the access lists of the pending
+// transactions on top of the state of the sealing block.
+func (w *worker) newAccessListGenerator(env *environment, pending map[common.Address]types.Transactions) *accessListGenerator {
+	var (
+		gen      = new(accessListGenerator)
+		statedb  = env.state.Copy()
+		header   = types.CopyHeader(env.header)
+		signer   = env.signer
+		coinbase = w.coinbase
+		cfg      = *w.chain.GetVMConfig()
+	)
+	// Copy the pending lists, the block assembly consumes them
+	txs := make(map[common.Address]types.Transactions, len(pending))
+	for addr, list := range pending {
+		txs[addr] = list
+	}
+	go gen.generate(w, statedb, header, signer, coinbase, cfg, txs)
+	return gen
+}
+
+// generate executes the transactions of every account in nonce order, recording
+// their access lists, until interrupted or out of gas.
+func (gen *accessListGenerator) generate(w *worker, statedb *state.StateDB, header *types.Header, signer types.Signer, coinbase common.Address, cfg vm.Config, txs map[common.Address]types.Transactions) {
+	var (
+		gasPool     = new(core.GasPool).AddGas(header.GasLimit)
+		gasUsed     uint64
+		precompiles = vm.ActivePrecompiles(w.chainConfig.Rules(header.Number))
+	)
+	for _, list := range txs {
+		for _, tx := range list {
+			if atomic.LoadUint32(&gen.interrupt) == 1 || gasPool.Gas() < tx.Gas() {
+				return
+			}
+			if len(tx.AccessList()) > 0 {
+				continue // Listed by the sender already
+			}
+			msg, err := tx.AsMessage(signer, header.BaseFee)
+			if err != nil {
+				break
+			}
+			to := crypto.CreateAddress(msg.From(), msg.Nonce())
+			if msg.To() != nil {
+				to = *msg.To()
+			}
+			tracer := vm.NewAccessListTracer(nil, msg.From(), to, precompiles)
+			cfg.Debug, cfg.Tracer = true, tracer
+
+			snap := statedb.Snapshot()
+			if _, err := core.ApplyTransaction(w.chainConfig, w.chain, &coinbase, gasPool, statedb, header, tx, &gasUsed, cfg, nil); err != nil {
+				statedb.RevertToSnapshot(snap)
+				break
+			}
+			gen.lists.Store(tx.Hash(), tracer.AccessList())
+			accessListGeneratedMeter.Mark(1)
+		}
+	}
+}
+
+// get returns the generated access list of the transaction, if any.
+func (gen *accessListGenerator) get(hash common.Hash) (types.AccessList, bool) {
+	if gen == nil {
+		return nil, false
+	}
+	list, ok := gen.lists.Load(hash)
+	if !ok {
+		return nil, false
+	}
+	return list.(types.AccessList), true
+}
+
+// stop stops generating the access lists.
+func (gen *accessListGenerator) stop() {
+	if gen != nil {
+		atomic.StoreUint32(&gen.interrupt, 1)
+	}
+}
+
+// prefetchAccessList prefetches into the state of the sealing block the accounts
+// and slots listed by the access list of the transaction, or by the one generated
+// for it if it has none.
+func (w *worker) prefetchAccessList(tx *types.Transaction) {
+	if list := tx.AccessList(); len(list) > 0 {
+		w.current.state.PrefetchAccessList(list)
+		return
+	}
+	if list, ok := w.current.accessLists.get(tx.Hash()); ok {
+		accessListUsedMeter.Mark(1)
+		w.current.state.PrefetchAccessList(list)
+	}
+}
@@ -52,6 +52,10 @@ type Config struct {
 	GasPrice   *big.Int       // Minimum gas price for mining a transaction
 	Recommit   time.Duration  // The time interval for miner to re-create mining work.
 	Noverify   bool           // Disable remote mining solution verification(only useful in ethash).
+
+	RecommitAdaptive bool `toml:",omitempty"` // Adapt the recommit interval to the block fullness and the new transactions
+
+	AccessListPrefetch bool `toml:",omitempty"` // Generate the access lists of the pending transactions to prefetch their state
 }
 
 // Miner creates blocks and searches for proof-of-work values.
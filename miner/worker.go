@@ -34,6 +34,8 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/node/hooks"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
 )
@@ -76,6 +78,24 @@ const (
 
 	// staleThreshold is the maximum depth of the acceptable stale block.
 	staleThreshold = 7
+
+	// maxFinalizeFailures is the maximum number of consecutive failures to finalize
+	// the sealing block on the same parent, before giving up the parent until a new
+	// chain head arrives.
+	maxFinalizeFailures = 3
+
+	// fullBlockAdjustRatio is the ratio the adaptive recommit interval is increased
+	// with when the pending block is already full.
+	fullBlockAdjustRatio = 0.5
+
+	// recommitJamThreshold is the jam index of the transaction pool above which the
+	// adaptive recommit interval is shortened on any new transaction.
+	recommitJamThreshold = 150
+)
+
+var (
+	recommitIntervalGauge = metrics.NewRegisteredGauge("miner/recommit/interval", nil)
+	recommitsHistogram    = metrics.NewRegisteredHistogram("miner/recommit/count", nil, metrics.NewExpDecaySample(1028, 0.015))
 )
 
 // environment is the worker's current environment and holds all of the current state information.
@@ -94,6 +114,10 @@ type environment struct {
 	receipts []*types.Receipt
 
 	extraValidator types.EvmExtraValidator
+	minTip         *big.Int             // Lowest effective tip of the included transactions
+	feeless        *core.FeelessCounter // Transactions paying no gas price included so far
+
+	accessLists *accessListGenerator // Access lists generated for the pending transactions, nil if disabled
 }
 
 // task contains all information for consensus engine sealing and result submitting.
@@ -119,8 +143,9 @@ type newWorkReq struct {
 
 // intervalAdjust represents a resubmitting interval adjustment.
 type intervalAdjust struct {
-	ratio float64
-	inc   bool
+	ratio  float64
+	inc    bool
+	urgent bool // Drop to the minimal interval at once, for the adaptive recommit
 }
 
 // worker is the main object which takes care of submitting new work to consensus engine
@@ -164,6 +189,9 @@ type worker struct {
 	remoteUncles map[common.Hash]*types.Block // A set of side blocks as the possible uncle blocks.
 	unconfirmed  *unconfirmedBlocks           // A set of locally mined blocks pending canonicalness confirmations.
 
+	finalizeParent   common.Hash // The parent of the sealing block failed to be finalized lastly.
+	finalizeFailures int         // Consecutive failures to finalize the sealing block on finalizeParent.
+
 	mu       sync.RWMutex // The lock used to protect the coinbase and extra fields
 	coinbase common.Address
 	extra    []byte
@@ -365,7 +393,9 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 		interrupt   *int32
 		minRecommit = recommit // minimal resubmit interval specified by user.
 		timestamp   int64      // timestamp for each round of mining.
+		recommits   int64      // number of resubmits on the current head.
 	)
+	recommitIntervalGauge.Update(int64(recommit / time.Millisecond))
 
 	timer := time.NewTimer(0)
 	defer timer.Stop()
@@ -384,6 +414,9 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 		}
 		timer.Reset(recommit)
 		atomic.StoreInt32(&w.newTxs, 0)
+		if s == commitInterruptResubmit {
+			recommits++
+		}
 	}
 	// clearPending cleans the stale pending tasks.
 	clearPending := func(number uint64) {
@@ -406,6 +439,8 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 		case head := <-w.chainHeadCh:
 			clearPending(head.Block.NumberU64())
 			timestamp = time.Now().Unix()
+			recommitsHistogram.Update(recommits)
+			recommits = 0
 			commit(false, commitInterruptNewHead)
 
 		case <-timer.C:
@@ -428,6 +463,7 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 			}
 			log.Info("Miner recommit interval update", "from", minRecommit, "to", interval)
 			minRecommit, recommit = interval, interval
+			recommitIntervalGauge.Update(int64(recommit / time.Millisecond))
 
 			if w.resubmitHook != nil {
 				w.resubmitHook(minRecommit, recommit)
@@ -435,7 +471,15 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 
 		case adjust := <-w.resubmitAdjustCh:
 			// Adjust resubmit interval by feedback.
-			if adjust.inc {
+			if adjust.urgent {
+				// Pull in the urgent transactions soon, restarting the timer only if the
+				// interval is actually shortened, so a stream of them can't starve it.
+				if recommit > minRecommit {
+					log.Trace("Drop miner recommit interval", "from", recommit, "to", minRecommit)
+					recommit = minRecommit
+					timer.Reset(recommit)
+				}
+			} else if adjust.inc {
 				before := recommit
 				target := float64(recommit.Nanoseconds()) / adjust.ratio
 				recommit = recalcRecommit(minRecommit, recommit, target, true)
@@ -445,6 +489,7 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 				recommit = recalcRecommit(minRecommit, recommit, float64(minRecommit.Nanoseconds()), false)
 				log.Trace("Decrease miner recommit interval", "from", before, "to", recommit)
 			}
+			recommitIntervalGauge.Update(int64(recommit / time.Millisecond))
 
 			if w.resubmitHook != nil {
 				w.resubmitHook(minRecommit, recommit)
@@ -541,6 +586,14 @@ func (w *worker) mainLoop() {
 					w.updateSnapshot()
 				}
 			} else {
+				// Shorten the adaptive recommit interval if the new transactions should
+				// make it into the block being mined as soon as possible.
+				if w.config.RecommitAdaptive && w.isRunning() && w.urgentTxs(ev.Txs) {
+					select {
+					case w.resubmitAdjustCh <- &intervalAdjust{urgent: true}:
+					default:
+					}
+				}
 				// Special case, if the consensus engine is 0 period clique(dev mode),
 				// submit mining work here since all empty submission will be rejected
 				// by clique. Of course the advance sealing(empty submission) is disabled.
@@ -674,6 +727,7 @@ func (w *worker) resultLoop() {
 			}
 			log.Info("Successfully sealed new block", "number", block.Number(), "sealhash", sealhash, "hash", hash,
 				"elapsed", common.PrettyDuration(time.Since(task.createdAt)))
+			hooks.BlockSealed(block)
 
 			// Broadcast the block and announce chain insertion event
 			w.mux.Post(core.NewMinedBlockEvent{Block: block})
@@ -704,6 +758,7 @@ func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 		family:    mapset.NewSet(),
 		uncles:    mapset.NewSet(),
 		header:    header,
+		feeless:   core.NewFeelessCounter(w.chainConfig, header),
 	}
 	// when 08 is processed ancestors contain 07 (quick block)
 	for _, ancestor := range w.chain.GetBlocksFromHash(parent.Hash(), 7) {
@@ -720,6 +775,7 @@ func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 	// processes in the mean time and starting a new one.
 	if w.current != nil && w.current.state != nil {
 		w.current.state.StopPrefetcher()
+		w.current.accessLists.stop()
 	}
 	w.current = env
 	return nil
@@ -779,6 +835,7 @@ func (w *worker) updateSnapshot() {
 }
 
 func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Address) ([]*types.Log, error) {
+	w.prefetchAccessList(tx)
 	snap := w.current.state.Snapshot()
 
 	receipt, err := core.ApplyTransaction(w.chainConfig, w.chain, &coinbase, w.current.gasPool, w.current.state, w.current.header, tx, &w.current.header.GasUsed, *w.chain.GetVMConfig(), w.current.extraValidator)
@@ -788,10 +845,22 @@ func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Addres
 	}
 	w.current.txs = append(w.current.txs, tx)
 	w.current.receipts = append(w.current.receipts, receipt)
-
+	if tip := tx.EffectiveGasTipValue(w.current.header.BaseFee); w.current.minTip == nil || tip.Cmp(w.current.minTip) < 0 {
+		w.current.minTip = tip
+	}
 	return receipt.Logs, nil
 }
 
+// traceTx records the handling of a transaction by the sealing block in its
+// history, if submitted via RPC.
+func (w *worker) traceTx(tx *types.Transaction, event string, reason string) {
+	detail := "block " + w.current.header.Number.String()
+	if reason != "" {
+		detail += ": " + reason
+	}
+	w.eth.TxPool().RecordTxTrace(tx.Hash(), event, detail)
+}
+
 func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coinbase common.Address, interrupt *int32) bool {
 	// Short circuit if current is nil
 	if w.current == nil {
@@ -854,10 +923,18 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			err := w.posa.ValidateTx(from, tx, w.current.header, w.current.state)
 			if err != nil {
 				log.Trace("Ignoring consensus invalid transaction", "hash", tx.Hash().String(), "from", from.String(), "to", tx.To(), "err", err)
+				w.traceTx(tx, "skipped", err.Error())
 				txs.Pop()
 				continue
 			}
 		}
+		// Skip the account, its later transactions depend on this one
+		if err := w.current.feeless.Check(from, tx); err != nil {
+			log.Trace("Ignoring feeless transaction", "hash", tx.Hash(), "from", from, "err", err)
+			w.traceTx(tx, "skipped", err.Error())
+			txs.Pop()
+			continue
+		}
 		// Start executing the transaction
 		w.current.state.Prepare(tx.Hash(), w.current.tcount)
 
@@ -866,6 +943,7 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		case errors.Is(err, core.ErrGasLimitReached):
 			// Pop the current out-of-gas transaction without shifting in the next from the account
 			log.Trace("Gas limit exceeded for current block", "sender", from)
+			w.traceTx(tx, "skipped", err.Error())
 			txs.Pop()
 
 		case errors.Is(err, core.ErrNonceTooLow):
@@ -882,6 +960,8 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			// Everything ok, collect the logs and shift in the next transaction from the same account
 			coalescedLogs = append(coalescedLogs, logs...)
 			w.current.tcount++
+			w.current.feeless.Add(tx)
+			w.traceTx(tx, "selected", "")
 			txs.Shift()
 
 		case errors.Is(err, core.ErrTxTypeNotSupported):
@@ -893,6 +973,7 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 			// Strange error, discard the transaction and get the next in line (note, the
 			// nonce-too-high clause will prevent us from executing in vain).
 			log.Debug("Transaction failed, account skipped", "hash", tx.Hash(), "err", err)
+			w.traceTx(tx, "skipped", err.Error())
 			txs.Shift()
 		}
 	}
@@ -913,9 +994,35 @@ func (w *worker) commitTransactions(txs *types.TransactionsByPriceAndNonce, coin
 		w.pendingLogsFeed.Send(cpy)
 	}
 	// Notify resubmit loop to decrease resubmitting interval if current interval is larger
-	// than the user-specified one.
+	// than the user-specified one. The adaptive one is increased instead if the block is
+	// already full, since resubmitting it can only replace the included transactions.
 	if interrupt != nil {
-		w.resubmitAdjustCh <- &intervalAdjust{inc: false}
+		if w.config.RecommitAdaptive && w.current.gasPool.Gas() < params.TxGas {
+			w.resubmitAdjustCh <- &intervalAdjust{ratio: fullBlockAdjustRatio, inc: true}
+		} else {
+			w.resubmitAdjustCh <- &intervalAdjust{inc: false}
+		}
+	}
+	return false
+}
+
+// urgentTxs reports whether any of the new transactions should be pulled into the
+// block being mined soon, as the pool is jammed or it outbids the cheapest one of
+// the transactions already included.
+func (w *worker) urgentTxs(txs []*types.Transaction) bool {
+	if w.current == nil {
+		return false
+	}
+	if w.eth.TxPool().JamIndex() >= recommitJamThreshold {
+		return true
+	}
+	if w.current.minTip == nil {
+		return false
+	}
+	for _, tx := range txs {
+		if tx.EffectiveGasTipValue(w.current.header.BaseFee).Cmp(w.current.minTip) > 0 {
+			return true
+		}
 	}
 	return false
 }
@@ -928,6 +1035,13 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	tstart := time.Now()
 	parent := w.chain.CurrentBlock()
 
+	// Stop retrying the parent the engine keeps failing to finalize a block on, the
+	// work is resumed once a new chain head arrives.
+	if w.finalizeParent == parent.Hash() && w.finalizeFailures >= maxFinalizeFailures {
+		log.Debug("Skipping sealing work on failed parent", "number", parent.Number(), "hash", parent.Hash(), "failures", w.finalizeFailures)
+		return
+	}
+
 	if parent.Time() >= uint64(timestamp) {
 		timestamp = int64(parent.Time() + 1)
 	}
@@ -983,7 +1097,10 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 			log.Error("Failed to apply system contract upgrade", "err", err)
 			return
 		}
-		env.extraValidator = w.posa.CreateEvmExtraValidator(header, env.state)
+		if env.extraValidator, err = w.posa.CreateEvmExtraValidator(header, env.state); err != nil {
+			log.Error("Failed to create the extra validator", "err", err)
+			return
+		}
 	}
 	// Accumulate the uncles for the current block
 	uncles := make([]*types.Header, 0, 2)
@@ -1013,11 +1130,13 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 	// Create an empty block based on temporary copied state for
 	// sealing in advance without waiting block execution finished.
 	if !noempty && atomic.LoadUint32(&w.noempty) == 0 {
-		w.commit(uncles, nil, false, tstart)
+		if err := w.commit(uncles, nil, false, tstart); err != nil {
+			return
+		}
 	}
 
 	// Fill the block with all available pending transactions.
-	pending := w.eth.TxPool().Pending(true)
+	pending := w.eth.TxPool().PendingAt(true, header.BaseFee)
 	// Short circuit if there is no available pending transactions.
 	// But if we disable empty precommit already, ignore it. Since
 	// empty block is necessary to keep the liveness of the network.
@@ -1025,6 +1144,11 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		w.updateSnapshot()
 		return
 	}
+	// Generate the access lists of the pending transactions ahead of their execution
+	if w.config.AccessListPrefetch {
+		env.accessLists = w.newAccessListGenerator(env, pending)
+		defer env.accessLists.stop()
+	}
 	// Split the pending transactions into locals and remotes
 	localTxs, remoteTxs := make(map[common.Address]types.Transactions), pending
 	for _, account := range w.eth.TxPool().Locals() {
@@ -1059,8 +1183,10 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 	s := w.current.state.Copy()
 	block, receipts, err := w.engine.FinalizeAndAssemble(w.chain, w.current.header, s, txs, uncles, cpyReceipts)
 	if err != nil {
+		w.finalizeFailed(w.current.header.ParentHash, err)
 		return err
 	}
+	w.finalizeFailures = 0
 	if w.isRunning() {
 		if interval != nil {
 			interval()
@@ -1083,6 +1209,22 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 	return nil
 }
 
+// finalizeFailed records a failure to finalize the sealing block on the given parent.
+// The work is retried on the next recommit, and aborted for the parent after
+// maxFinalizeFailures consecutive failures.
+func (w *worker) finalizeFailed(parent common.Hash, err error) {
+	if w.finalizeParent != parent {
+		w.finalizeParent, w.finalizeFailures = parent, 0
+	}
+	w.finalizeFailures++
+
+	if w.finalizeFailures >= maxFinalizeFailures {
+		log.Error("Aborted sealing work on parent", "parent", parent, "failures", w.finalizeFailures, "err", err)
+	} else {
+		log.Warn("Failed to finalize sealing block, retrying", "parent", parent, "failures", w.finalizeFailures, "err", err)
+	}
+}
+
 // copyReceipts makes a deep copy of the given receipts.
 func copyReceipts(receipts []*types.Receipt) []*types.Receipt {
 	result := make([]*types.Receipt, len(receipts))
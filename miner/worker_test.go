@@ -17,6 +17,7 @@
 package miner
 
 import (
+	"errors"
 	"math/big"
 	"math/rand"
 	"sync/atomic"
@@ -521,3 +522,49 @@ func testAdjustInterval(t *testing.T, chainConfig *params.ChainConfig, engine co
 		t.Error("interval reset timeout")
 	}
 }
+
+func TestFinalizeFailures(t *testing.T) {
+	var (
+		w       = new(worker)
+		err     = errors.New("finalize failed")
+		parentA = common.HexToHash("0x0a")
+		parentB = common.HexToHash("0x0b")
+	)
+	for i := 1; i <= maxFinalizeFailures; i++ {
+		w.finalizeFailed(parentA, err)
+		if w.finalizeParent != parentA || w.finalizeFailures != i {
+			t.Fatalf("failure %d: parent %x failures %d", i, w.finalizeParent, w.finalizeFailures)
+		}
+	}
+	// The failures on a new parent start over
+	w.finalizeFailed(parentB, err)
+	if w.finalizeParent != parentB || w.finalizeFailures != 1 {
+		t.Fatalf("new parent: parent %x failures %d", w.finalizeParent, w.finalizeFailures)
+	}
+}
+
+func TestUrgentTxs(t *testing.T) {
+	engine := ethash.NewFaker()
+	defer engine.Close()
+
+	backend := newTestWorkerBackend(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	w := &worker{eth: backend}
+
+	tx := func(price int64) *types.Transaction {
+		return types.NewTransaction(0, testUserAddress, big.NewInt(0), params.TxGas, big.NewInt(price), nil)
+	}
+	if w.urgentTxs([]*types.Transaction{tx(100)}) {
+		t.Fatal("urgent transactions without pending block")
+	}
+	w.current = &environment{header: &types.Header{Number: big.NewInt(1)}}
+	if w.urgentTxs([]*types.Transaction{tx(100)}) {
+		t.Fatal("urgent transactions with empty pending block")
+	}
+	w.current.minTip = big.NewInt(10)
+	if w.urgentTxs([]*types.Transaction{tx(5), tx(10)}) {
+		t.Fatal("urgent transactions not outbidding the pending block")
+	}
+	if !w.urgentTxs([]*types.Transaction{tx(5), tx(11)}) {
+		t.Fatal("outbidding transaction not urgent")
+	}
+}
@@ -185,6 +185,8 @@ func (api *privateAdminAPI) StartHTTP(host *string, port *int, cors *string, api
 		CorsAllowedOrigins: api.node.config.HTTPCors,
 		Vhosts:             api.node.config.HTTPVirtualHosts,
 		Modules:            api.node.config.HTTPModules,
+		Methods:            api.node.config.HTTPMethods,
+		APIKeys:            api.node.config.APIKeys,
 	}
 	if cors != nil {
 		config.CorsAllowedOrigins = nil
@@ -258,6 +260,8 @@ func (api *privateAdminAPI) StartWS(host *string, port *int, allowedOrigins *str
 	config := wsConfig{
 		Modules: api.node.config.WSModules,
 		Origins: api.node.config.WSOrigins,
+		Methods: api.node.config.WSMethods,
+		APIKeys: api.node.config.APIKeys,
 		// ExposeAll: api.node.config.WSExposeAll,
 	}
 	if apis != nil {
@@ -65,6 +65,10 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DBEngine is the key-value store engine of the databases (leveldb or pebble),
+	// the one of the existing databases if empty, leveldb for new ones.
+	DBEngine string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -103,6 +107,9 @@ type Config struct {
 	// relative), then that specific path is enforced. An empty path disables IPC.
 	IPCPath string
 
+	// IPCMethods restricts the methods served over IPC on top of the namespaces.
+	IPCMethods *rpc.MethodFilter `toml:",omitempty"`
+
 	// HTTPHost is the host interface on which to start the HTTP RPC server. If this
 	// field is empty, no HTTP API endpoint will be started.
 	HTTPHost string
@@ -131,6 +138,10 @@ type Config struct {
 	// exposed.
 	HTTPModules []string
 
+	// HTTPMethods restricts the methods served over HTTP on top of the namespaces
+	// of HTTPModules, to the requests without an API key.
+	HTTPMethods *rpc.MethodFilter `toml:",omitempty"`
+
 	// HTTPTimeouts allows for customization of the timeout values used by the HTTP RPC
 	// interface.
 	HTTPTimeouts rpc.HTTPTimeouts
@@ -160,6 +171,16 @@ type Config struct {
 	// exposed.
 	WSModules []string
 
+	// WSMethods restricts the methods served over websocket on top of the namespaces
+	// of WSModules, to the connections without an API key.
+	WSMethods *rpc.MethodFilter `toml:",omitempty"`
+
+	// APIKeys are the API keys accepted over HTTP and websocket in the X-Api-Key
+	// header, mapped to the methods served to each of them instead of the ones of
+	// the transport. A key without a filter is served every method registered on
+	// the transport. If any key is set, the requests with unknown keys are rejected.
+	APIKeys map[string]*rpc.MethodFilter `toml:",omitempty"`
+
 	// WSExposeAll exposes all API modules via the WebSocket RPC interface rather
 	// than just the public ones.
 	//
@@ -0,0 +1,171 @@
+// Package hooks provides a stable in-process extension surface, so that the
+// infrastructure built on top of the node can observe or filter the transaction
+// admission and the block events without patching the core packages.
+//
+// Plugins are registered at startup, before the node is started, and a plugin
+// only needs to implement the hook interfaces it's interested in.
+//
+// The hooks are run synchronously by the goroutine raising the event, some of them
+// holding the locks of the core packages as documented on each hook. A hook must
+// return quickly and must not call back into the txpool or the blockchain, which
+// would deadlock, it should hand the event over to its own goroutine instead if
+// it has any heavy or blocking work to do.
+package hooks
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// Plugin is the base interface of all the plugins.
+type Plugin interface {
+	// Name returns the unique name of the plugin.
+	Name() string
+}
+
+// TxAdmissionHook is called before a transaction is admitted into the txpool,
+// the transaction is rejected if an error is returned. It's on the hot path, so
+// it must be fast. It's called holding the txpool lock.
+type TxAdmissionHook interface {
+	OnTxAdmission(tx *types.Transaction, local bool) error
+}
+
+// BlockImportedHook is called after a block is written as the new canonical head,
+// either imported from the network or sealed locally. It's called holding the
+// blockchain lock, before the chain events are sent.
+type BlockImportedHook interface {
+	OnBlockImported(block *types.Block, receipts types.Receipts)
+}
+
+// BlockSealedHook is called after a block is sealed by the local validator and
+// written into the chain. It's called by the miner, before the block is broadcast.
+type BlockSealedHook interface {
+	OnBlockSealed(block *types.Block)
+}
+
+// ReorgHook is called after a chain reorganisation, oldChain is the dropped blocks
+// and newChain is the added blocks, both in descending order of number. It's called
+// holding the blockchain lock.
+type ReorgHook interface {
+	OnReorg(oldHead, newHead *types.Header, oldChain, newChain []*types.Block)
+}
+
+var (
+	lock    sync.RWMutex
+	plugins = make(map[string]Plugin)
+
+	txAdmissionHooks   []TxAdmissionHook
+	blockImportedHooks []BlockImportedHook
+	blockSealedHooks   []BlockSealedHook
+	reorgHooks         []ReorgHook
+)
+
+// Register registers a plugin, it returns an error if a plugin with the same name
+// was already registered.
+func Register(p Plugin) error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, ok := plugins[p.Name()]; ok {
+		return fmt.Errorf("plugin %s already registered", p.Name())
+	}
+	plugins[p.Name()] = p
+
+	var hooks []string
+	if h, ok := p.(TxAdmissionHook); ok {
+		txAdmissionHooks = append(txAdmissionHooks, h)
+		hooks = append(hooks, "OnTxAdmission")
+	}
+	if h, ok := p.(BlockImportedHook); ok {
+		blockImportedHooks = append(blockImportedHooks, h)
+		hooks = append(hooks, "OnBlockImported")
+	}
+	if h, ok := p.(BlockSealedHook); ok {
+		blockSealedHooks = append(blockSealedHooks, h)
+		hooks = append(hooks, "OnBlockSealed")
+	}
+	if h, ok := p.(ReorgHook); ok {
+		reorgHooks = append(reorgHooks, h)
+		hooks = append(hooks, "OnReorg")
+	}
+	log.Info("Registered plugin", "name", p.Name(), "hooks", hooks)
+	return nil
+}
+
+// Plugins returns the names of all the registered plugins.
+func Plugins() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	return names
+}
+
+// recoverHook keeps a misbehaving plugin from crashing the node.
+func recoverHook(hook string) {
+	if r := recover(); r != nil {
+		log.Error("Plugin hook panicked", "hook", hook, "err", r)
+	}
+}
+
+// TxAdmission runs the OnTxAdmission hooks, and returns the first error.
+func TxAdmission(tx *types.Transaction, local bool) (err error) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for _, h := range txAdmissionHooks {
+		func() {
+			defer recoverHook("OnTxAdmission")
+			err = h.OnTxAdmission(tx, local)
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BlockImported runs the OnBlockImported hooks.
+func BlockImported(block *types.Block, receipts types.Receipts) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for _, h := range blockImportedHooks {
+		func() {
+			defer recoverHook("OnBlockImported")
+			h.OnBlockImported(block, receipts)
+		}()
+	}
+}
+
+// BlockSealed runs the OnBlockSealed hooks.
+func BlockSealed(block *types.Block) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for _, h := range blockSealedHooks {
+		func() {
+			defer recoverHook("OnBlockSealed")
+			h.OnBlockSealed(block)
+		}()
+	}
+}
+
+// Reorg runs the OnReorg hooks.
+func Reorg(oldHead, newHead *types.Header, oldChain, newChain []*types.Block) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for _, h := range reorgHooks {
+		func() {
+			defer recoverHook("OnReorg")
+			h.OnReorg(oldHead, newHead, oldChain, newChain)
+		}()
+	}
+}
@@ -0,0 +1,80 @@
+package hooks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type testPlugin struct {
+	name   string
+	reject error
+	sealed int
+}
+
+func (p *testPlugin) Name() string { return p.name }
+
+func (p *testPlugin) OnTxAdmission(tx *types.Transaction, local bool) error { return p.reject }
+
+func (p *testPlugin) OnBlockSealed(block *types.Block) { p.sealed++ }
+
+// resetRegistry drops the plugins registered by the test once it's done, the
+// registry being global.
+func resetRegistry(t *testing.T) {
+	t.Cleanup(func() {
+		lock.Lock()
+		defer lock.Unlock()
+
+		plugins = make(map[string]Plugin)
+		txAdmissionHooks, blockImportedHooks, blockSealedHooks, reorgHooks = nil, nil, nil, nil
+	})
+}
+
+type panicPlugin struct{}
+
+func (panicPlugin) Name() string                     { return "panic" }
+func (panicPlugin) OnBlockSealed(block *types.Block) { panic("boom") }
+
+// Tests that the hooks of the registered plugins are dispatched, and that a
+// panicking plugin doesn't break the others.
+func TestHooksDispatch(t *testing.T) {
+	resetRegistry(t)
+
+	errReject := errors.New("rejected")
+	p := &testPlugin{name: "test", reject: errReject}
+
+	if err := Register(panicPlugin{}); err != nil {
+		t.Fatalf("failed to register plugin: %v", err)
+	}
+	if err := Register(p); err != nil {
+		t.Fatalf("failed to register plugin: %v", err)
+	}
+	if err := Register(&testPlugin{name: "test"}); err == nil {
+		t.Fatalf("duplicate plugin registered")
+	}
+	tx := types.NewTransaction(0, [20]byte{}, nil, 0, nil, nil)
+	if err := TxAdmission(tx, false); err != errReject {
+		t.Fatalf("admission error mismatch: have %v, want %v", err, errReject)
+	}
+	BlockSealed(types.NewBlockWithHeader(&types.Header{}))
+	if p.sealed != 1 {
+		t.Fatalf("sealed hook calls mismatch: have %d, want 1", p.sealed)
+	}
+	if len(Plugins()) != 2 {
+		t.Fatalf("plugins count mismatch: have %d, want 2", len(Plugins()))
+	}
+}
+
+// Tests that the registry is left empty by the other tests, so the hooks of their
+// plugins don't leak into the rest of the package.
+func TestHooksRegistryReset(t *testing.T) {
+	t.Run("register", TestHooksDispatch)
+
+	if names := Plugins(); len(names) != 0 {
+		t.Fatalf("plugins left registered: %v", names)
+	}
+	if err := TxAdmission(types.NewTransaction(0, [20]byte{}, nil, 0, nil, nil), false); err != nil {
+		t.Fatalf("admission hook left registered: %v", err)
+	}
+}
@@ -37,6 +37,8 @@ import (
 // httpConfig is the JSON-RPC/HTTP configuration.
 type httpConfig struct {
 	Modules            []string
+	Methods            *rpc.MethodFilter
+	APIKeys            map[string]*rpc.MethodFilter
 	CorsAllowedOrigins []string
 	Vhosts             []string
 	prefix             string // path prefix on which to mount http handler
@@ -46,6 +48,8 @@ type httpConfig struct {
 type wsConfig struct {
 	Origins []string
 	Modules []string
+	Methods *rpc.MethodFilter
+	APIKeys map[string]*rpc.MethodFilter
 	prefix  string // path prefix on which to mount ws handler
 }
 
@@ -283,6 +287,7 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 	if err := RegisterApis(apis, config.Modules, srv, false); err != nil {
 		return err
 	}
+	srv.SetMethodFilter(config.Methods, config.APIKeys)
 	h.httpConfig = config
 	h.httpHandler.Store(&rpcHandler{
 		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts),
@@ -315,6 +320,7 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 	if err := RegisterApis(apis, config.Modules, srv, false); err != nil {
 		return err
 	}
+	srv.SetMethodFilter(config.Methods, config.APIKeys)
 	h.wsConfig = config
 	h.wsHandler.Store(&rpcHandler{
 		Handler: srv.WebsocketHandler(config.Origins),
@@ -473,14 +479,15 @@ func newGzipHandler(next http.Handler) http.Handler {
 type ipcServer struct {
 	log      log.Logger
 	endpoint string
+	methods  *rpc.MethodFilter
 
 	mu       sync.Mutex
 	listener net.Listener
 	srv      *rpc.Server
 }
 
-func newIPCServer(log log.Logger, endpoint string) *ipcServer {
-	return &ipcServer{log: log, endpoint: endpoint}
+func newIPCServer(log log.Logger, endpoint string, methods *rpc.MethodFilter) *ipcServer {
+	return &ipcServer{log: log, endpoint: endpoint, methods: methods}
 }
 
 // Start starts the httpServer's http.Server
@@ -491,7 +498,7 @@ func (is *ipcServer) start(apis []rpc.API) error {
 	if is.listener != nil {
 		return nil // already running
 	}
-	listener, srv, err := rpc.StartIPCEndpoint(is.endpoint, apis)
+	listener, srv, err := rpc.StartFilteredIPCEndpoint(is.endpoint, apis, is.methods)
 	if err != nil {
 		is.log.Warn("IPC opening failed", "url", is.endpoint, "error", err)
 		return err
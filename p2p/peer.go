@@ -23,6 +23,7 @@ import (
 	"net"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/mclock"
@@ -114,6 +115,11 @@ type Peer struct {
 	closed   chan struct{}
 	disc     chan DiscReason
 
+	// pingSent is the unix nano time the pending ping was sent at, 0 if none,
+	// rtt the moving average of the ping round trip times in nanoseconds.
+	pingSent int64
+	rtt      int64
+
 	// events receives message send / receive events if set
 	events   *event.Feed
 	testPipe *MsgPipeRW // for testing
@@ -138,6 +144,13 @@ func NewPeerPipe(id enode.ID, name string, caps []Cap, pipe *MsgPipeRW) *Peer {
 	return p
 }
 
+// NewTrustedPeerPipe creates a trusted peer for testing purposes, see NewPeerPipe.
+func NewTrustedPeerPipe(id enode.ID, name string, caps []Cap, pipe *MsgPipeRW) *Peer {
+	p := NewPeerPipe(id, name, caps, pipe)
+	p.rw.set(trustedConn, true)
+	return p
+}
+
 // ID returns the node's public key.
 func (p *Peer) ID() enode.ID {
 	return p.rw.node.ID()
@@ -182,6 +195,29 @@ func (p *Peer) RunningCap(protocol string, versions []uint) bool {
 	return false
 }
 
+// RTT returns the moving average of the round trip times of the devp2p pings, 0
+// until the first pong arrives.
+func (p *Peer) RTT() time.Duration {
+	return time.Duration(atomic.LoadInt64(&p.rtt))
+}
+
+// updateRTT folds the round trip time of the pending ping into the average once
+// its pong arrives.
+func (p *Peer) updateRTT(received time.Time) {
+	sent := atomic.SwapInt64(&p.pingSent, 0)
+	if sent == 0 {
+		return // unsolicited pong
+	}
+	sample := received.UnixNano() - sent
+	if sample < 0 {
+		return
+	}
+	if old := atomic.LoadInt64(&p.rtt); old != 0 {
+		sample = (old*3 + sample) / 4
+	}
+	atomic.StoreInt64(&p.rtt, sample)
+}
+
 // RemoteAddr returns the remote address of the network connection.
 func (p *Peer) RemoteAddr() net.Addr {
 	return p.rw.fd.RemoteAddr()
@@ -291,6 +327,7 @@ func (p *Peer) pingLoop() {
 	for {
 		select {
 		case <-ping.C:
+			atomic.StoreInt64(&p.pingSent, time.Now().UnixNano())
 			if err := SendItems(p.rw, pingMsg); err != nil {
 				p.protoErr <- err
 				return
@@ -323,6 +360,9 @@ func (p *Peer) handle(msg Msg) error {
 	case msg.Code == pingMsg:
 		msg.Discard()
 		go SendItems(p.rw, pongMsg)
+	case msg.Code == pongMsg:
+		p.updateRTT(msg.ReceivedAt)
+		return msg.Discard()
 	case msg.Code == discMsg:
 		// This is the last message. We don't need to discard or
 		// check errors because, the connection will be closed after it.
@@ -179,6 +179,29 @@ func TestPeerPing(t *testing.T) {
 	}
 }
 
+func TestPeerRTT(t *testing.T) {
+	var (
+		p    = new(Peer)
+		sent = time.Now()
+	)
+	// Unsolicited pongs are ignored
+	p.updateRTT(sent)
+	if rtt := p.RTT(); rtt != 0 {
+		t.Fatalf("rtt from unsolicited pong: %v", rtt)
+	}
+	p.pingSent = sent.UnixNano()
+	p.updateRTT(sent.Add(100 * time.Millisecond))
+	if rtt := p.RTT(); rtt != 100*time.Millisecond {
+		t.Fatalf("first rtt mismatch: have %v, want %v", rtt, 100*time.Millisecond)
+	}
+	// Later samples are averaged in
+	p.pingSent = sent.UnixNano()
+	p.updateRTT(sent.Add(500 * time.Millisecond))
+	if rtt := p.RTT(); rtt != 200*time.Millisecond {
+		t.Fatalf("averaged rtt mismatch: have %v, want %v", rtt, 200*time.Millisecond)
+	}
+}
+
 // This test checks that a disconnect message sent by a peer is returned
 // as the error from Peer.run.
 func TestPeerDisconnect(t *testing.T) {
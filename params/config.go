@@ -18,8 +18,13 @@ package params
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"math"
 	"math/big"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"golang.org/x/crypto/sha3"
@@ -116,18 +121,18 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	AllCongressProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, big.NewInt(2), big.NewInt(3), nil, nil, &CongressConfig{Period: 0, Epoch: 30000}}
+	AllCongressProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, big.NewInt(2), big.NewInt(3), nil, nil, nil, nil, nil, &CongressConfig{Period: 0, Epoch: 30000}}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, new(EthashConfig), nil, nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, new(EthashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -215,6 +220,19 @@ type ChainConfig struct {
 	RedCoastBlock *big.Int `json:"redCoastBlock,omitempty"` // RedCoast switch block (nil = no fork, set value ≥ 2 to activate it)
 	SophonBlock   *big.Int `json:"sophonBlock,omitempty"`   // Sophon switch block (nil = no fork, set > RedCoastBlock to activate it)
 
+	// ProxyCheckBlock activates the deny-list checks of delegatecall/callcode targets
+	// and CREATE2 deployers (nil = no fork, set > SophonBlock to activate it)
+	ProxyCheckBlock *big.Int `json:"proxyCheckBlock,omitempty"`
+
+	// ReplayProtectionBlock rejects the unprotected (non-EIP155) legacy transactions
+	// in the txpool and in blocks (nil = no fork, set > SophonBlock to activate it)
+	ReplayProtectionBlock *big.Int `json:"replayProtectionBlock,omitempty"`
+
+	// TaurusBlock enables the EVM changes of EIP-3855 (PUSH0), EIP-3860 (initcode
+	// size limit and metering) and EIP-1153 (transient storage) (nil = no fork,
+	// set > SophonBlock to activate it)
+	TaurusBlock *big.Int `json:"taurusBlock,omitempty"`
+
 	// Various consensus engines
 	Ethash   *EthashConfig   `json:"ethash,omitempty"`
 	Clique   *CliqueConfig   `json:"clique,omitempty"`
@@ -240,12 +258,133 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// DefaultCongressMaxValidators is the max number of validators allowed to seal if
+// it's not configured in the chain config.
+const DefaultCongressMaxValidators = 21
+
+// MaxCongressBlacklistDelay is the max activation delay of the blacklist updates,
+// bounded by the recent states kept by the nodes to resolve the blacklist in force.
+const MaxCongressBlacklistDelay = 100
+
+// CongressFeeBurnDenominator is the denominator of the congress fee burn ratio,
+// i.e. the ratio is in per mille of the transaction fees.
+const CongressFeeBurnDenominator = 1000
+
 // CongressConfig is the consensus engine configs for proof-of-stake-authority based sealing.
+//
+// The parameters gated by a fork block are in force from genesis if the block is
+// nil, except for the punish breaker and the fee burn forks, which are disabled if
+// their block is nil, no transaction fees being burnt without the latter.
 type CongressConfig struct {
 	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
 	Epoch  uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint
 
 	EnableDevVerification bool `json:"enableDevVerification"` // Enable developer address verification
+
+	// MaxValidators is the max number of validators allowed to seal, activated at
+	// MaxValidatorsBlock (from genesis if nil). DefaultCongressMaxValidators applies
+	// if it's zero or not yet activated. The epoch updates of the validator set are
+	// only capped once MaxValidatorsBlock is set and reached, the capped validators
+	// sealing from the following block.
+	MaxValidators      uint64   `json:"maxValidators,omitempty"`
+	MaxValidatorsBlock *big.Int `json:"maxValidatorsBlock,omitempty"`
+
+	// SystemCallGasCap is the gas limit of each consensus system call, activated at
+	// SystemCallGasCapBlock (from genesis if nil). System calls are unlimited if it's
+	// zero or not yet activated.
+	SystemCallGasCap      uint64   `json:"systemCallGasCap,omitempty"`
+	SystemCallGasCapBlock *big.Int `json:"systemCallGasCapBlock,omitempty"`
+
+	// PunishBreakerBlock enables the system governance proposals arming the punish
+	// circuit breaker, which turns the failed punish calls into logged skips instead
+	// of failing the block (nil = no fork). The breaker state lives in the chain state.
+	PunishBreakerBlock *big.Int `json:"punishBreakerBlock,omitempty"`
+
+	// MaxTxSize, MaxCalldataSize and MaxInitCodeSize are the max sizes in bytes of the
+	// encoded transactions, of their input data and of the contract creation code,
+	// activated at TxSizeLimitsBlock (from genesis if nil). Each limit is disabled if
+	// it's zero or not yet activated.
+	MaxTxSize         uint64   `json:"maxTxSize,omitempty"`
+	MaxCalldataSize   uint64   `json:"maxCalldataSize,omitempty"`
+	MaxInitCodeSize   uint64   `json:"maxInitCodeSize,omitempty"`
+	TxSizeLimitsBlock *big.Int `json:"txSizeLimitsBlock,omitempty"`
+
+	// MinSuggestedGasPrice and MaxSuggestedGasPrice are the governance bounds of the
+	// prices suggested by the gas price oracles, tightening the ones configured by
+	// the node operators. They're advisory only and not part of the consensus rules.
+	MinSuggestedGasPrice *big.Int `json:"minSuggestedGasPrice,omitempty"`
+	MaxSuggestedGasPrice *big.Int `json:"maxSuggestedGasPrice,omitempty"`
+
+	// EpochSealDelay is the number of seconds the out-of-turn validators wait on top
+	// of the period before sealing the first block after a validator set change,
+	// giving a head start to the in-turn validator, activated at EpochSealDelayBlock
+	// (from genesis if nil). There is no extra delay if it's zero or not yet activated.
+	EpochSealDelay      uint64   `json:"epochSealDelay,omitempty"`
+	EpochSealDelayBlock *big.Int `json:"epochSealDelayBlock,omitempty"`
+
+	// BlacklistDelay is the number of blocks an update of the blacklist waits before
+	// being enforced, on top of the next block, activated at BlacklistDelayBlock (from
+	// genesis if nil). The updates are enforced on the next block if it's zero or not
+	// yet activated.
+	BlacklistDelay      uint64   `json:"blacklistDelay,omitempty"`
+	BlacklistDelayBlock *big.Int `json:"blacklistDelayBlock,omitempty"`
+
+	// FeelessSenders are the accounts allowed to send transactions paying no gas
+	// price, activated at FeelessBlock (from genesis if nil). Once activated, the
+	// transactions paying no gas price are only valid from these senders, at most
+	// MaxFeelessTxs of them per block with at most MaxFeelessGas of gas limit in
+	// total, each cap being disabled if zero. They're unrestricted if there is no
+	// sender or if not yet activated.
+	FeelessSenders []common.Address `json:"feelessSenders,omitempty"`
+	MaxFeelessTxs  uint64           `json:"maxFeelessTxs,omitempty"`
+	MaxFeelessGas  uint64           `json:"maxFeelessGas,omitempty"`
+	FeelessBlock   *big.Int         `json:"feelessBlock,omitempty"`
+
+	// FeeBurnRatio is the per mille of the transaction fees burnt, sent to the burn
+	// address, the rest being collected into the fee recorder and distributed to
+	// the validators, activated at FeeBurnBlock which must be past London. Before
+	// the fork the base fees are burnt and the tips collected. The system governance
+	// may tune the ratio once the fork is reached.
+	FeeBurnRatio uint64   `json:"feeBurnRatio,omitempty"`
+	FeeBurnBlock *big.Int `json:"feeBurnBlock,omitempty"`
+}
+
+// TxSizeLimits are the transaction size limits in force at a block, zero meaning
+// no limit.
+type TxSizeLimits struct {
+	Tx       uint64 // Max size of the encoded transaction
+	Calldata uint64 // Max size of the transaction input data
+	InitCode uint64 // Max size of the contract creation code
+}
+
+// FeelessRules are the rules of the transactions paying no gas price in force at
+// a block, zero meaning no cap.
+type FeelessRules struct {
+	Senders map[common.Address]struct{} // Accounts allowed to send transactions paying no gas price
+	MaxTxs  uint64                      // Max number of transactions paying no gas price per block
+	MaxGas  uint64                      // Max total gas limit of the transactions paying no gas price per block
+}
+
+// Allowed reports whether the sender may send transactions paying no gas price.
+func (r *FeelessRules) Allowed(sender common.Address) bool {
+	_, ok := r.Senders[sender]
+	return ok
+}
+
+// equal reports whether the rules are the same.
+func (r *FeelessRules) equal(other *FeelessRules) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	if r.MaxTxs != other.MaxTxs || r.MaxGas != other.MaxGas || len(r.Senders) != len(other.Senders) {
+		return false
+	}
+	for sender := range r.Senders {
+		if !other.Allowed(sender) {
+			return false
+		}
+	}
+	return true
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -253,6 +392,177 @@ func (c *CongressConfig) String() string {
 	return "congress"
 }
 
+// MaxValidatorsAt returns the max number of validators allowed to seal the given
+// block. The validators sealing an epoch are selected at the epoch block ending
+// the previous one, so the cap only applies from the first epoch selected once
+// MaxValidatorsBlock is reached.
+func (c *CongressConfig) MaxValidatorsAt(num *big.Int) int {
+	if c.MaxValidators == 0 || !isCongressForked(c.MaxValidatorsBlock, c.validatorsSelectedAt(num)) {
+		return DefaultCongressMaxValidators
+	}
+	return int(c.MaxValidators)
+}
+
+// validatorsSelectedAt returns the epoch block selecting the validators sealing
+// the given block, genesis for the first epoch.
+func (c *CongressConfig) validatorsSelectedAt(num *big.Int) *big.Int {
+	if c.Epoch == 0 || num == nil || num.Sign() <= 0 {
+		return num
+	}
+	prev := new(big.Int).Sub(num, common.Big1)
+	return prev.Sub(prev, new(big.Int).Mod(prev, new(big.Int).SetUint64(c.Epoch)))
+}
+
+// SystemCallGasAt returns the gas limit of each consensus system call at the given block.
+func (c *CongressConfig) SystemCallGasAt(num *big.Int) uint64 {
+	if c.SystemCallGasCap == 0 || !isCongressForked(c.SystemCallGasCapBlock, num) {
+		return math.MaxUint64
+	}
+	return c.SystemCallGasCap
+}
+
+// IsPunishBreaker returns whether num represents a block number after the punish
+// breaker fork.
+func (c *CongressConfig) IsPunishBreaker(num *big.Int) bool {
+	return isForked(c.PunishBreakerBlock, num)
+}
+
+// TxSizeLimitsAt returns the transaction size limits at the given block.
+func (c *CongressConfig) TxSizeLimitsAt(num *big.Int) TxSizeLimits {
+	if !isCongressForked(c.TxSizeLimitsBlock, num) {
+		return TxSizeLimits{}
+	}
+	return TxSizeLimits{Tx: c.MaxTxSize, Calldata: c.MaxCalldataSize, InitCode: c.MaxInitCodeSize}
+}
+
+// EpochSealDelayAt returns the extra seal delay of the out-of-turn validators at the
+// given block, if it's the first block after a validator set change.
+func (c *CongressConfig) EpochSealDelayAt(num *big.Int) uint64 {
+	if !isCongressForked(c.EpochSealDelayBlock, num) {
+		return 0
+	}
+	return c.EpochSealDelay
+}
+
+// BlacklistDelayAt returns the activation delay of the blacklist updates at the
+// given block.
+func (c *CongressConfig) BlacklistDelayAt(num *big.Int) uint64 {
+	if !isCongressForked(c.BlacklistDelayBlock, num) {
+		return 0
+	}
+	return c.BlacklistDelay
+}
+
+// FeelessAt returns the rules of the transactions paying no gas price at the given
+// block, nil if they're unrestricted.
+func (c *CongressConfig) FeelessAt(num *big.Int) *FeelessRules {
+	if len(c.FeelessSenders) == 0 || !isCongressForked(c.FeelessBlock, num) {
+		return nil
+	}
+	rules := &FeelessRules{
+		Senders: make(map[common.Address]struct{}, len(c.FeelessSenders)),
+		MaxTxs:  c.MaxFeelessTxs,
+		MaxGas:  c.MaxFeelessGas,
+	}
+	for _, sender := range c.FeelessSenders {
+		rules.Senders[sender] = struct{}{}
+	}
+	return rules
+}
+
+// IsFeeBurn returns whether num represents a block number after the fee burn fork.
+func (c *CongressConfig) IsFeeBurn(num *big.Int) bool {
+	return isForked(c.FeeBurnBlock, num)
+}
+
+// FeeBurnRatioAt returns the per mille of the transaction fees burnt at the given
+// block by the chain config, unless tuned by the system governance.
+func (c *CongressConfig) FeeBurnRatioAt(num *big.Int) uint64 {
+	if !c.IsFeeBurn(num) {
+		return 0
+	}
+	return c.FeeBurnRatio
+}
+
+// congressGatedParam is a congress parameter activated at a fork block. The forks
+// can't be rescheduled once reached, nor can the values in force at the head be
+// changed.
+type congressGatedParam struct {
+	fork  string // Name of the fork in the errors
+	value string // Name of the value in the errors
+	unset string // Error detail if the fork is enabled without its value, empty if it needs none
+
+	block func(c *CongressConfig) *big.Int                  // Fork block of the parameter
+	isSet func(c *CongressConfig) bool                      // Whether the value is set, nil if it needs none
+	equal func(c, other *CongressConfig, num *big.Int) bool // Whether the values in force at num match, nil if it has none
+}
+
+// congressGatedParams are the fork gated congress parameters, in the order of the
+// config fields.
+var congressGatedParams = []congressGatedParam{
+	{
+		fork: "max validators", value: "max validators", unset: "max validators not set",
+		block: func(c *CongressConfig) *big.Int { return c.MaxValidatorsBlock },
+		isSet: func(c *CongressConfig) bool { return c.MaxValidators != 0 },
+		equal: func(c, other *CongressConfig, num *big.Int) bool {
+			return c.MaxValidatorsAt(num) == other.MaxValidatorsAt(num)
+		},
+	},
+	{
+		fork: "system call gas cap", value: "system call gas cap", unset: "gas cap not set",
+		block: func(c *CongressConfig) *big.Int { return c.SystemCallGasCapBlock },
+		isSet: func(c *CongressConfig) bool { return c.SystemCallGasCap != 0 },
+		equal: func(c, other *CongressConfig, num *big.Int) bool {
+			return c.SystemCallGasAt(num) == other.SystemCallGasAt(num)
+		},
+	},
+	{
+		fork:  "punish breaker",
+		block: func(c *CongressConfig) *big.Int { return c.PunishBreakerBlock },
+	},
+	{
+		fork: "tx size limits", value: "tx size limits", unset: "no limit set",
+		block: func(c *CongressConfig) *big.Int { return c.TxSizeLimitsBlock },
+		isSet: func(c *CongressConfig) bool {
+			return c.MaxTxSize != 0 || c.MaxCalldataSize != 0 || c.MaxInitCodeSize != 0
+		},
+		equal: func(c, other *CongressConfig, num *big.Int) bool {
+			return c.TxSizeLimitsAt(num) == other.TxSizeLimitsAt(num)
+		},
+	},
+	{
+		fork: "epoch seal delay", value: "epoch seal delay", unset: "delay not set",
+		block: func(c *CongressConfig) *big.Int { return c.EpochSealDelayBlock },
+		isSet: func(c *CongressConfig) bool { return c.EpochSealDelay != 0 },
+		equal: func(c, other *CongressConfig, num *big.Int) bool {
+			return c.EpochSealDelayAt(num) == other.EpochSealDelayAt(num)
+		},
+	},
+	{
+		fork: "blacklist delay", value: "blacklist delay", unset: "delay not set",
+		block: func(c *CongressConfig) *big.Int { return c.BlacklistDelayBlock },
+		isSet: func(c *CongressConfig) bool { return c.BlacklistDelay != 0 },
+		equal: func(c, other *CongressConfig, num *big.Int) bool {
+			return c.BlacklistDelayAt(num) == other.BlacklistDelayAt(num)
+		},
+	},
+	{
+		fork: "feeless", value: "feeless rules", unset: "no sender set",
+		block: func(c *CongressConfig) *big.Int { return c.FeelessBlock },
+		isSet: func(c *CongressConfig) bool { return len(c.FeelessSenders) != 0 },
+		equal: func(c, other *CongressConfig, num *big.Int) bool {
+			return c.FeelessAt(num).equal(other.FeelessAt(num))
+		},
+	},
+	{
+		fork: "fee burn", value: "fee burn ratio",
+		block: func(c *CongressConfig) *big.Int { return c.FeeBurnBlock },
+		equal: func(c, other *CongressConfig, num *big.Int) bool {
+			return c.FeeBurnRatioAt(num) == other.FeeBurnRatioAt(num)
+		},
+	},
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -266,7 +576,7 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, RedCoastBlock: %v, Berlin: %v, London: %v, Sophon: %v, Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, RedCoastBlock: %v, Berlin: %v, London: %v, Sophon: %v, ProxyCheck: %v, ReplayProtection: %v, Taurus: %v, Engine: %v}",
 		c.ChainID,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -283,6 +593,9 @@ func (c *ChainConfig) String() string {
 		c.BerlinBlock,
 		c.LondonBlock,
 		c.SophonBlock,
+		c.ProxyCheckBlock,
+		c.ReplayProtectionBlock,
+		c.TaurusBlock,
 		engine,
 	)
 }
@@ -372,6 +685,21 @@ func (c *ChainConfig) IsSophon(num *big.Int) bool {
 	return isForked(c.SophonBlock, num)
 }
 
+// IsProxyCheck returns whether num represents a block number after the ProxyCheckBlock fork
+func (c *ChainConfig) IsProxyCheck(num *big.Int) bool {
+	return isForked(c.ProxyCheckBlock, num)
+}
+
+// IsReplayProtection returns whether num represents a block number after the ReplayProtectionBlock fork
+func (c *ChainConfig) IsReplayProtection(num *big.Int) bool {
+	return isForked(c.ReplayProtectionBlock, num)
+}
+
+// IsTaurus returns whether num represents a block number after the TaurusBlock fork
+func (c *ChainConfig) IsTaurus(num *big.Int) bool {
+	return isForked(c.TaurusBlock, num)
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
@@ -390,6 +718,148 @@ func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *Confi
 	return lasterr
 }
 
+// UnknownFork is a fork scheduled in a JSON encoded chain configuration, which
+// this release doesn't know about.
+type UnknownFork struct {
+	Name  string
+	Block *big.Int
+}
+
+// UnknownForks returns the fork blocks scheduled in the JSON encoded chain
+// configuration, the congress section included, that this release doesn't know
+// about, ordered by block. A configuration written by a newer release carries
+// the forks added since, which would be silently dropped when decoded.
+func UnknownForks(data []byte) ([]UnknownFork, error) {
+	var (
+		config struct {
+			Congress json.RawMessage `json:"congress"`
+		}
+		forks []UnknownFork
+	)
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	top, err := unknownForkFields(data, reflect.TypeOf(ChainConfig{}), "")
+	if err != nil {
+		return nil, err
+	}
+	forks = append(forks, top...)
+	if len(config.Congress) > 0 && string(config.Congress) != "null" {
+		congress, err := unknownForkFields(config.Congress, reflect.TypeOf(CongressConfig{}), "congress.")
+		if err != nil {
+			return nil, err
+		}
+		forks = append(forks, congress...)
+	}
+	sort.Slice(forks, func(i, j int) bool {
+		if cmp := forks[i].Block.Cmp(forks[j].Block); cmp != 0 {
+			return cmp < 0
+		}
+		return forks[i].Name < forks[j].Name
+	})
+	return forks, nil
+}
+
+// unknownForkFields returns the fork blocks of the JSON object not matching any
+// field of the given struct type. Like the decoder, the names are matched case
+// insensitively.
+func unknownForkFields(data []byte, typ reflect.Type, prefix string) ([]UnknownFork, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	known := knownFields(typ)
+	var forks []UnknownFork
+	for name, value := range fields {
+		if known[strings.ToLower(name)] || !strings.HasSuffix(name, "Block") || string(value) == "null" {
+			continue
+		}
+		block := new(big.Int)
+		if err := json.Unmarshal(value, block); err != nil {
+			continue // not a fork block
+		}
+		forks = append(forks, UnknownFork{Name: prefix + name, Block: block})
+	}
+	return forks, nil
+}
+
+// knownFields returns the lower cased JSON names of the fields of a struct type.
+func knownFields(typ reflect.Type) map[string]bool {
+	known := make(map[string]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = typ.Field(i).Name
+		}
+		known[strings.ToLower(name)] = true
+	}
+	return known
+}
+
+// KeepUnknownFields carries the fields of the stored JSON encoded chain
+// configuration unknown to this release, the congress section included, over to
+// the encoding of the updated one, so that rewriting the configuration doesn't
+// drop the forks scheduled by a newer release.
+func KeepUnknownFields(stored, updated []byte) ([]byte, error) {
+	merged, unknown, err := keepUnknownFields(stored, updated, reflect.TypeOf(ChainConfig{}))
+	if err != nil {
+		return nil, err
+	}
+	var storedCongress, updatedCongress struct {
+		Congress json.RawMessage `json:"congress"`
+	}
+	if err := json.Unmarshal(stored, &storedCongress); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(updated, &updatedCongress); err != nil {
+		return nil, err
+	}
+	if isJSONObject(storedCongress.Congress) && isJSONObject(updatedCongress.Congress) {
+		fields, unknownCongress, err := keepUnknownFields(storedCongress.Congress, updatedCongress.Congress, reflect.TypeOf(CongressConfig{}))
+		if err != nil {
+			return nil, err
+		}
+		if unknownCongress {
+			if merged["congress"], err = json.Marshal(fields); err != nil {
+				return nil, err
+			}
+			unknown = true
+		}
+	}
+	if !unknown {
+		return updated, nil
+	}
+	return json.Marshal(merged)
+}
+
+// keepUnknownFields returns the fields of the updated JSON object, along with the
+// ones of the stored object not matching any field of the given struct type, and
+// whether there were any.
+func keepUnknownFields(stored, updated []byte, typ reflect.Type) (map[string]json.RawMessage, bool, error) {
+	var storedFields, updatedFields map[string]json.RawMessage
+	if err := json.Unmarshal(stored, &storedFields); err != nil {
+		return nil, false, err
+	}
+	if err := json.Unmarshal(updated, &updatedFields); err != nil {
+		return nil, false, err
+	}
+	var (
+		known   = knownFields(typ)
+		unknown bool
+	)
+	for name, value := range storedFields {
+		if !known[strings.ToLower(name)] {
+			updatedFields[name], unknown = value, true
+		}
+	}
+	return updatedFields, unknown, nil
+}
+
+// isJSONObject reports whether the raw JSON value is an object.
+func isJSONObject(data json.RawMessage) bool {
+	return len(data) > 0 && data[0] == '{'
+}
+
 // CheckConfigForkOrder checks that we don't "skip" any forks, geth isn't pluggable enough
 // to guarantee that forks can be implemented in a different order than on official networks
 func (c *ChainConfig) CheckConfigForkOrder() error {
@@ -437,6 +907,9 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 	for _, cur := range []fork{
 		{name: "redCoastBlock", block: c.RedCoastBlock, minValue: big.NewInt(2)},
 		{name: "sophonBlock", block: c.SophonBlock},
+		{name: "proxyCheckBlock", block: c.ProxyCheckBlock, optional: true},
+		{name: "replayProtectionBlock", block: c.ReplayProtectionBlock, optional: true},
+		{name: "taurusBlock", block: c.TaurusBlock, optional: true},
 	} {
 		// check minimal fork block
 		if cur.block != nil && cur.minValue != nil {
@@ -462,6 +935,22 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
+	if c.Congress != nil {
+		for _, param := range congressGatedParams {
+			if block := param.block(c.Congress); block != nil && param.isSet != nil && !param.isSet(c.Congress) {
+				return fmt.Errorf("congress %s fork enabled at %v, but %s", param.fork, block, param.unset)
+			}
+		}
+	}
+	if c.Congress != nil && c.Congress.BlacklistDelay > MaxCongressBlacklistDelay {
+		return fmt.Errorf("congress blacklist delay %d above the max %d", c.Congress.BlacklistDelay, MaxCongressBlacklistDelay)
+	}
+	if c.Congress != nil && c.Congress.FeeBurnRatio > CongressFeeBurnDenominator {
+		return fmt.Errorf("congress fee burn ratio %d above the max %d", c.Congress.FeeBurnRatio, CongressFeeBurnDenominator)
+	}
+	if c.Congress != nil && c.Congress.FeeBurnBlock != nil && (c.LondonBlock == nil || c.Congress.FeeBurnBlock.Cmp(c.LondonBlock) < 0) {
+		return fmt.Errorf("congress fee burn fork enabled at %v, before london at %v", c.Congress.FeeBurnBlock, c.LondonBlock)
+	}
 	return nil
 }
 
@@ -515,9 +1004,29 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.RedCoastBlock, newcfg.RedCoastBlock, head) {
 		return newCompatError("RedCoast fork block", c.RedCoastBlock, newcfg.RedCoastBlock)
 	}
+	if isForkIncompatible(c.ProxyCheckBlock, newcfg.ProxyCheckBlock, head) {
+		return newCompatError("ProxyCheck fork block", c.ProxyCheckBlock, newcfg.ProxyCheckBlock)
+	}
+	if isForkIncompatible(c.ReplayProtectionBlock, newcfg.ReplayProtectionBlock, head) {
+		return newCompatError("ReplayProtection fork block", c.ReplayProtectionBlock, newcfg.ReplayProtectionBlock)
+	}
+	if isForkIncompatible(c.TaurusBlock, newcfg.TaurusBlock, head) {
+		return newCompatError("Taurus fork block", c.TaurusBlock, newcfg.TaurusBlock)
+	}
 	if isForkIncompatible(c.ArrowGlacierBlock, newcfg.ArrowGlacierBlock, head) {
 		return newCompatError("Arrow Glacier fork block", c.ArrowGlacierBlock, newcfg.ArrowGlacierBlock)
 	}
+	if c.Congress != nil && newcfg.Congress != nil {
+		for _, param := range congressGatedParams {
+			stored, updated := param.block(c.Congress), param.block(newcfg.Congress)
+			if isForkIncompatible(stored, updated, head) {
+				return newCompatError("Congress "+param.fork+" fork block", stored, updated)
+			}
+			if param.equal != nil && !param.equal(c.Congress, newcfg.Congress, head) {
+				return newCompatError("Congress "+param.value, stored, updated)
+			}
+		}
+	}
 	return nil
 }
 
@@ -535,6 +1044,12 @@ func isForked(s, head *big.Int) bool {
 	return s.Cmp(head) <= 0
 }
 
+// isCongressForked returns whether a congress parameter activated at block s, from
+// genesis if nil, is in force at the given head block.
+func isCongressForked(s, head *big.Int) bool {
+	return s == nil || isForked(s, head)
+}
+
 func configNumEqual(x, y *big.Int) bool {
 	if x == nil {
 		return y == nil
@@ -586,6 +1101,7 @@ type Rules struct {
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158               bool
 	IsByzantium, IsConstantinople, IsPetersburg, IsIstanbul bool
 	IsBerlin, IsLondon                                      bool
+	IsTaurus                                                bool
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -606,5 +1122,6 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 		IsIstanbul:       c.IsIstanbul(num),
 		IsBerlin:         c.IsBerlin(num),
 		IsLondon:         c.IsLondon(num),
+		IsTaurus:         c.IsTaurus(num),
 	}
 }
@@ -17,9 +17,14 @@
 package params
 
 import (
+	"bytes"
+	"encoding/json"
+	"math"
 	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 func TestCheckCompatible(t *testing.T) {
@@ -99,6 +104,23 @@ func TestCheckCompatible(t *testing.T) {
 			head:    uint64(100),
 			wantErr: nil,
 		},
+		{
+			stored: &ChainConfig{Congress: &CongressConfig{PunishBreakerBlock: big.NewInt(10)}},
+			new:    &ChainConfig{Congress: &CongressConfig{PunishBreakerBlock: big.NewInt(20)}},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "Congress punish breaker fork block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored:  &ChainConfig{Congress: &CongressConfig{PunishBreakerBlock: big.NewInt(10)}},
+			new:     &ChainConfig{Congress: &CongressConfig{PunishBreakerBlock: big.NewInt(20)}},
+			head:    9,
+			wantErr: nil,
+		},
 	}
 
 	for _, test := range tests {
@@ -109,6 +131,15 @@ func TestCheckCompatible(t *testing.T) {
 	}
 }
 
+// feeBurnConfig returns a congress chain config activating london and the fee burn
+// ratio at the given blocks.
+func feeBurnConfig(london, feeBurn int64) *ChainConfig {
+	config := *AllCongressProtocolChanges
+	config.LondonBlock = big.NewInt(london)
+	config.Congress = &CongressConfig{FeeBurnRatio: 500, FeeBurnBlock: big.NewInt(feeBurn)}
+	return &config
+}
+
 func TestCheckConfigForkOrder(t *testing.T) {
 	type test struct {
 		new   *ChainConfig
@@ -125,6 +156,26 @@ func TestCheckConfigForkOrder(t *testing.T) {
 		{new: &ChainConfig{RedCoastBlock: big.NewInt(1)}, isErr: true},
 		{new: &ChainConfig{SophonBlock: big.NewInt(3)}, isErr: true},
 		{new: &ChainConfig{RedCoastBlock: big.NewInt(2), SophonBlock: big.NewInt(2)}, isErr: true},
+		{new: &ChainConfig{ReplayProtectionBlock: big.NewInt(10)}, isErr: true},
+		{new: &ChainConfig{RedCoastBlock: big.NewInt(2), SophonBlock: big.NewInt(3), ReplayProtectionBlock: big.NewInt(10)}},
+		{new: &ChainConfig{Congress: &CongressConfig{MaxValidatorsBlock: big.NewInt(10)}}, isErr: true},
+		{new: &ChainConfig{Congress: &CongressConfig{MaxValidators: 33, MaxValidatorsBlock: big.NewInt(10)}}},
+		{new: &ChainConfig{Congress: &CongressConfig{SystemCallGasCapBlock: big.NewInt(10)}}, isErr: true},
+		{new: &ChainConfig{Congress: &CongressConfig{SystemCallGasCap: 1e8, SystemCallGasCapBlock: big.NewInt(10)}}},
+		{new: &ChainConfig{Congress: &CongressConfig{PunishBreakerBlock: big.NewInt(10)}}},
+		{new: &ChainConfig{Congress: &CongressConfig{TxSizeLimitsBlock: big.NewInt(10)}}, isErr: true},
+		{new: &ChainConfig{Congress: &CongressConfig{MaxInitCodeSize: 49152, TxSizeLimitsBlock: big.NewInt(10)}}},
+		{new: &ChainConfig{Congress: &CongressConfig{EpochSealDelayBlock: big.NewInt(10)}}, isErr: true},
+		{new: &ChainConfig{Congress: &CongressConfig{EpochSealDelay: 2, EpochSealDelayBlock: big.NewInt(10)}}},
+		{new: &ChainConfig{Congress: &CongressConfig{BlacklistDelayBlock: big.NewInt(10)}}, isErr: true},
+		{new: &ChainConfig{Congress: &CongressConfig{BlacklistDelay: MaxCongressBlacklistDelay + 1}}, isErr: true},
+		{new: &ChainConfig{Congress: &CongressConfig{BlacklistDelay: 20, BlacklistDelayBlock: big.NewInt(10)}}},
+		{new: &ChainConfig{Congress: &CongressConfig{FeelessBlock: big.NewInt(10)}}, isErr: true},
+		{new: &ChainConfig{Congress: &CongressConfig{FeelessSenders: []common.Address{{0x01}}, FeelessBlock: big.NewInt(10)}}},
+		{new: &ChainConfig{Congress: &CongressConfig{FeeBurnRatio: CongressFeeBurnDenominator + 1}}, isErr: true},
+		{new: &ChainConfig{Congress: &CongressConfig{FeeBurnRatio: 500, FeeBurnBlock: big.NewInt(10)}}, isErr: true},
+		{new: feeBurnConfig(20, 10), isErr: true},
+		{new: feeBurnConfig(10, 10)},
 	}
 	for _, tc := range tests {
 		err := tc.new.CheckConfigForkOrder()
@@ -136,3 +187,270 @@ func TestCheckConfigForkOrder(t *testing.T) {
 		}
 	}
 }
+
+// Tests the fork gated congress parameters: the values in force around their fork
+// blocks, the validation of the forks and the compatibility of their updates.
+func TestCongressGatedParams(t *testing.T) {
+	sender := common.Address{0x01}
+	tests := []struct {
+		fork    string                                            // Name of the fork in congressGatedParams
+		config  func(block *big.Int, alt bool) *CongressConfig    // Config enabling the parameter at block, alt setting another value
+		unset   *CongressConfig                                   // Config enabling the fork without its value, nil if it needs none
+		at      func(c *CongressConfig, num *big.Int) interface{} // Value in force at num
+		off, on interface{}                                       // Values in force before and once the fork is reached
+		genesis bool                                              // Whether the parameter is in force from genesis if its block is nil
+	}{
+		{
+			fork: "max validators",
+			config: func(block *big.Int, alt bool) *CongressConfig {
+				if alt {
+					return &CongressConfig{MaxValidators: 41, MaxValidatorsBlock: block}
+				}
+				return &CongressConfig{MaxValidators: 33, MaxValidatorsBlock: block}
+			},
+			unset:   &CongressConfig{MaxValidatorsBlock: big.NewInt(100)},
+			at:      func(c *CongressConfig, num *big.Int) interface{} { return c.MaxValidatorsAt(num) },
+			off:     DefaultCongressMaxValidators,
+			on:      33,
+			genesis: true,
+		},
+		{
+			fork: "system call gas cap",
+			config: func(block *big.Int, alt bool) *CongressConfig {
+				if alt {
+					return &CongressConfig{SystemCallGasCap: 2e8, SystemCallGasCapBlock: block}
+				}
+				return &CongressConfig{SystemCallGasCap: 1e8, SystemCallGasCapBlock: block}
+			},
+			unset:   &CongressConfig{SystemCallGasCapBlock: big.NewInt(100)},
+			at:      func(c *CongressConfig, num *big.Int) interface{} { return c.SystemCallGasAt(num) },
+			off:     uint64(math.MaxUint64),
+			on:      uint64(1e8),
+			genesis: true,
+		},
+		{
+			fork: "punish breaker",
+			config: func(block *big.Int, alt bool) *CongressConfig {
+				return &CongressConfig{PunishBreakerBlock: block}
+			},
+			at:  func(c *CongressConfig, num *big.Int) interface{} { return c.IsPunishBreaker(num) },
+			off: false,
+			on:  true,
+		},
+		{
+			fork: "tx size limits",
+			config: func(block *big.Int, alt bool) *CongressConfig {
+				if alt {
+					return &CongressConfig{MaxCalldataSize: 65536, TxSizeLimitsBlock: block}
+				}
+				return &CongressConfig{MaxTxSize: 131072, MaxInitCodeSize: 49152, TxSizeLimitsBlock: block}
+			},
+			unset:   &CongressConfig{TxSizeLimitsBlock: big.NewInt(100)},
+			at:      func(c *CongressConfig, num *big.Int) interface{} { return c.TxSizeLimitsAt(num) },
+			off:     TxSizeLimits{},
+			on:      TxSizeLimits{Tx: 131072, InitCode: 49152},
+			genesis: true,
+		},
+		{
+			fork: "epoch seal delay",
+			config: func(block *big.Int, alt bool) *CongressConfig {
+				if alt {
+					return &CongressConfig{EpochSealDelay: 3, EpochSealDelayBlock: block}
+				}
+				return &CongressConfig{EpochSealDelay: 2, EpochSealDelayBlock: block}
+			},
+			unset:   &CongressConfig{EpochSealDelayBlock: big.NewInt(100)},
+			at:      func(c *CongressConfig, num *big.Int) interface{} { return c.EpochSealDelayAt(num) },
+			off:     uint64(0),
+			on:      uint64(2),
+			genesis: true,
+		},
+		{
+			fork: "blacklist delay",
+			config: func(block *big.Int, alt bool) *CongressConfig {
+				if alt {
+					return &CongressConfig{BlacklistDelay: 30, BlacklistDelayBlock: block}
+				}
+				return &CongressConfig{BlacklistDelay: 20, BlacklistDelayBlock: block}
+			},
+			unset:   &CongressConfig{BlacklistDelayBlock: big.NewInt(100)},
+			at:      func(c *CongressConfig, num *big.Int) interface{} { return c.BlacklistDelayAt(num) },
+			off:     uint64(0),
+			on:      uint64(20),
+			genesis: true,
+		},
+		{
+			fork: "feeless",
+			config: func(block *big.Int, alt bool) *CongressConfig {
+				if alt {
+					return &CongressConfig{FeelessSenders: []common.Address{sender}, MaxFeelessTxs: 10, FeelessBlock: block}
+				}
+				return &CongressConfig{FeelessSenders: []common.Address{sender}, MaxFeelessGas: 1e6, FeelessBlock: block}
+			},
+			unset:   &CongressConfig{MaxFeelessTxs: 10, FeelessBlock: big.NewInt(100)},
+			at:      func(c *CongressConfig, num *big.Int) interface{} { return c.FeelessAt(num) },
+			off:     (*FeelessRules)(nil),
+			on:      &FeelessRules{Senders: map[common.Address]struct{}{sender: {}}, MaxGas: 1e6},
+			genesis: true,
+		},
+		{
+			fork: "fee burn",
+			config: func(block *big.Int, alt bool) *CongressConfig {
+				if alt {
+					return &CongressConfig{FeeBurnRatio: 500, FeeBurnBlock: block}
+				}
+				return &CongressConfig{FeeBurnRatio: 300, FeeBurnBlock: block}
+			},
+			at:  func(c *CongressConfig, num *big.Int) interface{} { return c.FeeBurnRatioAt(num) },
+			off: uint64(0),
+			on:  uint64(300),
+		},
+	}
+	if len(tests) != len(congressGatedParams) {
+		t.Fatalf("tested params mismatch: have %d, want %d", len(tests), len(congressGatedParams))
+	}
+	chain := func(congress *CongressConfig) *ChainConfig {
+		config := *AllCongressProtocolChanges
+		config.BerlinBlock, config.LondonBlock = big.NewInt(0), big.NewInt(0)
+		config.Congress = congress
+		return &config
+	}
+	for i, tt := range tests {
+		param := congressGatedParams[i]
+		if param.fork != tt.fork {
+			t.Fatalf("test %d: param mismatch: have %s, want %s", i, param.fork, tt.fork)
+		}
+		// The value is in force from the fork block, or from genesis if allowed
+		config := tt.config(big.NewInt(100), false)
+		if have := tt.at(config, big.NewInt(99)); !reflect.DeepEqual(have, tt.off) {
+			t.Errorf("%s: value before the fork mismatch: have %v, want %v", tt.fork, have, tt.off)
+		}
+		if have := tt.at(config, big.NewInt(100)); !reflect.DeepEqual(have, tt.on) {
+			t.Errorf("%s: value at the fork mismatch: have %v, want %v", tt.fork, have, tt.on)
+		}
+		want := tt.off
+		if tt.genesis {
+			want = tt.on
+		}
+		if have := tt.at(tt.config(nil, false), common.Big0); !reflect.DeepEqual(have, want) {
+			t.Errorf("%s: value without fork block mismatch: have %v, want %v", tt.fork, have, want)
+		}
+		// The fork is only valid along with its value
+		if err := chain(config).CheckConfigForkOrder(); err != nil {
+			t.Errorf("%s: valid fork rejected: %v", tt.fork, err)
+		}
+		if tt.unset != nil {
+			if have := tt.at(tt.unset, big.NewInt(100)); !reflect.DeepEqual(have, tt.off) {
+				t.Errorf("%s: unset value mismatch: have %v, want %v", tt.fork, have, tt.off)
+			}
+			if err := chain(tt.unset).CheckConfigForkOrder(); err == nil {
+				t.Errorf("%s: fork without value accepted", tt.fork)
+			}
+		}
+		// The fork can't be rescheduled once reached, nor its value changed
+		stored := &ChainConfig{Congress: config}
+		if err := stored.CheckCompatible(&ChainConfig{Congress: tt.config(big.NewInt(200), false)}, 50); err != nil {
+			t.Errorf("%s: rescheduled fork incompatible before reached: %v", tt.fork, err)
+		}
+		err := stored.CheckCompatible(&ChainConfig{Congress: tt.config(big.NewInt(200), false)}, 150)
+		if want := "Congress " + tt.fork + " fork block"; err == nil || err.What != want || err.RewindTo != 99 {
+			t.Errorf("%s: rescheduled fork compatibility mismatch: have %v, want %s rewinding to 99", tt.fork, err, want)
+		}
+		update := &ChainConfig{Congress: tt.config(big.NewInt(100), true)}
+		if reflect.DeepEqual(tt.at(update.Congress, big.NewInt(100)), tt.on) {
+			continue
+		}
+		if err := stored.CheckCompatible(update, 50); err != nil {
+			t.Errorf("%s: changed value incompatible before activation: %v", tt.fork, err)
+		}
+		err = stored.CheckCompatible(update, 100)
+		if want := "Congress " + param.value; err == nil || err.What != want || err.RewindTo != 99 {
+			t.Errorf("%s: changed value compatibility mismatch: have %v, want %s rewinding to 99", tt.fork, err, want)
+		}
+	}
+}
+
+// Tests that the max validators only cap the epochs whose validators are selected
+// once the fork is reached, not the one running at the fork block.
+func TestCongressMaxValidatorsEpoch(t *testing.T) {
+	config := &CongressConfig{Epoch: 200, MaxValidators: 33, MaxValidatorsBlock: big.NewInt(300)}
+
+	tests := []struct {
+		number int64
+		want   int
+	}{
+		{0, DefaultCongressMaxValidators},
+		{300, DefaultCongressMaxValidators},
+		{400, DefaultCongressMaxValidators},
+		{401, 33},
+		{600, 33},
+	}
+	for _, tt := range tests {
+		if have := config.MaxValidatorsAt(big.NewInt(tt.number)); have != tt.want {
+			t.Errorf("block %d: max validators mismatch: have %d, want %d", tt.number, have, tt.want)
+		}
+	}
+}
+
+func TestFeelessRulesAllowed(t *testing.T) {
+	sender := common.Address{0x01}
+	rules := (&CongressConfig{FeelessSenders: []common.Address{sender}}).FeelessAt(common.Big0)
+	if !rules.Allowed(sender) || rules.Allowed(common.Address{0x02}) {
+		t.Errorf("feeless senders mismatch")
+	}
+}
+
+func TestUnknownForks(t *testing.T) {
+	data := []byte(`{"chainId": 1, "SophonBlock": 20, "futureBlock": 300, "pastBlock": 100, "noneBlock": null, "futureFlag": true,
+		"congress": {"period": 3, "maxValidatorsBlock": 50, "otherBlock": 100}}`)
+	forks, err := UnknownForks(data)
+	if err != nil {
+		t.Fatalf("failed to find unknown forks: %v", err)
+	}
+	want := []UnknownFork{
+		{Name: "congress.otherBlock", Block: big.NewInt(100)},
+		{Name: "pastBlock", Block: big.NewInt(100)},
+		{Name: "futureBlock", Block: big.NewInt(300)},
+	}
+	if !reflect.DeepEqual(forks, want) {
+		t.Errorf("unknown forks mismatch: have %v, want %v", forks, want)
+	}
+	// A configuration written by this release has none
+	data, _ = json.Marshal(MainnetChainConfig)
+	if forks, err := UnknownForks(data); err != nil || len(forks) != 0 {
+		t.Errorf("known forks reported: %v, %v", forks, err)
+	}
+}
+
+func TestKeepUnknownFields(t *testing.T) {
+	stored := []byte(`{"chainId": 1, "SophonBlock": 20, "futureBlock": 300, "congress": {"period": 3, "otherBlock": 100}}`)
+	updated, _ := json.Marshal(&ChainConfig{ChainID: big.NewInt(1), SophonBlock: big.NewInt(30), Congress: &CongressConfig{Period: 3}})
+
+	merged, err := KeepUnknownFields(stored, updated)
+	if err != nil {
+		t.Fatalf("failed to keep unknown fields: %v", err)
+	}
+	forks, err := UnknownForks(merged)
+	if err != nil {
+		t.Fatalf("failed to find unknown forks: %v", err)
+	}
+	want := []UnknownFork{
+		{Name: "congress.otherBlock", Block: big.NewInt(100)},
+		{Name: "futureBlock", Block: big.NewInt(300)},
+	}
+	if !reflect.DeepEqual(forks, want) {
+		t.Errorf("unknown forks mismatch: have %v, want %v", forks, want)
+	}
+	// The known fields are the updated ones
+	var config ChainConfig
+	if err := json.Unmarshal(merged, &config); err != nil {
+		t.Fatalf("failed to decode merged config: %v", err)
+	}
+	if config.SophonBlock.Uint64() != 30 {
+		t.Errorf("Sophon block mismatch: have %v, want 30", config.SophonBlock)
+	}
+	// Without unknown fields, the updated encoding is left as is
+	if merged, err := KeepUnknownFields(updated, updated); err != nil || !bytes.Equal(merged, updated) {
+		t.Errorf("known fields encoding modified: %s, %v", merged, err)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import "strings"
+
+// APIKeyHeader is the HTTP header carrying the API key of the HTTP requests and
+// of the WebSocket handshakes.
+const APIKeyHeader = "X-Api-Key"
+
+// MethodFilter restricts the methods served on top of the registered namespaces.
+// The entries are either full method names (eth_call), whole namespaces
+// (txpool_*) or everything (*).
+type MethodFilter struct {
+	Allow []string `toml:",omitempty"` // Methods allowed, all of them if empty
+	Deny  []string `toml:",omitempty"` // Methods denied, even if allowed
+}
+
+// Allowed reports whether the method passes the filter. A nil filter allows
+// every method.
+func (f *MethodFilter) Allowed(method string) bool {
+	if f == nil {
+		return true
+	}
+	if matchMethod(f.Deny, method) {
+		return false
+	}
+	return len(f.Allow) == 0 || matchMethod(f.Allow, method)
+}
+
+// matchMethod reports whether the method matches any of the filter entries.
+func matchMethod(entries []string, method string) bool {
+	for _, entry := range entries {
+		switch {
+		case entry == "*" || entry == method:
+			return true
+		case strings.HasSuffix(entry, serviceMethodSeparator+"*"):
+			if strings.HasPrefix(method, strings.TrimSuffix(entry, "*")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetMethodFilter restricts the methods served to the connections without an
+// API key to the given filter, and the ones with an API key to the filter of the
+// key instead. The requests with an unknown API key are rejected if any key is
+// configured, served as keyless otherwise. It must be called before serving.
+func (s *Server) SetMethodFilter(filter *MethodFilter, keys map[string]*MethodFilter) {
+	s.filter, s.keys = filter, keys
+}
+
+// methodFilter returns the filter of the API key, false if the key is unknown.
+func (s *Server) methodFilter(key string) (*MethodFilter, bool) {
+	if key == "" || len(s.keys) == 0 {
+		return s.filter, true
+	}
+	filter, ok := s.keys[key]
+	return filter, ok
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMethodFilterAllowed(t *testing.T) {
+	filter := &MethodFilter{Allow: []string{"eth_*", "debug_traceTransaction"}, Deny: []string{"eth_sendRawTransaction"}}
+
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{"eth_call", true},
+		{"eth_sendRawTransaction", false},
+		{"debug_traceTransaction", true},
+		{"debug_traceBlock", false},
+		{"ethx_call", false},
+		{"txpool_content", false},
+	}
+	for _, tt := range tests {
+		if have := filter.Allowed(tt.method); have != tt.want {
+			t.Errorf("%s: have %v, want %v", tt.method, have, tt.want)
+		}
+	}
+	if !(*MethodFilter)(nil).Allowed("debug_traceBlock") {
+		t.Errorf("nil filter denied method")
+	}
+}
+
+func TestHTTPMethodFilter(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetMethodFilter(&MethodFilter{Deny: []string{"test_echo"}}, map[string]*MethodFilter{
+		"internal": nil,
+		"limited":  {Allow: []string{"test_rets"}},
+	})
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	call := func(key, method string, args ...interface{}) error {
+		client, err := DialHTTP(httpsrv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		if key != "" {
+			client.SetHeader(APIKeyHeader, key)
+		}
+		var result interface{}
+		return client.Call(&result, method, args...)
+	}
+	echo := []interface{}{"x", 1, &echoArgs{"y"}}
+
+	// Keyless requests use the transport filter
+	if err := call("", "test_echo", echo...); err == nil {
+		t.Errorf("keyless: denied method served")
+	}
+	if err := call("", "test_rets"); err != nil {
+		t.Errorf("keyless: allowed method failed: %v", err)
+	}
+	// Keyed requests use the filter of the key instead
+	if err := call("internal", "test_echo", echo...); err != nil {
+		t.Errorf("internal key: allowed method failed: %v", err)
+	}
+	if err := call("limited", "test_echo", echo...); err == nil {
+		t.Errorf("limited key: denied method served")
+	}
+	if err := call("limited", "test_rets"); err != nil {
+		t.Errorf("limited key: allowed method failed: %v", err)
+	}
+	// Unknown keys are rejected
+	err := call("unknown", "test_rets")
+	if herr, ok := err.(HTTPError); !ok || herr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unknown key: have error %v, want status %d", err, http.StatusUnauthorized)
+	}
+}
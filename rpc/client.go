@@ -82,6 +82,7 @@ type Client struct {
 	idgen    func() ID // for subscriptions
 	scheme   string    // connection type: http, ws or ipc
 	services *serviceRegistry
+	filter   *MethodFilter // methods served to the remote end, all if nil
 
 	idCounter uint32
 
@@ -121,6 +122,7 @@ func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 		ctx = context.WithValue(ctx, "scheme", c.scheme)
 	}
 	handler := newHandler(ctx, conn, c.idgen, c.services)
+	handler.filter = c.filter
 	return &clientConn{conn, handler}
 }
 
@@ -206,12 +208,12 @@ func newClient(initctx context.Context, connect reconnectFunc) (*Client, error)
 	if err != nil {
 		return nil, err
 	}
-	c := initClient(conn, randomIDGenerator(), new(serviceRegistry))
+	c := initClient(conn, randomIDGenerator(), new(serviceRegistry), nil)
 	c.reconnectFunc = connect
 	return c, nil
 }
 
-func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *Client {
+func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, filter *MethodFilter) *Client {
 	scheme := ""
 	switch conn.(type) {
 	case *httpConn:
@@ -225,6 +227,7 @@ func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *C
 		idgen:       idgen,
 		scheme:      scheme,
 		services:    services,
+		filter:      filter,
 		writeConn:   conn,
 		close:       make(chan struct{}),
 		closing:     make(chan struct{}),
@@ -25,12 +25,19 @@ import (
 
 // StartIPCEndpoint starts an IPC endpoint.
 func StartIPCEndpoint(ipcEndpoint string, apis []API) (net.Listener, *Server, error) {
+	return StartFilteredIPCEndpoint(ipcEndpoint, apis, nil)
+}
+
+// StartFilteredIPCEndpoint starts an IPC endpoint serving only the methods
+// allowed by the filter.
+func StartFilteredIPCEndpoint(ipcEndpoint string, apis []API, filter *MethodFilter) (net.Listener, *Server, error) {
 	// Register all the APIs exposed by the services.
 	var (
 		handler    = NewServer()
 		regMap     = make(map[string]struct{})
 		registered []string
 	)
+	handler.SetMethodFilter(filter, nil)
 	for _, api := range apis {
 		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
 			log.Info("IPC registration failed", "namespace", api.Namespace, "error", err)
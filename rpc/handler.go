@@ -61,6 +61,7 @@ type handler struct {
 	conn           jsonWriter                     // where responses will be sent
 	log            log.Logger
 	allowSubscribe bool
+	filter         *MethodFilter // methods allowed on the connection, all if nil
 
 	subLock    sync.Mutex
 	serverSubs map[ID]*Subscription
@@ -323,7 +324,7 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage) *jsonrpcMessage
 	var callb *callback
 	if msg.isUnsubscribe() {
 		callb = h.unsubscribeCb
-	} else {
+	} else if h.filter.Allowed(msg.Method) {
 		callb = h.reg.callback(msg.Method)
 	}
 	if callb == nil {
@@ -356,6 +357,9 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage) *jsonrpcMes
 	if !h.allowSubscribe {
 		return msg.errorResponse(ErrNotificationsUnsupported)
 	}
+	if !h.filter.Allowed(msg.Method) {
+		return msg.errorResponse(&methodNotFoundError{method: msg.Method})
+	}
 
 	// Subscription method name is first argument.
 	name, err := parseSubscriptionName(msg.Params)
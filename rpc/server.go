@@ -46,6 +46,9 @@ type Server struct {
 	idgen    func() ID
 	run      int32
 	codecs   mapset.Set
+
+	filter *MethodFilter            // methods served to the keyless connections
+	keys   map[string]*MethodFilter // methods served per API key
 }
 
 // NewServer creates a new server instance with no registered handlers.
@@ -72,6 +75,11 @@ func (s *Server) RegisterName(name string, receiver interface{}) error {
 //
 // Note that codec options are no longer supported.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
+	s.serveCodec(codec, s.filter)
+}
+
+// serveCodec serves the codec, restricting the methods called to the filter.
+func (s *Server) serveCodec(codec ServerCodec, filter *MethodFilter) {
 	defer codec.close()
 
 	// Don't serve if server is stopped.
@@ -83,15 +91,15 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(codec, s.idgen, &s.services, filter)
 	<-codec.closed()
 	c.Close()
 }
 
 // serveSingleRequest reads and processes a single RPC request from the given codec. This
 // is used to serve HTTP connections. Subscriptions and reverse calls are not allowed in
-// this mode.
-func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
+// this mode. The methods called are restricted to the filter.
+func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec, filter *MethodFilter) {
 	// Don't serve if server is stopped.
 	if atomic.LoadInt32(&s.run) == 0 {
 		return
@@ -99,6 +107,7 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 
 	h := newHandler(ctx, codec, s.idgen, &s.services)
 	h.allowSubscribe = false
+	h.filter = filter
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.readBatch()
@@ -76,6 +76,8 @@ type Database struct {
 
 	preimages map[common.Hash][]byte // Preimages of nodes from the secure trie
 
+	gchook func(hash common.Hash, blob []byte) // Optional callback for nodes dropped by garbage collection
+
 	gctime  time.Duration      // Time spent on garbage collection since last commit
 	gcnodes uint64             // Nodes garbage collected since last commit
 	gcsize  common.StorageSize // Data storage garbage collected since last commit
@@ -629,6 +631,16 @@ func (db *Database) Dereference(root common.Hash) {
 		"gcnodes", db.gcnodes, "gcsize", db.gcsize, "gctime", db.gctime, "livenodes", len(db.dirties), "livesize", db.dirtiesSize)
 }
 
+// SetGCHook installs a callback invoked with every dirty node dropped from the
+// memory database by garbage collection, before it is discarded. The callback
+// runs with the database lock held and must not call back into the database.
+func (db *Database) SetGCHook(hook func(hash common.Hash, blob []byte)) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.gchook = hook
+}
+
 // dereference is the private locked version of Dereference.
 func (db *Database) dereference(child common.Hash, parent common.Hash) {
 	// Dereference the parent-child
@@ -671,6 +683,9 @@ func (db *Database) dereference(child common.Hash, parent common.Hash) {
 		node.forChilds(func(hash common.Hash) {
 			db.dereference(hash, child)
 		})
+		if db.gchook != nil {
+			db.gchook(child, node.rlp())
+		}
 		delete(db.dirties, child)
 		db.dirtiesSize -= common.StorageSize(common.HashLength + int(node.size))
 		if node.children != nil {